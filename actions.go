@@ -1,5 +1,7 @@
 package main
 
+import "strings"
+
 // ActionDefinition defines an action with its default keybindings, mouse bindings, and description
 type ActionDefinition struct {
 	Name         string
@@ -11,8 +13,14 @@ type ActionDefinition struct {
 // actionDefinitions contains all action definitions with default keybindings, mouse bindings, and descriptions
 var actionDefinitions = []ActionDefinition{
 	{"exit", []string{"Escape", "KeyQ"}, []string{}, "Quit application"},
+	{"accept", []string{"Alt+Enter"}, []string{}, "Print the current image's path and quit (--print-selected)"},
+	{"toggle_mark", []string{"Shift+KeyM"}, []string{}, "Mark/unmark the current image for accept_marked"},
+	{"accept_marked", []string{"Alt+Shift+Enter"}, []string{}, "Write the marked images' paths to stdout (or --marked-file) and quit"},
+	{"kiosk_unlock", []string{"Ctrl+Alt+KeyU"}, []string{}, "Unlock/lock kiosk mode (--kiosk)"},
 	{"help", []string{"Shift+Slash"}, []string{"Alt+RightClick"}, "Show/hide help"},
 	{"info", []string{"KeyI"}, []string{}, "Show/hide info display"},
+	{"list_errors", []string{"Shift+KeyE"}, []string{}, "Show/hide list of unreadable images"},
+	{"reading_stats", []string{"Shift+KeyI"}, []string{}, "Show/hide reading progress and statistics"},
 	{"next", []string{"Space", "KeyN"}, []string{"LeftClick", "WheelDown"}, "Next image (or 2 images in book mode)"},
 	{"previous", []string{"Backspace", "KeyP"}, []string{"RightClick", "WheelUp"}, "Previous image (or 2 images in book mode)"},
 	{"next_single", []string{"Shift+Space", "Shift+KeyN"}, []string{"Shift+LeftClick", "Shift+WheelDown"}, "Single page forward (fine adjustment)"},
@@ -20,30 +28,102 @@ var actionDefinitions = []ActionDefinition{
 	{"toggle_book_mode", []string{"KeyB"}, []string{"MiddleClick"}, "Toggle book mode (dual image view)"},
 	{"toggle_reading_direction", []string{"Shift+KeyB"}, []string{"Ctrl+MiddleClick"}, "Toggle reading direction (LTR ↔ RTL)"},
 	{"fullscreen", []string{"Enter"}, []string{"DoubleLeftClick"}, "Toggle fullscreen"},
+	{"maximize", []string{"Ctrl+Enter"}, []string{}, "Maximize/restore window (taskbar stays visible)"},
+	{"fullscreen_monitor", []string{"Ctrl+Shift+Enter"}, []string{}, "Cycle the monitor used for fullscreen"},
+	{"presentation", []string{"Shift+Enter"}, []string{}, "Toggle presentation mode (no overlays, laser pointer)"},
+	{"laser_pointer", []string{"Shift+KeyL"}, []string{}, "Toggle laser pointer for presentation mode"},
+	{"blank_screen", []string{"KeyW"}, []string{}, "Blank screen (black/white/off)"},
 	{"reset_window_size", []string{"Ctrl+KeyD"}, []string{}, "Reset to default window size"},
 	{"page_input", []string{"KeyG"}, []string{"Ctrl+LeftClick"}, "Go to page (enter page number)"},
+	{"history_back", []string{"Alt+ArrowLeft"}, []string{"Back"}, "Back to the page before the last jump (like a browser)"},
+	{"history_forward", []string{"Alt+ArrowRight"}, []string{"Forward"}, "Forward again through the jump history"},
 	{"jump_first", []string{"Home", "Shift+Comma"}, []string{}, "Jump to first page"},
 	{"jump_last", []string{"End", "Shift+Period"}, []string{}, "Jump to last page"},
+	{"percent_forward", []string{"Shift+PageDown"}, []string{}, "Jump forward by 10% of the pages"},
+	{"percent_back", []string{"Shift+PageUp"}, []string{}, "Jump back by 10% of the pages"},
+	{"slideshow", []string{"KeyA"}, []string{}, "Start/stop slideshow (auto-advance)"},
+	{"reading_timer", []string{"Shift+KeyA"}, []string{}, "Start/stop guided reading timer (progress bar per page)"},
+	{"next_chapter", []string{"PageDown"}, []string{}, "Jump to next chapter (archive folder or directory)"},
+	{"previous_chapter", []string{"PageUp"}, []string{}, "Jump to start of chapter, or previous chapter"},
+	{"chapter_list", []string{"Alt+KeyC"}, []string{}, "List the chapters (folders and chapter_pattern matches) and jump to one"},
 	{"rotate_left", []string{"KeyL"}, []string{}, "Rotate left 90 degrees"},
 	{"rotate_right", []string{"KeyR"}, []string{}, "Rotate right 90 degrees"},
+	{"auto_rotate", []string{"Ctrl+KeyR"}, []string{}, "Toggle turning spreads to fill portrait fullscreen"},
 	{"flip_horizontal", []string{"KeyH"}, []string{}, "Flip horizontally"},
 	{"flip_vertical", []string{"KeyV"}, []string{}, "Flip vertically"},
 	{"mark_prejoined_spread", []string{"KeyJ"}, []string{}, "Mark current image(s) as pre-joined spread"},
+	{"force_pair", []string{"Shift+KeyJ"}, []string{}, "Pair the current page with the next despite their sizes"},
+	{"force_split", []string{"Ctrl+KeyJ"}, []string{}, "Show the current spread as separate pages"},
+	{"next_anomaly", []string{"KeyE"}, []string{}, "Jump to the next page with a low resolution or an unusual size"},
 	{"cycle_sort", []string{"Shift+KeyS"}, []string{"Alt+MiddleClick"}, "Cycle sort method (Natural/Simple/Entry)"},
 	{"expand_directory", []string{"KeyS"}, []string{}, "Scan directory images (single file mode)"},
+	{"reload", []string{"F5"}, []string{}, "Reload current image(s) from disk"},
+	{"rename", []string{"F2"}, []string{}, "Rename current file"},
+	{"delete_file", []string{"Delete"}, []string{}, "Delete current file (hard_delete; held for undo until exit)"},
+	{"undo", []string{"Ctrl+KeyZ"}, []string{}, "Undo the last delete, rename or page jump"},
+	{"redo", []string{"Ctrl+Shift+KeyZ", "Ctrl+KeyY"}, []string{}, "Redo what undo reverted"},
+	{"rescan", []string{"Shift+F5"}, []string{}, "Rescan file list (pick up added/removed files)"},
+	{"rate_1", []string{"Ctrl+Key1"}, []string{}, "Rate current image 1 star"},
+	{"rate_2", []string{"Ctrl+Key2"}, []string{}, "Rate current image 2 stars"},
+	{"rate_3", []string{"Ctrl+Key3"}, []string{}, "Rate current image 3 stars"},
+	{"rate_4", []string{"Ctrl+Key4"}, []string{}, "Rate current image 4 stars"},
+	{"rate_5", []string{"Ctrl+Key5"}, []string{}, "Rate current image 5 stars"},
+	{"rate_clear", []string{"Ctrl+Key0"}, []string{}, "Clear rating of current image"},
+	{"tag", []string{"KeyT"}, []string{}, "Add/remove tags on current image"},
+	{"filter", []string{"Shift+KeyF"}, []string{}, "Filter images by rating/tag (e.g. >=4 tag:keep)"},
+	{"filter_name", []string{"Ctrl+KeyF"}, []string{}, "Filter images by file name (substring or regex)"},
+	{"search", []string{"Slash"}, []string{}, "Fuzzy search file names and jump to a match"},
+	{"animation_pause", []string{"KeyK"}, []string{}, "Pause/resume animation"},
+	{"animation_next_frame", []string{"Period"}, []string{}, "Step animation one frame forward (pauses)"},
+	{"animation_prev_frame", []string{"Comma"}, []string{}, "Step animation one frame backward (pauses)"},
+	{"animation_slower", []string{"BracketLeft"}, []string{}, "Decrease animation playback speed"},
+	{"animation_faster", []string{"BracketRight"}, []string{}, "Increase animation playback speed"},
+	{"exposure_up", []string{"Ctrl+Equal"}, []string{}, "Increase exposure of HDR and 16-bit images"},
+	{"exposure_down", []string{"Ctrl+Minus"}, []string{}, "Decrease exposure of HDR and 16-bit images"},
+	{"exposure_reset", []string{"Ctrl+Shift+Key0"}, []string{}, "Reset exposure to the configured value"},
+	{"sharpen_up", []string{"KeyU"}, []string{}, "Sharpen the display more (unsharp mask)"},
+	{"sharpen_down", []string{"Shift+KeyU"}, []string{}, "Sharpen the display less"},
+	{"denoise_up", []string{"KeyD"}, []string{}, "Denoise the display more"},
+	{"denoise_down", []string{"Shift+KeyD"}, []string{}, "Denoise the display less"},
+	{"descreen", []string{"Alt+KeyD"}, []string{}, "Toggle descreen (anti-moire blur for zoomed-out printed scans)"},
+	{"custom_shader", []string{"Alt+KeyS"}, []string{}, "Toggle the custom shader (reloads the file)"},
+	{"levels_stretch", []string{"Ctrl+KeyL"}, []string{}, "Toggle auto-stretched levels for 16-bit images"},
+	{"compare_diff", []string{"KeyC"}, []string{}, "Compare the two pages of a spread (heat map/blink/off)"},
+	{"contact_sheet", []string{"Shift+KeyC"}, []string{}, "Export a contact sheet of all images next to the collection"},
+	{"export_pages", []string{"Ctrl+Shift+KeyE"}, []string{}, "Copy the pages of the current archive (or a page range) to a folder"},
+	{"screenshot", []string{"F12"}, []string{}, "Save what is on screen as a PNG (screenshot_dir)"},
+	{"export_spread", []string{"Ctrl+Shift+KeyS"}, []string{}, "Save the two pages of the spread as one full-size PNG (screenshot_dir)"},
+	{"guides", []string{"Shift+KeyG"}, []string{}, "Cycle guide overlays (rule of thirds/grid/center cross/off)"},
+	{"measure", []string{"KeyM"}, []string{}, "Toggle ruler: drag to measure distance and angle (measure_dpi)"},
+	{"ocr", []string{"KeyX"}, []string{}, "Copy the text of the page (or the ruler's rectangle) to the clipboard via OCR"},
+	{"paste_image", []string{"Ctrl+KeyV"}, []string{}, "Show the image on the clipboard as a page after the current one"},
+	{"print", []string{"Ctrl+KeyP"}, []string{}, "Print the current page (fit to page)"},
+	{"print_spread", []string{"Ctrl+Shift+KeyP"}, []string{}, "Print both pages of the book mode spread"},
 	{"toggle_settings", []string{"KeyO"}, []string{}, "Open/close settings"},
+	{"export_config", []string{"Ctrl+KeyE"}, []string{}, "Export the current settings, including runtime toggles, to a file"},
+	{"import_config", []string{"Ctrl+KeyI"}, []string{}, "Import settings from an exported file"},
+	{"save_settings", []string{"Ctrl+KeyS"}, []string{}, "Save the current settings, including runtime toggles, now"},
+	{"open", []string{"Ctrl+KeyO"}, []string{}, "Open images or archives with a file dialog"},
+	{"open_directory", []string{"Ctrl+Shift+KeyO"}, []string{}, "Open a directory with a folder dialog"},
+	{"retry_open", []string{"KeyR"}, []string{}, "Retry a failed open (start screen only)"},
 
 	// Zoom and pan actions
 	{"zoom_in", []string{"Equal", "Shift+Equal"}, []string{"Ctrl+WheelUp"}, "Zoom in"},
 	{"zoom_out", []string{"Minus"}, []string{"Ctrl+WheelDown"}, "Zoom out"},
 	{"zoom_reset", []string{"Key0"}, []string{"Shift+MiddleClick"}, "Reset to 100% zoom"},
 	{"zoom_fit", []string{"KeyF"}, []string{"Alt+LeftClick"}, "Cycle zoom modes (Window/Width/Height/Manual)"},
+	{"integer_zoom", []string{"Shift+Key0"}, []string{}, "Toggle integer zoom (whole multiples, sharp pixels)"},
+	{"upscale_in_window", []string{"Ctrl+Shift+KeyF"}, []string{}, "Toggle enlarging small images to fit the window"},
 
 	// Pan actions (for manual zoom mode)
 	{"pan_up", []string{"ArrowUp"}, []string{}, "Pan up"},
 	{"pan_down", []string{"ArrowDown"}, []string{}, "Pan down"},
 	{"pan_left", []string{"ArrowLeft"}, []string{}, "Pan left"},
 	{"pan_right", []string{"ArrowRight"}, []string{}, "Pan right"},
+	{"pan_page_up", []string{"Shift+ArrowUp"}, []string{}, "Pan up by most of a screen (page_scroll_fraction)"},
+	{"pan_page_down", []string{"Shift+ArrowDown"}, []string{}, "Pan down by most of a screen (page_scroll_fraction)"},
+	{"smart_next", []string{"KeyZ"}, []string{}, "Scroll down a tall page, then turn to the top of the next one"},
+	{"smart_previous", []string{"Shift+KeyZ"}, []string{}, "Scroll up a tall page, then turn to the bottom of the previous one"},
 }
 
 // ActionExecutor provides centralized action execution logic
@@ -59,13 +139,29 @@ func NewActionExecutor() *ActionExecutor {
 // ExecuteAction executes the given action using the InputActions interface
 // This is the single source of truth for all action execution logic
 func (ae *ActionExecutor) ExecuteAction(action string, inputActions InputActions, inputState InputState) bool {
+	if !kioskActionAllowed(action, inputState.IsKioskLocked()) {
+		debugKV("input", "action_blocked", "action", action, "reason", "kiosk_locked")
+		return false
+	}
 	switch action {
 	case "exit":
 		inputActions.Exit()
+	case "accept":
+		inputActions.AcceptSelection()
+	case "toggle_mark":
+		inputActions.ToggleMark()
+	case "accept_marked":
+		inputActions.AcceptMarked()
+	case "kiosk_unlock":
+		inputActions.ToggleKioskLock()
 	case "help":
 		inputActions.ToggleHelp()
 	case "info":
 		inputActions.ToggleInfo()
+	case "list_errors":
+		inputActions.ToggleLoadErrors()
+	case "reading_stats":
+		inputActions.ToggleReadingStats()
 	case "next":
 		inputActions.NavigateNext()
 	case "previous":
@@ -80,12 +176,20 @@ func (ae *ActionExecutor) ExecuteAction(action string, inputActions InputActions
 		inputActions.ToggleReadingDirection()
 	case "fullscreen":
 		inputActions.ToggleFullscreen()
+	case "maximize":
+		inputActions.ToggleMaximize()
+	case "fullscreen_monitor":
+		inputActions.CycleFullscreenMonitor()
 	case "reset_window_size":
 		inputActions.ResetWindowSize()
 	case "page_input":
 		if !inputState.IsInPageInputMode() {
 			inputActions.EnterPageInputMode()
 		}
+	case "history_back":
+		inputActions.HistoryBack()
+	case "history_forward":
+		inputActions.HistoryForward()
 	case "jump_first":
 		inputActions.JumpToPage(1)
 	case "jump_last":
@@ -93,22 +197,146 @@ func (ae *ActionExecutor) ExecuteAction(action string, inputActions InputActions
 		if totalPages > 0 {
 			inputActions.JumpToPage(totalPages)
 		}
+	case "percent_forward":
+		inputActions.JumpByPercent(percentJumpStep)
+	case "percent_back":
+		inputActions.JumpByPercent(-percentJumpStep)
+	case "next_chapter":
+		inputActions.NextChapter()
+	case "previous_chapter":
+		inputActions.PreviousChapter()
+	case "chapter_list":
+		inputActions.OpenChapterList()
 	case "rotate_left":
 		inputActions.RotateLeft()
 	case "rotate_right":
 		inputActions.RotateRight()
+	case "auto_rotate":
+		inputActions.ToggleAutoRotate()
 	case "flip_horizontal":
 		inputActions.FlipHorizontal()
 	case "flip_vertical":
 		inputActions.FlipVertical()
 	case "mark_prejoined_spread":
 		inputActions.MarkCurrentAsPreJoinedSpread()
+	case "force_pair":
+		inputActions.ForcePair()
+	case "force_split":
+		inputActions.ForceSplit()
+	case "next_anomaly":
+		inputActions.NextAnomaly()
 	case "cycle_sort":
 		inputActions.CycleSortMethod()
 	case "expand_directory":
 		inputActions.ExpandToDirectory()
+	case "reload":
+		inputActions.ReloadCurrentImage()
+	case "delete_file":
+		inputActions.DeleteFile()
+	case "undo":
+		inputActions.Undo()
+	case "redo":
+		inputActions.Redo()
+	case "rename":
+		inputActions.EnterRenameMode()
+	case "rescan":
+		inputActions.RescanCollection()
+	case "rate_1":
+		inputActions.SetRating(1)
+	case "rate_2":
+		inputActions.SetRating(2)
+	case "rate_3":
+		inputActions.SetRating(3)
+	case "rate_4":
+		inputActions.SetRating(4)
+	case "rate_5":
+		inputActions.SetRating(5)
+	case "rate_clear":
+		inputActions.SetRating(0)
+	case "tag":
+		inputActions.EnterTagInput()
+	case "filter":
+		inputActions.EnterFilterInput()
+	case "filter_name":
+		inputActions.EnterNameFilter()
+	case "search":
+		inputActions.EnterSearch()
+	case "export_config":
+		inputActions.EnterConfigExport()
+	case "import_config":
+		inputActions.EnterConfigImport()
+	case "save_settings":
+		inputActions.SaveSettingsNow()
+	case "animation_pause":
+		inputActions.ToggleAnimationPause()
+	case "animation_next_frame":
+		inputActions.StepAnimationFrame(1)
+	case "animation_prev_frame":
+		inputActions.StepAnimationFrame(-1)
+	case "animation_slower":
+		inputActions.ChangeAnimationSpeed(-1)
+	case "animation_faster":
+		inputActions.ChangeAnimationSpeed(1)
+	case "exposure_up":
+		inputActions.ChangeHDRExposure(hdrExposureStep)
+	case "exposure_down":
+		inputActions.ChangeHDRExposure(-hdrExposureStep)
+	case "exposure_reset":
+		inputActions.ResetHDRExposure()
+	case "sharpen_up":
+		inputActions.ChangeSharpen(sharpenStep)
+	case "sharpen_down":
+		inputActions.ChangeSharpen(-sharpenStep)
+	case "denoise_up":
+		inputActions.ChangeDenoise(denoiseStep)
+	case "denoise_down":
+		inputActions.ChangeDenoise(-denoiseStep)
+	case "descreen":
+		inputActions.ToggleDescreen()
+	case "custom_shader":
+		inputActions.ToggleCustomShader()
+	case "levels_stretch":
+		inputActions.ToggleLevelsStretch()
+	case "compare_diff":
+		inputActions.CycleCompareMode()
+	case "slideshow":
+		inputActions.ToggleSlideshow()
+	case "reading_timer":
+		inputActions.ToggleReadingTimer()
+	case "presentation":
+		inputActions.TogglePresentation()
+	case "laser_pointer":
+		inputActions.ToggleLaserPointer()
+	case "blank_screen":
+		inputActions.CycleBlankScreen()
+	case "contact_sheet":
+		inputActions.ExportContactSheet()
+	case "export_pages":
+		inputActions.ExportPages()
+	case "screenshot":
+		inputActions.Screenshot()
+	case "export_spread":
+		inputActions.ExportSpread()
+	case "guides":
+		inputActions.CycleGuideMode()
+	case "measure":
+		inputActions.ToggleMeasure()
+	case "ocr":
+		inputActions.ExtractText()
+	case "paste_image":
+		inputActions.PasteImage()
+	case "print":
+		inputActions.PrintCurrent(false)
+	case "print_spread":
+		inputActions.PrintCurrent(true)
 	case "toggle_settings":
 		inputActions.ToggleSettings()
+	case "open":
+		inputActions.OpenFileDialog()
+	case "open_directory":
+		inputActions.OpenDirectoryDialog()
+	case "retry_open":
+		inputActions.RetryFailedOpen()
 
 	// Zoom and pan actions
 	case "zoom_in":
@@ -119,6 +347,10 @@ func (ae *ActionExecutor) ExecuteAction(action string, inputActions InputActions
 		inputActions.ZoomReset()
 	case "zoom_fit":
 		inputActions.ZoomFit()
+	case "integer_zoom":
+		inputActions.ToggleIntegerZoom()
+	case "upscale_in_window":
+		inputActions.ToggleUpscaleInWindow()
 	case "pan_up":
 		inputActions.PanUp()
 	case "pan_down":
@@ -127,8 +359,20 @@ func (ae *ActionExecutor) ExecuteAction(action string, inputActions InputActions
 		inputActions.PanLeft()
 	case "pan_right":
 		inputActions.PanRight()
+	case "pan_page_up":
+		inputActions.PanPage(-1)
+	case "pan_page_down":
+		inputActions.PanPage(1)
+	case "smart_next":
+		inputActions.SmartScroll(1)
+	case "smart_previous":
+		inputActions.SmartScroll(-1)
 
 	default:
+		if name, ok := strings.CutPrefix(action, scriptActionPrefix); ok {
+			inputActions.RunScriptAction(name)
+			return true
+		}
 		return false
 	}
 
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"time"
+
+	"nv/internal/imgdecode"
+)
+
+// animationSpeeds are the playback rates cycled by the speed actions.
+var animationSpeeds = []float64{0.25, 0.5, 1, 1.5, 2, 4}
+
+// AnimatedImage is a DisplayImage with several frames. Tiles always return
+// the current frame; playback state lives on the image and is only touched
+// from the Ebiten thread.
+type AnimatedImage interface {
+	DisplayImage
+	FrameCount() int
+	CurrentFrame() int
+	SetFrame(frame int)
+	// Advance adds elapsed playback time and reports whether the current
+	// frame changed.
+	Advance(elapsed time.Duration) bool
+}
+
+type animatedDisplayImage struct {
+	bounds  image.Rectangle
+	frames  [][]DisplayTile
+	delays  []time.Duration
+	current int
+	elapsed time.Duration
+}
+
+func newAnimatedDisplayImage(anim *imgdecode.Animation) (DisplayImage, error) {
+	if anim == nil || len(anim.Frames) == 0 {
+		return nil, fmt.Errorf("empty animation")
+	}
+
+	bounds := anim.Frames[0].Bounds()
+	result := &animatedDisplayImage{
+		bounds: image.Rect(0, 0, bounds.Dx(), bounds.Dy()),
+		frames: make([][]DisplayTile, 0, len(anim.Frames)),
+		delays: anim.Delays,
+	}
+	for _, frame := range anim.Frames {
+		img, err := newUnmanagedEbitenImage(frame)
+		if err != nil {
+			result.Deallocate()
+			return nil, err
+		}
+		result.frames = append(result.frames, []DisplayTile{{
+			Image: img,
+			W:     bounds.Dx(),
+			H:     bounds.Dy(),
+		}})
+	}
+	return result, nil
+}
+
+func (a *animatedDisplayImage) Bounds() image.Rectangle {
+	if a == nil {
+		return image.Rectangle{}
+	}
+	return a.bounds
+}
+
+func (a *animatedDisplayImage) Tiles() []DisplayTile {
+	if a == nil || len(a.frames) == 0 {
+		return nil
+	}
+	return a.frames[a.current]
+}
+
+func (a *animatedDisplayImage) TileCount() int {
+	return len(a.Tiles())
+}
+
+func (a *animatedDisplayImage) Deallocate() {
+	if a == nil {
+		return
+	}
+	for _, tiles := range a.frames {
+		for _, tile := range tiles {
+			if tile.Image != nil {
+				tile.Image.Deallocate()
+			}
+		}
+	}
+	a.frames = nil
+	a.current = 0
+}
+
+func (a *animatedDisplayImage) FrameCount() int {
+	return len(a.frames)
+}
+
+func (a *animatedDisplayImage) CurrentFrame() int {
+	return a.current
+}
+
+func (a *animatedDisplayImage) SetFrame(frame int) {
+	n := len(a.frames)
+	if n == 0 {
+		return
+	}
+	a.current = ((frame % n) + n) % n
+	a.elapsed = 0
+}
+
+func (a *animatedDisplayImage) Advance(elapsed time.Duration) bool {
+	if len(a.frames) < 2 {
+		return false
+	}
+	start := a.current
+	a.elapsed += elapsed
+	for a.elapsed >= a.delays[a.current] {
+		a.elapsed -= a.delays[a.current]
+		a.current = (a.current + 1) % len(a.frames)
+	}
+	return a.current != start
+}
+
+// visibleAnimations returns the animated images currently on screen.
+func (g *Game) visibleAnimations() []AnimatedImage {
+	if g.displayContent == nil {
+		return nil
+	}
+	var anims []AnimatedImage
+	for _, img := range []DisplayImage{g.displayContent.LeftImage, g.displayContent.RightImage} {
+		if anim, ok := img.(AnimatedImage); ok && anim.FrameCount() > 1 {
+			anims = append(anims, anim)
+		}
+	}
+	return anims
+}
+
+// playbackSpeed returns the animation rate; the zero value means 1x.
+func (g *Game) playbackSpeed() float64 {
+	if g.animationSpeed <= 0 {
+		return 1
+	}
+	return g.animationSpeed
+}
+
+// advanceAnimations moves visible animations forward by one tick of wall
+// time scaled by the playback speed, and reports whether a frame changed.
+func (g *Game) advanceAnimations(tick time.Duration) bool {
+	if g.animationPaused {
+		return false
+	}
+	elapsed := time.Duration(float64(tick) * g.playbackSpeed())
+	changed := false
+	for _, anim := range g.visibleAnimations() {
+		if anim.Advance(elapsed) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (g *Game) toggleAnimationPause() {
+	if len(g.visibleAnimations()) == 0 {
+		return
+	}
+	g.animationPaused = !g.animationPaused
+	if g.animationPaused {
+		g.showOverlayMessage("Animation: Paused")
+	} else {
+		g.showOverlayMessage("Animation: Playing")
+	}
+	g.calculateDisplayContent()
+}
+
+// stepAnimationFrame pauses playback and moves visible animations by delta
+// frames.
+func (g *Game) stepAnimationFrame(delta int) {
+	anims := g.visibleAnimations()
+	if len(anims) == 0 {
+		return
+	}
+	g.animationPaused = true
+	for _, anim := range anims {
+		anim.SetFrame(anim.CurrentFrame() + delta)
+	}
+	g.showOverlayMessage(fmt.Sprintf("Frame %d/%d", anims[0].CurrentFrame()+1, anims[0].FrameCount()))
+	g.calculateDisplayContent()
+}
+
+func (g *Game) changeAnimationSpeed(delta int) {
+	idx := slices.Index(animationSpeeds, g.playbackSpeed())
+	if idx < 0 {
+		idx = slices.Index(animationSpeeds, 1)
+	}
+	idx = max(0, min(len(animationSpeeds)-1, idx+delta))
+	g.animationSpeed = animationSpeeds[idx]
+	g.showOverlayMessage(fmt.Sprintf("Animation speed: %gx", g.animationSpeed))
+	g.calculateDisplayContent()
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// archiveKind identifies the container type of an archive file.
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveZip
+	archiveRar
+	archive7z
+	archiveTar
+)
+
+// archiveFormat describes an archive detected from its file name. Tar
+// archives may additionally be wrapped in a compression stream.
+type archiveFormat struct {
+	Kind        archiveKind
+	Compression string // tar stream compression: "", "gzip", "bzip2", "xz" or "zstd"
+}
+
+// archiveSuffixes maps file name suffixes to archive formats. Composite
+// suffixes come before the single extensions they end with.
+var archiveSuffixes = []struct {
+	suffix string
+	format archiveFormat
+}{
+	{".tar.gz", archiveFormat{archiveTar, "gzip"}},
+	{".tgz", archiveFormat{archiveTar, "gzip"}},
+	{".tar.bz2", archiveFormat{archiveTar, "bzip2"}},
+	{".tbz2", archiveFormat{archiveTar, "bzip2"}},
+	{".tar.xz", archiveFormat{archiveTar, "xz"}},
+	{".txz", archiveFormat{archiveTar, "xz"}},
+	{".tar.zst", archiveFormat{archiveTar, "zstd"}},
+	{".tzst", archiveFormat{archiveTar, "zstd"}},
+	{".tar", archiveFormat{archiveTar, ""}},
+	{".zip", archiveFormat{archiveZip, ""}},
+	{".rar", archiveFormat{archiveRar, ""}},
+	{".7z", archiveFormat{archive7z, ""}},
+	{".cbt", archiveFormat{archiveTar, ""}}, // Comic book archives
+	{".cbz", archiveFormat{archiveZip, ""}},
+	{".cbr", archiveFormat{archiveRar, ""}},
+	{".cb7", archiveFormat{archive7z, ""}},
+}
+
+// detectArchive identifies an archive by its file name, ignoring case and
+// recognizing composite extensions such as .tar.gz.
+func detectArchive(path string) (archiveFormat, bool) {
+	name := strings.ToLower(filepath.Base(path))
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(name, s.suffix) && len(name) > len(s.suffix) {
+			return s.format, true
+		}
+	}
+	return archiveFormat{}, false
+}
+
+// openTarArchive opens a possibly compressed tar file. The returned closer
+// releases both the decompressor and the file.
+func openTarArchive(archivePath, compression string) (*tar.Reader, io.Closer, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stream io.Reader = f
+	closers := multiCloser{f}
+	switch compression {
+	case "":
+	case "gzip":
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		stream = zr
+		closers = append(multiCloser{zr}, closers...)
+	case "bzip2":
+		stream = bzip2.NewReader(f)
+	case "xz":
+		xr, err := xz.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		stream = xr
+	case "zstd":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		stream = zr
+		closers = append(multiCloser{zstdCloser{zr}}, closers...)
+	default:
+		f.Close()
+		return nil, nil, fmt.Errorf("unsupported tar compression: %s", compression)
+	}
+	return tar.NewReader(stream), closers, nil
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var first error
+	for _, c := range m {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// zstdCloser adapts zstd.Decoder, whose Close returns nothing.
+type zstdCloser struct{ d *zstd.Decoder }
+
+func (z zstdCloser) Close() error {
+	z.d.Close()
+	return nil
+}
+
+func extractImagesFromTar(archivePath, compression string) ([]ImagePath, error) {
+	r, closer, err := openTarArchive(archivePath, compression)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var images []ImagePath
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && isArchiveImageEntry(header.Name, header.Size) {
+			images = append(images, ImagePath{
+				Path:        archivePath + ":" + header.Name,
+				ArchivePath: archivePath,
+				EntryPath:   header.Name,
+			})
+		}
+	}
+	return images, nil
+}
+
+// loadImageFromTar streams the archive up to the entry of p; tar has no
+// index, so compressed tarballs are decompressed from the start on every
+// load.
+func (m *DefaultImageManager) loadImageFromTar(p ImagePath, compression string) (DisplayImage, error) {
+	archivePath, entryPath := p.ArchivePath, p.EntryPath
+	r, closer, err := openTarArchive(archivePath, compression)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Name == entryPath {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			return m.loadImageFromBytes(data, p)
+		}
+	}
+	return nil, fmt.Errorf("entry %s not found in %s", entryPath, archivePath)
+}
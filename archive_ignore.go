@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultArchiveIgnore lists junk that archivers and file managers leave in
+// archives: resource forks, thumbnail caches and other hidden files.
+var defaultArchiveIgnore = []string{"__MACOSX", "Thumbs.db", ".DS_Store", ".*"}
+
+// archiveIgnore mirrors Config.ArchiveIgnore. Like contentSniffing it is read
+// by collection goroutines that do not carry the config; nil means defaults.
+var archiveIgnore atomic.Pointer[[]string]
+
+func setArchiveIgnore(patterns []string) {
+	patterns = slices.Clone(patterns)
+	archiveIgnore.Store(&patterns)
+}
+
+func archiveIgnorePatterns() []string {
+	if p := archiveIgnore.Load(); p != nil {
+		return *p
+	}
+	return defaultArchiveIgnore
+}
+
+// validArchiveIgnorePatterns drops empty and malformed glob patterns,
+// returning the rejected ones.
+func validArchiveIgnorePatterns(patterns []string) ([]string, []string) {
+	valid, invalid := []string{}, []string(nil)
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); p == "" || err != nil {
+			invalid = append(invalid, p)
+			continue
+		}
+		valid = append(valid, p)
+	}
+	return valid, invalid
+}
+
+// isArchiveImageEntry reports whether an archive entry should be listed as a
+// page: a supported image that is neither known to be empty nor matched by
+// the ignore list. size is -1 when the archive does not record it. Patterns
+// are case-insensitive globs tested against every component of the entry
+// path, so a pattern naming a folder skips its whole subtree; "." components
+// such as a leading "./" are not matched.
+func isArchiveImageEntry(name string, size int64) bool {
+	if size == 0 || !isSupportedExt(name) {
+		return false
+	}
+	patterns := archiveIgnorePatterns()
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		part = strings.ToLower(part)
+		if slices.ContainsFunc(patterns, func(p string) bool {
+			ok, _ := path.Match(strings.ToLower(p), part)
+			return ok
+		}) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/nwaples/rardecode"
+)
+
+// Archive prefetch modes: extract whole archives up front so page flips on
+// slow media do not re-read the archive.
+const (
+	archivePrefetchOff    = "off"
+	archivePrefetchMemory = "memory"
+	archivePrefetchTemp   = "temp"
+)
+
+var archivePrefetchModes = []string{archivePrefetchOff, archivePrefetchMemory, archivePrefetchTemp}
+
+const defaultArchivePrefetchMaxMB = 1024
+
+var errArchiveTooLarge = errors.New("archive exceeds prefetch size cap")
+
+// prefetchedArchive is one archive being or already extracted. ready is
+// closed when extraction ends; the other fields are only read after that.
+type prefetchedArchive struct {
+	ready   chan struct{}
+	cancel  context.CancelFunc
+	entries map[string][]byte // memory mode: entry -> data
+	files   map[string]string // temp mode: entry -> extracted file
+	dir     string
+	bytes   int64 // charged against the shared budget
+	err     error
+}
+
+// archivePrefetcher extracts the archive being read and the next one in the
+// collection in the background. All extracted data, in memory or in temp
+// files, shares one maxBytes budget. Lookups never wait: until an archive is
+// ready, loads fall back to reading the archive directly.
+type archivePrefetcher struct {
+	mu       sync.Mutex
+	mode     string
+	maxBytes int64
+	used     int64
+	paths    []ImagePath
+	current  int
+	archives map[string]*prefetchedArchive
+}
+
+func newArchivePrefetcher() *archivePrefetcher {
+	return &archivePrefetcher{
+		mode:     archivePrefetchOff,
+		maxBytes: defaultArchivePrefetchMaxMB << 20,
+		archives: make(map[string]*prefetchedArchive),
+	}
+}
+
+// Configure sets the mode and size cap, dropping extracted archives when
+// either changes.
+func (p *archivePrefetcher) Configure(mode string, maxMB int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	maxBytes := int64(maxMB) << 20
+	if mode == p.mode && maxBytes == p.maxBytes {
+		return
+	}
+	p.mode = mode
+	p.maxBytes = maxBytes
+	p.releaseLocked(nil)
+}
+
+// Sync replaces the collection the archives are picked from.
+func (p *archivePrefetcher) Sync(paths []ImagePath) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paths = paths
+	p.refreshLocked()
+}
+
+// SetCurrent moves prefetching along with the page being read.
+func (p *archivePrefetcher) SetCurrent(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if idx == p.current {
+		return
+	}
+	p.current = idx
+	p.refreshLocked()
+}
+
+// refreshLocked starts extracting the current and next archive and releases
+// all others.
+func (p *archivePrefetcher) refreshLocked() {
+	keep := make(map[string]bool)
+	if p.mode != archivePrefetchOff {
+		for _, archivePath := range prefetchTargets(p.paths, p.current) {
+			keep[archivePath] = true
+		}
+	}
+	p.releaseLocked(keep)
+
+	for archivePath := range keep {
+		if _, ok := p.archives[archivePath]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		a := &prefetchedArchive{ready: make(chan struct{}), cancel: cancel}
+		p.archives[archivePath] = a
+		go p.extract(ctx, archivePath, p.mode, a)
+	}
+}
+
+// prefetchTargets returns the archive holding the page at current and the
+// next different archive after it: the volume being read and the next one.
+func prefetchTargets(paths []ImagePath, current int) []string {
+	if current < 0 || current >= len(paths) {
+		current = 0
+	}
+	var targets []string
+	for _, path := range paths[min(current, len(paths)):] {
+		if path.ArchivePath == "" || slices.Contains(targets, path.ArchivePath) {
+			continue
+		}
+		targets = append(targets, path.ArchivePath)
+		if len(targets) == 2 {
+			break
+		}
+	}
+	return targets
+}
+
+// Lookup returns the data of an entry if its archive has been extracted.
+func (p *archivePrefetcher) Lookup(archivePath, entryPath string) ([]byte, bool) {
+	p.mu.Lock()
+	a := p.archives[archivePath]
+	p.mu.Unlock()
+	if a == nil {
+		return nil, false
+	}
+	select {
+	case <-a.ready:
+	default:
+		return nil, false
+	}
+	if a.err != nil {
+		return nil, false
+	}
+
+	if data, ok := a.entries[entryPath]; ok {
+		return data, true
+	}
+	if file, ok := a.files[entryPath]; ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			warnKV("cache", "archive_prefetch_read_failed", "archive", archivePath, "entry", entryPath, "error", err)
+			return nil, false
+		}
+		return data, true
+	}
+	return nil, false
+}
+
+// Close stops extraction and removes all extracted data before returning,
+// so no temp directory outlives the process.
+func (p *archivePrefetcher) Close() {
+	p.mu.Lock()
+	archives := p.archives
+	p.archives = make(map[string]*prefetchedArchive)
+	p.paths = nil
+	p.mu.Unlock()
+
+	for _, a := range archives {
+		a.cancel()
+	}
+	for _, a := range archives {
+		<-a.ready
+		p.discard(a)
+	}
+}
+
+// releaseLocked drops archives not in keep (all when keep is nil). Their
+// data is discarded once their extraction has stopped.
+func (p *archivePrefetcher) releaseLocked(keep map[string]bool) {
+	for archivePath, a := range p.archives {
+		if keep[archivePath] {
+			continue
+		}
+		delete(p.archives, archivePath)
+		a.cancel()
+		go func() {
+			<-a.ready
+			p.discard(a)
+		}()
+		debugKV("cache", "archive_prefetch_released", "archive", archivePath)
+	}
+}
+
+// discard removes the temp files of a finished extraction and returns its
+// bytes to the budget.
+func (p *archivePrefetcher) discard(a *prefetchedArchive) {
+	if a.dir != "" {
+		if err := os.RemoveAll(a.dir); err != nil {
+			warnKV("cache", "archive_prefetch_cleanup_failed", "dir", a.dir, "error", err)
+		}
+		a.dir = ""
+	}
+	p.mu.Lock()
+	p.used -= a.bytes
+	a.bytes = 0
+	p.mu.Unlock()
+}
+
+// remaining is the part of the budget not yet charged.
+func (p *archivePrefetcher) remaining() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return max(0, p.maxBytes-p.used)
+}
+
+// charge adds n bytes of a to the budget, failing when it would overflow.
+func (p *archivePrefetcher) charge(a *prefetchedArchive, n int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.used+n > p.maxBytes {
+		return errArchiveTooLarge
+	}
+	p.used += n
+	a.bytes += n
+	return nil
+}
+
+func (p *archivePrefetcher) extract(ctx context.Context, archivePath, mode string, a *prefetchedArchive) {
+	defer close(a.ready)
+	defer recoverWorker("archive_prefetch", archivePath, func(err error) { a.err = err })
+	start := time.Now()
+
+	count := 0
+	if mode == archivePrefetchTemp {
+		dir, err := os.MkdirTemp("", "nv-archive-*")
+		if err != nil {
+			a.err = err
+			warnKV("cache", "archive_prefetch_failed", "archive", archivePath, "mode", mode, "error", err)
+			return
+		}
+		a.dir = dir
+		a.files = make(map[string]string)
+	} else {
+		a.entries = make(map[string][]byte)
+	}
+
+	err := walkArchiveImages(archivePath, func(name string, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		limited := io.LimitReader(r, p.remaining()+1)
+		if mode == archivePrefetchTemp {
+			// Index-based names avoid trusting entry paths on disk
+			file := filepath.Join(a.dir, fmt.Sprintf("%06d%s", count, strings.ToLower(filepath.Ext(name))))
+			n, err := writeFileFromReader(file, limited)
+			if err != nil {
+				return err
+			}
+			a.files[name] = file
+			if err := p.charge(a, n); err != nil {
+				return err
+			}
+		} else {
+			data, err := io.ReadAll(limited)
+			if err != nil {
+				return err
+			}
+			if err := p.charge(a, int64(len(data))); err != nil {
+				return err
+			}
+			a.entries[name] = data
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		a.err = err
+		a.entries = nil
+		a.files = nil
+		p.discard(a)
+		if !errors.Is(err, context.Canceled) {
+			warnKV("cache", "archive_prefetch_failed", "archive", archivePath, "mode", mode, "error", err, "fallback", "direct")
+		}
+		return
+	}
+	infoKV("cache", "archive_prefetch_done",
+		"archive", archivePath,
+		"mode", mode,
+		"entries", count,
+		"bytes", a.bytes,
+		"elapsed_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+func writeFileFromReader(path string, r io.Reader) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// walkArchiveImages calls fn with the contents of each image entry, in
+// archive order.
+func walkArchiveImages(archivePath string, fn func(name string, r io.Reader) error) error {
+	format, ok := detectArchive(archivePath)
+	if !ok {
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
+	}
+
+	switch format.Kind {
+	case archiveZip:
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || !isArchiveImageEntry(f.Name, f.FileInfo().Size()) {
+				continue
+			}
+			if err := walkArchiveFile(f.Name, f.Open, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case archive7z:
+		r, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || !isArchiveImageEntry(f.Name, f.FileInfo().Size()) {
+				continue
+			}
+			if err := walkArchiveFile(f.Name, f.Open, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case archiveRar:
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, err := rardecode.NewReader(f, "")
+		if err != nil {
+			return err
+		}
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !header.IsDir && isArchiveImageEntry(header.Name, rarEntrySize(header)) {
+				if err := fn(header.Name, r); err != nil {
+					return err
+				}
+			}
+		}
+
+	default:
+		r, closer, err := openTarArchive(archivePath, format.Compression)
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if header.Typeflag == tar.TypeReg && isArchiveImageEntry(header.Name, header.Size) {
+				if err := fn(header.Name, r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func walkArchiveFile(name string, open func() (io.ReadCloser, error), fn func(string, io.Reader) error) error {
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(name, rc)
+}
+
+// SetArchivePrefetch changes how archives are pre-extracted and restarts
+// extraction for the current collection.
+func (m *DefaultImageManager) SetArchivePrefetch(mode string, maxMB int) {
+	m.prefetcher.Configure(mode, maxMB)
+	m.mu.RLock()
+	paths := m.paths
+	m.mu.RUnlock()
+	m.prefetcher.Sync(paths)
+}
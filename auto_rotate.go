@@ -0,0 +1,54 @@
+package main
+
+// autoRotation returns the extra rotation auto_rotate adds in portrait
+// fullscreen, such as on a monitor turned on its side: landscape content,
+// typically a two-page spread, is turned 90° to fill the screen. Right-to-left
+// spreads turn the other way, so the page read first stays on top.
+func (g *Game) autoRotation() int {
+	if !g.config.AutoRotate || !g.fullscreen || g.currentLogicalH <= g.currentLogicalW {
+		return 0
+	}
+	content := g.displayContent
+	if content == nil || content.LeftImage == nil {
+		return 0
+	}
+	w, h := content.LeftImage.Bounds().Dx(), content.LeftImage.Bounds().Dy()
+	if content.RightImage != nil {
+		w += content.RightImage.Bounds().Dx() + imageGap
+		h = max(h, content.RightImage.Bounds().Dy())
+	}
+	if g.rotationAngle == 90 || g.rotationAngle == 270 {
+		w, h = h, w
+	}
+	if w <= h {
+		return 0
+	}
+	if g.config.RightToLeft {
+		return 270
+	}
+	return 90
+}
+
+// displayRotation is the rotation the image is drawn with: the user's
+// rotation plus auto_rotate's.
+func (g *Game) displayRotation() int {
+	return (g.rotationAngle + g.autoRotation()) % 360
+}
+
+func (g *Game) toggleAutoRotate() {
+	g.config.AutoRotate = !g.config.AutoRotate
+	state := "off"
+	if g.config.AutoRotate {
+		state = "on"
+	}
+	g.showOverlayMessage("Auto-rotate in portrait fullscreen: " + state)
+	if g.zoomState.Mode != ZoomModeFitWindow && g.zoomState.Mode != ZoomModeManual {
+		g.updateZoomLevelForFitMode()
+	}
+	g.clampPanToLimits()
+	debugKV("viewport", "auto_rotate", "enabled", g.config.AutoRotate, "rotation", g.displayRotation())
+}
+
+func (g *Game) ToggleAutoRotate() {
+	g.toggleAutoRotate()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"nv/navlogic"
+)
+
+// book_mode_policy values: where book mode comes from
+const (
+	bookModePolicyGlobal   = "global"   // book_mode applies to everything
+	bookModePolicyArchives = "archives" // Book mode in archives, single pages in directories
+)
+
+var bookModePolicies = []string{bookModePolicyGlobal, bookModePolicyArchives}
+
+// applyBookModePolicy sets book mode for the volume of the current page
+// once it changes when book_mode_policy is "archives": on for comic
+// archives, off for loose image directories. B still toggles it until the
+// next volume. It reports whether the mode changed.
+func (g *Game) applyBookModePolicy() bool {
+	if g.config.BookModePolicy != bookModePolicyArchives {
+		return false
+	}
+	key, ok := g.currentVolume()
+	if !ok || key == g.bookModeVolume {
+		return false
+	}
+	g.bookModeVolume = key
+	p, _ := g.imageManager.GetPath(g.idx)
+	want := p.ArchivePath != ""
+	if g.bookMode == want {
+		return false
+	}
+
+	g.applyNavigationState(navlogic.ToggleBookMode(g.navigationState(), g.pageMetricsAt))
+	if g.bookMode {
+		g.showOverlayMessage("Book Mode: ON (archive)")
+	} else {
+		g.showOverlayMessage("Book Mode: OFF (directory)")
+	}
+	g.calculateDisplayContent()
+	debugKV("nav", "book_mode_policy_applied",
+		"volume", key,
+		"book_mode", g.bookMode,
+		"temp_single", g.tempSingleMode,
+	)
+	return true
+}
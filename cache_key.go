@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How long a page's file metadata is trusted before it is read again, so a
+// burst of lookups for the same page costs one stat
+const cacheKeyCheckInterval = time.Second
+
+// imageCacheKey identifies what a page shows. Paths are absolute, so one
+// file reached through different relative paths shares an entry, and the
+// archive and entry are separate fields, so names containing ':' cannot
+// collide. Size and modification time are those of the file or archive; a
+// rewritten file gets a new key and is decoded again.
+type imageCacheKey struct {
+	File    string // Image file, or the archive holding the entry
+	Entry   string // Entry within the archive; empty for plain files
+	Size    int64  // 0 when the file could not be read
+	ModTime int64  // Unix nanoseconds; 0 when the file could not be read
+}
+
+// cacheKeyMemo remembers the key of each page between metadata checks.
+type cacheKeyMemo struct {
+	mu      sync.Mutex
+	entries map[ImagePath]checkedCacheKey
+}
+
+type checkedCacheKey struct {
+	key     imageCacheKey
+	checked time.Time
+}
+
+// newImageCacheKey builds the key of p from the file system.
+func newImageCacheKey(p ImagePath) imageCacheKey {
+	key := imageCacheKey{File: p.Path}
+	if p.ArchivePath != "" {
+		key = imageCacheKey{File: p.ArchivePath, Entry: p.EntryPath}
+	}
+	if abs, err := filepath.Abs(key.File); err == nil {
+		key.File = abs
+	}
+	if info, err := os.Stat(key.File); err == nil {
+		key.Size = info.Size()
+		key.ModTime = info.ModTime().UnixNano()
+	}
+	return key
+}
+
+// cacheKeyFor returns the cache key of p. When the file changed since the
+// last check, the image cached under the old key is dropped and the game is
+// asked to refresh, so the page is decoded again.
+func (m *DefaultImageManager) cacheKeyFor(p ImagePath) imageCacheKey {
+	now := time.Now()
+	m.cacheKeys.mu.Lock()
+	if m.cacheKeys.entries == nil {
+		m.cacheKeys.entries = make(map[ImagePath]checkedCacheKey)
+	}
+	prev, known := m.cacheKeys.entries[p]
+	if known && now.Sub(prev.checked) < cacheKeyCheckInterval {
+		m.cacheKeys.mu.Unlock()
+		return prev.key
+	}
+	key := newImageCacheKey(p)
+	m.cacheKeys.entries[p] = checkedCacheKey{key: key, checked: now}
+	m.cacheKeys.mu.Unlock()
+
+	if known && prev.key != key && m.cache.Remove(prev.key) {
+		m.asyncRefresh.Store(true)
+		debugKV("cache", "cache_key_changed",
+			"path", p.Path,
+			"old_size", prev.key.Size,
+			"new_size", key.Size,
+		)
+	}
+	return key
+}
+
+// forgetCacheKey makes the next lookup of p read its metadata again.
+func (m *DefaultImageManager) forgetCacheKey(p ImagePath) {
+	m.cacheKeys.mu.Lock()
+	delete(m.cacheKeys.entries, p)
+	m.cacheKeys.mu.Unlock()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// suggestedChapterPattern finds chapter numbers in file names such as
+// "c012_p001.jpg", "ch5-03.png" or "Chapter 12 - 004.jpg". Virtual chapters
+// are opt-in, so it is only offered in the README, not used by default.
+const suggestedChapterPattern = `(?i)(?:^|[^a-z0-9])c(?:h|hap|hapter)?[ ._-]?(\d+)`
+
+// chapterMatcher holds the compiled Config.ChapterPattern; a nil re, from an
+// empty pattern, finds no chapters.
+type chapterMatcher struct {
+	re *regexp.Regexp
+}
+
+// chapterPattern mirrors Config.ChapterPattern for the path helpers, which do
+// not carry the config; nil, like an empty pattern, finds no chapters.
+var chapterPattern atomic.Pointer[chapterMatcher]
+
+// setChapterPattern installs a pattern validated by the config loader.
+func setChapterPattern(pattern string) {
+	m := &chapterMatcher{}
+	if pattern != "" {
+		m.re = regexp.MustCompile(pattern)
+	}
+	chapterPattern.Store(m)
+}
+
+func chapterRegexp() *regexp.Regexp {
+	if m := chapterPattern.Load(); m != nil {
+		return m.re
+	}
+	return nil
+}
+
+// validChapterPattern reports why pattern cannot find chapters: it must
+// compile and capture the chapter number in its first group.
+func validChapterPattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	if re.NumSubexp() < 1 {
+		return fmt.Errorf("%q has no capture group for the chapter", pattern)
+	}
+	return nil
+}
+
+// virtualChapter returns the chapter number in the file name of p, without
+// leading zeros, or "" when the name has none. It splits long compilations
+// whose pages all sit in one folder.
+func virtualChapter(p ImagePath) string {
+	re := chapterRegexp()
+	if re == nil {
+		return ""
+	}
+	name := filepath.Base(p.Path)
+	if p.ArchivePath != "" {
+		name = path.Base(strings.ReplaceAll(p.EntryPath, "\\", "/"))
+	}
+	m := re.FindStringSubmatch(name)
+	if len(m) < 2 || m[1] == "" {
+		return ""
+	}
+	if n := strings.TrimLeft(m[1], "0"); n != "" {
+		return n
+	}
+	return "0"
+}
+
+// entryDir returns the directory of an archive entry, "" for entries at the
+// archive root. RAR entries may use backslashes.
+func entryDir(entry string) string {
+	dir := path.Dir(strings.ReplaceAll(entry, "\\", "/"))
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// chapterKey identifies the chapter an image belongs to: its folder inside
+// an archive, or its directory for regular files, split further by the
+// chapter number in the file name.
+func chapterKey(p ImagePath) string {
+	key := filepath.Dir(p.Path)
+	if p.ArchivePath != "" {
+		key = p.ArchivePath + ":" + entryDir(p.EntryPath)
+	}
+	if n := virtualChapter(p); n != "" {
+		key += "#" + n
+	}
+	return key
+}
+
+// chapterLabel returns the chapter shown in the info overlay: the archive
+// subfolder and the chapter number from the file name, "" when there is
+// neither.
+func chapterLabel(p ImagePath) string {
+	label := ""
+	if p.ArchivePath != "" {
+		label = entryDir(p.EntryPath)
+	}
+	if n := virtualChapter(p); n != "" {
+		if label != "" {
+			label += " / "
+		}
+		label += "Chapter " + n
+	}
+	return label
+}
+
+// groupedLess orders entries of the same archive by folder first, so each
+// chapter stays contiguous and root entries come before subfolders. Other
+// paths compare as a whole.
+func groupedLess(a, b ImagePath, less func(x, y string) bool) bool {
+	if a.ArchivePath == "" || a.ArchivePath != b.ArchivePath {
+		return less(a.Path, b.Path)
+	}
+	dirA, dirB := entryDir(a.EntryPath), entryDir(b.EntryPath)
+	if dirA != dirB {
+		return less(dirA, dirB)
+	}
+	return less(a.EntryPath, b.EntryPath)
+}
+
+// chapterStart returns the first index of the chapter containing idx.
+func (g *Game) chapterStart(idx int) int {
+	current, ok := g.imageManager.GetPath(idx)
+	if !ok {
+		return idx
+	}
+	key := chapterKey(current)
+	for idx > 0 {
+		prev, ok := g.imageManager.GetPath(idx - 1)
+		if !ok || chapterKey(prev) != key {
+			break
+		}
+		idx--
+	}
+	return idx
+}
+
+// jumpToNextChapter moves to the first page whose folder differs from the
+// current one.
+func (g *Game) jumpToNextChapter() {
+	current, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	key := chapterKey(current)
+	total := g.imageManager.GetPathsCount()
+	for i := g.idx + 1; i < total; i++ {
+		p, ok := g.imageManager.GetPath(i)
+		if ok && chapterKey(p) != key {
+			debugKV("nav", "next_chapter", "prev_idx", g.idx, "next_idx", i, "chapter", chapterKey(p))
+			g.jumpToPage(i + 1)
+			g.showChapterMessage(p)
+			return
+		}
+	}
+	g.showOverlayMessage("Last chapter")
+}
+
+// jumpToPreviousChapter moves to the start of the current chapter, or to the
+// start of the previous one when already there.
+func (g *Game) jumpToPreviousChapter() {
+	start := g.chapterStart(g.idx)
+	if start == g.idx {
+		if start == 0 {
+			g.showOverlayMessage("First chapter")
+			return
+		}
+		start = g.chapterStart(start - 1)
+	}
+	debugKV("nav", "previous_chapter", "prev_idx", g.idx, "next_idx", start)
+	g.jumpToPage(start + 1)
+	if p, ok := g.imageManager.GetPath(start); ok {
+		g.showChapterMessage(p)
+	}
+}
+
+func (g *Game) showChapterMessage(p ImagePath) {
+	label := chapterLabel(p)
+	if virtualChapter(p) != "" {
+		g.showOverlayMessage(label)
+		return
+	}
+	if label == "" {
+		if p.ArchivePath != "" {
+			label = filepath.Base(p.ArchivePath)
+		} else {
+			label = filepath.Base(filepath.Dir(p.Path))
+		}
+	}
+	g.showOverlayMessage(fmt.Sprintf("Chapter: %s", label))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// chapterEntry is one chapter of the current list.
+type chapterEntry struct {
+	Start int // Index of the first page
+	Pages int
+	Label string
+}
+
+// listChapters splits paths into runs of pages with the same chapterKey.
+func listChapters(paths []ImagePath) []chapterEntry {
+	var chapters []chapterEntry
+	prevKey := ""
+	for i, p := range paths {
+		key := chapterKey(p)
+		if i > 0 && key == prevKey {
+			chapters[len(chapters)-1].Pages++
+			continue
+		}
+		label := chapterLabel(p)
+		if label == "" {
+			label = filepath.Base(filepath.Dir(p.Path))
+			if p.ArchivePath != "" {
+				label = filepath.Base(p.ArchivePath)
+			}
+		}
+		chapters = append(chapters, chapterEntry{Start: i, Pages: 1, Label: label})
+		prevKey = key
+	}
+	return chapters
+}
+
+// chapterListResults turns chapters into prompt results. Without a query
+// the list is a window around the current chapter; with one it is the
+// fuzzy matches. selection is the result to highlight.
+func chapterListResults(chapters []chapterEntry, current int, query string, limit int) (results []SearchResult, selection int) {
+	for _, c := range chapters {
+		score, ok := fuzzyScore(query, c.Label)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("p.%d  %s (%d pages)", c.Start+1, c.Label, c.Pages)
+		results = append(results, SearchResult{Index: c.Start, Name: name, Score: score})
+	}
+	if query != "" {
+		results = fuzzySortResults(results)
+		return results[:min(len(results), limit)], 0
+	}
+
+	cur := 0
+	for i, c := range chapters {
+		if c.Start <= current {
+			cur = i
+		}
+	}
+	start := max(0, min(cur-limit/2, len(results)-limit))
+	end := min(len(results), start+limit)
+	return results[start:end], cur - start
+}
+
+// openChapterList opens the chapter list prompt: type to filter, Enter to
+// jump to the highlighted chapter.
+func (g *Game) openChapterList() {
+	if g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+	g.openTextPrompt(TextPromptChapters, "")
+	g.updateChapterList("")
+}
+
+func (g *Game) updateChapterList(query string) {
+	chapters := listChapters(g.currentPaths())
+	g.searchResults, g.searchSelection = chapterListResults(chapters, g.idx, query, searchResultLimit)
+	if query == "" {
+		g.textPromptStatus = fmt.Sprintf("%d chapters", len(chapters))
+	} else {
+		g.textPromptStatus = fmt.Sprintf("%d shown", len(g.searchResults))
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const defaultThumbnailSize = 256
+
+// subcommands are the headless modes selected by the first argument. They
+// reuse collection, archive and decoding without creating a window.
+var subcommands = map[string]func(args []string) int{
+	"thumb":   runThumbSubcommand,
+	"convert": runConvertSubcommand,
+	"list":    runListSubcommand,
+	"extract": runExtractSubcommand,
+	"config":  runConfigSubcommand,
+
+	// Explorer integration; a stub without -tags shell_menu
+	"context-menu": runContextMenuSubcommand,
+}
+
+// subcommandSortMethods names the sort strategies for the -sort flag.
+var subcommandSortMethods = map[string]int{
+	"natural": SortNatural,
+	"simple":  SortSimple,
+	"entry":   SortEntryOrder,
+}
+
+// runSubcommand runs the subcommand named by args[0] and reports whether
+// there was one, with its exit code. A file or folder that happens to be
+// named like a subcommand is opened instead.
+func runSubcommand(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return 0, false
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		debugKV("startup", "subcommand_shadowed", "arg", args[0], "reason", "existing_path")
+		return 0, false
+	}
+	return run(args[1:]), true
+}
+
+// subcommandFlags holds the flags shared by every subcommand.
+type subcommandFlags struct {
+	set        *flag.FlagSet
+	configPath *string
+	debug      *bool
+	sortMethod *string
+	outputDir  *string // nil for subcommands that write to stdout only
+}
+
+func newSubcommandFlags(name, usage string, withOutput bool) subcommandFlags {
+	set := flag.NewFlagSet("nv "+name, flag.ContinueOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "usage: nv %s [flags] <files|dirs|archives>...\n%s\n", name, usage)
+		set.PrintDefaults()
+	}
+	flags := subcommandFlags{
+		set:        set,
+		configPath: set.String("c", "", "config file path (sort method, archive_ignore, sniff_content)"),
+		debug:      set.Bool("d", false, "enable debug logging"),
+		sortMethod: set.String("sort", "", "sort order: natural, simple or entry (default: config)"),
+	}
+	if withOutput {
+		flags.outputDir = set.String("o", ".", "output directory")
+	}
+	return flags
+}
+
+// parse parses args and collects the images they name using the config.
+func (f subcommandFlags) parse(args []string) ([]ImagePath, int) {
+	if err := f.set.Parse(args); err != nil {
+		return nil, 2
+	}
+	if f.set.NArg() == 0 {
+		f.set.Usage()
+		return nil, 2
+	}
+	debugMode.Store(*f.debug)
+
+	config := loadStartupConfig(*f.configPath).Config
+	sortMethod := config.SortMethod
+	if *f.sortMethod != "" {
+		method, ok := subcommandSortMethods[*f.sortMethod]
+		if !ok {
+			errorKV("collection", "subcommand_invalid_sort", "sort", *f.sortMethod)
+			return nil, 2
+		}
+		sortMethod = method
+	}
+	contentSniffing.Store(config.SniffContent)
+	setArchiveIgnore(config.ArchiveIgnore)
+	paths, err := collectImages(f.set.Args(), sortMethod)
+	if err == nil && len(paths) == 0 {
+		err = errors.New("no images found")
+	}
+	if err != nil {
+		errorKV("collection", "subcommand_collect_failed", "command", f.set.Name(), "args", f.set.Args(), "error", err)
+		return nil, 1
+	}
+	if f.outputDir != nil {
+		if err := os.MkdirAll(*f.outputDir, 0o755); err != nil {
+			errorKV("collection", "subcommand_output_failed", "dir", *f.outputDir, "error", err)
+			return nil, 1
+		}
+	}
+	return paths, 0
+}
+
+func runThumbSubcommand(args []string) int {
+	flags := newSubcommandFlags("thumb", "Write a thumbnail of every image, keeping archive folders as subdirectories.", true)
+	size := flags.set.Int("size", defaultThumbnailSize, "longest thumbnail edge in pixels")
+	format := flags.set.String("format", "png", "output format: png or jpeg")
+	paths, code := flags.parse(args)
+	if paths == nil {
+		return code
+	}
+	ext := ".png"
+	switch *format {
+	case "png":
+	case "jpg", "jpeg":
+		ext = ".jpg"
+	default:
+		errorKV("collection", "subcommand_invalid_format", "format", *format)
+		return 2
+	}
+	if *size < 1 {
+		errorKV("collection", "subcommand_invalid_size", "size", *size)
+		return 2
+	}
+
+	return writeDecodedImages("thumb", paths, *flags.outputDir, ext, func(img image.Image) image.Image {
+		return scaleToFit(img, *size)
+	})
+}
+
+func runConvertSubcommand(args []string) int {
+	flags := newSubcommandFlags("convert", "Decode every image and write it as PNG, keeping archive folders as subdirectories.", true)
+	paths, code := flags.parse(args)
+	if paths == nil {
+		return code
+	}
+	return writeDecodedImages("convert", paths, *flags.outputDir, ".png", func(img image.Image) image.Image {
+		return img
+	})
+}
+
+func runListSubcommand(args []string) int {
+	flags := newSubcommandFlags("list", "Print the images in display order, one per line.", false)
+	full := flags.set.Bool("full", false, "print archive entries as archive:entry")
+	numbered := flags.set.Bool("n", false, "prefix each line with its page number")
+	paths, code := flags.parse(args)
+	if paths == nil {
+		return code
+	}
+	writeImageList(os.Stdout, paths, *full, *numbered)
+	return 0
+}
+
+// writeImageList prints one image per line: the entry path for archive
+// entries unless full is set, and the file path otherwise.
+func writeImageList(w io.Writer, paths []ImagePath, full, numbered bool) {
+	for i, p := range paths {
+		name := p.Path
+		if p.ArchivePath != "" && !full {
+			name = p.EntryPath
+		}
+		if numbered {
+			fmt.Fprintf(w, "%d\t%s\n", i+1, name)
+		} else {
+			fmt.Fprintln(w, name)
+		}
+	}
+}
+
+// writeDecodedImages decodes paths, transforms each image and writes it
+// below outputDir, printing the written files. Failures are logged and the
+// remaining images are still written.
+func writeDecodedImages(command string, paths []ImagePath, outputDir, ext string, transform func(image.Image) image.Image) int {
+	written, failed := 0, 0
+	names := subcommandOutputNames(paths, ext)
+	err := forEachDecodedImage(paths, func(i int, img image.Image, err error) {
+		output := filepath.Join(outputDir, names[i])
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(output), 0o755)
+		}
+		if err == nil {
+			err = writeImageFile(output, transform(img))
+		}
+		if err != nil {
+			failed++
+			warnKV("collection", "subcommand_image_failed", "command", command, "path", paths[i].Path, "error", err)
+			return
+		}
+		written++
+		fmt.Println(output)
+	})
+	if err != nil {
+		errorKV("collection", "subcommand_failed", "command", command, "error", err)
+		return 1
+	}
+	infoKV("collection", "subcommand_done", "command", command, "written", written, "failed", failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// subcommandOutputName maps an image to a relative output file. Archive
+// entries go under a directory named after the archive, keeping their
+// folders; ".." and absolute components are dropped so entries cannot
+// escape the output directory.
+func subcommandOutputName(p ImagePath, ext string) string {
+	if p.ArchivePath == "" {
+		base := filepath.Base(p.Path)
+		return strings.TrimSuffix(base, filepath.Ext(base)) + ext
+	}
+
+	archive := filepath.Base(p.ArchivePath)
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(strings.ToLower(archive), s.suffix) {
+			archive = archive[:len(archive)-len(s.suffix)]
+			break
+		}
+	}
+	// Cleaning as a rooted path resolves every ".." against the root
+	parts := []string{archive}
+	for _, part := range strings.Split(path.Clean("/"+strings.ReplaceAll(p.EntryPath, "\\", "/")), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	last := parts[len(parts)-1]
+	parts[len(parts)-1] = strings.TrimSuffix(last, path.Ext(last)) + ext
+	return filepath.Join(parts...)
+}
+
+// subcommandOutputNames maps every image to an output file, numbering
+// names that would otherwise collide ("a.png", "a_2.png"), as loose files
+// from different folders or with different extensions share a base name.
+// Names are compared case-insensitively for Windows and macOS.
+func subcommandOutputNames(paths []ImagePath, ext string) []string {
+	names := make([]string, len(paths))
+	used := make(map[string]bool, len(paths))
+	for i, p := range paths {
+		name := subcommandOutputName(p, ext)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d%s", stem, n, ext)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// scaleToFit shrinks img so its longest edge is at most size pixels.
+func scaleToFit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= size && b.Dy() <= size {
+		return img
+	}
+	scale := min(float64(size)/float64(b.Dx()), float64(size)/float64(b.Dy()))
+	dst := image.NewNRGBA(image.Rect(0, 0, max(1, int(float64(b.Dx())*scale)), max(1, int(float64(b.Dy())*scale))))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	return dst
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardCommands lists programs that put their standard input on the
+// clipboard, in order of preference. The Windows command reads the text from
+// NV_CLIPBOARD instead, as the environment is UTF-16 but the console
+// code page may not cover the text.
+func clipboardCommands(goos string, wayland bool) [][]string {
+	switch goos {
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command", "Set-Clipboard -Value $env:NV_CLIPBOARD"}}
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	}
+	x11 := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	if wayland {
+		return append([][]string{{"wl-copy"}}, x11...)
+	}
+	return x11
+}
+
+var errNoClipboardTool = errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+
+// setClipboardText copies text with the first available clipboard command.
+func setClipboardText(text string, goos string) error {
+	for _, args := range clipboardCommands(goos, os.Getenv("WAYLAND_DISPLAY") != "") {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		cmd.Env = append(os.Environ(), "NV_CLIPBOARD="+text)
+		if err := cmd.Run(); err != nil {
+			warnKV("clipboard", "copy_failed", "command", args[0], "error", err)
+			continue
+		}
+		debugKV("clipboard", "copied", "command", args[0], "bytes", len(text))
+		return nil
+	}
+	return errNoClipboardTool
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// clipboardImageCommands lists programs that write the clipboard image to
+// standard output as PNG, in order of preference.
+func clipboardImageCommands(goos string, wayland bool) [][]string {
+	switch goos {
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-STA", "-Command",
+			"Add-Type -AssemblyName System.Windows.Forms; $i = [Windows.Forms.Clipboard]::GetImage(); " +
+				"if ($i) { $s = New-Object IO.MemoryStream; $i.Save($s, [Drawing.Imaging.ImageFormat]::Png); " +
+				"$o = [Console]::OpenStandardOutput(); $o.Write($s.ToArray(), 0, $s.Length); $o.Flush() }"}}
+	case "darwin":
+		return [][]string{{"pngpaste", "-"}}
+	}
+	x11 := [][]string{{"xclip", "-selection", "clipboard", "-target", "image/png", "-out"}}
+	if wayland {
+		return append([][]string{{"wl-paste", "--no-newline", "--type", "image/png"}}, x11...)
+	}
+	return x11
+}
+
+var (
+	errNoClipboardImageTool = errors.New("no clipboard tool found (install wl-clipboard or xclip, or pngpaste on macOS)")
+	errNoClipboardImage     = errors.New("no image on the clipboard")
+)
+
+// readClipboardImage returns the clipboard image as encoded data, from the
+// first available command that has one.
+func readClipboardImage(goos string) ([]byte, error) {
+	found := false
+	for _, args := range clipboardImageCommands(goos, os.Getenv("WAYLAND_DISPLAY") != "") {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		found = true
+		data, err := exec.Command(path, args[1:]...).Output()
+		if err != nil {
+			debugKV("clipboard", "paste_failed", "command", args[0], "error", err)
+			continue
+		}
+		if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+			debugKV("clipboard", "paste_not_image", "command", args[0], "bytes", len(data))
+			continue
+		}
+		debugKV("clipboard", "pasted", "command", args[0], "bytes", len(data))
+		return data, nil
+	}
+	if !found {
+		return nil, errNoClipboardImageTool
+	}
+	return nil, errNoClipboardImage
+}
+
+// clipboardPasteResult carries a pasted image, saved to a temporary file,
+// back to the game loop.
+type clipboardPasteResult struct {
+	Path string
+	Err  error
+}
+
+// savePastedImage writes data to a new temporary file for the image
+// manager to load like any other page.
+func savePastedImage(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "nv-clipboard-*.png")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// pasteClipboardImage reads the clipboard on a background goroutine, as the
+// tools can be slow to answer; the page is added from Update.
+func (g *Game) pasteClipboardImage(goos string) {
+	if !g.clipboardPasteActive.CompareAndSwap(false, true) {
+		return
+	}
+	if g.clipboardPasteResults == nil {
+		g.clipboardPasteResults = make(chan clipboardPasteResult, 1)
+	}
+	results := g.clipboardPasteResults
+	go func() {
+		defer g.clipboardPasteActive.Store(false)
+		defer recoverWorker("clipboard_paste", "", func(err error) { results <- clipboardPasteResult{Err: err} })
+		data, err := readClipboardImage(goos)
+		var path string
+		if err == nil {
+			path, err = savePastedImage(data)
+		}
+		results <- clipboardPasteResult{Path: path, Err: err}
+	}()
+}
+
+func (g *Game) applyClipboardPasteResults() bool {
+	select {
+	case res := <-g.clipboardPasteResults:
+		if res.Err != nil {
+			g.showOverlayMessage(fmt.Sprintf("Paste failed: %v", res.Err))
+			warnKV("clipboard", "paste_image_failed", "error", res.Err)
+			return true
+		}
+		g.insertPastedPage(res.Path)
+		return true
+	default:
+		return false
+	}
+}
+
+// insertPastedPage shows a pasted image as a transient page right after
+// the pages on screen. It is not saved anywhere and belongs to no volume:
+// it is never paired in book mode and cannot be rated, marked, renamed or
+// deleted. The file is removed on exit.
+func (g *Game) insertPastedPage(path string) {
+	g.pastedFiles = append(g.pastedFiles, path)
+	paths := g.currentPaths()
+	if len(paths) == 0 {
+		g.loadFailure = nil
+	}
+	at := 0
+	if len(paths) > 0 {
+		at = g.idx + 1
+		if g.displayContent != nil {
+			at = max(at, g.displayContent.Metadata.LeftPage, g.displayContent.Metadata.RightPage)
+		}
+		at = min(at, len(paths))
+	}
+	paths = slices.Insert(paths, at, ImagePath{Path: path, Transient: true})
+	g.imageManager.SetPaths(paths)
+	g.idx = at
+	g.tempSingleMode = false
+	g.calculateDisplayContent()
+	g.showOverlayMessage(fmt.Sprintf("Pasted image as page %d", at+1))
+	infoKV("clipboard", "paste_image", "path", path, "page", at+1, "paths_count", len(paths))
+}
+
+// removePastedFiles deletes the temporary files of pasted images.
+func (g *Game) removePastedFiles() {
+	for _, path := range g.pastedFiles {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			warnKV("clipboard", "paste_cleanup_failed", "path", path, "error", err)
+		}
+	}
+	g.pastedFiles = nil
+}
+
+// isPastedPage reports whether p is a page added from the clipboard.
+func (g *Game) isPastedPage(p ImagePath) bool {
+	return p.Transient
+}
+
+// refusePastedPage reports whether p is a pasted page, telling the reader
+// that action only applies to real files.
+func (g *Game) refusePastedPage(p ImagePath, action string) bool {
+	if !p.Transient {
+		return false
+	}
+	g.showOverlayMessage("Pasted pages cannot be " + action)
+	debugKV("clipboard", "pasted_page_refused", "path", p.Path, "action", action)
+	return true
+}
+
+// keepPastedPages carries the pasted pages of old over into a freshly
+// collected list at their old positions, since no rescan can find them.
+func keepPastedPages(old, paths []ImagePath) []ImagePath {
+	for i, p := range old {
+		if p.Transient {
+			paths = slices.Insert(paths, min(i, len(paths)), p)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// CompareMode selects how the two pages of a spread are compared.
+type CompareMode int
+
+const (
+	CompareOff     CompareMode = iota
+	CompareHeatmap             // Per-pixel difference shown as a heat map
+	CompareBlink               // Alternate both pages in place
+)
+
+func (m CompareMode) String() string {
+	switch m {
+	case CompareHeatmap:
+		return "Heat map"
+	case CompareBlink:
+		return "Blink"
+	default:
+		return "Off"
+	}
+}
+
+const (
+	compareBlinkInterval = 500 * time.Millisecond
+	// maxCompareDimension bounds the diff texture; larger pages are compared
+	// at reduced resolution.
+	maxCompareDimension = 2 * defaultTileSize
+)
+
+// compareHeatShader maps the largest channel difference to a black, red,
+// yellow, white ramp. The square root makes small differences visible.
+const compareHeatShader = `//kage:unit pixels
+package main
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	d := imageSrc0UnsafeAt(srcPos)
+	m := sqrt(clamp(max(max(d.r, d.g), d.b), 0.0, 1.0))
+	return vec4(clamp(vec3(m*3.0, m*3.0-1.0, m*3.0-2.0), 0.0, 1.0), 1.0)
+}
+`
+
+// subtractBlend leaves max(0, dst-src) in the color channels and keeps the
+// destination opaque.
+var subtractBlend = ebiten.Blend{
+	BlendFactorSourceRGB:        ebiten.BlendFactorOne,
+	BlendFactorSourceAlpha:      ebiten.BlendFactorOne,
+	BlendFactorDestinationRGB:   ebiten.BlendFactorOne,
+	BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+	BlendOperationRGB:           ebiten.BlendOperationReverseSubtract,
+	BlendOperationAlpha:         ebiten.BlendOperationMax,
+}
+
+// cycleCompareMode steps through off, heat map and blink. Comparison needs
+// two pages on screen, so it is only available in book mode.
+func (g *Game) cycleCompareMode() {
+	if g.compareMode == CompareOff && (g.displayContent == nil || g.displayContent.RightImage == nil) {
+		g.showOverlayMessage("Compare needs two pages (book mode)")
+		return
+	}
+	g.compareMode = (g.compareMode + 1) % 3
+	g.compareBlinkElapsed = 0
+	g.compareShowRight = false
+	g.showOverlayMessage("Compare: " + g.compareMode.String())
+	debugKV("renderer", "compare_mode", "mode", g.compareMode.String(), "idx", g.idx)
+}
+
+// advanceCompareBlink swaps the blinking page every compareBlinkInterval and
+// reports whether the screen needs a redraw.
+func (g *Game) advanceCompareBlink(tick time.Duration) bool {
+	if g.compareMode != CompareBlink {
+		return false
+	}
+	g.compareBlinkElapsed += tick
+	if g.compareBlinkElapsed < compareBlinkInterval {
+		return false
+	}
+	g.compareBlinkElapsed -= compareBlinkInterval
+	g.compareShowRight = !g.compareShowRight
+	return true
+}
+
+type rendererCompareCache struct {
+	left         DisplayImage
+	right        DisplayImage
+	image        DisplayImage
+	shader       *ebiten.Shader
+	shaderFailed bool
+}
+
+// drawCompare draws the active comparison of the two displayed pages and
+// reports whether it did; with a single page the normal view is used.
+func (r *Renderer) drawCompare(screen *ebiten.Image, content *DisplayContent) bool {
+	if content.RightImage == nil {
+		return false
+	}
+	switch r.renderState.GetCompareMode() {
+	case CompareHeatmap:
+		r.drawImagesDirect(screen, r.compareDiffImage(content.LeftImage, content.RightImage), nil)
+		return true
+	case CompareBlink:
+		img := content.LeftImage
+		if r.renderState.IsCompareShowingRight() {
+			img = content.RightImage
+		}
+		r.drawImagesDirect(screen, img, nil)
+		return true
+	}
+	return false
+}
+
+// compareDiffImage returns the heat map of |left-right|, with both pages
+// aligned at their top-left corners. The result is cached per page pair.
+func (r *Renderer) compareDiffImage(left, right DisplayImage) DisplayImage {
+	cache := &r.compareCache
+	if cache.image != nil && cache.left == left && cache.right == right {
+		return cache.image
+	}
+	if cache.image != nil {
+		cache.image.Deallocate()
+	}
+
+	lb, rb := left.Bounds(), right.Bounds()
+	w, h := max(lb.Dx(), rb.Dx()), max(lb.Dy(), rb.Dy())
+	scale := math.Min(1, float64(maxCompareDimension)/float64(max(w, h)))
+	w, h = max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))
+
+	// Subtraction clamps at zero, so each direction is computed separately
+	// and the two halves are added.
+	diff := ebiten.NewImage(w, h)
+	for _, pair := range [][2]DisplayImage{{left, right}, {right, left}} {
+		part := ebiten.NewImage(w, h)
+		drawDisplayImageScaled(part, pair[0], scale, ebiten.Blend{})
+		drawDisplayImageScaled(part, pair[1], scale, subtractBlend)
+		diff.DrawImage(part, &ebiten.DrawImageOptions{Blend: ebiten.BlendLighter})
+		part.Deallocate()
+	}
+
+	heat := diff
+	if shader := r.compareShader(); shader != nil {
+		heat = ebiten.NewImage(w, h)
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images[0] = diff
+		heat.DrawRectShader(w, h, shader, op)
+		diff.Deallocate()
+	}
+
+	cache.left, cache.right = left, right
+	cache.image = createDisplayImageFromEbitenImage(heat)
+	debugKV("renderer", "compare_diff_built", "width", w, "height", h, "scale", scale)
+	return cache.image
+}
+
+func (r *Renderer) compareShader() *ebiten.Shader {
+	if r.compareCache.shader == nil && !r.compareCache.shaderFailed {
+		shader, err := ebiten.NewShader([]byte(compareHeatShader))
+		if err != nil {
+			warnKV("renderer", "compare_shader_failed", "error", err, "fallback", "raw_diff")
+			r.compareCache.shaderFailed = true
+			return nil
+		}
+		r.compareCache.shader = shader
+	}
+	return r.compareCache.shader
+}
+
+func drawDisplayImageScaled(dst *ebiten.Image, img DisplayImage, scale float64, blend ebiten.Blend) {
+	scale /= displayTextureScale(img)
+	for _, tile := range img.Tiles() {
+		if tile.Image == nil {
+			continue
+		}
+		op := &ebiten.DrawImageOptions{Blend: blend}
+		op.Filter = ebiten.FilterLinear
+		op.GeoM.Translate(float64(tile.X), float64(tile.Y))
+		op.GeoM.Scale(scale, scale)
+		dst.DrawImage(tile.Image, op)
+	}
+}
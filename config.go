@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"nv/internal/imgdecode"
 )
 
 // Window size constants
@@ -16,6 +19,9 @@ const (
 	minHeight     = 300
 )
 
+// maxRecentFiles caps the recent files list shown on the start screen
+const maxRecentFiles = 9
+
 // Sort method constants
 const (
 	SortNatural    = 0 // Natural sort order (e.g., file1, file2, file10)
@@ -40,8 +46,11 @@ func getDefaultMouseSettings() MouseSettings {
 
 // validateKeybindings validates the keybindings configuration
 func validateKeybindings(keybindings map[string][]string) error {
-	// Check for valid key formats and detect conflicts
+	// Check for valid key formats and detect conflicts. Start screen only
+	// actions never run while viewing, so they only conflict with the other
+	// actions available on the start screen.
 	keyToAction := make(map[string]string)
+	emptyKeyToAction := make(map[string]string)
 
 	for action, keys := range keybindings {
 		for _, keyStr := range keys {
@@ -51,10 +60,18 @@ func validateKeybindings(keybindings map[string][]string) error {
 			}
 
 			// Check for conflicts
-			if existingAction, exists := keyToAction[keyStr]; exists {
-				return fmt.Errorf("key conflict: '%s' is bound to both '%s' and '%s'", keyStr, existingAction, action)
+			if !slices.Contains(emptyStateOnlyActions, action) {
+				if existingAction, exists := keyToAction[keyStr]; exists {
+					return fmt.Errorf("key conflict: '%s' is bound to both '%s' and '%s'", keyStr, existingAction, action)
+				}
+				keyToAction[keyStr] = action
+			}
+			if slices.Contains(emptyStateActions, action) {
+				if existingAction, exists := emptyKeyToAction[keyStr]; exists {
+					return fmt.Errorf("key conflict: '%s' is bound to both '%s' and '%s'", keyStr, existingAction, action)
+				}
+				emptyKeyToAction[keyStr] = action
 			}
-			keyToAction[keyStr] = action
 		}
 	}
 
@@ -175,6 +192,14 @@ func validateMouseSettings(settings MouseSettings) MouseSettings {
 		settings.DoubleClickTime = 1000
 	}
 
+	// Validate wheel behavior while zoomed
+	if !slices.Contains(wheelZoomedModes, settings.WheelZoomed) {
+		settings.WheelZoomed = wheelZoomedPan
+	}
+	if !slices.Contains(wheelPageModifiers, settings.WheelPageModifier) {
+		settings.WheelPageModifier = "Alt"
+	}
+
 	// Validate drag threshold (1 to 20 pixels)
 	if settings.DragThreshold < 1 {
 		settings.DragThreshold = 5
@@ -196,6 +221,9 @@ type ConfigLoadResult struct {
 type Config struct {
 	WindowWidth          int                 `json:"window_width"`
 	WindowHeight         int                 `json:"window_height"`
+	WindowX              int                 `json:"window_x"`
+	WindowY              int                 `json:"window_y"`
+	WindowMonitor        string              `json:"window_monitor"`
 	DefaultWindowWidth   int                 `json:"default_window_width"`
 	DefaultWindowHeight  int                 `json:"default_window_height"`
 	AspectRatioThreshold float64             `json:"aspect_ratio_threshold"`
@@ -203,18 +231,81 @@ type Config struct {
 	FontSize             float64             `json:"font_size"`
 	SortMethod           int                 `json:"sort_method"`
 	BookMode             bool                `json:"book_mode"`
+	BookModePolicy       string              `json:"book_mode_policy"`
+	PlanSpreads          bool                `json:"plan_spreads"`
 	Fullscreen           bool                `json:"fullscreen"`
+	Maximized            bool                `json:"maximized"`
+	UpscaleInWindow      bool                `json:"upscale_in_window"`
+	IntegerZoom          bool                `json:"integer_zoom"`
+	AutoRotate           bool                `json:"auto_rotate"`
+	FullscreenMonitor    int                 `json:"fullscreen_monitor"`
 	CacheSize            int                 `json:"cache_size"`
 	MaxImageDimension    int                 `json:"max_image_dimension"`
 	TransitionFrames     int                 `json:"transition_frames"`
 	PreloadEnabled       bool                `json:"preload_enabled"`
 	PreloadCount         int                 `json:"preload_count"`
+	UploadBudgetMB       int                 `json:"upload_budget_mb"`
+	GPUMemoryCapMB       int                 `json:"gpu_memory_cap_mb"`
+	IdleThrottle         bool                `json:"idle_throttle"`
+	LogToFile            bool                `json:"log_to_file"`
+	LogLevel             string              `json:"log_level"`
+	CheckForUpdates      bool                `json:"check_for_updates"`
+	SaveOnExit           string              `json:"save_on_exit"`
+	ReadOnly             bool                `json:"read_only"`
+	ArchivePrefetch      string              `json:"archive_prefetch"`
+	ArchivePrefetchMaxMB int                 `json:"archive_prefetch_max_mb"`
+	SkipUnreadableImages bool                `json:"skip_unreadable_images"`
+	SniffContent         bool                `json:"sniff_content"`
+	ArchiveIgnore        []string            `json:"archive_ignore"`
+	ChapterPattern       string              `json:"chapter_pattern"`
+	ContactSheetColumns  int                 `json:"contact_sheet_columns"`
+	ContactSheetCellSize int                 `json:"contact_sheet_cell_size"`
+	ContactSheetLabels   bool                `json:"contact_sheet_labels"`
+	SlideshowSeconds     int                 `json:"slideshow_seconds"`
+	ReadingTimerSeconds  int                 `json:"reading_timer_seconds"`
+	ReadingTimerAdvance  bool                `json:"reading_timer_advance"`
+	PageToast            bool                `json:"page_toast"`
+	NextPagePreview      bool                `json:"next_page_preview"`
+	VolumeEnd            string              `json:"volume_end"`
+	HardDelete           bool                `json:"hard_delete"`
+	ConfirmDelete        bool                `json:"confirm_delete"`
+	KioskSlideshow       bool                `json:"kiosk_slideshow"`
+	MediaControls        bool                `json:"media_controls"`
+	TrackReadingProgress bool                `json:"track_reading_progress"`
+	RememberDirection    bool                `json:"remember_reading_direction"`
+	AutoDirection        bool                `json:"auto_reading_direction"`
+	DirectionRules       []DirectionRule     `json:"direction_rules"`
+	PresentationPointer  bool                `json:"presentation_pointer"`
+	OnScreenControls     bool                `json:"on_screen_controls"`
+	CursorHideSeconds    int                 `json:"cursor_hide_seconds"`
+	PrintCommand         string              `json:"print_command"`
+	OCRCommand           string              `json:"ocr_command"`
+	OCRLanguages         string              `json:"ocr_languages"`
+	ScreenshotDir        string              `json:"screenshot_dir"`
+	MeasureDPI           int                 `json:"measure_dpi"`
+	Upscale              string              `json:"upscale"`
+	UpscaleFactor        int                 `json:"upscale_factor"`
+	UpscaleCommand       string              `json:"upscale_command"`
+	GridSpacing          int                 `json:"grid_spacing"`
+	GuideColor           string              `json:"guide_color"`
+	Scripts              []string            `json:"scripts"`
+	EventCommands        map[string]string   `json:"event_commands"`
 	InitialZoomMode      string              `json:"initial_zoom_mode"`
 	FitWidthAlignTop     bool                `json:"fit_width_align_top"`
 	FitHeightAlignLeft   bool                `json:"fit_height_align_left"`
+	PanSpeed             float64             `json:"pan_speed"`
+	PanTurnsPage         bool                `json:"pan_turns_page"`
+	PageScrollFraction   float64             `json:"page_scroll_fraction"`
+	ToneMapOperator      string              `json:"tone_map_operator"`
+	HDRExposure          float64             `json:"hdr_exposure"`
+	DisplaySharpen       float64             `json:"display_sharpen"`
+	DisplayDenoise       float64             `json:"display_denoise"`
+	CustomShader         string              `json:"custom_shader"`
 	Keybindings          map[string][]string `json:"keybindings"`
 	Mousebindings        map[string][]string `json:"mousebindings"`
 	MouseSettings        MouseSettings       `json:"mouse_settings"`
+	Theme                ThemeSettings       `json:"theme"`
+	RecentFiles          []string            `json:"recent_files,omitempty"` // Kept in history.json; read here from older configs
 }
 
 func getConfigPath() string {
@@ -246,6 +337,7 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 	config := Config{
 		WindowWidth:          defaultWidth,
 		WindowHeight:         defaultHeight,
+		WindowMonitor:        "",            // Default: the system places the window
 		DefaultWindowWidth:   defaultWidth,  // Default window width
 		DefaultWindowHeight:  defaultHeight, // Default window height
 		AspectRatioThreshold: 1.5,           // Default threshold for aspect ratio compatibility
@@ -254,6 +346,11 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 		SortMethod:           SortNatural,   // Default to natural sort
 		BookMode:             false,         // Default to single page mode
 		Fullscreen:           false,         // Default to windowed mode
+		Maximized:            false,         // Default: window at window_width × window_height
+		UpscaleInWindow:      false,         // Default: small images keep their size in a window
+		IntegerZoom:          false,         // Default: fit scales freely
+		AutoRotate:           false,         // Default: spreads stay upright on portrait screens
+		FullscreenMonitor:    0,             // Default: fullscreen on the window's monitor
 		CacheSize:            16,            // Default cache size for images
 		MaxImageDimension:    0,             // Default: use the built-in tiling threshold
 		TransitionFrames:     0,             // Default: no forced transition frames
@@ -261,10 +358,67 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 		InitialZoomMode:      "fit_window",  // Default: fit to window
 		FitWidthAlignTop:     false,
 		FitHeightAlignLeft:   false,
+		PanSpeed:             defaultPanSpeed,           // Default: held arrow keys pan one screen per second
+		PanTurnsPage:         false,                     // Default: panning stops at the page edge
+		PageScrollFraction:   defaultPageScrollFraction, // Default: page panning keeps 10% overlap
+		ToneMapOperator:      imgdecode.ToneMapReinhard, // Default HDR tone mapping
+		HDRExposure:          0,                         // Default: no exposure compensation (EV)
+		DisplaySharpen:       0,                         // Default: no sharpening
+		DisplayDenoise:       0,                         // Default: no denoising
 		PreloadCount:         4,                         // Default: preload up to 4 images
-		Keybindings:          getDefaultKeybindings(),   // Default keybindings
-		Mousebindings:        getDefaultMousebindings(), // Default mouse bindings
-		MouseSettings:        getDefaultMouseSettings(), // Default mouse settings
+		UploadBudgetMB:       defaultUploadBudgetMB,     // Default: about one large page per frame
+		GPUMemoryCapMB:       0,                         // Default: cache_size alone bounds GPU memory
+		IdleThrottle:         true,                      // Default: stop frames while nothing changes
+		LogToFile:            false,                     // Default: log to the console only
+		LogLevel:             string(logLevelInfo),      // Default: info, warnings and errors
+		CheckForUpdates:      false,                     // Default: no network access at startup
+		BookModePolicy:       bookModePolicyGlobal,      // Default: book_mode applies everywhere
+		PlanSpreads:          true,                      // Default: pair the whole list once
+		SaveOnExit:           saveOnExitAll,             // Default: remember toggles made with keys
+		ReadOnly:             false,                     // Default: save settings and state
+		ArchivePrefetch:      archivePrefetchOff,        // Default: read archive entries on demand
+		ArchivePrefetchMaxMB: defaultArchivePrefetchMaxMB,
+		SkipUnreadableImages: false,                              // Default: show error placeholders
+		SniffContent:         false,                              // Default: recognize images by extension only
+		ArchiveIgnore:        slices.Clone(defaultArchiveIgnore), // Default: skip common archive junk
+		ChapterPattern:       "",                                 // Default: no virtual chapters (opt-in)
+		ContactSheetColumns:  defaultContactSheetColumns,         // Default: 6 thumbnails per row
+		ContactSheetCellSize: defaultContactSheetCellSize,        // Default: 256 px cells
+		ContactSheetLabels:   true,                               // Default: file names under thumbnails
+		SlideshowSeconds:     defaultSlideshowSeconds,            // Default: 5 seconds per page
+		ReadingTimerSeconds:  defaultReadingTimerSeconds,         // Default: 20 seconds of reading per page
+		ReadingTimerAdvance:  false,                              // Default: indicator only
+		PageToast:            false,                              // Default: page numbers only in the info display
+		NextPagePreview:      false,                              // Default: no preview of the next page
+		VolumeEnd:            volumeEndStop,                      // Default: stay on the last page of an archive
+		HardDelete:           false,                              // Default: files are never deleted
+		ConfirmDelete:        true,                               // Default: press delete twice
+		KioskSlideshow:       false,                              // Default: kiosk mode shows pages manually
+		MediaControls:        false,                              // Default: no MPRIS player (opt-in, Linux)
+		TrackReadingProgress: true,                               // Default: record pages read and time spent
+		RememberDirection:    true,                               // Default: each volume keeps its reading direction
+		AutoDirection:        true,                               // Default: detect the direction of new volumes
+		DirectionRules:       []DirectionRule{},                  // Path rules for the reading direction
+		PresentationPointer:  true,                               // Default: laser pointer in presentation mode
+		OnScreenControls:     false,                              // Default: no on-screen buttons
+		CursorHideSeconds:    defaultCursorHideSeconds,           // Default: hide the cursor after 3 idle seconds
+		PrintCommand:         "",                                 // Default: lp, or Paint on Windows
+		OCRCommand:           "",                                 // Default: tesseract
+		OCRLanguages:         defaultOCRLanguages,                // Default: English
+		ScreenshotDir:        "",                                 // Default: ~/Pictures, or home
+		MeasureDPI:           0,                                  // Default: ruler shows pixels only
+		Upscale:              upscaleOff,                         // Default: draw small pages as they are
+		UpscaleFactor:        defaultUpscaleFactor,               // Default: double the size
+		UpscaleCommand:       "",                                 // Default: none, needed for "command"
+		GridSpacing:          defaultGridSpacing,                 // Default: 100 image pixels
+		GuideColor:           defaultGuideColor,                  // Default: translucent cyan
+		Keybindings:          getDefaultKeybindings(),            // Default keybindings
+		Mousebindings:        getDefaultMousebindings(),          // Default mouse bindings
+		MouseSettings:        getDefaultMouseSettings(),          // Default mouse settings
+		Theme:                getDefaultThemeSettings(),          // Default: black around the image
+		RecentFiles:          []string{},                         // Start screen history
+		Scripts:              []string{},                         // Lua scripts run at startup
+		EventCommands:        map[string]string{},                // Shell commands run on events
 	}
 
 	result := ConfigLoadResult{
@@ -310,6 +464,9 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 		config.DefaultWindowHeight = defaultHeight
 	}
 
+	// Validate fullscreen monitor (0 = the window's monitor, 1 = primary, ...)
+	config.FullscreenMonitor = max(0, config.FullscreenMonitor)
+
 	// Validate aspect ratio threshold
 	if config.AspectRatioThreshold <= 1.0 {
 		config.AspectRatioThreshold = 1.5
@@ -351,6 +508,27 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 		config.PreloadCount = 16
 	}
 
+	// Validate texture upload budget (0 = unpaced, up to 1024 MB per frame)
+	config.UploadBudgetMB = max(0, min(1024, config.UploadBudgetMB))
+
+	// Validate GPU memory cap (0 = unlimited, up to 64 GB)
+	config.GPUMemoryCapMB = max(0, min(65536, config.GPUMemoryCapMB))
+
+	// Validate book mode policy
+	if !slices.Contains(bookModePolicies, config.BookModePolicy) {
+		config.BookModePolicy = bookModePolicyGlobal
+	}
+
+	// Validate save on exit policy
+	if !slices.Contains(saveOnExitPolicies, config.SaveOnExit) {
+		config.SaveOnExit = saveOnExitAll
+	}
+
+	// Validate log level
+	if !validLogLevel(config.LogLevel) {
+		config.LogLevel = string(logLevelInfo)
+	}
+
 	// Validate initial zoom mode
 	validZoomModes := []string{"fit_window", "fit_width", "fit_height", "actual_size"}
 	isValid := false
@@ -364,6 +542,133 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 		config.InitialZoomMode = "fit_window"
 	}
 
+	if !slices.Contains(volumeEndModes, config.VolumeEnd) {
+		config.VolumeEnd = volumeEndStop
+	}
+
+	// Validate archive prefetch mode and memory cap (64 MB to 16 GB)
+	if !slices.Contains(archivePrefetchModes, config.ArchivePrefetch) {
+		config.ArchivePrefetch = archivePrefetchOff
+	}
+	if config.ArchivePrefetchMaxMB <= 0 {
+		config.ArchivePrefetchMaxMB = defaultArchivePrefetchMaxMB
+	}
+	config.ArchivePrefetchMaxMB = max(64, min(16384, config.ArchivePrefetchMaxMB))
+
+	// Validate archive ignore patterns (an empty list disables filtering)
+	if config.ArchiveIgnore == nil {
+		config.ArchiveIgnore = []string{}
+	}
+	valid, invalid := validArchiveIgnorePatterns(config.ArchiveIgnore)
+	if len(invalid) > 0 {
+		warnKV("config", "archive_ignore_invalid", "patterns", invalid, "reason", "dropped")
+		config.ArchiveIgnore = valid
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Invalid archive_ignore patterns: %q", invalid))
+	}
+
+	// Validate the chapter pattern (an empty pattern disables it)
+	if err := validChapterPattern(config.ChapterPattern); err != nil {
+		warnKV("config", "chapter_pattern_invalid", "pattern", config.ChapterPattern, "error", err, "reason", "use_default")
+		config.ChapterPattern = ""
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Invalid chapter_pattern: %v", err))
+	}
+
+	// Validate contact sheet layout (1-32 columns, 32-1024 px cells)
+	if config.ContactSheetColumns <= 0 {
+		config.ContactSheetColumns = defaultContactSheetColumns
+	}
+	config.ContactSheetColumns = min(32, config.ContactSheetColumns)
+	if config.ContactSheetCellSize <= 0 {
+		config.ContactSheetCellSize = defaultContactSheetCellSize
+	}
+	config.ContactSheetCellSize = max(32, min(1024, config.ContactSheetCellSize))
+
+	// Validate slideshow interval (1-3600 seconds)
+	if config.SlideshowSeconds <= 0 {
+		config.SlideshowSeconds = defaultSlideshowSeconds
+	}
+	config.SlideshowSeconds = min(3600, config.SlideshowSeconds)
+
+	// Validate reading timer pace (1-3600 seconds per page)
+	if config.ReadingTimerSeconds <= 0 {
+		config.ReadingTimerSeconds = defaultReadingTimerSeconds
+	}
+	config.ReadingTimerSeconds = min(3600, config.ReadingTimerSeconds)
+
+	// Validate cursor hiding (0 = only in fullscreen and slideshows, up to an hour)
+	config.CursorHideSeconds = max(0, min(3600, config.CursorHideSeconds))
+
+	// Validate held-key pan speed (0 = steps per press, up to 10 screens per second)
+	config.PanSpeed = max(0, min(10, config.PanSpeed))
+
+	// Validate page panning step (10%-100% of the screen height)
+	if config.PageScrollFraction <= 0 {
+		config.PageScrollFraction = defaultPageScrollFraction
+	}
+	config.PageScrollFraction = max(0.1, min(1, config.PageScrollFraction))
+
+	// Validate ruler resolution (0 = pixels only, up to 100000 dpi)
+	config.MeasureDPI = max(0, min(100000, config.MeasureDPI))
+
+	// Validate upscaling (2x-4x)
+	if !slices.Contains(upscaleModes, config.Upscale) {
+		config.Upscale = upscaleOff
+	}
+	if config.Upscale == upscaleCommand && strings.TrimSpace(config.UpscaleCommand) == "" {
+		warnKV("config", "upscale_invalid", "reason", "no_command", "fallback", upscaleOff)
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, errUpscaleCommandMissing.Error())
+		config.Upscale = upscaleOff
+	}
+	if config.UpscaleFactor <= 0 {
+		config.UpscaleFactor = defaultUpscaleFactor
+	}
+	config.UpscaleFactor = max(2, min(4, config.UpscaleFactor))
+
+	// Validate guide overlays (8-4096 px grid, #RRGGBB[AA] color)
+	if config.GridSpacing <= 0 {
+		config.GridSpacing = defaultGridSpacing
+	}
+	config.GridSpacing = max(8, min(4096, config.GridSpacing))
+	if _, err := parseHexColor(config.GuideColor); err != nil {
+		warnKV("config", "guide_color_invalid", "value", config.GuideColor, "reason", "default_used")
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, err.Error())
+		config.GuideColor = defaultGuideColor
+	}
+
+	// Validate reading direction rules (a match and "rtl" or "ltr")
+	directionRules, droppedRules := validDirectionRules(config.DirectionRules)
+	config.DirectionRules = directionRules
+	if len(droppedRules) > 0 {
+		warnKV("config", "direction_rules_invalid", "rules", droppedRules, "reason", "dropped")
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Invalid direction rules: %s", strings.Join(droppedRules, ", ")))
+	}
+
+	if config.Scripts == nil {
+		config.Scripts = []string{}
+	}
+	eventCommands, unknownEvents := validEventCommands(config.EventCommands)
+	config.EventCommands = eventCommands
+	if len(unknownEvents) > 0 {
+		warnKV("config", "event_commands_invalid", "events", unknownEvents, "reason", "dropped")
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Unknown event_commands events: %q", unknownEvents))
+	}
+
+	// Validate HDR tone mapping
+	if !imgdecode.IsToneMapOperator(config.ToneMapOperator) {
+		config.ToneMapOperator = imgdecode.ToneMapReinhard
+	}
+	config.HDRExposure = clampHDRExposure(config.HDRExposure)
+
+	// Validate display filters (sharpen 0-3, denoise 0-1)
+	config.DisplaySharpen = clampSharpen(config.DisplaySharpen)
+	config.DisplayDenoise = clampDenoise(config.DisplayDenoise)
+
 	// Validate keybindings - ensure defaults exist for missing actions
 	if config.Keybindings == nil {
 		config.Keybindings = getDefaultKeybindings()
@@ -409,11 +714,42 @@ func loadConfigFromPath(configPath string) ConfigLoadResult {
 	// Validate mouse settings
 	config.MouseSettings = validateMouseSettings(config.MouseSettings)
 
+	// Validate theme colors (#RRGGBB[AA])
+	if theme, warnings := validateThemeSettings(config.Theme); len(warnings) > 0 {
+		config.Theme = theme
+		result.Status = "Warning"
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Validate recent files - drop blanks and duplicates, keep the newest entries.
+	// The history file wins; a config from an older version still carries them.
+	if recent, ok := loadRecentFiles(statePathForConfig(configPath, historyFileName)); ok {
+		config.RecentFiles = recent
+	}
+	config.RecentFiles = normalizeRecentFiles(config.RecentFiles)
+
 	// Update the result with the final config
 	result.Config = config
 	return result
 }
 
+// normalizeRecentFiles removes empty and duplicate entries and applies maxRecentFiles
+func normalizeRecentFiles(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		result = append(result, file)
+		if len(result) == maxRecentFiles {
+			break
+		}
+	}
+	return result
+}
+
 // getSortMethodName returns the human-readable name of a sort method
 func getSortMethodName(sortMethod int) string {
 	strategy := GetSortStrategy(sortMethod)
@@ -425,6 +761,11 @@ func saveConfig(config Config) {
 }
 
 func saveConfigToPath(config Config, configPath string) {
+	if readOnly {
+		debugKV("config", "config_save_skipped", "reason", "read_only", "path", configPath)
+		return
+	}
+
 	// Don't save if size is too small
 	if config.WindowWidth < minWidth || config.WindowHeight < minHeight {
 		warnKV("config", "config_save_skipped",
@@ -442,6 +783,9 @@ func saveConfigToPath(config Config, configPath string) {
 		return
 	}
 
+	saveRecentFiles(statePathForConfig(configPath, historyFileName), config.RecentFiles)
+	config.RecentFiles = nil
+
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		errorKV("config", "config_marshal_failed", "error", err)
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// effectiveConfig returns the config as the viewer currently runs: toggles
+// made with keys since startup (book mode, zoom mode, fullscreen, display
+// filters; sort and reading direction already live in g.config) are folded
+// in. Recent files are left out, as they only make sense on this machine.
+func (g *Game) effectiveConfig() Config {
+	c := g.readerConfig()
+	c.BookMode = g.bookMode
+	c.Fullscreen = g.fullscreen
+	c.HDRExposure = g.hdrExposure
+	c.DisplaySharpen = g.sharpen
+	c.DisplayDenoise = g.denoise
+	if g.zoomState != nil && g.zoomState.Mode != ZoomModeManual {
+		c.InitialZoomMode = g.zoomState.Mode.String()
+	}
+	c.RecentFiles = nil
+	return c
+}
+
+// configTransferPath resolves a file name given for export or import:
+// relative names are taken from baseDir and ".json" is added when the name
+// has no extension.
+func configTransferPath(name, baseDir string) string {
+	name = strings.TrimSpace(name)
+	if home, err := os.UserHomeDir(); err == nil && (strings.HasPrefix(name, "~/") || strings.HasPrefix(name, "~"+string(filepath.Separator))) {
+		name = filepath.Join(home, name[2:])
+	}
+	if filepath.Ext(name) == "" {
+		name += ".json"
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(baseDir, name)
+}
+
+// configDir is where names typed into the export and import prompts are
+// looked up.
+func (g *Game) configDir() string {
+	if g.configPath != "" {
+		return filepath.Dir(g.configPath)
+	}
+	return filepath.Dir(getConfigPath())
+}
+
+// exportConfigFile writes config to path in the config file format.
+func exportConfigFile(config Config, path string) error {
+	config.RecentFiles = nil
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// commandSettings are the settings that make nv run programs or shaders.
+// A shared config file may come from anyone, so an import keeps these from
+// the current config unless they are explicitly allowed.
+var commandSettings = []struct {
+	name string
+	get  func(*Config) any
+	keep func(dst *Config, current Config)
+}{
+	{"event_commands", func(c *Config) any { return c.EventCommands }, func(d *Config, c Config) { d.EventCommands = c.EventCommands }},
+	{"scripts", func(c *Config) any { return c.Scripts }, func(d *Config, c Config) { d.Scripts = c.Scripts }},
+	{"print_command", func(c *Config) any { return c.PrintCommand }, func(d *Config, c Config) { d.PrintCommand = c.PrintCommand }},
+	{"ocr_command", func(c *Config) any { return c.OCRCommand }, func(d *Config, c Config) { d.OCRCommand = c.OCRCommand }},
+	{"upscale_command", func(c *Config) any { return c.UpscaleCommand }, func(d *Config, c Config) { d.UpscaleCommand = c.UpscaleCommand }},
+	{"custom_shader", func(c *Config) any { return c.CustomShader }, func(d *Config, c Config) { d.CustomShader = c.CustomShader }},
+}
+
+// importConfigFile reads and validates an exported config. The window size
+// and recent files of current are kept, since they belong to this machine.
+// Command settings that differ from current are returned by name; they are
+// kept from current unless allowCommands is set.
+func importConfigFile(path string, current Config, allowCommands bool) (ConfigLoadResult, []string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return ConfigLoadResult{}, nil, err
+	}
+	res := loadConfigFromPath(path)
+	if res.HasError {
+		return res, nil, fmt.Errorf("%s: %s", filepath.Base(path), res.Status)
+	}
+	var changed []string
+	for _, s := range commandSettings {
+		if reflect.DeepEqual(s.get(&res.Config), s.get(&current)) {
+			continue
+		}
+		changed = append(changed, s.name)
+		if !allowCommands {
+			s.keep(&res.Config, current)
+		}
+	}
+	res.Config.WindowWidth = current.WindowWidth
+	res.Config.WindowHeight = current.WindowHeight
+	res.Config.WindowX = current.WindowX
+	res.Config.WindowY = current.WindowY
+	res.Config.WindowMonitor = current.WindowMonitor
+	res.Config.Maximized = current.Maximized
+	res.Config.RecentFiles = current.RecentFiles
+	return res, changed, nil
+}
+
+func (g *Game) enterConfigExport() {
+	g.openTextPrompt(TextPromptExportConfig, "nv-config.json")
+}
+
+func (g *Game) enterConfigImport() {
+	g.openTextPrompt(TextPromptImportConfig, "nv-config.json")
+}
+
+func (g *Game) processConfigExport(name string) {
+	if strings.TrimSpace(name) == "" {
+		return
+	}
+	path := configTransferPath(name, g.configDir())
+	if err := exportConfigFile(g.effectiveConfig(), path); err != nil {
+		warnKV("config", "export_failed", "path", path, "error", err)
+		g.showOverlayMessage(fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	infoKV("config", "exported", "path", path)
+	g.showOverlayMessage("Config exported to " + path)
+}
+
+func (g *Game) processConfigImport(name string) {
+	if strings.TrimSpace(name) == "" {
+		return
+	}
+	path := configTransferPath(name, g.configDir())
+	res, kept, err := importConfigFile(path, g.config, false)
+	if err != nil {
+		warnKV("config", "import_failed", "path", path, "error", err)
+		g.showOverlayMessage(fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+	g.saveAndApplyConfig(res.Config)
+	g.resetZoomToInitial()
+	infoKV("config", "imported", "path", path, "warnings", len(res.Warnings), "kept", strings.Join(kept, ","))
+	msg := "Config imported from " + filepath.Base(path)
+	if len(kept) > 0 {
+		msg += " (kept local " + strings.Join(kept, ", ") + ")"
+	}
+	if readOnly {
+		msg += " (read-only, not saved)"
+	}
+	g.showOverlayMessage(msg)
+}
+
+// runConfigSubcommand runs "nv config export|import <file>", which copies
+// the saved config to a file or replaces it with one.
+func runConfigSubcommand(args []string) int {
+	set := flag.NewFlagSet("nv config", flag.ContinueOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "usage: nv config [flags] export|import <file>\nCopy the settings to a file, or replace them with an exported file.\n")
+		set.PrintDefaults()
+	}
+	configPath := set.String("c", "", "config file path (default: OS config dir)")
+	debug := set.Bool("d", false, "enable debug logging")
+	allowCommands := set.Bool("allow-commands", false, "also import event_commands, scripts, print/ocr/upscale commands and custom_shader")
+	if err := set.Parse(args); err != nil {
+		return 2
+	}
+	if set.NArg() != 2 || (set.Arg(0) != "export" && set.Arg(0) != "import") {
+		set.Usage()
+		return 2
+	}
+	debugMode.Store(*debug)
+
+	target := *configPath
+	if target == "" {
+		target = getConfigPath()
+	}
+	current := loadStartupConfig(*configPath).Config
+	cwd, err := os.Getwd()
+	if err != nil {
+		errorKV("config", "getwd_failed", "error", err)
+		return 1
+	}
+	path := configTransferPath(set.Arg(1), cwd)
+
+	if set.Arg(0) == "export" {
+		if err := exportConfigFile(current, path); err != nil {
+			errorKV("config", "export_failed", "path", path, "error", err)
+			return 1
+		}
+		fmt.Println(path)
+		return 0
+	}
+	if current.ReadOnly {
+		errorKV("config", "import_failed", "path", path, "reason", "read_only", "config_path", target)
+		return 1
+	}
+	res, changed, err := importConfigFile(path, current, *allowCommands)
+	if err != nil {
+		errorKV("config", "import_failed", "path", path, "error", err)
+		return 1
+	}
+	saveConfigToPath(res.Config, target)
+	fmt.Printf("Imported %s into %s\n", path, target)
+	if len(changed) > 0 {
+		if *allowCommands {
+			fmt.Printf("Imported command settings: %s\n", strings.Join(changed, ", "))
+		} else {
+			fmt.Printf("Kept the current %s; rerun with -allow-commands to import them\n", strings.Join(changed, ", "))
+		}
+	}
+	return 0
+}
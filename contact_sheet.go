@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"nv/internal/imgdecode"
+)
+
+const (
+	defaultContactSheetColumns  = 6
+	defaultContactSheetCellSize = 256
+
+	contactSheetPadding     = 4
+	contactSheetLabelHeight = 16
+	// maxContactSheetPixels keeps the composed sheet within a few hundred MB.
+	maxContactSheetPixels = 64 << 20
+)
+
+var (
+	contactSheetBackground = color.NRGBA{32, 32, 32, 255}
+	contactSheetLabelColor = color.NRGBA{200, 200, 200, 255}
+	contactSheetErrorColor = color.NRGBA{96, 32, 32, 255}
+)
+
+var errContactSheetTooLarge = errors.New("contact sheet too large; use fewer images or a smaller cell size")
+
+// contactSheetOptions controls the grid layout of a contact sheet.
+type contactSheetOptions struct {
+	Columns  int
+	CellSize int // Thumbnail cell edge in pixels
+	Labels   bool
+}
+
+func contactSheetOptionsFromConfig(c Config) contactSheetOptions {
+	return contactSheetOptions{
+		Columns:  c.ContactSheetColumns,
+		CellSize: c.ContactSheetCellSize,
+		Labels:   c.ContactSheetLabels,
+	}
+}
+
+// contactSheetLayout returns the sheet size and the height of one row.
+func contactSheetLayout(count int, opts contactSheetOptions) (int, int, int) {
+	columns := max(1, min(opts.Columns, count))
+	rows := (count + columns - 1) / columns
+	rowH := opts.CellSize + contactSheetPadding
+	if opts.Labels {
+		rowH += contactSheetLabelHeight
+	}
+	return columns*(opts.CellSize+contactSheetPadding) + contactSheetPadding, rows*rowH + contactSheetPadding, rowH
+}
+
+// buildContactSheet composes thumbnails of paths into one image. Entries
+// that fail to decode get a red placeholder so the grid keeps its order.
+func buildContactSheet(paths []ImagePath, opts contactSheetOptions) (*image.NRGBA, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no images")
+	}
+	w, h, rowH := contactSheetLayout(len(paths), opts)
+	if int64(w)*int64(h) > maxContactSheetPixels {
+		return nil, errContactSheetTooLarge
+	}
+
+	sheet := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.Draw(sheet, sheet.Bounds(), image.NewUniform(contactSheetBackground), image.Point{}, xdraw.Src)
+	columns := max(1, min(opts.Columns, len(paths)))
+	cell := func(i int) image.Rectangle {
+		x := contactSheetPadding + (i%columns)*(opts.CellSize+contactSheetPadding)
+		y := contactSheetPadding + (i/columns)*rowH
+		return image.Rect(x, y, x+opts.CellSize, y+opts.CellSize)
+	}
+
+	failed := 0
+	err := forEachDecodedImage(paths, func(i int, img image.Image, err error) {
+		r := cell(i)
+		if err != nil {
+			failed++
+			debugKV("collection", "contact_sheet_decode_failed", "path", paths[i].Path, "error", err)
+			xdraw.Draw(sheet, r, image.NewUniform(contactSheetErrorColor), image.Point{}, xdraw.Src)
+		} else {
+			drawThumbnail(sheet, r, img)
+		}
+		if opts.Labels {
+			drawContactSheetLabel(sheet, r, contactSheetLabelText(paths[i]))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	debugKV("collection", "contact_sheet_built", "images", len(paths), "failed", failed, "width", w, "height", h)
+	return sheet, nil
+}
+
+// forEachDecodedImage decodes every path once, reading each archive in a
+// single pass instead of seeking to every entry.
+func forEachDecodedImage(paths []ImagePath, fn func(i int, img image.Image, err error)) error {
+	return readImageEntries(paths, func(indices []int, origin string, data []byte, err error) {
+		var img image.Image
+		if err == nil {
+			img, err = imgdecode.DecodeBytes(data, origin)
+		}
+		for _, i := range indices {
+			fn(i, img, err)
+		}
+	})
+}
+
+// drawThumbnail scales img to fit r, keeping its aspect ratio.
+func drawThumbnail(dst *image.NRGBA, r image.Rectangle, img image.Image) {
+	b := img.Bounds()
+	if b.Empty() {
+		return
+	}
+	scale := min(float64(r.Dx())/float64(b.Dx()), float64(r.Dy())/float64(b.Dy()), 1)
+	tw, th := max(1, int(float64(b.Dx())*scale)), max(1, int(float64(b.Dy())*scale))
+	x := r.Min.X + (r.Dx()-tw)/2
+	y := r.Min.Y + (r.Dy()-th)/2
+	xdraw.BiLinear.Scale(dst, image.Rect(x, y, x+tw, y+th), img, b, xdraw.Over, nil)
+}
+
+func contactSheetLabelText(p ImagePath) string {
+	if p.ArchivePath != "" {
+		return filepath.Base(strings.ReplaceAll(p.EntryPath, "\\", "/"))
+	}
+	return filepath.Base(p.Path)
+}
+
+// drawContactSheetLabel writes name below the cell, cut to the cell width.
+func drawContactSheetLabel(dst *image.NRGBA, r image.Rectangle, name string) {
+	face := basicfont.Face7x13
+	maxChars := r.Dx() / face.Advance
+	if runes := []rune(name); len(runes) > maxChars && maxChars > 1 {
+		name = string(runes[:maxChars-1]) + "…"
+	}
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(contactSheetLabelColor),
+		Face: face,
+		Dot:  fixed.P(r.Min.X, r.Max.Y+face.Ascent+1),
+	}
+	d.DrawString(name)
+}
+
+// writeImageFile encodes img as JPEG for .jpg/.jpeg outputs and as PNG
+// otherwise.
+func writeImageFile(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 90})
+	default:
+		err = png.Encode(f, img)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// runContactSheetCLI is the headless --contact-sheet mode: it collects args
+// like a normal launch, writes the sheet and returns the exit code.
+func runContactSheetCLI(output string, args []string, config Config, opts contactSheetOptions) int {
+	start := time.Now()
+	contentSniffing.Store(config.SniffContent)
+	setArchiveIgnore(config.ArchiveIgnore)
+	paths, err := collectImages(args, config.SortMethod)
+	if err == nil && len(paths) == 0 {
+		err = errors.New("no images found")
+	}
+	if err == nil {
+		var sheet *image.NRGBA
+		if sheet, err = buildContactSheet(paths, opts); err == nil {
+			err = writeImageFile(output, sheet)
+		}
+	}
+	if err != nil {
+		errorKV("collection", "contact_sheet_failed", "output", output, "args", args, "error", err)
+		return 1
+	}
+	infoKV("collection", "contact_sheet_written",
+		"output", output,
+		"images", len(paths),
+		"elapsed_ms", time.Since(start).Milliseconds(),
+	)
+	return 0
+}
+
+// contactSheetResult carries a finished export back to the game loop.
+type contactSheetResult struct {
+	Output string
+	Count  int
+	Err    error
+}
+
+// contactSheetOutputPath names the sheet after the archive or directory of
+// the first image and places it beside it, without overwriting files.
+func contactSheetOutputPath(first ImagePath) string {
+	source := first.ArchivePath
+	if source == "" {
+		source = filepath.Dir(first.Path)
+	}
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	dir := filepath.Dir(source)
+	candidate := filepath.Join(dir, base+"_contact.png")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s_contact_%d.png", base, n))
+	}
+}
+
+// exportContactSheet writes a contact sheet of the current list on a
+// background goroutine; the outcome is reported from Update.
+func (g *Game) exportContactSheet() {
+	count := g.imageManager.GetPathsCount()
+	if count == 0 {
+		return
+	}
+	if !g.contactSheetActive.CompareAndSwap(false, true) {
+		g.showOverlayMessage("Contact sheet export already running")
+		return
+	}
+	if g.contactSheetResults == nil {
+		g.contactSheetResults = make(chan contactSheetResult, 1)
+	}
+
+	paths := make([]ImagePath, 0, count)
+	for i := range count {
+		if p, ok := g.imageManager.GetPath(i); ok {
+			paths = append(paths, p)
+		}
+	}
+	output := contactSheetOutputPath(paths[0])
+	opts := contactSheetOptionsFromConfig(g.config)
+	results := g.contactSheetResults
+	g.showOverlayMessage(fmt.Sprintf("Exporting contact sheet (%d images)...", len(paths)))
+	debugKV("collection", "contact_sheet_begin", "output", output, "images", len(paths), "columns", opts.Columns, "cell_size", opts.CellSize)
+	go func() {
+		defer g.contactSheetActive.Store(false)
+		defer recoverWorker("contact_sheet", output, func(err error) {
+			results <- contactSheetResult{Output: output, Count: len(paths), Err: err}
+		})
+		sheet, err := buildContactSheet(paths, opts)
+		if err == nil {
+			err = writeImageFile(output, sheet)
+		}
+		results <- contactSheetResult{Output: output, Count: len(paths), Err: err}
+	}()
+}
+
+func (g *Game) applyContactSheetResults() bool {
+	select {
+	case res := <-g.contactSheetResults:
+		if res.Err != nil {
+			g.showOverlayMessage(fmt.Sprintf("Contact sheet failed: %v", res.Err))
+			warnKV("collection", "contact_sheet_failed", "output", res.Output, "error", res.Err)
+			return true
+		}
+		g.showOverlayMessage("Contact sheet saved: " + filepath.Base(res.Output))
+		infoKV("collection", "contact_sheet_written", "output", res.Output, "images", res.Count)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errContextMenuUnavailable is returned by builds without the Explorer
+// integration: it is Windows only and compiled in with -tags shell_menu.
+var errContextMenuUnavailable = errors.New("Explorer context menu needs a Windows build with -tags shell_menu")
+
+// contextMenuVerb is the registry key name of the "Browse with nv" verb.
+const contextMenuVerb = "nv.browse"
+
+// contextMenuEntry is one place the "Browse with nv" verb is registered,
+// relative to HKCU\Software\Classes, with the command Explorer runs.
+type contextMenuEntry struct {
+	Key     string
+	Command string
+}
+
+// contextMenuEntries lists the verbs for folders, the background of an open
+// folder and every archive type. Archives use SystemFileAssociations so the
+// verb appears whichever program opens them by default.
+func contextMenuEntries(exe string) []contextMenuEntry {
+	folder := fmt.Sprintf(`"%s" "%%1"`, exe)
+	entries := []contextMenuEntry{
+		{`Directory\shell\` + contextMenuVerb, folder},
+		{`Directory\Background\shell\` + contextMenuVerb, fmt.Sprintf(`"%s" "%%V"`, exe)},
+	}
+	for _, a := range fileAssociations {
+		if isArchiveExt("archive" + a.Ext) {
+			entries = append(entries, contextMenuEntry{`SystemFileAssociations\` + a.Ext + `\shell\` + contextMenuVerb, folder})
+		}
+	}
+	return entries
+}
+
+// runContextMenuSubcommand runs "nv context-menu install|uninstall".
+func runContextMenuSubcommand(args []string) int {
+	if len(args) != 1 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintln(os.Stderr, "usage: nv context-menu install|uninstall\nAdd or remove \"Browse with nv\" in the Explorer menu of folders and archives.")
+		return 2
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		errorKV("association", "executable_not_found", "error", err)
+		return 1
+	}
+
+	install := args[0] == "install"
+	if err := applyContextMenu(exe, install); err != nil {
+		errorKV("association", "context_menu_failed", "action", args[0], "path", exe, "error", err)
+		return 1
+	}
+	infoKV("association", "context_menu_"+args[0]+"ed", "path", exe)
+	if install {
+		fmt.Println(`Added "Browse with nv" to the Explorer menu of folders and archives`)
+	} else {
+		fmt.Println(`Removed "Browse with nv" from the Explorer menu`)
+	}
+	return 0
+}
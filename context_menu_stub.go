@@ -0,0 +1,7 @@
+//go:build !windows || !shell_menu
+
+package main
+
+func applyContextMenu(_ string, _ bool) error {
+	return errContextMenuUnavailable
+}
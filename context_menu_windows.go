@@ -0,0 +1,41 @@
+//go:build windows && shell_menu
+
+package main
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// applyContextMenu writes or deletes the static "Browse with nv" verbs for
+// the current user. Static verbs need no COM shell extension DLL; Windows 11
+// lists them under "Show more options".
+func applyContextMenu(exe string, install bool) error {
+	classes, err := registry.OpenKey(registry.CURRENT_USER, `Software\Classes`, registry.ALL_ACCESS)
+	if err != nil {
+		return err
+	}
+	defer classes.Close()
+
+	for _, e := range contextMenuEntries(exe) {
+		if !install {
+			if err := deleteRegistryTree(classes, e.Key); err != nil && !errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+				return err
+			}
+			continue
+		}
+		if err := setRegistryString(classes, e.Key, "", "Browse with nv"); err != nil {
+			return err
+		}
+		if err := setRegistryString(classes, e.Key, "Icon", exe+",0"); err != nil {
+			return err
+		}
+		if err := setRegistryString(classes, e.Key+`\command`, "", e.Command); err != nil {
+			return err
+		}
+	}
+	procSHChangeNotify.Call(shcneAssocChanged, shcnfIDList, 0, 0)
+	return nil
+}
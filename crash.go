@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// crashReportPrefix names crash reports written to the state directory,
+// e.g. crash-20240102-150405.txt.
+const crashReportPrefix = "crash-"
+
+// workerConfigPath is the config path of the running Game, for reports from
+// goroutines that do not hold it.
+var workerConfigPath atomic.Pointer[string]
+
+// crashInfo is what a crash report records besides the panic itself.
+type crashInfo struct {
+	Time        time.Time
+	Worker      string // Goroutine that panicked; empty for the main loop
+	CurrentFile string
+	PageIndex   int
+	PageCount   int
+	Config      Config
+}
+
+// recoverCrash is deferred by Update, Draw and main. On a panic it writes a
+// crash report, tells the user where it is and exits, instead of letting the
+// window disappear without a trace.
+func (g *Game) recoverCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	info := crashInfo{Time: time.Now()}
+	if g != nil {
+		info.Config = g.config
+		info.PageIndex = g.idx
+		func() {
+			// The state that panicked may panic again; the report matters more.
+			defer func() { recover() }()
+			info.CurrentFile = g.getCurrentImagePath()
+			info.PageCount = g.imageManager.GetPathsCount()
+		}()
+	}
+
+	configPath := ""
+	if g != nil {
+		configPath = g.configPath
+	}
+	path, err := writeCrashReport(crashReportDir(configPath), r, stack, info)
+	if err != nil {
+		errorKV("crash", "report_write_failed", "panic", fmt.Sprint(r), "error", err)
+		os.Stderr.Write(stack)
+	} else {
+		errorKV("crash", "panic", "panic", fmt.Sprint(r), "report", path)
+	}
+
+	msg := fmt.Sprintf("nv crashed: %v", r)
+	if path != "" {
+		msg += "\n\nA crash report was saved to:\n" + path + "\n\nPlease attach it when reporting the problem."
+	}
+	showCrashDialog(msg)
+	os.Exit(2)
+}
+
+// recoverWorker is deferred by goroutines working beside the main loop:
+// the image loaders, archive prefetch, exports and other background jobs.
+// On a panic it writes a crash report and lets nv run on; onPanic, when
+// not nil, receives the panic as an error so the job can report it, e.g.
+// by showing the page as failed.
+func recoverWorker(worker, file string, onPanic func(error)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	configPath := ""
+	if p := workerConfigPath.Load(); p != nil {
+		configPath = *p
+	}
+	info := crashInfo{Time: time.Now(), Worker: worker, CurrentFile: file}
+	path, err := writeCrashReport(crashReportDir(configPath), r, stack, info)
+	if err != nil {
+		errorKV("crash", "report_write_failed", "worker", worker, "panic", fmt.Sprint(r), "error", err)
+		os.Stderr.Write(stack)
+	} else {
+		errorKV("crash", "worker_panic", "worker", worker, "file", file, "panic", fmt.Sprint(r), "report", path)
+	}
+	if onPanic != nil {
+		onPanic(fmt.Errorf("internal error: %v", r))
+	}
+}
+
+// crashReportDir places crash reports in the state directory, or the
+// temporary directory in read-only mode.
+func crashReportDir(configPath string) string {
+	if readOnly {
+		return os.TempDir()
+	}
+	return stateDirForConfig(configPath)
+}
+
+// writeCrashReport writes the panic, stack and context to a new file in dir
+// and returns its path. A later report in the same second gets a numbered
+// name such as crash-20240102-150405-2.txt.
+func writeCrashReport(dir string, r any, stack []byte, info crashInfo) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := crashReportPrefix + info.Time.Format("20060102-150405")
+	if info.Worker != "" {
+		name += "-" + info.Worker
+	}
+	data := []byte(formatCrashReport(r, stack, info))
+	// Names go down to the second and workers keep running after a panic,
+	// so number later reports instead of overwriting the first
+	path := filepath.Join(dir, name+".txt")
+	for n := 2; ; n++ {
+		err := writeNewFile(path, data)
+		if !errors.Is(err, fs.ErrExist) {
+			if err != nil {
+				return "", err
+			}
+			return path, nil
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.txt", name, n))
+	}
+}
+
+// formatCrashReport lays out a crash report. The config summary, left out
+// for workers, skips bindings, recent files, scripts and commands, which say
+// nothing about a crash and may be private.
+func formatCrashReport(r any, stack []byte, info crashInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nv crash report\n\n")
+	fmt.Fprintf(&b, "Time:     %s\n", info.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:  v%s (built on %s)\n", version, buildDate)
+	fmt.Fprintf(&b, "Go:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "Panic:    %v\n", r)
+	if info.Worker != "" {
+		fmt.Fprintf(&b, "Worker:   %s\n", info.Worker)
+	}
+	fmt.Fprintf(&b, "File:     %s\n", info.CurrentFile)
+	if info.PageCount > 0 {
+		fmt.Fprintf(&b, "Page:     %d of %d\n", info.PageIndex+1, info.PageCount)
+	}
+
+	if info.Worker != "" {
+		// Workers do not hold the config
+		fmt.Fprintf(&b, "\nStack:\n%s", stack)
+		return b.String()
+	}
+
+	c := info.Config
+	fmt.Fprintf(&b, "\nConfig:\n")
+	for _, kv := range [][2]any{
+		{"window", fmt.Sprintf("%dx%d", c.WindowWidth, c.WindowHeight)},
+		{"fullscreen", c.Fullscreen},
+		{"book_mode", c.BookMode},
+		{"right_to_left", c.RightToLeft},
+		{"sort_method", c.SortMethod},
+		{"initial_zoom_mode", c.InitialZoomMode},
+		{"cache_size", c.CacheSize},
+		{"max_image_dimension", c.MaxImageDimension},
+		{"preload", fmt.Sprintf("%v (%d)", c.PreloadEnabled, c.PreloadCount)},
+		{"upload_budget_mb", c.UploadBudgetMB},
+		{"gpu_memory_cap_mb", c.GPUMemoryCapMB},
+		{"archive_prefetch", c.ArchivePrefetch},
+		{"tone_map_operator", c.ToneMapOperator},
+		{"upscale", c.Upscale},
+		{"sniff_content", c.SniffContent},
+		{"idle_throttle", c.IdleThrottle},
+		{"log_level", c.LogLevel},
+	} {
+		fmt.Fprintf(&b, "  %s: %v\n", kv[0], kv[1])
+	}
+
+	fmt.Fprintf(&b, "\nStack:\n%s", stack)
+	return b.String()
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// showCrashDialog shows msg with the first available desktop tool (osascript
+// on macOS, zenity or kdialog) and waits until it is dismissed. Without one,
+// the log line is all the user gets.
+func showCrashDialog(msg string) {
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("osascript"); err == nil {
+			exec.Command("osascript", "-e", "display alert \"nv crashed\" message "+strconv.Quote(msg)+" as critical").Run()
+			return
+		}
+	}
+	if _, err := exec.LookPath("zenity"); err == nil {
+		exec.Command("zenity", "--error", "--title=nv", "--no-markup", "--text="+msg).Run()
+		return
+	}
+	if _, err := exec.LookPath("kdialog"); err == nil {
+		exec.Command("kdialog", "--title", "nv", "--error", msg).Run()
+	}
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package main
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	mbOK        = 0x00000000
+	mbIconError = 0x00000010
+)
+
+var procMessageBoxW = modUser32.NewProc("MessageBoxW")
+
+// showCrashDialog shows msg in a message box and waits until it is dismissed.
+func showCrashDialog(msg string) {
+	text, err := windows.UTF16PtrFromString(msg)
+	if err != nil {
+		return
+	}
+	title, _ := windows.UTF16PtrFromString("nv")
+	procMessageBoxW.Call(0, uintptr(unsafe.Pointer(text)), uintptr(unsafe.Pointer(title)), mbOK|mbIconError)
+}
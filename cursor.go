@@ -0,0 +1,59 @@
+package main
+
+import (
+	"image"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// defaultCursorHideSeconds is the idle time before the cursor hides; it also
+// applies in fullscreen and slideshows when cursor_hide_seconds is 0.
+const defaultCursorHideSeconds = 3
+
+// cursorHideDelay returns how long the pointer has to rest before the
+// cursor hides, or 0 when it stays visible.
+func (g *Game) cursorHideDelay() time.Duration {
+	seconds := g.config.CursorHideSeconds
+	if seconds == 0 && (g.fullscreen || g.slideshowActive) {
+		seconds = defaultCursorHideSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// trackCursorIdle records pointer activity at (x, y), with pressed set while
+// a mouse button is held, and reports whether the cursor should be hidden at
+// now. The laser pointer always replaces the cursor; the on-screen controls
+// keep it visible.
+func (g *Game) trackCursorIdle(now time.Time, x, y int, pressed bool) bool {
+	if pointer := image.Pt(x, y); pointer != g.cursorPos || pressed || g.cursorMovedAt.IsZero() {
+		g.cursorPos = pointer
+		g.cursorMovedAt = now
+	}
+	if g.laserPointerVisible() {
+		return true
+	}
+	delay := g.cursorHideDelay()
+	return delay > 0 && !g.controlsVisible && now.Sub(g.cursorMovedAt) >= delay
+}
+
+// updateCursor hides the system cursor while the pointer rests or the laser
+// pointer is shown, and brings it back on movement.
+func (g *Game) updateCursor(now time.Time) {
+	x, y := ebiten.CursorPosition()
+	pressed := false
+	for b := ebiten.MouseButton0; b <= ebiten.MouseButtonMax; b++ {
+		pressed = pressed || ebiten.IsMouseButtonPressed(b)
+	}
+	hide := g.trackCursorIdle(now, x, y, pressed)
+	if hide == g.cursorHidden {
+		return
+	}
+	g.cursorHidden = hide
+	if hide {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	}
+	debugKV("ui", "cursor", "hidden", hide)
+}
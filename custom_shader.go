@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// customShaderTimeUniform matches a declaration of the Time uniform, which
+// makes the shader animated so the screen is redrawn every frame.
+var customShaderTimeUniform = regexp.MustCompile(`(?m)^\s*var\s+Time\s+float\b`)
+
+// loadCustomShader compiles the Kage shader at path. animated reports
+// whether it declares "var Time float".
+func loadCustomShader(path string) (shader *ebiten.Shader, animated bool, err error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	shader, err = ebiten.NewShader(src)
+	if err != nil {
+		return nil, false, err
+	}
+	return shader, customShaderTimeUniform.Match(src), nil
+}
+
+// loadCustomShaderFromConfig (re)compiles the configured shader, so editing
+// the file and toggling the shader off and on picks up the changes.
+func (g *Game) loadCustomShaderFromConfig() error {
+	if g.customShader != nil {
+		g.customShader.Deallocate()
+		g.customShader = nil
+	}
+	g.customShaderAnimated = false
+	if g.config.CustomShader == "" {
+		return nil
+	}
+	path := resolveScriptPaths(g.configPath, []string{g.config.CustomShader})[0]
+	shader, animated, err := loadCustomShader(path)
+	if err != nil {
+		warnKV("shader", "load_failed", "path", path, "error", err)
+		return err
+	}
+	g.customShader, g.customShaderAnimated = shader, animated
+	g.customShaderStart = time.Now()
+	infoKV("shader", "loaded", "path", path, "animated", animated)
+	return nil
+}
+
+// initCustomShader loads the configured shader at startup and turns it on.
+func (g *Game) initCustomShader() {
+	if g.config.CustomShader == "" {
+		return
+	}
+	if err := g.loadCustomShaderFromConfig(); err != nil {
+		g.showOverlayMessage(fmt.Sprintf("Shader error: %v", firstLine(err.Error())))
+		return
+	}
+	g.customShaderOn = true
+}
+
+func (g *Game) toggleCustomShader() {
+	if g.config.CustomShader == "" {
+		g.showOverlayMessage("No custom_shader configured")
+		return
+	}
+	if g.customShaderOn {
+		g.customShaderOn = false
+		g.showOverlayMessage("Custom shader: OFF")
+		return
+	}
+	if err := g.loadCustomShaderFromConfig(); err != nil {
+		g.showOverlayMessage(fmt.Sprintf("Shader error: %v", firstLine(err.Error())))
+		return
+	}
+	g.customShaderOn = true
+	g.showOverlayMessage("Custom shader: ON")
+}
+
+// customShaderRedraw reports whether an animated shader needs a new frame.
+func (g *Game) customShaderRedraw() bool {
+	return g.customShaderOn && g.customShader != nil && g.customShaderAnimated
+}
+
+// GetCustomShader returns the active post-processing shader and its
+// uniforms, or nil.
+func (g *Game) GetCustomShader() (*ebiten.Shader, map[string]any) {
+	if !g.customShaderOn || g.customShader == nil {
+		return nil, nil
+	}
+	return g.customShader, map[string]any{
+		"Time": float32(time.Since(g.customShaderStart).Seconds()),
+	}
+}
+
+// drawPostProcessed draws the frame into an offscreen buffer and copies it
+// to screen through the custom shader. Ebiten panics on uniforms of the
+// wrong type; such a shader is skipped until it is reloaded.
+func (r *Renderer) drawPostProcessed(screen *ebiten.Image, shader *ebiten.Shader, uniforms map[string]any) {
+	size := screen.Bounds().Size()
+	if r.postBuffer == nil || r.postBuffer.Bounds().Size() != size {
+		if r.postBuffer != nil {
+			r.postBuffer.Deallocate()
+		}
+		r.postBuffer = ebiten.NewImage(size.X, size.Y)
+	}
+	r.drawFrame(r.postBuffer)
+
+	defer func() {
+		if p := recover(); p != nil {
+			warnKV("shader", "draw_failed", "error", p, "fallback", "unprocessed")
+			r.failedShader = shader
+			screen.Clear()
+			screen.DrawImage(r.postBuffer, nil)
+		}
+	}()
+	screen.Clear()
+	op := &ebiten.DrawRectShaderOptions{Uniforms: uniforms}
+	op.Images[0] = r.postBuffer
+	screen.DrawRectShader(size.X, size.Y, shader, op)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// deleteConfirmWindow is how long a first press of delete_file waits for
+// the second one that deletes.
+const deleteConfirmWindow = 3 * time.Second
+
+// deletedPage is a deleted file held for undo until exit.
+type deletedPage struct {
+	path   ImagePath // Where the file was
+	held   string    // Where it is held
+	idx    int       // Its page index
+	marked bool      // Whether it was marked, restored with the file
+}
+
+// deleteCurrentFile deletes the current file with hard_delete on. The file
+// is moved to a holding folder, so undo can bring it back until nv quits
+// and the folder is removed. With confirm_delete a second press within
+// deleteConfirmWindow is needed.
+func (g *Game) deleteCurrentFile(now time.Time) {
+	if !g.config.HardDelete {
+		g.showOverlayMessage("Deleting is off (hard_delete)")
+		return
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	if p.ArchivePath != "" {
+		g.showOverlayMessage("Archive entries cannot be deleted")
+		return
+	}
+	if g.refusePastedPage(p, "deleted") {
+		return
+	}
+	if g.config.ConfirmDelete && (g.deleteArmed != p.Path || now.Sub(g.deleteArmedAt) > deleteConfirmWindow) {
+		g.deleteArmed, g.deleteArmedAt = p.Path, now
+		g.showOverlayMessage(fmt.Sprintf("Delete %s? Press again to confirm", filepath.Base(p.Path)))
+		return
+	}
+	g.deleteArmed = ""
+
+	d, err := g.deletePage(g.idx)
+	if err != nil {
+		errorKV("delete", "delete_failed", "path", p.Path, "error", err)
+		g.showOverlayMessage("Delete failed: " + err.Error())
+		return
+	}
+	g.pushPinnedUndo("delete "+filepath.Base(p.Path),
+		func() error { return g.restoreDeletedPage(d) },
+		func() error {
+			idx, err := g.pageIndexOf(d.path.Path)
+			if err != nil {
+				return err
+			}
+			redone, err := g.deletePage(idx)
+			if err != nil {
+				return err
+			}
+			*d = *redone
+			return nil
+		},
+	)
+	g.showOverlayMessage(fmt.Sprintf("Deleted %s (Ctrl+Z to undo)", filepath.Base(p.Path)))
+}
+
+// deletePage moves the file of page idx to the holding folder, removes the
+// page from the list and runs the file_deleted command for it.
+func (g *Game) deletePage(idx int) (*deletedPage, error) {
+	p, ok := g.imageManager.GetPath(idx)
+	if !ok {
+		return nil, errors.New("no such page")
+	}
+	held, err := g.holdDeletedFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	marked := g.marks[p.Path]
+	delete(g.marks, p.Path)
+	paths := slices.Delete(g.currentPaths(), idx, idx+1)
+	g.imageManager.SetPaths(paths)
+	g.idx = max(0, min(idx, len(paths)-1))
+	g.tempSingleMode = false
+	g.calculateDisplayContent()
+	infoKV("delete", "deleted", "path", p.Path, "held", held, "paths_count", len(paths))
+	g.notifyEventFilesDeleted([]ImagePath{p}, nil)
+	return &deletedPage{path: p, held: held, idx: idx, marked: marked}, nil
+}
+
+// holdDeletedFile moves path into the holding folder, creating the folder
+// on first use, and returns where it went.
+func (g *Game) holdDeletedFile(path string) (string, error) {
+	if g.deleteHoldDir == "" {
+		dir, err := os.MkdirTemp("", "nv-deleted-*")
+		if err != nil {
+			return "", err
+		}
+		g.deleteHoldDir = dir
+	}
+	// Numbered so files with the same name from different folders coexist
+	held := filepath.Join(g.deleteHoldDir, fmt.Sprintf("%d_%s", g.deleteHeld, filepath.Base(path)))
+	if err := moveFile(path, held); err != nil {
+		return "", err
+	}
+	g.deleteHeld++
+	return held, nil
+}
+
+// restoreDeletedPage moves a held file back to its folder and page.
+func (g *Game) restoreDeletedPage(d *deletedPage) error {
+	if _, err := os.Lstat(d.path.Path); err == nil {
+		return fmt.Errorf("%s exists again", filepath.Base(d.path.Path))
+	}
+	if err := moveFile(d.held, d.path.Path); err != nil {
+		return err
+	}
+	if d.marked {
+		if g.marks == nil {
+			g.marks = map[string]bool{}
+		}
+		g.marks[d.path.Path] = true
+	}
+	paths := g.currentPaths()
+	at := min(d.idx, len(paths))
+	paths = slices.Insert(paths, at, d.path)
+	g.loadFailure = nil
+	g.imageManager.SetPaths(paths)
+	g.idx = at
+	g.tempSingleMode = false
+	g.calculateDisplayContent()
+	infoKV("delete", "restored", "path", d.path.Path, "page", at+1)
+	return nil
+}
+
+// removeDeletedFiles deletes the holding folder for good on exit.
+func (g *Game) removeDeletedFiles() {
+	if g.deleteHoldDir == "" {
+		return
+	}
+	if err := os.RemoveAll(g.deleteHoldDir); err != nil {
+		warnKV("delete", "hold_cleanup_failed", "path", g.deleteHoldDir, "error", err)
+	}
+	debugKV("delete", "hold_removed", "files", g.deleteHeld)
+	g.deleteHoldDir = ""
+}
+
+// moveFile renames src to dst, copying across file systems when renaming
+// is not possible, e.g. from a USB drive to the temporary folder.
+func moveFile(src, dst string) error {
+	renameErr := os.Rename(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return renameErr
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.Join(renameErr, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// maxComicInfoSize bounds the ComicInfo.xml read from an archive.
+const maxComicInfoSize = 1 << 20
+
+// DirectionRule sets the reading direction of volumes whose path contains
+// Match, ignoring case, e.g. a manga directory or a "(manga)" file name tag.
+type DirectionRule struct {
+	Match     string `json:"match"`
+	Direction string `json:"direction"` // directionRTL or directionLTR
+}
+
+// validDirectionRules drops rules without a match or with an unknown
+// direction, returning them for the warning.
+func validDirectionRules(rules []DirectionRule) (valid []DirectionRule, dropped []string) {
+	valid = []DirectionRule{}
+	for _, r := range rules {
+		dir := strings.ToLower(strings.TrimSpace(r.Direction))
+		if strings.TrimSpace(r.Match) == "" || (dir != directionRTL && dir != directionLTR) {
+			dropped = append(dropped, fmt.Sprintf("%q: %q", r.Match, r.Direction))
+			continue
+		}
+		valid = append(valid, DirectionRule{Match: r.Match, Direction: dir})
+	}
+	return valid, dropped
+}
+
+// matchDirectionRule returns the direction of the first rule matching the
+// volume path.
+func matchDirectionRule(rules []DirectionRule, volume string) (rightToLeft, ok bool) {
+	path := strings.ToLower(filepath.ToSlash(volume))
+	for _, r := range rules {
+		if strings.Contains(path, strings.ToLower(filepath.ToSlash(r.Match))) {
+			return r.Direction == directionRTL, true
+		}
+	}
+	return false, false
+}
+
+// comicInfoDirection reads the Manga field of a ComicInfo.xml: "Yes" and
+// "YesAndRightToLeft" read right to left, "No" left to right.
+func comicInfoDirection(r io.Reader) (rightToLeft, ok bool) {
+	var info struct {
+		Manga string `xml:"Manga"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(r, maxComicInfoSize)).Decode(&info); err != nil {
+		return false, false
+	}
+	switch strings.ToLower(strings.TrimSpace(info.Manga)) {
+	case "yes", "yesandrighttoleft":
+		return true, true
+	case "no":
+		return false, true
+	}
+	return false, false
+}
+
+// archiveComicInfoDirection looks for ComicInfo.xml in a zip or 7z archive,
+// whose index makes finding it cheap; other archives are not searched.
+func archiveComicInfoDirection(archivePath string) (rightToLeft, ok bool) {
+	format, isArchive := detectArchive(archivePath)
+	if !isArchive {
+		return false, false
+	}
+	isComicInfo := func(name string) bool {
+		return strings.EqualFold(filepath.Base(filepath.FromSlash(name)), "ComicInfo.xml")
+	}
+	read := func(open func() (io.ReadCloser, error)) (bool, bool) {
+		rc, err := open()
+		if err != nil {
+			return false, false
+		}
+		defer rc.Close()
+		return comicInfoDirection(rc)
+	}
+
+	switch format.Kind {
+	case archiveZip:
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return false, false
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if isComicInfo(f.Name) {
+				return read(f.Open)
+			}
+		}
+	case archive7z:
+		r, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return false, false
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if isComicInfo(f.Name) {
+				return read(f.Open)
+			}
+		}
+	}
+	return false, false
+}
+
+// detectVolumeDirection guesses the reading direction of a volume from the
+// direction_rules, then the archive's ComicInfo.xml. source names the hint
+// for the overlay.
+func (g *Game) detectVolumeDirection(volume string) (rightToLeft bool, source string, ok bool) {
+	if rightToLeft, ok := matchDirectionRule(g.config.DirectionRules, volume); ok {
+		return rightToLeft, "direction rule", true
+	}
+	if rightToLeft, ok := archiveComicInfoDirection(volume); ok {
+		return rightToLeft, "ComicInfo.xml", true
+	}
+	return false, "", false
+}
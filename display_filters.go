@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// Strength change per keypress and upper bounds of the display filters
+	sharpenStep = 0.25
+	maxSharpen  = 3.0
+	denoiseStep = 0.1
+	maxDenoise  = 1.0
+
+	// Descreen blur radius (Gaussian sigma, in texels) per unit of
+	// downscaling, and its cap set by the shader's sampling window
+	descreenSigmaPerScale = 0.6
+	maxDescreenSigma      = 2.5
+)
+
+func clampSharpen(v float64) float64 {
+	return max(0, min(maxSharpen, v))
+}
+
+func clampDenoise(v float64) float64 {
+	return max(0, min(maxDenoise, v))
+}
+
+// displayFilterShader sharpens and denoises each texel from its 3x3
+// neighborhood, then interpolates bilinearly like FilterLinear.
+//
+// Denoising blends towards an edge-preserving weighted mean, where
+// neighbors of a similar color count more. Sharpening is an unsharp mask
+// against the plain box blur. Colors are premultiplied, so they are clamped
+// to alpha.
+//
+// Descreening replaces all of that with a Gaussian blur around the sample
+// point when the page is drawn smaller than its size, so halftone dots are
+// averaged out before they can alias into moire.
+const displayFilterShader = `//kage:unit pixels
+package main
+
+var Sharpen float
+var Denoise float
+var Descreen float
+
+func texel(p vec2) vec4 {
+	origin := imageSrc0Origin()
+	return imageSrc0UnsafeAt(clamp(p, origin+0.5, origin+imageSrc0Size()-0.5))
+}
+
+func filtered(p vec2) vec4 {
+	c := texel(p)
+	box := vec4(0)
+	mean := vec4(0)
+	weights := 0.0
+	for y := -1; y <= 1; y++ {
+		for x := -1; x <= 1; x++ {
+			n := texel(p + vec2(float(x), float(y)))
+			box += n
+			d := n.rgb - c.rgb
+			w := exp(-dot(d, d)*40.0)
+			mean += n * w
+			weights += w
+		}
+	}
+	base := mix(c, mean/weights, Denoise)
+	out := base + Sharpen*(base-box/9.0)
+	return vec4(clamp(out.rgb, vec3(0), vec3(c.a)), c.a)
+}
+
+func descreened(pos vec2) vec4 {
+	center := floor(pos) + 0.5
+	sum := vec4(0)
+	weights := 0.0
+	for y := -5; y <= 5; y++ {
+		for x := -5; x <= 5; x++ {
+			p := center + vec2(float(x), float(y))
+			d := p - pos
+			w := exp(-dot(d, d) / (2.0 * Descreen * Descreen))
+			sum += texel(p) * w
+			weights += w
+		}
+	}
+	return sum / weights
+}
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	if Descreen > 0 {
+		return descreened(srcPos)
+	}
+	p := srcPos - 0.5
+	f := fract(p)
+	p = floor(p) + 0.5
+	top := mix(filtered(p), filtered(p+vec2(1, 0)), f.x)
+	bottom := mix(filtered(p+vec2(0, 1)), filtered(p+vec2(1, 1)), f.x)
+	return mix(top, bottom, f.y)
+}
+`
+
+func (g *Game) setDisplayFilters(sharpen, denoise float64) {
+	g.sharpen = clampSharpen(sharpen)
+	g.denoise = clampDenoise(denoise)
+	g.showOverlayMessage(fmt.Sprintf("Sharpen: %.2f  Denoise: %.1f", g.sharpen, g.denoise))
+	debugKV("renderer", "display_filters", "sharpen", g.sharpen, "denoise", g.denoise)
+}
+
+func (g *Game) changeSharpen(delta float64) {
+	g.setDisplayFilters(g.sharpen+delta, g.denoise)
+}
+
+func (g *Game) changeDenoise(delta float64) {
+	g.setDisplayFilters(g.sharpen, g.denoise+delta)
+}
+
+// toggleDescreen switches the anti-moire filter for this session.
+func (g *Game) toggleDescreen() {
+	g.descreen = !g.descreen
+	if g.descreen {
+		g.showOverlayMessage("Descreen: ON (when zoomed out)")
+	} else {
+		g.showOverlayMessage("Descreen: OFF")
+	}
+	debugKV("renderer", "descreen", "enabled", g.descreen)
+}
+
+// GetDisplayFilters returns the sharpen and denoise strengths.
+func (g *Game) GetDisplayFilters() (float64, float64) {
+	return g.sharpen, g.denoise
+}
+
+func (g *Game) IsDescreening() bool {
+	return g.descreen
+}
+
+// descreenSigma returns the blur for textures drawn at scale, or 0 when
+// they are not shrunk.
+func descreenSigma(scale float64) float64 {
+	if scale <= 0 || scale >= 1 {
+		return 0
+	}
+	return min(maxDescreenSigma, descreenSigmaPerScale/scale)
+}
+
+// displayFilter returns the shader and its uniforms when a filter applies
+// to textures drawn at scale. A shader that fails to compile turns
+// filtering off for the session.
+func (r *Renderer) displayFilter(scale float64) (*ebiten.Shader, map[string]any) {
+	sharpen, denoise := r.renderState.GetDisplayFilters()
+	descreen := 0.0
+	if r.renderState.IsDescreening() {
+		descreen = descreenSigma(scale)
+	}
+	if sharpen == 0 && denoise == 0 && descreen == 0 {
+		return nil, nil
+	}
+	if r.filterShader == nil && !r.filterShaderFailed {
+		shader, err := ebiten.NewShader([]byte(displayFilterShader))
+		if err != nil {
+			warnKV("renderer", "filter_shader_failed", "error", err, "fallback", "unfiltered")
+			r.filterShaderFailed = true
+			return nil, nil
+		}
+		r.filterShader = shader
+	}
+	if r.filterShader == nil {
+		return nil, nil
+	}
+	return r.filterShader, map[string]any{
+		"Sharpen":  float32(sharpen),
+		"Denoise":  float32(denoise),
+		"Descreen": float32(descreen),
+	}
+}
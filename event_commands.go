@@ -0,0 +1,216 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Events that can run a configured shell command.
+const (
+	eventImageChanged = "image_changed"
+	eventSessionEnded = "session_ended"
+	eventFileDeleted  = "file_deleted"
+)
+
+var commandEvents = []string{eventImageChanged, eventSessionEnded, eventFileDeleted}
+
+// validEventCommands drops entries for unknown events and empty commands,
+// returning the unknown event names.
+func validEventCommands(commands map[string]string) (map[string]string, []string) {
+	valid := make(map[string]string, len(commands))
+	var unknown []string
+	for event, command := range commands {
+		if !slices.Contains(commandEvents, event) {
+			unknown = append(unknown, event)
+			continue
+		}
+		if strings.TrimSpace(command) != "" {
+			valid[event] = command
+		}
+	}
+	sort.Strings(unknown)
+	return valid, unknown
+}
+
+// eventCommandVars returns the template variables for an image. Page and
+// total are empty when the image is not part of the current list.
+func eventCommandVars(p ImagePath, page, total int) map[string]string {
+	name := filepath.Base(p.Path)
+	if p.ArchivePath != "" {
+		name = filepath.Base(strings.ReplaceAll(p.EntryPath, "\\", "/"))
+	}
+	vars := map[string]string{
+		"path":    p.Path,
+		"archive": p.ArchivePath,
+		"entry":   p.EntryPath,
+		"name":    name,
+		"page":    "",
+		"total":   "",
+	}
+	if page > 0 {
+		vars["page"] = strconv.Itoa(page)
+		vars["total"] = strconv.Itoa(total)
+	}
+	return vars
+}
+
+// expandEventCommand replaces {name} placeholders with shell-quoted values.
+// Unknown placeholders are left as they are and "{{" yields a literal brace.
+// On Windows a value is never spliced into the line: cmd.exe expands %VAR%
+// inside quotes, so a crafted file name could break out of them. The
+// placeholder becomes a delayed expansion of the NV_<NAME> variable that
+// shellCommand sets, which cmd substitutes after parsing the line.
+func expandEventCommand(command string, vars map[string]string, goos string) string {
+	var b strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] != '{' {
+			b.WriteByte(command[i])
+			continue
+		}
+		if strings.HasPrefix(command[i:], "{{") {
+			b.WriteByte('{')
+			i++
+			continue
+		}
+		end := strings.IndexByte(command[i:], '}')
+		if end < 0 {
+			b.WriteString(command[i:])
+			break
+		}
+		name := command[i+1 : i+end]
+		value, ok := vars[name]
+		switch {
+		case !ok:
+			b.WriteString(command[i : i+end+1])
+		case goos == "windows" && value == "":
+			b.WriteString(`""`)
+		case goos == "windows":
+			b.WriteString(`"!` + commandEnvName(name) + `!"`)
+		default:
+			b.WriteString(shellQuote(value))
+		}
+		i += end
+	}
+	return b.String()
+}
+
+// shellQuote quotes s as a single argument for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// commandEnvName is the environment variable carrying a template value.
+func commandEnvName(name string) string {
+	return "NV_" + strings.ToUpper(name)
+}
+
+// commandEnv returns the template values as NV_<NAME> variables, sorted.
+func commandEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, commandEnvName(name)+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// shellCommand wraps a command line expanded from vars for the platform
+// shell. The values are also passed as NV_<NAME> environment variables;
+// cmd.exe runs with delayed expansion to read them.
+func shellCommand(line string, vars map[string]string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd")
+		setRawCommandLine(cmd, "/V:ON /C "+line)
+	} else {
+		cmd = exec.Command("/bin/sh", "-c", line)
+	}
+	cmd.Env = append(os.Environ(), commandEnv(vars)...)
+	return cmd
+}
+
+// runEventCommand starts the command configured for event, if any, without
+// waiting for it. The viewer never blocks on hooks; failures are logged.
+func runEventCommand(commands map[string]string, event string, vars map[string]string) {
+	command, ok := commands[event]
+	if !ok {
+		return
+	}
+	line := expandEventCommand(command, vars, runtime.GOOS)
+	cmd := shellCommand(line, vars)
+	if err := cmd.Start(); err != nil {
+		warnKV("hooks", "command_start_failed", "event", event, "command", line, "error", err)
+		return
+	}
+	debugKV("hooks", "command_started", "event", event, "command", line)
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			warnKV("hooks", "command_failed", "event", event, "command", line, "error", err)
+		}
+	}()
+}
+
+// notifyEventImageChanged runs the image_changed command when the current
+// page differs from the one last reported.
+func (g *Game) notifyEventImageChanged() {
+	if _, ok := g.config.EventCommands[eventImageChanged]; !ok {
+		return
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok || p.Path == g.eventLastPath {
+		return
+	}
+	g.eventLastPath = p.Path
+	if p.Transient {
+		return // A pasted page is no file the command could act on
+	}
+	runEventCommand(g.config.EventCommands, eventImageChanged, eventCommandVars(p, g.idx+1, g.imageManager.GetPathsCount()))
+}
+
+// notifyEventSessionEnded runs the session_ended command with the page the
+// session ended on.
+func (g *Game) notifyEventSessionEnded() {
+	if _, ok := g.config.EventCommands[eventSessionEnded]; !ok {
+		return
+	}
+	vars := eventCommandVars(ImagePath{}, 0, 0)
+	if p, ok := g.imageManager.GetPath(g.idx); ok {
+		vars = eventCommandVars(p, g.idx+1, g.imageManager.GetPathsCount())
+	}
+	runEventCommand(g.config.EventCommands, eventSessionEnded, vars)
+}
+
+// notifyEventFilesDeleted runs the file_deleted command once for every
+// image of before that is missing from after.
+func (g *Game) notifyEventFilesDeleted(before, after []ImagePath) {
+	if _, ok := g.config.EventCommands[eventFileDeleted]; !ok {
+		return
+	}
+	for _, p := range removedImagePaths(before, after) {
+		if p.Transient {
+			continue
+		}
+		runEventCommand(g.config.EventCommands, eventFileDeleted, eventCommandVars(p, 0, 0))
+	}
+}
+
+// removedImagePaths returns the images of before that are not in after.
+func removedImagePaths(before, after []ImagePath) []ImagePath {
+	present := make(map[ImagePath]bool, len(after))
+	for _, p := range after {
+		present[p] = true
+	}
+	var removed []ImagePath
+	for _, p := range before {
+		if !present[p] {
+			removed = append(removed, p)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "os/exec"
+
+// setRawCommandLine is only needed for cmd.exe.
+func setRawCommandLine(cmd *exec.Cmd, line string) {
+	cmd.Args = append(cmd.Args, line)
+}
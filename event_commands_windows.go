@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setRawCommandLine passes line to cmd unescaped; cmd.exe does its own
+// parsing and would misread Go's argument quoting.
+func setRawCommandLine(cmd *exec.Cmd, line string) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: "cmd " + line}
+}
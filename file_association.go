@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// fileAssociation is a file type nv registers itself for. Comic archives are
+// made the default handler; everything else only gains an "Open with" entry,
+// so registering does not take over photos or generic archives.
+type fileAssociation struct {
+	Ext     string
+	MIME    string
+	Default bool
+}
+
+var fileAssociations = []fileAssociation{
+	{".png", "image/png", false},
+	{".jpg", "image/jpeg", false},
+	{".jpeg", "image/jpeg", false},
+	{".webp", "image/webp", false},
+	{".bmp", "image/bmp", false},
+	{".gif", "image/gif", false},
+	{".psd", "image/vnd.adobe.photoshop", false},
+	{".xcf", "image/x-xcf", false},
+	{".hdr", "image/vnd.radiance", false},
+	{".exr", "image/x-exr", false},
+	{".tif", "image/tiff", false},
+	{".tiff", "image/tiff", false},
+	{".zip", "application/zip", false},
+	{".rar", "application/vnd.rar", false},
+	{".7z", "application/x-7z-compressed", false},
+	{".tar", "application/x-tar", false},
+	{".cbz", "application/vnd.comicbook+zip", true},
+	{".cbr", "application/vnd.comicbook-rar", true},
+	{".cb7", "application/x-cb7", true},
+	{".cbt", "application/x-cbt", true},
+}
+
+// desktopFileName is the desktop entry written on Linux.
+const desktopFileName = "nv.desktop"
+
+// runFileAssociation runs --register or --unregister and returns the exit
+// code.
+func runFileAssociation(register bool) int {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		errorKV("association", "executable_not_found", "error", err)
+		return 1
+	}
+
+	action, done := "register", "Registered"
+	if !register {
+		action, done = "unregister", "Unregistered"
+	}
+	if err := applyFileAssociations(exe, register); err != nil {
+		errorKV("association", action+"_failed", "path", exe, "error", err)
+		return 1
+	}
+	infoKV("association", action+"ed", "path", exe)
+	fmt.Printf("%s %s for images and comic archives\n", done, exe)
+	return 0
+}
+
+// desktopEntry is the Linux desktop entry launching exe for every
+// associated MIME type.
+func desktopEntry(exe string) string {
+	var mimes []string
+	for _, a := range fileAssociations {
+		if !slices.Contains(mimes, a.MIME) {
+			mimes = append(mimes, a.MIME)
+		}
+	}
+	return fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=nv
+GenericName=Image Viewer
+Comment=Nekomimist's Image Viewer for images and comic archives
+Exec=%s %%F
+Icon=nv
+Terminal=false
+Categories=Graphics;Viewer;
+MimeType=%s;
+`, desktopExecQuote(exe), strings.Join(mimes, ";"))
+}
+
+// desktopExecQuote quotes path for an Exec line when it needs it.
+func desktopExecQuote(path string) string {
+	if !strings.ContainsAny(path, " \t\"'\\$`") {
+		return path
+	}
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`)
+	return `"` + r.Replace(path) + `"`
+}
+
+// withoutDesktopEntry removes name from every association in a mimeapps.list
+// and drops associations left empty.
+func withoutDesktopEntry(mimeapps, name string) string {
+	lines := strings.SplitAfter(mimeapps, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r\n"), "=")
+		if !ok || strings.HasPrefix(key, "[") {
+			b.WriteString(line)
+			continue
+		}
+		entries := strings.Split(value, ";")
+		if !slices.Contains(entries, name) {
+			b.WriteString(line)
+			continue
+		}
+		var kept []string
+		for _, entry := range entries {
+			if entry != "" && entry != name {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		b.WriteString(key + "=" + strings.Join(kept, ";") + ";")
+		if strings.HasSuffix(line, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// macOSAssociationHelp explains how to associate file types on macOS, where a
+// bare binary cannot register itself: it has to be wrapped in an app bundle
+// whose Info.plist declares the document types.
+func macOSAssociationHelp(exe string) string {
+	var exts []string
+	for _, a := range fileAssociations {
+		exts = append(exts, "\t\t\t\t<string>"+strings.TrimPrefix(a.Ext, ".")+"</string>")
+	}
+	return fmt.Sprintf(`macOS associates file types with app bundles, not with plain binaries.
+To open images and comic archives with nv from Finder:
+
+1. Create nv.app/Contents/MacOS and copy %s there as nv.
+2. Save the following as nv.app/Contents/Info.plist:
+
+<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleExecutable</key>
+	<string>nv</string>
+	<key>CFBundleIdentifier</key>
+	<string>io.github.nekomimist.nv</string>
+	<key>CFBundleName</key>
+	<string>nv</string>
+	<key>CFBundlePackageType</key>
+	<string>APPL</string>
+	<key>CFBundleDocumentTypes</key>
+	<array>
+		<dict>
+			<key>CFBundleTypeName</key>
+			<string>Images and comic archives</string>
+			<key>CFBundleTypeRole</key>
+			<string>Viewer</string>
+			<key>CFBundleTypeExtensions</key>
+			<array>
+%s
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+
+3. Move nv.app to /Applications, then choose it in a file's Get Info >
+   "Open with" and click "Change All...".
+`, filepath.Base(exe), strings.Join(exts, "\n"))
+}
@@ -0,0 +1,101 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// applyFileAssociations installs or removes the desktop entry and icon in
+// the user's data directory and makes nv the default for comic archives.
+// On macOS it prints how to set up an app bundle instead.
+func applyFileAssociations(exe string, register bool) error {
+	if runtime.GOOS == "darwin" {
+		if register {
+			fmt.Print(macOSAssociationHelp(exe))
+		} else {
+			fmt.Println("Remove nv.app from /Applications to undo the file associations.")
+		}
+		return nil
+	}
+
+	dataDir, configDir, err := xdgUserDirs()
+	if err != nil {
+		return err
+	}
+	appsDir := filepath.Join(dataDir, "applications")
+	desktopPath := filepath.Join(appsDir, desktopFileName)
+	iconPath := filepath.Join(dataDir, "icons", "hicolor", "48x48", "apps", "nv.png")
+	mimeappsPath := filepath.Join(configDir, "mimeapps.list")
+
+	if !register {
+		for _, path := range []string{desktopPath, iconPath} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if data, err := os.ReadFile(mimeappsPath); err == nil {
+			if err := os.WriteFile(mimeappsPath, []byte(withoutDesktopEntry(string(data), desktopFileName)), 0o644); err != nil {
+				return err
+			}
+		}
+		runDesktopTool("update-desktop-database", appsDir)
+		return nil
+	}
+
+	if err := os.MkdirAll(appsDir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(desktopPath, []byte(desktopEntry(exe)), 0o644); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(iconPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(iconPath, icon48, 0o644); err != nil {
+		return err
+	}
+	runDesktopTool("update-desktop-database", appsDir)
+	for _, a := range fileAssociations {
+		if a.Default {
+			runDesktopTool("xdg-mime", "default", desktopFileName, a.MIME)
+		}
+	}
+	return nil
+}
+
+// xdgUserDirs returns $XDG_DATA_HOME and $XDG_CONFIG_HOME with their
+// defaults under the home directory.
+func xdgUserDirs() (string, string, error) {
+	dataDir, configDir := os.Getenv("XDG_DATA_HOME"), os.Getenv("XDG_CONFIG_HOME")
+	if dataDir == "" || configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		if dataDir == "" {
+			dataDir = filepath.Join(home, ".local", "share")
+		}
+		if configDir == "" {
+			configDir = filepath.Join(home, ".config")
+		}
+	}
+	return dataDir, configDir, nil
+}
+
+// runDesktopTool runs an optional desktop integration tool; a missing or
+// failing tool only leaves caches to be refreshed later.
+func runDesktopTool(name string, args ...string) {
+	if _, err := exec.LookPath(name); err != nil {
+		debugKV("association", "tool_missing", "tool", name)
+		return
+	}
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		warnKV("association", "tool_failed", "tool", name, "args", args, "error", err, "output", string(out))
+	}
+}
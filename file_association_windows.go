@@ -0,0 +1,142 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	// ProgIDs for images and comic archives under HKCU\Software\Classes
+	progIDImage = "nv.Image"
+	progIDComic = "nv.ComicArchive"
+
+	shcneAssocChanged = 0x08000000
+	shcnfIDList       = 0x0000
+)
+
+var procSHChangeNotify = modShell32.NewProc("SHChangeNotify")
+
+// applyFileAssociations registers nv for the current user: two ProgIDs, an
+// "Open with" entry for every extension, and the default handler for comic
+// archives that have none yet. Windows does not let programs override a
+// default the user picked; that stays a choice in Settings.
+func applyFileAssociations(exe string, register bool) error {
+	classes, err := registry.OpenKey(registry.CURRENT_USER, `Software\Classes`, registry.ALL_ACCESS)
+	if err != nil {
+		return err
+	}
+	defer classes.Close()
+
+	if register {
+		err = registerAssociations(classes, exe)
+	} else {
+		err = unregisterAssociations(classes)
+	}
+	procSHChangeNotify.Call(shcneAssocChanged, shcnfIDList, 0, 0)
+	return err
+}
+
+func progIDFor(a fileAssociation) string {
+	if a.Default {
+		return progIDComic
+	}
+	return progIDImage
+}
+
+func registerAssociations(classes registry.Key, exe string) error {
+	command := fmt.Sprintf(`"%s" "%%1"`, exe)
+	for progID, name := range map[string]string{progIDImage: "Image (nv)", progIDComic: "Comic archive (nv)"} {
+		if err := setRegistryString(classes, progID, "", name); err != nil {
+			return err
+		}
+		if err := setRegistryString(classes, progID+`\DefaultIcon`, "", exe+",0"); err != nil {
+			return err
+		}
+		if err := setRegistryString(classes, progID+`\shell\open\command`, "", command); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range fileAssociations {
+		progID := progIDFor(a)
+		if err := setRegistryString(classes, a.Ext+`\OpenWithProgids`, progID, ""); err != nil {
+			return err
+		}
+		if !a.Default {
+			continue
+		}
+		if current, err := getRegistryString(classes, a.Ext, ""); err == nil && current != "" && current != progID {
+			continue
+		}
+		if err := setRegistryString(classes, a.Ext, "", progID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unregisterAssociations(classes registry.Key) error {
+	for _, a := range fileAssociations {
+		progID := progIDFor(a)
+		if k, err := registry.OpenKey(classes, a.Ext+`\OpenWithProgids`, registry.SET_VALUE); err == nil {
+			k.DeleteValue(progID)
+			k.Close()
+		}
+		if current, err := getRegistryString(classes, a.Ext, ""); err == nil && current == progID {
+			if k, err := registry.OpenKey(classes, a.Ext, registry.SET_VALUE); err == nil {
+				k.DeleteValue("")
+				k.Close()
+			}
+		}
+	}
+	for _, progID := range []string{progIDImage, progIDComic} {
+		if err := deleteRegistryTree(classes, progID); err != nil && !errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+			return err
+		}
+	}
+	return nil
+}
+
+func setRegistryString(parent registry.Key, path, name, value string) error {
+	k, _, err := registry.CreateKey(parent, path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringValue(name, value)
+}
+
+func getRegistryString(parent registry.Key, path, name string) (string, error) {
+	k, err := registry.OpenKey(parent, path, registry.QUERY_VALUE)
+	if err != nil {
+		return "", err
+	}
+	defer k.Close()
+	value, _, err := k.GetStringValue(name)
+	return value, err
+}
+
+// deleteRegistryTree deletes path and its subkeys; registry.DeleteKey only
+// removes empty keys.
+func deleteRegistryTree(parent registry.Key, path string) error {
+	k, err := registry.OpenKey(parent, path, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return err
+	}
+	subkeys, err := k.ReadSubKeyNames(-1)
+	k.Close()
+	if err != nil {
+		return err
+	}
+	for _, sub := range subkeys {
+		if err := deleteRegistryTree(parent, path+`\`+sub); err != nil {
+			return err
+		}
+	}
+	return registry.DeleteKey(parent, path)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// fileDialogKind selects what the native open dialog lets the user pick.
+type fileDialogKind int
+
+const (
+	fileDialogFiles fileDialogKind = iota
+	fileDialogDirectory
+)
+
+func (k fileDialogKind) String() string {
+	switch k {
+	case fileDialogFiles:
+		return "files"
+	case fileDialogDirectory:
+		return "directory"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	errFileDialogCanceled    = errors.New("open dialog canceled")
+	errFileDialogUnavailable = errors.New("no native file dialog available")
+)
+
+// openDialogFilePatterns lists the glob patterns offered by the file chooser filter.
+var openDialogFilePatterns = []string{
+	"*.png", "*.jpg", "*.jpeg", "*.webp", "*.bmp", "*.gif", "*.psd", "*.xcf", "*.hdr", "*.exr", "*.tif", "*.tiff",
+	"*.zip", "*.rar", "*.7z", "*.cbz", "*.cbr", "*.cb7", "*.cbt",
+	"*.tar", "*.tar.gz", "*.tgz", "*.tar.bz2", "*.tbz2", "*.tar.xz", "*.txz", "*.tar.zst", "*.tzst",
+}
+
+func openDialogTitle(kind fileDialogKind) string {
+	if kind == fileDialogDirectory {
+		return "Open Directory - nv"
+	}
+	return "Open Images or Archives - nv"
+}
+
+// splitDialogOutput turns newline-separated chooser output into a path list.
+func splitDialogOutput(output string) []string {
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths
+}
+
+// osascriptDialogScript returns the AppleScript lines for the macOS chooser.
+// Files allow several selections like zenity and kdialog; the chosen list is
+// printed one POSIX path per line for splitDialogOutput.
+func osascriptDialogScript(kind fileDialogKind) []string {
+	prompt := `"` + openDialogTitle(kind) + `"`
+	if kind == fileDialogDirectory {
+		return []string{`POSIX path of (choose folder with prompt ` + prompt + `)`}
+	}
+	return []string{
+		`set picked to choose file with prompt ` + prompt + ` with multiple selections allowed`,
+		`set out to ""`,
+		`repeat with f in picked`,
+		`set out to out & POSIX path of f & linefeed`,
+		`end repeat`,
+		`return out`,
+	}
+}
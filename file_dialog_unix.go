@@ -0,0 +1,83 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// showOpenDialog runs the first available desktop chooser (zenity, kdialog,
+// or osascript on macOS) and returns the selected paths.
+func showOpenDialog(kind fileDialogKind) ([]string, error) {
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("osascript"); err == nil {
+			return runOsascriptDialog(kind)
+		}
+	}
+	if _, err := exec.LookPath("zenity"); err == nil {
+		return runZenityDialog(kind)
+	}
+	if _, err := exec.LookPath("kdialog"); err == nil {
+		return runKdialogDialog(kind)
+	}
+	return nil, errFileDialogUnavailable
+}
+
+func runZenityDialog(kind fileDialogKind) ([]string, error) {
+	args := []string{"--file-selection", "--title=" + openDialogTitle(kind)}
+	if kind == fileDialogDirectory {
+		args = append(args, "--directory")
+	} else {
+		args = append(args,
+			"--multiple",
+			"--separator=\n",
+			"--file-filter=Images and archives | "+strings.Join(openDialogFilePatterns, " "),
+			"--file-filter=All files | *",
+		)
+	}
+	return runDialogCommand("zenity", args...)
+}
+
+func runKdialogDialog(kind fileDialogKind) ([]string, error) {
+	args := []string{"--title", openDialogTitle(kind)}
+	if kind == fileDialogDirectory {
+		args = append(args, "--getexistingdirectory", ".")
+	} else {
+		args = append(args,
+			"--getopenfilename", ".",
+			strings.Join(openDialogFilePatterns, " ")+"|Images and archives",
+			"--multiple", "--separate-output",
+		)
+	}
+	return runDialogCommand("kdialog", args...)
+}
+
+func runOsascriptDialog(kind fileDialogKind) ([]string, error) {
+	var args []string
+	for _, line := range osascriptDialogScript(kind) {
+		args = append(args, "-e", line)
+	}
+	return runDialogCommand("osascript", args...)
+}
+
+// runDialogCommand treats a non-zero exit status as cancellation, which is
+// how zenity, kdialog, and osascript all report a dismissed chooser.
+func runDialogCommand(name string, args ...string) ([]string, error) {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, errFileDialogCanceled
+		}
+		return nil, err
+	}
+
+	paths := splitDialogOutput(string(out))
+	if len(paths) == 0 {
+		return nil, errFileDialogCanceled
+	}
+	return paths, nil
+}
@@ -0,0 +1,198 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	ofnAllowMultiSelect = 0x00000200
+	ofnPathMustExist    = 0x00000800
+	ofnFileMustExist    = 0x00001000
+	ofnExplorer         = 0x00080000
+	ofnNoChangeDir      = 0x00000008
+
+	bifReturnOnlyFSDirs = 0x00000001
+	bifNewDialogStyle   = 0x00000040
+
+	openDialogBufferSize = 64 * 1024
+)
+
+var (
+	modComdlg32 = windows.NewLazySystemDLL("comdlg32.dll")
+	modShell32  = windows.NewLazySystemDLL("shell32.dll")
+	modUser32   = windows.NewLazySystemDLL("user32.dll")
+	modOle32    = windows.NewLazySystemDLL("ole32.dll")
+
+	procGetOpenFileNameW     = modComdlg32.NewProc("GetOpenFileNameW")
+	procCommDlgExtendedError = modComdlg32.NewProc("CommDlgExtendedError")
+	procSHBrowseForFolderW   = modShell32.NewProc("SHBrowseForFolderW")
+	procSHGetPathFromIDListW = modShell32.NewProc("SHGetPathFromIDListW")
+	procGetForegroundWindow  = modUser32.NewProc("GetForegroundWindow")
+	procCoTaskMemFree        = modOle32.NewProc("CoTaskMemFree")
+)
+
+// openFileNameW mirrors the Win32 OPENFILENAMEW structure.
+type openFileNameW struct {
+	structSize    uint32
+	owner         uintptr
+	instance      uintptr
+	filter        *uint16
+	customFilter  *uint16
+	maxCustFilter uint32
+	filterIndex   uint32
+	file          *uint16
+	maxFile       uint32
+	fileTitle     *uint16
+	maxFileTitle  uint32
+	initialDir    *uint16
+	title         *uint16
+	flags         uint32
+	fileOffset    uint16
+	fileExtension uint16
+	defExt        *uint16
+	custData      uintptr
+	hook          uintptr
+	templateName  *uint16
+	reserved      unsafe.Pointer
+	reservedDword uint32
+	flagsEx       uint32
+}
+
+// browseInfoW mirrors the Win32 BROWSEINFOW structure.
+type browseInfoW struct {
+	owner       uintptr
+	root        uintptr
+	displayName *uint16
+	title       *uint16
+	flags       uint32
+	callback    uintptr
+	param       uintptr
+	image       int32
+}
+
+// showOpenDialog shows the common file dialog or folder browser. The dialog
+// runs on a locked OS thread with COM initialized because it pumps its own
+// message loop outside Ebiten's main thread.
+func showOpenDialog(kind fileDialogKind) ([]string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err == nil {
+		defer windows.CoUninitialize()
+	}
+
+	owner, _, _ := procGetForegroundWindow.Call()
+	if kind == fileDialogDirectory {
+		return showFolderBrowser(owner)
+	}
+	return showFileOpenDialog(owner)
+}
+
+func showFileOpenDialog(owner uintptr) ([]string, error) {
+	filter := utf16DoubleNullList(
+		"Images and archives", strings.Join(openDialogFilePatterns, ";"),
+		"All files", "*.*",
+	)
+	title, err := windows.UTF16PtrFromString(openDialogTitle(fileDialogFiles))
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]uint16, openDialogBufferSize)
+	ofn := openFileNameW{
+		owner:       owner,
+		filter:      &filter[0],
+		filterIndex: 1,
+		file:        &buf[0],
+		maxFile:     uint32(len(buf)),
+		title:       title,
+		flags:       ofnAllowMultiSelect | ofnExplorer | ofnFileMustExist | ofnPathMustExist | ofnNoChangeDir,
+	}
+	ofn.structSize = uint32(unsafe.Sizeof(ofn))
+
+	ret, _, _ := procGetOpenFileNameW.Call(uintptr(unsafe.Pointer(&ofn)))
+	if ret == 0 {
+		code, _, _ := procCommDlgExtendedError.Call()
+		if code == 0 {
+			return nil, errFileDialogCanceled
+		}
+		return nil, fmt.Errorf("GetOpenFileNameW failed: 0x%x", code)
+	}
+
+	return parseMultiSelectBuffer(buf), nil
+}
+
+func showFolderBrowser(owner uintptr) ([]string, error) {
+	title, err := windows.UTF16PtrFromString(openDialogTitle(fileDialogDirectory))
+	if err != nil {
+		return nil, err
+	}
+
+	displayName := make([]uint16, windows.MAX_PATH)
+	info := browseInfoW{
+		owner:       owner,
+		displayName: &displayName[0],
+		title:       title,
+		flags:       bifReturnOnlyFSDirs | bifNewDialogStyle,
+	}
+
+	pidl, _, _ := procSHBrowseForFolderW.Call(uintptr(unsafe.Pointer(&info)))
+	if pidl == 0 {
+		return nil, errFileDialogCanceled
+	}
+	defer procCoTaskMemFree.Call(pidl)
+
+	path := make([]uint16, windows.MAX_PATH)
+	ok, _, _ := procSHGetPathFromIDListW.Call(pidl, uintptr(unsafe.Pointer(&path[0])))
+	if ok == 0 {
+		return nil, fmt.Errorf("selected folder is not a file system path")
+	}
+	return []string{windows.UTF16ToString(path)}, nil
+}
+
+// parseMultiSelectBuffer decodes the explorer-style result buffer: either a
+// single full path, or a directory followed by file names, each
+// NUL-terminated with an extra NUL at the end.
+func parseMultiSelectBuffer(buf []uint16) []string {
+	var parts []string
+	start := 0
+	for i, c := range buf {
+		if c != 0 {
+			continue
+		}
+		if i == start {
+			break
+		}
+		parts = append(parts, windows.UTF16ToString(buf[start:i]))
+		start = i + 1
+	}
+
+	if len(parts) <= 1 {
+		return parts
+	}
+
+	dir := parts[0]
+	paths := make([]string, 0, len(parts)-1)
+	for _, name := range parts[1:] {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths
+}
+
+func utf16DoubleNullList(items ...string) []uint16 {
+	var list []uint16
+	for _, item := range items {
+		list = append(list, utf16.Encode([]rune(item))...)
+		list = append(list, 0)
+	}
+	return append(list, 0)
+}
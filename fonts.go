@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-text/typesetting/font"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// mplus1pRegularTTF is the M+ 1p font (fonts/LICENSE.md), which covers
+// Japanese.
+//
+//go:embed fonts/mplus-1p-regular.ttf
+var mplus1pRegularTTF []byte
+
+// systemFallbackFonts are fonts tried, when installed, for characters the
+// embedded fonts lack: symbols, emoji and Chinese or Korean text. Glyphs are
+// drawn from outlines, so emoji show in one color, and fonts with bitmap
+// glyphs only (Noto Color Emoji, Apple Color Emoji) are left out.
+func systemFallbackFonts() []string {
+	switch runtime.GOOS {
+	case "windows":
+		dir := filepath.Join(os.Getenv("WINDIR"), "Fonts")
+		if os.Getenv("WINDIR") == "" {
+			dir = `C:\Windows\Fonts`
+		}
+		return []string{
+			filepath.Join(dir, "seguisym.ttf"),
+			filepath.Join(dir, "seguiemj.ttf"),
+			filepath.Join(dir, "msyh.ttc"),
+			filepath.Join(dir, "malgun.ttf"),
+		}
+	case "darwin":
+		return []string{
+			"/System/Library/Fonts/Apple Symbols.ttf",
+			"/System/Library/Fonts/PingFang.ttc",
+			"/System/Library/Fonts/AppleSDGothicNeo.ttc",
+		}
+	default:
+		return []string{
+			"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+			"/usr/share/fonts/TTF/DejaVuSans.ttf",
+			"/usr/share/fonts/truetype/noto/NotoSansSymbols2-Regular.ttf",
+			"/usr/share/fonts/noto/NotoSansSymbols2-Regular.ttf",
+			"/usr/share/fonts/truetype/noto/NotoEmoji-Regular.ttf",
+			"/usr/share/fonts/noto/NotoEmoji-Regular.ttf",
+			"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+			"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+		}
+	}
+}
+
+// fallbackFontSources returns the fonts used, in order, for characters the
+// Go font lacks: the embedded M+ font for Japanese, then the installed
+// systemFallbackFonts. They are loaded on first use.
+var fallbackFontSources = sync.OnceValue(func() []*text.GoTextFaceSource {
+	var sources []*text.GoTextFaceSource
+	if s, err := text.NewGoTextFaceSource(bytes.NewReader(mplus1pRegularTTF)); err == nil {
+		sources = append(sources, s)
+	} else {
+		warnKV("renderer", "fallback_font_failed", "font", "mplus-1p", "error", err)
+	}
+	for _, path := range systemFallbackFonts() {
+		s, err := loadFontFile(path)
+		if err != nil {
+			continue
+		}
+		sources = append(sources, s)
+		debugKV("renderer", "fallback_font_loaded", "path", path)
+	}
+	return sources
+})
+
+// glyphFontSources are all overlay fonts, the Go font first, for checking
+// which characters can be drawn.
+var glyphFontSources = sync.OnceValue(func() []*text.GoTextFaceSource {
+	s, err := text.NewGoTextFaceSource(bytes.NewReader(goregular.TTF))
+	if err != nil {
+		return fallbackFontSources()
+	}
+	return append([]*text.GoTextFaceSource{s}, fallbackFontSources()...)
+})
+
+// loadFontFile reads a font file; collections (.ttc) use their first font.
+func loadFontFile(path string) (*text.GoTextFaceSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".ttc") {
+		return text.NewGoTextFaceSource(bytes.NewReader(data))
+	}
+	sources, err := text.NewGoTextFaceSourcesFromCollection(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("empty font collection %s", path)
+	}
+	return sources[0], nil
+}
+
+// newTextFace returns src at size, falling back to fallbackFontSources for
+// characters src lacks.
+func newTextFace(src *text.GoTextFaceSource, size float64) text.Face {
+	faces := []text.Face{&text.GoTextFace{Source: src, Size: size}}
+	for _, fallback := range fallbackFontSources() {
+		faces = append(faces, &text.GoTextFace{Source: fallback, Size: size})
+	}
+	if len(faces) == 1 {
+		return faces[0]
+	}
+	face, err := text.NewMultiFace(faces...)
+	if err != nil {
+		return faces[0]
+	}
+	return face
+}
+
+// glyphCache remembers hasGlyph per rune; readableText runs for every
+// overlay text on every frame, and the fonts never change once loaded.
+var glyphCache sync.Map // rune -> bool
+
+// hasGlyph reports whether any overlay font can draw r.
+func hasGlyph(r rune) bool {
+	if found, ok := glyphCache.Load(r); ok {
+		return found.(bool)
+	}
+	found := false
+	for _, src := range glyphFontSources() {
+		if f, ok := src.UnsafeInternal().(*font.Face); ok {
+			if _, ok := f.NominalGlyph(r); ok {
+				found = true
+				break
+			}
+		}
+	}
+	glyphCache.Store(r, found)
+	return found
+}
+
+// readableText replaces characters no overlay font can draw with their code
+// point, e.g. "[U+1F600]", so they never show up as empty boxes. Control
+// characters and joiners are left alone.
+func readableText(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x20 || r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F) || hasGlyph(r) {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, "[U+%04X]", r)
+	}
+	return b.String()
+}
+
+// measureText measures s as DrawText draws it.
+func measureText(s string, face text.Face) (float64, float64) {
+	return text.Measure(readableText(s), face, 0)
+}
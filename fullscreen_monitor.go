@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// fullscreenMonitorIndex returns the index into the system's monitor list of
+// the fullscreen_monitor setting, or -1 for the monitor the window is on,
+// which is also used when that monitor is no longer connected.
+func fullscreenMonitorIndex(setting, count int) int {
+	if setting < 1 || setting > count {
+		return -1
+	}
+	return setting - 1
+}
+
+// nextFullscreenMonitor steps the fullscreen_monitor setting through the
+// window's monitor (0) and each of count monitors.
+func nextFullscreenMonitor(setting, count int) int {
+	if setting < 0 || setting >= count {
+		return 0
+	}
+	return setting + 1
+}
+
+// fullscreenMonitor returns the monitor fullscreen is shown on.
+func (g *Game) fullscreenMonitor() *ebiten.MonitorType {
+	monitors := ebiten.AppendMonitors(nil)
+	if i := fullscreenMonitorIndex(g.config.FullscreenMonitor, len(monitors)); i >= 0 {
+		return monitors[i]
+	}
+	return ebiten.Monitor()
+}
+
+// moveToMonitor puts the window on m unless it is already there, since
+// moving it also recenters it.
+func moveToMonitor(m *ebiten.MonitorType) {
+	if m != nil && m != ebiten.Monitor() {
+		ebiten.SetMonitor(m)
+	}
+}
+
+// cycleFullscreenMonitor picks the next monitor for fullscreen and moves
+// there right away when already fullscreen.
+func (g *Game) cycleFullscreenMonitor() {
+	monitors := ebiten.AppendMonitors(nil)
+	g.config.FullscreenMonitor = nextFullscreenMonitor(g.config.FullscreenMonitor, len(monitors))
+	label := "window's monitor"
+	if i := fullscreenMonitorIndex(g.config.FullscreenMonitor, len(monitors)); i >= 0 {
+		label = fmt.Sprintf("%d/%d (%s)", i+1, len(monitors), monitors[i].Name())
+	}
+	if g.fullscreen {
+		moveToMonitor(g.fullscreenMonitor())
+	}
+	g.showOverlayMessage("Fullscreen on " + label)
+	debugKV("viewport", "fullscreen_monitor", "setting", g.config.FullscreenMonitor, "monitors", len(monitors))
+}
@@ -1,31 +1,52 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
+	"nv/internal/imgdecode"
 	"nv/navlogic"
 )
 
 func isArchiveExt(path string) bool {
+	_, ok := detectArchive(path)
+	return ok
+}
+
+func isSupportedExt(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
-	case ".zip", ".rar", ".7z":
+	case ".png", ".jpg", ".jpeg", ".webp", ".bmp", ".gif", ".psd", ".xcf", ".hdr", ".exr", ".tif", ".tiff":
 		return true
 	default:
 		return false
 	}
 }
 
-func isSupportedExt(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".png", ".jpg", ".jpeg", ".webp", ".bmp", ".gif":
+// contentSniffing mirrors Config.SniffContent. Collection also runs on the
+// open dialog and single-instance goroutines, which do not carry the config.
+var contentSniffing atomic.Bool
+
+// isImageFile reports whether a file should be collected as an image: by
+// extension, or by its leading bytes when content sniffing is enabled.
+func isImageFile(path string) bool {
+	if isSupportedExt(path) {
 		return true
-	default:
+	}
+	if !contentSniffing.Load() || isArchiveExt(path) {
 		return false
 	}
+	format, err := imgdecode.SniffFile(path)
+	if err != nil || format == "" {
+		return false
+	}
+	debugKV("collection", "content_sniffed", "path", path, "format", format)
+	return true
 }
 
 type CollectionSourceMode int
@@ -46,6 +67,13 @@ func (m CollectionSourceMode) String() string {
 	}
 }
 
+// CollectionLoadFailure records an open attempt that produced nothing to show,
+// so the start screen can explain it and offer a retry.
+type CollectionLoadFailure struct {
+	Args   []string
+	Reason string
+}
+
 type CollectionSource struct {
 	Mode             CollectionSourceMode
 	Args             []string
@@ -85,6 +113,18 @@ func (g *Game) getCurrentImagePath() string {
 	return imagePath.Path
 }
 
+// currentPaths returns a copy of the active path list.
+func (g *Game) currentPaths() []ImagePath {
+	count := g.imageManager.GetPathsCount()
+	paths := make([]ImagePath, 0, count)
+	for i := 0; i < count; i++ {
+		if p, ok := g.imageManager.GetPath(i); ok {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
 func findImagePathIndex(paths []ImagePath, targetPath string) int {
 	if targetPath == "" {
 		return -1
@@ -107,10 +147,15 @@ func (g *Game) reloadPathsForCurrentSource() bool {
 	currentPath := g.getCurrentImagePath()
 
 	paths, err := g.collectionSource.collect(g.config.SortMethod)
+	paths = filterPaths(paths, g.collectionFilter, g.ratings)
+	if err == nil && len(paths) > 0 {
+		paths = keepPastedPages(g.currentPaths(), paths)
+	}
 	if err != nil || len(paths) == 0 {
 		debugKV("collection", "reload_paths_failed",
 			"source_mode", g.collectionSource.Mode,
 			"sort_method", g.config.SortMethod,
+			"filter", g.collectionFilter.String(),
 			"current_path", currentPath,
 			"paths_count", len(paths),
 			"error", err,
@@ -140,6 +185,137 @@ func (g *Game) reloadPathsForCurrentSource() bool {
 	return true
 }
 
+// rescanCollection re-collects the current source so files added or removed
+// since launch are picked up, keeping the current file focused if it remains.
+func (g *Game) rescanCollection() {
+	prevCount := g.imageManager.GetPathsCount()
+	prevPaths := g.currentPaths()
+	if !g.reloadPathsForCurrentSource() {
+		g.showOverlayMessage("Rescan failed: no images found")
+		return
+	}
+	g.notifyEventFilesDeleted(prevPaths, g.currentPaths())
+
+	count := g.imageManager.GetPathsCount()
+	g.imageManager.StartPreload(g.idx, NavigationJump)
+	g.showOverlayMessage(fmt.Sprintf("Rescanned: %d image(s) (%+d)", count, count-prevCount))
+	debugKV("collection", "rescan",
+		"source_mode", g.collectionSource.Mode,
+		"prev_count", prevCount,
+		"paths_count", count,
+		"idx", g.idx,
+	)
+}
+
+func (g *Game) enterRenameMode() {
+	imagePath, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	if imagePath.ArchivePath != "" {
+		g.showOverlayMessage("Cannot rename archive entries")
+		debugKV("collection", "rename_skip", "path", imagePath.Path, "reason", "archive_entry")
+		return
+	}
+	if g.refusePastedPage(imagePath, "renamed") {
+		return
+	}
+
+	g.openTextPrompt(TextPromptRename, filepath.Base(imagePath.Path))
+	debugKV("collection", "rename_begin", "idx", g.idx, "path", imagePath.Path)
+}
+
+func (g *Game) processRename(input string) {
+	newName := strings.TrimSpace(input)
+	oldPath, _ := g.imageManager.GetPath(g.idx)
+	newPath, err := g.renameCurrentFile(newName)
+	if err != nil {
+		g.showOverlayMessage(fmt.Sprintf("Rename failed: %v", err))
+		warnKV("collection", "rename_failed", "idx", g.idx, "new_name", newName, "error", err)
+		return
+	}
+	if newPath != oldPath.Path {
+		g.pushUndo("rename to "+filepath.Base(newPath),
+			func() error { return g.renamePageAt(newPath, filepath.Base(oldPath.Path)) },
+			func() error { return g.renamePageAt(oldPath.Path, filepath.Base(newPath)) },
+		)
+	}
+	g.showOverlayMessage("Renamed to " + filepath.Base(newPath))
+}
+
+// renamePageAt renames the page with path to newName, for undo and redo.
+func (g *Game) renamePageAt(path, newName string) error {
+	if err := g.jumpToPath(path); err != nil {
+		return err
+	}
+	_, err := g.renameCurrentFile(newName)
+	return err
+}
+
+// renameCurrentFile renames the current regular file within its directory
+// and updates the path list (and cache key) in place.
+func (g *Game) renameCurrentFile(newName string) (string, error) {
+	imagePath, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return "", errors.New("no current file")
+	}
+	if imagePath.ArchivePath != "" {
+		return "", errors.New("archive entries cannot be renamed")
+	}
+	if imagePath.Transient {
+		return "", errors.New("pasted pages cannot be renamed")
+	}
+	if newName == "" || newName == "." || newName == ".." || strings.ContainsAny(newName, `/\`) {
+		return "", fmt.Errorf("invalid file name %q", newName)
+	}
+
+	oldPath := imagePath.Path
+	newPath := filepath.Join(filepath.Dir(oldPath), newName)
+	if newPath == oldPath {
+		return oldPath, nil
+	}
+	// Allow case-only renames on case-insensitive file systems.
+	if _, err := os.Lstat(newPath); err == nil && !strings.EqualFold(newPath, oldPath) {
+		return "", fmt.Errorf("%s already exists", newName)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return "", err
+	}
+
+	g.imageManager.RenamePath(g.idx, newPath)
+	renamed := imagePath
+	renamed.Path = newPath
+	g.moveFileState(imagePath, renamed)
+	if g.launchSingleFile == oldPath {
+		g.launchSingleFile = newPath
+	}
+	if g.collectionSource.ExpandedFilePath == oldPath {
+		g.collectionSource.ExpandedFilePath = newPath
+	}
+	for i, arg := range g.collectionSource.Args {
+		if arg == oldPath {
+			g.collectionSource.Args[i] = newPath
+		}
+	}
+
+	debugKV("collection", "rename_complete", "idx", g.idx, "old_path", oldPath, "new_path", newPath)
+	return newPath, nil
+}
+
+// moveFileState carries what nv keeps about a file over to its new name:
+// its mark, rating and tags, book mode pairing and whether it was read.
+func (g *Game) moveFileState(from, to ImagePath) {
+	if g.marks[from.Path] {
+		delete(g.marks, from.Path)
+		g.marks[to.Path] = true
+	}
+	g.ratings.Rename(ratingKey(from), ratingKey(to))
+	volume, oldPage := volumeKey(from)
+	_, newPage := volumeKey(to)
+	g.pairings.RenamePage(volume, oldPage, newPage)
+	g.progress.renamePage(volume, oldPage, newPage)
+}
+
 func (g *Game) cycleSortMethod() {
 	prevSortMethod := g.config.SortMethod
 	g.config.SortMethod = (g.config.SortMethod + 1) % 3
@@ -217,17 +393,18 @@ func (g *Game) expandToDirectoryAndJump() {
 	)
 }
 
+// applyPendingOpenRequests drains forwarded launches and finished open
+// dialogs. Either channel may be nil, which simply never becomes ready.
 func (g *Game) applyPendingOpenRequests() bool {
-	if g.externalOpenRequests == nil {
-		return false
-	}
-
 	applied := false
 	for {
 		select {
 		case req := <-g.externalOpenRequests:
 			g.applyPendingOpenRequest(req)
 			applied = true
+		case res := <-g.openDialogResults:
+			g.applyOpenDialogResult(res)
+			applied = true
 		default:
 			return applied
 		}
@@ -248,24 +425,31 @@ func (g *Game) replaceCollectionFromArgs(args []string, paths []ImagePath) {
 	g.imageManager.SetPaths(paths)
 	g.collectionSource = newArgsCollectionSource(args)
 	g.launchSingleFile = ""
+	g.loadFailure = nil
+	g.collectionFilter = CollectionFilter{}
 	g.idx = 0
 	g.tempSingleMode = false
 	g.bookMode = g.config.BookMode
+	g.bookModeVolume = ""
 	g.learnedSpreadAspects = nil
 	g.rotationAngle = 0
 	g.flipH = false
 	g.flipV = false
 	g.showHelp = false
 	g.showInfo = false
+	g.showLoadErrors = false
+	g.lastNavDirection = NavigationForward
 	g.showSettings = false
 	g.settingsIndex = 0
 	g.pendingConfig = Config{}
 	g.pageInputMode = false
 	g.pageInputBuffer = ""
+	g.closeTextPrompt()
 
 	g.resetZoomToInitial()
 	initializeSingleFileMode(g, args)
 	initializeBookModeForLaunch(g, paths)
+	g.rememberRecentFiles(args)
 	g.calculateDisplayContent()
 	g.imageManager.StartPreload(g.idx, NavigationJump)
 	g.showOverlayMessage(fmt.Sprintf("Loaded %d image(s)", len(paths)))
@@ -277,3 +461,181 @@ func (g *Game) replaceCollectionFromArgs(args []string, paths []ImagePath) {
 		"temp_single", g.tempSingleMode,
 	)
 }
+
+// openDialogResult carries the outcome of a native open dialog back to the
+// game loop.
+type openDialogResult struct {
+	Kind  fileDialogKind
+	Args  []string
+	Paths []ImagePath
+	Err   error
+}
+
+// startOpenDialog shows the native chooser on a background goroutine so the
+// Ebiten loop keeps running; the result is applied from Update.
+func (g *Game) startOpenDialog(kind fileDialogKind) {
+	if !g.openDialogActive.CompareAndSwap(false, true) {
+		debugKV("collection", "open_dialog_skip", "kind", kind, "reason", "already_open")
+		return
+	}
+	if g.openDialogResults == nil {
+		g.openDialogResults = make(chan openDialogResult, 1)
+	}
+
+	results := g.openDialogResults
+	sortMethod := g.config.SortMethod
+	debugKV("collection", "open_dialog_begin", "kind", kind, "sort_method", sortMethod)
+	go func() {
+		defer g.openDialogActive.Store(false)
+		defer recoverWorker("open_dialog", "", func(err error) { results <- openDialogResult{Kind: kind, Err: err} })
+		results <- collectOpenDialogSelection(kind, sortMethod, showOpenDialog)
+	}()
+}
+
+func collectOpenDialogSelection(kind fileDialogKind, sortMethod int, show func(fileDialogKind) ([]string, error)) openDialogResult {
+	args, err := show(kind)
+	if err != nil {
+		return openDialogResult{Kind: kind, Err: err}
+	}
+
+	paths, err := collectImages(args, sortMethod)
+	if err != nil {
+		return openDialogResult{Kind: kind, Args: args, Err: err}
+	}
+	return openDialogResult{Kind: kind, Args: args, Paths: paths}
+}
+
+func (g *Game) applyOpenDialogResult(res openDialogResult) {
+	switch {
+	case errors.Is(res.Err, errFileDialogCanceled):
+		debugKV("collection", "open_dialog_canceled", "kind", res.Kind)
+		return
+	case errors.Is(res.Err, errFileDialogUnavailable):
+		g.showOverlayMessage("Open dialog unavailable (install zenity or kdialog)")
+		warnKV("collection", "open_dialog_unavailable", "kind", res.Kind)
+		return
+	case res.Err != nil:
+		g.reportOpenFailure(res.Args, fmt.Sprintf("Open failed: %v", res.Err))
+		warnKV("collection", "open_dialog_failed", "kind", res.Kind, "args", res.Args, "error", res.Err)
+		return
+	case len(res.Paths) == 0:
+		g.reportOpenFailure(res.Args, "No images found")
+		debugKV("collection", "open_dialog_failed", "kind", res.Kind, "args", res.Args, "reason", "no_images")
+		return
+	}
+
+	g.replaceCollectionFromArgs(res.Args, res.Paths)
+	debugKV("collection", "open_dialog_complete",
+		"kind", res.Kind,
+		"args_count", len(res.Args),
+		"paths_count", len(res.Paths),
+	)
+}
+
+// rememberRecentFiles moves the launch arguments to the front of the recent
+// files list. The list is persisted with the rest of the config on shutdown.
+func (g *Game) rememberRecentFiles(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	recent := make([]string, 0, len(args)+len(g.config.RecentFiles))
+	for _, arg := range args {
+		if absPath, err := filepath.Abs(arg); err == nil {
+			arg = absPath
+		}
+		recent = append(recent, arg)
+	}
+	g.config.RecentFiles = normalizeRecentFiles(append(recent, g.config.RecentFiles...))
+	debugKV("collection", "recent_files_updated", "count", len(g.config.RecentFiles))
+}
+
+// OpenRecentFile opens the index-th entry of the recent files list.
+func (g *Game) OpenRecentFile(index int) {
+	if index < 0 || index >= len(g.config.RecentFiles) {
+		debugKV("collection", "open_recent_skip", "index", index, "reason", "out_of_range")
+		return
+	}
+	g.openPaths([]string{g.config.RecentFiles[index]}, "recent")
+}
+
+// openPaths collects images for args and replaces the current collection,
+// leaving it untouched when nothing can be loaded.
+func (g *Game) openPaths(args []string, origin string) bool {
+	paths, err := collectImages(args, g.config.SortMethod)
+	if err != nil {
+		g.reportOpenFailure(args, fmt.Sprintf("Open failed: %v", err))
+		warnKV("collection", "open_paths_failed", "origin", origin, "args", args, "error", err)
+		return false
+	}
+	if len(paths) == 0 {
+		g.reportOpenFailure(args, "No images found")
+		debugKV("collection", "open_paths_failed", "origin", origin, "args", args, "reason", "no_images")
+		return false
+	}
+
+	g.replaceCollectionFromArgs(args, paths)
+	debugKV("collection", "open_paths_complete", "origin", origin, "args_count", len(args), "paths_count", len(paths))
+	return true
+}
+
+// reportOpenFailure shows why an open attempt failed. While nothing is
+// loaded the failure is also kept for the start screen, which offers a retry.
+func (g *Game) reportOpenFailure(args []string, reason string) {
+	g.showOverlayMessage(reason)
+	if g.imageManager.GetPathsCount() > 0 {
+		return
+	}
+	g.loadFailure = &CollectionLoadFailure{
+		Args:   append([]string(nil), args...),
+		Reason: reason,
+	}
+}
+
+// RetryFailedOpen repeats the open attempt recorded on the start screen.
+func (g *Game) RetryFailedOpen() {
+	if g.loadFailure == nil || len(g.loadFailure.Args) == 0 {
+		debugKV("collection", "retry_open_skip", "reason", "no_failure")
+		return
+	}
+	g.openPaths(g.loadFailure.Args, "retry")
+}
+
+// applyDroppedFiles opens files or directories dropped onto the window.
+func (g *Game) applyDroppedFiles(dropped fs.FS) bool {
+	if dropped == nil {
+		return false
+	}
+
+	args := droppedFilePaths(dropped)
+	if len(args) == 0 || g.kioskLocked {
+		return false
+	}
+	g.openPaths(args, "drop")
+	return true
+}
+
+// droppedFilePaths resolves the real paths behind Ebiten's dropped-file FS.
+// Desktop builds back each top-level entry with an *os.File, whose Name is
+// the original path.
+func droppedFilePaths(dropped fs.FS) []string {
+	entries, err := fs.ReadDir(dropped, ".")
+	if err != nil {
+		warnKV("collection", "dropped_files_read_failed", "error", err)
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		f, err := dropped.Open(entry.Name())
+		if err != nil {
+			warnKV("collection", "dropped_file_open_failed", "name", entry.Name(), "error", err)
+			continue
+		}
+		if osFile, ok := f.(*os.File); ok {
+			paths = append(paths, osFile.Name())
+		}
+		f.Close()
+	}
+	return paths
+}
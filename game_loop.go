@@ -7,12 +7,20 @@ import (
 )
 
 func (g *Game) Update() error {
-	if g.applyPendingOpenRequests() {
+	defer g.recoverCrash()
+	textureUploads.nextFrame()
+	if g.applyPendingOpenRequests() || g.applyDroppedFiles(ebiten.DroppedFiles()) {
 		g.wasInputHandled = true
 		g.renderer.lastSnapshot = nil
 	}
 
-	if !g.wasInputHandled {
+	if g.applyRemoteCommands() || g.applyMediaCommands() {
+		g.wasInputHandled = true
+	}
+
+	g.updateQuicklook()
+	messageSince := g.overlayMessageTime
+	if !g.wasInputHandled && !g.exitRequested {
 		g.wasInputHandled = g.inputHandler.HandleInput()
 	}
 
@@ -21,12 +29,70 @@ func (g *Game) Update() error {
 		g.overlayMessageTime = time.Time{}
 	}
 
+	if g.applyContactSheetResults() || g.applyPageExportResults() || g.applyPrintResults() || g.applyScreenshotResults() || g.applyOCRResults() ||
+		g.applyClipboardPasteResults() || g.applyUpdateCheckResult() {
+		g.wasInputHandled = true
+	}
+
+	if g.notifyScriptImageChanged() {
+		g.wasInputHandled = true
+	}
+	g.notifyEventImageChanged()
+	// Each of these must run every frame, so none may short-circuit another
+	layoutChanged := g.applyVolumeDirection()
+	layoutChanged = g.applyBookModePolicy() || layoutChanged
+	layoutChanged = g.syncPagePairings() || layoutChanged
+	layoutChanged = g.updateSpreadLayout() || layoutChanged
+	if layoutChanged {
+		g.wasInputHandled = true
+	}
+	if g.flashPageNumber(messageSince) {
+		g.wasInputHandled = true
+	}
+	g.trackReadingProgress(time.Now())
+	g.publishRemoteState()
+	g.publishMediaStatus()
+
 	if g.imageManager.ConsumeAsyncRefresh() {
+		g.skipUnreadablePages(g.lastNavDirection)
 		g.calculateDisplayContent()
 		g.renderer.lastSnapshot = nil
 		debugKV("cache", "async_refresh", "idx", g.idx)
 	}
 
+	tick := time.Second / time.Duration(ebiten.TPS())
+	if g.advanceAnimations(tick) {
+		g.renderer.lastSnapshot = nil
+	}
+	if g.advanceCompareBlink(tick) {
+		g.renderer.lastSnapshot = nil
+	}
+	if g.updateUpscale() {
+		g.wasInputHandled = true
+	}
+	if g.advanceSlideshow(tick) {
+		g.wasInputHandled = true
+	}
+	if g.advanceReadingTimer(tick) {
+		g.wasInputHandled = true
+	}
+	if g.updateVolumeEnd(time.Now()) {
+		g.wasInputHandled = true
+	}
+	if g.updateLoadingIndicator(time.Now()) {
+		g.wasInputHandled = true
+	}
+	if g.updateOnScreenControls(time.Now()) {
+		g.wasInputHandled = true
+	}
+	g.updateLaserPointer()
+	if g.updateMeasure() {
+		g.wasInputHandled = true
+	}
+
+	g.updateIdleThrottle(time.Now(), g.needsContinuousFrames())
+
+	g.handleWindowClose()
 	if g.exitRequested {
 		g.shutdown()
 		return ebiten.Termination
@@ -36,6 +102,7 @@ func (g *Game) Update() error {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
+	defer g.recoverCrash()
 	if g.needsInitialZoomUpdate {
 		g.updateZoomLevelForFitMode()
 		g.needsInitialZoomUpdate = false
@@ -53,7 +120,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	if g.wasInputHandled ||
 		g.renderer.lastSnapshot == nil ||
 		!currentSnapshot.Equals(g.renderer.lastSnapshot) ||
-		g.forceRedrawFrames > 0 {
+		g.forceRedrawFrames > 0 ||
+		g.customShaderRedraw() {
 		switch {
 		case g.wasInputHandled:
 			redrawReason = "input_handled"
@@ -63,6 +131,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 			redrawReason = "snapshot_changed"
 		case g.forceRedrawFrames > 0:
 			redrawReason = "forced_redraw"
+		default:
+			redrawReason = "shader_animation"
 		}
 		g.renderer.Draw(screen)
 		g.renderer.lastSnapshot = currentSnapshot
@@ -73,6 +143,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 		g.wasInputHandled = false
 	}
+	g.captureScreenshot(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"nv/navlogic"
@@ -18,6 +19,9 @@ func (g *Game) navigationState() navlogic.State {
 		RightToLeft:          g.config.RightToLeft,
 		AspectRatioThreshold: g.config.AspectRatioThreshold,
 		LearnedSpreadAspects: append([]float64(nil), g.learnedSpreadAspects...),
+		Layout:               g.spreadLayout,
+		Spreads:              g.spreadIndex,
+		Pairings:             g.pagePairings,
 	}
 }
 
@@ -25,6 +29,7 @@ func (g *Game) applyNavigationState(state navlogic.State) {
 	g.idx = state.Index
 	g.bookMode = state.BookMode
 	g.tempSingleMode = state.TempSingleMode
+	g.spreadLayout = state.Layout
 }
 
 func (g *Game) pageMetricsAt(idx int) navlogic.PageMetrics {
@@ -48,7 +53,11 @@ func (g *Game) displayImageAt(idx int) DisplayImage {
 	if idx < 0 {
 		return nil
 	}
-	return g.imageManager.GetImage(idx)
+	img := g.imageManager.GetImage(idx)
+	if up := g.upscaledImageAt(idx, img); up != nil {
+		return up
+	}
+	return img
 }
 
 func (g *Game) pageAspectAt(idx int) float64 {
@@ -134,9 +143,20 @@ func (g *Game) addLearnedSpreadAspect(aspect float64) bool {
 	}
 
 	g.learnedSpreadAspects = append(g.learnedSpreadAspects, aspect)
+	g.spreadIndex = nil // Pairs seen before may no longer be paired
 	return true
 }
 
+// preloadLayoutFor tells the preloader which pages are on screen and how
+// far a page turn moves.
+func preloadLayoutFor(state navlogic.State, plan navlogic.DisplayPlan) PreloadLayout {
+	layout := PreloadLayout{Shown: max(1, plan.ActualImages), Step: 1}
+	if state.BookMode {
+		layout.Step = 2
+	}
+	return layout
+}
+
 // calculateDisplayContent determines what should be displayed based on current state.
 func (g *Game) calculateDisplayContent() {
 	state := g.navigationState()
@@ -145,6 +165,11 @@ func (g *Game) calculateDisplayContent() {
 		g.displayContent = nil
 		return
 	}
+	g.recordSpread(state, plan)
+	g.imageManager.SetPreloadLayout(preloadLayoutFor(state, plan))
+	if dm, ok := g.imageManager.(*DefaultImageManager); ok {
+		dm.SetShownPages(plan.LeftIndex, plan.RightIndex)
+	}
 
 	g.displayContent = &DisplayContent{
 		LeftImage:  g.displayImageAt(plan.LeftIndex),
@@ -154,8 +179,23 @@ func (g *Game) calculateDisplayContent() {
 			RightPage:    plan.RightIndex + 1,
 			TotalPages:   plan.TotalPages,
 			ActualImages: plan.ActualImages,
+			Unreadable:   len(g.imageManager.GetLoadErrors()),
+			Filter:       g.collectionFilter.Summary(),
+			Mode:         g.bookModeLabel(state, plan),
+			Anomaly:      g.displayedAnomaly(plan),
+			MarkCount:    len(g.marks),
+
+			AnimationPaused: g.animationPaused,
+			AnimationSpeed:  g.playbackSpeed(),
 		},
 	}
+	if imagePath, ok := g.imageManager.GetPath(plan.LeftIndex); ok {
+		entry := g.ratings.Get(ratingKey(imagePath))
+		g.displayContent.Metadata.Rating = entry.Rating
+		g.displayContent.Metadata.Tags = entry.Tags
+		g.displayContent.Metadata.Chapter = chapterLabel(imagePath)
+		g.displayContent.Metadata.Marked = g.marks[imagePath.Path]
+	}
 
 	if g.zoomState.Mode != ZoomModeManual && !g.needsInitialZoomUpdate {
 		g.updateZoomLevelForFitMode()
@@ -204,15 +244,94 @@ func (g *Game) toggleBookMode() {
 
 func (g *Game) toggleReadingDirection() {
 	g.config.RightToLeft = !g.config.RightToLeft
+	g.volumeDirectionActive = false
 	direction := "Left-to-Right"
 	if g.config.RightToLeft {
 		direction = "Right-to-Left"
 	}
 	g.showOverlayMessage("Reading Direction: " + direction)
+	g.rememberVolumeDirection()
 	g.calculateDisplayContent()
 	debugKV("nav", "toggle_reading_direction", "rtl", g.config.RightToLeft)
 }
 
+// bookModeLabel names the view mode for the info display: "S" for single
+// page mode, "B" for a spread, and "B-single" with the reason when book
+// mode shows one page.
+func (g *Game) bookModeLabel(state navlogic.State, plan navlogic.DisplayPlan) string {
+	switch {
+	case !state.BookMode && !state.TempSingleMode:
+		return "S"
+	case plan.ActualImages == 2:
+		return "B"
+	}
+
+	first := plan.LeftIndex
+	if first+1 >= plan.TotalPages {
+		return "B-single (last page)"
+	}
+	if state.Pairings[first] == navlogic.PairApart {
+		return "B-single (split by hand)"
+	}
+	decision := navlogic.ExplainBookModeDecision(g.pageMetricsAt(first), g.pageMetricsAt(first+1),
+		state.AspectRatioThreshold, state.LearnedSpreadAspects)
+	if decision.UseBookMode {
+		return "B-single (spread offset)" // The pair was split by a single step
+	}
+	return "B-single (" + decision.Reason + ")"
+}
+
+// forcePair shows the first page shown and the next one as a spread
+// whatever their sizes, or pairs them automatically again.
+func (g *Game) forcePair() {
+	g.togglePairing(navlogic.PairTogether)
+}
+
+// forceSplit shows the first page shown and the next one apart, or pairs
+// them automatically again.
+func (g *Game) forceSplit() {
+	g.togglePairing(navlogic.PairApart)
+}
+
+// togglePairing sets the pairing of the first page shown and the next one
+// by hand, turning book mode on if needed; when it is already set that way
+// it goes back to automatic pairing. The choice is kept for the volume.
+func (g *Game) togglePairing(pairing navlogic.Pairing) {
+	g.syncPagePairings()
+	plan := navlogic.PlanDisplay(g.navigationState(), g.pageMetricsAt)
+	first := plan.LeftIndex
+	if plan.ActualImages == 2 {
+		first = min(plan.LeftIndex, plan.RightIndex)
+	}
+	if first < 0 || first+1 >= plan.TotalPages {
+		g.showOverlayMessage("No next page to pair with")
+		return
+	}
+	for _, i := range []int{first, first + 1} {
+		if p, _ := g.imageManager.GetPath(i); g.refusePastedPage(p, "paired") {
+			return
+		}
+	}
+
+	if g.pagePairings[first] == pairing {
+		pairing = navlogic.PairAuto
+	}
+	g.setPairing(first, pairing)
+	g.idx = first
+	g.bookMode = true
+	g.tempSingleMode = false
+	switch pairing {
+	case navlogic.PairTogether:
+		g.showOverlayMessage(fmt.Sprintf("Paired pages %d and %d", first+1, first+2))
+	case navlogic.PairApart:
+		g.showOverlayMessage(fmt.Sprintf("Split pages %d and %d", first+1, first+2))
+	default:
+		g.showOverlayMessage(fmt.Sprintf("Pages %d and %d: automatic pairing", first+1, first+2))
+	}
+	g.calculateDisplayContent()
+	debugKV("nav", "set_pairing", "first", first, "pairing", pairing, "pairings", len(g.pagePairings))
+}
+
 func (g *Game) markCurrentAsPreJoinedSpread() {
 	plan := navlogic.PlanDisplay(g.navigationState(), g.pageMetricsAt)
 	if plan.TotalPages == 0 || plan.LeftIndex < 0 {
@@ -258,6 +377,18 @@ func (g *Game) processPageInput() {
 		return
 	}
 
+	if digits, ok := strings.CutSuffix(g.pageInputBuffer, "%"); ok {
+		percent, err := strconv.Atoi(digits)
+		if err != nil || percent > 100 {
+			g.showOverlayMessage("Invalid percentage (0-100%)")
+			debugKV("input", "page_input_invalid", "buffer", g.pageInputBuffer, "reason", "percent")
+			return
+		}
+		debugKV("input", "page_input_submit", "buffer", g.pageInputBuffer, "percent", percent)
+		g.jumpToPercent(percent)
+		return
+	}
+
 	pageNum, err := strconv.Atoi(g.pageInputBuffer)
 	if err != nil {
 		g.showOverlayMessage("Invalid page number")
@@ -269,6 +400,52 @@ func (g *Game) processPageInput() {
 	g.jumpToPage(pageNum)
 }
 
+// percentJumpStep is how far percent_forward and percent_back move, in
+// percent of the pages.
+const percentJumpStep = 10
+
+// percentPage returns the page at percent of a list of total pages, 0%
+// being the first page and 100% the last.
+func percentPage(percent, total int) int {
+	percent = max(0, min(100, percent))
+	return 1 + (percent*(total-1)+50)/100
+}
+
+// jumpToPercent jumps to the page at percent of the list.
+func (g *Game) jumpToPercent(percent int) {
+	total := g.imageManager.GetPathsCount()
+	if total == 0 {
+		return
+	}
+	page := percentPage(percent, total)
+	g.jumpToPage(page)
+	g.showOverlayMessage(fmt.Sprintf("%d%%: page %d / %d", percent, page, total))
+}
+
+// jumpByPercent jumps forward, or back when negative, by percent of the
+// list, at least one page.
+func (g *Game) jumpByPercent(percent int) {
+	total := g.imageManager.GetPathsCount()
+	if total == 0 {
+		return
+	}
+	step := max(1, total*percent/100)
+	if percent < 0 {
+		step = min(-1, total*percent/100)
+	}
+	page := max(1, min(total, g.idx+1+step))
+	if page == g.idx+1 {
+		if percent < 0 {
+			g.showOverlayMessage("First page")
+		} else {
+			g.showOverlayMessage("Last page")
+		}
+		return
+	}
+	g.jumpToPage(page)
+	g.showOverlayMessage(fmt.Sprintf("Page %d / %d (%d%%)", page, total, 100*(page-1)/max(1, total-1)))
+}
+
 func (g *Game) jumpToPage(pageNum int) {
 	prevState := g.navigationState()
 	nextState, boundary := navlogic.JumpToPage(g.navigationState(), pageNum, g.pageMetricsAt)
@@ -278,10 +455,17 @@ func (g *Game) jumpToPage(pageNum int) {
 		return
 	}
 
+	from, fromOK := g.imageManager.GetPath(prevState.Index)
 	g.applyNavigationState(nextState)
 	g.imageManager.StartPreload(g.idx, NavigationJump)
 	g.resetZoomToInitial()
 	g.calculateDisplayContent()
+	if to, ok := g.imageManager.GetPath(g.idx); ok && fromOK && nextState.Index != prevState.Index && !g.automaticJump {
+		g.recordJump(from, to, g.idx+1)
+		if !g.navigatingHistory {
+			g.navHistory.visit(from.Path, to.Path)
+		}
+	}
 	debugKV("nav", "jump_to_page",
 		"requested_page", pageNum,
 		"prev_idx", prevState.Index,
@@ -297,6 +481,9 @@ func (g *Game) navigateNext(singleStep bool) {
 	nextState, boundary := navlogic.NavigateNext(g.navigationState(), g.pageMetricsAt, singleStep)
 	if boundary == navlogic.BoundaryLastPage {
 		debugKV("nav", "navigate_next", "single_step", singleStep, "prev_idx", prevState.Index, "boundary", boundary)
+		if g.handleVolumeEnd(time.Now()) {
+			return
+		}
 		g.showOverlayMessage("Last page")
 		return
 	}
@@ -316,6 +503,68 @@ func (g *Game) navigateNext(singleStep bool) {
 	)
 }
 
+// reloadCurrentImages re-decodes the page(s) on screen, e.g. after an
+// external tool rewrote the file.
+func (g *Game) reloadCurrentImages() {
+	plan := navlogic.PlanDisplay(g.navigationState(), g.pageMetricsAt)
+	if plan.TotalPages == 0 || plan.LeftIndex < 0 {
+		return
+	}
+
+	indices := []int{plan.LeftIndex}
+	if plan.RightIndex >= 0 && plan.RightIndex != plan.LeftIndex {
+		indices = append(indices, plan.RightIndex)
+	}
+	for _, idx := range indices {
+		g.imageManager.InvalidateImage(idx)
+	}
+
+	g.calculateDisplayContent()
+	g.showOverlayMessage("Reloaded")
+	debugKV("nav", "reload_current", "idx", g.idx, "indices", indices)
+}
+
+// skipUnreadablePages steps past pages whose decode is known to have failed,
+// continuing in direction, when SkipUnreadableImages is enabled. Failures are
+// only known once a load finishes, so Update calls this again after async
+// refreshes using the last navigation direction.
+func (g *Game) skipUnreadablePages(direction NavigationDirection) {
+	g.lastNavDirection = direction
+	if !g.config.SkipUnreadableImages {
+		return
+	}
+
+	startIdx := g.idx
+	skipped := 0
+	for g.imageManager.IsLoadFailed(g.idx) && skipped < g.imageManager.GetPathsCount() {
+		var nextState navlogic.State
+		var boundary navlogic.Boundary
+		if direction == NavigationBackward {
+			nextState, boundary = navlogic.NavigatePrevious(g.navigationState(), g.pageMetricsAt, true)
+		} else {
+			nextState, boundary = navlogic.NavigateNext(g.navigationState(), g.pageMetricsAt, true)
+		}
+		if boundary != navlogic.BoundaryNone {
+			break
+		}
+		g.applyNavigationState(nextState)
+		skipped++
+	}
+	if skipped == 0 {
+		return
+	}
+
+	g.resetZoomToInitial()
+	g.calculateDisplayContent()
+	g.showOverlayMessage(fmt.Sprintf("Skipped %d unreadable image(s)", skipped))
+	debugKV("nav", "skip_unreadable",
+		"direction", direction,
+		"start_idx", startIdx,
+		"next_idx", g.idx,
+		"skipped", skipped,
+	)
+}
+
 func (g *Game) navigatePrevious(singleStep bool) {
 	prevState := g.navigationState()
 	nextState, boundary := navlogic.NavigatePrevious(g.navigationState(), g.pageMetricsAt, singleStep)
@@ -2,20 +2,108 @@ package main
 
 import (
 	"fmt"
+	"reflect"
+	"slices"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-func (g *Game) saveCurrentConfig() {
+// save_on_exit policies: what quitting writes back to the config file
+const (
+	saveOnExitAll    = "all"    // Everything, including toggles made with keys
+	saveOnExitWindow = "window" // Only the window size
+	saveOnExitNone   = "none"   // Nothing; the config changes only on explicit saves
+)
+
+var saveOnExitPolicies = []string{saveOnExitAll, saveOnExitWindow, saveOnExitNone}
+
+func (g *Game) configFilePath() string {
 	if g.configPath != "" {
-		saveConfigToPath(g.config, g.configPath)
-	} else {
-		saveConfig(g.config)
+		return g.configPath
+	}
+	return getConfigPath()
+}
+
+// readerConfig is the running config with the reader's own reading
+// direction in place of one a volume applied.
+func (g *Game) readerConfig() Config {
+	c := g.config
+	if g.volumeDirectionActive {
+		c.RightToLeft = g.readerRightToLeft
+	}
+	return c
+}
+
+// copyWindowState copies the window size, position and state.
+func copyWindowState(dst *Config, src Config) {
+	dst.WindowWidth = src.WindowWidth
+	dst.WindowHeight = src.WindowHeight
+	dst.WindowX = src.WindowX
+	dst.WindowY = src.WindowY
+	dst.WindowMonitor = src.WindowMonitor
+	dst.Maximized = src.Maximized
+}
+
+// saveConfigOnExit applies save_on_exit. Only "all" writes the running
+// config; the other policies start from the file as saved, so toggles made
+// with keys stay out of it. The recent files list is history, not a
+// setting, so it is written under every policy.
+func (g *Game) saveConfigOnExit() {
+	configPath := g.configFilePath()
+	switch g.config.SaveOnExit {
+	case saveOnExitWindow:
+		saved := loadConfigFromPath(configPath).Config
+		copyWindowState(&saved, g.config)
+		saved.RecentFiles = g.config.RecentFiles
+		saveConfigToPath(saved, configPath)
+	case saveOnExitNone:
+		saveRecentFiles(statePathForConfig(configPath, historyFileName), g.config.RecentFiles)
+	default:
+		saveConfigToPath(g.readerConfig(), configPath)
+	}
+	debugKV("config", "save_on_exit", "policy", g.config.SaveOnExit, "path", configPath)
+}
+
+// changedConfigFields returns the indices of the fields that differ between
+// two configs.
+func changedConfigFields(base, edited Config) []int {
+	b, e := reflect.ValueOf(base), reflect.ValueOf(edited)
+	var changed []int
+	for i := range b.NumField() {
+		if !reflect.DeepEqual(b.Field(i).Interface(), e.Field(i).Interface()) {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// copyConfigFields copies the given fields of src into dst.
+func copyConfigFields(dst *Config, src Config, fields []int) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for _, i := range fields {
+		d.Field(i).Set(s.Field(i))
 	}
 }
 
+// SaveSettingsNow writes the settings as they are now, including toggles
+// made with keys, whatever save_on_exit says.
+func (g *Game) SaveSettingsNow() {
+	if readOnly {
+		g.showOverlayMessage("Read-only: settings not saved")
+		return
+	}
+	g.saveCurrentWindowSize()
+	saved := g.effectiveConfig()
+	saved.RecentFiles = g.config.RecentFiles
+	saveConfigToPath(saved, g.configFilePath())
+	g.showOverlayMessage("Settings saved")
+	infoKV("config", "settings_saved_now", "config_path", g.configPath)
+}
+
 func (g *Game) saveCurrentWindowSize() {
 	if g.fullscreen {
+		g.saveWindowPosition()
 		if g.savedWinW > 0 && g.savedWinH > 0 {
 			g.config.WindowWidth = g.savedWinW
 			g.config.WindowHeight = g.savedWinH
@@ -23,6 +111,12 @@ func (g *Game) saveCurrentWindowSize() {
 		return
 	}
 
+	// A maximized window keeps the size and position it restores to
+	g.config.Maximized = ebiten.IsWindowMaximized()
+	if g.config.Maximized {
+		return
+	}
+	g.saveWindowPosition()
 	w, h := ebiten.WindowSize()
 	g.config.WindowWidth = w
 	g.config.WindowHeight = h
@@ -33,6 +127,7 @@ func (g *Game) ToggleSettings() {
 	g.showSettings = !g.showSettings
 	if g.showSettings {
 		g.pendingConfig = g.config
+		g.settingsBase = g.config
 		g.settingsIndex = 0
 		debugKV("config", "settings_open", "selected_index", g.settingsIndex)
 		return
@@ -64,25 +159,62 @@ func (g *Game) SettingsCancel() {
 	debugKV("config", "settings_cancel")
 }
 
+// SettingsSave writes the settings edited in the panel. Only the edited
+// fields are merged into the file as saved and into the running config, so
+// runtime state shown in the panel, such as a toggled book mode, is saved
+// only as save_on_exit says.
 func (g *Game) SettingsSave() {
 	debugKV("config", "settings_save_begin", "config_path", g.configPath)
-	if g.configPath != "" {
-		saveConfigToPath(g.pendingConfig, g.configPath)
-		res := loadConfigFromPath(g.configPath)
-		g.applyConfigResult(res)
+	changed := changedConfigFields(g.settingsBase, g.pendingConfig)
+	running := g.config
+	res := g.configStatus
+	if !readOnly {
+		path := g.configFilePath()
+		saved := loadConfigFromPath(path).Config
+		copyConfigFields(&saved, g.pendingConfig, changed)
+		saveConfigToPath(saved, path)
+		// Take the edited values as loaded back, normalized like at startup
+		res = loadConfigFromPath(path)
 	} else {
-		saveConfig(g.pendingConfig)
-		res := loadConfig()
-		g.applyConfigResult(res)
+		res.Config = g.pendingConfig
 	}
+	copyConfigFields(&running, res.Config, changed)
+	res.Config = running
+	g.applyConfigResult(res)
 
 	g.showSettings = false
-	g.showOverlayMessage("Settings saved")
+	if readOnly {
+		g.showOverlayMessage("Settings applied (read-only, not saved)")
+	} else {
+		g.showOverlayMessage("Settings saved")
+	}
 	debugKV("config", "settings_save_complete", "config_path", g.configPath)
 }
 
+// saveAndApplyConfig saves config and applies it as loaded back, so the
+// runtime sees exactly what the next start will. In read-only mode it is
+// applied for this session only.
+func (g *Game) saveAndApplyConfig(config Config) {
+	if readOnly {
+		res := g.configStatus
+		res.Config = config
+		g.applyConfigResult(res)
+		return
+	}
+	if g.configPath != "" {
+		saveConfigToPath(config, g.configPath)
+		g.applyConfigResult(loadConfigFromPath(g.configPath))
+	} else {
+		saveConfig(config)
+		g.applyConfigResult(loadConfig())
+	}
+}
+
 func (g *Game) applyConfigResult(res ConfigLoadResult) {
 	g.configStatus = res
+	if g.renderer != nil {
+		g.renderer.invalidateHelpLayout()
+	}
 	debugKV("config", "apply_config_result",
 		"status", res.Status,
 		"warnings", len(res.Warnings),
@@ -115,25 +247,80 @@ func (g *Game) applyNewConfig(newCfg Config) {
 		g.toggleFullscreen()
 	}
 	if !g.fullscreen {
+		if ebiten.IsWindowMaximized() {
+			ebiten.RestoreWindow()
+		}
 		ebiten.SetWindowSize(g.config.WindowWidth, g.config.WindowHeight)
 		g.savedWinW = g.config.WindowWidth
 		g.savedWinH = g.config.WindowHeight
+		if g.config.Maximized {
+			ebiten.MaximizeWindow()
+		}
 	}
 
 	g.bookMode = g.config.BookMode
+	g.bookModeVolume = ""
+	if old.RightToLeft != g.config.RightToLeft {
+		g.volumeDirectionActive = false
+	}
+	if old.AspectRatioThreshold != g.config.AspectRatioThreshold {
+		g.spreadIndex = nil
+	}
 
-	if old.SortMethod != g.config.SortMethod {
+	contentSniffing.Store(g.config.SniffContent)
+	setArchiveIgnore(g.config.ArchiveIgnore)
+	setChapterPattern(g.config.ChapterPattern)
+	if old.SortMethod != g.config.SortMethod || old.SniffContent != g.config.SniffContent ||
+		!slices.Equal(old.ArchiveIgnore, g.config.ArchiveIgnore) {
 		g.reloadPathsForCurrentSource()
 	}
 
+	if old.LogLevel != g.config.LogLevel {
+		setLogLevel(g.config.LogLevel)
+	}
 	g.updatePreloadConfig(g.config.PreloadCount, g.config.PreloadEnabled)
+	textureUploads.setPerFrame(int64(g.config.UploadBudgetMB) << 20)
 	if dm, ok := g.imageManager.(*DefaultImageManager); ok {
 		dm.SetMaxImageDimension(g.config.MaxImageDimension)
+		dm.SetGPUMemoryCap(int64(g.config.GPUMemoryCapMB) << 20)
+		dm.SetArchivePrefetch(g.config.ArchivePrefetch, g.config.ArchivePrefetchMaxMB)
+	}
+	if old.ToneMapOperator != g.config.ToneMapOperator || old.HDRExposure != g.config.HDRExposure {
+		g.hdrExposure = g.config.HDRExposure
+		g.applyToneMapping()
+	}
+
+	if old.DisplaySharpen != g.config.DisplaySharpen || old.DisplayDenoise != g.config.DisplayDenoise {
+		g.sharpen, g.denoise = g.config.DisplaySharpen, g.config.DisplayDenoise
 	}
 
 	if g.mousebindingManager != nil {
 		g.mousebindingManager.UpdateSettings(g.config.MouseSettings)
 	}
+	if !slices.Equal(old.Scripts, g.config.Scripts) {
+		g.loadScripts()
+	}
+	if old.TrackReadingProgress != g.config.TrackReadingProgress {
+		g.saveReadingProgress()
+		g.progress = newProgressStoreForConfig(g.config, g.configPath)
+		g.reading = readingSession{}
+		g.showReadingStats = false
+	}
+	if old.RememberDirection != g.config.RememberDirection {
+		g.directions = newDirectionStoreForConfig(g.config, g.configPath)
+		g.directionVolume = ""
+	}
+	if old.CustomShader != g.config.CustomShader {
+		g.customShaderOn = false
+		g.initCustomShader()
+	}
+	if old.MediaControls != g.config.MediaControls {
+		if g.config.MediaControls {
+			g.startMediaControls()
+		} else {
+			g.stopMediaControls()
+		}
+	}
 
 	g.resetZoomToInitial()
 	g.calculateDisplayContent()
@@ -165,6 +352,14 @@ func (g *Game) ToggleFullscreen() {
 	g.toggleFullscreen()
 }
 
+func (g *Game) ToggleMaximize() {
+	g.toggleMaximize()
+}
+
+func (g *Game) CycleFullscreenMonitor() {
+	g.cycleFullscreenMonitor()
+}
+
 func (g *Game) ResetWindowSize() {
 	g.resetToDefaultWindowSize()
 }
@@ -177,8 +372,16 @@ func (g *Game) shutdown() {
 	g.didShutdown = true
 	debugKV("startup", "shutdown_begin", "fullscreen", g.fullscreen, "idx", g.idx)
 	g.saveCurrentWindowSize()
-	g.saveCurrentConfig()
+	g.saveConfigOnExit()
 	g.imageManager.StopPreload()
+	g.scripts.Close()
+	g.remote.Close()
+	g.stopMediaControls()
+	g.notifyEventSessionEnded()
+	g.creditReadingTime(time.Now())
+	g.saveReadingProgress()
+	g.removePastedFiles()
+	g.removeDeletedFiles()
 }
 
 func (g *Game) toggleFullscreen() {
@@ -186,9 +389,12 @@ func (g *Game) toggleFullscreen() {
 	g.fullscreen = !g.fullscreen
 	if g.fullscreen {
 		g.savedWinW, g.savedWinH = ebiten.WindowSize()
+		g.windowedMonitor = ebiten.Monitor()
+		moveToMonitor(g.fullscreenMonitor())
 		ebiten.SetFullscreen(true)
 	} else {
 		ebiten.SetFullscreen(false)
+		moveToMonitor(g.windowedMonitor)
 		if g.savedWinW > 0 && g.savedWinH > 0 {
 			ebiten.SetWindowSize(g.savedWinW, g.savedWinH)
 		}
@@ -206,6 +412,28 @@ func (g *Game) toggleFullscreen() {
 	)
 }
 
+// toggleMaximize maximizes the window, which unlike fullscreen leaves the
+// taskbar and title bar visible, or restores it. It leaves fullscreen first.
+func (g *Game) toggleMaximize() {
+	if g.fullscreen {
+		g.toggleFullscreen()
+	}
+	if ebiten.IsWindowMaximized() {
+		ebiten.RestoreWindow()
+		g.config.Maximized = false
+	} else {
+		// Remember the size to restore to at the next start
+		g.config.WindowWidth, g.config.WindowHeight = ebiten.WindowSize()
+		g.saveWindowPosition()
+		ebiten.MaximizeWindow()
+		g.config.Maximized = true
+	}
+	if g.config.TransitionFrames > 0 {
+		g.forceRedrawFrames = g.config.TransitionFrames
+	}
+	debugKV("viewport", "toggle_maximize", "maximized", g.config.Maximized)
+}
+
 func (g *Game) resetToDefaultWindowSize() {
 	currentWidth, currentHeight := ebiten.WindowSize()
 	defaultWidth := g.config.DefaultWindowWidth
@@ -225,6 +453,10 @@ func (g *Game) resetToDefaultWindowSize() {
 		g.showOverlayMessage(fmt.Sprintf("Window size: %dx%d (default)", defaultWidth, defaultHeight))
 	}
 
+	if ebiten.IsWindowMaximized() {
+		ebiten.RestoreWindow()
+		g.config.Maximized = false
+	}
 	ebiten.SetWindowSize(defaultWidth, defaultHeight)
 	g.savedWinW = defaultWidth
 	g.savedWinH = defaultHeight
@@ -1,8 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"image"
+	"runtime"
+	"sync/atomic"
 	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"nv/internal/mpris"
+	"nv/navlogic"
 )
 
 const (
@@ -12,10 +21,22 @@ const (
 
 // DisplayMetadata contains information about what is being displayed.
 type DisplayMetadata struct {
-	LeftPage     int // Page number currently shown on the left side (or single-page slot)
-	RightPage    int // Page number currently shown on the right side, 0 when not used
-	TotalPages   int // Total number of pages
-	ActualImages int // Number of images actually being displayed (1 or 2)
+	LeftPage     int      // Page number currently shown on the left side (or single-page slot)
+	RightPage    int      // Page number currently shown on the right side, 0 when not used
+	TotalPages   int      // Total number of pages
+	ActualImages int      // Number of images actually being displayed (1 or 2)
+	Unreadable   int      // Number of collection entries known to have failed decoding
+	Rating       int      // Stored rating of the current page, 0 when unrated
+	Tags         []string // Stored tags of the current page
+	Filter       string   // Active collection filter, empty when none
+	Chapter      string   // Archive folder of the current page, empty at the root
+	Mode         string   // "S", "B" or "B-single" with why the spread collapsed
+	Anomaly      string   // Why a page on screen looks corrupted, empty when none
+	Marked       bool     // The current page is marked
+	MarkCount    int      // Number of marked pages
+
+	AnimationPaused bool    // Animation playback is paused
+	AnimationSpeed  float64 // Animation playback rate (1 = normal)
 }
 
 // DisplayContent represents what should be displayed on screen.
@@ -33,10 +54,32 @@ type Game struct {
 	mousebindingManager *MousebindingManager
 	idx                 int
 	fullscreen          bool
-	bookMode            bool // Book/spread view mode
-	tempSingleMode      bool // Temporary single page mode (return to book mode after navigation)
-	showHelp            bool // Help overlay display
-	showInfo            bool // Info display (page numbers, metadata, etc.)
+	bookMode            bool   // Book/spread view mode
+	tempSingleMode      bool   // Temporary single page mode (return to book mode after navigation)
+	showHelp            bool   // Help overlay display
+	helpFilter          string // Incremental filter typed into the help overlay
+	helpScroll          int    // First help row shown when the rows are paged
+	showInfo            bool   // Info display (page numbers, metadata, etc.)
+	showLoadErrors      bool   // Unreadable image list overlay
+	pageToastPath       string // Page the page_toast was last shown for
+
+	// Book mode pairing planned for the whole page list, nil until planned
+	spreadLayout          *navlogic.Layout
+	spreadLayoutKey       spreadLayoutKey
+	spreadLayoutCancel    context.CancelFunc // Stops the running plan
+	spreadLayoutResults   chan spreadLayoutResult
+	spreadLayoutLearned   int // Learned spread ratios and threshold it was paired with
+	spreadLayoutThreshold float64
+
+	// Pairings set by hand: per volume in the store, by first page for the
+	// current list
+	pairings        *PairingStore
+	pagePairings    map[int]navlogic.Pairing
+	pagePairingsKey spreadLayoutKey
+
+	// Spreads shown so far, so going back shows the pairs seen going forward
+	spreadIndex    *navlogic.SpreadIndex
+	spreadIndexKey spreadLayoutKey
 
 	// Display content state (what should be rendered)
 	displayContent *DisplayContent
@@ -50,14 +93,29 @@ type Game struct {
 	pageInputMode   bool
 	pageInputBuffer string
 
+	// Text prompt state (rename, tag, filter input)
+	textPrompt       TextPromptKind
+	textPromptBuffer string
+	textPromptStatus string // Live feedback shown beside the buffer (e.g. match count)
+
+	// Live name filter state: unfiltered list and focused path when opened
+	nameFilterBase   []ImagePath
+	nameFilterOrigin string
+
+	// Fuzzy search overlay state; searchBase is the unfiltered list searched
+	searchResults   []SearchResult
+	searchSelection int
+	searchBase      []ImagePath
+
 	// Overlay message state (unified system for boundary, sort, direction messages)
 	overlayMessage     string
 	overlayMessageTime time.Time
 
-	savedWinW       int // Window mode size for restoration (config save)
-	savedWinH       int // Window mode size for restoration (config save)
-	currentLogicalW int // Current logical size for zoom/pan calculations
-	currentLogicalH int // Current logical size for zoom/pan calculations
+	savedWinW       int                 // Window mode size for restoration (config save)
+	savedWinH       int                 // Window mode size for restoration (config save)
+	windowedMonitor *ebiten.MonitorType // Monitor the window returns to from fullscreen
+	currentLogicalW int                 // Current logical size for zoom/pan calculations
+	currentLogicalH int                 // Current logical size for zoom/pan calculations
 	config          Config
 	configPath      string // Custom config file path, empty for default
 
@@ -65,12 +123,57 @@ type Game struct {
 	collectionSource     CollectionSource
 	launchSingleFile     string // Original launch file path when started from a single regular image
 	learnedSpreadAspects []float64
+	loadFailure          *CollectionLoadFailure // Last failed open while nothing is loaded (start screen)
+	lastNavDirection     NavigationDirection    // Direction used when skipping unreadable pages
+
+	// Ratings and tags sidecar database, and the filter narrowing the path list
+	ratings          *RatingStore
+	collectionFilter CollectionFilter
+
+	// Reading progress per volume, nil when tracking is off
+	progress         *ProgressStore
+	reading          readingSession
+	showReadingStats bool
+
+	// Reading direction per volume, nil when not remembered
+	directions          *DirectionStore
+	directionVolume     string // Volume whose direction was applied last
+	directionPagePath   string // Current page and its volume, cached
+	directionPageVolume string
+	bookModeVolume      string // Volume book_mode_policy was applied to last
+	// Set while config.RightToLeft holds a volume's direction rather than
+	// the reader's own, which is kept in readerRightToLeft
+	volumeDirectionActive bool
+	readerRightToLeft     bool
 
 	// Image transformation state
 	rotationAngle int  // 0, 90, 180, 270 degrees
 	flipH         bool // Horizontal flip
 	flipV         bool // Vertical flip
 
+	// Animation playback state (applies to every visible animation)
+	animationPaused bool
+	animationSpeed  float64 // 0 means 1x
+
+	// Exposure in stops for HDR and 16-bit images; starts at the configured
+	// value and is adjusted at runtime by the exposure actions
+	hdrExposure   float64
+	sharpen       float64 // Display filter strengths, adjusted at runtime
+	denoise       float64
+	descreen      bool // Anti-moire blur when zoomed out, per session
+	levelsStretch bool // Auto-stretch levels of 16-bit images
+
+	// Custom post-processing shader from the config
+	customShader         *ebiten.Shader
+	customShaderOn       bool
+	customShaderAnimated bool      // Declares Time, so it redraws every frame
+	customShaderStart    time.Time // Origin of the Time uniform
+
+	// Comparison of the two pages of a spread
+	compareMode         CompareMode
+	compareBlinkElapsed time.Duration
+	compareShowRight    bool // Blink is currently showing the right page
+
 	// Rendering optimization state
 	forceRedrawFrames int  // Force redraw for N frames
 	wasInputHandled   bool // True if input was processed in this frame
@@ -82,10 +185,153 @@ type Game struct {
 	showSettings  bool
 	settingsIndex int
 	pendingConfig Config
+	settingsBase  Config // pendingConfig as opened, to find what was edited
 
 	externalOpenRequests <-chan pendingLaunchRequest
 	instanceBridge       *singleInstanceBridge
 
+	// Native open dialog state (dialog runs off the Ebiten thread)
+	openDialogResults chan openDialogResult
+	openDialogActive  atomic.Bool
+
+	// Contact sheet export state (composed off the Ebiten thread)
+	contactSheetResults chan contactSheetResult
+	contactSheetActive  atomic.Bool
+
+	// Page export state (files copied off the Ebiten thread)
+	pageExportResults chan pageExportResult
+	pageExportActive  atomic.Bool
+
+	// User Lua scripts and the page last reported to their hooks
+	scripts        *scriptEngine
+	scriptLastPath string
+
+	// Page last reported to the image_changed event command
+	eventLastPath string
+
+	// HTTP remote viewer (--serve), nil when disabled
+	remote *remoteServer
+
+	// Pages on screen when the load diagnostics were last shown
+	loadDiagnosticsShown []string
+
+	// Slideshow state; slideshowIdx detects manual navigation
+	slideshowActive  bool
+	slideshowElapsed time.Duration
+	slideshowIdx     int
+
+	// Kiosk mode (--kiosk); while locked only kioskActions run
+	kiosk       bool
+	kioskLocked bool
+
+	// Quick look (--quicklook); closes once the focus it had is lost
+	quicklook        bool
+	quicklookFocused bool
+
+	// Picker mode (--print-selected); selectedPath is printed on exit
+	printSelected bool
+	selectedPath  string
+
+	// Marked pages by path; acceptedMarks is written out on exit, and so are
+	// all marks on any exit when markedFile (--marked-file) is set.
+	// quitArmedAt is the first quit that warned about unwritten marks.
+	marks         map[string]bool
+	acceptedMarks []string
+	markedFile    string
+	quitArmedAt   time.Time
+	closeHandled  bool
+
+	// Deleted files held in deleteHoldDir for undo; deleteArmed is the path
+	// waiting for the confirming second press
+	deleteHoldDir string
+	deleteHeld    int
+	deleteArmed   string
+	deleteArmedAt time.Time
+
+	// Undo history of deletes, renames and jumps
+	undoStack     []undoEntry
+	redoStack     []undoEntry
+	replayingUndo bool
+
+	// "Volume finished" interstitial after the last page of an archive
+	volumeEnd *volumeEndState
+
+	// Pages visited by jumps, for history_back and history_forward
+	navHistory        navHistory
+	navigatingHistory bool
+
+	// Set while a jump is made by the slideshow or a script rather than the
+	// reader; such jumps are not recorded for undo or history
+	automaticJump bool
+
+	// Guided reading timer; readingTimerIdx detects manual navigation
+	readingTimerActive  bool
+	readingTimerElapsed time.Duration
+	readingTimerIdx     int
+
+	// Spinner over pages still decoding; loadingSince is zero once loaded
+	loadingSince   time.Time
+	loadingVisible bool
+	loadingStep    int
+
+	// Screenshot capture: requested by the action, read back in Draw
+	screenshotPending bool
+	screenshotResults chan screenshotResult
+
+	// Print job state (decoded and spooled off the Ebiten thread)
+	printResults chan printResult
+	printActive  atomic.Bool
+
+	// Startup update check; nil once its result has been shown
+	updateResults chan updateCheckResult
+
+	// OCR job state (recognized off the Ebiten thread)
+	ocrResults chan ocrResult
+	ocrActive  atomic.Bool
+
+	// Images pasted from the clipboard, kept as temporary files until exit
+	clipboardPasteResults chan clipboardPasteResult
+	clipboardPasteActive  atomic.Bool
+	pastedFiles           []string
+
+	// Upscaled versions of small pages shown fullscreen
+	upscale *upscaler
+
+	// Presentation mode, laser pointer and screen blanking
+	presenting     bool
+	blankMode      BlankMode
+	laserX, laserY int
+
+	// Cursor hidden while the pointer rests or the laser pointer is shown
+	cursorHidden  bool
+	cursorPos     image.Point
+	cursorMovedAt time.Time
+
+	// Auto-hiding on-screen controls
+	controlsVisible bool
+	controlsHovered int // Index of the button under the pointer, or -1
+	controlsPointer image.Point
+	controlsScreen  image.Point // Screen size the buttons were placed for
+	controlsShownAt time.Time
+
+	guideMode GuideMode
+
+	// Ruler: screen points of the last drag and the view it was taken in
+	measuring    bool
+	measureShown bool
+	measureStart image.Point
+	measureEnd   image.Point
+	measureView  viewTransform
+	measureIdx   int
+
+	// MPRIS media controls (Linux), nil when unavailable
+	mediaPlayer   *mpris.Player
+	mediaCommands chan mpris.Command
+
+	// Idle frame throttling; lastBusy is the last frame that needed redrawing
+	idleThrottled atomic.Bool
+	lastBusy      time.Time
+
 	exitRequested bool
 	didShutdown   bool
 }
@@ -119,6 +365,9 @@ func (g *Game) flipVertical() {
 }
 
 func (g *Game) Exit() {
+	if !g.confirmQuit(time.Now()) {
+		return
+	}
 	g.exitRequested = true
 }
 
@@ -127,8 +376,22 @@ func (g *Game) IsFullscreen() bool {
 	return g.fullscreen
 }
 
+// FitsSmallImages reports whether fit to window enlarges images smaller than
+// the screen: always in fullscreen, and in a window with upscale_in_window.
+func (g *Game) FitsSmallImages() bool {
+	return g.fullscreen || g.config.UpscaleInWindow
+}
+
+func (g *Game) GetCompareMode() CompareMode {
+	return g.compareMode
+}
+
+func (g *Game) IsCompareShowingRight() bool {
+	return g.compareShowRight
+}
+
 func (g *Game) GetRotationAngle() int {
-	return g.rotationAngle
+	return g.displayRotation()
 }
 
 func (g *Game) IsFlippedH() bool {
@@ -143,10 +406,30 @@ func (g *Game) IsShowingHelp() bool {
 	return g.showHelp
 }
 
+func (g *Game) GetHelpFilter() string {
+	return g.helpFilter
+}
+
+func (g *Game) GetHelpScroll() int {
+	return g.helpScroll
+}
+
 func (g *Game) IsShowingInfo() bool {
 	return g.showInfo
 }
 
+func (g *Game) IsShowingLoadErrors() bool {
+	return g.showLoadErrors
+}
+
+func (g *Game) IsShowingReadingStats() bool {
+	return g.showReadingStats
+}
+
+func (g *Game) GetLoadErrors() []ImageLoadError {
+	return g.imageManager.GetLoadErrors()
+}
+
 func (g *Game) IsInPageInputMode() bool {
 	return g.pageInputMode
 }
@@ -155,6 +438,30 @@ func (g *Game) GetPageInputBuffer() string {
 	return g.pageInputBuffer
 }
 
+func (g *Game) IsInTextPrompt() bool {
+	return g.textPrompt != TextPromptNone
+}
+
+func (g *Game) GetTextPromptKind() TextPromptKind {
+	return g.textPrompt
+}
+
+func (g *Game) GetTextPromptBuffer() string {
+	return g.textPromptBuffer
+}
+
+func (g *Game) GetTextPromptStatus() string {
+	return g.textPromptStatus
+}
+
+func (g *Game) GetSearchResults() []SearchResult {
+	return g.searchResults
+}
+
+func (g *Game) GetSearchSelection() int {
+	return g.searchSelection
+}
+
 func (g *Game) GetOverlayMessage() string {
 	return g.overlayMessage
 }
@@ -191,19 +498,47 @@ func (g *Game) GetMousebindings() map[string][]string {
 	return g.mousebindingManager.GetMousebindings()
 }
 
+func (g *Game) GetRecentFiles() []string {
+	return g.config.RecentFiles
+}
+
+func (g *Game) GetLoadFailure() *CollectionLoadFailure {
+	return g.loadFailure
+}
+
 func (g *Game) GetDisplayContent() *DisplayContent {
 	return g.displayContent
 }
 
 // InputActions interface implementation
 func (g *Game) ToggleHelp() {
-	g.showHelp = !g.showHelp
+	g.toggleHelp()
+}
+
+func (g *Game) UpdateHelpFilter(filter string) {
+	g.updateHelpFilter(filter)
+}
+
+func (g *Game) ScrollHelp(delta int) {
+	g.scrollHelp(delta)
+}
+
+func (g *Game) PageHelp(delta int) {
+	g.pageHelp(delta)
 }
 
 func (g *Game) ToggleInfo() {
 	g.showInfo = !g.showInfo
 }
 
+func (g *Game) ToggleLoadErrors() {
+	g.showLoadErrors = !g.showLoadErrors
+}
+
+func (g *Game) ToggleReadingStats() {
+	g.toggleReadingStats()
+}
+
 func (g *Game) ToggleBookMode() {
 	g.toggleBookMode()
 }
@@ -226,6 +561,62 @@ func (g *Game) UpdatePageInputBuffer(buffer string) {
 	g.pageInputBuffer = buffer
 }
 
+func (g *Game) SearchFromPageInput(query string) {
+	g.searchFromPageInput(query)
+}
+
+func (g *Game) EnterRenameMode() {
+	g.enterRenameMode()
+}
+
+func (g *Game) ExitTextPrompt() {
+	g.cancelTextPrompt()
+}
+
+func (g *Game) SubmitTextPrompt() {
+	g.submitTextPrompt()
+}
+
+func (g *Game) UpdateTextPromptBuffer(buffer string) {
+	g.updateTextPromptBuffer(buffer)
+}
+
+func (g *Game) MoveTextPromptSelection(delta int) {
+	g.moveTextPromptSelection(delta)
+}
+
+func (g *Game) OpenChapterList() {
+	g.openChapterList()
+}
+
+func (g *Game) EnterSearch() {
+	g.enterSearch()
+}
+
+func (g *Game) EnterConfigExport() {
+	g.enterConfigExport()
+}
+
+func (g *Game) EnterConfigImport() {
+	g.enterConfigImport()
+}
+
+func (g *Game) SetRating(rating int) {
+	g.setCurrentRating(rating)
+}
+
+func (g *Game) EnterTagInput() {
+	g.enterTagInput()
+}
+
+func (g *Game) EnterFilterInput() {
+	g.enterFilterInput()
+}
+
+func (g *Game) EnterNameFilter() {
+	g.enterNameFilter()
+}
+
 func (g *Game) ToggleReadingDirection() {
 	g.toggleReadingDirection()
 }
@@ -238,23 +629,35 @@ func (g *Game) MarkCurrentAsPreJoinedSpread() {
 	g.markCurrentAsPreJoinedSpread()
 }
 
+func (g *Game) ForcePair() {
+	g.forcePair()
+}
+
+func (g *Game) ForceSplit() {
+	g.forceSplit()
+}
+
 func (g *Game) NavigateNext() {
 	g.navigateNext(false)
+	g.skipUnreadablePages(NavigationForward)
 	g.imageManager.StartPreload(g.idx, NavigationForward)
 }
 
 func (g *Game) NavigatePrevious() {
 	g.navigatePrevious(false)
+	g.skipUnreadablePages(NavigationBackward)
 	g.imageManager.StartPreload(g.idx, NavigationBackward)
 }
 
 func (g *Game) NavigateNextSingle() {
 	g.navigateNext(true)
+	g.skipUnreadablePages(NavigationForward)
 	g.imageManager.StartPreload(g.idx, NavigationForward)
 }
 
 func (g *Game) NavigatePreviousSingle() {
 	g.navigatePrevious(true)
+	g.skipUnreadablePages(NavigationBackward)
 	g.imageManager.StartPreload(g.idx, NavigationBackward)
 }
 
@@ -262,11 +665,191 @@ func (g *Game) JumpToPage(page int) {
 	g.jumpToPage(page)
 }
 
+func (g *Game) JumpByPercent(percent int) {
+	g.jumpByPercent(percent)
+}
+
+func (g *Game) NextChapter() {
+	g.jumpToNextChapter()
+}
+
+func (g *Game) NextAnomaly() {
+	g.jumpToNextAnomaly()
+}
+
+func (g *Game) PreviousChapter() {
+	g.jumpToPreviousChapter()
+}
+
 func (g *Game) ExpandToDirectory() {
 	g.expandToDirectoryAndJump()
 	g.imageManager.StartPreload(g.idx, NavigationJump)
 }
 
+func (g *Game) ReloadCurrentImage() {
+	g.reloadCurrentImages()
+}
+
+func (g *Game) RescanCollection() {
+	g.rescanCollection()
+}
+
+func (g *Game) OpenFileDialog() {
+	g.startOpenDialog(fileDialogFiles)
+}
+
+func (g *Game) OpenDirectoryDialog() {
+	g.startOpenDialog(fileDialogDirectory)
+}
+
+func (g *Game) ToggleAnimationPause() {
+	g.toggleAnimationPause()
+}
+
+func (g *Game) StepAnimationFrame(delta int) {
+	g.stepAnimationFrame(delta)
+}
+
+func (g *Game) ChangeAnimationSpeed(delta int) {
+	g.changeAnimationSpeed(delta)
+}
+
+func (g *Game) ChangeHDRExposure(delta float64) {
+	g.changeHDRExposure(delta)
+}
+
+func (g *Game) ResetHDRExposure() {
+	g.resetHDRExposure()
+}
+
+func (g *Game) ChangeSharpen(delta float64) {
+	g.changeSharpen(delta)
+}
+
+func (g *Game) ChangeDenoise(delta float64) {
+	g.changeDenoise(delta)
+}
+
+func (g *Game) ToggleDescreen() {
+	g.toggleDescreen()
+}
+
+func (g *Game) ToggleCustomShader() {
+	g.toggleCustomShader()
+}
+
+func (g *Game) RunScriptAction(name string) {
+	g.runScriptAction(name)
+}
+
+func (g *Game) ExportContactSheet() {
+	g.exportContactSheet()
+}
+
+func (g *Game) ExportPages() {
+	g.startPageExport()
+}
+
+func (g *Game) Screenshot() {
+	g.requestScreenshot()
+}
+
+func (g *Game) ExportSpread() {
+	g.exportSpread()
+}
+
+func (g *Game) PrintCurrent(spread bool) {
+	g.printCurrent(spread)
+}
+
+func (g *Game) TogglePresentation() {
+	g.togglePresentation()
+}
+
+func (g *Game) ToggleLaserPointer() {
+	g.toggleLaserPointer()
+}
+
+func (g *Game) CycleBlankScreen() {
+	g.cycleBlankScreen()
+}
+
+func (g *Game) CycleGuideMode() {
+	g.cycleGuideMode()
+}
+
+func (g *Game) PasteImage() {
+	g.pasteClipboardImage(runtime.GOOS)
+}
+
+func (g *Game) AcceptSelection() {
+	g.acceptSelection()
+}
+
+func (g *Game) DeleteFile() {
+	g.deleteCurrentFile(time.Now())
+}
+
+func (g *Game) HistoryBack() {
+	g.historyBack()
+}
+
+func (g *Game) HistoryForward() {
+	g.historyForward()
+}
+
+func (g *Game) Undo() {
+	g.undo()
+}
+
+func (g *Game) Redo() {
+	g.redo()
+}
+
+func (g *Game) ToggleMark() {
+	g.toggleMark()
+}
+
+func (g *Game) AcceptMarked() {
+	g.acceptMarked()
+}
+
+func (g *Game) ExtractText() {
+	g.ocrCurrent()
+}
+
+func (g *Game) ToggleMeasure() {
+	g.toggleMeasure()
+}
+
+func (g *Game) MeasureFrom(x, y int) {
+	g.measureFrom(x, y)
+}
+
+func (g *Game) MeasureTo(x, y int) bool {
+	return g.measureTo(x, y)
+}
+
+func (g *Game) ToggleSlideshow() {
+	g.toggleSlideshow()
+}
+
+func (g *Game) ToggleKioskLock() {
+	g.toggleKioskLock()
+}
+
+func (g *Game) ToggleReadingTimer() {
+	g.toggleReadingTimer()
+}
+
+func (g *Game) CycleCompareMode() {
+	g.cycleCompareMode()
+}
+
+func (g *Game) ToggleLevelsStretch() {
+	g.toggleLevelsStretch()
+}
+
 func (g *Game) RotateLeft() {
 	g.rotateLeft()
 }
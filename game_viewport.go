@@ -58,6 +58,9 @@ func (g *Game) zoomIn() {
 	}
 
 	newLevel := g.zoomState.Level * 1.25
+	if g.config.IntegerZoom {
+		newLevel = stepIntegerZoom(g.zoomState.Level, true)
+	}
 	if newLevel > 4.0 {
 		g.zoomState.Level = 4.0
 		g.showOverlayMessage("Maximum zoom 400%")
@@ -77,6 +80,9 @@ func (g *Game) zoomOut() {
 	}
 
 	newLevel := g.zoomState.Level / 1.25
+	if g.config.IntegerZoom {
+		newLevel = stepIntegerZoom(g.zoomState.Level, false)
+	}
 	if newLevel < 0.25 {
 		g.zoomState.Level = 0.25
 		g.showOverlayMessage("Minimum zoom 25%")
@@ -123,6 +129,21 @@ func (g *Game) zoomFit() {
 	debugKV("viewport", "zoom_fit_cycle", "prev_mode", prevMode, "next_mode", g.zoomState.Mode, "level", g.zoomState.Level)
 }
 
+// toggleUpscaleInWindow switches whether fit to window enlarges small images
+// in windowed mode too.
+func (g *Game) toggleUpscaleInWindow() {
+	g.config.UpscaleInWindow = !g.config.UpscaleInWindow
+	if g.zoomState.Mode == ZoomModeFitWindow {
+		g.updateZoomLevelForFitMode()
+	}
+	state := "off"
+	if g.config.UpscaleInWindow {
+		state = "on"
+	}
+	g.showOverlayMessage("Enlarge small images in window: " + state)
+	debugKV("viewport", "upscale_in_window", "enabled", g.config.UpscaleInWindow)
+}
+
 func (g *Game) switchToManual100() {
 	prevMode := g.zoomState.Mode
 	g.zoomState.Mode = ZoomModeManual
@@ -138,6 +159,9 @@ func (g *Game) panUp() {
 		return
 	}
 
+	if g.turnPageAtPanEdge(-1) {
+		return
+	}
 	_, stepY := g.getPanStep()
 	g.zoomState.PanOffsetY += stepY
 	g.clampPanToLimits()
@@ -149,6 +173,9 @@ func (g *Game) panDown() {
 		return
 	}
 
+	if g.turnPageAtPanEdge(1) {
+		return
+	}
 	_, stepY := g.getPanStep()
 	g.zoomState.PanOffsetY -= stepY
 	g.clampPanToLimits()
@@ -187,6 +214,24 @@ func (g *Game) panByDelta(deltaX, deltaY float64) {
 	g.clampPanToLimits()
 }
 
+// defaultPanSpeed is the held-key pan speed in screens per second.
+const defaultPanSpeed = 1.0
+
+// panContinuous moves the view for one tick of a held pan key towards
+// (dirX, dirY), each -1, 0 or 1 with positive values panning right and
+// down, at pan_speed screens per second. Diagonals are as fast as straight
+// moves.
+func (g *Game) panContinuous(dirX, dirY float64) {
+	if g.zoomState.Mode == ZoomModeFitWindow || (dirX == 0 && dirY == 0) {
+		return
+	}
+	perTick := g.config.PanSpeed / float64(ebiten.TPS())
+	if dirX != 0 && dirY != 0 {
+		perTick /= math.Sqrt2
+	}
+	g.panByDelta(-dirX*perTick*float64(g.currentLogicalW), -dirY*perTick*float64(g.currentLogicalH))
+}
+
 // getPanStep calculates dynamic pan step size based on screen size and zoom level.
 func (g *Game) getPanStep() (float64, float64) {
 	stepX := float64(g.currentLogicalW) * 0.1
@@ -214,7 +259,7 @@ func (g *Game) updateZoomLevelForFitMode() {
 	var scale float64
 	switch g.zoomState.Mode {
 	case ZoomModeFitWindow:
-		if g.fullscreen {
+		if g.FitsSmallImages() {
 			scale = math.Min(w/fiw, h/fih)
 		} else if fiw > w || fih > h {
 			scale = math.Min(w/fiw, h/fih)
@@ -230,6 +275,9 @@ func (g *Game) updateZoomLevelForFitMode() {
 	}
 
 	scale *= ebiten.Monitor().DeviceScaleFactor()
+	if g.config.IntegerZoom {
+		scale = snapIntegerZoom(scale)
+	}
 	g.zoomState.Level = scale
 	debugKV("viewport", "fit_scale_updated",
 		"mode", g.zoomState.Mode,
@@ -312,7 +360,7 @@ func (g *Game) getTransformedImageSize() (int, int) {
 		h = int(math.Max(float64(leftH), float64(rightH)))
 	}
 
-	if g.rotationAngle == 90 || g.rotationAngle == 270 {
+	if angle := g.displayRotation(); angle == 90 || angle == 270 {
 		return h, w
 	}
 	return w, h
@@ -412,6 +460,10 @@ func (g *Game) ZoomFit() {
 	g.zoomFit()
 }
 
+func (g *Game) ToggleUpscaleInWindow() {
+	g.toggleUpscaleInWindow()
+}
+
 func (g *Game) PanUp() {
 	g.panUp()
 }
@@ -431,3 +483,23 @@ func (g *Game) PanRight() {
 func (g *Game) PanByDelta(deltaX, deltaY float64) {
 	g.panByDelta(deltaX, deltaY)
 }
+
+func (g *Game) PanContinuous(dirX, dirY float64) {
+	g.panContinuous(dirX, dirY)
+}
+
+func (g *Game) PanPage(dir int) {
+	g.panPage(dir)
+}
+
+func (g *Game) SmartScroll(dir int) {
+	g.smartScroll(dir)
+}
+
+func (g *Game) TurnPageAtPanEdge(dir int) bool {
+	return g.turnPageAtPanEdge(dir)
+}
+
+func (g *Game) GetPanSpeed() float64 {
+	return g.config.PanSpeed
+}
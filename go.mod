@@ -4,10 +4,16 @@ go 1.24.3
 
 require (
 	github.com/bodgit/sevenzip v1.6.1
+	github.com/go-text/typesetting v0.2.0
 	github.com/hajimehoshi/ebiten/v2 v2.8.8
+	github.com/hashicorp/golang-lru/v2 v2.0.7
+	github.com/klauspost/compress v1.17.11
 	github.com/maruel/natural v1.1.1
 	github.com/nwaples/rardecode v1.1.3
+	github.com/ulikunitz/xz v0.5.12
+	github.com/yuin/gopher-lua v1.1.1
 	golang.org/x/image v0.28.0
+	golang.org/x/sys v0.25.0
 )
 
 require (
@@ -17,15 +23,10 @@ require (
 	github.com/ebitengine/gomobile v0.0.0-20240911145611-4856209ac325 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/ebitengine/purego v0.8.0 // indirect
-	github.com/go-text/typesetting v0.2.0 // indirect
-	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
-	github.com/klauspost/compress v1.17.11 // indirect
 	github.com/pierrec/lz4/v4 v4.1.22 // indirect
 	github.com/spf13/afero v1.11.0 // indirect
-	github.com/ulikunitz/xz v0.5.12 // indirect
 	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
 	golang.org/x/sync v0.15.0 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
 )
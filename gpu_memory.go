@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"slices"
+)
+
+// GPUMemoryStats is the approximate texture memory held by the image cache.
+type GPUMemoryStats struct {
+	Used  int64 // Bytes of cached textures
+	Limit int64 // Cap in bytes; 0 when unlimited
+}
+
+// String formats the stats for the debug info line, e.g. "GPU 312/512 MB".
+func (s GPUMemoryStats) String() string {
+	used := (s.Used + 1<<20 - 1) >> 20
+	if s.Limit <= 0 {
+		return fmt.Sprintf("GPU %d MB", used)
+	}
+	return fmt.Sprintf("GPU %d/%d MB", used, s.Limit>>20)
+}
+
+// displayImageBytes estimates the GPU memory of img: every tile of every
+// animation frame as an RGBA texture.
+func displayImageBytes(img DisplayImage) int64 {
+	var frames [][]DisplayTile
+	switch v := img.(type) {
+	case nil:
+		return 0
+	case *animatedDisplayImage:
+		frames = v.frames
+	default:
+		frames = [][]DisplayTile{img.Tiles()}
+	}
+	var total int64
+	for _, tiles := range frames {
+		for _, tile := range tiles {
+			total += textureBytes(image.Rect(0, 0, tile.W, tile.H))
+		}
+	}
+	return total
+}
+
+// SetGPUMemoryCap sets the texture memory the cache may hold; 0 or less
+// means unlimited. Lowering it evicts right away.
+func (m *DefaultImageManager) SetGPUMemoryCap(limit int64) {
+	m.gpuCap.Store(max(0, limit))
+	m.enforceGPUCap(imageCacheKey{})
+}
+
+// GetGPUMemory reports the texture memory held by the cache.
+func (m *DefaultImageManager) GetGPUMemory() GPUMemoryStats {
+	return GPUMemoryStats{Used: m.gpuBytes.Load(), Limit: m.gpuCap.Load()}
+}
+
+// SetShownPages records the pages on screen; they are never evicted to meet
+// the GPU memory cap, so the renderer is not left with freed textures.
+func (m *DefaultImageManager) SetShownPages(indices ...int) {
+	keys := make([]imageCacheKey, 0, len(indices))
+	for _, idx := range indices {
+		if p, ok := m.getPath(idx); ok {
+			keys = append(keys, m.cacheKeyFor(p))
+		}
+	}
+	m.shownKeys.Store(&keys)
+}
+
+func (m *DefaultImageManager) isShown(key imageCacheKey) bool {
+	keys := m.shownKeys.Load()
+	return keys != nil && slices.Contains(*keys, key)
+}
+
+// addToCache caches img under key and counts its texture memory. An entry
+// already under key is released first so its memory is not lost track of.
+func (m *DefaultImageManager) addToCache(key imageCacheKey, img DisplayImage) {
+	if old, ok := m.cache.Peek(key); ok {
+		if old == img {
+			m.cache.Get(key)
+			return
+		}
+		m.cache.Remove(key)
+	}
+	m.gpuBytes.Add(displayImageBytes(img))
+	m.cache.Add(key, img)
+	m.enforceGPUCap(key)
+}
+
+// enforceGPUCap evicts the least recently used images until the cache fits
+// the GPU memory cap, sparing keep and the pages on screen, which alone
+// may still exceed it.
+func (m *DefaultImageManager) enforceGPUCap(keep imageCacheKey) {
+	limit := m.gpuCap.Load()
+	if limit <= 0 || m.gpuBytes.Load() <= limit {
+		return
+	}
+	evicted := 0
+	for _, key := range m.cache.Keys() {
+		if m.gpuBytes.Load() <= limit {
+			break
+		}
+		if key == keep || m.isShown(key) {
+			continue
+		}
+		if m.cache.Remove(key) {
+			evicted++
+		}
+	}
+	debugKV("cache", "gpu_cap_evict",
+		"evicted", evicted,
+		"used_mb", m.gpuBytes.Load()>>20,
+		"limit_mb", limit>>20,
+	)
+}
+
+// GetGPUMemory reports the texture memory of the image cache, or false when
+// the image manager does not track it.
+func (g *Game) GetGPUMemory() (GPUMemoryStats, bool) {
+	if dm, ok := g.imageManager.(*DefaultImageManager); ok {
+		return dm.GetGPUMemory(), true
+	}
+	return GPUMemoryStats{}, false
+}
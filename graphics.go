@@ -24,12 +24,13 @@ func InitGraphics() error {
 	return nil
 }
 
-// DrawText draws text with specified position and color
-func DrawText(screen *ebiten.Image, textString string, font *text.GoTextFace, x, y float64, textColor color.RGBA) {
+// DrawText draws text with specified position and color. Characters no
+// font can draw are spelled out, see readableText.
+func DrawText(screen *ebiten.Image, textString string, font text.Face, x, y float64, textColor color.RGBA) {
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(x, y)
 	op.ColorScale.ScaleWithColor(textColor)
-	text.Draw(screen, textString, font, op)
+	text.Draw(screen, readableText(textString), font, op)
 }
 
 // DrawFilledRect draws filled rectangles with float64 coordinates
@@ -63,10 +64,7 @@ func CreateErrorImage(width, height int, filename, errorMsg string) *ebiten.Imag
 	errorImg.Fill(color.RGBA{120, 30, 30, 255}) // Dark red background
 
 	// Create font for error text
-	errorFont := &text.GoTextFace{
-		Source: globalFontSource,
-		Size:   20.0,
-	}
+	errorFont := newTextFace(globalFontSource, 20)
 
 	// Draw white border
 	DrawFilledRect(errorImg, 0, 0, float64(width), 3, color.RGBA{255, 255, 255, 255})
@@ -100,6 +100,11 @@ func TestGUI_RendererCachesIntermediateImages(t *testing.T) {
 	if transformed3 == transformed2 {
 		t.Fatal("expected cache invalidation when rotation changes")
 	}
+
+	g.rotationAngle = 0
+	if got := r.applyTransformations(book1); got != book1 || r.transformCache.image != nil {
+		t.Fatal("expected the transformed copy to be released without a transformation")
+	}
 }
 
 func TestGUI_CalculateDisplayContentUsesNavigationPlan(t *testing.T) {
@@ -213,6 +218,58 @@ func TestGUI_BuildPageNumberStringUsesScreenOrder(t *testing.T) {
 			},
 			expected: "4←3 / 10",
 		},
+		{
+			name: "unreadable count",
+			metadata: DisplayMetadata{
+				LeftPage:     3,
+				TotalPages:   10,
+				ActualImages: 1,
+				Unreadable:   2,
+			},
+			expected: "3 / 10 (2 unreadable)",
+		},
+		{
+			name: "collapsed spread",
+			metadata: DisplayMetadata{
+				LeftPage:     10,
+				TotalPages:   10,
+				ActualImages: 1,
+				Mode:         "B-single (last page)",
+			},
+			expected: "B-single (last page) 10 / 10",
+		},
+		{
+			name: "anomaly badge",
+			metadata: DisplayMetadata{
+				LeftPage:     4,
+				TotalPages:   10,
+				ActualImages: 1,
+				Unreadable:   1,
+				Anomaly:      "low resolution 120x160",
+			},
+			expected: "4 / 10 (1 unreadable) (! low resolution 120x160)",
+		},
+		{
+			name: "marked page",
+			metadata: DisplayMetadata{
+				LeftPage:     2,
+				TotalPages:   10,
+				ActualImages: 1,
+				Marked:       true,
+				MarkCount:    3,
+			},
+			expected: "2 / 10 [marked, 3 total]",
+		},
+		{
+			name: "marks elsewhere",
+			metadata: DisplayMetadata{
+				LeftPage:     5,
+				TotalPages:   10,
+				ActualImages: 1,
+				MarkCount:    3,
+			},
+			expected: "5 / 10 [3 marked]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -341,7 +398,7 @@ func TestGUI_CreateDisplayImageTilesWhenOverLimit(t *testing.T) {
 	})
 
 	src := image.NewNRGBA(image.Rect(0, 0, defaultTileSize+1, 4))
-	img, err := manager.createEbitenImageFromDecoded(src, "large.png")
+	img, err := manager.createEbitenImageFromDecoded(src, ImagePath{Path: "large.png"})
 	if err != nil {
 		t.Fatalf("createEbitenImageFromDecoded() error = %v", err)
 	}
@@ -357,3 +414,33 @@ func TestGUI_CreateDisplayImageTilesWhenOverLimit(t *testing.T) {
 		t.Fatalf("expected tiled image, got %d tile(s)", img.TileCount())
 	}
 }
+
+func TestGUI_RendererReusesRenderedPanels(t *testing.T) {
+	g := &Game{
+		config:              Config{FontSize: 18},
+		keybindingManager:   NewKeybindingManager(map[string][]string{"next": {"Space"}}),
+		mousebindingManager: NewMousebindingManager(map[string][]string{}, MouseSettings{}),
+		overlayMessage:      "Saved",
+	}
+	r := NewRenderer(g)
+	screen := ebiten.NewImage(1600, 1000)
+
+	r.drawHelpOverlay(screen)
+	help := r.help.image
+	r.drawHelpOverlay(screen)
+	if help == nil || r.help.image != help {
+		t.Fatal("expected the rendered help overlay to be reused")
+	}
+
+	r.drawOverlayMessage(screen)
+	message := r.messagePanel.image
+	r.drawOverlayMessage(screen)
+	if message == nil || r.messagePanel.image != message {
+		t.Fatal("expected the rendered message to be reused")
+	}
+	g.overlayMessage = "Deleted"
+	r.drawOverlayMessage(screen)
+	if r.messagePanel.image == message {
+		t.Fatal("expected a new message to be rendered again")
+	}
+}
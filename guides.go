@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// GuideMode selects the reference lines drawn over the image.
+type GuideMode int
+
+const (
+	GuideOff GuideMode = iota
+	GuideThirds
+	GuideGrid
+	GuideCenter
+)
+
+func (m GuideMode) String() string {
+	switch m {
+	case GuideThirds:
+		return "Rule of thirds"
+	case GuideGrid:
+		return "Grid"
+	case GuideCenter:
+		return "Center cross"
+	default:
+		return "Off"
+	}
+}
+
+const (
+	defaultGridSpacing = 100
+	defaultGuideColor  = "#00FFFF99"
+
+	// Grid lines closer than this on screen are skipped to avoid a solid fill
+	minGridScreenSpacing = 4
+)
+
+// parseHexColor reads a config color, "#RRGGBB" or "#RRGGBBAA".
+func parseHexColor(s string) (color.NRGBA, error) {
+	hex, ok := strings.CutPrefix(strings.TrimSpace(s), "#")
+	if !ok || (len(hex) != 6 && len(hex) != 8) {
+		return color.NRGBA{}, fmt.Errorf("color %q is not #RRGGBB or #RRGGBBAA", s)
+	}
+	c := color.NRGBA{A: 255}
+	var err error
+	if len(hex) == 6 {
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	} else {
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	}
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("color %q is not #RRGGBB or #RRGGBBAA", s)
+	}
+	return c, nil
+}
+
+// guideLine is a segment in screen coordinates.
+type guideLine struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// guideLines returns the lines of mode over the image shown at view.
+// spacing is the grid cell size in image pixels, so the grid follows zoom.
+func guideLines(mode GuideMode, view viewTransform, spacing int) []guideLine {
+	x, y, w, h := view.OffsetX, view.OffsetY, view.Width, view.Height
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	vertical := func(lx float64) guideLine { return guideLine{lx, y, lx, y + h} }
+	horizontal := func(ly float64) guideLine { return guideLine{x, ly, x + w, ly} }
+
+	switch mode {
+	case GuideThirds:
+		return []guideLine{
+			vertical(x + w/3), vertical(x + w*2/3),
+			horizontal(y + h/3), horizontal(y + h*2/3),
+		}
+	case GuideCenter:
+		return []guideLine{vertical(x + w/2), horizontal(y + h/2)}
+	case GuideGrid:
+		step := float64(spacing) * view.Scale
+		if step < minGridScreenSpacing {
+			return nil
+		}
+		var lines []guideLine
+		for lx := x + step; lx < x+w; lx += step {
+			lines = append(lines, vertical(lx))
+		}
+		for ly := y + step; ly < y+h; ly += step {
+			lines = append(lines, horizontal(ly))
+		}
+		return lines
+	}
+	return nil
+}
+
+func (g *Game) cycleGuideMode() {
+	g.guideMode = (g.guideMode + 1) % 4
+	g.showOverlayMessage("Guides: " + g.guideMode.String())
+	debugKV("ui", "guides", "mode", g.guideMode.String(), "spacing", g.config.GridSpacing)
+}
+
+func (g *Game) GetGuideMode() GuideMode {
+	return g.guideMode
+}
+
+// GetGuideStyle returns the configured line color and grid spacing.
+func (g *Game) GetGuideStyle() (color.NRGBA, int) {
+	c, err := parseHexColor(g.config.GuideColor)
+	if err != nil {
+		c, _ = parseHexColor(defaultGuideColor)
+	}
+	return c, g.config.GridSpacing
+}
+
+func (r *Renderer) drawGuides(screen *ebiten.Image) {
+	mode := r.renderState.GetGuideMode()
+	if mode == GuideOff {
+		return
+	}
+	c, spacing := r.renderState.GetGuideStyle()
+	for _, l := range guideLines(mode, r.view, spacing) {
+		vector.StrokeLine(screen, float32(l.X0), float32(l.Y0), float32(l.X1), float32(l.Y1), 1, c, true)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"nv/internal/imgdecode"
+)
+
+const (
+	// hdrExposureStep is the exposure change per keypress, in stops
+	hdrExposureStep = 0.5
+	// maxHDRExposure bounds exposure compensation in both directions
+	maxHDRExposure = 10.0
+)
+
+func clampHDRExposure(ev float64) float64 {
+	return max(-maxHDRExposure, min(maxHDRExposure, ev))
+}
+
+// SetToneMapping changes how HDR images are converted for display. Cached
+// HDR images are dropped so the next lookup decodes them again with the new
+// settings.
+func (m *DefaultImageManager) SetToneMapping(t imgdecode.ToneMapping) {
+	m.adjustMu.Lock()
+	changed := m.toneMapping != t
+	m.toneMapping = t
+	m.adjustMu.Unlock()
+	if !changed {
+		return
+	}
+	purged := m.purgeAdjustedImages()
+	debugKV("cache", "tone_mapping_changed",
+		"operator", t.Operator,
+		"exposure", t.Exposure,
+		"purged", purged,
+	)
+}
+
+func (m *DefaultImageManager) currentToneMapping() imgdecode.ToneMapping {
+	m.adjustMu.RLock()
+	defer m.adjustMu.RUnlock()
+	return m.toneMapping
+}
+
+// loadHDRFromBytes decodes a Radiance or OpenEXR image and tone maps it with
+// the current settings.
+func (m *DefaultImageManager) loadHDRFromBytes(data []byte, p ImagePath) (DisplayImage, error) {
+	hdr, err := imgdecode.DecodeHDR(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", loadOrigin(p), err)
+	}
+	return m.createAdjustedImage(m.currentToneMapping().Apply(hdr), p)
+}
+
+func (g *Game) toneMapping() imgdecode.ToneMapping {
+	return imgdecode.ToneMapping{Operator: g.config.ToneMapOperator, Exposure: g.hdrExposure}
+}
+
+// applyToneMapping pushes the current tone mapping and levels to the image
+// manager and redraws, which reloads any HDR or 16-bit page on screen.
+func (g *Game) applyToneMapping() {
+	if dm, ok := g.imageManager.(*DefaultImageManager); ok {
+		dm.SetToneMapping(g.toneMapping())
+		dm.SetLevels(g.levels())
+	}
+	g.calculateDisplayContent()
+}
+
+func (g *Game) setHDRExposure(ev float64) {
+	g.hdrExposure = clampHDRExposure(ev)
+	g.applyToneMapping()
+	g.showOverlayMessage(fmt.Sprintf("Exposure: %+.1f EV", g.hdrExposure))
+	debugKV("renderer", "hdr_exposure", "exposure", g.hdrExposure, "operator", g.config.ToneMapOperator)
+}
+
+func (g *Game) changeHDRExposure(delta float64) {
+	g.setHDRExposure(g.hdrExposure + delta)
+}
+
+// resetHDRExposure returns to the exposure configured in settings.
+func (g *Game) resetHDRExposure() {
+	g.setHDRExposure(g.config.HDRExposure)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// helpMatches reports whether an action row of the help overlay contains
+// filter, ignoring case, in its name, bindings or description.
+func helpMatches(filter, action string, keys, mouse []string, desc string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, field := range slices.Concat([]string{action, desc}, keys, mouse) {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleHelp shows or hides the help overlay; it always opens unfiltered at
+// the top.
+func (g *Game) toggleHelp() {
+	g.showHelp = !g.showHelp
+	g.helpFilter = ""
+	g.helpScroll = 0
+}
+
+// updateHelpFilter narrows the help overlay to the matching rows and goes
+// back to the first one.
+func (g *Game) updateHelpFilter(filter string) {
+	g.helpFilter = filter
+	g.helpScroll = 0
+}
+
+// scrollHelp moves the help overlay by delta rows, staying within the rows
+// of the last drawn layout.
+func (g *Game) scrollHelp(delta int) {
+	scroll := g.helpScroll + delta
+	if g.renderer != nil && g.renderer.help != nil && g.renderer.help.filter == g.helpFilter {
+		scroll = min(scroll, g.renderer.help.maxScroll())
+	}
+	g.helpScroll = max(scroll, 0)
+}
+
+// pageHelp scrolls the help overlay by delta pages.
+func (g *Game) pageHelp(delta int) {
+	rows := 1
+	if g.renderer != nil && g.renderer.help != nil {
+		rows = max(g.renderer.help.pageRows, 1)
+	}
+	g.scrollHelp(delta * rows)
+}
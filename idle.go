@@ -0,0 +1,87 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// Time without input or animation before frames stop
+	idleThrottleDelay = time.Second
+
+	// Frames still run this often while idle, so background results (loads,
+	// remote commands, finished jobs) and message timeouts are picked up
+	idleWakeInterval = 250 * time.Millisecond
+)
+
+// setFrameThrottle switches Ebiten between frames at the display rate and
+// frames only on input or when scheduled. Tests replace it.
+var setFrameThrottle = func(throttled bool) {
+	if throttled {
+		ebiten.SetFPSMode(ebiten.FPSModeVsyncOffMinimum)
+		return
+	}
+	ebiten.SetFPSMode(ebiten.FPSModeVsyncOn)
+}
+
+// startIdleWakeups schedules the periodic frames that run while idle. The
+// goroutine lives as long as the process.
+func (g *Game) startIdleWakeups() {
+	go func() {
+		defer recoverWorker("idle_wakeup", "", nil)
+		ticker := time.NewTicker(idleWakeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if g.idleThrottled.Load() {
+				ebiten.ScheduleFrame()
+			}
+		}
+	}()
+}
+
+// needsContinuousFrames reports whether something on screen advances with
+// time, so frames have to keep running at the display rate.
+func (g *Game) needsContinuousFrames() bool {
+	return g.wasInputHandled ||
+		g.forceRedrawFrames > 0 ||
+		g.slideshowActive ||
+		g.readingTimerActive ||
+		!g.loadingSince.IsZero() ||
+		(g.volumeEnd != nil && g.config.VolumeEnd == volumeEndAuto) ||
+		g.compareMode == CompareBlink ||
+		(g.customShaderOn && g.customShaderAnimated) ||
+		(!g.animationPaused && len(g.visibleAnimations()) > 0) ||
+		inputHeld()
+}
+
+// inputHeld reports whether a key or mouse button is down; key repeat and
+// drags count frames.
+func inputHeld() bool {
+	if len(inpututil.AppendPressedKeys(nil)) > 0 {
+		return true
+	}
+	for b := ebiten.MouseButton0; b <= ebiten.MouseButtonMax; b++ {
+		if ebiten.IsMouseButtonPressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateIdleThrottle stops frames once nothing has happened for
+// idleThrottleDelay and resumes them as soon as something does. Input wakes
+// Ebiten by itself.
+func (g *Game) updateIdleThrottle(now time.Time, busy bool) {
+	if busy || !g.config.IdleThrottle || g.lastBusy.IsZero() {
+		g.lastBusy = now
+	}
+	throttle := now.Sub(g.lastBusy) >= idleThrottleDelay
+	if throttle == g.idleThrottled.Load() {
+		return
+	}
+	g.idleThrottled.Store(throttle)
+	setFrameThrottle(throttle)
+	debugKV("renderer", "idle_throttle", "throttled", throttle)
+}
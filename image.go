@@ -11,9 +11,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"nv/internal/imgdecode"
 
@@ -27,6 +28,7 @@ type ImagePath struct {
 	Path        string // Local file path or archive:entry format
 	ArchivePath string // Empty for regular files, path to archive for entries
 	EntryPath   string // Empty for regular files, path within archive for entries
+	Transient   bool   // Pasted from the clipboard; exists for this session only
 }
 
 // NavigationDirection represents the direction of navigation
@@ -51,10 +53,27 @@ func (d NavigationDirection) String() string {
 	}
 }
 
+// PreloadLayout describes how pages are shown, so preloading can skip the
+// pages already on screen and fetch whole spreads in book mode. Spreads pair
+// indices the same way in both reading directions; right-to-left only swaps
+// their sides.
+type PreloadLayout struct {
+	Shown int // Pages on screen from the current index: 2 for a spread
+	Step  int // Pages per view when turning pages: 2 in book mode
+}
+
 // PreloadRequest represents a request to preload an image
 type PreloadRequest struct {
 	Index     int
 	Direction NavigationDirection
+	Layout    PreloadLayout
+}
+
+// ImageLoadError describes a collection entry that could not be decoded
+type ImageLoadError struct {
+	Index  int
+	Path   string
+	Reason string
 }
 
 // PreloadStats provides statistics about preloading
@@ -69,6 +88,9 @@ const (
 	defaultMaxImageDimension = 8192
 	defaultTileSize          = 2048
 	fallbackTileSize         = 1024
+
+	// Parallel loads when warming up the first pages at startup
+	maxWarmUpWorkers = 4
 )
 
 type DisplayTile struct {
@@ -134,6 +156,7 @@ type PreloadManager struct {
 	stats        PreloadStats
 	maxPreload   int
 	enabled      bool
+	layout       PreloadLayout
 }
 
 // NewPreloadManager creates a new PreloadManager
@@ -208,6 +231,13 @@ func (pm *PreloadManager) Stop() {
 	debugKV("cache", "preload_stop")
 }
 
+// SetLayout sets the page layout used by later preload requests.
+func (pm *PreloadManager) SetLayout(layout PreloadLayout) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.layout = layout
+}
+
 // StartPreload starts preloading images from the current index in the specified direction
 func (pm *PreloadManager) StartPreload(currentIdx int, direction NavigationDirection) {
 	if !pm.IsEnabled() {
@@ -228,11 +258,16 @@ drain:
 	}
 
 	// Send new preload request
+	pm.mu.RLock()
+	layout := pm.layout
+	pm.mu.RUnlock()
 	select {
-	case pm.requestChan <- PreloadRequest{Index: currentIdx, Direction: direction}:
+	case pm.requestChan <- PreloadRequest{Index: currentIdx, Direction: direction, Layout: layout}:
 		debugKV("cache", "preload_start",
 			"idx", currentIdx,
 			"direction", direction,
+			"shown", layout.Shown,
+			"step", layout.Step,
 			"drained", drained,
 		)
 	default:
@@ -252,7 +287,10 @@ func (pm *PreloadManager) worker() {
 			return
 		case req := <-pm.requestChan:
 			if pm.IsEnabled() {
-				pm.processPreloadRequest(req)
+				func() {
+					defer recoverWorker("preload", "", nil)
+					pm.processPreloadRequest(req)
+				}()
 			}
 		}
 	}
@@ -269,7 +307,7 @@ func (pm *PreloadManager) processPreloadRequest(req PreloadRequest) {
 		return
 	}
 
-	indices := pm.calculatePreloadIndices(req.Index, req.Direction, pathsCount)
+	indices := pm.calculatePreloadIndices(req.Index, req.Direction, req.Layout, pathsCount)
 	debugKV("cache", "preload_plan",
 		"idx", req.Index,
 		"direction", req.Direction,
@@ -287,22 +325,28 @@ func (pm *PreloadManager) processPreloadRequest(req PreloadRequest) {
 	}
 }
 
-// calculatePreloadIndices calculates which image indices to preload
-func (pm *PreloadManager) calculatePreloadIndices(currentIdx int, direction NavigationDirection, pathsCount int) []int {
+// calculatePreloadIndices calculates which image indices to preload.
+// Forward preloading starts after the pages on screen, and in book mode the
+// count is rounded up to whole spreads so the next spread is complete.
+func (pm *PreloadManager) calculatePreloadIndices(currentIdx int, direction NavigationDirection, layout PreloadLayout, pathsCount int) []int {
 	var indices []int
 
+	step := max(1, layout.Step)
+	count := (pm.maxPreload + step - 1) / step * step
+	first := currentIdx + max(1, layout.Shown)
+
 	switch direction {
 	case NavigationForward:
 		// Preload forward
-		for i := 1; i <= pm.maxPreload; i++ {
-			idx := currentIdx + i
+		for i := range count {
+			idx := first + i
 			if idx < pathsCount {
 				indices = append(indices, idx)
 			}
 		}
 	case NavigationBackward:
 		// Preload backward
-		for i := 1; i <= pm.maxPreload; i++ {
+		for i := 1; i <= count; i++ {
 			idx := currentIdx - i
 			if idx >= 0 {
 				indices = append(indices, idx)
@@ -310,11 +354,11 @@ func (pm *PreloadManager) calculatePreloadIndices(currentIdx int, direction Navi
 		}
 	case NavigationJump:
 		// Preload both directions from jump point
-		half := pm.maxPreload / 2
+		half := (count/2 + step - 1) / step * step
 
 		// Forward
-		for i := 1; i <= half; i++ {
-			idx := currentIdx + i
+		for i := range half {
+			idx := first + i
 			if idx < pathsCount {
 				indices = append(indices, idx)
 			}
@@ -342,11 +386,11 @@ func (pm *PreloadManager) preloadImage(idx int) {
 	if !ok {
 		return
 	}
-	cacheKey := imagePath.Path
+	cacheKey := pm.imageManager.cacheKeyFor(imagePath)
 
 	// Check if already in cache
 	if _, ok := pm.imageManager.cache.Get(cacheKey); ok {
-		debugKV("cache", "preload_skip", "reason", "already_cached", "idx", idx, "path", cacheKey)
+		debugKV("cache", "preload_skip", "reason", "already_cached", "idx", idx, "path", imagePath.Path)
 		return // Already cached
 	}
 
@@ -362,71 +406,62 @@ type ImageManager interface {
 	SetPaths(paths []ImagePath)
 	GetPathsCount() int
 	StartPreload(currentIdx int, direction NavigationDirection)
+	SetPreloadLayout(layout PreloadLayout)
 	StopPreload()
 	GetPreloadStats() PreloadStats
 	ConsumeAsyncRefresh() bool
+	IsLoadFailed(idx int) bool
+	GetLoadErrors() []ImageLoadError
+	InvalidateImage(idx int) bool
+	RenamePath(idx int, newPath string) bool
 }
 
 // DefaultImageManager implements ImageManager
 type DefaultImageManager struct {
 	paths              []ImagePath
-	cache              *lru.Cache[string, DisplayImage]
+	cache              *lru.Cache[imageCacheKey, DisplayImage]
 	mu                 sync.RWMutex
 	preloadManager     *PreloadManager
 	maxImageDimension  atomic.Int64
 	loadRequests       chan loadRequest
 	preloadRequests    chan loadRequest
-	inflight           map[string]struct{}
+	inflight           map[imageCacheKey]struct{}
 	inflightMu         sync.Mutex
 	loadCtx            context.Context
 	loadCancel         context.CancelFunc
 	loadWorkerOnce     sync.Once
 	loadingPlaceholder DisplayImage
 	asyncRefresh       atomic.Bool
+	pacedLoads         atomic.Int32      // Speculative loads in progress, see awaitUpload
+	loadErrors         map[string]string // page path -> decode error, kept across SetPaths
+	loadErrorsMu       sync.RWMutex
+	movingKeys         sync.Map // cache keys being re-keyed; eviction must not deallocate them
+	cacheKeys          cacheKeyMemo
+	gpuBytes           atomic.Int64 // Estimated texture memory of cached images
+	gpuCap             atomic.Int64 // 0 = unlimited
+	shownKeys          atomic.Pointer[[]imageCacheKey]
+	toneMapping        imgdecode.ToneMapping
+	levels             imgdecode.Levels
+	adjustMu           sync.RWMutex
+	adjusted           sync.Map // DisplayImages rendered with tone mapping or levels
+	prefetcher         *archivePrefetcher
+	diagnostics        loadDiagnostics // Debug mode load timings
 }
 
 type loadRequest struct {
 	path     ImagePath
-	cacheKey string
+	cacheKey imageCacheKey
 	preload  bool
 }
 
 // NewImageManager creates a new DefaultImageManager
 func NewImageManager(cacheSize int) ImageManager {
-	cache, err := lru.NewWithEvict[string, DisplayImage](cacheSize, func(_ string, img DisplayImage) {
-		if img != nil {
-			img.Deallocate()
-		}
-	})
-	if err != nil {
-		errorKV("cache", "cache_create_failed", "requested_size", cacheSize, "error", err)
-		cache, _ = lru.NewWithEvict[string, DisplayImage](16, func(_ string, img DisplayImage) {
-			if img != nil {
-				img.Deallocate()
-			}
-		})
-	}
-
-	return newDefaultImageManager(cache)
+	return newDefaultImageManager(cacheSize)
 }
 
 // NewImageManagerWithPreload creates a new DefaultImageManager with preload configuration
 func NewImageManagerWithPreload(cacheSize int, preloadCount int, preloadEnabled bool) ImageManager {
-	cache, err := lru.NewWithEvict[string, DisplayImage](cacheSize, func(_ string, img DisplayImage) {
-		if img != nil {
-			img.Deallocate()
-		}
-	})
-	if err != nil {
-		errorKV("cache", "cache_create_failed", "requested_size", cacheSize, "error", err)
-		cache, _ = lru.NewWithEvict[string, DisplayImage](16, func(_ string, img DisplayImage) {
-			if img != nil {
-				img.Deallocate()
-			}
-		})
-	}
-
-	manager := newDefaultImageManager(cache)
+	manager := newDefaultImageManager(cacheSize)
 
 	// Initialize preload manager with configuration
 	manager.preloadManager = NewPreloadManager(manager, preloadCount)
@@ -435,22 +470,45 @@ func NewImageManagerWithPreload(cacheSize int, preloadCount int, preloadEnabled
 	return manager
 }
 
-func newDefaultImageManager(cache *lru.Cache[string, DisplayImage]) *DefaultImageManager {
+func newDefaultImageManager(cacheSize int) *DefaultImageManager {
 	loadCtx, loadCancel := context.WithCancel(context.Background())
 	manager := &DefaultImageManager{
 		paths:              []ImagePath{},
-		cache:              cache,
 		loadRequests:       make(chan loadRequest, 8),
 		preloadRequests:    make(chan loadRequest, 8),
-		inflight:           make(map[string]struct{}),
+		inflight:           make(map[imageCacheKey]struct{}),
+		loadErrors:         make(map[string]string),
 		loadCtx:            loadCtx,
 		loadCancel:         loadCancel,
 		loadingPlaceholder: createLoadingPlaceholder(),
+		prefetcher:         newArchivePrefetcher(),
+	}
+
+	cache, err := lru.NewWithEvict[imageCacheKey, DisplayImage](cacheSize, manager.releaseEvicted)
+	if err != nil {
+		errorKV("cache", "cache_create_failed", "requested_size", cacheSize, "error", err)
+		cache, _ = lru.NewWithEvict[imageCacheKey, DisplayImage](16, manager.releaseEvicted)
 	}
+	manager.cache = cache
+
 	manager.startLoadWorker()
 	return manager
 }
 
+// releaseEvicted frees GPU memory for images leaving the cache, except for
+// entries that are only being moved to a new key.
+func (m *DefaultImageManager) releaseEvicted(key imageCacheKey, img DisplayImage) {
+	if img == nil {
+		return
+	}
+	m.gpuBytes.Add(-displayImageBytes(img))
+	if _, moving := m.movingKeys.Load(key); moving {
+		return
+	}
+	m.adjusted.Delete(img)
+	img.Deallocate()
+}
+
 // SetMaxImageDimension updates the dimension threshold that switches decoded images to tiled rendering.
 // A value of 0 uses the default threshold.
 func (m *DefaultImageManager) SetMaxImageDimension(limit int) {
@@ -496,8 +554,19 @@ func (m *DefaultImageManager) processLoadRequest(req loadRequest) {
 		delete(m.inflight, req.cacheKey)
 		m.inflightMu.Unlock()
 	}()
+	if req.preload {
+		m.pacedLoads.Add(1)
+		defer m.pacedLoads.Add(-1)
+	}
 
-	img, err := m.loadImage(req.path)
+	start := time.Now()
+	source := "disk"
+	if req.preload {
+		source = "preload"
+	}
+	m.diagnostics.begin(req.path, source)
+	img, err := m.loadImageRecovering(req.path)
+	m.diagnostics.finish(req.path, time.Since(start), img)
 	if err != nil {
 		errorKV("cache", "cache_load_failed",
 			"path", req.path.Path,
@@ -505,26 +574,36 @@ func (m *DefaultImageManager) processLoadRequest(req loadRequest) {
 			"error", err,
 		)
 		errorImg := createDisplayImageFromEbitenImage(CreateErrorImage(400, 300, req.path.Path, err.Error()))
-		m.cache.Add(req.cacheKey, errorImg)
+		m.setLoadError(req.path.Path, err.Error())
+		m.addToCache(req.cacheKey, errorImg)
 		m.asyncRefresh.Store(true)
 		m.recordPreloadResult(req.preload, false)
 		return
 	}
 
-	m.cache.Add(req.cacheKey, img)
+	m.setLoadError(req.path.Path, "")
+	m.addToCache(req.cacheKey, img)
 	m.asyncRefresh.Store(true)
 	m.recordPreloadResult(req.preload, true)
 
 	var mem runtime.MemStats
 	runtime.ReadMemStats(&mem)
 	debugKV("cache", "cache_load_complete",
-		"path", req.cacheKey,
+		"path", req.path.Path,
 		"source", loadSource(req.preload),
 		"cache_len", m.cache.Len(),
 		"mem_mb", mem.Alloc/1024/1024,
 	)
 }
 
+// loadImageRecovering runs loadImage for a loader goroutine. A panic in a
+// decoder becomes a crash report and a load error, so the page shows as
+// failed and the loader keeps going.
+func (m *DefaultImageManager) loadImageRecovering(imagePath ImagePath) (img DisplayImage, err error) {
+	defer recoverWorker("image_load", imagePath.Path, func(e error) { img, err = nil, e })
+	return m.loadImage(imagePath)
+}
+
 func (m *DefaultImageManager) requestAsyncLoad(imagePath ImagePath) {
 	m.enqueueLoadRequest(imagePath, false)
 }
@@ -534,10 +613,10 @@ func (m *DefaultImageManager) requestPreload(imagePath ImagePath) {
 }
 
 func (m *DefaultImageManager) enqueueLoadRequest(imagePath ImagePath, preload bool) {
-	cacheKey := imagePath.Path
+	cacheKey := m.cacheKeyFor(imagePath)
 	if _, ok := m.cache.Get(cacheKey); ok {
 		debugKV("cache", "cache_enqueue_skip",
-			"path", cacheKey,
+			"path", imagePath.Path,
 			"source", loadSource(preload),
 			"reason", "already_cached",
 		)
@@ -548,7 +627,7 @@ func (m *DefaultImageManager) enqueueLoadRequest(imagePath ImagePath, preload bo
 	if _, exists := m.inflight[cacheKey]; exists {
 		m.inflightMu.Unlock()
 		debugKV("cache", "cache_enqueue_skip",
-			"path", cacheKey,
+			"path", imagePath.Path,
 			"source", loadSource(preload),
 			"reason", "already_inflight",
 		)
@@ -569,14 +648,14 @@ func (m *DefaultImageManager) enqueueLoadRequest(imagePath ImagePath, preload bo
 	case <-m.loadCtx.Done():
 		m.clearInflight(cacheKey)
 		debugKV("cache", "cache_enqueue_skip",
-			"path", cacheKey,
+			"path", imagePath.Path,
 			"source", loadSource(preload),
 			"reason", "load_context_closed",
 		)
 	case queue <- req:
 		m.updatePreloadQueueSize()
 		debugKV("cache", "cache_enqueue",
-			"path", cacheKey,
+			"path", imagePath.Path,
 			"source", loadSource(preload),
 			"queue", queueName,
 			"queue_len", len(queue),
@@ -584,7 +663,7 @@ func (m *DefaultImageManager) enqueueLoadRequest(imagePath ImagePath, preload bo
 	default:
 		m.clearInflight(cacheKey)
 		debugKV("cache", "cache_enqueue_skip",
-			"path", cacheKey,
+			"path", imagePath.Path,
 			"source", loadSource(preload),
 			"queue", queueName,
 			"reason", "queue_full",
@@ -592,7 +671,7 @@ func (m *DefaultImageManager) enqueueLoadRequest(imagePath ImagePath, preload bo
 	}
 }
 
-func (m *DefaultImageManager) clearInflight(cacheKey string) {
+func (m *DefaultImageManager) clearInflight(cacheKey imageCacheKey) {
 	m.inflightMu.Lock()
 	delete(m.inflight, cacheKey)
 	m.inflightMu.Unlock()
@@ -643,6 +722,7 @@ func (m *DefaultImageManager) SetPaths(paths []ImagePath) {
 	m.mu.Lock()
 	m.paths = paths
 	m.mu.Unlock()
+	m.prefetcher.Sync(paths)
 	debugKV("cache", "paths_replaced",
 		"paths_count", len(paths),
 		"cache_len", m.cache.Len(),
@@ -656,16 +736,74 @@ func (m *DefaultImageManager) GetPathsCount() int {
 }
 
 func (m *DefaultImageManager) StartPreload(currentIdx int, direction NavigationDirection) {
+	m.prefetcher.SetCurrent(currentIdx)
 	if m.preloadManager != nil {
 		m.preloadManager.StartPreload(currentIdx, direction)
 	}
 }
 
+// WarmUp loads the pages from start onward in parallel, so the first pages
+// are ready before the window opens instead of trickling through the
+// single load worker.
+func (m *DefaultImageManager) WarmUp(start, count int) {
+	m.mu.RLock()
+	end := min(len(m.paths), start+count)
+	var paths []ImagePath
+	if start >= 0 && start < end {
+		paths = slices.Clone(m.paths[start:end])
+	}
+	m.mu.RUnlock()
+
+	var requests []loadRequest
+	m.inflightMu.Lock()
+	for _, p := range paths {
+		key := m.cacheKeyFor(p)
+		if _, ok := m.cache.Peek(key); ok {
+			continue
+		}
+		if _, exists := m.inflight[key]; exists {
+			continue
+		}
+		m.inflight[key] = struct{}{}
+		requests = append(requests, loadRequest{path: p, cacheKey: key, preload: true})
+	}
+	m.inflightMu.Unlock()
+	if len(requests) == 0 {
+		return
+	}
+
+	queue := make(chan loadRequest, len(requests))
+	for _, req := range requests {
+		queue <- req
+	}
+	close(queue)
+	workers := min(len(requests), maxWarmUpWorkers, runtime.NumCPU())
+	for range workers {
+		go func() {
+			for req := range queue {
+				if m.loadCtx.Err() != nil {
+					m.clearInflight(req.cacheKey)
+					continue
+				}
+				m.processLoadRequest(req)
+			}
+		}()
+	}
+	debugKV("cache", "warm_up_start", "start", start, "pages", len(requests), "workers", workers)
+}
+
+func (m *DefaultImageManager) SetPreloadLayout(layout PreloadLayout) {
+	if m.preloadManager != nil {
+		m.preloadManager.SetLayout(layout)
+	}
+}
+
 func (m *DefaultImageManager) StopPreload() {
 	if m.preloadManager != nil {
 		m.preloadManager.Stop()
 	}
 	m.loadCancel()
+	m.prefetcher.Close()
 	debugKV("cache", "load_stop")
 }
 
@@ -680,6 +818,100 @@ func (m *DefaultImageManager) GetPath(idx int) (ImagePath, bool) {
 	return m.getPath(idx)
 }
 
+// InvalidateImage drops the cached image for idx so the next GetImage
+// re-decodes it from disk or archive. Returns false if idx is out of range.
+func (m *DefaultImageManager) InvalidateImage(idx int) bool {
+	imagePath, ok := m.getPath(idx)
+	if !ok {
+		return false
+	}
+	m.cache.Remove(m.cacheKeyFor(imagePath))
+	m.forgetCacheKey(imagePath)
+	m.setLoadError(imagePath.Path, "")
+	debugKV("cache", "cache_invalidate", "idx", idx, "path", imagePath.Path)
+	return true
+}
+
+// RenamePath replaces the path at idx after the file was renamed on disk,
+// carrying over its cached image and load error under the new key.
+func (m *DefaultImageManager) RenamePath(idx int, newPath string) bool {
+	m.mu.Lock()
+	if idx < 0 || idx >= len(m.paths) {
+		m.mu.Unlock()
+		return false
+	}
+	old := m.paths[idx]
+	oldPath := old.Path
+	paths := append([]ImagePath(nil), m.paths...)
+	paths[idx] = ImagePath{Path: newPath}
+	m.paths = paths
+	m.mu.Unlock()
+
+	oldKey := m.cacheKeyFor(old)
+	m.forgetCacheKey(old)
+	if img, ok := m.cache.Peek(oldKey); ok {
+		m.movingKeys.Store(oldKey, struct{}{})
+		m.cache.Remove(oldKey)
+		m.movingKeys.Delete(oldKey)
+		m.addToCache(m.cacheKeyFor(paths[idx]), img)
+	}
+
+	m.loadErrorsMu.Lock()
+	if reason, ok := m.loadErrors[oldPath]; ok {
+		delete(m.loadErrors, oldPath)
+		m.loadErrors[newPath] = reason
+	}
+	m.loadErrorsMu.Unlock()
+
+	debugKV("cache", "path_renamed", "idx", idx, "old_path", oldPath, "new_path", newPath)
+	return true
+}
+
+// setLoadError records (or clears, when reason is empty) a decode failure for path.
+func (m *DefaultImageManager) setLoadError(path, reason string) {
+	m.loadErrorsMu.Lock()
+	defer m.loadErrorsMu.Unlock()
+	if reason == "" {
+		delete(m.loadErrors, path)
+		return
+	}
+	m.loadErrors[path] = reason
+}
+
+// IsLoadFailed reports whether the entry at idx is known to be undecodable.
+// Entries that have not been loaded yet report false.
+func (m *DefaultImageManager) IsLoadFailed(idx int) bool {
+	imagePath, ok := m.getPath(idx)
+	if !ok {
+		return false
+	}
+	m.loadErrorsMu.RLock()
+	defer m.loadErrorsMu.RUnlock()
+	_, failed := m.loadErrors[imagePath.Path]
+	return failed
+}
+
+// GetLoadErrors returns the known decode failures of the current collection in page order.
+func (m *DefaultImageManager) GetLoadErrors() []ImageLoadError {
+	m.mu.RLock()
+	paths := m.paths
+	m.mu.RUnlock()
+
+	m.loadErrorsMu.RLock()
+	defer m.loadErrorsMu.RUnlock()
+	if len(m.loadErrors) == 0 {
+		return nil
+	}
+
+	var errs []ImageLoadError
+	for i, imagePath := range paths {
+		if reason, ok := m.loadErrors[imagePath.Path]; ok {
+			errs = append(errs, ImageLoadError{Index: i, Path: imagePath.Path, Reason: reason})
+		}
+	}
+	return errs
+}
+
 func (m *DefaultImageManager) GetBookModeImages(idx int, rightToLeft bool) (DisplayImage, DisplayImage) {
 	var leftImg, rightImg DisplayImage
 
@@ -704,7 +936,7 @@ func (m *DefaultImageManager) GetImage(idx int) DisplayImage {
 	}
 	imagePath := m.paths[idx]
 	m.mu.RUnlock()
-	cacheKey := imagePath.Path
+	cacheKey := m.cacheKeyFor(imagePath)
 
 	// Check if image is already in cache
 	img, ok := m.cache.Get(cacheKey)
@@ -712,7 +944,7 @@ func (m *DefaultImageManager) GetImage(idx int) DisplayImage {
 		return img
 	}
 
-	debugKV("cache", "cache_lookup_miss", "idx", idx, "path", cacheKey)
+	debugKV("cache", "cache_lookup_miss", "idx", idx, "path", imagePath.Path)
 	m.startLoadWorker()
 	m.requestAsyncLoad(imagePath)
 	return m.loadingPlaceholder
@@ -732,15 +964,62 @@ func (m *DefaultImageManager) getPath(idx int) (ImagePath, bool) {
 
 // Image loading functions
 
-func (m *DefaultImageManager) loadImageFromBytes(data []byte, path string) (DisplayImage, error) {
-	decoded, err := imgdecode.DecodeBytes(data, path)
+func (m *DefaultImageManager) loadImageFromBytes(data []byte, p ImagePath) (DisplayImage, error) {
+	m.diagnostics.note(p, func(s *pageLoadStats) { s.EncodedBytes = int64(len(data)) })
+	if imgdecode.IsHDRData(data) {
+		return m.loadHDRFromBytes(data, p)
+	}
+	if img, ok := m.loadAnimationFromBytes(data, p); ok {
+		return img, nil
+	}
+
+	decoded, err := imgdecode.DecodeBytes(data, loadOrigin(p))
 	if err != nil {
-		return nil, fmt.Errorf("decoding %s: %v", path, err)
+		return nil, fmt.Errorf("decoding %s: %v", loadOrigin(p), err)
+	}
+	return m.createDisplayImageFromDecoded(decoded, p)
+}
+
+// loadAnimationFromBytes builds an animated display image when data holds
+// several frames that fit the texture limit. Anything else reports false so
+// the caller decodes a static image instead.
+func (m *DefaultImageManager) loadAnimationFromBytes(data []byte, p ImagePath) (DisplayImage, bool) {
+	path := p.Path
+	if !imgdecode.IsAnimationCandidate(data) {
+		return nil, false
+	}
+	anim, err := imgdecode.DecodeAnimation(data)
+	if err != nil || anim == nil {
+		if err != nil {
+			debugKV("cache", "animation_decode_failed", "path", path, "error", err, "fallback", "static")
+		}
+		return nil, false
+	}
+
+	bounds := anim.Frames[0].Bounds()
+	if limit := m.preferredMaxDimension(); limit > 0 && (bounds.Dx() > limit || bounds.Dy() > limit) {
+		debugKV("cache", "animation_skip", "path", path, "reason", "exceeds_texture_limit", "limit", limit)
+		return nil, false
 	}
-	return m.createEbitenImageFromDecoded(decoded, path)
+	if !m.awaitUpload(textureBytes(bounds) * int64(len(anim.Frames))) {
+		return nil, false
+	}
+	img, err := newAnimatedDisplayImage(anim)
+	if err != nil {
+		warnKV("cache", "animation_texture_failed", "path", path, "error", err, "fallback", "static")
+		return nil, false
+	}
+	debugKV("cache", "animation_loaded",
+		"path", path,
+		"width", bounds.Dx(),
+		"height", bounds.Dy(),
+		"frames", len(anim.Frames),
+	)
+	return img, true
 }
 
-func (m *DefaultImageManager) loadImageFromZip(archivePath, entryPath string) (DisplayImage, error) {
+func (m *DefaultImageManager) loadImageFromZip(p ImagePath) (DisplayImage, error) {
+	archivePath, entryPath := p.ArchivePath, p.EntryPath
 	r, err := zip.OpenReader(archivePath)
 	if err != nil {
 		return nil, err
@@ -760,13 +1039,14 @@ func (m *DefaultImageManager) loadImageFromZip(archivePath, entryPath string) (D
 				return nil, err
 			}
 
-			return m.loadImageFromBytes(data, entryPath)
+			return m.loadImageFromBytes(data, p)
 		}
 	}
 	return nil, fmt.Errorf("entry %s not found in %s", entryPath, archivePath)
 }
 
-func (m *DefaultImageManager) loadImageFromRar(archivePath, entryPath string) (DisplayImage, error) {
+func (m *DefaultImageManager) loadImageFromRar(p ImagePath) (DisplayImage, error) {
+	archivePath, entryPath := p.ArchivePath, p.EntryPath
 	f, err := os.Open(archivePath)
 	if err != nil {
 		return nil, err
@@ -792,13 +1072,14 @@ func (m *DefaultImageManager) loadImageFromRar(archivePath, entryPath string) (D
 			if err != nil {
 				return nil, err
 			}
-			return m.loadImageFromBytes(data, entryPath)
+			return m.loadImageFromBytes(data, p)
 		}
 	}
 	return nil, fmt.Errorf("entry %s not found in %s", entryPath, archivePath)
 }
 
-func (m *DefaultImageManager) loadImageFrom7z(archivePath, entryPath string) (DisplayImage, error) {
+func (m *DefaultImageManager) loadImageFrom7z(p ImagePath) (DisplayImage, error) {
+	archivePath, entryPath := p.ArchivePath, p.EntryPath
 	r, err := sevenzip.OpenReader(archivePath)
 	if err != nil {
 		return nil, err
@@ -818,7 +1099,7 @@ func (m *DefaultImageManager) loadImageFrom7z(archivePath, entryPath string) (Di
 				return nil, err
 			}
 
-			return m.loadImageFromBytes(data, entryPath)
+			return m.loadImageFromBytes(data, p)
 		}
 	}
 	return nil, fmt.Errorf("entry %s not found in %s", entryPath, archivePath)
@@ -826,27 +1107,45 @@ func (m *DefaultImageManager) loadImageFrom7z(archivePath, entryPath string) (Di
 
 func (m *DefaultImageManager) loadImage(imagePath ImagePath) (DisplayImage, error) {
 	if imagePath.ArchivePath == "" {
+		// Files without a known extension were picked up by content
+		// sniffing; the byte path detects animation and HDR from the data.
+		if imgdecode.MayBeAnimated(imagePath.Path) || imgdecode.IsHDRPath(imagePath.Path) || !isSupportedExt(imagePath.Path) {
+			data, err := os.ReadFile(imagePath.Path)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s: %v", imagePath.Path, err)
+			}
+			return m.loadImageFromBytes(data, imagePath)
+		}
+
 		decoded, err := imgdecode.DecodeFile(imagePath.Path)
 		if err != nil {
 			return nil, fmt.Errorf("decoding %s: %v", imagePath.Path, err)
 		}
-		return m.createEbitenImageFromDecoded(decoded, imagePath.Path)
+		return m.createDisplayImageFromDecoded(decoded, imagePath)
+	}
+
+	if data, ok := m.prefetcher.Lookup(imagePath.ArchivePath, imagePath.EntryPath); ok {
+		m.diagnostics.note(imagePath, func(s *pageLoadStats) { s.Source = "prefetch" })
+		return m.loadImageFromBytes(data, imagePath)
 	}
 
-	ext := strings.ToLower(filepath.Ext(imagePath.ArchivePath))
-	switch ext {
-	case ".zip":
-		return m.loadImageFromZip(imagePath.ArchivePath, imagePath.EntryPath)
-	case ".rar":
-		return m.loadImageFromRar(imagePath.ArchivePath, imagePath.EntryPath)
-	case ".7z":
-		return m.loadImageFrom7z(imagePath.ArchivePath, imagePath.EntryPath)
+	format, _ := detectArchive(imagePath.ArchivePath)
+	switch format.Kind {
+	case archiveZip:
+		return m.loadImageFromZip(imagePath)
+	case archiveRar:
+		return m.loadImageFromRar(imagePath)
+	case archive7z:
+		return m.loadImageFrom7z(imagePath)
+	case archiveTar:
+		return m.loadImageFromTar(imagePath, format.Compression)
 	default:
-		return nil, fmt.Errorf("unsupported archive format: %s", ext)
+		return nil, fmt.Errorf("unsupported archive format: %s", filepath.Base(imagePath.ArchivePath))
 	}
 }
 
-func (m *DefaultImageManager) createEbitenImageFromDecoded(src image.Image, origin string) (DisplayImage, error) {
+func (m *DefaultImageManager) createEbitenImageFromDecoded(src image.Image, p ImagePath) (DisplayImage, error) {
+	origin := loadOrigin(p)
 	if src == nil {
 		return nil, fmt.Errorf("decoded image is nil for %s", origin)
 	}
@@ -855,6 +1154,13 @@ func (m *DefaultImageManager) createEbitenImageFromDecoded(src image.Image, orig
 	bounds := src.Bounds()
 	width := bounds.Dx()
 	height := bounds.Dy()
+	if !m.awaitUpload(textureBytes(bounds)) {
+		return nil, fmt.Errorf("loading stopped before uploading %s", origin)
+	}
+	uploadStart := time.Now()
+	defer func() {
+		m.diagnostics.note(p, func(s *pageLoadStats) { s.Upload += time.Since(uploadStart) })
+	}()
 	if limit > 0 && (width > limit || height > limit) {
 		infoKV("cache", "image_tiling",
 			"path", origin,
@@ -984,7 +1290,7 @@ func extractImagesFromZip(archivePath string) ([]ImagePath, error) {
 
 	var images []ImagePath
 	for _, f := range r.File {
-		if !f.FileInfo().IsDir() && isSupportedExt(f.Name) {
+		if !f.FileInfo().IsDir() && isArchiveImageEntry(f.Name, f.FileInfo().Size()) {
 			images = append(images, ImagePath{
 				Path:        archivePath + ":" + f.Name,
 				ArchivePath: archivePath,
@@ -1017,7 +1323,7 @@ func extractImagesFromRar(archivePath string) ([]ImagePath, error) {
 			return nil, err
 		}
 
-		if !header.IsDir && isSupportedExt(header.Name) {
+		if !header.IsDir && isArchiveImageEntry(header.Name, rarEntrySize(header)) {
 			images = append(images, ImagePath{
 				Path:        archivePath + ":" + header.Name,
 				ArchivePath: archivePath,
@@ -1028,6 +1334,15 @@ func extractImagesFromRar(archivePath string) ([]ImagePath, error) {
 	return images, nil
 }
 
+// rarEntrySize returns the unpacked size of a RAR entry, or -1 when the
+// archive does not record it, as in streamed RAR5 entries.
+func rarEntrySize(h *rardecode.FileHeader) int64 {
+	if h.UnKnownSize {
+		return -1
+	}
+	return h.UnPackedSize
+}
+
 func extractImagesFrom7z(archivePath string) ([]ImagePath, error) {
 	r, err := sevenzip.OpenReader(archivePath)
 	if err != nil {
@@ -1037,7 +1352,7 @@ func extractImagesFrom7z(archivePath string) ([]ImagePath, error) {
 
 	var images []ImagePath
 	for _, f := range r.File {
-		if !f.FileInfo().IsDir() && isSupportedExt(f.Name) {
+		if !f.FileInfo().IsDir() && isArchiveImageEntry(f.Name, f.FileInfo().Size()) {
 			images = append(images, ImagePath{
 				Path:        archivePath + ":" + f.Name,
 				ArchivePath: archivePath,
@@ -1049,23 +1364,25 @@ func extractImagesFrom7z(archivePath string) ([]ImagePath, error) {
 }
 
 func processArchive(archivePath string) ([]ImagePath, error) {
-	if !isArchiveExt(archivePath) {
+	format, ok := detectArchive(archivePath)
+	if !ok {
 		return []ImagePath{}, nil
 	}
 
 	var archiveImages []ImagePath
 	var err error
 
-	ext := strings.ToLower(filepath.Ext(archivePath))
-	switch ext {
-	case ".zip":
+	switch format.Kind {
+	case archiveZip:
 		archiveImages, err = extractImagesFromZip(archivePath)
-	case ".rar":
+	case archiveRar:
 		archiveImages, err = extractImagesFromRar(archivePath)
-	case ".7z":
+	case archive7z:
 		archiveImages, err = extractImagesFrom7z(archivePath)
+	case archiveTar:
+		archiveImages, err = extractImagesFromTar(archivePath, format.Compression)
 	default:
-		return []ImagePath{}, fmt.Errorf("unsupported archive format: %s", ext)
+		return []ImagePath{}, fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
 	}
 
 	if err != nil {
@@ -1105,7 +1422,7 @@ func collectImagesFromSameDirectory(filePath string, sortMethod int) ([]ImagePat
 		fullPath := filepath.Join(dir, entry.Name())
 
 		// Only collect image files, not archives
-		if isSupportedExt(fullPath) {
+		if isImageFile(fullPath) {
 			images = append(images, ImagePath{
 				Path:        fullPath,
 				ArchivePath: "",
@@ -1142,7 +1459,7 @@ func collectImages(args []string, sortMethod int) ([]ImagePath, error) {
 				if fi.IsDir() {
 					return nil
 				}
-				if isSupportedExt(path) {
+				if isImageFile(path) {
 					dirImages = append(dirImages, ImagePath{
 						Path:        path,
 						ArchivePath: "",
@@ -1172,7 +1489,7 @@ func collectImages(args []string, sortMethod int) ([]ImagePath, error) {
 				"archives_seen", archiveCount,
 			)
 		} else {
-			if isSupportedExt(p) {
+			if isImageFile(p) {
 				list = append(list, ImagePath{
 					Path:        p,
 					ArchivePath: "",
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"slices"
+	"strings"
+	"unicode"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
@@ -59,6 +63,7 @@ type InputHandler struct {
 	mousebindingManager *MousebindingManager
 	dragState           *DragState          // Mouse drag state for pan operations
 	pendingMouseAction  *PendingMouseAction // Delayed mouse action to resolve drag/click conflicts
+	controlPressed      bool                // Left button went down on an on-screen button
 }
 
 // NewInputHandler creates a new InputHandler
@@ -77,8 +82,19 @@ func NewInputHandler(inputActions InputActions, inputState InputState, keybindin
 // Returns true if any input was processed, false otherwise
 func (h *InputHandler) HandleInput() bool {
 	if h.inputActions.GetTotalPagesCount() == 0 {
-		debugKV("input", "handle_input_skip", "reason", "no_pages")
-		return false
+		return h.handleEmptyStateKeys()
+	}
+
+	// Text prompts take all keys and suppress mouse actions so the
+	// target file cannot change while text is being edited
+	if h.inputState.IsInTextPrompt() {
+		return h.handleTextPromptKeys()
+	}
+
+	// The help overlay is modal: typing filters it and the paging keys
+	// and wheel scroll it
+	if h.inputState.IsShowingHelp() {
+		return h.handleHelpKeys()
 	}
 
 	// Process keyboard input first
@@ -102,13 +118,97 @@ func (h *InputHandler) handleKeyboardInput() bool {
 		return h.handleSettingsModeKeys()
 	}
 
+	// Held pan keys move the view smoothly instead of in steps, while
+	// other keys keep working
+	smoothPan := h.inputState.GetPanSpeed() > 0
+	panned := smoothPan && h.handleHeldPan()
+
 	// Normal input processing uses the action system
 	for _, actionDef := range actionDefinitions {
+		if smoothPan && slices.Contains(panActions, actionDef.Name) {
+			continue
+		}
+		if slices.Contains(emptyStateOnlyActions, actionDef.Name) {
+			continue // Their keys may belong to viewer actions
+		}
 		if h.keybindingManager.ExecuteAction(actionDef.Name, h.inputActions, h.inputState) {
 			debugKV("input", "action", "source", "keyboard", "action", actionDef.Name)
 			return true
 		}
 	}
+	for _, action := range scriptActionNames(h.keybindingManager.GetKeybindings()) {
+		if h.keybindingManager.ExecuteAction(action, h.inputActions, h.inputState) {
+			debugKV("input", "action", "source", "keyboard", "action", action)
+			return true
+		}
+	}
+
+	return panned
+}
+
+// panActions are handled by handleHeldPan while smooth panning is on.
+var panActions = []string{"pan_left", "pan_right", "pan_up", "pan_down"}
+
+// handleHeldPan pans the view a little every tick while pan keys are held,
+// so the speed does not depend on the frame rate or key repeat.
+func (h *InputHandler) handleHeldPan() bool {
+	if h.keybindingManager.CheckAction("pan_down") && h.inputActions.TurnPageAtPanEdge(1) {
+		return true
+	}
+	if h.keybindingManager.CheckAction("pan_up") && h.inputActions.TurnPageAtPanEdge(-1) {
+		return true
+	}
+
+	var dirX, dirY float64
+	for _, pan := range []struct {
+		action string
+		dx, dy float64
+	}{
+		{"pan_left", -1, 0},
+		{"pan_right", 1, 0},
+		{"pan_up", 0, -1},
+		{"pan_down", 0, 1},
+	} {
+		if h.keybindingManager.IsActionHeld(pan.action) {
+			dirX += pan.dx
+			dirY += pan.dy
+		}
+	}
+	if dirX == 0 && dirY == 0 {
+		return false
+	}
+	h.inputActions.PanContinuous(dirX, dirY)
+	return true
+}
+
+// emptyStateActions lists the actions that still make sense before anything is loaded
+var emptyStateActions = []string{"exit", "fullscreen", "maximize", "open", "open_directory", "retry_open"}
+
+// emptyStateOnlyActions are only available on the start screen, so their keys
+// may be reused by viewer actions.
+var emptyStateOnlyActions = []string{"retry_open"}
+
+// handleEmptyStateKeys handles the start screen shown when no images are loaded.
+// Only the open/quit style actions are available, plus 1-9 to open a recent
+// file.
+func (h *InputHandler) handleEmptyStateKeys() bool {
+	for _, action := range emptyStateActions {
+		if h.keybindingManager.ExecuteAction(action, h.inputActions, h.inputState) {
+			debugKV("input", "action", "source", "empty_state", "action", action)
+			return true
+		}
+	}
+
+	var digit string
+	if digit = h.checkDigitKeys(ebiten.Key1, ebiten.Key9, '1'); digit == "" {
+		digit = h.checkDigitKeys(ebiten.KeyNumpad1, ebiten.KeyNumpad9, '1')
+	}
+	if digit != "" {
+		index := int(digit[0] - '1')
+		debugKV("input", "action", "source", "empty_state", "action", "open_recent", "index", index)
+		h.inputActions.OpenRecentFile(index)
+		return true
+	}
 
 	return false
 }
@@ -144,6 +244,30 @@ func (h *InputHandler) handlePageInputModeKeys() bool {
 		return true
 	}
 
+	// Shift+5 ends the number with "%" to jump to a percentage
+	if ebiten.IsKeyPressed(ebiten.KeyShift) && inpututil.IsKeyJustPressed(ebiten.Key5) {
+		currentBuffer := h.inputState.GetPageInputBuffer()
+		if currentBuffer != "" && !strings.HasSuffix(currentBuffer, "%") {
+			h.inputActions.UpdatePageInputBuffer(currentBuffer + "%")
+			debugKV("input", "action", "source", "page_input", "action", "page_input_percent", "buffer", currentBuffer+"%")
+		}
+		return true
+	}
+
+	// Anything but a number searches the file names instead
+	chars := ebiten.AppendInputChars(nil)
+	if slices.ContainsFunc(chars, func(c rune) bool { return unicode.IsPrint(c) && !unicode.IsDigit(c) && c != '%' }) {
+		query := []rune(strings.TrimSuffix(h.inputState.GetPageInputBuffer(), "%"))
+		for _, c := range chars {
+			if unicode.IsPrint(c) {
+				query = append(query, c)
+			}
+		}
+		debugKV("input", "action", "source", "page_input", "action", "page_input_search", "query", string(query))
+		h.inputActions.SearchFromPageInput(string(query))
+		return true
+	}
+
 	// Handle digit input (both regular and numpad)
 	var digit string
 	if digit = h.checkDigitKeys(ebiten.Key0, ebiten.Key9, '0'); digit == "" {
@@ -151,6 +275,9 @@ func (h *InputHandler) handlePageInputModeKeys() bool {
 	}
 	if digit != "" {
 		currentBuffer := h.inputState.GetPageInputBuffer()
+		if strings.HasSuffix(currentBuffer, "%") {
+			return true
+		}
 		h.inputActions.UpdatePageInputBuffer(currentBuffer + digit)
 		debugKV("input", "action", "source", "page_input", "action", "page_input_append", "buffer", currentBuffer+digit)
 		return true
@@ -159,6 +286,128 @@ func (h *InputHandler) handlePageInputModeKeys() bool {
 	return false
 }
 
+// handleTextPromptKeys edits the text prompt buffer. Like page input it
+// bypasses the action system, but accepts arbitrary text via
+// ebiten.AppendInputChars.
+func (h *InputHandler) handleTextPromptKeys() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		debugKV("input", "action", "source", "text_prompt", "action", "text_prompt_cancel")
+		h.inputActions.ExitTextPrompt()
+		return true
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
+		debugKV("input", "action", "source", "text_prompt", "action", "text_prompt_submit", "buffer", h.inputState.GetTextPromptBuffer())
+		h.inputActions.SubmitTextPrompt()
+		return true
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) || isKeyRepeating(ebiten.KeyArrowUp) {
+		h.inputActions.MoveTextPromptSelection(-1)
+		return true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || isKeyRepeating(ebiten.KeyArrowDown) {
+		h.inputActions.MoveTextPromptSelection(1)
+		return true
+	}
+
+	buffer := []rune(h.inputState.GetTextPromptBuffer())
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) || isKeyRepeating(ebiten.KeyBackspace) {
+		if len(buffer) > 0 {
+			h.inputActions.UpdateTextPromptBuffer(string(buffer[:len(buffer)-1]))
+		}
+		return true
+	}
+
+	chars := ebiten.AppendInputChars(nil)
+	if len(chars) == 0 {
+		return false
+	}
+	for _, c := range chars {
+		if unicode.IsPrint(c) {
+			buffer = append(buffer, c)
+		}
+	}
+	h.inputActions.UpdateTextPromptBuffer(string(buffer))
+	return true
+}
+
+// handleHelpKeys filters and scrolls the help overlay. The help action,
+// from keyboard or mouse, closes it; Escape clears the filter first.
+func (h *InputHandler) handleHelpKeys() bool {
+	if h.keybindingManager.ExecuteAction("help", h.inputActions, h.inputState) ||
+		(h.mousebindingManager != nil && h.mousebindingManager.ExecuteAction("help", h.inputActions, h.inputState)) {
+		debugKV("input", "action", "source", "help", "action", "help")
+		return true
+	}
+
+	filter := []rune(h.inputState.GetHelpFilter())
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		if len(filter) > 0 {
+			h.inputActions.UpdateHelpFilter("")
+		} else {
+			h.inputActions.ToggleHelp()
+		}
+		debugKV("input", "action", "source", "help", "action", "help_escape")
+		return true
+	}
+
+	for _, scroll := range []struct {
+		key   ebiten.Key
+		delta int
+		page  bool
+	}{
+		{ebiten.KeyPageUp, -1, true},
+		{ebiten.KeyPageDown, 1, true},
+		{ebiten.KeyArrowUp, -1, false},
+		{ebiten.KeyArrowDown, 1, false},
+	} {
+		if inpututil.IsKeyJustPressed(scroll.key) || isKeyRepeating(scroll.key) {
+			if scroll.page {
+				h.inputActions.PageHelp(scroll.delta)
+			} else {
+				h.inputActions.ScrollHelp(scroll.delta)
+			}
+			return true
+		}
+	}
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		if dy > 0 {
+			h.inputActions.ScrollHelp(-3)
+		} else {
+			h.inputActions.ScrollHelp(3)
+		}
+		return true
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) || isKeyRepeating(ebiten.KeyBackspace) {
+		if len(filter) > 0 {
+			h.inputActions.UpdateHelpFilter(string(filter[:len(filter)-1]))
+		}
+		return true
+	}
+
+	chars := ebiten.AppendInputChars(nil)
+	if len(chars) == 0 {
+		return false
+	}
+	for _, c := range chars {
+		if unicode.IsPrint(c) {
+			filter = append(filter, c)
+		}
+	}
+	h.inputActions.UpdateHelpFilter(string(filter))
+	return true
+}
+
+// isKeyRepeating reports auto-repeat ticks for a held key (after a short delay).
+func isKeyRepeating(key ebiten.Key) bool {
+	const delay = 30
+	const interval = 3
+	d := inpututil.KeyPressDuration(key)
+	return d >= delay && (d-delay)%interval == 0
+}
+
 func (h *InputHandler) checkDigitKeys(startKey, endKey ebiten.Key, baseChar rune) string {
 	for key := startKey; key <= endKey; key++ {
 		if inpututil.IsKeyJustPressed(key) {
@@ -174,13 +423,30 @@ func (h *InputHandler) handleMouseInput() bool {
 		return false
 	}
 
+	// On-screen buttons take clicks and taps before anything else
+	if h.handleOnScreenControls() {
+		return true
+	}
+
+	// The wheel scrolls a zoomed image instead of turning pages
+	h.mousebindingManager.SetZoomMode(h.inputState.GetZoomMode())
+	if dx, dy, ok := h.mousebindingManager.WheelPan(); ok {
+		h.inputActions.PanByDelta(dx, dy)
+		return true
+	}
+
 	// Handle pending action resolution first
 	if h.handlePendingMouseAction() {
 		return true
 	}
 
-	// Handle drag operations (with conflict-aware logic)
-	if h.handleMouseDragWithConflictResolution() {
+	// The ruler takes the left button; other mouse actions keep working
+	if h.inputState.IsMeasuring() {
+		if h.handleMeasureDrag() {
+			return true
+		}
+	} else if h.handleMouseDragWithConflictResolution() {
+		// Handle drag operations (with conflict-aware logic)
 		return true
 	}
 
@@ -196,7 +462,60 @@ func (h *InputHandler) handleMouseInput() bool {
 			return true // Return immediately on first action processed
 		}
 	}
+	for _, action := range scriptActionNames(h.mousebindingManager.GetMousebindings()) {
+		if h.isLeftClickAction(action) {
+			continue
+		}
+		if h.mousebindingManager.ExecuteAction(action, h.inputActions, h.inputState) {
+			debugKV("input", "action", "source", "mouse", "action", action)
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleOnScreenControls runs the action of an on-screen button that was
+// clicked or tapped. The click does not reach the mouse bindings, so it
+// neither turns the page nor counts towards a double click.
+func (h *InputHandler) handleOnScreenControls() bool {
+	// Holding the button after the click must not start a drag
+	if h.controlPressed {
+		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			return true
+		}
+		h.controlPressed = false
+	}
+
+	var x, y int
+	mouse := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	if mouse {
+		x, y = ebiten.CursorPosition()
+	} else if touches := inpututil.AppendJustPressedTouchIDs(nil); len(touches) > 0 {
+		x, y = ebiten.TouchPosition(touches[0])
+	} else {
+		return false
+	}
+	action, ok := h.inputState.OnScreenControlAt(x, y)
+	if !ok {
+		return false
+	}
+	h.controlPressed = mouse
+	debugKV("input", "action", "source", "on_screen_control", "action", action)
+	globalActionExecutor.ExecuteAction(action, h.inputActions, h.inputState)
+	return true
+}
 
+// handleMeasureDrag stretches the ruler from the press position to the cursor
+func (h *InputHandler) handleMeasureDrag() bool {
+	mouseX, mouseY := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		h.inputActions.MeasureFrom(mouseX, mouseY)
+		return true
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		return h.inputActions.MeasureTo(mouseX, mouseY)
+	}
 	return false
 }
 
@@ -343,13 +662,18 @@ func (h *InputHandler) handleMouseDragWithConflictResolution() bool {
 
 // checkAndSetPendingLeftClickActions checks for LeftClick actions and makes them pending
 func (h *InputHandler) checkAndSetPendingLeftClickActions(mouseX, mouseY int) {
+	actions := make([]string, 0, len(actionDefinitions))
 	for _, actionDef := range actionDefinitions {
-		if h.isLeftClickAction(actionDef.Name) {
-			if h.mousebindingManager.CheckAction(actionDef.Name) {
+		actions = append(actions, actionDef.Name)
+	}
+	actions = append(actions, scriptActionNames(h.mousebindingManager.GetMousebindings())...)
+	for _, action := range actions {
+		if h.isLeftClickAction(action) {
+			if h.mousebindingManager.CheckAction(action) {
 				// Found a LeftClick action that would trigger - make it pending
-				h.pendingMouseAction.SetPending(actionDef.Name, mouseX, mouseY)
+				h.pendingMouseAction.SetPending(action, mouseX, mouseY)
 				debugKV("input", "pending_click_set",
-					"action", actionDef.Name,
+					"action", action,
 					"start_x", mouseX,
 					"start_y", mouseY,
 				)
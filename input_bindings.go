@@ -28,6 +28,7 @@ var keyNameToEbitenKey = map[string]ebiten.Key{
 	"Enter":      ebiten.KeyEnter,
 	"Escape":     ebiten.KeyEscape,
 	"Tab":        ebiten.KeyTab,
+	"Delete":     ebiten.KeyDelete,
 	"Home":       ebiten.KeyHome,
 	"End":        ebiten.KeyEnd,
 	"PageUp":     ebiten.KeyPageUp,
@@ -37,6 +38,11 @@ var keyNameToEbitenKey = map[string]ebiten.Key{
 	"ArrowLeft":  ebiten.KeyArrowLeft,
 	"ArrowRight": ebiten.KeyArrowRight,
 
+	// Function keys
+	"F1": ebiten.KeyF1, "F2": ebiten.KeyF2, "F3": ebiten.KeyF3, "F4": ebiten.KeyF4,
+	"F5": ebiten.KeyF5, "F6": ebiten.KeyF6, "F7": ebiten.KeyF7, "F8": ebiten.KeyF8,
+	"F9": ebiten.KeyF9, "F10": ebiten.KeyF10, "F11": ebiten.KeyF11, "F12": ebiten.KeyF12,
+
 	// Punctuation
 	"Comma":     ebiten.KeyComma,
 	"Period":    ebiten.KeyPeriod,
@@ -46,6 +52,9 @@ var keyNameToEbitenKey = map[string]ebiten.Key{
 	"Minus":     ebiten.KeyMinus,
 	"Equal":     ebiten.KeyEqual,
 
+	"BracketLeft":  ebiten.KeyBracketLeft,
+	"BracketRight": ebiten.KeyBracketRight,
+
 	// Numpad
 	"Numpad0":     ebiten.KeyNumpad0,
 	"Numpad1":     ebiten.KeyNumpad1,
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// snapIntegerZoom rounds a fit scale down to the nearest whole multiple
+// (1x, 2x, 3x, ...) or, below 1x, to the nearest 1/n that still fits, so
+// every image pixel covers the same number of screen pixels.
+func snapIntegerZoom(scale float64) float64 {
+	const eps = 1e-9
+	if scale <= 0 {
+		return scale
+	}
+	if scale >= 1 {
+		return math.Floor(scale + eps)
+	}
+	return 1 / math.Ceil(1/scale-eps)
+}
+
+// stepIntegerZoom returns the next whole zoom level above (up) or below
+// level in the sequence ..., 1/3, 1/2, 1, 2, 3, ...
+func stepIntegerZoom(level float64, up bool) float64 {
+	// Index the sequence so that 1x is 0, 2x is 1 and 1/2x is -1
+	levelAt := func(k int) float64 {
+		if k >= 0 {
+			return float64(k + 1)
+		}
+		return 1 / float64(1-k)
+	}
+	snapped := snapIntegerZoom(level)
+	k := int(math.Round(snapped)) - 1
+	if snapped < 1 {
+		k = 1 - int(math.Round(1/snapped))
+	}
+	if up {
+		k++
+	} else if math.Abs(level-snapped) < 1e-9 {
+		k--
+	}
+	return levelAt(k)
+}
+
+// toggleIntegerZoom switches integer zoom, where fitting and zoom steps
+// keep to whole multiples for pixel art.
+func (g *Game) toggleIntegerZoom() {
+	g.config.IntegerZoom = !g.config.IntegerZoom
+	switch g.zoomState.Mode {
+	case ZoomModeManual:
+		if g.config.IntegerZoom {
+			g.zoomState.Level = max(0.25, min(4, snapIntegerZoom(g.zoomState.Level)))
+		}
+	default:
+		g.updateZoomLevelForFitMode()
+	}
+	g.clampPanToLimits()
+	state := "off"
+	if g.config.IntegerZoom {
+		state = "on"
+	}
+	g.showOverlayMessage(fmt.Sprintf("Integer zoom: %s", state))
+	debugKV("viewport", "integer_zoom", "enabled", g.config.IntegerZoom, "level", g.zoomState.Level)
+}
+
+func (g *Game) ToggleIntegerZoom() {
+	g.toggleIntegerZoom()
+}
+
+func (g *Game) IsIntegerZoom() bool {
+	return g.config.IntegerZoom
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"image"
+	"image/color"
 	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 const (
@@ -13,6 +17,8 @@ const (
 type RenderState interface {
 	// Display modes
 	IsFullscreen() bool
+	FitsSmallImages() bool // Fit to window scales small images up
+	IsIntegerZoom() bool   // Scales snap to whole multiples for pixel art
 
 	// Rendering data
 	GetDisplayContent() *DisplayContent
@@ -22,11 +28,54 @@ type RenderState interface {
 	IsFlippedH() bool
 	IsFlippedV() bool
 
+	// Page comparison state
+	GetCompareMode() CompareMode
+	IsCompareShowingRight() bool
+
+	// Presentation state
+	IsPresenting() bool
+	GetBlankMode() BlankMode
+	GetLaserPointer() (image.Point, bool)
+
+	// Sharpen and denoise strengths
+	GetDisplayFilters() (float64, float64)
+	IsDescreening() bool
+	GetCustomShader() (*ebiten.Shader, map[string]any)
+
+	// Guide overlays and ruler
+	GetGuideMode() GuideMode
+	GetGuideStyle() (color.NRGBA, int)
+	GetBackgroundColor() color.Color // Around the image, per window mode
+	GetMeasureLine() (measureLine, bool)
+	GetMeasureDPI() int
+
+	// Auto-hiding on-screen controls
+	GetOnScreenControls() []onScreenControl
+
+	// Thumbnail of the page after the ones on screen
+	GetNextPagePreview() (DisplayImage, bool)
+
 	// UI state
 	IsShowingHelp() bool
+	GetHelpFilter() string
+	GetHelpScroll() int
 	IsShowingInfo() bool
+	IsShowingLoadErrors() bool
+	IsShowingReadingStats() bool
+	GetReadingTimer() (float64, bool)
+	GetLoadingIndicator() (int, bool)
+	GetReadingStats() ReadingStats
+	GetVolumeEnd() []string // "Volume finished" lines, nil when not shown
+	GetGPUMemory() (GPUMemoryStats, bool)
+	GetLoadDiagnostics() string // Debug mode decode and upload timings of the pages on screen
 	IsInPageInputMode() bool
 	GetPageInputBuffer() string
+	IsInTextPrompt() bool
+	GetTextPromptKind() TextPromptKind
+	GetTextPromptBuffer() string
+	GetTextPromptStatus() string
+	GetSearchResults() []SearchResult
+	GetSearchSelection() int
 	GetOverlayMessage() string
 	GetOverlayMessageTime() time.Time
 
@@ -42,6 +91,9 @@ type RenderState interface {
 	GetConfigStatus() ConfigLoadResult
 	GetKeybindings() map[string][]string
 	GetMousebindings() map[string][]string
+	GetRecentFiles() []string
+	GetLoadFailure() *CollectionLoadFailure
+	GetLoadErrors() []ImageLoadError
 
 	// Settings overlay state
 	IsShowingSettings() bool
@@ -59,16 +111,40 @@ type RenderStateSnapshot struct {
 	// Window dimensions for resize detection
 	WindowWidth  int
 	WindowHeight int
+
+	// Laser pointer position, moved by the mouse without input actions
+	LaserPointer        image.Point
+	LaserPointerVisible bool
+
+	// Ruler, dragged out with the mouse
+	MeasureLine    measureLine
+	MeasureVisible bool
+
+	// Reading timer progress step, advancing without input
+	ReadingTimerStep int
+
+	// Loading spinner frame, turning while a page decodes
+	LoadingStep int
 }
 
 // NewRenderStateSnapshot creates a lightweight snapshot of non-key-input state
 // Only tracks fields that can change without key input
 func NewRenderStateSnapshot(state RenderState, windowWidth, windowHeight int) *RenderStateSnapshot {
+	laser, laserVisible := state.GetLaserPointer()
+	ruler, rulerVisible := state.GetMeasureLine()
+	timer, timerActive := state.GetReadingTimer()
+	loading, loadingVisible := state.GetLoadingIndicator()
 	return &RenderStateSnapshot{
-		OverlayMessage:     state.GetOverlayMessage(),
-		OverlayMessageTime: state.GetOverlayMessageTime(),
-		WindowWidth:        windowWidth,
-		WindowHeight:       windowHeight,
+		OverlayMessage:      state.GetOverlayMessage(),
+		OverlayMessageTime:  state.GetOverlayMessageTime(),
+		WindowWidth:         windowWidth,
+		WindowHeight:        windowHeight,
+		LaserPointer:        laser,
+		LaserPointerVisible: laserVisible,
+		MeasureLine:         ruler,
+		MeasureVisible:      rulerVisible,
+		ReadingTimerStep:    readingTimerStep(timer, timerActive),
+		LoadingStep:         loadingIndicatorStep(loading, loadingVisible),
 	}
 }
 
@@ -107,7 +183,13 @@ func (s *RenderStateSnapshot) Equals(other *RenderStateSnapshot) bool {
 	// Compare only fields that can change without key input
 	return overlayEqual() &&
 		s.WindowWidth == other.WindowWidth &&
-		s.WindowHeight == other.WindowHeight
+		s.WindowHeight == other.WindowHeight &&
+		s.LaserPointer == other.LaserPointer &&
+		s.LaserPointerVisible == other.LaserPointerVisible &&
+		s.MeasureLine == other.MeasureLine &&
+		s.MeasureVisible == other.MeasureVisible &&
+		s.ReadingTimerStep == other.ReadingTimerStep &&
+		s.LoadingStep == other.LoadingStep
 }
 
 // InputActions provides action methods for the input handler
@@ -118,15 +200,85 @@ type InputActions interface {
 	// Display toggles
 	ToggleHelp()
 	ToggleInfo()
+	ToggleLoadErrors()
+	ToggleReadingStats()
 	ToggleBookMode()
 	ToggleFullscreen()
+	ToggleMaximize()
+	CycleFullscreenMonitor()
 	ResetWindowSize()
 
+	// Help overlay filter and scrolling
+	UpdateHelpFilter(filter string)
+	ScrollHelp(delta int)
+	PageHelp(delta int)
+
 	// Page input
 	EnterPageInputMode()
 	ExitPageInputMode()
 	ProcessPageInput()
 	UpdatePageInputBuffer(buffer string)
+	SearchFromPageInput(query string)
+
+	// Text prompt input (rename, tag, filter)
+	EnterRenameMode()
+	ExitTextPrompt()
+	SubmitTextPrompt()
+	UpdateTextPromptBuffer(buffer string)
+	MoveTextPromptSelection(delta int)
+
+	// Ratings, tags and filtering
+	SetRating(rating int)
+	EnterTagInput()
+	EnterFilterInput()
+	EnterNameFilter()
+	EnterSearch()
+
+	// Config export and import
+	EnterConfigExport()
+	EnterConfigImport()
+	SaveSettingsNow()
+
+	// Animation playback
+	ToggleAnimationPause()
+	StepAnimationFrame(delta int)
+	ChangeAnimationSpeed(delta int)
+
+	// HDR tone mapping and 16-bit levels
+	ChangeHDRExposure(delta float64)
+	ResetHDRExposure()
+	ChangeSharpen(delta float64)
+	ChangeDenoise(delta float64)
+	ToggleDescreen()
+	ToggleCustomShader()
+	ToggleLevelsStretch()
+	CycleCompareMode()
+	ToggleSlideshow()
+	ToggleKioskLock()
+	ToggleReadingTimer()
+	PrintCurrent(spread bool)
+	ExtractText()
+	PasteImage()
+	AcceptSelection()
+	ToggleMark()
+	DeleteFile()
+	Undo()
+	Redo()
+	HistoryBack()
+	HistoryForward()
+	AcceptMarked()
+	Screenshot()
+	ExportSpread()
+	TogglePresentation()
+	ToggleLaserPointer()
+	CycleBlankScreen()
+	CycleGuideMode()
+	ToggleMeasure()
+	MeasureFrom(x, y int)
+	MeasureTo(x, y int) bool
+	ExportContactSheet()
+	ExportPages()
+	RunScriptAction(name string)
 
 	// Settings UI
 	ToggleSettings()
@@ -141,6 +293,8 @@ type InputActions interface {
 	ToggleReadingDirection()
 	CycleSortMethod()
 	MarkCurrentAsPreJoinedSpread()
+	ForcePair()
+	ForceSplit()
 
 	// Navigation
 	NavigateNext()
@@ -148,11 +302,23 @@ type InputActions interface {
 	NavigateNextSingle()
 	NavigatePreviousSingle()
 	JumpToPage(page int)
+	JumpByPercent(percent int)
+	NextChapter()
+	PreviousChapter()
+	OpenChapterList()
+	NextAnomaly()
 	ExpandToDirectory()
+	ReloadCurrentImage()
+	RescanCollection()
+	OpenFileDialog()
+	OpenDirectoryDialog()
+	OpenRecentFile(index int)
+	RetryFailedOpen()
 
 	// Transformations
 	RotateLeft()
 	RotateRight()
+	ToggleAutoRotate()
 	FlipHorizontal()
 	FlipVertical()
 
@@ -161,11 +327,17 @@ type InputActions interface {
 	ZoomOut()
 	ZoomReset()
 	ZoomFit()
+	ToggleUpscaleInWindow()
+	ToggleIntegerZoom()
 	PanUp()
 	PanDown()
 	PanLeft()
 	PanRight()
 	PanByDelta(deltaX, deltaY float64) // Mouse drag pan
+	PanContinuous(dirX, dirY float64)  // Held pan keys
+	PanPage(dir int)                   // Pan by page_scroll_fraction of the screen
+	SmartScroll(dir int)               // Scroll a tall page, turning it at the edge
+	TurnPageAtPanEdge(dir int) bool    // Pan past the edge turns the page when configured
 
 	// Common data access
 	GetTotalPagesCount() int
@@ -175,6 +347,14 @@ type InputActions interface {
 type InputState interface {
 	IsInPageInputMode() bool
 	GetPageInputBuffer() string
+	IsInTextPrompt() bool
+	GetTextPromptBuffer() string
 	GetZoomMode() ZoomMode // For drag permission checking
 	IsInSettingsMode() bool
+	IsShowingHelp() bool
+	GetHelpFilter() string
+	IsMeasuring() bool    // Left drag draws the ruler instead of panning
+	GetPanSpeed() float64 // 0 pans in steps per key press
+	OnScreenControlAt(x, y int) (string, bool)
+	IsKioskLocked() bool
 }
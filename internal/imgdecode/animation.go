@@ -0,0 +1,143 @@
+package imgdecode
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxAnimationBytes caps the RGBA memory of all composited frames; larger
+	// animations fall back to a static first frame.
+	MaxAnimationBytes = 512 << 20
+
+	// Frames declaring a delay of 10ms or less play at zeroFrameDelay and
+	// other short delays are raised to minFrameDelay, matching common
+	// browser behavior.
+	minFrameDelay  = 20 * time.Millisecond
+	zeroFrameDelay = 100 * time.Millisecond
+)
+
+// Animation holds the fully composited frames of an animated image, ready to
+// be shown one after another.
+type Animation struct {
+	Frames []*image.RGBA
+	Delays []time.Duration
+}
+
+// IsAnimationCandidate reports whether data is in a format that may carry
+// several frames.
+func IsAnimationCandidate(data []byte) bool {
+	return isGIFData(data) || isAPNGData(data) || isAnimatedWebPData(data)
+}
+
+// MayBeAnimated reports whether a file with this name can hold an animation,
+// so callers read it whole and try DecodeAnimation first.
+func MayBeAnimated(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif", ".png", ".webp":
+		return true
+	default:
+		return false
+	}
+}
+
+// DecodeAnimation decodes data as an animation. It returns nil without error
+// when data is not an animated format, holds a single frame, or would exceed
+// MaxAnimationBytes.
+func DecodeAnimation(data []byte) (*Animation, error) {
+	switch {
+	case isGIFData(data):
+		return decodeGIFAnimation(data)
+	case isAPNGData(data):
+		return decodeAPNGAnimation(data)
+	case isAnimatedWebPData(data):
+		return decodeWebPAnimation(data)
+	default:
+		return nil, nil
+	}
+}
+
+func isGIFData(data []byte) bool {
+	return len(data) >= 6 && string(data[:4]) == "GIF8"
+}
+
+func decodeGIFAnimation(data []byte) (*Animation, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) < 2 {
+		return nil, nil
+	}
+
+	canvasRect := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if canvasRect.Empty() {
+		canvasRect = g.Image[0].Bounds()
+	}
+	if !fitsAnimationBudget(canvasRect, len(g.Image)) {
+		return nil, nil
+	}
+
+	canvas := image.NewRGBA(canvasRect)
+	anim := &Animation{
+		Frames: make([]*image.RGBA, 0, len(g.Image)),
+		Delays: make([]time.Duration, 0, len(g.Image)),
+	}
+	for i, frame := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+
+		delay := 0
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+		anim.Delays = append(anim.Delays, normalizeFrameDelay(time.Duration(delay)*10*time.Millisecond))
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return anim, nil
+}
+
+// fitsAnimationBudget reports whether frames of the given size stay within
+// MaxAnimationBytes once composited to RGBA.
+func fitsAnimationBudget(rect image.Rectangle, frames int) bool {
+	frameBytes := int64(rect.Dx()) * int64(rect.Dy()) * 4
+	return frameBytes > 0 && frameBytes*int64(frames) <= MaxAnimationBytes
+}
+
+func normalizeFrameDelay(d time.Duration) time.Duration {
+	switch {
+	case d <= 10*time.Millisecond:
+		return zeroFrameDelay
+	case d < minFrameDelay:
+		return minFrameDelay
+	default:
+		return d
+	}
+}
+
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
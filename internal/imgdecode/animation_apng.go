@@ -0,0 +1,229 @@
+package imgdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// apngFrame is one fcTL control chunk plus the image data that follows it.
+type apngFrame struct {
+	rect    image.Rectangle
+	delay   time.Duration
+	dispose byte
+	blend   byte
+	data    [][]byte
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !isPNGData(data) {
+		return nil, errors.New("not a PNG")
+	}
+	var chunks []pngChunk
+	rest := data[len(pngSignature):]
+	for len(rest) >= 12 {
+		length := binary.BigEndian.Uint32(rest[:4])
+		if uint64(length)+12 > uint64(len(rest)) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunk := pngChunk{typ: string(rest[4:8]), data: rest[8 : 8+length]}
+		chunks = append(chunks, chunk)
+		rest = rest[12+length:]
+		if chunk.typ == "IEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// isAPNGData reports whether a PNG declares animation (acTL before IDAT).
+func isAPNGData(data []byte) bool {
+	if !isPNGData(data) {
+		return false
+	}
+	rest := data[len(pngSignature):]
+	for len(rest) >= 8 {
+		length := binary.BigEndian.Uint32(rest[:4])
+		switch string(rest[4:8]) {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+		if uint64(length)+12 > uint64(len(rest)) {
+			return false
+		}
+		rest = rest[12+length:]
+	}
+	return false
+}
+
+func decodeAPNGAnimation(data []byte) (*Animation, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, errors.New("missing PNG IHDR")
+	}
+	ihdr := chunks[0].data
+	canvasRect := image.Rect(0, 0, int(binary.BigEndian.Uint32(ihdr[0:4])), int(binary.BigEndian.Uint32(ihdr[4:8])))
+
+	// Chunks that every frame needs to decode on its own
+	var shared []pngChunk
+	var frames []*apngFrame
+	var current *apngFrame
+	for _, chunk := range chunks[1:] {
+		switch chunk.typ {
+		case "PLTE", "tRNS", "gAMA", "cHRM", "sRGB", "iCCP", "sBIT":
+			if len(frames) == 0 {
+				shared = append(shared, chunk)
+			}
+		case "fcTL":
+			frame, err := parseAPNGFrameControl(chunk.data)
+			if err != nil {
+				return nil, err
+			}
+			current = frame
+			frames = append(frames, frame)
+		case "IDAT":
+			// The default image is only part of the animation when an fcTL
+			// precedes it.
+			if current != nil {
+				current.data = append(current.data, chunk.data)
+			}
+		case "fdAT":
+			if current == nil || len(chunk.data) < 4 {
+				return nil, errors.New("fdAT without frame control")
+			}
+			current.data = append(current.data, chunk.data[4:])
+		}
+	}
+	if len(frames) < 2 {
+		return nil, nil
+	}
+	if !fitsAnimationBudget(canvasRect, len(frames)) {
+		return nil, nil
+	}
+
+	canvas := image.NewRGBA(canvasRect)
+	anim := &Animation{
+		Frames: make([]*image.RGBA, 0, len(frames)),
+		Delays: make([]time.Duration, 0, len(frames)),
+	}
+	for i, frame := range frames {
+		if !frame.rect.In(canvasRect) {
+			return nil, fmt.Errorf("APNG frame %d outside canvas", i)
+		}
+		img, err := decodeAPNGFrame(ihdr, shared, frame)
+		if err != nil {
+			return nil, fmt.Errorf("APNG frame %d: %w", i, err)
+		}
+
+		dispose := frame.dispose
+		if i == 0 && dispose == apngDisposePrevious {
+			dispose = apngDisposeBackground
+		}
+		var previous *image.RGBA
+		if dispose == apngDisposePrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		op := draw.Over
+		if frame.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, frame.rect, img, img.Bounds().Min, op)
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+		anim.Delays = append(anim.Delays, normalizeFrameDelay(frame.delay))
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, frame.rect, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			canvas = previous
+		}
+	}
+	return anim, nil
+}
+
+func parseAPNGFrameControl(data []byte) (*apngFrame, error) {
+	if len(data) != 26 {
+		return nil, errors.New("invalid fcTL chunk")
+	}
+	width := int(binary.BigEndian.Uint32(data[4:8]))
+	height := int(binary.BigEndian.Uint32(data[8:12]))
+	x := int(binary.BigEndian.Uint32(data[12:16]))
+	y := int(binary.BigEndian.Uint32(data[16:20]))
+	delayNum := binary.BigEndian.Uint16(data[20:22])
+	delayDen := binary.BigEndian.Uint16(data[22:24])
+	if delayDen == 0 {
+		delayDen = 100
+	}
+	return &apngFrame{
+		rect:    image.Rect(x, y, x+width, y+height),
+		delay:   time.Duration(delayNum) * time.Second / time.Duration(delayDen),
+		dispose: data[24],
+		blend:   data[25],
+	}, nil
+}
+
+// decodeAPNGFrame rebuilds a standalone PNG for one frame (IHDR resized to
+// the frame, shared palette/color chunks, frame data as IDAT) and decodes it.
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, frame *apngFrame) (image.Image, error) {
+	if len(frame.data) == 0 {
+		return nil, errors.New("frame has no image data")
+	}
+
+	frameIHDR := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(frameIHDR[0:4], uint32(frame.rect.Dx()))
+	binary.BigEndian.PutUint32(frameIHDR[4:8], uint32(frame.rect.Dy()))
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, "IHDR", frameIHDR)
+	for _, chunk := range shared {
+		writePNGChunk(&buf, chunk.typ, chunk.data)
+	}
+	for _, data := range frame.data {
+		writePNGChunk(&buf, "IDAT", data)
+	}
+	writePNGChunk(&buf, "IEND", nil)
+	return png.Decode(&buf)
+}
+
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], typ)
+	buf.Write(header[:])
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
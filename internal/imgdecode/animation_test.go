@@ -0,0 +1,232 @@
+package imgdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestDecodeAnimationGIFComposesFrames(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	for i := range first.Pix {
+		first.Pix[i] = 1
+	}
+	// Second frame only covers the top-left pixel; the rest must keep the
+	// first frame's content (DisposalNone).
+	second := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+	second.Pix[0] = 2
+
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:    []*image.Paletted{first, second},
+		Delay:    []int{5, 0},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{ColorModel: palette, Width: 4, Height: 4},
+	})
+	if err != nil {
+		t.Fatalf("gif encode: %v", err)
+	}
+
+	if !IsAnimationCandidate(buf.Bytes()) {
+		t.Fatal("expected GIF data to be an animation candidate")
+	}
+	anim, err := DecodeAnimation(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeAnimation failed: %v", err)
+	}
+	if anim == nil || len(anim.Frames) != 2 {
+		t.Fatalf("frames = %v, want 2", anim)
+	}
+	if got := anim.Frames[1].RGBAAt(0, 0); got != (color.RGBA{B: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (0,0) = %v, want blue", got)
+	}
+	if got := anim.Frames[1].RGBAAt(3, 3); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (3,3) = %v, want red carried over", got)
+	}
+	if anim.Delays[0] != 50*time.Millisecond || anim.Delays[1] != zeroFrameDelay {
+		t.Fatalf("delays = %v", anim.Delays)
+	}
+}
+
+func TestDecodeAnimationSingleFrameReturnsNil(t *testing.T) {
+	var buf bytes.Buffer
+	img := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White})
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("gif encode: %v", err)
+	}
+
+	anim, err := DecodeAnimation(buf.Bytes())
+	if err != nil || anim != nil {
+		t.Fatalf("DecodeAnimation = %v, %v; want nil, nil", anim, err)
+	}
+}
+
+func TestDecodeAnimationAPNGComposesFrames(t *testing.T) {
+	red := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(red, red.Bounds(), image.NewUniform(color.NRGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	blue := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(blue, blue.Bounds(), image.NewUniform(color.NRGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+
+	redChunks := encodePNGChunks(t, red)
+	blueChunks := encodePNGChunks(t, blue)
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, "IHDR", redChunks["IHDR"])
+	writePNGChunk(&buf, "acTL", []byte{0, 0, 0, 2, 0, 0, 0, 0})
+	writePNGChunk(&buf, "fcTL", apngFrameControl(0, 4, 4, 0, 0, 3, 100))
+	writePNGChunk(&buf, "IDAT", redChunks["IDAT"])
+	writePNGChunk(&buf, "fcTL", apngFrameControl(1, 2, 2, 2, 2, 0, 0))
+	writePNGChunk(&buf, "fdAT", append([]byte{0, 0, 0, 2}, blueChunks["IDAT"]...))
+	writePNGChunk(&buf, "IEND", nil)
+
+	if !IsAnimationCandidate(buf.Bytes()) {
+		t.Fatal("expected APNG data to be an animation candidate")
+	}
+	anim, err := DecodeAnimation(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeAnimation failed: %v", err)
+	}
+	if anim == nil || len(anim.Frames) != 2 {
+		t.Fatalf("frames = %v, want 2", anim)
+	}
+	if got := anim.Frames[1].RGBAAt(3, 3); got != (color.RGBA{B: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (3,3) = %v, want blue", got)
+	}
+	if got := anim.Frames[1].RGBAAt(0, 0); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (0,0) = %v, want red carried over", got)
+	}
+	if anim.Delays[0] != 30*time.Millisecond || anim.Delays[1] != zeroFrameDelay {
+		t.Fatalf("delays = %v", anim.Delays)
+	}
+
+	// The default image must still decode as a static PNG.
+	if _, err := DecodeBytes(buf.Bytes(), "anim.png"); err != nil {
+		t.Fatalf("static decode of APNG failed: %v", err)
+	}
+}
+
+func TestDecodeAnimationWebPComposesFrames(t *testing.T) {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation
+	putUint24LE(vp8x[4:7], 3)
+	putUint24LE(vp8x[7:10], 3)
+	writeRIFFChunk(&body, "VP8X", vp8x)
+	writeRIFFChunk(&body, "ANIM", make([]byte, 6))
+	writeRIFFChunk(&body, "ANMF", webpAnimationFrame(0, 0, 4, 4, 40, 0, color.NRGBA{R: 255, A: 255}))
+	writeRIFFChunk(&body, "ANMF", webpAnimationFrame(2, 2, 2, 2, 60, webpFrameNoBlend, color.NRGBA{G: 255, A: 255}))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(body.Len()))
+	buf.Write(body.Bytes())
+
+	if !IsAnimationCandidate(buf.Bytes()) {
+		t.Fatal("expected animated WebP data to be an animation candidate")
+	}
+	anim, err := DecodeAnimation(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeAnimation failed: %v", err)
+	}
+	if anim == nil || len(anim.Frames) != 2 {
+		t.Fatalf("frames = %v, want 2", anim)
+	}
+	if got := anim.Frames[1].RGBAAt(3, 3); got != (color.RGBA{G: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (3,3) = %v, want green", got)
+	}
+	if got := anim.Frames[1].RGBAAt(0, 0); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("frame 2 pixel (0,0) = %v, want red carried over", got)
+	}
+	if anim.Delays[0] != 40*time.Millisecond || anim.Delays[1] != 60*time.Millisecond {
+		t.Fatalf("delays = %v", anim.Delays)
+	}
+}
+
+func encodePNGChunks(t *testing.T, img image.Image) map[string][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png encode: %v", err)
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatalf("read chunks: %v", err)
+	}
+	byType := map[string][]byte{}
+	for _, chunk := range chunks {
+		byType[chunk.typ] = append(byType[chunk.typ], chunk.data...)
+	}
+	return byType
+}
+
+func apngFrameControl(seq, w, h, x, y uint32, delayNum, delayDen uint16) []byte {
+	data := make([]byte, 26)
+	binary.BigEndian.PutUint32(data[0:4], seq)
+	binary.BigEndian.PutUint32(data[4:8], w)
+	binary.BigEndian.PutUint32(data[8:12], h)
+	binary.BigEndian.PutUint32(data[12:16], x)
+	binary.BigEndian.PutUint32(data[16:20], y)
+	binary.BigEndian.PutUint16(data[20:22], delayNum)
+	binary.BigEndian.PutUint16(data[22:24], delayDen)
+	return data
+}
+
+func webpAnimationFrame(x, y, w, h, durationMS int, flags byte, c color.NRGBA) []byte {
+	header := make([]byte, 16)
+	putUint24LE(header[0:3], x/2)
+	putUint24LE(header[3:6], y/2)
+	putUint24LE(header[6:9], w-1)
+	putUint24LE(header[9:12], h-1)
+	putUint24LE(header[12:15], durationMS)
+	header[15] = flags
+
+	var frame bytes.Buffer
+	frame.Write(header)
+	writeRIFFChunk(&frame, "VP8L", encodeSolidVP8L(w, h, c))
+	return frame.Bytes()
+}
+
+// encodeSolidVP8L writes a lossless bitstream whose five prefix codes each
+// hold a single symbol, so every pixel is c without any per-pixel bits.
+func encodeSolidVP8L(w, h int, c color.NRGBA) []byte {
+	var out []byte
+	var acc uint64
+	var n uint
+	put := func(v uint64, bits uint) {
+		acc |= v << n
+		n += bits
+		for n >= 8 {
+			out = append(out, byte(acc))
+			acc >>= 8
+			n -= 8
+		}
+	}
+
+	put(0x2f, 8)
+	put(uint64(w-1), 14)
+	put(uint64(h-1), 14)
+	put(1, 1) // alpha used
+	put(0, 3) // version
+	put(0, 1) // no transform
+	put(0, 1) // no color cache
+	put(0, 1) // no meta prefix codes
+	for _, symbol := range []uint8{c.G, c.R, c.B, c.A, 0} {
+		put(1, 1) // simple code
+		put(0, 1) // one symbol
+		put(1, 1) // 8-bit symbol
+		put(uint64(symbol), 8)
+	}
+	if n > 0 {
+		out = append(out, byte(acc))
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package imgdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	webpFlagAnimation = 0x02
+	webpFlagAlpha     = 0x10
+
+	webpFrameDisposeBackground = 0x01
+	webpFrameNoBlend           = 0x02
+)
+
+type riffChunk struct {
+	typ  string
+	data []byte
+}
+
+func readRIFFChunks(data []byte) []riffChunk {
+	var chunks []riffChunk
+	for len(data) >= 8 {
+		size := binary.LittleEndian.Uint32(data[4:8])
+		if uint64(size) > uint64(len(data)-8) {
+			break
+		}
+		chunks = append(chunks, riffChunk{typ: string(data[:4]), data: data[8 : 8+size]})
+		next := 8 + int(size) + int(size&1)
+		if next > len(data) {
+			break
+		}
+		data = data[next:]
+	}
+	return chunks
+}
+
+func isWebPData(data []byte) bool {
+	return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// isAnimatedWebPData reports whether a WebP file sets the VP8X animation flag.
+func isAnimatedWebPData(data []byte) bool {
+	return isWebPData(data) && len(data) >= 21 && string(data[12:16]) == "VP8X" && data[20]&webpFlagAnimation != 0
+}
+
+func readUint24LE(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+func decodeWebPAnimation(data []byte) (*Animation, error) {
+	chunks := readRIFFChunks(data[12:])
+	if len(chunks) == 0 || chunks[0].typ != "VP8X" || len(chunks[0].data) < 10 {
+		return nil, errors.New("missing WebP VP8X header")
+	}
+	vp8x := chunks[0].data
+	canvasRect := image.Rect(0, 0, readUint24LE(vp8x[4:7])+1, readUint24LE(vp8x[7:10])+1)
+
+	var frames []riffChunk
+	for _, chunk := range chunks[1:] {
+		if chunk.typ == "ANMF" {
+			frames = append(frames, chunk)
+		}
+	}
+	if len(frames) < 2 {
+		return nil, nil
+	}
+	if !fitsAnimationBudget(canvasRect, len(frames)) {
+		return nil, nil
+	}
+
+	canvas := image.NewRGBA(canvasRect)
+	anim := &Animation{
+		Frames: make([]*image.RGBA, 0, len(frames)),
+		Delays: make([]time.Duration, 0, len(frames)),
+	}
+	for i, frame := range frames {
+		if len(frame.data) < 16 {
+			return nil, fmt.Errorf("WebP frame %d: truncated ANMF chunk", i)
+		}
+		header := frame.data[:16]
+		x, y := readUint24LE(header[0:3])*2, readUint24LE(header[3:6])*2
+		w, h := readUint24LE(header[6:9])+1, readUint24LE(header[9:12])+1
+		rect := image.Rect(x, y, x+w, y+h)
+		if !rect.In(canvasRect) {
+			return nil, fmt.Errorf("WebP frame %d outside canvas", i)
+		}
+		flags := header[15]
+
+		img, err := decodeWebPFrame(frame.data[16:], w, h)
+		if err != nil {
+			return nil, fmt.Errorf("WebP frame %d: %w", i, err)
+		}
+
+		op := draw.Over
+		if flags&webpFrameNoBlend != 0 {
+			op = draw.Src
+		}
+		draw.Draw(canvas, rect, img, img.Bounds().Min, op)
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+		anim.Delays = append(anim.Delays, normalizeFrameDelay(time.Duration(readUint24LE(header[12:15]))*time.Millisecond))
+
+		if flags&webpFrameDisposeBackground != 0 {
+			draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+// decodeWebPFrame wraps the bitstream chunks of one ANMF frame in a
+// standalone WebP container and decodes it. Frames with a separate alpha
+// chunk need an extended (VP8X) header to carry it.
+func decodeWebPFrame(frameData []byte, width, height int) (image.Image, error) {
+	var alpha, bitstream *riffChunk
+	chunks := readRIFFChunks(frameData)
+	for i := range chunks {
+		switch chunks[i].typ {
+		case "ALPH":
+			alpha = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, errors.New("frame has no image data")
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	if alpha != nil && bitstream.typ == "VP8 " {
+		header := make([]byte, 10)
+		header[0] = webpFlagAlpha
+		putUint24LE(header[4:7], width-1)
+		putUint24LE(header[7:10], height-1)
+		writeRIFFChunk(&body, "VP8X", header)
+		writeRIFFChunk(&body, "ALPH", alpha.data)
+	}
+	writeRIFFChunk(&body, bitstream.typ, bitstream.data)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(body.Len()))
+	buf.Write(size[:])
+	buf.Write(body.Bytes())
+	return webp.Decode(&buf)
+}
+
+func putUint24LE(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+func writeRIFFChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var header [8]byte
+	copy(header[:4], typ)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	buf.Write(header[:])
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
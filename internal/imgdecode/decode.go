@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
 )
 
@@ -21,6 +22,25 @@ var errNativeUnavailable = errors.New("native decoder unavailable")
 
 const nativePNGMinPixels = 1_000_000
 
+// Limits for the built-in decoders, checked against header dimensions
+// before any pixel buffer is allocated so that a corrupt or crafted file
+// fails with an error instead of exhausting memory.
+const (
+	maxImageDimension = 1 << 16
+	maxImagePixels    = 1 << 27
+)
+
+// checkImageSize rejects dimensions beyond the decoder limits.
+func checkImageSize(format string, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%s: invalid dimensions", format)
+	}
+	if width > maxImageDimension || height > maxImageDimension || width*height > maxImagePixels {
+		return fmt.Errorf("%s: image size %dx%d exceeds the decoder limit", format, width, height)
+	}
+	return nil
+}
+
 // DecodeFile decodes an image from a filesystem path.
 func DecodeFile(path string) (image.Image, error) {
 	if !nativeEnabled() {
@@ -76,6 +96,11 @@ func shouldTryNative(data []byte, origin string) bool {
 	if !isPNGData(data) && !hasPNGExt(origin) {
 		return false
 	}
+	// Native decoders return 8-bit pixels; keep 16-bit PNGs on the Go
+	// decoder so levels can work on the full precision.
+	if pngBitDepth(data) == 16 {
+		return false
+	}
 
 	width, height, ok := pngDimensions(data)
 	if !ok {
@@ -114,6 +139,13 @@ func pngDimensions(data []byte) (int, int, bool) {
 	return int(width), int(height), true
 }
 
+func pngBitDepth(data []byte) int {
+	if len(data) < 25 || !isPNGData(data) || string(data[12:16]) != "IHDR" {
+		return 0
+	}
+	return int(data[24])
+}
+
 func isJPEGData(data []byte) bool {
 	return len(data) >= 2 && data[0] == 0xff && data[1] == 0xd8
 }
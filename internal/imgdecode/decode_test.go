@@ -60,3 +60,25 @@ func TestDecodeBytesInvalid(t *testing.T) {
 		t.Fatalf("expected invalid image error")
 	}
 }
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		head []byte
+		want string
+	}{
+		{[]byte("\x89PNG\r\n\x1a\n\x00\x00"), "png"},
+		{[]byte{0xff, 0xd8, 0xff, 0xe0}, "jpeg"},
+		{[]byte("GIF89a..."), "gif"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "webp"},
+		{[]byte("RIFF\x00\x00\x00\x00AVI LIST"), ""},
+		{[]byte("II*\x00\x08\x00"), "tiff"},
+		{[]byte("#?RADIANCE\n"), "hdr"},
+		{[]byte("PK\x03\x04"), ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := SniffFormat(tt.head); got != tt.want {
+			t.Errorf("SniffFormat(%q) = %q, want %q", tt.head, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package imgdecode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+)
+
+// OpenEXR support covers single-part scanline images with NONE, RLE, ZIPS
+// or ZIP compression. Only the R, G, B, A (or luminance Y) channels are
+// read; tiled, deep and multi-part files are rejected.
+
+const exrMagic = "\x76\x2f\x31\x01"
+
+const (
+	exrFlagTiled     = 0x200
+	exrFlagNonImage  = 0x800
+	exrFlagMultipart = 0x1000
+
+	exrPixelUint  = 0
+	exrPixelHalf  = 1
+	exrPixelFloat = 2
+
+	exrCompressNone = 0
+	exrCompressRLE  = 1
+	exrCompressZIPS = 2
+	exrCompressZIP  = 3
+
+	// exrMaxChannels bounds the per-line buffer a channel list can demand.
+	exrMaxChannels = 64
+)
+
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+func (c exrChannel) size() int {
+	if c.pixelType == exrPixelHalf {
+		return 2
+	}
+	return 4
+}
+
+type exrHeader struct {
+	channels    []exrChannel
+	compression byte
+	dataWindow  image.Rectangle
+}
+
+func isEXRData(data []byte) bool {
+	return len(data) >= 8 && string(data[:4]) == exrMagic
+}
+
+func readEXRString(r *bytes.Reader) (string, error) {
+	var buf []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == 0 {
+			return string(buf), nil
+		}
+		buf = append(buf, b)
+	}
+}
+
+func readEXRHeader(r *bytes.Reader) (exrHeader, error) {
+	var preamble [8]byte
+	if _, err := io.ReadFull(r, preamble[:]); err != nil {
+		return exrHeader{}, err
+	}
+	if string(preamble[:4]) != exrMagic {
+		return exrHeader{}, errors.New("exr: invalid signature")
+	}
+	flags := binary.LittleEndian.Uint32(preamble[4:])
+	if flags&exrFlagTiled != 0 {
+		return exrHeader{}, errors.New("exr: tiled images are not supported")
+	}
+	if flags&(exrFlagNonImage|exrFlagMultipart) != 0 {
+		return exrHeader{}, errors.New("exr: deep and multi-part images are not supported")
+	}
+
+	var h exrHeader
+	haveChannels, haveWindow := false, false
+	for {
+		name, err := readEXRString(r)
+		if err != nil {
+			return exrHeader{}, err
+		}
+		if name == "" {
+			break
+		}
+		if _, err := readEXRString(r); err != nil { // attribute type
+			return exrHeader{}, err
+		}
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return exrHeader{}, err
+		}
+		if size < 0 || int64(size) > int64(r.Len()) {
+			return exrHeader{}, errors.New("exr: attribute overflows header")
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return exrHeader{}, err
+		}
+
+		switch name {
+		case "channels":
+			channels, err := parseEXRChannels(value)
+			if err != nil {
+				return exrHeader{}, err
+			}
+			h.channels = channels
+			haveChannels = true
+		case "compression":
+			if len(value) != 1 {
+				return exrHeader{}, errors.New("exr: invalid compression attribute")
+			}
+			h.compression = value[0]
+		case "dataWindow":
+			if len(value) != 16 {
+				return exrHeader{}, errors.New("exr: invalid dataWindow attribute")
+			}
+			xMin := int(int32(binary.LittleEndian.Uint32(value[0:])))
+			yMin := int(int32(binary.LittleEndian.Uint32(value[4:])))
+			xMax := int(int32(binary.LittleEndian.Uint32(value[8:])))
+			yMax := int(int32(binary.LittleEndian.Uint32(value[12:])))
+			h.dataWindow = image.Rect(xMin, yMin, xMax+1, yMax+1)
+			haveWindow = true
+		}
+	}
+	if !haveChannels || !haveWindow {
+		return exrHeader{}, errors.New("exr: missing channels or dataWindow")
+	}
+	if h.dataWindow.Empty() {
+		return exrHeader{}, errors.New("exr: invalid dimensions")
+	}
+	return h, nil
+}
+
+func parseEXRChannels(value []byte) ([]exrChannel, error) {
+	r := bytes.NewReader(value)
+	var channels []exrChannel
+	for {
+		name, err := readEXRString(r)
+		if err != nil {
+			return nil, fmt.Errorf("exr: channel list: %w", err)
+		}
+		if name == "" {
+			return channels, nil
+		}
+		var fields struct {
+			PixelType int32
+			Linear    uint8
+			Reserved  [3]uint8
+			XSampling int32
+			YSampling int32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &fields); err != nil {
+			return nil, fmt.Errorf("exr: channel list: %w", err)
+		}
+		if fields.PixelType < exrPixelUint || fields.PixelType > exrPixelFloat {
+			return nil, fmt.Errorf("exr: channel %q has unknown pixel type %d", name, fields.PixelType)
+		}
+		if fields.XSampling != 1 || fields.YSampling != 1 {
+			return nil, fmt.Errorf("exr: subsampled channel %q is not supported", name)
+		}
+		channels = append(channels, exrChannel{name: name, pixelType: fields.PixelType})
+	}
+}
+
+func exrLinesPerChunk(compression byte) (int, error) {
+	switch compression {
+	case exrCompressNone, exrCompressRLE, exrCompressZIPS:
+		return 1, nil
+	case exrCompressZIP:
+		return 16, nil
+	default:
+		return 0, fmt.Errorf("exr: unsupported compression %d", compression)
+	}
+}
+
+func decodeEXR(data []byte) (*HDRImage, error) {
+	r := bytes.NewReader(data)
+	h, err := readEXRHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	linesPerChunk, err := exrLinesPerChunk(h.compression)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := h.dataWindow.Dx(), h.dataWindow.Dy()
+	if err := checkImageSize("exr", width, height); err != nil {
+		return nil, err
+	}
+	if len(h.channels) > exrMaxChannels {
+		return nil, fmt.Errorf("exr: %d channels exceeds the decoder limit", len(h.channels))
+	}
+	lineBytes := 0
+	targets := make([]int, len(h.channels)) // RGBA slot per channel, or -1
+	hasColor, hasAlpha, luminance := false, false, false
+	for i, ch := range h.channels {
+		lineBytes += ch.size() * width
+		targets[i] = -1
+		switch ch.name {
+		case "R", "G", "B":
+			targets[i] = strings.Index("RGB", ch.name)
+			hasColor = true
+		case "A":
+			targets[i] = 3
+			hasAlpha = true
+		case "Y":
+			luminance = true
+		}
+	}
+	if !hasColor && !luminance {
+		return nil, errors.New("exr: no RGB or Y channels")
+	}
+
+	chunks := (height + linesPerChunk - 1) / linesPerChunk
+	if chunks*8 > r.Len() {
+		return nil, errors.New("exr: offset table overflows file")
+	}
+	offsets := make([]uint64, chunks)
+	if err := binary.Read(r, binary.LittleEndian, offsets); err != nil {
+		return nil, fmt.Errorf("exr: offset table: %w", err)
+	}
+
+	img := newHDRImage(width, height)
+	if !hasAlpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 1
+		}
+	}
+	for i, offset := range offsets {
+		if offset+8 > uint64(len(data)) {
+			return nil, fmt.Errorf("exr: chunk %d out of range", i)
+		}
+		chunk := data[offset:]
+		y := int(int32(binary.LittleEndian.Uint32(chunk[0:]))) - h.dataWindow.Min.Y
+		size := binary.LittleEndian.Uint32(chunk[4:])
+		if uint64(size) > uint64(len(chunk)-8) || y < 0 || y >= height {
+			return nil, fmt.Errorf("exr: invalid chunk %d", i)
+		}
+		lines := min(linesPerChunk, height-y)
+		block, err := decompressEXRChunk(chunk[8:8+size], lines*lineBytes, h.compression)
+		if err != nil {
+			return nil, fmt.Errorf("exr: chunk %d: %w", i, err)
+		}
+
+		for line := 0; line < lines; line++ {
+			src := block[line*lineBytes:]
+			out := img.Pix[(y+line)*width*4:]
+			for c, ch := range h.channels {
+				size := ch.size()
+				for x := 0; x < width; x++ {
+					v := exrSample(src[x*size:], ch.pixelType)
+					switch {
+					case targets[c] >= 0:
+						out[x*4+targets[c]] = v
+					case ch.name == "Y" && !hasColor:
+						out[x*4], out[x*4+1], out[x*4+2] = v, v, v
+					}
+				}
+				src = src[width*size:]
+			}
+		}
+	}
+	return img, nil
+}
+
+func exrSample(b []byte, pixelType int32) float32 {
+	switch pixelType {
+	case exrPixelHalf:
+		return halfToFloat32(binary.LittleEndian.Uint16(b))
+	case exrPixelFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	default:
+		return float32(binary.LittleEndian.Uint32(b))
+	}
+}
+
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		// Zero or subnormal: mant * 2^-24
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
+
+// decompressEXRChunk returns expected bytes of scanline data. Writers store
+// a chunk raw when compression would not make it smaller.
+func decompressEXRChunk(src []byte, expected int, compression byte) ([]byte, error) {
+	if compression == exrCompressNone || len(src) == expected {
+		if len(src) < expected {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return src[:expected], nil
+	}
+
+	var packed []byte
+	switch compression {
+	case exrCompressRLE:
+		var err error
+		packed, err = decodeEXRRLE(src, expected)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		zr, err := zlib.NewReader(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		packed = make([]byte, expected)
+		if _, err := io.ReadFull(zr, packed); err != nil {
+			return nil, err
+		}
+	}
+
+	// Undo the byte delta predictor, then re-interleave the two halves
+	for i := 1; i < len(packed); i++ {
+		packed[i] = packed[i-1] + packed[i] - 128
+	}
+	out := make([]byte, expected)
+	half := (expected + 1) / 2
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = packed[i/2]
+		} else {
+			out[i] = packed[half+i/2]
+		}
+	}
+	return out, nil
+}
+
+func decodeEXRRLE(src []byte, expected int) ([]byte, error) {
+	out := make([]byte, 0, expected)
+	for i := 0; i < len(src); {
+		count := int(int8(src[i]))
+		i++
+		if count < 0 {
+			if i-count > len(src) || len(out)-count > expected {
+				return nil, errors.New("RLE literal overflows")
+			}
+			out = append(out, src[i:i-count]...)
+			i -= count
+		} else {
+			if i >= len(src) || len(out)+count+1 > expected {
+				return nil, errors.New("RLE run overflows")
+			}
+			for n := 0; n <= count; n++ {
+				out = append(out, src[i])
+			}
+			i++
+		}
+	}
+	if len(out) != expected {
+		return nil, errors.New("RLE data too short")
+	}
+	return out, nil
+}
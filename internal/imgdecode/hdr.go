@@ -0,0 +1,172 @@
+package imgdecode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Tone mapping operators for high dynamic range images.
+const (
+	ToneMapReinhard = "reinhard"
+	ToneMapACES     = "aces"
+	ToneMapClamp    = "clamp"
+)
+
+// ToneMapOperators lists the supported operators in display order.
+var ToneMapOperators = []string{ToneMapReinhard, ToneMapACES, ToneMapClamp}
+
+// HDRImage holds linear floating-point RGBA pixels, four values per pixel.
+type HDRImage struct {
+	Rect image.Rectangle
+	Pix  []float32
+}
+
+func newHDRImage(width, height int) *HDRImage {
+	return &HDRImage{
+		Rect: image.Rect(0, 0, width, height),
+		Pix:  make([]float32, width*height*4),
+	}
+}
+
+// ToneMapping maps HDR radiance to displayable 8-bit sRGB. Exposure is in
+// stops (EV): each +1 doubles the brightness before the operator runs.
+type ToneMapping struct {
+	Operator string
+	Exposure float64
+}
+
+// DefaultToneMapping is used when decoding HDR through image.Decode.
+var DefaultToneMapping = ToneMapping{Operator: ToneMapReinhard}
+
+// IsToneMapOperator reports whether name is a supported operator.
+func IsToneMapOperator(name string) bool {
+	for _, op := range ToneMapOperators {
+		if op == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply tone maps img into a new 8-bit image.
+func (t ToneMapping) Apply(img *HDRImage) *image.NRGBA {
+	out := image.NewNRGBA(image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()))
+	scale := float32(math.Exp2(t.Exposure))
+	curve := toneCurve(t.Operator)
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			out.Pix[i+c] = encodeSRGB(curve(img.Pix[i+c] * scale))
+		}
+		out.Pix[i+3] = uint8(clamp01(img.Pix[i+3])*255 + 0.5)
+	}
+	return out
+}
+
+func toneCurve(operator string) func(float32) float32 {
+	switch operator {
+	case ToneMapACES:
+		// Narkowicz's fit of the ACES filmic curve
+		return func(x float32) float32 {
+			x = max(x, 0)
+			return (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+		}
+	case ToneMapClamp:
+		return func(x float32) float32 { return x }
+	default:
+		return func(x float32) float32 {
+			x = max(x, 0)
+			return x / (1 + x)
+		}
+	}
+}
+
+func clamp01(v float32) float32 {
+	// NaN compares false everywhere and ends up as 0
+	if !(v > 0) {
+		return 0
+	}
+	return min(v, 1)
+}
+
+func encodeSRGB(v float32) uint8 {
+	v = clamp01(v)
+	var s float64
+	if v <= 0.0031308 {
+		s = float64(v) * 12.92
+	} else {
+		s = 1.055*math.Pow(float64(v), 1/2.4) - 0.055
+	}
+	return uint8(s*255 + 0.5)
+}
+
+// IsHDRPath reports whether path has a high dynamic range image extension.
+func IsHDRPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdr", ".exr":
+		return true
+	}
+	return false
+}
+
+// IsHDRData reports whether data starts with a Radiance or OpenEXR signature.
+func IsHDRData(data []byte) bool {
+	return isRadianceData(data) || isEXRData(data)
+}
+
+// DecodeHDR decodes a Radiance or OpenEXR image into linear floats.
+func DecodeHDR(data []byte) (*HDRImage, error) {
+	switch {
+	case isRadianceData(data):
+		return decodeRadiance(data)
+	case isEXRData(data):
+		return decodeEXR(data)
+	default:
+		return nil, errors.New("not an HDR image")
+	}
+}
+
+func init() {
+	image.RegisterFormat("hdr", "#?RADIANCE", decodeHDRImage, decodeHDRConfig)
+	image.RegisterFormat("hdr", "#?RGBE", decodeHDRImage, decodeHDRConfig)
+	image.RegisterFormat("exr", exrMagic, decodeHDRImage, decodeHDRConfig)
+}
+
+func decodeHDRImage(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, err := DecodeHDR(data)
+	if err != nil {
+		return nil, err
+	}
+	return DefaultToneMapping.Apply(img), nil
+}
+
+func decodeHDRConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	var width, height int
+	switch {
+	case isRadianceData(data):
+		_, width, height, _, err = readRadianceHeader(data)
+	case isEXRData(data):
+		var h exrHeader
+		h, err = readEXRHeader(bytes.NewReader(data))
+		width, height = h.dataWindow.Dx(), h.dataWindow.Dy()
+	default:
+		err = errors.New("not an HDR image")
+	}
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: width, Height: height}, nil
+}
@@ -0,0 +1,230 @@
+package imgdecode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDecodeRadianceRLE(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 8\n")
+	// New-style scanline: R run of 128, G literal ramp, B run of 0, E run of 129
+	buf.Write([]byte{2, 2, 0, 8})
+	buf.Write([]byte{128 + 8, 128})
+	buf.Write([]byte{8, 0, 16, 32, 48, 64, 80, 96, 112})
+	buf.Write([]byte{128 + 8, 0})
+	buf.Write([]byte{128 + 8, 129})
+
+	img, err := DecodeHDR(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodeHDR: %v", err)
+	}
+	if img.Rect.Dx() != 8 || img.Rect.Dy() != 1 {
+		t.Fatalf("size = %v", img.Rect)
+	}
+	// Exponent 129 scales the mantissa by 2^-7: (128+0.5)/128
+	if r := img.Pix[0]; math.Abs(float64(r)-128.5/128) > 1e-6 {
+		t.Fatalf("red = %v", r)
+	}
+	if g := img.Pix[4*2+1]; math.Abs(float64(g)-32.5/128) > 1e-6 {
+		t.Fatalf("green at x=2 = %v", g)
+	}
+	if a := img.Pix[3]; a != 1 {
+		t.Fatalf("alpha = %v, want 1", a)
+	}
+}
+
+func TestDecodeRadianceFlatBottomUp(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("#?RGBE\n\n+Y 2 +X 1\n")
+	buf.Write([]byte{0, 0, 0, 0})         // stored first, displayed at the bottom
+	buf.Write([]byte{128, 128, 128, 129}) // displayed at the top
+
+	img, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("image.Decode: %v", err)
+	}
+	top := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA)
+	bottom := color.NRGBAModel.Convert(img.At(0, 1)).(color.NRGBA)
+	if top.R == 0 || bottom.R != 0 {
+		t.Fatalf("rows not flipped: top=%v bottom=%v", top, bottom)
+	}
+}
+
+func TestDecodeRadianceRejectsOversizedHeaders(t *testing.T) {
+	for _, header := range []string{
+		"#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 100000 +X 100000\n",
+		"#?RADIANCE\n\n-Y 50000 +X 50000\n",
+		"#?RADIANCE\n\n-Y 4096 +X 4096\n\x01\x01\x01\x10",
+	} {
+		if _, err := DecodeHDR([]byte(header)); err == nil {
+			t.Errorf("DecodeHDR(%q) accepted the header", header)
+		}
+	}
+}
+
+type exrTestChannel struct {
+	name      string
+	pixelType int32
+}
+
+// buildEXR writes a single-part scanline EXR whose chunks are produced by
+// encodeChunk from the raw scanline bytes of each chunk.
+func buildEXR(width, height int, compression byte, channels []exrTestChannel, lines [][]byte, encodeChunk func([]byte) []byte) []byte {
+	var header bytes.Buffer
+	header.WriteString(exrMagic)
+	binary.Write(&header, binary.LittleEndian, uint32(2))
+
+	attr := func(name, typ string, value []byte) {
+		header.WriteString(name + "\x00" + typ + "\x00")
+		binary.Write(&header, binary.LittleEndian, int32(len(value)))
+		header.Write(value)
+	}
+	var chlist bytes.Buffer
+	for _, ch := range channels {
+		chlist.WriteString(ch.name + "\x00")
+		binary.Write(&chlist, binary.LittleEndian, ch.pixelType)
+		chlist.Write([]byte{0, 0, 0, 0})
+		binary.Write(&chlist, binary.LittleEndian, int32(1))
+		binary.Write(&chlist, binary.LittleEndian, int32(1))
+	}
+	chlist.WriteByte(0)
+	attr("channels", "chlist", chlist.Bytes())
+	attr("compression", "compression", []byte{compression})
+	var window bytes.Buffer
+	binary.Write(&window, binary.LittleEndian, []int32{0, 0, int32(width - 1), int32(height - 1)})
+	attr("dataWindow", "box2i", window.Bytes())
+	header.WriteByte(0)
+
+	linesPerChunk, _ := exrLinesPerChunk(compression)
+	var chunks [][]byte
+	for y := 0; y < height; y += linesPerChunk {
+		var raw []byte
+		for _, line := range lines[y:min(height, y+linesPerChunk)] {
+			raw = append(raw, line...)
+		}
+		var chunk bytes.Buffer
+		binary.Write(&chunk, binary.LittleEndian, int32(y))
+		data := encodeChunk(raw)
+		binary.Write(&chunk, binary.LittleEndian, int32(len(data)))
+		chunk.Write(data)
+		chunks = append(chunks, chunk.Bytes())
+	}
+
+	offset := uint64(header.Len() + 8*len(chunks))
+	for _, chunk := range chunks {
+		binary.Write(&header, binary.LittleEndian, offset)
+		offset += uint64(len(chunk))
+	}
+	for _, chunk := range chunks {
+		header.Write(chunk)
+	}
+	return header.Bytes()
+}
+
+func TestDecodeEXRUncompressedHalf(t *testing.T) {
+	// Channels are stored alphabetically: B, G, R. Half 0x3c00 = 1.0, 0x4000 = 2.0
+	var line bytes.Buffer
+	binary.Write(&line, binary.LittleEndian, []uint16{0x0000, 0x3c00}) // B
+	binary.Write(&line, binary.LittleEndian, []uint16{0x3c00, 0x0000}) // G
+	binary.Write(&line, binary.LittleEndian, []uint16{0x4000, 0x3800}) // R
+	data := buildEXR(2, 1, exrCompressNone,
+		[]exrTestChannel{{"B", exrPixelHalf}, {"G", exrPixelHalf}, {"R", exrPixelHalf}},
+		[][]byte{line.Bytes()},
+		func(raw []byte) []byte { return raw },
+	)
+
+	img, err := DecodeHDR(data)
+	if err != nil {
+		t.Fatalf("DecodeHDR: %v", err)
+	}
+	want := []float32{2, 1, 0, 1, 0.5, 0, 1, 1}
+	for i, w := range want {
+		if img.Pix[i] != w {
+			t.Fatalf("Pix = %v, want %v", img.Pix, want)
+		}
+	}
+}
+
+// zipsEncode applies OpenEXR's byte reordering and delta predictor before
+// zlib compression.
+func zipsEncode(raw []byte) []byte {
+	reordered := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i, b := range raw {
+		if i%2 == 0 {
+			reordered[i/2] = b
+		} else {
+			reordered[half+i/2] = b
+		}
+	}
+	predicted := make([]byte, len(reordered))
+	for i := range reordered {
+		if i == 0 {
+			predicted[i] = reordered[i]
+		} else {
+			predicted[i] = reordered[i] - reordered[i-1] + 128
+		}
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(predicted)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeEXRRejectsOversizedDataWindow(t *testing.T) {
+	data := buildEXR(1<<20, 1, exrCompressNone,
+		[]exrTestChannel{{"Y", exrPixelHalf}},
+		[][]byte{nil},
+		func(raw []byte) []byte { return raw },
+	)
+	if _, err := DecodeHDR(data); err == nil {
+		t.Fatal("DecodeHDR accepted a 1048576-pixel-wide data window")
+	}
+}
+
+func TestDecodeEXRZIPSFloatLuminance(t *testing.T) {
+	lines := make([][]byte, 2)
+	for y := range lines {
+		var line bytes.Buffer
+		binary.Write(&line, binary.LittleEndian, []float32{float32(y) + 0.25, 4, 8})
+		lines[y] = line.Bytes()
+	}
+	data := buildEXR(3, 2, exrCompressZIPS, []exrTestChannel{{"Y", exrPixelFloat}}, lines, zipsEncode)
+
+	img, err := DecodeHDR(data)
+	if err != nil {
+		t.Fatalf("DecodeHDR: %v", err)
+	}
+	px := img.Pix[(1*3+0)*4:]
+	if px[0] != 1.25 || px[1] != 1.25 || px[2] != 1.25 || px[3] != 1 {
+		t.Fatalf("pixel (0,1) = %v, want gray 1.25", px[:4])
+	}
+}
+
+func TestToneMappingExposureAndOperators(t *testing.T) {
+	img := newHDRImage(1, 1)
+	copy(img.Pix, []float32{1, 0.25, 0, 0.5})
+
+	clamp := ToneMapping{Operator: ToneMapClamp}.Apply(img)
+	if got := clamp.Pix[:4]; got[0] != 255 || got[2] != 0 || got[3] != 128 {
+		t.Fatalf("clamp = %v", got)
+	}
+	darker := ToneMapping{Operator: ToneMapClamp, Exposure: -1}.Apply(img)
+	if darker.Pix[0] >= clamp.Pix[0] || darker.Pix[1] >= clamp.Pix[1] {
+		t.Fatalf("exposure -1 did not darken: %v vs %v", darker.Pix[:3], clamp.Pix[:3])
+	}
+	// Reinhard maps 1.0 to 0.5 linear, about 188 in sRGB
+	if got := DefaultToneMapping.Apply(img).Pix[0]; got != 188 {
+		t.Fatalf("reinhard red = %d, want 188", got)
+	}
+	if !IsToneMapOperator(ToneMapACES) || IsToneMapOperator("filmic") {
+		t.Fatal("IsToneMapOperator mismatch")
+	}
+}
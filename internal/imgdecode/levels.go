@@ -0,0 +1,143 @@
+package imgdecode
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+)
+
+// Levels adjusts images with more than 8 bits per channel while they are
+// reduced to 8 bits for display, so a narrow tonal range can be pulled out
+// of the full-precision samples instead of the already quantized result.
+type Levels struct {
+	// Exposure scales sample values by 2^Exposure.
+	Exposure float64
+	// Stretch maps the 0.1th..99.9th percentile of color samples to the
+	// full output range.
+	Stretch bool
+}
+
+// levelsStretchClip is the fraction of samples clipped at each end when
+// stretching.
+const levelsStretchClip = 0.001
+
+// IsHighBitDepth reports whether img stores more than 8 bits per channel.
+func IsHighBitDepth(img image.Image) bool {
+	switch img.(type) {
+	case *image.Gray16, *image.RGBA64, *image.NRGBA64:
+		return true
+	}
+	return false
+}
+
+// Apply converts a 16-bit image to 8 bits with the levels adjustment.
+// Images of other types are converted without adjustment.
+func (l Levels) Apply(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+
+	black, white := 0.0, 65535.0
+	if l.Stretch {
+		black, white = stretchRange(img)
+	}
+	scale := math.Exp2(l.Exposure) / (white - black)
+	lut := make([]uint8, 1<<16)
+	for v := range lut {
+		f := max(0, min(1, (float64(v)-black)*scale))
+		lut[v] = uint8(f*255 + 0.5)
+	}
+
+	forEachSample16(img, func(i int, r, g, bl, a uint16) {
+		p := out.Pix[i*4 : i*4+4 : i*4+4]
+		p[0], p[1], p[2], p[3] = lut[r], lut[g], lut[bl], uint8(a>>8)
+	})
+	return out
+}
+
+// stretchRange returns the sample values at the clip percentiles, or the
+// full range when the image is flat.
+func stretchRange(img image.Image) (float64, float64) {
+	var histogram [1 << 16]int
+	total := 0
+	forEachSample16(img, func(_ int, r, g, b, a uint16) {
+		if a == 0 {
+			return
+		}
+		histogram[r]++
+		histogram[g]++
+		histogram[b]++
+		total += 3
+	})
+	if total == 0 {
+		return 0, 65535
+	}
+
+	clip := int(float64(total) * levelsStretchClip)
+	low, high := 0, len(histogram)-1
+	for sum := 0; low < high; low++ {
+		sum += histogram[low]
+		if sum > clip {
+			break
+		}
+	}
+	for sum := 0; high > low; high-- {
+		sum += histogram[high]
+		if sum > clip {
+			break
+		}
+	}
+	if high <= low {
+		return 0, 65535
+	}
+	return float64(low), float64(high)
+}
+
+// forEachSample16 calls fn with the non-premultiplied 16-bit samples of each
+// pixel in row-major order, i being the pixel index from the top-left.
+func forEachSample16(img image.Image, fn func(i int, r, g, b, a uint16)) {
+	b := img.Bounds()
+	w := b.Dx()
+	switch src := img.(type) {
+	case *image.Gray16:
+		for y := 0; y < b.Dy(); y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < w; x++ {
+				v := binary.BigEndian.Uint16(row[x*2:])
+				fn(y*w+x, v, v, v, 0xffff)
+			}
+		}
+	case *image.NRGBA64:
+		for y := 0; y < b.Dy(); y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < w; x++ {
+				p := row[x*8:]
+				fn(y*w+x, binary.BigEndian.Uint16(p[0:]), binary.BigEndian.Uint16(p[2:]), binary.BigEndian.Uint16(p[4:]), binary.BigEndian.Uint16(p[6:]))
+			}
+		}
+	case *image.RGBA64:
+		for y := 0; y < b.Dy(); y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < w; x++ {
+				p := row[x*8:]
+				a := uint32(binary.BigEndian.Uint16(p[6:]))
+				if a == 0 {
+					fn(y*w+x, 0, 0, 0, 0)
+					continue
+				}
+				unpremul := func(v uint16) uint16 { return uint16(uint32(v) * 0xffff / a) }
+				fn(y*w+x, unpremul(binary.BigEndian.Uint16(p[0:])), unpremul(binary.BigEndian.Uint16(p[2:])), unpremul(binary.BigEndian.Uint16(p[4:])), uint16(a))
+			}
+		}
+	default:
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < w; x++ {
+				r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				if a == 0 {
+					fn(y*w+x, 0, 0, 0, 0)
+					continue
+				}
+				fn(y*w+x, uint16(r*0xffff/a), uint16(g*0xffff/a), uint16(bl*0xffff/a), uint16(a))
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package imgdecode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func narrowGray16() *image.Gray16 {
+	// A scan whose detail lives in a narrow band around 1/4 of full scale
+	img := image.NewGray16(image.Rect(0, 0, 4, 1))
+	for x, v := range []uint16{16000, 16100, 16200, 16300} {
+		img.SetGray16(x, 0, color.Gray16{Y: v})
+	}
+	return img
+}
+
+func TestDecodeBytesKeeps16BitPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, narrowGray16()); err != nil {
+		t.Fatalf("png encode: %v", err)
+	}
+	img, err := DecodeBytes(buf.Bytes(), "scan.png")
+	if err != nil {
+		t.Fatalf("DecodeBytes: %v", err)
+	}
+	if !IsHighBitDepth(img) {
+		t.Fatalf("decoded %T, want a 16-bit image", img)
+	}
+}
+
+func TestDecodeTIFF16(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tiff.Encode(&buf, narrowGray16(), nil); err != nil {
+		t.Fatalf("tiff encode: %v", err)
+	}
+	img, err := decodeStdlib(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !IsHighBitDepth(img) || img.Bounds().Dx() != 4 {
+		t.Fatalf("decoded %T %v", img, img.Bounds())
+	}
+}
+
+func TestLevelsStretchAndExposure(t *testing.T) {
+	src := narrowGray16()
+
+	// Without adjustment the band collapses to almost a single 8-bit value
+	plain := Levels{}.Apply(src)
+	if spread := int(plain.Pix[12]) - int(plain.Pix[0]); spread > 2 {
+		t.Fatalf("plain spread = %d, expected the band to be crushed", spread)
+	}
+
+	stretched := Levels{Stretch: true}.Apply(src)
+	if stretched.Pix[0] != 0 || stretched.Pix[12] != 255 {
+		t.Fatalf("stretched ends = %d..%d, want 0..255", stretched.Pix[0], stretched.Pix[12])
+	}
+	if mid := stretched.Pix[4]; mid < 64 || mid > 106 {
+		t.Fatalf("stretched second sample = %d, want about 85", mid)
+	}
+
+	brighter := Levels{Exposure: 1}.Apply(src)
+	if brighter.Pix[0] <= plain.Pix[0] || brighter.Pix[3] != 255 {
+		t.Fatalf("exposure +1 = %v, plain = %v", brighter.Pix[:4], plain.Pix[:4])
+	}
+}
@@ -0,0 +1,408 @@
+package imgdecode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// Photoshop documents (PSD and the large-document PSB variant) store a
+// flattened copy of the whole image after the layer data. Only that merged
+// composite is decoded; layers are skipped.
+
+const (
+	psdModeBitmap    = 0
+	psdModeGrayscale = 1
+	psdModeIndexed   = 2
+	psdModeRGB       = 3
+	psdModeCMYK      = 4
+
+	// The format allows at most 56 channels; color mode data is a palette
+	// or duotone specification and never comes close to the section limit.
+	psdMaxChannels      = 56
+	psdMaxSectionLength = 1 << 20
+)
+
+func init() {
+	image.RegisterFormat("psd", "8BPS", decodePSD, decodePSDConfig)
+}
+
+type psdHeader struct {
+	version  int // 1 = PSD, 2 = PSB
+	channels int
+	width    int
+	height   int
+	depth    int
+	mode     int
+}
+
+func readPSDHeader(r io.Reader) (psdHeader, error) {
+	var raw [26]byte
+	if _, err := io.ReadFull(r, raw[:]); err != nil {
+		return psdHeader{}, err
+	}
+	if string(raw[:4]) != "8BPS" {
+		return psdHeader{}, errors.New("psd: invalid signature")
+	}
+	h := psdHeader{
+		version:  int(binary.BigEndian.Uint16(raw[4:6])),
+		channels: int(binary.BigEndian.Uint16(raw[12:14])),
+		height:   int(binary.BigEndian.Uint32(raw[14:18])),
+		width:    int(binary.BigEndian.Uint32(raw[18:22])),
+		depth:    int(binary.BigEndian.Uint16(raw[22:24])),
+		mode:     int(binary.BigEndian.Uint16(raw[24:26])),
+	}
+	if h.version != 1 && h.version != 2 {
+		return psdHeader{}, fmt.Errorf("psd: unsupported version %d", h.version)
+	}
+	if h.channels <= 0 || h.channels > psdMaxChannels {
+		return psdHeader{}, fmt.Errorf("psd: invalid channel count %d", h.channels)
+	}
+	if err := checkImageSize("psd", h.width, h.height); err != nil {
+		return psdHeader{}, err
+	}
+	switch h.depth {
+	case 1:
+		if h.mode != psdModeBitmap {
+			return psdHeader{}, errors.New("psd: 1-bit depth outside bitmap mode")
+		}
+	case 8, 16:
+	default:
+		return psdHeader{}, fmt.Errorf("psd: unsupported depth %d", h.depth)
+	}
+	return h, nil
+}
+
+func (h psdHeader) colorModel() color.Model {
+	if h.mode == psdModeGrayscale || h.mode == psdModeBitmap {
+		return color.GrayModel
+	}
+	return color.NRGBAModel
+}
+
+func decodePSDConfig(r io.Reader) (image.Config, error) {
+	h, err := readPSDHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: h.colorModel(), Width: h.width, Height: h.height}, nil
+}
+
+func decodePSD(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readPSDHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// Color mode data holds the palette for indexed images.
+	colorData, err := readPSDSection(br, 4)
+	if err != nil {
+		return nil, fmt.Errorf("psd: color mode data: %w", err)
+	}
+	if err := skipPSDSection(br, 4); err != nil {
+		return nil, fmt.Errorf("psd: image resources: %w", err)
+	}
+	hasMergedAlpha, err := skipPSDLayerInfo(br, h.version)
+	if err != nil {
+		return nil, fmt.Errorf("psd: layer info: %w", err)
+	}
+
+	planes, err := readPSDImageData(br, h)
+	if err != nil {
+		return nil, fmt.Errorf("psd: image data: %w", err)
+	}
+	return composePSDPlanes(h, planes, colorData, hasMergedAlpha)
+}
+
+func readPSDSection(r io.Reader, lengthSize int) ([]byte, error) {
+	length, err := readPSDLength(r, lengthSize)
+	if err != nil {
+		return nil, err
+	}
+	if length > psdMaxSectionLength {
+		return nil, fmt.Errorf("section length %d exceeds the decoder limit", length)
+	}
+	return readPSDBytes(r, length)
+}
+
+func skipPSDSection(r io.Reader, lengthSize int) error {
+	length, err := readPSDLength(r, lengthSize)
+	if err != nil {
+		return err
+	}
+	return skipPSDBytes(r, length)
+}
+
+// readPSDBytes reads exactly n bytes. The buffer grows with the data that
+// is actually present, so a bogus length fails at the end of the input
+// instead of allocating up front.
+func readPSDBytes(r io.Reader, n uint64) ([]byte, error) {
+	if n > math.MaxInt64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+func skipPSDBytes(r io.Reader, n uint64) error {
+	if n > math.MaxInt64 {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := io.CopyN(io.Discard, r, int64(n))
+	return err
+}
+
+func readPSDLength(r io.Reader, size int) (uint64, error) {
+	var raw [8]byte
+	if _, err := io.ReadFull(r, raw[:size]); err != nil {
+		return 0, err
+	}
+	if size == 8 {
+		return binary.BigEndian.Uint64(raw[:8]), nil
+	}
+	return uint64(binary.BigEndian.Uint32(raw[:4])), nil
+}
+
+// skipPSDLayerInfo skips the layer and mask section. A negative layer count
+// means the first extra channel of the merged image is its transparency.
+func skipPSDLayerInfo(r *bufio.Reader, version int) (bool, error) {
+	lengthSize := 4
+	if version == 2 {
+		lengthSize = 8
+	}
+	total, err := readPSDLength(r, lengthSize)
+	if err != nil || total == 0 {
+		return false, err
+	}
+
+	hasMergedAlpha := false
+	consumed := uint64(0)
+	if total >= uint64(lengthSize)+2 {
+		layerInfoLength, err := readPSDLength(r, lengthSize)
+		if err != nil {
+			return false, err
+		}
+		consumed += uint64(lengthSize)
+		if layerInfoLength >= 2 {
+			var count [2]byte
+			if _, err := io.ReadFull(r, count[:]); err != nil {
+				return false, err
+			}
+			consumed += 2
+			hasMergedAlpha = int16(binary.BigEndian.Uint16(count[:])) < 0
+		}
+	}
+	if consumed > total {
+		return false, errors.New("layer section overflows")
+	}
+	return hasMergedAlpha, skipPSDBytes(r, total-consumed)
+}
+
+// readPSDImageData reads the merged image as one plane per channel.
+func readPSDImageData(r io.Reader, h psdHeader) ([][]byte, error) {
+	var raw [2]byte
+	if _, err := io.ReadFull(r, raw[:]); err != nil {
+		return nil, err
+	}
+	compression := binary.BigEndian.Uint16(raw[:])
+
+	rowBytes := (h.width*h.depth + 7) / 8
+	planes := make([][]byte, h.channels)
+	switch compression {
+	case 0:
+		for c := range planes {
+			plane, err := readPSDBytes(r, uint64(rowBytes*h.height))
+			if err != nil {
+				return nil, err
+			}
+			planes[c] = plane
+		}
+	case 1:
+		countSize := 2
+		if h.version == 2 {
+			countSize = 4
+		}
+		// PackBits never needs more than one header byte per 128 bytes
+		maxPacked := rowBytes + (rowBytes+127)/128
+		counts := make([]int, h.channels*h.height)
+		buf := make([]byte, countSize)
+		for i := range counts {
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, err
+			}
+			if countSize == 4 {
+				counts[i] = int(binary.BigEndian.Uint32(buf))
+			} else {
+				counts[i] = int(binary.BigEndian.Uint16(buf))
+			}
+			if counts[i] > maxPacked {
+				return nil, fmt.Errorf("row length %d exceeds %d", counts[i], maxPacked)
+			}
+		}
+		for c := range planes {
+			// Read the whole channel before expanding it, so the plane is
+			// only allocated once its compressed data is known to exist
+			rows := counts[c*h.height : (c+1)*h.height]
+			total := 0
+			for _, n := range rows {
+				total += n
+			}
+			packed, err := readPSDBytes(r, uint64(total))
+			if err != nil {
+				return nil, err
+			}
+			planes[c] = make([]byte, rowBytes*h.height)
+			for y, n := range rows {
+				if err := unpackBits(planes[c][y*rowBytes:(y+1)*rowBytes], packed[:n]); err != nil {
+					return nil, err
+				}
+				packed = packed[n:]
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", compression)
+	}
+	return planes, nil
+}
+
+// unpackBits expands PackBits run-length data into dst.
+func unpackBits(dst, src []byte) error {
+	di := 0
+	for si := 0; si < len(src) && di < len(dst); {
+		n := int(int8(src[si]))
+		si++
+		switch {
+		case n >= 0:
+			count := n + 1
+			if si+count > len(src) || di+count > len(dst) {
+				return errors.New("packbits literal overflows")
+			}
+			copy(dst[di:], src[si:si+count])
+			si += count
+			di += count
+		case n != -128:
+			count := 1 - n
+			if si >= len(src) || di+count > len(dst) {
+				return errors.New("packbits run overflows")
+			}
+			for i := 0; i < count; i++ {
+				dst[di+i] = src[si]
+			}
+			si++
+			di += count
+		}
+	}
+	if di != len(dst) {
+		return errors.New("packbits data too short")
+	}
+	return nil
+}
+
+func composePSDPlanes(h psdHeader, planes [][]byte, colorData []byte, hasMergedAlpha bool) (image.Image, error) {
+	bytesPerSample := h.depth / 8
+	sample := func(c, x, y int) uint8 {
+		// 16-bit samples are big endian; the high byte is enough for display
+		return planes[c][(y*h.width+x)*bytesPerSample]
+	}
+
+	switch h.mode {
+	case psdModeBitmap:
+		img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+		rowBytes := (h.width + 7) / 8
+		for y := 0; y < h.height; y++ {
+			for x := 0; x < h.width; x++ {
+				// Bitmap mode stores 1 for black
+				if planes[0][y*rowBytes+x/8]&(0x80>>(x%8)) == 0 {
+					img.Pix[y*img.Stride+x] = 0xff
+				}
+			}
+		}
+		return img, nil
+
+	case psdModeGrayscale:
+		alpha := -1
+		if hasMergedAlpha && h.channels >= 2 {
+			alpha = 1
+		}
+		if alpha < 0 {
+			img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+			for y := 0; y < h.height; y++ {
+				for x := 0; x < h.width; x++ {
+					img.Pix[y*img.Stride+x] = sample(0, x, y)
+				}
+			}
+			return img, nil
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+		for y := 0; y < h.height; y++ {
+			for x := 0; x < h.width; x++ {
+				v := sample(0, x, y)
+				img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: sample(alpha, x, y)})
+			}
+		}
+		return img, nil
+
+	case psdModeIndexed:
+		if h.depth != 8 || len(colorData) < 768 {
+			return nil, errors.New("psd: invalid indexed color table")
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+		for y := 0; y < h.height; y++ {
+			for x := 0; x < h.width; x++ {
+				i := int(sample(0, x, y))
+				img.SetNRGBA(x, y, color.NRGBA{R: colorData[i], G: colorData[256+i], B: colorData[512+i], A: 0xff})
+			}
+		}
+		return img, nil
+
+	case psdModeRGB:
+		if h.channels < 3 {
+			return nil, errors.New("psd: RGB image with fewer than 3 channels")
+		}
+		alpha := -1
+		if hasMergedAlpha && h.channels >= 4 {
+			alpha = 3
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+		for y := 0; y < h.height; y++ {
+			for x := 0; x < h.width; x++ {
+				a := uint8(0xff)
+				if alpha >= 0 {
+					a = sample(alpha, x, y)
+				}
+				img.SetNRGBA(x, y, color.NRGBA{R: sample(0, x, y), G: sample(1, x, y), B: sample(2, x, y), A: a})
+			}
+		}
+		return img, nil
+
+	case psdModeCMYK:
+		if h.channels < 4 {
+			return nil, errors.New("psd: CMYK image with fewer than 4 channels")
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+		for y := 0; y < h.height; y++ {
+			for x := 0; x < h.width; x++ {
+				// Photoshop stores CMYK inverted (0 = full ink)
+				c := color.CMYK{C: 0xff - sample(0, x, y), M: 0xff - sample(1, x, y), Y: 0xff - sample(2, x, y), K: 0xff - sample(3, x, y)}
+				r, g, b := color.CMYKToRGB(c.C, c.M, c.Y, c.K)
+				img.SetNRGBA(x, y, color.NRGBA{R: r, G: g, B: b, A: 0xff})
+			}
+		}
+		return img, nil
+
+	default:
+		return nil, fmt.Errorf("psd: unsupported color mode %d", h.mode)
+	}
+}
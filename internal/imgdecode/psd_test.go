@@ -0,0 +1,115 @@
+package imgdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// buildPSD writes a minimal PSD with the given layer section and image data.
+func buildPSD(width, height, channels, mode int, layerSection, imageData []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("8BPS")
+	binary.Write(&buf, binary.BigEndian, uint16(1))
+	buf.Write(make([]byte, 6))
+	binary.Write(&buf, binary.BigEndian, uint16(channels))
+	binary.Write(&buf, binary.BigEndian, uint32(height))
+	binary.Write(&buf, binary.BigEndian, uint32(width))
+	binary.Write(&buf, binary.BigEndian, uint16(8))
+	binary.Write(&buf, binary.BigEndian, uint16(mode))
+	binary.Write(&buf, binary.BigEndian, uint32(0)) // color mode data
+	binary.Write(&buf, binary.BigEndian, uint32(0)) // image resources
+	binary.Write(&buf, binary.BigEndian, uint32(len(layerSection)))
+	buf.Write(layerSection)
+	buf.Write(imageData)
+	return buf.Bytes()
+}
+
+func TestDecodePSDRawRGB(t *testing.T) {
+	// Planar R, G, B for two pixels: red then blue
+	imageData := []byte{0, 0, 255, 0, 0, 0, 0, 255}
+	data := buildPSD(2, 1, 3, psdModeRGB, nil, imageData)
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if format != "psd" || cfg.Width != 2 || cfg.Height != 1 {
+		t.Fatalf("config = %q %dx%d", format, cfg.Width, cfg.Height)
+	}
+
+	img, err := decodeStdlib(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(img.At(0, 0)); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Fatalf("pixel 0 = %v", got)
+	}
+	if got := color.NRGBAModel.Convert(img.At(1, 0)); got != (color.NRGBA{B: 255, A: 255}) {
+		t.Fatalf("pixel 1 = %v", got)
+	}
+}
+
+func TestDecodePSDPackBitsGrayWithMergedAlpha(t *testing.T) {
+	// Layer info with a layer count of -1 marks the merged alpha channel
+	var layers bytes.Buffer
+	binary.Write(&layers, binary.BigEndian, uint32(2))
+	binary.Write(&layers, binary.BigEndian, int16(-1))
+
+	var imageData bytes.Buffer
+	binary.Write(&imageData, binary.BigEndian, uint16(1))
+	rows := [][]byte{
+		{0xfd, 0x80},                   // gray: run of four 0x80
+		{0x01, 0xff, 0x00, 0xff, 0x40}, // alpha: literal ff 00, then two 0x40
+	}
+	for _, row := range rows {
+		binary.Write(&imageData, binary.BigEndian, uint16(len(row)))
+	}
+	for _, row := range rows {
+		imageData.Write(row)
+	}
+	data := buildPSD(4, 1, 2, psdModeGrayscale, layers.Bytes(), imageData.Bytes())
+
+	img, err := decodeStdlib(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []uint8{0xff, 0x00, 0x40, 0x40}
+	for x, a := range want {
+		got := color.NRGBAModel.Convert(img.At(x, 0)).(color.NRGBA)
+		if got.R != 0x80 || got.A != a {
+			t.Fatalf("pixel %d = %v, want gray 0x80 alpha %#x", x, got, a)
+		}
+	}
+}
+
+func TestDecodePSDRejectsOversizedOrTruncatedData(t *testing.T) {
+	var packed bytes.Buffer
+	binary.Write(&packed, binary.BigEndian, uint16(1))
+	binary.Write(&packed, binary.BigEndian, uint16(2)) // one row count, then EOF
+
+	var hugeRow bytes.Buffer
+	binary.Write(&hugeRow, binary.BigEndian, uint16(1))
+	binary.Write(&hugeRow, binary.BigEndian, uint16(0xffff))
+
+	cases := map[string][]byte{
+		"dimensions":   buildPSD(100000, 100000, 3, psdModeRGB, nil, []byte{0, 0}),
+		"raw plane":    buildPSD(4096, 4096, 3, psdModeRGB, nil, []byte{0, 0, 1, 2, 3}),
+		"packed plane": buildPSD(4096, 4096, 3, psdModeRGB, nil, packed.Bytes()),
+		"row length":   buildPSD(4, 1, 1, psdModeGrayscale, nil, hugeRow.Bytes()),
+	}
+	for name, data := range cases {
+		if _, err := decodeStdlib(data); err == nil {
+			t.Errorf("%s: decode succeeded", name)
+		}
+	}
+
+	// A color mode data length beyond the section limit
+	data := buildPSD(1, 1, 1, psdModeGrayscale, nil, []byte{0, 0, 0})
+	binary.BigEndian.PutUint32(data[26:], 0xfffffff0)
+	if _, err := decodeStdlib(data); err == nil {
+		t.Error("oversized color mode data accepted")
+	}
+}
@@ -0,0 +1,182 @@
+package imgdecode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Radiance RGBE (.hdr) files: a text header, a resolution line, then
+// scanlines of shared-exponent pixels, usually run-length encoded per
+// component.
+
+func isRadianceData(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("#?RADIANCE")) || bytes.HasPrefix(data, []byte("#?RGBE"))
+}
+
+// readRadianceHeader returns the offset of the pixel data, the image size,
+// and whether rows are stored bottom-up.
+func readRadianceHeader(data []byte) (int, int, int, bool, error) {
+	pos := 0
+	readLine := func() (string, bool) {
+		end := bytes.IndexByte(data[pos:], '\n')
+		if end < 0 {
+			return "", false
+		}
+		line := string(data[pos : pos+end])
+		pos += end + 1
+		return line, true
+	}
+
+	if _, ok := readLine(); !ok {
+		return 0, 0, 0, false, io.ErrUnexpectedEOF
+	}
+	for {
+		line, ok := readLine()
+		if !ok {
+			return 0, 0, 0, false, io.ErrUnexpectedEOF
+		}
+		if line == "" {
+			break
+		}
+		if format, found := strings.CutPrefix(line, "FORMAT="); found && format != "32-bit_rle_rgbe" {
+			return 0, 0, 0, false, fmt.Errorf("hdr: unsupported format %q", format)
+		}
+	}
+
+	line, ok := readLine()
+	if !ok {
+		return 0, 0, 0, false, io.ErrUnexpectedEOF
+	}
+	var yAxis, xAxis string
+	var width, height int
+	if _, err := fmt.Sscanf(line, "%s %d %s %d", &yAxis, &height, &xAxis, &width); err != nil {
+		return 0, 0, 0, false, fmt.Errorf("hdr: invalid resolution line %q", line)
+	}
+	if xAxis != "+X" || (yAxis != "-Y" && yAxis != "+Y") {
+		return 0, 0, 0, false, fmt.Errorf("hdr: unsupported orientation %q", line)
+	}
+	if err := checkImageSize("hdr", width, height); err != nil {
+		return 0, 0, 0, false, err
+	}
+	// Every scanline takes at least four bytes, run-length encoded or not.
+	if height > (len(data)-pos)/4 {
+		return 0, 0, 0, false, io.ErrUnexpectedEOF
+	}
+	return pos, width, height, yAxis == "+Y", nil
+}
+
+func decodeRadiance(data []byte) (*HDRImage, error) {
+	pos, width, height, bottomUp, err := readRadianceHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	img := newHDRImage(width, height)
+	scanline := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		n, err := readRadianceScanline(data[pos:], scanline)
+		if err != nil {
+			return nil, fmt.Errorf("hdr: scanline %d: %w", y, err)
+		}
+		pos += n
+
+		row := y
+		if bottomUp {
+			row = height - 1 - y
+		}
+		out := img.Pix[row*width*4:]
+		for x := 0; x < width; x++ {
+			rgbe := scanline[x*4:]
+			out[x*4+3] = 1
+			if rgbe[3] == 0 {
+				continue
+			}
+			f := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+			out[x*4] = (float32(rgbe[0]) + 0.5) * f
+			out[x*4+1] = (float32(rgbe[1]) + 0.5) * f
+			out[x*4+2] = (float32(rgbe[2]) + 0.5) * f
+		}
+	}
+	return img, nil
+}
+
+// readRadianceScanline fills dst with RGBE quadruplets and returns the
+// number of bytes consumed.
+func readRadianceScanline(src, dst []byte) (int, error) {
+	width := len(dst) / 4
+	if width < 8 || width > 0x7fff || len(src) < 4 || src[0] != 2 || src[1] != 2 || src[2]&0x80 != 0 {
+		return readFlatRadianceScanline(src, dst)
+	}
+	if int(src[2])<<8|int(src[3]) != width {
+		return 0, errors.New("scanline width mismatch")
+	}
+
+	// New-style RLE: each of the four components is encoded separately
+	pos := 4
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			if pos >= len(src) {
+				return 0, io.ErrUnexpectedEOF
+			}
+			count := int(src[pos])
+			pos++
+			if count > 128 {
+				count -= 128
+				if x+count > width || pos >= len(src) {
+					return 0, errors.New("run overflows scanline")
+				}
+				for i := 0; i < count; i++ {
+					dst[(x+i)*4+c] = src[pos]
+				}
+				pos++
+			} else {
+				if count == 0 || x+count > width || pos+count > len(src) {
+					return 0, errors.New("literal overflows scanline")
+				}
+				for i := 0; i < count; i++ {
+					dst[(x+i)*4+c] = src[pos+i]
+				}
+				pos += count
+			}
+			x += count
+		}
+	}
+	return pos, nil
+}
+
+// readFlatRadianceScanline reads uncompressed pixels, expanding the old
+// (1,1,1,n) repeat markers.
+func readFlatRadianceScanline(src, dst []byte) (int, error) {
+	width := len(dst) / 4
+	pos, shift := 0, 0
+	for x := 0; x < width; {
+		if pos+4 > len(src) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		p := src[pos : pos+4]
+		pos += 4
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			if x == 0 {
+				return 0, errors.New("repeat marker without previous pixel")
+			}
+			count := int(p[3]) << shift
+			if x+count > width {
+				return 0, errors.New("run overflows scanline")
+			}
+			for i := 0; i < count; i++ {
+				copy(dst[(x+i)*4:(x+i+1)*4], dst[(x-1)*4:x*4])
+			}
+			x += count
+			shift += 8
+			continue
+		}
+		copy(dst[x*4:(x+1)*4], p)
+		x++
+		shift = 0
+	}
+	return pos, nil
+}
@@ -0,0 +1,60 @@
+package imgdecode
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// SniffLength is the number of leading bytes SniffFormat looks at.
+const SniffLength = 16
+
+// imageSignatures maps leading bytes to the format they identify. Formats
+// with a container (RIFF) are checked separately.
+var imageSignatures = []struct {
+	format string
+	magic  []byte
+}{
+	{"png", pngSignature},
+	{"jpeg", []byte{0xff, 0xd8, 0xff}},
+	{"gif", []byte("GIF87a")},
+	{"gif", []byte("GIF89a")},
+	{"bmp", []byte("BM")},
+	{"tiff", []byte("II*\x00")},
+	{"tiff", []byte("MM\x00*")},
+	{"psd", []byte("8BPS")},
+	{"xcf", []byte("gimp xcf ")},
+	{"hdr", []byte("#?RADIANCE")},
+	{"hdr", []byte("#?RGBE")},
+	{"exr", []byte(exrMagic)},
+}
+
+// SniffFormat identifies a supported image format from the first bytes of
+// a file, returning "" when none matches.
+func SniffFormat(head []byte) string {
+	if isWebPData(head) {
+		return "webp"
+	}
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return sig.format
+		}
+	}
+	return ""
+}
+
+// SniffFile reads the start of path and identifies its image format.
+func SniffFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return SniffFormat(head[:n]), nil
+}
@@ -0,0 +1,500 @@
+package imgdecode
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+)
+
+// GIMP XCF files have no stored composite, so the visible top-level layers
+// are flattened here with normal blending and layer opacity. Group layers
+// carry their own rendered pixels, so children inside groups are skipped.
+// Layer masks and other blend modes are ignored, and only 8-bit precision
+// is supported.
+
+const (
+	xcfPropEnd          = 0
+	xcfPropColormap     = 1
+	xcfPropOpacity      = 6
+	xcfPropVisible      = 8
+	xcfPropOffsets      = 15
+	xcfPropCompression  = 17
+	xcfPropItemPath     = 30
+	xcfPropFloatOpacity = 33
+
+	xcfCompressNone = 0
+	xcfCompressRLE  = 1
+	xcfCompressZlib = 2
+
+	xcfTileSize = 64
+)
+
+func init() {
+	image.RegisterFormat("xcf", "gimp xcf ", decodeXCF, decodeXCFConfig)
+}
+
+type xcfReader struct {
+	data    []byte
+	pos     int
+	version int
+}
+
+func (r *xcfReader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v := binary.BigEndian.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+// pointer reads a file offset, which is 64-bit from format version 11.
+func (r *xcfReader) pointer() (int, error) {
+	if r.version >= 11 {
+		if r.pos+8 > len(r.data) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		v := binary.BigEndian.Uint64(r.data[r.pos:])
+		r.pos += 8
+		if v > uint64(len(r.data)) {
+			return 0, errors.New("xcf: pointer out of range")
+		}
+		return int(v), nil
+	}
+	v, err := r.uint32()
+	if int(v) > len(r.data) {
+		return 0, errors.New("xcf: pointer out of range")
+	}
+	return int(v), err
+}
+
+func (r *xcfReader) seek(pos int) error {
+	if pos < 0 || pos > len(r.data) {
+		return errors.New("xcf: offset out of range")
+	}
+	r.pos = pos
+	return nil
+}
+
+// properties reads a property list up to PROP_END, returning payloads by type.
+func (r *xcfReader) properties() (map[uint32][]byte, error) {
+	props := map[uint32][]byte{}
+	for {
+		typ, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		length, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if typ == xcfPropEnd {
+			return props, nil
+		}
+		if uint64(r.pos)+uint64(length) > uint64(len(r.data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		props[typ] = r.data[r.pos : r.pos+int(length)]
+		r.pos += int(length)
+	}
+}
+
+type xcfHeader struct {
+	version  int
+	width    int
+	height   int
+	baseType int
+}
+
+func readXCFHeader(r *xcfReader) (xcfHeader, error) {
+	if len(r.data) < 14 || string(r.data[:9]) != "gimp xcf " {
+		return xcfHeader{}, errors.New("xcf: invalid signature")
+	}
+	tag := string(r.data[9:13])
+	version := 0
+	if tag != "file" {
+		if tag[0] != 'v' {
+			return xcfHeader{}, errors.New("xcf: invalid version tag")
+		}
+		v, err := strconv.Atoi(tag[1:])
+		if err != nil {
+			return xcfHeader{}, errors.New("xcf: invalid version tag")
+		}
+		version = v
+	}
+	r.version = version
+	r.pos = 14
+
+	var fields [3]uint32
+	for i := range fields {
+		v, err := r.uint32()
+		if err != nil {
+			return xcfHeader{}, err
+		}
+		fields[i] = v
+	}
+	h := xcfHeader{version: version, width: int(fields[0]), height: int(fields[1]), baseType: int(fields[2])}
+	if err := checkImageSize("xcf", h.width, h.height); err != nil {
+		return xcfHeader{}, err
+	}
+
+	if version >= 4 {
+		precision, err := r.uint32()
+		if err != nil {
+			return xcfHeader{}, err
+		}
+		if !xcfIs8BitPrecision(version, precision) {
+			return xcfHeader{}, fmt.Errorf("xcf: unsupported precision %d", precision)
+		}
+	}
+	return h, nil
+}
+
+func xcfIs8BitPrecision(version int, precision uint32) bool {
+	if version == 4 {
+		return precision == 0
+	}
+	return precision == 100 || precision == 150
+}
+
+func decodeXCFConfig(r io.Reader) (image.Config, error) {
+	head := make([]byte, 14+16)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return image.Config{}, err
+	}
+	h, err := readXCFHeader(&xcfReader{data: head[:n]})
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+func decodeXCF(rd io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	r := &xcfReader{data: data}
+	h, err := readXCFHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	props, err := r.properties()
+	if err != nil {
+		return nil, fmt.Errorf("xcf: image properties: %w", err)
+	}
+	compression := byte(xcfCompressNone)
+	if p := props[xcfPropCompression]; len(p) > 0 {
+		compression = p[0]
+	}
+	palette := xcfColormap(props[xcfPropColormap])
+
+	var layerPointers []int
+	for {
+		ptr, err := r.pointer()
+		if err != nil {
+			return nil, fmt.Errorf("xcf: layer list: %w", err)
+		}
+		if ptr == 0 {
+			break
+		}
+		layerPointers = append(layerPointers, ptr)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, h.width, h.height))
+	// The first layer is the top of the stack, so draw from the bottom up
+	for i := len(layerPointers) - 1; i >= 0; i-- {
+		if err := r.seek(layerPointers[i]); err != nil {
+			return nil, err
+		}
+		if err := drawXCFLayer(r, canvas, compression, palette); err != nil {
+			return nil, fmt.Errorf("xcf: layer %d: %w", i, err)
+		}
+	}
+	return canvas, nil
+}
+
+func xcfColormap(p []byte) color.Palette {
+	if len(p) < 4 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint32(p))
+	var palette color.Palette
+	for i := 0; i < n && 4+i*3+3 <= len(p); i++ {
+		c := p[4+i*3:]
+		palette = append(palette, color.NRGBA{R: c[0], G: c[1], B: c[2], A: 0xff})
+	}
+	return palette
+}
+
+func drawXCFLayer(r *xcfReader, canvas *image.RGBA, compression byte, palette color.Palette) error {
+	var fields [3]uint32
+	for i := range fields {
+		v, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		fields[i] = v
+	}
+	width, height, layerType := int(fields[0]), int(fields[1]), int(fields[2])
+
+	nameLength, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	if uint64(r.pos)+uint64(nameLength) > uint64(len(r.data)) {
+		return io.ErrUnexpectedEOF
+	}
+	r.pos += int(nameLength)
+
+	props, err := r.properties()
+	if err != nil {
+		return err
+	}
+	if p := props[xcfPropVisible]; len(p) >= 4 && binary.BigEndian.Uint32(p) == 0 {
+		return nil
+	}
+	if p := props[xcfPropItemPath]; len(p) > 4 {
+		// Children of a group are already part of the group's pixels
+		return nil
+	}
+	opacity := uint8(0xff)
+	if p := props[xcfPropOpacity]; len(p) >= 4 {
+		opacity = uint8(min(binary.BigEndian.Uint32(p), 0xff))
+	}
+	if p := props[xcfPropFloatOpacity]; len(p) >= 4 {
+		f := math.Float32frombits(binary.BigEndian.Uint32(p))
+		opacity = uint8(math.Round(float64(max(0, min(1, f))) * 0xff))
+	}
+	var offsetX, offsetY int
+	if p := props[xcfPropOffsets]; len(p) >= 8 {
+		offsetX = int(int32(binary.BigEndian.Uint32(p[0:4])))
+		offsetY = int(int32(binary.BigEndian.Uint32(p[4:8])))
+	}
+
+	hierarchy, err := r.pointer()
+	if err != nil {
+		return err
+	}
+	if width <= 0 || height <= 0 || opacity == 0 {
+		return nil
+	}
+
+	layer, err := readXCFHierarchy(r, hierarchy, width, height, layerType, compression, palette)
+	if err != nil {
+		return err
+	}
+	dst := layer.Bounds().Add(image.Pt(offsetX, offsetY))
+	draw.DrawMask(canvas, dst, layer, image.Point{}, image.NewUniform(color.Alpha{A: opacity}), image.Point{}, draw.Over)
+	return nil
+}
+
+// readXCFHierarchy decodes the full-resolution level of a layer's pixels.
+func readXCFHierarchy(r *xcfReader, offset, width, height, layerType int, compression byte, palette color.Palette) (*image.NRGBA, error) {
+	if err := r.seek(offset); err != nil {
+		return nil, err
+	}
+	if _, err := r.uint32(); err != nil { // width
+		return nil, err
+	}
+	if _, err := r.uint32(); err != nil { // height
+		return nil, err
+	}
+	bppValue, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	bpp := int(bppValue)
+	if bpp != xcfLayerBPP(layerType) {
+		return nil, fmt.Errorf("unsupported layer type %d with %d bytes per pixel", layerType, bpp)
+	}
+	level, err := r.pointer()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.seek(level); err != nil {
+		return nil, err
+	}
+	if _, err := r.uint32(); err != nil {
+		return nil, err
+	}
+	if _, err := r.uint32(); err != nil {
+		return nil, err
+	}
+
+	if err := checkImageSize("xcf", width, height); err != nil {
+		return nil, err
+	}
+	tilesX := (width + xcfTileSize - 1) / xcfTileSize
+	tilesY := (height + xcfTileSize - 1) / xcfTileSize
+	// Every tile needs an entry in the pointer table that follows
+	pointerSize := 4
+	if r.version >= 11 {
+		pointerSize = 8
+	}
+	if tilesX*tilesY > (len(r.data)-r.pos)/pointerSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	tile := make([]byte, xcfTileSize*xcfTileSize*bpp)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			ptr, err := r.pointer()
+			if err != nil {
+				return nil, err
+			}
+			if ptr == 0 {
+				return nil, errors.New("missing tile")
+			}
+			tileW := min(xcfTileSize, width-tx*xcfTileSize)
+			tileH := min(xcfTileSize, height-ty*xcfTileSize)
+			pixels := tile[:tileW*tileH*bpp]
+			if err := readXCFTile(r.data, ptr, pixels, bpp, compression); err != nil {
+				return nil, err
+			}
+			for y := 0; y < tileH; y++ {
+				for x := 0; x < tileW; x++ {
+					px := pixels[(y*tileW+x)*bpp:]
+					img.SetNRGBA(tx*xcfTileSize+x, ty*xcfTileSize+y, xcfPixel(px, layerType, palette))
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+func xcfLayerBPP(layerType int) int {
+	switch layerType {
+	case 0:
+		return 3 // RGB
+	case 1:
+		return 4 // RGBA
+	case 2, 4:
+		return 1 // Gray, Indexed
+	case 3, 5:
+		return 2 // GrayA, IndexedA
+	default:
+		return 0
+	}
+}
+
+func xcfPixel(px []byte, layerType int, palette color.Palette) color.NRGBA {
+	switch layerType {
+	case 0:
+		return color.NRGBA{R: px[0], G: px[1], B: px[2], A: 0xff}
+	case 1:
+		return color.NRGBA{R: px[0], G: px[1], B: px[2], A: px[3]}
+	case 2:
+		return color.NRGBA{R: px[0], G: px[0], B: px[0], A: 0xff}
+	case 3:
+		return color.NRGBA{R: px[0], G: px[0], B: px[0], A: px[1]}
+	default:
+		c := color.NRGBA{A: 0xff}
+		if int(px[0]) < len(palette) {
+			c = palette[px[0]].(color.NRGBA)
+		}
+		if layerType == 5 {
+			c.A = px[1]
+		}
+		return c
+	}
+}
+
+// readXCFTile fills dst with interleaved pixels of one tile.
+func readXCFTile(data []byte, offset int, dst []byte, bpp int, compression byte) error {
+	src := data[offset:]
+	switch compression {
+	case xcfCompressNone:
+		if len(src) < len(dst) {
+			return io.ErrUnexpectedEOF
+		}
+		copy(dst, src)
+		return nil
+	case xcfCompressZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(src))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		_, err = io.ReadFull(zr, dst)
+		return err
+	case xcfCompressRLE:
+		return decodeXCFRLE(src, dst, bpp)
+	default:
+		return fmt.Errorf("unsupported compression %d", compression)
+	}
+}
+
+// decodeXCFRLE expands GIMP's per-channel run-length encoding, writing each
+// channel into its interleaved position in dst.
+func decodeXCFRLE(src, dst []byte, bpp int) error {
+	pixels := len(dst) / bpp
+	si := 0
+	next := func() (byte, error) {
+		if si >= len(src) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		b := src[si]
+		si++
+		return b, nil
+	}
+	for channel := 0; channel < bpp; channel++ {
+		for n := 0; n < pixels; {
+			op, err := next()
+			if err != nil {
+				return err
+			}
+			length, literal := 0, false
+			switch {
+			case op <= 126:
+				length = int(op) + 1
+			case op == 127, op == 128:
+				hi, err := next()
+				if err != nil {
+					return err
+				}
+				lo, err := next()
+				if err != nil {
+					return err
+				}
+				length = int(hi)<<8 | int(lo)
+				literal = op == 128
+			default:
+				length = 256 - int(op)
+				literal = true
+			}
+			if n+length > pixels {
+				return errors.New("xcf: RLE run overflows tile")
+			}
+			if literal {
+				for i := 0; i < length; i++ {
+					v, err := next()
+					if err != nil {
+						return err
+					}
+					dst[(n+i)*bpp+channel] = v
+				}
+			} else {
+				v, err := next()
+				if err != nil {
+					return err
+				}
+				for i := 0; i < length; i++ {
+					dst[(n+i)*bpp+channel] = v
+				}
+			}
+			n += length
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package imgdecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+type xcfTestLayer struct {
+	width, height int
+	layerType     int
+	offsetX       int
+	visible       bool
+	tile          []byte // compressed tile data
+}
+
+func writeXCFProp(buf *bytes.Buffer, typ uint32, payload []byte) {
+	binary.Write(buf, binary.BigEndian, typ)
+	binary.Write(buf, binary.BigEndian, uint32(len(payload)))
+	buf.Write(payload)
+}
+
+func be32(values ...uint32) []byte {
+	out := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return out
+}
+
+// buildXCF writes a version 3 XCF whose layers each fit in a single tile.
+// Layers are listed top first, as GIMP stores them.
+func buildXCF(width, height int, compression byte, layers []xcfTestLayer) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("gimp xcf v003\x00")
+	buf.Write(be32(uint32(width), uint32(height), 0))
+	writeXCFProp(&buf, xcfPropCompression, []byte{compression})
+	writeXCFProp(&buf, xcfPropEnd, nil)
+
+	layerTable := buf.Len()
+	buf.Write(make([]byte, 4*(len(layers)+1))) // layer pointers, zero-terminated
+	buf.Write(be32(0))                         // channel pointers
+
+	for i, layer := range layers {
+		binary.BigEndian.PutUint32(buf.Bytes()[layerTable+4*i:], uint32(buf.Len()))
+		buf.Write(be32(uint32(layer.width), uint32(layer.height), uint32(layer.layerType)))
+		buf.Write(be32(2))
+		buf.WriteString("L\x00")
+		visible := uint32(0)
+		if layer.visible {
+			visible = 1
+		}
+		writeXCFProp(&buf, xcfPropVisible, be32(visible))
+		writeXCFProp(&buf, xcfPropOffsets, be32(uint32(layer.offsetX), 0))
+		writeXCFProp(&buf, xcfPropEnd, nil)
+		hierarchy := buf.Len() + 8
+		buf.Write(be32(uint32(hierarchy), 0))
+
+		level := hierarchy + 16
+		buf.Write(be32(uint32(layer.width), uint32(layer.height), uint32(xcfLayerBPP(layer.layerType)), uint32(level)))
+		tile := level + 16
+		buf.Write(be32(uint32(layer.width), uint32(layer.height), uint32(tile), 0))
+		buf.Write(layer.tile)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeXCFFlattensVisibleLayers(t *testing.T) {
+	data := buildXCF(3, 1, xcfCompressRLE, []xcfTestLayer{
+		// Top: hidden layer that would paint everything white
+		{width: 3, height: 1, layerType: 0, visible: false, tile: []byte{
+			0x02, 0xff, 0x02, 0xff, 0x02, 0xff,
+		}},
+		// Middle: one opaque red pixel offset to x=2
+		{width: 1, height: 1, layerType: 1, offsetX: 2, visible: true, tile: []byte{
+			0x00, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff,
+		}},
+		// Bottom: green background, blue channel as a literal run
+		{width: 3, height: 1, layerType: 0, visible: true, tile: []byte{
+			0x02, 0x00, 0x02, 0xff, 0xfd, 0x00, 0x10, 0x20,
+		}},
+	})
+
+	img, err := decodeStdlib(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []color.NRGBA{
+		{G: 0xff, B: 0x00, A: 0xff},
+		{G: 0xff, B: 0x10, A: 0xff},
+		{R: 0xff, A: 0xff},
+	}
+	for x, w := range want {
+		if got := color.NRGBAModel.Convert(img.At(x, 0)); got != w {
+			t.Fatalf("pixel %d = %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestDecodeXCFUncompressedGray(t *testing.T) {
+	data := buildXCF(2, 1, xcfCompressNone, []xcfTestLayer{
+		{width: 2, height: 1, layerType: 3, visible: true, tile: []byte{0x80, 0xff, 0x40, 0xff}},
+	})
+	img, err := decodeStdlib(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := color.GrayModel.Convert(img.At(1, 0)).(color.Gray); got.Y != 0x40 {
+		t.Fatalf("pixel 1 = %v, want gray 0x40", got)
+	}
+}
+
+func TestDecodeXCFRejectsOversizedDimensions(t *testing.T) {
+	if _, err := decodeStdlib(buildXCF(100000, 100000, xcfCompressNone, nil)); err == nil {
+		t.Error("oversized canvas accepted")
+	}
+	// A layer claiming far more tiles than the file has pointers for
+	layer := xcfTestLayer{width: 8000, height: 8000, layerType: 2, visible: true, tile: []byte{0}}
+	if _, err := decodeStdlib(buildXCF(4, 4, xcfCompressNone, []xcfTestLayer{layer})); err == nil {
+		t.Error("oversized layer accepted")
+	}
+}
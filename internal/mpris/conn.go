@@ -0,0 +1,217 @@
+package mpris
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dialTimeout bounds connecting and authenticating to the session bus so a
+// stale bus address cannot delay startup.
+const dialTimeout = 2 * time.Second
+
+var errNoSessionBus = errors.New("no D-Bus session bus address")
+
+// conn is a minimal D-Bus connection: it can call methods synchronously
+// before serving starts and send messages from any goroutine afterwards.
+type conn struct {
+	c      net.Conn
+	r      *bufio.Reader
+	mu     sync.Mutex // Serializes writes and serial allocation
+	serial uint32
+	name   string // Unique bus name assigned by Hello
+}
+
+// sessionBusAddress returns the unix socket of the session bus from
+// DBUS_SESSION_BUS_ADDRESS, falling back to the systemd user bus.
+func sessionBusAddress() (string, error) {
+	if addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); addr != "" {
+		return parseBusAddress(addr)
+	}
+	if uid := os.Getuid(); uid >= 0 {
+		path := fmt.Sprintf("/run/user/%d/bus", uid)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errNoSessionBus
+}
+
+// parseBusAddress picks the first unix transport of a D-Bus address list.
+// Abstract sockets are returned with Go's "@" prefix.
+func parseBusAddress(addr string) (string, error) {
+	for _, entry := range strings.Split(addr, ";") {
+		rest, ok := strings.CutPrefix(entry, "unix:")
+		if !ok {
+			continue
+		}
+		for _, kv := range strings.Split(rest, ",") {
+			key, value, _ := strings.Cut(kv, "=")
+			value, err := unescapeBusValue(value)
+			if err != nil {
+				return "", err
+			}
+			switch key {
+			case "path":
+				return value, nil
+			case "abstract":
+				return "@" + value, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported D-Bus address %q", addr)
+}
+
+// unescapeBusValue decodes the %XX escapes of an address value.
+func unescapeBusValue(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// dial connects to the unix socket at path, authenticates with the
+// EXTERNAL mechanism and registers with Hello.
+func dial(path string) (*conn, error) {
+	c, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c.SetDeadline(time.Now().Add(dialTimeout))
+	bc := &conn{c: c, r: bufio.NewReader(c)}
+	if err := bc.auth(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("authenticating: %w", err)
+	}
+	reply, err := bc.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	bc.name, _ = reply[0].(string)
+	c.SetDeadline(time.Time{})
+	return bc, nil
+}
+
+func (c *conn) auth() error {
+	uid := strconv.Itoa(os.Getuid())
+	if _, err := c.c.Write([]byte("\x00AUTH EXTERNAL " + hex.EncodeToString([]byte(uid)) + "\r\n")); err != nil {
+		return err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = c.c.Write([]byte("BEGIN\r\n"))
+	return err
+}
+
+// send assigns a serial to m and writes it.
+func (c *conn) send(m *message) (uint32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.serial++
+	data, err := m.marshal(c.serial)
+	if err != nil {
+		return 0, err
+	}
+	_, err = c.c.Write(data)
+	return c.serial, err
+}
+
+// call sends a method call and waits for its reply, dropping anything else
+// that arrives first. It is only used before serve starts reading.
+func (c *conn) call(dest string, path ObjectPath, iface, member string, sig Signature, args ...any) ([]any, error) {
+	serial, err := c.send(&message{
+		Type: typeMethodCall, Destination: dest, Path: path, Interface: iface, Member: member,
+		Signature: sig, Body: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		m, err := readMessage(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if m.ReplySerial != serial {
+			continue
+		}
+		switch m.Type {
+		case typeMethodReturn:
+			return m.Body, nil
+		case typeError:
+			return nil, callError(m)
+		}
+	}
+}
+
+func callError(m *message) error {
+	if len(m.Body) > 0 {
+		if text, ok := m.Body[0].(string); ok {
+			return fmt.Errorf("%s: %s", m.ErrorName, text)
+		}
+	}
+	return errors.New(m.ErrorName)
+}
+
+// reply answers call with a method return.
+func (c *conn) reply(call *message, sig Signature, body ...any) error {
+	if call.Flags&flagNoReplyExpected != 0 {
+		return nil
+	}
+	_, err := c.send(&message{
+		Type: typeMethodReturn, ReplySerial: call.Serial, Destination: call.Sender,
+		Signature: sig, Body: body,
+	})
+	return err
+}
+
+// replyError answers call with a D-Bus error.
+func (c *conn) replyError(call *message, name, text string) error {
+	if call.Flags&flagNoReplyExpected != 0 {
+		return nil
+	}
+	_, err := c.send(&message{
+		Type: typeError, ReplySerial: call.Serial, Destination: call.Sender, ErrorName: name,
+		Signature: "s", Body: []any{text},
+	})
+	return err
+}
+
+func (c *conn) Close() error {
+	return c.c.Close()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,613 @@
+package mpris
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// D-Bus message types.
+const (
+	typeMethodCall   byte = 1
+	typeMethodReturn byte = 2
+	typeError        byte = 3
+	typeSignal       byte = 4
+)
+
+// Header field codes.
+const (
+	fieldPath        byte = 1
+	fieldInterface   byte = 2
+	fieldMember      byte = 3
+	fieldErrorName   byte = 4
+	fieldReplySerial byte = 5
+	fieldDestination byte = 6
+	fieldSender      byte = 7
+	fieldSignature   byte = 8
+)
+
+// flagNoReplyExpected marks calls whose caller does not wait for a reply.
+const flagNoReplyExpected byte = 1
+
+// maxMessageSize is the D-Bus protocol limit for a whole message.
+const maxMessageSize = 128 << 20
+
+// ObjectPath is a D-Bus object path ("o").
+type ObjectPath string
+
+// Signature is a D-Bus type signature ("g").
+type Signature string
+
+// Variant is a value together with its D-Bus signature ("v").
+type Variant struct {
+	Sig   Signature
+	Value any
+}
+
+// message is a decoded D-Bus message. Body values use the Go types listed
+// in encoder.value.
+type message struct {
+	Type        byte
+	Flags       byte
+	Serial      uint32
+	Path        ObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   Signature
+	Body        []any
+}
+
+// encoder writes little-endian D-Bus wire data. Alignment is relative to
+// the start of the message, which is the start of buf.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.align(8)
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+func (e *encoder) signature(s Signature) {
+	e.buf = append(e.buf, byte(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+// value writes v, which must match the single complete type sig.
+func (e *encoder) value(sig string, v any) error {
+	switch sig[0] {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.buf = append(e.buf, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		var u uint32
+		if b {
+			u = 1
+		}
+		e.uint32(u)
+	case 'i':
+		i, ok := v.(int32)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.uint32(uint32(i))
+	case 'u':
+		u, ok := v.(uint32)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.uint32(u)
+	case 'x':
+		i, ok := v.(int64)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.uint64(uint64(i))
+	case 'd':
+		f, ok := v.(float64)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.uint64(math.Float64bits(f))
+	case 's':
+		s, ok := v.(string)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.string(s)
+	case 'o':
+		s, ok := v.(ObjectPath)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.string(string(s))
+	case 'g':
+		s, ok := v.(Signature)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.signature(s)
+	case 'v':
+		vv, ok := v.(Variant)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		if n, err := typeLength(string(vv.Sig)); err != nil || n != len(vv.Sig) {
+			return fmt.Errorf("variant signature %q is not a single type", vv.Sig)
+		}
+		e.signature(vv.Sig)
+		return e.value(string(vv.Sig), vv.Value)
+	case 'a':
+		return e.array(sig, v)
+	case '(':
+		fields, ok := v.([]any)
+		if !ok {
+			return typeMismatch(sig, v)
+		}
+		e.align(8)
+		return e.values(sig[1:len(sig)-1], fields)
+	default:
+		return fmt.Errorf("unsupported type %q", sig)
+	}
+	return nil
+}
+
+// array writes "as", "a{sv}" and arrays of structs or variants.
+func (e *encoder) array(sig string, v any) error {
+	elem := sig[1:]
+	e.uint32(0)
+	lenPos := len(e.buf) - 4
+	e.align(alignment(elem[0]))
+	start := len(e.buf)
+	var err error
+	switch items := v.(type) {
+	case []string:
+		if elem != "s" {
+			return typeMismatch(sig, v)
+		}
+		for _, s := range items {
+			e.string(s)
+		}
+	case map[string]Variant:
+		if elem != "{sv}" {
+			return typeMismatch(sig, v)
+		}
+		for _, k := range sortedKeys(items) {
+			e.align(8)
+			e.string(k)
+			if err = e.value("v", items[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range items {
+			if err = e.value(elem, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return typeMismatch(sig, v)
+	}
+	binary.LittleEndian.PutUint32(e.buf[lenPos:], uint32(len(e.buf)-start))
+	return nil
+}
+
+// values writes one value per complete type in sig.
+func (e *encoder) values(sig string, vs []any) error {
+	for len(sig) > 0 {
+		n, err := typeLength(sig)
+		if err != nil {
+			return err
+		}
+		if len(vs) == 0 {
+			return fmt.Errorf("missing value for %q", sig[:n])
+		}
+		if err := e.value(sig[:n], vs[0]); err != nil {
+			return err
+		}
+		sig, vs = sig[n:], vs[1:]
+	}
+	if len(vs) > 0 {
+		return fmt.Errorf("%d values beyond signature", len(vs))
+	}
+	return nil
+}
+
+func typeMismatch(sig string, v any) error {
+	return fmt.Errorf("cannot encode %T as %q", v, sig)
+}
+
+func alignment(c byte) int {
+	switch c {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 's', 'o', 'a', 'h':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	default:
+		return 1
+	}
+}
+
+// typeLength returns the length of the first complete type in sig.
+func typeLength(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := typeLength(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		closer := byte(')')
+		if sig[0] == '{' {
+			closer = '}'
+		}
+		i := 1
+		for i < len(sig) && sig[i] != closer {
+			n, err := typeLength(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i >= len(sig) {
+			return 0, fmt.Errorf("unterminated signature %q", sig)
+		}
+		if i == 1 {
+			return 0, fmt.Errorf("empty struct in signature %q", sig)
+		}
+		return i + 1, nil
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 's', 'o', 'g', 'v', 'h':
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("invalid signature %q", sig)
+	}
+}
+
+// marshal encodes m with the given serial.
+func (m *message) marshal(serial uint32) ([]byte, error) {
+	var body encoder
+	if err := body.values(string(m.Signature), m.Body); err != nil {
+		return nil, fmt.Errorf("encoding %s body: %w", m.Member, err)
+	}
+
+	var fields []any
+	addField := func(code byte, sig Signature, v any) {
+		fields = append(fields, []any{code, Variant{Sig: sig, Value: v}})
+	}
+	if m.Path != "" {
+		addField(fieldPath, "o", m.Path)
+	}
+	if m.Interface != "" {
+		addField(fieldInterface, "s", m.Interface)
+	}
+	if m.Member != "" {
+		addField(fieldMember, "s", m.Member)
+	}
+	if m.ErrorName != "" {
+		addField(fieldErrorName, "s", m.ErrorName)
+	}
+	if m.ReplySerial != 0 {
+		addField(fieldReplySerial, "u", m.ReplySerial)
+	}
+	if m.Destination != "" {
+		addField(fieldDestination, "s", m.Destination)
+	}
+	if m.Signature != "" {
+		addField(fieldSignature, "g", m.Signature)
+	}
+
+	e := encoder{buf: []byte{'l', m.Type, m.Flags, 1}}
+	e.uint32(uint32(len(body.buf)))
+	e.uint32(serial)
+	if err := e.value("a(yv)", fields); err != nil {
+		return nil, err
+	}
+	e.align(8)
+	return append(e.buf, body.buf...), nil
+}
+
+// decoder reads D-Bus wire data; pos is relative to the message start.
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errTruncated = errors.New("truncated message")
+
+func (d *decoder) align(n int) error {
+	next := (d.pos + n - 1) / n * n
+	if next > len(d.buf) {
+		return errTruncated
+	}
+	d.pos = next
+	return nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errTruncated
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	if err := d.align(4); err != nil {
+		return 0, err
+	}
+	b, err := d.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	if err := d.align(8); err != nil {
+		return 0, err
+	}
+	b, err := d.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint64(b), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.take(int(n) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(b[:n]), nil
+}
+
+func (d *decoder) signature() (Signature, error) {
+	b, err := d.take(1)
+	if err != nil {
+		return "", err
+	}
+	s, err := d.take(int(b[0]) + 1)
+	if err != nil {
+		return "", err
+	}
+	return Signature(s[:b[0]]), nil
+}
+
+// value reads one value of the single complete type sig. Arrays decode to
+// []any, except "as" to []string and "a{sv}" to map[string]Variant.
+func (d *decoder) value(sig string) (any, error) {
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		u, err := d.uint32()
+		return u != 0, err
+	case 'n', 'q':
+		if err := d.align(2); err != nil {
+			return nil, err
+		}
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		u, err := d.uint32()
+		return int32(u), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x':
+		u, err := d.uint64()
+		return int64(u), err
+	case 't':
+		return d.uint64()
+	case 'd':
+		u, err := d.uint64()
+		return math.Float64frombits(u), err
+	case 's':
+		return d.string()
+	case 'o':
+		s, err := d.string()
+		return ObjectPath(s), err
+	case 'g':
+		return d.signature()
+	case 'v':
+		vs, err := d.signature()
+		if err != nil {
+			return nil, err
+		}
+		if n, err := typeLength(string(vs)); err != nil || n != len(vs) {
+			return nil, fmt.Errorf("invalid variant signature %q", vs)
+		}
+		v, err := d.value(string(vs))
+		return Variant{Sig: vs, Value: v}, err
+	case 'a':
+		return d.array(sig[1:])
+	case '(', '{':
+		if err := d.align(8); err != nil {
+			return nil, err
+		}
+		return d.values(sig[1 : len(sig)-1])
+	default:
+		return nil, fmt.Errorf("unsupported type %q", sig)
+	}
+}
+
+func (d *decoder) array(elem string) (any, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.align(alignment(elem[0])); err != nil {
+		return nil, err
+	}
+	end := d.pos + int(n)
+	if end > len(d.buf) {
+		return nil, errTruncated
+	}
+	var items []any
+	for d.pos < end {
+		start := d.pos
+		v, err := d.value(elem)
+		if err != nil {
+			return nil, err
+		}
+		// An element that takes no bytes would never reach the end
+		if d.pos == start {
+			return nil, fmt.Errorf("array element %q is empty", elem)
+		}
+		items = append(items, v)
+	}
+	if d.pos != end {
+		return nil, fmt.Errorf("array of %q overruns its length", elem)
+	}
+	switch elem {
+	case "s":
+		strs := make([]string, len(items))
+		for i, v := range items {
+			strs[i] = v.(string)
+		}
+		return strs, nil
+	case "{sv}":
+		dict := make(map[string]Variant, len(items))
+		for _, v := range items {
+			entry := v.([]any)
+			dict[entry[0].(string)] = entry[1].(Variant)
+		}
+		return dict, nil
+	}
+	return items, nil
+}
+
+func (d *decoder) values(sig string) ([]any, error) {
+	var vs []any
+	for len(sig) > 0 {
+		n, err := typeLength(sig)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(sig[:n])
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+		sig = sig[n:]
+	}
+	return vs, nil
+}
+
+// readMessage reads one message from r.
+func readMessage(r io.Reader) (*message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %q", fixed[0])
+	}
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	headerLen := (16 + int(fieldsLen) + 7) &^ 7
+	if uint64(headerLen)+uint64(bodyLen) > maxMessageSize {
+		return nil, errors.New("message too large")
+	}
+	buf := make([]byte, headerLen+int(bodyLen))
+	copy(buf, fixed)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return nil, err
+	}
+
+	m := &message{Type: fixed[1], Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+	d := decoder{buf: buf[:16+fieldsLen], pos: 12, order: order}
+	fields, err := d.value("a(yv)")
+	if err != nil {
+		return nil, fmt.Errorf("decoding header: %w", err)
+	}
+	for _, f := range fields.([]any) {
+		field := f.([]any)
+		v := field[1].(Variant).Value
+		var ok bool
+		switch field[0].(byte) {
+		case fieldPath:
+			m.Path, ok = v.(ObjectPath)
+		case fieldInterface:
+			m.Interface, ok = v.(string)
+		case fieldMember:
+			m.Member, ok = v.(string)
+		case fieldErrorName:
+			m.ErrorName, ok = v.(string)
+		case fieldReplySerial:
+			m.ReplySerial, ok = v.(uint32)
+		case fieldDestination:
+			m.Destination, ok = v.(string)
+		case fieldSender:
+			m.Sender, ok = v.(string)
+		case fieldSignature:
+			m.Signature, ok = v.(Signature)
+		default:
+			ok = true
+		}
+		if !ok {
+			return nil, fmt.Errorf("header field %d has type %s", field[0], field[1].(Variant).Sig)
+		}
+	}
+
+	body := decoder{buf: buf, pos: headerLen, order: order}
+	if m.Body, err = body.values(string(m.Signature)); err != nil {
+		return nil, fmt.Errorf("decoding %s body: %w", m.Member, err)
+	}
+	return m, nil
+}
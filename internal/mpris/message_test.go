@@ -0,0 +1,106 @@
+package mpris
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := &message{
+		Type:        typeSignal,
+		Path:        objectPath,
+		Interface:   propsInterface,
+		Member:      "PropertiesChanged",
+		Destination: ":1.42",
+		Signature:   "sa{sv}asybxdg",
+		Body: []any{
+			playerInterface,
+			map[string]Variant{
+				"PlaybackStatus": {"s", "Playing"},
+				"CanGoNext":      {"b", true},
+				"Metadata": {"a{sv}", map[string]Variant{
+					"mpris:trackid":     {"o", ObjectPath("/org/mpris/MediaPlayer2/Page/3")},
+					"xesam:trackNumber": {"i", int32(3)},
+				}},
+			},
+			[]string{"a", "bc"},
+			byte(7),
+			false,
+			int64(-5),
+			0.25,
+			Signature("a{sv}"),
+		},
+	}
+	data, err := m.marshal(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Serial != 9 || got.Type != typeSignal || got.Member != m.Member || got.Path != m.Path ||
+		got.Interface != m.Interface || got.Destination != m.Destination || got.Signature != m.Signature {
+		t.Fatalf("header = %+v", got)
+	}
+	if !reflect.DeepEqual(got.Body, m.Body) {
+		t.Fatalf("body = %#v\nwant %#v", got.Body, m.Body)
+	}
+}
+
+func TestMarshalRejectsMismatchedBody(t *testing.T) {
+	for _, m := range []*message{
+		{Type: typeMethodCall, Member: "X", Signature: "s", Body: []any{int32(1)}},
+		{Type: typeMethodCall, Member: "X", Signature: "ss", Body: []any{"a"}},
+		{Type: typeMethodCall, Member: "X", Signature: "v", Body: []any{Variant{"ss", "a"}}},
+	} {
+		if _, err := m.marshal(1); err == nil {
+			t.Errorf("marshal(%q, %v) succeeded", m.Signature, m.Body)
+		}
+	}
+}
+
+func TestReadMessageRejectsTruncated(t *testing.T) {
+	data, err := (&message{Type: typeMethodCall, Member: "Hello", Signature: "s", Body: []any{"x"}}).marshal(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMessage(bytes.NewReader(data[:len(data)-3])); err == nil {
+		t.Fatal("truncated message decoded")
+	}
+}
+
+func TestDecodeRejectsEmptyArrayElements(t *testing.T) {
+	if _, err := typeLength("a()"); err == nil {
+		t.Fatal("typeLength accepted an empty struct")
+	}
+	// An array claiming 8 bytes of empty structs must fail, not spin
+	d := &decoder{buf: []byte{8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, order: binary.LittleEndian}
+	if _, err := d.array("()"); err == nil {
+		t.Fatal("array of empty structs decoded")
+	}
+	// A string element running past the array length is an error too
+	d = &decoder{buf: []byte{4, 0, 0, 0, 2, 0, 0, 0, 'a', 'b', 0}, order: binary.LittleEndian}
+	if _, err := d.array("s"); err == nil {
+		t.Fatal("overrunning array decoded")
+	}
+}
+
+func TestParseBusAddress(t *testing.T) {
+	for addr, want := range map[string]string{
+		"unix:path=/run/user/1000/bus":                          "/run/user/1000/bus",
+		"unix:abstract=/tmp/dbus-XYZ,guid=0123":                 "@/tmp/dbus-XYZ",
+		"tcp:host=localhost,port=1;unix:path=/tmp/my%20bus,x=1": "/tmp/my bus",
+		"unix:guid=abc,path=/tmp/b":                             "/tmp/b",
+	} {
+		got, err := parseBusAddress(addr)
+		if err != nil || got != want {
+			t.Errorf("parseBusAddress(%q) = %q, %v; want %q", addr, got, err, want)
+		}
+	}
+	if _, err := parseBusAddress("tcp:host=localhost,port=1"); err == nil {
+		t.Error("tcp-only address accepted")
+	}
+}
@@ -0,0 +1,360 @@
+// Package mpris exposes a media player on the D-Bus session bus using the
+// MPRIS interface, so desktop media keys, presentation remotes and panel
+// widgets can drive the viewer. It implements just enough of the D-Bus wire
+// protocol for that and has no dependencies.
+package mpris
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+const (
+	objectPath      ObjectPath = "/org/mpris/MediaPlayer2"
+	rootInterface              = "org.mpris.MediaPlayer2"
+	playerInterface            = "org.mpris.MediaPlayer2.Player"
+	propsInterface             = "org.freedesktop.DBus.Properties"
+	busNamePrefix              = "org.mpris.MediaPlayer2."
+
+	errUnknownMethod   = "org.freedesktop.DBus.Error.UnknownMethod"
+	errUnknownProperty = "org.freedesktop.DBus.Error.UnknownProperty"
+	errInvalidArgs     = "org.freedesktop.DBus.Error.InvalidArgs"
+	errPropertyRO      = "org.freedesktop.DBus.Error.PropertyReadOnly"
+)
+
+// Command is a request from a media controller.
+type Command string
+
+const (
+	CommandNext      Command = "next"
+	CommandPrevious  Command = "previous"
+	CommandPlay      Command = "play"
+	CommandPause     Command = "pause"
+	CommandPlayPause Command = "play_pause"
+	CommandStop      Command = "stop"
+	CommandRaise     Command = "raise"
+	CommandQuit      Command = "quit"
+)
+
+// playerCommands maps Player and root interface methods to commands.
+var playerCommands = map[string]Command{
+	"Next":      CommandNext,
+	"Previous":  CommandPrevious,
+	"Play":      CommandPlay,
+	"Pause":     CommandPause,
+	"PlayPause": CommandPlayPause,
+	"Stop":      CommandStop,
+	"Raise":     CommandRaise,
+	"Quit":      CommandQuit,
+}
+
+// Status describes the current page, shown by media widgets as the track.
+type Status struct {
+	Playing bool   // Slideshow running
+	Title   string // File name of the page
+	Album   string // Archive or directory name
+	ArtURL  string // file:// URL of the page, empty for archive entries
+	Page    int    // 1-based page number, 0 when nothing is open
+	Total   int
+
+	QuitLocked bool // Quit is refused, advertised as CanQuit=false
+}
+
+// Player is a registered MPRIS player. Commands are delivered on the
+// connection's goroutine; callers hand them to their own loop.
+type Player struct {
+	conn     *conn
+	busName  string
+	identity string
+	handle   func(Command)
+
+	mu     sync.Mutex
+	status Status
+	done   chan struct{}
+}
+
+// Connect registers identity (e.g. "nv") on the session bus and serves
+// MPRIS until Close. handle is called for every command received.
+func Connect(identity string, handle func(Command)) (*Player, error) {
+	path, err := sessionBusAddress()
+	if err != nil {
+		return nil, err
+	}
+	return connectAddress(path, identity, handle)
+}
+
+func connectAddress(path, identity string, handle func(Command)) (*Player, error) {
+	c, err := dial(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Player{conn: c, identity: identity, handle: handle, done: make(chan struct{})}
+	// Several instances may run at once; only the first gets the plain name
+	for _, name := range []string{busNamePrefix + identity, fmt.Sprintf("%s%s.instance%d", busNamePrefix, identity, os.Getpid())} {
+		const doNotQueue, primaryOwner = 4, 1
+		reply, err := c.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "RequestName", "su", name, uint32(doNotQueue))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("requesting %s: %w", name, err)
+		}
+		if code, _ := reply[0].(uint32); code == primaryOwner {
+			p.busName = name
+			break
+		}
+	}
+	if p.busName == "" {
+		c.Close()
+		return nil, fmt.Errorf("bus name %s%s is taken", busNamePrefix, identity)
+	}
+	go p.serve()
+	return p, nil
+}
+
+// BusName is the well-known name the player owns.
+func (p *Player) BusName() string {
+	return p.busName
+}
+
+func (p *Player) Close() error {
+	err := p.conn.Close()
+	<-p.done
+	return err
+}
+
+// SetStatus updates the published state, notifying controllers of changes.
+func (p *Player) SetStatus(s Status) {
+	p.mu.Lock()
+	prev := p.status
+	p.status = s
+	p.mu.Unlock()
+	if prev == s {
+		return
+	}
+
+	p.notifyChanged(playerInterface, p.playerProperties(s), p.playerProperties(prev))
+	p.notifyChanged(rootInterface, p.rootProperties(s), p.rootProperties(prev))
+}
+
+// notifyChanged signals the properties of iface that differ between old and
+// current, if any.
+func (p *Player) notifyChanged(iface string, current, old map[string]Variant) {
+	changed := make(map[string]Variant)
+	for name, v := range current {
+		if !reflect.DeepEqual(v, old[name]) {
+			changed[name] = v
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	p.conn.send(&message{
+		Type: typeSignal, Path: objectPath, Interface: propsInterface, Member: "PropertiesChanged",
+		Signature: "sa{sv}as", Body: []any{iface, changed, []string{}},
+	})
+}
+
+func (p *Player) serve() {
+	defer close(p.done)
+	for {
+		m, err := readMessage(p.conn.r)
+		if err != nil {
+			return
+		}
+		if m.Type == typeMethodCall {
+			p.dispatch(m)
+		}
+	}
+}
+
+func (p *Player) dispatch(m *message) {
+	c := p.conn
+	switch {
+	case m.Interface == "org.freedesktop.DBus.Peer" && m.Member == "Ping":
+		c.reply(m, "")
+	case m.Path != objectPath:
+		c.replyError(m, "org.freedesktop.DBus.Error.UnknownObject", "no object at "+string(m.Path))
+	case m.Interface == "org.freedesktop.DBus.Introspectable" && m.Member == "Introspect":
+		c.reply(m, "s", introspection)
+	case m.Interface == propsInterface:
+		p.dispatchProperties(m)
+	case m.Interface == playerInterface || m.Interface == rootInterface || m.Interface == "":
+		cmd, ok := playerCommands[m.Member]
+		if !ok {
+			// Seek, SetPosition and OpenUri are advertised as unsupported
+			c.reply(m, "")
+			return
+		}
+		c.reply(m, "")
+		p.handle(cmd)
+	default:
+		c.replyError(m, errUnknownMethod, "unknown interface "+m.Interface)
+	}
+}
+
+func (p *Player) dispatchProperties(m *message) {
+	c := p.conn
+	p.mu.Lock()
+	s := p.status
+	p.mu.Unlock()
+	props := func(iface string) map[string]Variant {
+		switch iface {
+		case rootInterface:
+			return p.rootProperties(s)
+		case playerInterface:
+			return p.playerProperties(s)
+		}
+		return nil
+	}
+
+	switch m.Member {
+	case "Get":
+		iface, _ := argString(m, 0)
+		name, _ := argString(m, 1)
+		v, ok := props(iface)[name]
+		if !ok {
+			c.replyError(m, errUnknownProperty, fmt.Sprintf("no property %s.%s", iface, name))
+			return
+		}
+		c.reply(m, "v", v)
+	case "GetAll":
+		iface, ok := argString(m, 0)
+		all := props(iface)
+		if !ok || (all == nil && iface != "") {
+			c.replyError(m, errInvalidArgs, "unknown interface "+iface)
+			return
+		}
+		if all == nil {
+			all = map[string]Variant{}
+		}
+		c.reply(m, "a{sv}", all)
+	case "Set":
+		c.replyError(m, errPropertyRO, "properties are read-only")
+	default:
+		c.replyError(m, errUnknownMethod, "unknown method "+m.Member)
+	}
+}
+
+func argString(m *message, i int) (string, bool) {
+	if i >= len(m.Body) {
+		return "", false
+	}
+	s, ok := m.Body[i].(string)
+	return s, ok
+}
+
+func (p *Player) rootProperties(s Status) map[string]Variant {
+	return map[string]Variant{
+		"CanQuit":             {"b", !s.QuitLocked},
+		"CanRaise":            {"b", true},
+		"CanSetFullscreen":    {"b", false},
+		"HasTrackList":        {"b", false},
+		"Identity":            {"s", p.identity},
+		"SupportedUriSchemes": {"as", []string{}},
+		"SupportedMimeTypes":  {"as", []string{}},
+	}
+}
+
+func (p *Player) playerProperties(s Status) map[string]Variant {
+	playback := "Paused"
+	switch {
+	case s.Page == 0:
+		playback = "Stopped"
+	case s.Playing:
+		playback = "Playing"
+	}
+	metadata := map[string]Variant{
+		"mpris:trackid": {"o", ObjectPath(fmt.Sprintf("/org/mpris/MediaPlayer2/Page/%d", s.Page))},
+	}
+	if s.Title != "" {
+		metadata["xesam:title"] = Variant{"s", s.Title}
+	}
+	if s.Album != "" {
+		metadata["xesam:album"] = Variant{"s", s.Album}
+	}
+	if s.ArtURL != "" {
+		metadata["mpris:artUrl"] = Variant{"s", s.ArtURL}
+	}
+	if s.Page > 0 {
+		metadata["xesam:trackNumber"] = Variant{"i", int32(s.Page)}
+	}
+	return map[string]Variant{
+		"PlaybackStatus": {"s", playback},
+		"Rate":           {"d", 1.0},
+		"MinimumRate":    {"d", 1.0},
+		"MaximumRate":    {"d", 1.0},
+		"Volume":         {"d", 1.0},
+		"Position":       {"x", int64(0)},
+		"Metadata":       {"a{sv}", metadata},
+		"CanGoNext":      {"b", s.Page > 0 && s.Page < s.Total},
+		"CanGoPrevious":  {"b", s.Page > 1},
+		"CanPlay":        {"b", s.Page > 0},
+		"CanPause":       {"b", s.Page > 0},
+		"CanSeek":        {"b", false},
+		"CanControl":     {"b", true},
+	}
+}
+
+const introspection = `<!DOCTYPE node PUBLIC "-//freedesktop//DTD D-BUS Object Introspection 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/introspect.dtd">
+<node>
+  <interface name="org.freedesktop.DBus.Introspectable">
+    <method name="Introspect"><arg name="xml" type="s" direction="out"/></method>
+  </interface>
+  <interface name="org.freedesktop.DBus.Properties">
+    <method name="Get">
+      <arg name="interface" type="s" direction="in"/><arg name="name" type="s" direction="in"/>
+      <arg name="value" type="v" direction="out"/>
+    </method>
+    <method name="GetAll">
+      <arg name="interface" type="s" direction="in"/><arg name="props" type="a{sv}" direction="out"/>
+    </method>
+    <method name="Set">
+      <arg name="interface" type="s" direction="in"/><arg name="name" type="s" direction="in"/>
+      <arg name="value" type="v" direction="in"/>
+    </method>
+    <signal name="PropertiesChanged">
+      <arg name="interface" type="s"/><arg name="changed" type="a{sv}"/><arg name="invalidated" type="as"/>
+    </signal>
+  </interface>
+  <interface name="org.mpris.MediaPlayer2">
+    <method name="Raise"/>
+    <method name="Quit"/>
+    <property name="CanQuit" type="b" access="read"/>
+    <property name="CanRaise" type="b" access="read"/>
+    <property name="CanSetFullscreen" type="b" access="read"/>
+    <property name="HasTrackList" type="b" access="read"/>
+    <property name="Identity" type="s" access="read"/>
+    <property name="SupportedUriSchemes" type="as" access="read"/>
+    <property name="SupportedMimeTypes" type="as" access="read"/>
+  </interface>
+  <interface name="org.mpris.MediaPlayer2.Player">
+    <method name="Next"/>
+    <method name="Previous"/>
+    <method name="Pause"/>
+    <method name="PlayPause"/>
+    <method name="Stop"/>
+    <method name="Play"/>
+    <method name="Seek"><arg name="offset" type="x" direction="in"/></method>
+    <method name="SetPosition">
+      <arg name="track" type="o" direction="in"/><arg name="position" type="x" direction="in"/>
+    </method>
+    <method name="OpenUri"><arg name="uri" type="s" direction="in"/></method>
+    <signal name="Seeked"><arg name="position" type="x"/></signal>
+    <property name="PlaybackStatus" type="s" access="read"/>
+    <property name="Rate" type="d" access="read"/>
+    <property name="Metadata" type="a{sv}" access="read"/>
+    <property name="Volume" type="d" access="read"/>
+    <property name="Position" type="x" access="read"/>
+    <property name="MinimumRate" type="d" access="read"/>
+    <property name="MaximumRate" type="d" access="read"/>
+    <property name="CanGoNext" type="b" access="read"/>
+    <property name="CanGoPrevious" type="b" access="read"/>
+    <property name="CanPlay" type="b" access="read"/>
+    <property name="CanPause" type="b" access="read"/>
+    <property name="CanSeek" type="b" access="read"/>
+    <property name="CanControl" type="b" access="read"/>
+  </interface>
+</node>
+`
@@ -0,0 +1,132 @@
+package mpris
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestBus runs a private session bus, skipping when dbus-daemon is not
+// installed.
+func startTestBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	socket := filepath.Join(t.TempDir(), "bus")
+	config := filepath.Join(t.TempDir(), "session.conf")
+	if err := writeTestBusConfig(config, socket); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("starting dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	if _, err := bufio.NewReader(out).ReadString('\n'); err != nil {
+		t.Skipf("dbus-daemon did not start: %v", err)
+	}
+	return socket
+}
+
+func writeTestBusConfig(path, socket string) error {
+	return os.WriteFile(path, []byte(`<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=`+socket+`</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`), 0o644)
+}
+
+func TestPlayerOverSessionBus(t *testing.T) {
+	socket := startTestBus(t)
+	commands := make(chan Command, 4)
+	player, err := connectAddress(socket, "nv", func(c Command) { commands <- c })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer player.Close()
+	if player.BusName() != "org.mpris.MediaPlayer2.nv" {
+		t.Fatalf("bus name = %q", player.BusName())
+	}
+	second, err := connectAddress(socket, "nv", func(Command) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(second.BusName(), "org.mpris.MediaPlayer2.nv.instance") {
+		t.Fatalf("second bus name = %q", second.BusName())
+	}
+	second.Close()
+
+	player.SetStatus(Status{Title: "02.png", Album: "book.zip", Page: 2, Total: 5})
+
+	client, err := dial(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := client.call(player.BusName(), objectPath, playerInterface, "Next", ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cmd := <-commands:
+		if cmd != CommandNext {
+			t.Fatalf("command = %q, want next", cmd)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command received")
+	}
+
+	reply, err := client.call(player.BusName(), objectPath, propsInterface, "Get", "ss", playerInterface, "Metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := reply[0].(Variant).Value.(map[string]Variant)
+	if metadata["xesam:title"].Value != "02.png" || metadata["xesam:album"].Value != "book.zip" {
+		t.Fatalf("metadata = %v", metadata)
+	}
+
+	reply, err = client.call(player.BusName(), objectPath, propsInterface, "GetAll", "s", rootInterface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all := reply[0].(map[string]Variant); all["Identity"].Value != "nv" {
+		t.Fatalf("root properties = %v", all)
+	}
+	player.SetStatus(Status{Title: "02.png", Album: "book.zip", Page: 2, Total: 5, QuitLocked: true})
+	reply, err = client.call(player.BusName(), objectPath, propsInterface, "Get", "ss", rootInterface, "CanQuit")
+	if err != nil || reply[0].(Variant).Value != false {
+		t.Fatalf("CanQuit while locked = %v, %v", reply, err)
+	}
+
+	if _, err := client.call(player.BusName(), objectPath, propsInterface, "Get", "ss", playerInterface, "Nope"); err == nil ||
+		!strings.Contains(err.Error(), errUnknownProperty) {
+		t.Fatalf("unknown property error = %v", err)
+	}
+
+	reply, err = client.call(player.BusName(), objectPath, "org.freedesktop.DBus.Introspectable", "Introspect", "")
+	if err != nil || !strings.Contains(reply[0].(string), playerInterface) {
+		t.Fatalf("introspect = %v, %v", reply, err)
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+// keyJustPressed and keyHeld read the keyboard. Tests replace them.
+var (
+	keyJustPressed = inpututil.IsKeyJustPressed
+	keyHeld        = ebiten.IsKeyPressed
+)
+
 // KeybindingManager handles dynamic keybinding processing
 type KeybindingManager struct {
 	keybindings map[string][]string
@@ -65,29 +71,34 @@ func (km *KeybindingManager) parseKeyString(keyStr string) (*KeyCombination, boo
 // isKeyPressed checks if a key combination is currently being pressed
 func (km *KeybindingManager) isKeyPressed(combination *KeyCombination) bool {
 	// Check if the main key was just pressed
-	if !inpututil.IsKeyJustPressed(combination.Key) {
+	if !keyJustPressed(combination.Key) {
 		return false
 	}
+	return modifiersMatch(combination)
+}
 
+// modifiersMatch reports whether exactly the modifiers of combination are
+// held.
+func modifiersMatch(combination *KeyCombination) bool {
 	// Check modifiers
-	if combination.Shift && !ebiten.IsKeyPressed(ebiten.KeyShift) {
+	if combination.Shift && !keyHeld(ebiten.KeyShift) {
 		return false
 	}
-	if combination.Ctrl && !ebiten.IsKeyPressed(ebiten.KeyControl) {
+	if combination.Ctrl && !keyHeld(ebiten.KeyControl) {
 		return false
 	}
-	if combination.Alt && !ebiten.IsKeyPressed(ebiten.KeyAlt) {
+	if combination.Alt && !keyHeld(ebiten.KeyAlt) {
 		return false
 	}
 
 	// Check that unwanted modifiers aren't pressed
-	if !combination.Shift && ebiten.IsKeyPressed(ebiten.KeyShift) {
+	if !combination.Shift && keyHeld(ebiten.KeyShift) {
 		return false
 	}
-	if !combination.Ctrl && ebiten.IsKeyPressed(ebiten.KeyControl) {
+	if !combination.Ctrl && keyHeld(ebiten.KeyControl) {
 		return false
 	}
-	if !combination.Alt && ebiten.IsKeyPressed(ebiten.KeyAlt) {
+	if !combination.Alt && keyHeld(ebiten.KeyAlt) {
 		return false
 	}
 
@@ -111,6 +122,18 @@ func (km *KeybindingManager) CheckAction(action string) bool {
 	return false
 }
 
+// IsActionHeld reports whether a key bound to action is held down, unlike
+// CheckAction which only fires on the press.
+func (km *KeybindingManager) IsActionHeld(action string) bool {
+	for _, keyStr := range km.keybindings[action] {
+		combination, valid := km.parseKeyString(keyStr)
+		if valid && keyHeld(combination.Key) && modifiersMatch(combination) {
+			return true
+		}
+	}
+	return false
+}
+
 // ExecuteAction executes the given action using the InputActions interface
 func (km *KeybindingManager) ExecuteAction(action string, inputActions InputActions, inputState InputState) bool {
 	if !km.CheckAction(action) {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// kioskActions are the actions left to visitors while kiosk mode is locked:
+// looking around, but no quitting, opening, editing or reconfiguring.
+var kioskActions = map[string]bool{
+	"kiosk_unlock":     true,
+	"help":             true,
+	"info":             true,
+	"next":             true,
+	"previous":         true,
+	"next_single":      true,
+	"previous_single":  true,
+	"page_input":       true,
+	"jump_first":       true,
+	"jump_last":        true,
+	"history_back":     true,
+	"history_forward":  true,
+	"percent_forward":  true,
+	"percent_back":     true,
+	"next_chapter":     true,
+	"previous_chapter": true,
+	"slideshow":        true,
+	"animation_pause":  true,
+	"zoom_in":          true,
+	"zoom_out":         true,
+	"zoom_reset":       true,
+	"zoom_fit":         true,
+	"pan_up":           true,
+	"pan_down":         true,
+	"pan_left":         true,
+	"pan_right":        true,
+	"pan_page_up":      true,
+	"pan_page_down":    true,
+	"smart_next":       true,
+	"smart_previous":   true,
+}
+
+// kioskActionAllowed reports whether action may run in the given lock state.
+func kioskActionAllowed(action string, locked bool) bool {
+	return !locked || kioskActions[action]
+}
+
+// startKiosk locks the viewer for an unattended display. The slideshow,
+// when configured, loops instead of stopping on the last page.
+func (g *Game) startKiosk() {
+	g.kiosk = true
+	g.kioskLocked = true
+	if g.config.KioskSlideshow {
+		g.setSlideshow(true)
+	}
+	infoKV("kiosk", "started", "slideshow", g.config.KioskSlideshow)
+}
+
+// toggleKioskLock lets an operator leave and re-enter the locked state.
+func (g *Game) toggleKioskLock() {
+	if !g.kiosk {
+		return
+	}
+	g.kioskLocked = !g.kioskLocked
+	if g.kioskLocked {
+		g.showOverlayMessage("Kiosk locked")
+	} else {
+		g.showOverlayMessage("Kiosk unlocked")
+	}
+	infoKV("kiosk", "lock_changed", "locked", g.kioskLocked)
+}
+
+func (g *Game) IsKioskLocked() bool {
+	return g.kioskLocked
+}
+
+// handleWindowClose quits on the window's close button unless kiosk mode
+// is locked or unwritten marks need a confirming second close. Closing is
+// only intercepted in kiosk mode or while such marks exist.
+func (g *Game) handleWindowClose() {
+	handled := g.kiosk || g.unwrittenMarks()
+	if handled != g.closeHandled {
+		ebiten.SetWindowClosingHandled(handled)
+		g.closeHandled = handled
+	}
+	if !handled || !ebiten.IsWindowBeingClosed() {
+		return
+	}
+	if g.kioskLocked {
+		debugKV("kiosk", "close_blocked")
+		return
+	}
+	if !g.confirmQuit(time.Now()) {
+		return
+	}
+	g.exitRequested = true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image"
+
+	"nv/internal/imgdecode"
+)
+
+// SetLevels changes the adjustment applied to 16-bit images and drops cached
+// adjusted images so they are decoded again.
+func (m *DefaultImageManager) SetLevels(l imgdecode.Levels) {
+	m.adjustMu.Lock()
+	changed := m.levels != l
+	m.levels = l
+	m.adjustMu.Unlock()
+	if !changed {
+		return
+	}
+	purged := m.purgeAdjustedImages()
+	debugKV("cache", "levels_changed",
+		"exposure", l.Exposure,
+		"stretch", l.Stretch,
+		"purged", purged,
+	)
+}
+
+func (m *DefaultImageManager) currentLevels() imgdecode.Levels {
+	m.adjustMu.RLock()
+	defer m.adjustMu.RUnlock()
+	return m.levels
+}
+
+// createDisplayImageFromDecoded reduces 16-bit images to 8 bits with the
+// current levels before upload; Ebiten textures are 8 bits per channel, so
+// this is the last point where the extra precision is available.
+func (m *DefaultImageManager) createDisplayImageFromDecoded(src image.Image, p ImagePath) (DisplayImage, error) {
+	if src == nil || !imgdecode.IsHighBitDepth(src) {
+		return m.createEbitenImageFromDecoded(src, p)
+	}
+	return m.createAdjustedImage(m.currentLevels().Apply(src), p)
+}
+
+// createAdjustedImage uploads an image whose pixels depend on tone mapping
+// or levels, remembering it so a settings change can drop it from the cache.
+func (m *DefaultImageManager) createAdjustedImage(src image.Image, p ImagePath) (DisplayImage, error) {
+	img, err := m.createEbitenImageFromDecoded(src, p)
+	if err != nil {
+		return nil, err
+	}
+	m.adjusted.Store(img, struct{}{})
+	return img, nil
+}
+
+// purgeAdjustedImages removes adjusted images from the cache and returns how
+// many were dropped.
+func (m *DefaultImageManager) purgeAdjustedImages() int {
+	purged := 0
+	for _, key := range m.cache.Keys() {
+		img, ok := m.cache.Peek(key)
+		if !ok {
+			continue
+		}
+		if _, adjusted := m.adjusted.Load(img); adjusted {
+			m.cache.Remove(key)
+			purged++
+		}
+	}
+	return purged
+}
+
+func (g *Game) levels() imgdecode.Levels {
+	return imgdecode.Levels{Exposure: g.hdrExposure, Stretch: g.levelsStretch}
+}
+
+func (g *Game) toggleLevelsStretch() {
+	g.levelsStretch = !g.levelsStretch
+	g.applyToneMapping()
+	if g.levelsStretch {
+		g.showOverlayMessage("Levels: Auto stretch")
+	} else {
+		g.showOverlayMessage("Levels: Full range")
+	}
+	debugKV("renderer", "levels_stretch", "enabled", g.levelsStretch)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// pageLoadStats is how a page got into the cache, for the debug overlay.
+type pageLoadStats struct {
+	Source       string        // "disk" when shown on demand, "preload" or "prefetch"
+	Cached       bool          // Shown again from the cache since it was loaded
+	Total        time.Duration // Reading, decoding and uploading together
+	Upload       time.Duration // Creating the GPU textures
+	EncodedBytes int64         // Size of the file or archive entry
+	DecodedBytes int64         // Texture memory of the decoded page
+
+	shown bool // Came on screen since it was loaded
+}
+
+// Decode is the load time spent before the upload: reading, decompressing
+// and decoding.
+func (s pageLoadStats) Decode() time.Duration {
+	return max(0, s.Total-s.Upload)
+}
+
+// loadDiagnostics collects pageLoadStats in debug mode. Loads in progress
+// are kept by the whole ImagePath, so equally named entries of different
+// archives loading at once stay apart, and filed by page path once they
+// finish.
+type loadDiagnostics struct {
+	mu      sync.Mutex
+	pending map[ImagePath]*pageLoadStats
+	pages   map[string]pageLoadStats
+}
+
+// loadOrigin is the name the decoding helpers show in messages for p.
+func loadOrigin(p ImagePath) string {
+	if p.ArchivePath != "" {
+		return p.EntryPath
+	}
+	return p.Path
+}
+
+func (d *loadDiagnostics) begin(p ImagePath, source string) {
+	if !debugMode.Load() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pending == nil {
+		d.pending = make(map[ImagePath]*pageLoadStats)
+	}
+	d.pending[p] = &pageLoadStats{Source: source}
+}
+
+// note updates the load in progress for p, if any.
+func (d *loadDiagnostics) note(p ImagePath, update func(*pageLoadStats)) {
+	if !debugMode.Load() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if stats, ok := d.pending[p]; ok {
+		update(stats)
+	}
+}
+
+// show records that the page at path came on screen, reporting whether its
+// load is known. Coming on screen again without a new load means the page
+// was served from the cache.
+func (d *loadDiagnostics) show(path string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats, ok := d.pages[path]
+	if !ok {
+		return false
+	}
+	if stats.shown {
+		stats.Cached = true
+	}
+	stats.shown = true
+	d.pages[path] = stats
+	return true
+}
+
+// finish files the load of p; img is nil when it failed.
+func (d *loadDiagnostics) finish(p ImagePath, elapsed time.Duration, img DisplayImage) {
+	if !debugMode.Load() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats, ok := d.pending[p]
+	delete(d.pending, p)
+	if !ok || img == nil {
+		return
+	}
+	stats.Total = elapsed
+	stats.DecodedBytes = displayImageBytes(img)
+	if stats.EncodedBytes == 0 && p.ArchivePath == "" {
+		// Decoded straight from the file without reading it into memory
+		if info, err := os.Stat(p.Path); err == nil {
+			stats.EncodedBytes = info.Size()
+		}
+	}
+	if d.pages == nil {
+		d.pages = make(map[string]pageLoadStats)
+	}
+	d.pages[p.Path] = *stats
+}
+
+func (d *loadDiagnostics) get(path string) (pageLoadStats, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats, ok := d.pages[path]
+	return stats, ok
+}
+
+// formatLoadBytes formats a size for the debug overlay, e.g. "840 KB".
+func formatLoadBytes(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%d KB", (n+1<<10-1)>>10)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+func (s pageLoadStats) String() string {
+	source := s.Source
+	if s.Cached {
+		source = "cache (" + s.Source + ")"
+	}
+	return fmt.Sprintf("%s, decode %dms, upload %dms, %s → %s",
+		source, s.Decode().Milliseconds(), s.Upload.Milliseconds(),
+		formatLoadBytes(s.EncodedBytes), formatLoadBytes(s.DecodedBytes))
+}
+
+// GetLoadDiagnostics describes how each page on screen was loaded, in
+// debug mode only, e.g. "p12 preload, decode 84ms, upload 6ms, 1.2 MB →
+// 31.6 MB", or "p12 cache (preload), ..." when the page is shown again
+// without loading. Pages decoded before debug mode was on show nothing.
+func (g *Game) GetLoadDiagnostics() string {
+	dm, ok := g.imageManager.(*DefaultImageManager)
+	if !debugMode.Load() || !ok || g.displayContent == nil {
+		return ""
+	}
+	var parts, shown []string
+	for _, page := range []int{g.displayContent.Metadata.LeftPage, g.displayContent.Metadata.RightPage} {
+		p, ok := g.imageManager.GetPath(page - 1)
+		if page <= 0 || !ok || slices.Contains(shown, p.Path) {
+			continue
+		}
+		if slices.Contains(g.loadDiagnosticsShown, p.Path) || dm.diagnostics.show(p.Path) {
+			shown = append(shown, p.Path)
+		}
+		if stats, ok := dm.diagnostics.get(p.Path); ok {
+			parts = append(parts, fmt.Sprintf("p%d %s", page, stats))
+		}
+	}
+	g.loadDiagnosticsShown = shown
+	return strings.Join(parts, "  |  ")
+}
+
+// drawLoadDiagnostics shows GetLoadDiagnostics in the bottom left corner,
+// clear of the info display and the next page preview.
+func (r *Renderer) drawLoadDiagnostics(screen *ebiten.Image) {
+	s := r.renderState.GetLoadDiagnostics()
+	if s == "" {
+		return
+	}
+	panel := r.diagnosticsPanel.render(r, s, r.renderState.GetFontSize()*0.8, 5, bgColorLight)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(10, float64(screen.Bounds().Dy()-panel.Bounds().Dy())-10)
+	screen.DrawImage(panel, op)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// Pages that decode faster than this never show the spinner
+	loadingIndicatorDelay = 100 * time.Millisecond
+
+	loadingSpinnerDots  = 8
+	loadingSpinnerFrame = 90 * time.Millisecond
+
+	loadingSpinnerRadius = 18
+	loadingSpinnerDotR   = 3.5
+)
+
+var loadingSpinnerBackground = color.NRGBA{0, 0, 0, 150}
+
+// isLoadingPlaceholder reports whether img is the stand-in GetImage returns
+// while the page is still being decoded.
+func (m *DefaultImageManager) isLoadingPlaceholder(img DisplayImage) bool {
+	return img != nil && img == m.loadingPlaceholder
+}
+
+// pageLoading reports whether a page on screen is still being decoded,
+// e.g. from a large archive or a network share.
+func (g *Game) pageLoading() bool {
+	dm, ok := g.imageManager.(*DefaultImageManager)
+	if !ok || g.displayContent == nil {
+		return false
+	}
+	return dm.isLoadingPlaceholder(g.displayContent.LeftImage) ||
+		dm.isLoadingPlaceholder(g.displayContent.RightImage)
+}
+
+// updateLoadingIndicator shows the spinner once a page on screen has been
+// loading for loadingIndicatorDelay, so slow media does not look frozen.
+// It reports whether the spinner appeared, moved, or went away.
+func (g *Game) updateLoadingIndicator(now time.Time) bool {
+	wasVisible, prevStep := g.loadingVisible, g.loadingStep
+	if !g.pageLoading() {
+		g.loadingSince = time.Time{}
+		g.loadingVisible = false
+		g.loadingStep = 0
+		return wasVisible
+	}
+	if g.loadingSince.IsZero() {
+		g.loadingSince = now
+	}
+	waited := now.Sub(g.loadingSince)
+	if waited < loadingIndicatorDelay {
+		return false
+	}
+	g.loadingVisible = true
+	g.loadingStep = int((waited-loadingIndicatorDelay)/loadingSpinnerFrame) % loadingSpinnerDots
+	if !wasVisible {
+		debugKV("cache", "loading_indicator_shown", "idx", g.idx, "waited_ms", waited.Milliseconds())
+	}
+	return !wasVisible || g.loadingStep != prevStep
+}
+
+// GetLoadingIndicator returns the spinner frame while a page is loading.
+func (g *Game) GetLoadingIndicator() (int, bool) {
+	return g.loadingStep, g.loadingVisible
+}
+
+// loadingIndicatorStep is the spinner frame for the render snapshot, -1
+// while it is hidden.
+func loadingIndicatorStep(step int, visible bool) int {
+	if !visible {
+		return -1
+	}
+	return step
+}
+
+// drawLoadingIndicator draws a ring of dots in the middle of the screen,
+// the brightest one going round as the frames advance.
+func (r *Renderer) drawLoadingIndicator(screen *ebiten.Image) {
+	step, ok := r.renderState.GetLoadingIndicator()
+	if !ok {
+		return
+	}
+	cx, cy := float32(screen.Bounds().Dx())/2, float32(screen.Bounds().Dy())/2
+	vector.DrawFilledCircle(screen, cx, cy, loadingSpinnerRadius+loadingSpinnerDotR*3, loadingSpinnerBackground, true)
+	for i := range loadingSpinnerDots {
+		angle := 2*math.Pi*float64(i)/loadingSpinnerDots - math.Pi/2
+		x := cx + loadingSpinnerRadius*float32(math.Cos(angle))
+		y := cy + loadingSpinnerRadius*float32(math.Sin(angle))
+		// Dots trail off behind the current one
+		age := (step - i + loadingSpinnerDots) % loadingSpinnerDots
+		alpha := uint8(255 - age*200/loadingSpinnerDots)
+		vector.DrawFilledCircle(screen, x, y, loadingSpinnerDotR, color.NRGBA{255, 255, 255, alpha}, true)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 type logLevel string
@@ -18,37 +21,91 @@ const (
 	logLevelFatal logLevel = "fatal"
 )
 
+// logLevels are the levels log_level accepts, least severe first.
+var logLevels = []logLevel{logLevelDebug, logLevelInfo, logLevelWarn, logLevelError}
+
+// logThreshold holds the least severe level written; it is read from every
+// goroutine that logs, so it is atomic. Debug lines are written only in
+// debug mode, which log_level "debug" turns on as -d does.
+var logThreshold atomic.Value // logLevel
+
+// currentLogThreshold returns the level set by setLogLevel, "info" before.
+func currentLogThreshold() logLevel {
+	if level, ok := logThreshold.Load().(logLevel); ok {
+		return level
+	}
+	return logLevelInfo
+}
+
+const (
+	logFileName    = "nv.log"
+	logRotateBytes = 5 << 20 // Size at which the log file is rotated
+	logBackups     = 3       // Rotated files kept as nv.log.1 … nv.log.3
+)
+
 func debugKV(component, event string, kv ...any) {
-	if !debugMode {
+	if !debugMode.Load() {
 		return
 	}
 	log.Print(formatLogLine(logLevelDebug, component, event, kv...))
 }
 
 func infoKV(component, event string, kv ...any) {
-	log.Print(formatLogLine(logLevelInfo, component, event, kv...))
+	if threshold := currentLogThreshold(); threshold == logLevelInfo || threshold == logLevelDebug {
+		log.Print(formatLogLine(logLevelInfo, component, event, kv...))
+	}
 }
 
 func warnKV(component, event string, kv ...any) {
-	log.Print(formatLogLine(logLevelWarn, component, event, kv...))
+	if currentLogThreshold() != logLevelError {
+		log.Print(formatLogLine(logLevelWarn, component, event, kv...))
+	}
 }
 
 func errorKV(component, event string, kv ...any) {
 	log.Print(formatLogLine(logLevelError, component, event, kv...))
 }
 
+// validLogLevel reports whether level is accepted by log_level.
+func validLogLevel(level string) bool {
+	for _, l := range logLevels {
+		if string(l) == level {
+			return true
+		}
+	}
+	return false
+}
+
+// setLogLevel applies the log_level setting; "debug" enables debug lines
+// even without -d. Unknown levels mean "info".
+func setLogLevel(level string) {
+	threshold := logLevelInfo
+	if validLogLevel(level) {
+		threshold = logLevel(level)
+	}
+	logThreshold.Store(threshold)
+	debugMode.Store(debugFlag.Load() || threshold == logLevelDebug)
+}
+
+// logPathForConfig places the log file in the state directory.
+func logPathForConfig(configPath string) string {
+	return filepath.Join(stateDirForConfig(configPath), logFileName)
+}
+
 func fatalKV(component, event string, kv ...any) {
 	log.Print(formatLogLine(logLevelFatal, component, event, kv...))
 	os.Exit(1)
 }
 
-func configureLogOutput(path string) (*os.File, error) {
+// configureLogOutput writes logs to the console and, when path is set, to
+// a log file there that is rotated once it grows past logRotateBytes.
+func configureLogOutput(path string) (*rotatingLogFile, error) {
 	if path == "" {
 		log.SetOutput(os.Stderr)
 		return nil, nil
 	}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	file, err := openRotatingLogFile(path, logRotateBytes, logBackups)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +114,81 @@ func configureLogOutput(path string) (*os.File, error) {
 	return file, nil
 }
 
+// rotatingLogFile appends to a log file, moving it to path.1 (and older
+// files one number up) when a write would take it past maxSize.
+type rotatingLogFile struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+	backups int
+}
+
+func openRotatingLogFile(path string, maxSize int64, backups int) (*rotatingLogFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &rotatingLogFile{path: path, file: file, size: info.Size(), maxSize: maxSize, backups: backups}, nil
+}
+
+func (f *rotatingLogFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one, dropping the oldest, and starts an
+// empty log file.
+func (f *rotatingLogFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	for i := f.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.backups > 0 {
+		os.Rename(f.path, f.path+".1")
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		f.file = nil
+		return err
+	}
+	f.file, f.size = file, 0
+	return nil
+}
+
+func (f *rotatingLogFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
 func formatLogLine(level logLevel, component, event string, kv ...any) string {
 	line := "level=" + string(level)
 	line += " component=" + formatLogValue(component)
@@ -10,7 +10,6 @@ import (
 	"testing"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	lru "github.com/hashicorp/golang-lru/v2"
 	"nv/navlogic"
 )
 
@@ -55,14 +54,14 @@ func TestDebugKVRespectsDebugMode(t *testing.T) {
 	var buf bytes.Buffer
 	prevWriter := log.Writer()
 	prevFlags := log.Flags()
-	prevDebugMode := debugMode
+	prevDebugMode := debugMode.Load()
 	log.SetOutput(&buf)
 	log.SetFlags(0)
-	debugMode = false
+	debugMode.Store(false)
 	t.Cleanup(func() {
 		log.SetOutput(prevWriter)
 		log.SetFlags(prevFlags)
-		debugMode = prevDebugMode
+		debugMode.Store(prevDebugMode)
 	})
 
 	debugKV("input", "action", "source", "keyboard")
@@ -70,7 +69,7 @@ func TestDebugKVRespectsDebugMode(t *testing.T) {
 		t.Fatalf("expected no debug output, got %q", buf.String())
 	}
 
-	debugMode = true
+	debugMode.Store(true)
 	debugKV("input", "action", "source", "keyboard")
 	got := buf.String()
 	if !strings.Contains(got, `level=debug component="input" event="action"`) {
@@ -85,14 +84,14 @@ func TestInfoWarnErrorAlwaysLog(t *testing.T) {
 	var buf bytes.Buffer
 	prevWriter := log.Writer()
 	prevFlags := log.Flags()
-	prevDebugMode := debugMode
+	prevDebugMode := debugMode.Load()
 	log.SetOutput(&buf)
 	log.SetFlags(0)
-	debugMode = false
+	debugMode.Store(false)
 	t.Cleanup(func() {
 		log.SetOutput(prevWriter)
 		log.SetFlags(prevFlags)
-		debugMode = prevDebugMode
+		debugMode.Store(prevDebugMode)
 	})
 
 	infoKV("config", "loaded", "path", "/tmp/config.json")
@@ -147,16 +146,7 @@ func TestLogDisplayPlanEmitsStructuredNavigationEvents(t *testing.T) {
 }
 
 func TestDefaultImageManagerGetImageLogsCacheMiss(t *testing.T) {
-	cache, err := lru.NewWithEvict[string, DisplayImage](2, func(_ string, img DisplayImage) {
-		if img != nil {
-			img.Deallocate()
-		}
-	})
-	if err != nil {
-		t.Fatalf("failed to create cache: %v", err)
-	}
-
-	manager := newDefaultImageManager(cache)
+	manager := newDefaultImageManager(2)
 	t.Cleanup(func() {
 		manager.StopPreload()
 	})
@@ -213,20 +203,81 @@ func TestConfigureLogOutputWritesToFile(t *testing.T) {
 	}
 }
 
+func TestRotatingLogFileKeepsBackups(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "nv.log")
+	file, err := openRotatingLogFile(logPath, 10, 2)
+	if err != nil {
+		t.Fatalf("openRotatingLogFile() error = %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error = %v", line, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		logPath:        "fourth\n",
+		logPath + ".1": "third\n",
+		logPath + ".2": "second\n",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		if string(data) != want {
+			t.Fatalf("%s = %q, want %q", path, data, want)
+		}
+	}
+	if _, err := os.Stat(logPath + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only 2 backups, stat .3 error = %v", err)
+	}
+}
+
+func TestSetLogLevelFiltersLessSevereLines(t *testing.T) {
+	prevThreshold, prevDebugFlag, prevDebugMode := currentLogThreshold(), debugFlag.Load(), debugMode.Load()
+	debugFlag.Store(false)
+	t.Cleanup(func() {
+		logThreshold.Store(prevThreshold)
+		debugFlag.Store(prevDebugFlag)
+		debugMode.Store(prevDebugMode)
+	})
+
+	setLogLevel("warn")
+	got := captureLogOutput(t, debugMode.Load(), func() {
+		infoKV("config", "loaded")
+		warnKV("config", "fallback")
+	})
+	if strings.Contains(got, `event="loaded"`) || !strings.Contains(got, `event="fallback"`) {
+		t.Fatalf("warn level output = %q", got)
+	}
+
+	setLogLevel("debug")
+	if !debugMode.Load() {
+		t.Fatal("log_level debug should enable debug mode")
+	}
+	setLogLevel("bogus")
+	if currentLogThreshold() != logLevelInfo || debugMode.Load() {
+		t.Fatalf("unknown level: threshold = %q, debug = %v", currentLogThreshold(), debugMode.Load())
+	}
+}
+
 func captureLogOutput(t *testing.T, debug bool, fn func()) string {
 	t.Helper()
 
 	var buf bytes.Buffer
 	prevWriter := log.Writer()
 	prevFlags := log.Flags()
-	prevDebugMode := debugMode
+	prevDebugMode := debugMode.Load()
 	log.SetOutput(&buf)
 	log.SetFlags(0)
-	debugMode = debug
+	debugMode.Store(debug)
 	t.Cleanup(func() {
 		log.SetOutput(prevWriter)
 		log.SetFlags(prevFlags)
-		debugMode = prevDebugMode
+		debugMode.Store(prevDebugMode)
 	})
 
 	fn()
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// quitConfirmWindow is how long a quit that warned about unwritten marks
+// waits for the confirming second quit.
+const quitConfirmWindow = 3 * time.Second
+
+// toggleMark marks or unmarks the current page for the list written by
+// accept_marked, e.g. the photos to keep from a shoot.
+func (g *Game) toggleMark() {
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok || g.refusePastedPage(p, "marked") {
+		return
+	}
+	if g.marks == nil {
+		g.marks = map[string]bool{}
+	}
+	if g.marks[p.Path] {
+		delete(g.marks, p.Path)
+		g.showOverlayMessage(fmt.Sprintf("Unmarked (%d marked)", len(g.marks)))
+	} else {
+		g.marks[p.Path] = true
+		g.showOverlayMessage(fmt.Sprintf("Marked (%d marked)", len(g.marks)))
+	}
+	debugKV("marks", "toggled", "path", p.Path, "marked", g.marks[p.Path], "count", len(g.marks))
+	g.calculateDisplayContent()
+}
+
+// markedPaths returns the marked pages in collection order. Marks on pages
+// no longer in the collection, e.g. after opening another folder, are kept;
+// renaming a page moves its mark and deleting it drops the mark until undo.
+func (g *Game) markedPaths() []string {
+	paths := make([]string, 0, len(g.marks))
+	seen := map[string]bool{}
+	for i := range g.imageManager.GetPathsCount() {
+		if p, ok := g.imageManager.GetPath(i); ok && g.marks[p.Path] && !seen[p.Path] {
+			paths = append(paths, p.Path)
+			seen[p.Path] = true
+		}
+	}
+	var rest []string
+	for path := range g.marks {
+		if !seen[path] {
+			rest = append(rest, path)
+		}
+	}
+	slices.Sort(rest)
+	return append(paths, rest...)
+}
+
+// acceptMarked quits so the marked paths can be written out.
+func (g *Game) acceptMarked() {
+	if len(g.marks) == 0 {
+		g.showOverlayMessage("No marked images")
+		return
+	}
+	g.acceptedMarks = g.markedPaths()
+	infoKV("marks", "accepted", "count", len(g.acceptedMarks))
+	g.exitRequested = true
+}
+
+// marksWrittenOnExit reports whether --marked-file names a file, which
+// receives the marks on every exit rather than only on accept_marked.
+func (g *Game) marksWrittenOnExit() bool {
+	return g.markedFile != "" && g.markedFile != "-"
+}
+
+// unwrittenMarks reports whether quitting now would lose the marks.
+func (g *Game) unwrittenMarks() bool {
+	return len(g.marks) > 0 && g.acceptedMarks == nil && !g.marksWrittenOnExit()
+}
+
+// confirmQuit reports whether a quit may go ahead. With unwritten marks the
+// first quit only warns; a second one within quitConfirmWindow discards them.
+func (g *Game) confirmQuit(now time.Time) bool {
+	if !g.unwrittenMarks() {
+		return true
+	}
+	if !g.quitArmedAt.IsZero() && now.Sub(g.quitArmedAt) <= quitConfirmWindow {
+		infoKV("marks", "discarded", "count", len(g.marks))
+		return true
+	}
+	g.quitArmedAt = now
+	g.showOverlayMessage(fmt.Sprintf("%d marked images not written: quit again to discard, or accept_marked to write them", len(g.marks)))
+	debugKV("marks", "quit_armed", "count", len(g.marks))
+	return false
+}
+
+// writeMarksOnExit writes every mark to --marked-file when the viewer quits
+// without accept_marked, so closing the window keeps a culling session.
+func (g *Game) writeMarksOnExit(stdout io.Writer) {
+	if g.acceptedMarks != nil || len(g.marks) == 0 || !g.marksWrittenOnExit() {
+		return
+	}
+	writeMarkedPaths(stdout, g.markedFile, g.markedPaths())
+}
+
+// writeMarkedPaths writes paths one per line to the file dest, or to stdout
+// when dest is empty or "-", and returns the exit code.
+func writeMarkedPaths(stdout io.Writer, dest string, paths []string) int {
+	data := strings.Join(paths, "\n") + "\n"
+	if dest == "" || dest == "-" {
+		if _, err := io.WriteString(stdout, data); err != nil {
+			errorKV("marks", "write_failed", "error", err)
+			return selectionCanceled
+		}
+		return selectionAccepted
+	}
+	if err := os.WriteFile(dest, []byte(data), 0644); err != nil {
+		errorKV("marks", "write_failed", "path", dest, "error", err)
+		return selectionCanceled
+	}
+	infoKV("marks", "written", "path", dest, "count", len(paths))
+	return selectionAccepted
+}
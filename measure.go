@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// measureDPIPresets are the values offered by the settings screen; 0 shows
+// pixels only.
+var measureDPIPresets = []int{0, 72, 96, 150, 200, 300, 400, 600, 1200, 2400}
+
+var (
+	measureLineColor    = color.RGBA{255, 230, 0, 255}
+	measureOutlineColor = color.RGBA{0, 0, 0, 160} // Premultiplied
+)
+
+// viewTransform is how the canvas was last placed on screen, recorded by
+// the renderer so screen distances can be converted to image pixels.
+type viewTransform struct {
+	Scale   float64
+	OffsetX float64
+	OffsetY float64
+	Width   float64 // Size of the canvas on screen
+	Height  float64
+}
+
+// measureLine is a ruler drawn in screen coordinates at a given view scale.
+type measureLine struct {
+	Start image.Point
+	End   image.Point
+	Scale float64
+}
+
+// measurement is a ruler converted to image pixels.
+type measurement struct {
+	DX, DY   float64 // Image pixels, right and down positive
+	Distance float64
+	Angle    float64 // Degrees counter-clockwise from the right, -180..180
+}
+
+// measure converts the screen line to image pixels at the view scale.
+func (l measureLine) measure() measurement {
+	scale := l.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	dx := float64(l.End.X-l.Start.X) / scale
+	dy := float64(l.End.Y-l.Start.Y) / scale
+	// Screen y grows downwards; negate before the division so a level line
+	// is +0 and leftwards reads 180 rather than -180
+	up := float64(l.Start.Y-l.End.Y) / scale
+	angle := math.Atan2(up, dx) * 180 / math.Pi
+	return measurement{DX: dx, DY: dy, Distance: math.Hypot(dx, dy), Angle: angle}
+}
+
+// formatMeasurement describes m for the ruler label, adding physical units
+// when dpi is set.
+func formatMeasurement(m measurement, dpi int) string {
+	s := fmt.Sprintf("%.1f px  %.1f°  (%.0f × %.0f)", m.Distance, m.Angle, math.Abs(m.DX), math.Abs(m.DY))
+	if dpi > 0 {
+		inches := m.Distance / float64(dpi)
+		s += fmt.Sprintf("  %.1f mm / %.2f in @ %d dpi", inches*25.4, inches, dpi)
+	}
+	return s
+}
+
+// toggleMeasure enters or leaves ruler mode, in which dragging with the
+// left button measures instead of panning or turning pages.
+func (g *Game) toggleMeasure() {
+	g.measuring = !g.measuring
+	g.measureShown = false
+	if g.measuring {
+		g.showOverlayMessage("Ruler: drag to measure")
+	} else {
+		g.showOverlayMessage("Ruler off")
+	}
+	debugKV("ui", "measure", "active", g.measuring, "dpi", g.config.MeasureDPI)
+}
+
+// measureFrom starts a ruler at the screen position x, y.
+func (g *Game) measureFrom(x, y int) {
+	g.measureStart = image.Pt(x, y)
+	g.measureEnd = g.measureStart
+	g.measureView = g.renderer.view
+	g.measureIdx = g.idx
+	g.measureShown = true
+}
+
+// measureTo moves the end of the ruler, reporting whether it changed.
+func (g *Game) measureTo(x, y int) bool {
+	p := image.Pt(x, y)
+	if !g.measureShown || p == g.measureEnd {
+		return false
+	}
+	g.measureEnd = p
+	return true
+}
+
+// updateMeasure drops the ruler once the page or the view under it has
+// changed, since its screen points no longer match the image. It reports
+// whether the ruler was removed.
+func (g *Game) updateMeasure() bool {
+	if !g.measureShown || (g.idx == g.measureIdx && g.renderer.view == g.measureView) {
+		return false
+	}
+	g.measureShown = false
+	debugKV("ui", "measure_cleared", "idx", g.idx, "scale", g.renderer.view.Scale)
+	return true
+}
+
+// GetMeasureLine returns the ruler while one has been dragged out.
+func (g *Game) GetMeasureLine() (measureLine, bool) {
+	if !g.measuring || !g.measureShown || g.measureStart == g.measureEnd {
+		return measureLine{}, false
+	}
+	return measureLine{Start: g.measureStart, End: g.measureEnd, Scale: g.measureView.Scale}, true
+}
+
+func (g *Game) IsMeasuring() bool {
+	return g.measuring
+}
+
+func (g *Game) GetMeasureDPI() int {
+	return g.config.MeasureDPI
+}
+
+func (r *Renderer) drawMeasureLine(screen *ebiten.Image) {
+	l, ok := r.renderState.GetMeasureLine()
+	if !ok {
+		return
+	}
+	x0, y0 := float32(l.Start.X), float32(l.Start.Y)
+	x1, y1 := float32(l.End.X), float32(l.End.Y)
+	vector.StrokeLine(screen, x0, y0, x1, y1, 4, measureOutlineColor, true)
+	vector.StrokeLine(screen, x0, y0, x1, y1, 2, measureLineColor, true)
+	for _, p := range []image.Point{l.Start, l.End} {
+		vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), 4, measureLineColor, true)
+	}
+
+	label := formatMeasurement(l.measure(), r.renderState.GetMeasureDPI())
+	face := r.face(r.renderState.GetFontSize() * 0.75)
+	tw, th := measureText(label, face)
+	padding := 8.0
+	boxW, boxH := tw+padding*2, th+padding*2
+	// Keep the label next to the end point but inside the screen
+	sw, sh := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	boxX := math.Max(0, math.Min(float64(l.End.X)+12, sw-boxW))
+	boxY := math.Max(0, math.Min(float64(l.End.Y)+12, sh-boxH))
+	DrawFilledRect(screen, boxX, boxY, boxW, boxH, bgColorDark)
+	DrawText(screen, label, face, boxX+padding, boxY+padding, colorWhite)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+
+	"nv/internal/mpris"
+)
+
+const mediaCommandBacklog = 8
+
+// startMediaControls registers the viewer as an MPRIS player on Linux so
+// media keys and presentation remotes can turn pages. A missing session bus
+// is not an error worth showing.
+func (g *Game) startMediaControls() {
+	if g.mediaPlayer != nil || !g.config.MediaControls || runtime.GOOS != "linux" {
+		return
+	}
+	commands := make(chan mpris.Command, mediaCommandBacklog)
+	player, err := mpris.Connect("nv", func(cmd mpris.Command) {
+		select {
+		case commands <- cmd:
+		default:
+			debugKV("media", "command_dropped", "command", cmd)
+		}
+	})
+	if err != nil {
+		debugKV("media", "mpris_unavailable", "error", err)
+		return
+	}
+	g.mediaPlayer = player
+	g.mediaCommands = commands
+	infoKV("media", "mpris_registered", "bus_name", player.BusName())
+}
+
+func (g *Game) stopMediaControls() {
+	if g.mediaPlayer == nil {
+		return
+	}
+	g.mediaPlayer.Close()
+	g.mediaPlayer = nil
+	g.mediaCommands = nil
+}
+
+// applyMediaCommands runs commands received from media controllers.
+func (g *Game) applyMediaCommands() bool {
+	applied := false
+	for {
+		select {
+		case cmd := <-g.mediaCommands:
+			debugKV("media", "command", "command", cmd)
+			g.applyMediaCommand(cmd)
+			applied = true
+		default:
+			return applied
+		}
+	}
+}
+
+func (g *Game) applyMediaCommand(cmd mpris.Command) {
+	switch cmd {
+	case mpris.CommandNext:
+		g.NavigateNext()
+	case mpris.CommandPrevious:
+		g.NavigatePrevious()
+	case mpris.CommandPlay:
+		g.setSlideshow(true)
+	case mpris.CommandPause, mpris.CommandStop:
+		g.setSlideshow(false)
+	case mpris.CommandPlayPause:
+		g.toggleSlideshow()
+	case mpris.CommandRaise:
+		bestEffortActivateWindow()
+	case mpris.CommandQuit:
+		if g.kioskLocked {
+			debugKV("media", "command_blocked", "command", cmd, "reason", "kiosk_locked")
+			return
+		}
+		g.Exit()
+	}
+}
+
+// publishMediaStatus shows the current page as the playing track.
+func (g *Game) publishMediaStatus() {
+	if g.mediaPlayer == nil {
+		return
+	}
+	g.mediaPlayer.SetStatus(g.mediaStatus())
+}
+
+func (g *Game) mediaStatus() mpris.Status {
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return mpris.Status{QuitLocked: g.kioskLocked}
+	}
+	status := mpris.Status{
+		Playing:    g.slideshowActive,
+		Title:      contactSheetLabelText(p),
+		Page:       g.idx + 1,
+		Total:      g.imageManager.GetPathsCount(),
+		QuitLocked: g.kioskLocked,
+	}
+	if p.ArchivePath != "" {
+		status.Album = filepath.Base(p.ArchivePath)
+	} else {
+		status.Album = filepath.Base(filepath.Dir(p.Path))
+		if abs, err := filepath.Abs(p.Path); err == nil {
+			status.ArtURL = (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+		}
+	}
+	return status
+}
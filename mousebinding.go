@@ -18,6 +18,62 @@ type MouseSettings struct {
 	EnableDragPan    bool    `json:"enable_drag_pan"`   // Enable drag to pan
 	DragSensitivity  float64 `json:"drag_sensitivity"`  // Drag movement sensitivity
 	DragPanInverted  bool    `json:"drag_pan_inverted"` // Invert drag pan direction (both X and Y axes)
+
+	// Wheel while zoomed in manually: "pan" scrolls the image, "navigate"
+	// keeps the wheel bindings. Holding WheelPageModifier turns pages anyway.
+	WheelZoomed       string `json:"wheel_zoomed"`
+	WheelPageModifier string `json:"wheel_page_modifier"` // "Alt", "Shift", "Ctrl" or "" for none
+}
+
+const (
+	wheelZoomedPan      = "pan"
+	wheelZoomedNavigate = "navigate"
+
+	// Screen pixels panned per wheel step
+	wheelPanStep = 80.0
+)
+
+var (
+	wheelZoomedModes   = []string{wheelZoomedPan, wheelZoomedNavigate}
+	wheelPageModifiers = []string{"Alt", "Shift", "Ctrl", ""}
+)
+
+// modifierState is the set of modifier keys held.
+type modifierState struct {
+	Shift, Ctrl, Alt bool
+}
+
+func pressedModifiers() modifierState {
+	return modifierState{
+		Shift: ebiten.IsKeyPressed(ebiten.KeyShift),
+		Ctrl:  ebiten.IsKeyPressed(ebiten.KeyControl),
+		Alt:   ebiten.IsKeyPressed(ebiten.KeyAlt),
+	}
+}
+
+// without returns mods with the named modifier released.
+func (mods modifierState) without(name string) modifierState {
+	switch name {
+	case "Shift":
+		mods.Shift = false
+	case "Ctrl":
+		mods.Ctrl = false
+	case "Alt":
+		mods.Alt = false
+	}
+	return mods
+}
+
+func (mods modifierState) has(name string) bool {
+	switch name {
+	case "Shift":
+		return mods.Shift
+	case "Ctrl":
+		return mods.Ctrl
+	case "Alt":
+		return mods.Alt
+	}
+	return false
 }
 
 // DoubleClickTracker tracks double-click state
@@ -45,6 +101,7 @@ type MousebindingManager struct {
 	mouseMapping       map[string]ebiten.MouseButton
 	settings           MouseSettings
 	doubleClickTracker DoubleClickTracker
+	zoomMode           ZoomMode // Zoom of the view, see SetZoomMode
 }
 
 // NewMousebindingManager creates a new MousebindingManager
@@ -110,25 +167,16 @@ func (mm *MousebindingManager) isMouseActionTriggered(combination *MouseCombinat
 		return false
 	}
 
-	// Check modifiers first
-	if combination.Shift && !ebiten.IsKeyPressed(ebiten.KeyShift) {
-		return false
-	}
-	if combination.Ctrl && !ebiten.IsKeyPressed(ebiten.KeyControl) {
-		return false
-	}
-	if combination.Alt && !ebiten.IsKeyPressed(ebiten.KeyAlt) {
-		return false
+	mods := pressedModifiers()
+	if combination.IsWheel {
+		var pans bool
+		if mods, pans = mm.wheelModifiers(mods); pans {
+			return false
+		}
 	}
 
-	// Check that unwanted modifiers aren't pressed
-	if !combination.Shift && ebiten.IsKeyPressed(ebiten.KeyShift) {
-		return false
-	}
-	if !combination.Ctrl && ebiten.IsKeyPressed(ebiten.KeyControl) {
-		return false
-	}
-	if !combination.Alt && ebiten.IsKeyPressed(ebiten.KeyAlt) {
+	// Modifiers must match exactly
+	if combination.Shift != mods.Shift || combination.Ctrl != mods.Ctrl || combination.Alt != mods.Alt {
 		return false
 	}
 
@@ -162,6 +210,73 @@ func (mm *MousebindingManager) isMouseActionTriggered(combination *MouseCombinat
 	return inpututil.IsMouseButtonJustPressed(combination.Button)
 }
 
+// SetZoomMode tells the manager how the view is zoomed, which decides what
+// the wheel does.
+func (mm *MousebindingManager) SetZoomMode(mode ZoomMode) {
+	mm.zoomMode = mode
+}
+
+// wheelModifiers returns the modifiers wheel bindings are matched against
+// while mods are held, and whether the plain wheel pans instead. While the
+// wheel pans a zoomed view, the page modifier is dropped so it turns pages
+// through the plain wheel bindings.
+func (mm *MousebindingManager) wheelModifiers(mods modifierState) (modifierState, bool) {
+	if mm.zoomMode != ZoomModeManual || mm.settings.WheelZoomed != wheelZoomedPan {
+		return mods, false
+	}
+	if page := mm.settings.WheelPageModifier; page != "" && mods.has(page) {
+		return mods.without(page), false
+	}
+	return mods, mods == modifierState{}
+}
+
+// WheelPan returns the pan offset for this frame's wheel movement when the
+// wheel pans the view.
+func (mm *MousebindingManager) WheelPan() (float64, float64, bool) {
+	if !mm.settings.EnableMouse {
+		return 0, 0, false
+	}
+	wheelX, wheelY := ebiten.Wheel()
+	return mm.wheelPan(pressedModifiers(), wheelX, wheelY)
+}
+
+// wheelPan turns a wheel movement into a pan offset. The vertical part pans
+// when wheelModifiers says so; the horizontal part, from tilt wheels and
+// trackpads, pans whenever the image is not fit to the window, unless a
+// WheelLeft/WheelRight binding takes it. Trackpad deltas are fractional, so
+// the pan follows the fingers smoothly.
+func (mm *MousebindingManager) wheelPan(mods modifierState, wheelX, wheelY float64) (float64, float64, bool) {
+	if _, pans := mm.wheelModifiers(mods); !pans {
+		wheelY = 0
+	}
+	if mm.zoomMode == ZoomModeFitWindow || mods != (modifierState{}) || mm.horizontalWheelBound() {
+		wheelX = 0
+	}
+	if wheelX == 0 && wheelY == 0 {
+		return 0, 0, false
+	}
+	if mm.settings.WheelInverted {
+		wheelX, wheelY = -wheelX, -wheelY
+	}
+	// Scrolling right shows more of the right side, like pan_right
+	step := wheelPanStep * mm.settings.WheelSensitivity
+	return -wheelX * step, wheelY * step, true
+}
+
+// horizontalWheelBound reports whether WheelLeft or WheelRight without
+// modifiers is bound to an action.
+func (mm *MousebindingManager) horizontalWheelBound() bool {
+	for _, mouseStrings := range mm.mousebindings {
+		for _, mouseStr := range mouseStrings {
+			c, ok := mm.parseMouseString(mouseStr)
+			if ok && c.IsWheel && c.WheelDeltaX != 0 && !c.Shift && !c.Ctrl && !c.Alt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // checkDoubleClick checks if a double-click occurred for the given button
 func (mm *MousebindingManager) checkDoubleClick(button ebiten.MouseButton) bool {
 	if !inpututil.IsMouseButtonJustPressed(button) {
@@ -240,13 +355,15 @@ func (mm *MousebindingManager) GetSettings() MouseSettings {
 // GetDefaultMouseSettings returns the default mouse settings
 func GetDefaultMouseSettings() MouseSettings {
 	return MouseSettings{
-		WheelSensitivity: 1.0,
-		DoubleClickTime:  300, // milliseconds
-		DragThreshold:    5,   // pixels
-		EnableMouse:      true,
-		WheelInverted:    false,
-		EnableDragPan:    true,  // Enable drag to pan by default
-		DragSensitivity:  1.0,   // 1:1 mouse movement to pan ratio
-		DragPanInverted:  false, // false = mouse/trackball style (drag to move image)
+		WheelSensitivity:  1.0,
+		DoubleClickTime:   300, // milliseconds
+		DragThreshold:     5,   // pixels
+		EnableMouse:       true,
+		WheelInverted:     false,
+		EnableDragPan:     true,           // Enable drag to pan by default
+		DragSensitivity:   1.0,            // 1:1 mouse movement to pan ratio
+		DragPanInverted:   false,          // false = mouse/trackball style (drag to move image)
+		WheelZoomed:       wheelZoomedPan, // Wheel scrolls a manually zoomed image
+		WheelPageModifier: "Alt",          // Alt+wheel still turns pages
 	}
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// compileNamePattern returns a case-insensitive matcher for pattern. Patterns
+// that are valid regular expressions match as regexes; anything else falls
+// back to a plain substring match so partially typed input still works.
+func compileNamePattern(pattern string) func(string) bool {
+	if re, err := regexp.Compile("(?i)" + pattern); err == nil {
+		return re.MatchString
+	}
+	lower := strings.ToLower(pattern)
+	return func(name string) bool {
+		return strings.Contains(strings.ToLower(name), lower)
+	}
+}
+
+// imageDisplayName is the name pattern filters and searches match against:
+// the entry path for archive entries, the base name for regular files.
+func imageDisplayName(imagePath ImagePath) string {
+	if imagePath.ArchivePath != "" {
+		return imagePath.EntryPath
+	}
+	return filepath.Base(imagePath.Path)
+}
+
+func filterPathsByName(paths []ImagePath, pattern string) []ImagePath {
+	if pattern == "" {
+		return paths
+	}
+	match := compileNamePattern(pattern)
+	filtered := make([]ImagePath, 0, len(paths))
+	for _, p := range paths {
+		if match(imageDisplayName(p)) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// setPathsKeepingFocus replaces the path list, keeping focusPath current when
+// it is still present and falling back to the first page otherwise.
+func (g *Game) setPathsKeepingFocus(paths []ImagePath, focusPath string) {
+	g.imageManager.SetPaths(paths)
+	targetIdx := findImagePathIndex(paths, focusPath)
+	if targetIdx < 0 {
+		targetIdx = 0
+	}
+	g.setCurrentIndex(targetIdx)
+	g.calculateDisplayContent()
+}
+
+// enterNameFilter opens the live filename filter. The list without any name
+// pattern is snapshotted so each keystroke filters in memory and Escape can
+// restore it.
+func (g *Game) enterNameFilter() {
+	if g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+
+	base := g.currentPaths()
+	if g.collectionFilter.Pattern != "" {
+		filter := g.collectionFilter
+		filter.Pattern = ""
+		paths, err := g.collectionSource.collect(g.config.SortMethod)
+		if err != nil {
+			warnKV("collection", "name_filter_collect_failed", "error", err)
+			return
+		}
+		base = filterPaths(paths, filter, g.ratings)
+	}
+
+	g.nameFilterBase = base
+	g.nameFilterOrigin = g.getCurrentImagePath()
+	g.openTextPrompt(TextPromptNameFilter, g.collectionFilter.Pattern)
+	g.updateNameFilter(g.collectionFilter.Pattern)
+	debugKV("collection", "name_filter_begin", "paths_count", len(base), "pattern", g.collectionFilter.Pattern)
+}
+
+// updateNameFilter narrows the visible list to names matching pattern. When
+// nothing matches the previous list stays visible.
+func (g *Game) updateNameFilter(pattern string) {
+	matches := filterPathsByName(g.nameFilterBase, pattern)
+	g.textPromptStatus = fmt.Sprintf("%d / %d", len(matches), len(g.nameFilterBase))
+	if len(matches) == 0 {
+		return
+	}
+	g.setPathsKeepingFocus(matches, g.getCurrentImagePath())
+}
+
+// commitNameFilter keeps the current pattern so sort and rescan reapply it.
+func (g *Game) commitNameFilter(pattern string) {
+	if len(filterPathsByName(g.nameFilterBase, pattern)) == 0 {
+		g.cancelNameFilter()
+		g.showOverlayMessage("Find: no match for " + pattern)
+		return
+	}
+
+	g.collectionFilter.Pattern = pattern
+	g.imageManager.StartPreload(g.idx, NavigationJump)
+	if pattern != "" {
+		g.showOverlayMessage(fmt.Sprintf("Find %q: %d image(s)", pattern, g.imageManager.GetPathsCount()))
+	}
+	g.nameFilterBase = nil
+	debugKV("collection", "name_filter_commit", "pattern", pattern, "paths_count", g.imageManager.GetPathsCount())
+}
+
+// cancelNameFilter restores the full list and the page that was current when
+// the filter was opened.
+func (g *Game) cancelNameFilter() {
+	if g.nameFilterBase != nil {
+		g.collectionFilter.Pattern = ""
+		g.setPathsKeepingFocus(g.nameFilterBase, g.nameFilterOrigin)
+		g.imageManager.StartPreload(g.idx, NavigationJump)
+	}
+	g.nameFilterBase = nil
+	debugKV("collection", "name_filter_cancel", "paths_count", g.imageManager.GetPathsCount())
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// maxNavHistory caps the pages kept in the jump history.
+const maxNavHistory = 100
+
+// navHistory is the browser-like list of pages visited by jumps. Page
+// turns are not entries; they move the current entry along instead.
+type navHistory struct {
+	pages []string // Paths of visited pages, oldest first
+	pos   int      // Index of the current page
+}
+
+// visit records a jump from one page to another, dropping the pages ahead
+// of the current one like a browser does.
+func (h *navHistory) visit(from, to string) {
+	if len(h.pages) == 0 {
+		h.pages = []string{from}
+		h.pos = 0
+	}
+	h.pages = append(h.pages[:h.pos], from, to)
+	if len(h.pages) > maxNavHistory {
+		h.pages = slices.Delete(h.pages, 0, len(h.pages)-maxNavHistory)
+	}
+	h.pos = len(h.pages) - 1
+}
+
+// step moves delta entries back (negative) or forward from current, the
+// page on screen, which replaces the current entry so pages read since the
+// last jump are returned to. exists skips pages no longer in the list.
+func (h *navHistory) step(current string, delta int, exists func(string) bool) (string, bool) {
+	if len(h.pages) == 0 {
+		return "", false
+	}
+	h.pages[h.pos] = current
+	for i := h.pos + delta; i >= 0 && i < len(h.pages); i += delta {
+		if h.pages[i] != current && exists(h.pages[i]) {
+			h.pos = i
+			return h.pages[i], true
+		}
+	}
+	return "", false
+}
+
+// historyBack returns to the page before the last jump, e.g. after an
+// accidental jump_first.
+func (g *Game) historyBack() {
+	g.stepNavHistory(-1, "No earlier page in history")
+}
+
+func (g *Game) historyForward() {
+	g.stepNavHistory(1, "No later page in history")
+}
+
+func (g *Game) stepNavHistory(delta int, none string) {
+	current, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	exists := func(path string) bool {
+		_, err := g.pageIndexOf(path)
+		return err == nil
+	}
+	path, ok := g.navHistory.step(current.Path, delta, exists)
+	if !ok {
+		g.showOverlayMessage(none)
+		return
+	}
+	g.navigatingHistory = true
+	err := g.jumpToPath(path)
+	g.navigatingHistory = false
+	if err != nil {
+		return
+	}
+	g.showOverlayMessage(fmt.Sprintf("Page %d / %d", g.idx+1, g.imageManager.GetPathsCount()))
+	debugKV("nav", "history_step", "delta", delta, "idx", g.idx, "pos", g.navHistory.pos, "entries", len(g.navHistory.pages))
+}
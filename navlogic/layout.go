@@ -0,0 +1,179 @@
+package navlogic
+
+import (
+	"maps"
+	"slices"
+)
+
+// Layout is a book mode pairing planned once for a whole page list, so that
+// moving forward and back always lands on the same spreads instead of
+// re-deciding the pairing at every step.
+type Layout struct {
+	metrics              []PageMetrics
+	aspectRatioThreshold float64
+	learnedSpreadAspects []float64
+	pairings             map[int]Pairing // Set by hand, by the first page of the pair
+	breaks               []int           // Pages a spread must start at, sorted
+	starts               []int           // First page of the spread of each page
+}
+
+// PlanLayout pairs pages from the first one on: each page is paired with
+// the next when ShouldUseBookMode accepts them, and shown alone otherwise.
+// Pages with unknown metrics are shown alone.
+func PlanLayout(metrics []PageMetrics, aspectRatioThreshold float64, learnedSpreadAspects []float64) *Layout {
+	l := &Layout{
+		metrics:              slices.Clone(metrics),
+		aspectRatioThreshold: aspectRatioThreshold,
+		learnedSpreadAspects: slices.Clone(learnedSpreadAspects),
+	}
+	l.plan()
+	return l
+}
+
+func (l *Layout) plan() {
+	l.starts = make([]int, len(l.metrics))
+	for i := 0; i < len(l.metrics); {
+		l.starts[i] = i
+		next := i + 1
+		if next < len(l.metrics) && !slices.Contains(l.breaks, next) && l.canPair(i) {
+			l.starts[next] = i
+			next++
+		}
+		i = next
+	}
+}
+
+func (l *Layout) canPair(idx int) bool {
+	switch l.pairings[idx] {
+	case PairTogether:
+		return true
+	case PairApart:
+		return false
+	}
+	return ShouldUseBookMode(l.metrics[idx], l.metrics[idx+1], l.aspectRatioThreshold, l.learnedSpreadAspects)
+}
+
+// Replan pairs the same pages again with another threshold, learned spread
+// ratios or pairings set by hand, keeping the spreads started with
+// WithSpreadAt.
+func (l *Layout) Replan(aspectRatioThreshold float64, learnedSpreadAspects []float64, pairings map[int]Pairing) *Layout {
+	next := PlanLayout(l.metrics, aspectRatioThreshold, learnedSpreadAspects)
+	next.breaks = slices.Clone(l.breaks)
+	next.pairings = maps.Clone(pairings)
+	next.plan()
+	return next
+}
+
+// WithPairing returns the layout re-paired with a spread starting at idx
+// and pairing set for idx and the page after it.
+func (l *Layout) WithPairing(idx int, pairing Pairing) *Layout {
+	next := l.WithSpreadAt(idx)
+	next.pairings = maps.Clone(l.pairings)
+	if next.pairings == nil {
+		next.pairings = map[int]Pairing{}
+	}
+	next.pairings[idx] = pairing
+	next.plan()
+	return next
+}
+
+// WithSpreadAt returns the layout re-paired so that a spread starts at idx,
+// dropping earlier such requests for its neighbours so that stepping a page
+// forward and back again restores the original pairing.
+func (l *Layout) WithSpreadAt(idx int) *Layout {
+	next := &Layout{
+		metrics:              l.metrics,
+		aspectRatioThreshold: l.aspectRatioThreshold,
+		learnedSpreadAspects: l.learnedSpreadAspects,
+		pairings:             l.pairings,
+	}
+	for _, b := range l.breaks {
+		if b < idx-1 || b > idx+1 {
+			next.breaks = append(next.breaks, b)
+		}
+	}
+	if idx > 0 {
+		next.breaks = append(next.breaks, idx)
+		slices.Sort(next.breaks)
+	}
+	next.plan()
+	return next
+}
+
+// PageCount returns the number of pages the layout was planned for.
+func (l *Layout) PageCount() int {
+	return len(l.starts)
+}
+
+// Metrics returns the page metrics the layout was planned from.
+func (l *Layout) Metrics() []PageMetrics {
+	return l.metrics
+}
+
+// Spread returns the first page and page count of the spread showing idx.
+func (l *Layout) Spread(idx int) (first, count int) {
+	first = l.starts[idx]
+	if first+1 < len(l.starts) && l.starts[first+1] == first {
+		return first, 2
+	}
+	return first, 1
+}
+
+// layoutFor returns the state's layout when it drives book mode.
+func layoutFor(state State) *Layout {
+	if !state.BookMode && !state.TempSingleMode {
+		return nil
+	}
+	if state.Layout == nil || state.Layout.PageCount() != state.PageCount || state.PageCount == 0 {
+		return nil
+	}
+	return state.Layout
+}
+
+func planLayoutDisplay(state State, layout *Layout) DisplayPlan {
+	first, count := layout.Spread(state.Index)
+	plan := DisplayPlan{
+		LeftIndex:    first,
+		RightIndex:   -1,
+		CurrentPage:  state.Index + 1,
+		TotalPages:   state.PageCount,
+		ActualImages: count,
+	}
+	if count == 2 {
+		plan.LeftIndex, plan.RightIndex = pairIndices(state, first)
+	}
+	return plan
+}
+
+func onLayout(state State, idx int) State {
+	state.Index, _ = state.Layout.Spread(idx)
+	state.BookMode = true
+	state.TempSingleMode = false
+	return state
+}
+
+func navigateLayoutNext(state State, layout *Layout, singleStep bool) (State, Boundary) {
+	first, count := layout.Spread(state.Index)
+	next := first + count
+	if singleStep {
+		next = first + 1
+	}
+	if next >= state.PageCount {
+		return state, BoundaryLastPage
+	}
+	if singleStep {
+		state.Layout = layout.WithSpreadAt(next)
+	}
+	return onLayout(state, next), BoundaryNone
+}
+
+func navigateLayoutPrevious(state State, layout *Layout, singleStep bool) (State, Boundary) {
+	first, _ := layout.Spread(state.Index)
+	if first == 0 {
+		return state, BoundaryFirstPage
+	}
+	if singleStep {
+		state.Layout = layout.WithSpreadAt(first - 1)
+	}
+	return onLayout(state, first-1), BoundaryNone
+}
@@ -0,0 +1,113 @@
+package navlogic
+
+import (
+	"slices"
+	"testing"
+)
+
+func spreadFirsts(t *testing.T, state State) (forward, backward []int) {
+	t.Helper()
+	lookup := lookupFromSlice(state.Layout.Metrics())
+	forward = []int{state.Index}
+	for {
+		next, boundary := NavigateNext(state, lookup, false)
+		if boundary == BoundaryLastPage {
+			break
+		}
+		state = next
+		forward = append(forward, state.Index)
+	}
+	backward = []int{state.Index}
+	for {
+		prev, boundary := NavigatePrevious(state, lookup, false)
+		if boundary == BoundaryFirstPage {
+			break
+		}
+		state = prev
+		backward = append(backward, state.Index)
+	}
+	return forward, backward
+}
+
+func TestLayoutNavigatesStableSpreads(t *testing.T) {
+	metrics := metricsFromKinds([]testPageKind{
+		testPagePairable, testPagePairable, testPageForcedSingle,
+		testPagePairable, testPagePairable, testPagePairable,
+	})
+	state := State{
+		PageCount:            len(metrics),
+		BookMode:             true,
+		AspectRatioThreshold: 1.5,
+		Layout:               PlanLayout(metrics, 1.5, nil),
+	}
+
+	forward, backward := spreadFirsts(t, state)
+	if want := []int{0, 2, 3, 5}; !slices.Equal(forward, want) {
+		t.Fatalf("forward spreads = %v, want %v", forward, want)
+	}
+	if want := []int{5, 3, 2, 0}; !slices.Equal(backward, want) {
+		t.Fatalf("backward spreads = %v, want %v", backward, want)
+	}
+
+	state.Index = 4
+	plan := PlanDisplay(state, lookupFromSlice(metrics))
+	if plan.LeftIndex != 3 || plan.RightIndex != 4 || plan.ActualImages != 2 {
+		t.Fatalf("page 5 plan = %+v, want the 4-5 spread", plan)
+	}
+	if jumped := SetCurrentIndex(state, 4, lookupFromSlice(metrics)); jumped.Index != 3 {
+		t.Fatalf("jump to page 5 index = %d, want its spread at 3", jumped.Index)
+	}
+
+	state.RightToLeft = true
+	plan = PlanDisplay(state, lookupFromSlice(metrics))
+	if plan.LeftIndex != 4 || plan.RightIndex != 3 {
+		t.Fatalf("right-to-left plan = %+v, want 4 on the left", plan)
+	}
+}
+
+func TestLayoutSingleStepShiftsThePairing(t *testing.T) {
+	metrics := metricsFromKinds([]testPageKind{
+		testPagePairable, testPagePairable, testPagePairable, testPagePairable, testPagePairable,
+	})
+	lookup := lookupFromSlice(metrics)
+	state := State{
+		PageCount:            len(metrics),
+		BookMode:             true,
+		AspectRatioThreshold: 1.5,
+		Layout:               PlanLayout(metrics, 1.5, nil),
+	}
+
+	shifted, _ := NavigateNext(state, lookup, true)
+	if shifted.Index != 1 {
+		t.Fatalf("single step index = %d, want 1", shifted.Index)
+	}
+	if forward, _ := spreadFirsts(t, shifted); !slices.Equal(forward, []int{1, 3}) {
+		t.Fatalf("shifted spreads = %v, want [1 3]", forward)
+	}
+	if first, count := shifted.Layout.Spread(0); first != 0 || count != 1 {
+		t.Fatalf("page 1 spread = %d+%d, want a single page", first, count)
+	}
+
+	restored, _ := NavigatePrevious(shifted, lookup, true)
+	if forward, _ := spreadFirsts(t, restored); !slices.Equal(forward, []int{0, 2, 4}) {
+		t.Fatalf("restored spreads = %v, want [0 2 4]", forward)
+	}
+}
+
+func TestLayoutIsIgnoredWhenStale(t *testing.T) {
+	metrics := metricsFromKinds([]testPageKind{testPagePairable, testPagePairable})
+	state := State{
+		PageCount:            3,
+		BookMode:             true,
+		AspectRatioThreshold: 1.5,
+		Layout:               PlanLayout(metrics, 1.5, nil),
+	}
+	if layoutFor(state) != nil {
+		t.Fatal("a layout for another page count should be ignored")
+	}
+	state.PageCount = 2
+	state.BookMode = false
+	if layoutFor(state) != nil {
+		t.Fatal("a layout should only drive book mode")
+	}
+}
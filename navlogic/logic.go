@@ -21,8 +21,20 @@ type State struct {
 	RightToLeft          bool
 	AspectRatioThreshold float64
 	LearnedSpreadAspects []float64
+	Layout               *Layout         // Planned pairing; nil decides at each step
+	Spreads              *SpreadIndex    // Spreads seen so far; nil records none
+	Pairings             map[int]Pairing // Pairing set by hand, by the first page of the pair
 }
 
+// Pairing overrides the pairing of a page with the next one.
+type Pairing int
+
+const (
+	PairAuto     Pairing = iota // Decided by ShouldUseBookMode
+	PairTogether                // Always a spread, e.g. a color spread the heuristic rejects
+	PairApart                   // Never a spread
+)
+
 type DisplayPlan struct {
 	LeftIndex    int
 	RightIndex   int
@@ -67,6 +79,9 @@ func PlanDisplay(state State, lookup MetricsLookup) DisplayPlan {
 		ActualImages: 1,
 	}
 
+	if layout := layoutFor(state); layout != nil {
+		return planLayoutDisplay(state, layout)
+	}
 	if state.TempSingleMode || !state.BookMode {
 		return plan
 	}
@@ -74,7 +89,7 @@ func PlanDisplay(state State, lookup MetricsLookup) DisplayPlan {
 	leftIdx, rightIdx := pairIndices(state, state.Index)
 	leftMetrics := lookup(leftIdx)
 	rightMetrics := lookup(rightIdx)
-	if ShouldUseBookMode(leftMetrics, rightMetrics, state.AspectRatioThreshold, state.LearnedSpreadAspects) {
+	if canPair(state, state.Index, leftMetrics, rightMetrics) {
 		plan.LeftIndex = leftIdx
 		plan.RightIndex = rightIdx
 		plan.ActualImages = 2
@@ -104,10 +119,13 @@ func SetCurrentIndex(state State, targetIdx int, lookup MetricsLookup) State {
 	}
 
 	targetIdx = clampIndex(targetIdx, state.PageCount)
+	if layoutFor(state) != nil {
+		return onLayout(state, targetIdx)
+	}
 	if state.BookMode && targetIdx == state.PageCount-1 {
 		if targetIdx > 0 {
 			leftIdx, rightIdx := pairIndices(state, targetIdx-1)
-			if ShouldUseBookMode(lookup(leftIdx), lookup(rightIdx), state.AspectRatioThreshold, state.LearnedSpreadAspects) {
+			if canPair(state, targetIdx-1, lookup(leftIdx), lookup(rightIdx)) {
 				state.Index = targetIdx - 1
 				state.TempSingleMode = false
 				return state
@@ -126,6 +144,9 @@ func SetCurrentIndex(state State, targetIdx int, lookup MetricsLookup) State {
 
 func NavigateNext(state State, lookup MetricsLookup, singleStep bool) (State, Boundary) {
 	state = normalizeState(state)
+	if layout := layoutFor(state); layout != nil {
+		return navigateLayoutNext(state, layout, singleStep)
+	}
 	if state.Index+1 >= state.PageCount {
 		return state, BoundaryLastPage
 	}
@@ -158,10 +179,23 @@ func NavigateNext(state State, lookup MetricsLookup, singleStep bool) (State, Bo
 
 func NavigatePrevious(state State, lookup MetricsLookup, singleStep bool) (State, Boundary) {
 	state = normalizeState(state)
+	if layout := layoutFor(state); layout != nil {
+		return navigateLayoutPrevious(state, layout, singleStep)
+	}
 	if state.Index <= 0 {
 		return state, BoundaryFirstPage
 	}
 
+	if (state.BookMode || state.TempSingleMode) && !singleStep && state.Spreads != nil {
+		prevPage := minDisplayedIndex(PlanDisplay(state, lookup)) - 1
+		if first, count, ok := state.Spreads.ending(prevPage); ok {
+			state.Index = first
+			state.BookMode = true
+			state.TempSingleMode = count == 1
+			return state, BoundaryNone
+		}
+	}
+
 	if state.TempSingleMode && !state.BookMode {
 		if state.Index < 2 {
 			state.Index = 0
@@ -233,6 +267,10 @@ func ToggleBookMode(state State, lookup MetricsLookup) State {
 		return state
 	}
 
+	if state.Layout != nil && state.Layout.PageCount() == state.PageCount && state.PageCount > 0 {
+		return onLayout(state, state.Index)
+	}
+
 	if state.PageCount == 1 {
 		state.BookMode = true
 		state.TempSingleMode = true
@@ -241,7 +279,7 @@ func ToggleBookMode(state State, lookup MetricsLookup) State {
 
 	if state.Index == state.PageCount-1 {
 		leftIdx, rightIdx := pairIndices(state, state.Index-1)
-		if ShouldUseBookMode(lookup(leftIdx), lookup(rightIdx), state.AspectRatioThreshold, state.LearnedSpreadAspects) {
+		if canPair(state, state.Index-1, lookup(leftIdx), lookup(rightIdx)) {
 			state.Index--
 			state.TempSingleMode = false
 			state.BookMode = true
@@ -349,6 +387,21 @@ func clampIndex(idx, pageCount int) int {
 	return idx
 }
 
+// canPair reports whether idx and the page after it form a spread: as set
+// by hand, or else as ShouldUseBookMode decides from their metrics.
+func canPair(state State, idx int, leftMetrics, rightMetrics PageMetrics) bool {
+	if idx < 0 || idx+1 >= state.PageCount {
+		return false
+	}
+	switch state.Pairings[idx] {
+	case PairTogether:
+		return true
+	case PairApart:
+		return false
+	}
+	return ShouldUseBookMode(leftMetrics, rightMetrics, state.AspectRatioThreshold, state.LearnedSpreadAspects)
+}
+
 func pairIndices(state State, idx int) (int, int) {
 	if state.RightToLeft {
 		return idx + 1, idx
@@ -0,0 +1,42 @@
+package navlogic
+
+// SpreadIndex records the spreads shown while reading, so that going back
+// shows the pairs that were seen going forward. Pairing is decided from the
+// current page onwards, so without it a step back can pair the previous
+// pages differently. A planned Layout takes precedence.
+type SpreadIndex struct {
+	counts map[int]int // First page of a spread -> its page count
+}
+
+func NewSpreadIndex() *SpreadIndex {
+	return &SpreadIndex{counts: map[int]int{}}
+}
+
+// Record remembers the spread of plan, replacing recorded spreads sharing a
+// page with it.
+func (s *SpreadIndex) Record(plan DisplayPlan) {
+	first := minDisplayedIndex(plan)
+	if first < 0 {
+		return
+	}
+	count := 1
+	if plan.ActualImages == 2 {
+		count = 2
+	}
+	for f, c := range s.counts {
+		if f < first+count && first < f+c {
+			delete(s.counts, f)
+		}
+	}
+	s.counts[first] = count
+}
+
+// ending returns the recorded spread whose last page is idx.
+func (s *SpreadIndex) ending(idx int) (first, count int, ok bool) {
+	for _, count := range []int{2, 1} {
+		if c, ok := s.counts[idx-count+1]; ok && c == count {
+			return idx - count + 1, count, true
+		}
+	}
+	return 0, 0, false
+}
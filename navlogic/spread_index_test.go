@@ -0,0 +1,67 @@
+package navlogic
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSpreadIndexMakesBackwardTraversalMatchForward(t *testing.T) {
+	// Forward pairs 1-2, then 3 alone before the tall page 4; deciding
+	// backward from page 4 alone would pair 2-3 instead.
+	metrics := metricsFromKinds([]testPageKind{
+		testPagePairable, testPagePairable, testPagePairable, testPageForcedSingle,
+	})
+	lookup := lookupFromSlice(metrics)
+	spreadsOf := func(spreads *SpreadIndex) (forward, backward [][2]int) {
+		state := State{PageCount: len(metrics), BookMode: true, AspectRatioThreshold: 1.5, Spreads: spreads}
+		record := func(plans *[][2]int) {
+			plan := PlanDisplay(state, lookup)
+			if spreads != nil {
+				spreads.Record(plan)
+			}
+			*plans = append(*plans, [2]int{minDisplayedIndex(plan), maxDisplayedIndex(plan)})
+		}
+		record(&forward)
+		for {
+			next, boundary := NavigateNext(state, lookup, false)
+			if boundary != BoundaryNone {
+				break
+			}
+			state = next
+			record(&forward)
+		}
+		backward = append(backward, forward[len(forward)-1])
+		for {
+			prev, boundary := NavigatePrevious(state, lookup, false)
+			if boundary != BoundaryNone {
+				break
+			}
+			state = prev
+			record(&backward)
+		}
+		slices.Reverse(backward)
+		return forward, backward
+	}
+
+	if forward, backward := spreadsOf(nil); slices.Equal(forward, backward) {
+		t.Fatalf("expected the unindexed traversal to be asymmetric, got %v both ways", forward)
+	}
+	forward, backward := spreadsOf(NewSpreadIndex())
+	if !slices.Equal(forward, backward) {
+		t.Fatalf("backward spreads %v differ from forward %v", backward, forward)
+	}
+}
+
+func TestSpreadIndexRecordReplacesOverlappingSpreads(t *testing.T) {
+	s := NewSpreadIndex()
+	s.Record(DisplayPlan{LeftIndex: 0, RightIndex: 1, ActualImages: 2})
+	s.Record(DisplayPlan{LeftIndex: 2, RightIndex: 3, ActualImages: 2})
+	s.Record(DisplayPlan{LeftIndex: 2, RightIndex: 1, ActualImages: 2}) // Shifted a page, right to left
+
+	if _, _, ok := s.ending(1); ok {
+		t.Fatal("the 1-2 spread should have been replaced")
+	}
+	if first, count, ok := s.ending(2); !ok || first != 1 || count != 2 {
+		t.Fatalf("spread ending at 3 = %d+%d (%v), want 2-3", first, count, ok)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	nextPagePreviewSize   = 160 // Longest side of the thumbnail
+	nextPagePreviewMargin = 12
+	nextPagePreviewAlpha  = 0.65
+)
+
+var nextPagePreviewFrame = color.NRGBA{0, 0, 0, 140}
+
+// cachedImage returns the decoded image for idx without loading it or
+// touching its place in the cache.
+func (m *DefaultImageManager) cachedImage(idx int) (DisplayImage, bool) {
+	imagePath, ok := m.getPath(idx)
+	if !ok {
+		return nil, false
+	}
+	return m.cache.Peek(m.cacheKeyFor(imagePath))
+}
+
+// nextPagePreviewIndex is the page after the ones on screen, or -1 on the
+// last page.
+func (g *Game) nextPagePreviewIndex() int {
+	if g.displayContent == nil {
+		return -1
+	}
+	// Page numbers are 1-based, so the last one shown is the next index
+	next := max(g.displayContent.Metadata.LeftPage, g.displayContent.Metadata.RightPage)
+	if next <= 0 || next >= g.imageManager.GetPathsCount() {
+		return -1
+	}
+	return next
+}
+
+// GetNextPagePreview returns the next page for next_page_preview once the
+// preloader has decoded it, and whether it belongs on the left, the side
+// right-to-left readers turn toward. Pages that failed to load are not
+// previewed.
+func (g *Game) GetNextPagePreview() (DisplayImage, bool) {
+	if !g.config.NextPagePreview {
+		return nil, false
+	}
+	dm, ok := g.imageManager.(*DefaultImageManager)
+	if !ok {
+		return nil, false
+	}
+	idx := g.nextPagePreviewIndex()
+	if idx < 0 || dm.IsLoadFailed(idx) {
+		return nil, false
+	}
+	img, ok := dm.cachedImage(idx)
+	if !ok {
+		return nil, false
+	}
+	return img, g.config.RightToLeft
+}
+
+// drawNextPagePreview draws the next page faintly in the top corner the
+// reader turns toward.
+func (r *Renderer) drawNextPagePreview(screen *ebiten.Image) {
+	img, left := r.renderState.GetNextPagePreview()
+	if img == nil {
+		return
+	}
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return
+	}
+	scale := float64(nextPagePreviewSize) / float64(max(b.Dx(), b.Dy()))
+	w, h := float64(b.Dx())*scale, float64(b.Dy())*scale
+	x := float64(screen.Bounds().Dx()) - w - nextPagePreviewMargin
+	if left {
+		x = nextPagePreviewMargin
+	}
+	y := float64(nextPagePreviewMargin)
+
+	vector.DrawFilledRect(screen, float32(x-2), float32(y-2), float32(w+4), float32(h+4), nextPagePreviewFrame, false)
+	texScale := scale / displayTextureScale(img)
+	for _, tile := range img.Tiles() {
+		if tile.Image == nil {
+			continue
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.Filter = ebiten.FilterLinear
+		op.GeoM.Translate(float64(tile.X), float64(tile.Y))
+		op.GeoM.Scale(texScale, texScale)
+		op.GeoM.Translate(x, y)
+		op.ColorScale.ScaleAlpha(nextPagePreviewAlpha)
+		screen.DrawImage(tile.Image, op)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultOCRCommand   = "tesseract {file} stdout -l {lang}"
+	defaultOCRLanguages = "eng"
+)
+
+// ocrRegion is part of the displayed canvas as fractions of its width and
+// height. The zero value covers the whole canvas.
+type ocrRegion struct {
+	X0, Y0, X1, Y1 float64
+}
+
+func (r ocrRegion) IsZero() bool {
+	return r == ocrRegion{}
+}
+
+// rect scales the region to bounds; the zero region is the whole of bounds.
+func (r ocrRegion) rect(bounds image.Rectangle) image.Rectangle {
+	if r.IsZero() {
+		return bounds
+	}
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	return image.Rect(
+		bounds.Min.X+int(math.Floor(r.X0*w)), bounds.Min.Y+int(math.Floor(r.Y0*h)),
+		bounds.Min.X+int(math.Ceil(r.X1*w)), bounds.Min.Y+int(math.Ceil(r.Y1*h)),
+	).Intersect(bounds)
+}
+
+// ocrRegionFromLine turns the screen rectangle spanned by a ruler into a
+// region of the canvas shown at view. ok is false when the rectangle misses
+// the image or has no area.
+func ocrRegionFromLine(l measureLine, view viewTransform) (ocrRegion, bool) {
+	if view.Width <= 0 || view.Height <= 0 {
+		return ocrRegion{}, false
+	}
+	fx := func(x int) float64 { return max(0, min(1, (float64(x)-view.OffsetX)/view.Width)) }
+	fy := func(y int) float64 { return max(0, min(1, (float64(y)-view.OffsetY)/view.Height)) }
+	r := ocrRegion{
+		X0: fx(min(l.Start.X, l.End.X)), Y0: fy(min(l.Start.Y, l.End.Y)),
+		X1: fx(max(l.Start.X, l.End.X)), Y1: fy(max(l.Start.Y, l.End.Y)),
+	}
+	if r.X1 <= r.X0 || r.Y1 <= r.Y0 {
+		return ocrRegion{}, false
+	}
+	return r, true
+}
+
+// ocrJob is the page or spread captured on the game loop for recognition.
+type ocrJob struct {
+	Paths     []ImagePath // Display order, left to right
+	Rotation  int
+	FlipH     bool
+	FlipV     bool
+	Region    ocrRegion
+	Command   string
+	Languages string
+}
+
+// ocrResult carries recognized text back to the game loop. Err covers both
+// recognition and copying to the clipboard.
+type ocrResult struct {
+	Text   string
+	Region bool
+	Err    error
+}
+
+// runOCRJob rebuilds the canvas as displayed, crops the region, and runs
+// the OCR command on it, returning its standard output.
+func runOCRJob(job ocrJob) (string, error) {
+	pages := make([]image.Image, len(job.Paths))
+	var decodeErr error
+	if err := forEachDecodedImage(job.Paths, func(i int, img image.Image, err error) {
+		if err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+		pages[i] = img
+	}); err != nil {
+		return "", err
+	}
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+	canvas := transformImage(composePages(pages, imageGap), job.Rotation, job.FlipH, job.FlipV)
+	crop := canvas.SubImage(job.Region.rect(canvas.Bounds()))
+	if crop.Bounds().Empty() {
+		return "", errors.New("selected region is empty")
+	}
+
+	f, err := os.CreateTemp("", "nv-ocr-*.png")
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	f.Close()
+	defer os.Remove(tmp)
+	if err := writeImageFile(tmp, crop); err != nil {
+		return "", err
+	}
+
+	vars := map[string]string{"file": tmp, "lang": job.Languages}
+	line := expandEventCommand(job.Command, vars, runtime.GOOS)
+	out, err := shellCommand(line, vars).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+				return "", fmt.Errorf("%w: %s", err, firstLine(msg))
+			}
+		}
+		return "", err
+	}
+	return cleanOCRText(string(out)), nil
+}
+
+// cleanOCRText normalizes line endings and drops the trailing form feed and
+// blank lines tesseract adds.
+func cleanOCRText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.TrimSpace(strings.Trim(s, "\f\n "))
+}
+
+// ocrCurrent recognizes the text of the displayed page or spread, or of the
+// rectangle spanned by the ruler when one is drawn, and copies it to the
+// clipboard on a background goroutine.
+func (g *Game) ocrCurrent() {
+	if g.displayContent == nil {
+		return
+	}
+	if !g.ocrActive.CompareAndSwap(false, true) {
+		g.showOverlayMessage("OCR already running")
+		return
+	}
+	if g.ocrResults == nil {
+		g.ocrResults = make(chan ocrResult, 1)
+	}
+
+	meta := g.displayContent.Metadata
+	pages := []int{meta.LeftPage}
+	if meta.ActualImages == 2 {
+		pages = append(pages, meta.RightPage)
+	}
+	job := ocrJob{
+		Rotation:  g.rotationAngle,
+		FlipH:     g.flipH,
+		FlipV:     g.flipV,
+		Command:   g.config.OCRCommand,
+		Languages: g.config.OCRLanguages,
+	}
+	if job.Command == "" {
+		job.Command = defaultOCRCommand
+	}
+	if job.Languages == "" {
+		job.Languages = defaultOCRLanguages
+	}
+	if l, ok := g.GetMeasureLine(); ok {
+		if region, ok := ocrRegionFromLine(l, g.measureView); ok {
+			job.Region = region
+		}
+	}
+	for _, page := range pages {
+		if p, ok := g.imageManager.GetPath(page - 1); ok {
+			job.Paths = append(job.Paths, p)
+		}
+	}
+	if len(job.Paths) == 0 {
+		g.ocrActive.Store(false)
+		return
+	}
+
+	results := g.ocrResults
+	if job.Region.IsZero() {
+		g.showOverlayMessage("Recognizing text...")
+	} else {
+		g.showOverlayMessage("Recognizing text in selection...")
+	}
+	debugKV("ocr", "begin", "pages", pages, "region", job.Region, "languages", job.Languages, "command", job.Command)
+	go func() {
+		defer g.ocrActive.Store(false)
+		defer recoverWorker("ocr", "", func(err error) { results <- ocrResult{Region: !job.Region.IsZero(), Err: err} })
+		text, err := runOCRJob(job)
+		if err == nil && text != "" {
+			err = setClipboardText(text, runtime.GOOS)
+		}
+		results <- ocrResult{Text: text, Region: !job.Region.IsZero(), Err: err}
+	}()
+}
+
+func (g *Game) applyOCRResults() bool {
+	select {
+	case res := <-g.ocrResults:
+		switch {
+		case res.Err != nil:
+			g.showOverlayMessage(fmt.Sprintf("OCR failed: %v", res.Err))
+			warnKV("ocr", "failed", "error", res.Err)
+		case res.Text == "":
+			g.showOverlayMessage("OCR: no text found")
+			infoKV("ocr", "empty", "region", res.Region)
+		default:
+			g.showOverlayMessage(fmt.Sprintf("Copied %d characters of text", utf8.RuneCountInString(res.Text)))
+			infoKV("ocr", "copied", "chars", utf8.RuneCountInString(res.Text), "region", res.Region)
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	onScreenControlSize = 56 // Button diameter in screen pixels
+	onScreenControlGap  = 16 // Space between buttons and to the screen edge
+
+	// Share of the width at the left and right edges, and the band at the
+	// top, where pointer movement reveals the controls
+	onScreenEdgeZone = 0.15
+	onScreenTopZone  = onScreenControlSize + onScreenControlGap*2
+
+	// The controls hide this long after the pointer stops moving
+	onScreenControlsLinger = 2 * time.Second
+)
+
+var (
+	onScreenControlBg      = color.RGBA{0, 0, 0, 128}       // Premultiplied
+	onScreenControlHoverBg = color.RGBA{48, 48, 48, 200}    // Premultiplied
+	onScreenControlFg      = color.RGBA{230, 230, 230, 230} // Premultiplied
+)
+
+// onScreenControl is one button of the auto-hiding on-screen controls.
+type onScreenControl struct {
+	action  string
+	rect    image.Rectangle
+	hovered bool
+}
+
+// onScreenControlLayout places the buttons on a w×h screen: previous and
+// next at the middle of the side edges, swapped for right-to-left reading,
+// and fullscreen and the guide overlay at the top right.
+func onScreenControlLayout(w, h int, rightToLeft bool) []onScreenControl {
+	button := func(x, y int) image.Rectangle {
+		return image.Rect(x, y, x+onScreenControlSize, y+onScreenControlSize)
+	}
+	left, right := "previous", "next"
+	if rightToLeft {
+		left, right = right, left
+	}
+	midY := h/2 - onScreenControlSize/2
+	rightX := w - onScreenControlGap - onScreenControlSize
+	return []onScreenControl{
+		{action: left, rect: button(onScreenControlGap, midY)},
+		{action: right, rect: button(rightX, midY)},
+		{action: "fullscreen", rect: button(rightX, onScreenControlGap)},
+		{action: "guides", rect: button(rightX-onScreenControlGap-onScreenControlSize, onScreenControlGap)},
+	}
+}
+
+// nearScreenEdge reports whether (x, y) lies in a zone where pointer
+// movement reveals the controls.
+func nearScreenEdge(x, y, w, h int) bool {
+	edge := int(float64(w) * onScreenEdgeZone)
+	return x < edge || x >= w-edge || y < onScreenTopZone
+}
+
+// screenSize returns the size of the screen Draw receives.
+func (g *Game) screenSize() (int, int) {
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	return int(float64(g.currentLogicalW) * scale), int(float64(g.currentLogicalH) * scale)
+}
+
+// updateOnScreenControls shows the controls while the pointer moves near a
+// screen edge or a touch lands, and hides them once it rests elsewhere. It
+// reports whether their visibility or hover state changed.
+func (g *Game) updateOnScreenControls(now time.Time) bool {
+	x, y := ebiten.CursorPosition()
+	touched := false
+	if touches := inpututil.AppendJustPressedTouchIDs(nil); len(touches) > 0 {
+		x, y = ebiten.TouchPosition(touches[0])
+		touched = true
+	}
+	w, h := g.screenSize()
+	return g.trackOnScreenControls(now, x, y, w, h, touched)
+}
+
+// trackOnScreenControls is updateOnScreenControls for a pointer at (x, y) on
+// a w×h screen.
+func (g *Game) trackOnScreenControls(now time.Time, x, y, w, h int, touched bool) bool {
+	if !g.config.OnScreenControls || g.presenting {
+		changed := g.controlsVisible
+		g.controlsVisible = false
+		return changed
+	}
+
+	g.controlsScreen = image.Pt(w, h)
+	pointer := image.Pt(x, y)
+	moved := pointer != g.controlsPointer
+	g.controlsPointer = pointer
+	if touched || (moved && nearScreenEdge(x, y, w, h)) {
+		g.controlsShownAt = now
+	}
+
+	hovered := -1
+	for i, c := range onScreenControlLayout(w, h, g.config.RightToLeft) {
+		if pointer.In(c.rect) {
+			hovered = i
+		}
+	}
+	visible := hovered >= 0 || now.Sub(g.controlsShownAt) < onScreenControlsLinger
+	if !visible {
+		hovered = -1
+	}
+	changed := visible != g.controlsVisible || hovered != g.controlsHovered
+	g.controlsVisible = visible
+	g.controlsHovered = hovered
+	return changed
+}
+
+// GetOnScreenControls returns the buttons to draw, or nil while they are
+// hidden.
+func (g *Game) GetOnScreenControls() []onScreenControl {
+	if !g.controlsVisible {
+		return nil
+	}
+	controls := onScreenControlLayout(g.controlsScreen.X, g.controlsScreen.Y, g.config.RightToLeft)
+	if g.controlsHovered >= 0 && g.controlsHovered < len(controls) {
+		controls[g.controlsHovered].hovered = true
+	}
+	return controls
+}
+
+// OnScreenControlAt returns the action of the visible button at (x, y).
+func (g *Game) OnScreenControlAt(x, y int) (string, bool) {
+	for _, c := range g.GetOnScreenControls() {
+		if image.Pt(x, y).In(c.rect) {
+			return c.action, true
+		}
+	}
+	return "", false
+}
+
+// drawOnScreenControls draws the visible buttons as translucent discs with
+// a line icon.
+func (r *Renderer) drawOnScreenControls(screen *ebiten.Image) {
+	for _, c := range r.renderState.GetOnScreenControls() {
+		bg := onScreenControlBg
+		if c.hovered {
+			bg = onScreenControlHoverBg
+		}
+		cx := float32(c.rect.Min.X+c.rect.Max.X) / 2
+		cy := float32(c.rect.Min.Y+c.rect.Max.Y) / 2
+		vector.DrawFilledCircle(screen, cx, cy, onScreenControlSize/2, bg, true)
+		drawOnScreenControlIcon(screen, c.action, cx, cy)
+	}
+}
+
+func drawOnScreenControlIcon(screen *ebiten.Image, action string, cx, cy float32) {
+	const s = float32(onScreenControlSize) / 5 // Icon half size
+	const width = 3
+	line := func(x0, y0, x1, y1 float32) {
+		vector.StrokeLine(screen, cx+x0, cy+y0, cx+x1, cy+y1, width, onScreenControlFg, true)
+	}
+	switch action {
+	case "previous", "next":
+		// Chevron pointing at the edge the button sits on
+		dir := float32(1)
+		if cx < float32(screen.Bounds().Dx())/2 {
+			dir = -1
+		}
+		line(-dir*s/2, -s, dir*s/2, 0)
+		line(dir*s/2, 0, -dir*s/2, s)
+	case "fullscreen":
+		// Four corner brackets
+		for _, d := range [][2]float32{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			line(d[0]*s, d[1]*s, d[0]*s/3, d[1]*s)
+			line(d[0]*s, d[1]*s, d[0]*s, d[1]*s/3)
+		}
+	case "guides":
+		// Three by three grid
+		for _, t := range []float32{-s / 3, s / 3} {
+			line(t, -s, t, s)
+			line(-s, t, s, t)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"nv/navlogic"
+)
+
+const (
+	// Pages whose longer side is below this are flagged as low resolution
+	anomalyMinSide = 480
+
+	// A page whose area is this many times smaller or larger than the
+	// typical one nearby is flagged; spreads are only twice a page
+	anomalySizeRatio = 4.0
+
+	anomalyNeighbors = 4 // Pages compared on each side
+)
+
+// knownPageMetrics returns the size of page idx as far as it is known
+// without loading anything: from the planned spread layout, which reads
+// every page header, or else from pages already in the cache.
+func (g *Game) knownPageMetrics(idx int) navlogic.PageMetrics {
+	count := g.imageManager.GetPathsCount()
+	if idx < 0 || idx >= count {
+		return navlogic.PageMetrics{}
+	}
+	if g.spreadLayout != nil && g.spreadLayout.PageCount() == count {
+		return g.spreadLayout.Metrics()[idx]
+	}
+	dm, ok := g.imageManager.(*DefaultImageManager)
+	if !ok || dm.IsLoadFailed(idx) {
+		return navlogic.PageMetrics{}
+	}
+	img, ok := dm.cachedImage(idx)
+	if !ok || dm.isLoadingPlaceholder(img) {
+		return navlogic.PageMetrics{}
+	}
+	b := img.Bounds()
+	return navlogic.PageMetrics{Width: b.Dx(), Height: b.Dy()}
+}
+
+// pageAnomaly describes why page idx looks wrong, "" when it does not:
+// a resolution too low to read, or a size far from the pages around it,
+// which is common for corrupted or placeholder pages.
+func pageAnomaly(lookup navlogic.MetricsLookup, count, idx int) string {
+	m := lookup(idx)
+	if m.Width <= 0 || m.Height <= 0 {
+		return ""
+	}
+	if max(m.Width, m.Height) < anomalyMinSide {
+		return fmt.Sprintf("low resolution %dx%d", m.Width, m.Height)
+	}
+
+	var areas []int
+	for i := max(0, idx-anomalyNeighbors); i < min(count, idx+anomalyNeighbors+1); i++ {
+		if n := lookup(i); i != idx && n.Width > 0 && n.Height > 0 {
+			areas = append(areas, n.Width*n.Height)
+		}
+	}
+	if len(areas) < 2 {
+		return ""
+	}
+	slices.Sort(areas)
+	typical := float64(areas[len(areas)/2])
+	area := float64(m.Width * m.Height)
+	if area*anomalySizeRatio < typical || area > typical*anomalySizeRatio {
+		return fmt.Sprintf("unusual size %dx%d", m.Width, m.Height)
+	}
+	return ""
+}
+
+// displayedAnomaly returns the anomaly of the first page on screen that
+// has one, for the info display badge.
+func (g *Game) displayedAnomaly(plan navlogic.DisplayPlan) string {
+	count := g.imageManager.GetPathsCount()
+	for _, idx := range []int{plan.LeftIndex, plan.RightIndex} {
+		if idx < 0 {
+			continue
+		}
+		if reason := pageAnomaly(g.knownPageMetrics, count, idx); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// jumpToNextAnomaly moves to the next page after the ones on screen that
+// pageAnomaly flags. Without a planned layout only loaded pages are known.
+func (g *Game) jumpToNextAnomaly() {
+	start := g.idx + 1
+	if g.displayContent != nil {
+		start = max(start, g.displayContent.Metadata.LeftPage, g.displayContent.Metadata.RightPage)
+	}
+	count := g.imageManager.GetPathsCount()
+	for i := start; i < count; i++ {
+		if reason := pageAnomaly(g.knownPageMetrics, count, i); reason != "" {
+			debugKV("nav", "next_anomaly", "prev_idx", g.idx, "next_idx", i, "reason", reason)
+			g.jumpToPage(i + 1)
+			g.showOverlayMessage(fmt.Sprintf("Page %d: %s", i+1, reason))
+			return
+		}
+	}
+	g.showOverlayMessage("No unusual pages ahead")
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pageExportResult carries a finished page export back to the game loop.
+type pageExportResult struct {
+	Dir     string
+	Count   int // Files written
+	Skipped int // Pages whose file already existed
+	Failed  int
+	Err     error
+}
+
+// parsePageRange parses "5", "5-12", "5-" or "-12" as 1-based pages and
+// returns the 0-based first and last index. An empty range is every page.
+func parsePageRange(s string, count int) (first, last int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, count - 1, nil
+	}
+	from, to, isRange := strings.Cut(s, "-")
+	first, last = 1, count
+	if from != "" {
+		if first, err = strconv.Atoi(from); err != nil {
+			return 0, 0, fmt.Errorf("invalid page %q", from)
+		}
+	}
+	if !isRange {
+		last = first
+	} else if to != "" {
+		if last, err = strconv.Atoi(to); err != nil {
+			return 0, 0, fmt.Errorf("invalid page %q", to)
+		}
+	}
+	if first < 1 || last > count || first > last {
+		return 0, 0, fmt.Errorf("pages %s out of 1-%d", s, count)
+	}
+	return first - 1, last - 1, nil
+}
+
+// exportFileName numbers the n-th exported page so the files sort in
+// display order, keeping the entry's own name after the number.
+func exportFileName(n, total int, p ImagePath) string {
+	width := max(3, len(strconv.Itoa(total)))
+	base := filepath.Base(p.Path)
+	if p.ArchivePath != "" {
+		base = path.Base(strings.ReplaceAll(p.EntryPath, "\\", "/"))
+	}
+	return fmt.Sprintf("%0*d_%s", width, n, base)
+}
+
+// readImageEntries reads the undecoded data of paths, opening each archive
+// once, and calls fn for every file or archive entry with the indices of the
+// paths naming it and the name to decode it by.
+func readImageEntries(paths []ImagePath, fn func(indices []int, origin string, data []byte, err error)) error {
+	entries := make(map[string]map[string][]int)
+	for i, p := range paths {
+		if p.ArchivePath == "" {
+			data, err := os.ReadFile(p.Path)
+			fn([]int{i}, p.Path, data, err)
+			continue
+		}
+		if entries[p.ArchivePath] == nil {
+			entries[p.ArchivePath] = make(map[string][]int)
+		}
+		entries[p.ArchivePath][p.EntryPath] = append(entries[p.ArchivePath][p.EntryPath], i)
+	}
+
+	for archivePath, wanted := range entries {
+		err := walkArchiveImages(archivePath, func(name string, r io.Reader) error {
+			indices, ok := wanted[name]
+			if !ok {
+				return nil
+			}
+			delete(wanted, name)
+			data, err := io.ReadAll(r)
+			fn(indices, name, data, err)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", filepath.Base(archivePath), err)
+		}
+		for name, indices := range wanted {
+			fn(indices, name, nil, fmt.Errorf("entry %s not found", name))
+		}
+	}
+	return nil
+}
+
+// forEachImageBytes reads the undecoded data of paths, opening each archive
+// once, and calls fn with the index of every path.
+func forEachImageBytes(paths []ImagePath, fn func(i int, data []byte, err error)) error {
+	return readImageEntries(paths, func(indices []int, _ string, data []byte, err error) {
+		for _, i := range indices {
+			fn(i, data, err)
+		}
+	})
+}
+
+// exportPageFiles copies the images of paths into dir unchanged, named by
+// exportFileName, and returns the written files in page order. Existing
+// files are left alone and counted as skipped. Pages that cannot be read are
+// logged and counted; the others are still written.
+func exportPageFiles(paths []ImagePath, dir string) (written []string, skipped, failed int, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, 0, 0, err
+	}
+	outputs := make([]string, len(paths))
+	err = forEachImageBytes(paths, func(i int, data []byte, err error) {
+		output := filepath.Join(dir, exportFileName(i+1, len(paths), paths[i]))
+		if err == nil {
+			err = writeNewFile(output, data)
+		}
+		if errors.Is(err, fs.ErrExist) {
+			skipped++
+			warnKV("collection", "page_export_skipped", "path", output, "reason", "exists")
+			return
+		}
+		if err != nil {
+			failed++
+			warnKV("collection", "page_export_failed", "path", paths[i].Path, "error", err)
+			return
+		}
+		outputs[i] = output
+	})
+	for _, output := range outputs {
+		if output != "" {
+			written = append(written, output)
+		}
+	}
+	return written, skipped, failed, err
+}
+
+// writeNewFile writes data to a file that must not exist yet.
+func writeNewFile(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	return f.Close()
+}
+
+func runExtractSubcommand(args []string) int {
+	flags := newSubcommandFlags("extract", "Copy the images unchanged, numbered in display order so the files sort the same way.", true)
+	pages := flags.set.String("pages", "", "pages to extract, e.g. 5-12 (default: all)")
+	paths, code := flags.parse(args)
+	if paths == nil {
+		return code
+	}
+	first, last, err := parsePageRange(*pages, len(paths))
+	if err != nil {
+		errorKV("collection", "subcommand_invalid_pages", "pages", *pages, "error", err)
+		return 2
+	}
+
+	written, skipped, failed, err := exportPageFiles(paths[first:last+1], *flags.outputDir)
+	for _, output := range written {
+		fmt.Println(output)
+	}
+	if err != nil {
+		errorKV("collection", "subcommand_failed", "command", "extract", "error", err)
+		return 1
+	}
+	if skipped > 0 {
+		warnKV("collection", "subcommand_skipped_existing", "command", "extract", "skipped", skipped)
+	}
+	infoKV("collection", "subcommand_done", "command", "extract", "written", len(written), "skipped", skipped, "failed", failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// pageExportDefaultDir is offered by the export prompt: a folder named
+// after the archive or directory of p, beside it.
+func pageExportDefaultDir(p ImagePath) string {
+	source := p.ArchivePath
+	if source == "" {
+		source = filepath.Dir(p.Path)
+	}
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	return filepath.Join(filepath.Dir(source), base+" pages")
+}
+
+// startPageExport opens the export prompt with the default folder.
+func (g *Game) startPageExport() {
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	g.openTextPrompt(TextPromptExportPages, pageExportDefaultDir(p))
+}
+
+// volumePages returns the pages of the archive or directory showing idx.
+func (g *Game) volumePages(idx int) []ImagePath {
+	current, ok := g.imageManager.GetPath(idx)
+	if !ok {
+		return nil
+	}
+	volume, _ := volumeKey(current)
+	var pages []ImagePath
+	for i := range g.imageManager.GetPathsCount() {
+		if p, ok := g.imageManager.GetPath(i); ok {
+			if v, _ := volumeKey(p); v == volume {
+				pages = append(pages, p)
+			}
+		}
+	}
+	return pages
+}
+
+// splitPageExportInput separates an optional leading page range from the
+// folder: "12-15 shared" exports pages 12 to 15 into "shared".
+func splitPageExportInput(input string) (pages, dir string) {
+	input = strings.TrimSpace(input)
+	head, rest, ok := strings.Cut(input, " ")
+	if ok && head != "" && strings.Trim(head, "0123456789-") == "" && strings.ContainsAny(head, "0123456789") {
+		return head, strings.TrimSpace(rest)
+	}
+	return "", input
+}
+
+// processPageExport copies the pages named by the prompt input into its
+// folder on a background goroutine: the current archive or directory, or
+// the given range of the whole list. Relative folders are resolved beside
+// the current page's archive or directory.
+func (g *Game) processPageExport(input string) {
+	current, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return
+	}
+	pages, dir := splitPageExportInput(input)
+	if dir == "" {
+		g.showOverlayMessage("Export: no folder given")
+		return
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(pageExportDefaultDir(current)), dir)
+	}
+
+	var paths []ImagePath
+	if pages == "" {
+		paths = g.volumePages(g.idx)
+	} else {
+		count := g.imageManager.GetPathsCount()
+		first, last, err := parsePageRange(pages, count)
+		if err != nil {
+			g.showOverlayMessage(fmt.Sprintf("Export: %v", err))
+			return
+		}
+		for i := first; i <= last; i++ {
+			if p, ok := g.imageManager.GetPath(i); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	if !g.pageExportActive.CompareAndSwap(false, true) {
+		g.showOverlayMessage("Page export already running")
+		return
+	}
+	if g.pageExportResults == nil {
+		g.pageExportResults = make(chan pageExportResult, 1)
+	}
+	results := g.pageExportResults
+	g.showOverlayMessage(fmt.Sprintf("Exporting %d pages...", len(paths)))
+	debugKV("collection", "page_export_begin", "dir", dir, "pages", len(paths))
+	go func() {
+		defer g.pageExportActive.Store(false)
+		defer recoverWorker("page_export", dir, func(err error) { results <- pageExportResult{Dir: dir, Err: err} })
+		written, skipped, failed, err := exportPageFiles(paths, dir)
+		results <- pageExportResult{Dir: dir, Count: len(written), Skipped: skipped, Failed: failed, Err: err}
+	}()
+}
+
+func (g *Game) applyPageExportResults() bool {
+	select {
+	case res := <-g.pageExportResults:
+		switch {
+		case res.Err != nil:
+			g.showOverlayMessage(fmt.Sprintf("Export failed: %v", res.Err))
+			warnKV("collection", "page_export_aborted", "dir", res.Dir, "error", res.Err)
+		default:
+			msg := fmt.Sprintf("Exported %d pages to %s", res.Count, filepath.Base(res.Dir))
+			if res.Skipped > 0 {
+				msg += fmt.Sprintf(", %d skipped (already there)", res.Skipped)
+			}
+			if res.Failed > 0 {
+				msg += fmt.Sprintf(", %d failed", res.Failed)
+			}
+			g.showOverlayMessage(msg)
+		}
+		infoKV("collection", "page_export_done", "dir", res.Dir, "written", res.Count, "skipped", res.Skipped, "failed", res.Failed)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// defaultPageScrollFraction is the share of the screen height page panning
+// and smart scrolling move by, leaving some overlap to keep the reader's place.
+const defaultPageScrollFraction = 0.9
+
+// panRangeY returns how far the view can pan up or down from the centered
+// position; 0 when the page fits the screen height.
+func (g *Game) panRangeY() float64 {
+	_, ih := g.getTransformedImageSize()
+	h := float64(g.currentLogicalH) * ebiten.Monitor().DeviceScaleFactor()
+	return max(0, float64(ih)*g.zoomState.Level/2-h/2)
+}
+
+// atPanEdge reports whether the view shows the bottom of the page (dir > 0)
+// or its top (dir < 0). A page fit to the window is always at both edges.
+func (g *Game) atPanEdge(dir int) bool {
+	if g.zoomState.Mode == ZoomModeFitWindow {
+		return true
+	}
+	edge := -float64(dir) * g.panRangeY()
+	return math.Abs(g.zoomState.PanOffsetY-edge) < 0.5
+}
+
+// smartScroll scrolls towards the bottom of a page taller than the screen
+// (dir > 0) or its top by most of a screen, and once that edge is shown
+// turns the page like scroll reading in a comic reader.
+func (g *Game) smartScroll(dir int) {
+	if g.atPanEdge(dir) {
+		g.turnPageFromEdge(dir)
+		return
+	}
+	g.panPage(dir)
+}
+
+// panPage pans down (dir > 0) or up by page_scroll_fraction of the screen
+// height, so zoomed pages read in predictable steps.
+func (g *Game) panPage(dir int) {
+	if g.zoomState.Mode == ZoomModeFitWindow {
+		return
+	}
+	step := float64(g.currentLogicalH) * ebiten.Monitor().DeviceScaleFactor() * g.config.PageScrollFraction
+	g.zoomState.PanOffsetY -= float64(dir) * step
+	g.clampPanToLimits()
+	debugKV("viewport", "pan_page", "dir", dir, "pan_y", g.zoomState.PanOffsetY)
+}
+
+// turnPageAtPanEdge turns the page when pan_turns_page is on and a pan
+// towards the bottom (dir > 0) or top starts at that edge. It reports
+// whether it did.
+func (g *Game) turnPageAtPanEdge(dir int) bool {
+	if !g.config.PanTurnsPage || g.zoomState.Mode == ZoomModeFitWindow || !g.atPanEdge(dir) {
+		return false
+	}
+	g.turnPageFromEdge(dir)
+	return true
+}
+
+// turnPageFromEdge turns forward (dir > 0) or back, keeping the zoom mode and
+// level, and shows the new page from where reading continues: its top going
+// forward, its bottom going back.
+func (g *Game) turnPageFromEdge(dir int) {
+	mode, level := g.zoomState.Mode, g.zoomState.Level
+	prevIdx := g.idx
+	if dir > 0 {
+		g.navigateNext(false)
+	} else {
+		g.navigatePrevious(false)
+	}
+	if g.idx == prevIdx || mode == ZoomModeFitWindow {
+		return
+	}
+
+	g.zoomState.Mode = mode
+	g.zoomState.Level = level
+	if mode != ZoomModeManual {
+		g.updateZoomLevelForFitMode()
+	}
+	g.needsInitialZoomUpdate = false
+	g.needsInitialPanAlign = false
+	g.zoomState.PanOffsetY = float64(dir) * 1e12
+	g.clampPanToLimits()
+	debugKV("viewport", "page_turned_at_edge", "dir", dir, "mode", mode, "pan_y", g.zoomState.PanOffsetY)
+}
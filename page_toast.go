@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// flashPageNumber briefly shows the page number, with the chapter or the
+// folder, whenever the page changes while page_toast is on and the info
+// display is off. Pages turned by an action that showed its own message
+// keep it. It reports whether a toast was shown.
+func (g *Game) flashPageNumber(messageSince time.Time) bool {
+	if g.displayContent == nil {
+		return false
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok || p.Path == g.pageToastPath {
+		return false
+	}
+	first := g.pageToastPath == ""
+	g.pageToastPath = p.Path
+	if first || !g.config.PageToast || g.showInfo || g.overlayMessageTime.After(messageSince) {
+		return false
+	}
+	g.showOverlayMessage(g.pageToastText(p))
+	return true
+}
+
+// pageToastText formats the pages shown as "3-4 / 20" followed by the
+// chapter of an archive, or the folder of loose images.
+func (g *Game) pageToastText(p ImagePath) string {
+	meta := g.displayContent.Metadata
+	text := fmt.Sprintf("%d / %d", meta.LeftPage, meta.TotalPages)
+	if meta.ActualImages == 2 {
+		text = fmt.Sprintf("%d-%d / %d", min(meta.LeftPage, meta.RightPage), max(meta.LeftPage, meta.RightPage), meta.TotalPages)
+	}
+	switch {
+	case meta.Chapter != "":
+		text += "  " + meta.Chapter
+	case p.ArchivePath != "":
+		text += "  " + filepath.Base(p.ArchivePath)
+	default:
+		text += "  " + filepath.Base(filepath.Dir(p.Path))
+	}
+	return text
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"nv/navlogic"
+)
+
+const (
+	pairingsFileName = "pairings.json"
+
+	pairingTogether = "together"
+	pairingApart    = "apart"
+)
+
+// PairingStore remembers the book mode pairings set by hand, kept as a JSON
+// file in the state directory: per volume (see volumeKey), the first page
+// of each pair and whether it is shown together with the next page or
+// apart from it.
+type PairingStore struct {
+	path    string
+	entries map[string]map[string]string // Volume -> page -> pairingTogether or pairingApart
+}
+
+// loadPairingStore reads the file at path. A missing or invalid file yields
+// an empty store.
+func loadPairingStore(path string) *PairingStore {
+	store := &PairingStore{path: path, entries: map[string]map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			warnKV("pairing", "pairings_read_failed", "path", path, "error", err)
+		}
+		return store
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		warnKV("pairing", "pairings_invalid", "path", path, "error", err, "reason", "use_empty")
+		store.entries = map[string]map[string]string{}
+		return store
+	}
+	debugKV("pairing", "pairings_loaded", "path", path, "volumes", len(store.entries))
+	return store
+}
+
+func (s *PairingStore) save() {
+	if s == nil || s.path == "" || readOnly {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		errorKV("pairing", "pairings_dir_create_failed", "path", s.path, "error", err)
+		return
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		errorKV("pairing", "pairings_marshal_failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		errorKV("pairing", "pairings_save_failed", "path", s.path, "error", err)
+	}
+}
+
+// Get returns the pairing set for page of volume and the page after it.
+func (s *PairingStore) Get(volume, page string) navlogic.Pairing {
+	if s == nil {
+		return navlogic.PairAuto
+	}
+	switch s.entries[volume][page] {
+	case pairingTogether:
+		return navlogic.PairTogether
+	case pairingApart:
+		return navlogic.PairApart
+	}
+	return navlogic.PairAuto
+}
+
+// Set remembers the pairing of page of volume; PairAuto forgets it.
+func (s *PairingStore) Set(volume, page string, pairing navlogic.Pairing) {
+	if s == nil {
+		return
+	}
+	pages := s.entries[volume]
+	switch pairing {
+	case navlogic.PairTogether, navlogic.PairApart:
+		if pages == nil {
+			pages = map[string]string{}
+			s.entries[volume] = pages
+		}
+		pages[page] = pairingTogether
+		if pairing == navlogic.PairApart {
+			pages[page] = pairingApart
+		}
+	default:
+		if _, ok := pages[page]; !ok {
+			return
+		}
+		delete(pages, page)
+		if len(pages) == 0 {
+			delete(s.entries, volume)
+		}
+	}
+	s.save()
+}
+
+// RenamePage moves the pairing of oldPage of volume to newPage, for a
+// renamed file.
+func (s *PairingStore) RenamePage(volume, oldPage, newPage string) {
+	if s == nil || oldPage == newPage {
+		return
+	}
+	pages := s.entries[volume]
+	pairing, ok := pages[oldPage]
+	if !ok {
+		return
+	}
+	delete(pages, oldPage)
+	pages[newPage] = pairing
+	s.save()
+}
+
+// syncPagePairings loads the pairings set by hand for the pages of the
+// current list once it changes. It reports whether the display changed.
+func (g *Game) syncPagePairings() bool {
+	if g.imageManager == nil {
+		return false
+	}
+	key := g.currentSpreadLayoutKey()
+	if key == g.pagePairingsKey {
+		return false
+	}
+	g.pagePairingsKey = key
+
+	pairings := map[int]navlogic.Pairing{}
+	if g.pairings != nil && len(g.pairings.entries) > 0 {
+		for i := range key.count {
+			p, _ := g.imageManager.GetPath(i)
+			volume, page := volumeKey(p)
+			if pairing := g.pairings.Get(volume, page); pairing != navlogic.PairAuto {
+				pairings[i] = pairing
+			}
+		}
+	}
+	// Pasted pages always stand alone
+	if len(g.pastedFiles) > 0 {
+		for i := range key.count {
+			if p, _ := g.imageManager.GetPath(i); p.Transient {
+				pairings[i] = navlogic.PairApart
+				if i > 0 {
+					pairings[i-1] = navlogic.PairApart
+				}
+			}
+		}
+	}
+	if len(pairings) == 0 && len(g.pagePairings) == 0 {
+		g.pagePairings = pairings
+		return false
+	}
+	g.pagePairings = pairings
+	if g.spreadLayout != nil {
+		g.spreadLayout = g.spreadLayout.Replan(g.config.AspectRatioThreshold, g.learnedSpreadAspects, g.pagePairings)
+	}
+	g.calculateDisplayContent()
+	debugKV("pairing", "page_pairings_loaded", "pages", key.count, "pairings", len(pairings))
+	return true
+}
+
+// setPairing sets the pairing of page idx and the next one by hand, for
+// this list and, through the store, for its volume.
+func (g *Game) setPairing(idx int, pairing navlogic.Pairing) {
+	g.syncPagePairings()
+	if g.pagePairings == nil {
+		g.pagePairings = map[int]navlogic.Pairing{}
+	}
+	if pairing == navlogic.PairAuto {
+		delete(g.pagePairings, idx)
+	} else {
+		g.pagePairings[idx] = pairing
+	}
+	if p, ok := g.imageManager.GetPath(idx); ok {
+		volume, page := volumeKey(p)
+		g.pairings.Set(volume, page, pairing)
+	}
+	if g.spreadLayout != nil {
+		g.spreadLayout = g.spreadLayout.WithPairing(idx, pairing)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// BlankMode covers the screen with a solid color, e.g. to pause a talk.
+type BlankMode int
+
+const (
+	BlankOff BlankMode = iota
+	BlankBlack
+	BlankWhite
+)
+
+func (m BlankMode) String() string {
+	switch m {
+	case BlankBlack:
+		return "Black"
+	case BlankWhite:
+		return "White"
+	default:
+		return "Off"
+	}
+}
+
+const (
+	laserPointerRadius     = 7
+	laserPointerHaloRadius = 14
+)
+
+var (
+	laserPointerColor = color.RGBA{255, 32, 32, 255}
+	laserPointerHalo  = color.RGBA{96, 0, 0, 96} // Premultiplied
+)
+
+// togglePresentation enters or leaves presentation mode, which hides the
+// info display and overlay messages and shows the laser pointer.
+func (g *Game) togglePresentation() {
+	g.presenting = !g.presenting
+	if !g.presenting {
+		g.showOverlayMessage("Presentation mode off")
+	}
+	g.updateLaserPointer()
+	debugKV("ui", "presentation", "active", g.presenting, "laser_pointer", g.config.PresentationPointer)
+}
+
+func (g *Game) toggleLaserPointer() {
+	g.config.PresentationPointer = !g.config.PresentationPointer
+	if !g.presenting {
+		state := "off"
+		if g.config.PresentationPointer {
+			state = "on"
+		}
+		g.showOverlayMessage("Laser pointer (presentation mode): " + state)
+	}
+	g.updateLaserPointer()
+}
+
+// cycleBlankScreen steps through off, black and white.
+func (g *Game) cycleBlankScreen() {
+	g.blankMode = (g.blankMode + 1) % 3
+	debugKV("ui", "blank_screen", "mode", g.blankMode.String())
+}
+
+func (g *Game) laserPointerVisible() bool {
+	return g.presenting && g.config.PresentationPointer && g.blankMode == BlankOff
+}
+
+// updateLaserPointer follows the mouse while the pointer is shown, with the
+// system cursor hidden. It reports whether the pointer moved.
+func (g *Game) updateLaserPointer() bool {
+	g.updateCursor(time.Now())
+	if !g.laserPointerVisible() {
+		return false
+	}
+	x, y := ebiten.CursorPosition()
+	if x == g.laserX && y == g.laserY {
+		return false
+	}
+	g.laserX, g.laserY = x, y
+	return true
+}
+
+// drawBlankScreen fills the screen when blanking; it reports whether the
+// rest of the frame should be skipped.
+func (r *Renderer) drawBlankScreen(screen *ebiten.Image) bool {
+	switch r.renderState.GetBlankMode() {
+	case BlankBlack:
+		screen.Fill(color.Black)
+	case BlankWhite:
+		screen.Fill(color.White)
+	default:
+		return false
+	}
+	return true
+}
+
+func (r *Renderer) drawLaserPointer(screen *ebiten.Image) {
+	p, ok := r.renderState.GetLaserPointer()
+	if !ok {
+		return
+	}
+	x, y := float32(p.X), float32(p.Y)
+	vector.DrawFilledCircle(screen, x, y, laserPointerHaloRadius, laserPointerHalo, true)
+	vector.DrawFilledCircle(screen, x, y, laserPointerRadius, laserPointerColor, true)
+}
+
+// GetLaserPointer returns the pointer position while it is shown.
+func (g *Game) GetLaserPointer() (image.Point, bool) {
+	if !g.laserPointerVisible() {
+		return image.Point{}, false
+	}
+	return image.Pt(g.laserX, g.laserY), true
+}
+
+func (g *Game) GetBlankMode() BlankMode {
+	return g.blankMode
+}
+
+func (g *Game) IsPresenting() bool {
+	return g.presenting
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"runtime"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// defaultPrintCommand returns the spooler command line for goos. {file} is
+// replaced by the quoted path of a temporary PNG.
+func defaultPrintCommand(goos string) string {
+	if goos == "windows" {
+		// Paint shows the print dialog and scales the image to the page
+		return "mspaint /p {file}"
+	}
+	return "lp -o fit-to-page {file}"
+}
+
+// printJob is a page or spread captured on the game loop for printing.
+type printJob struct {
+	Paths    []ImagePath // Display order, left to right
+	Rotation int
+	FlipH    bool
+	FlipV    bool
+	Command  string
+}
+
+// printResult carries a finished print job back to the game loop.
+type printResult struct {
+	Name string
+	Err  error
+}
+
+// composePrintImage places the pages side by side on white, centered
+// vertically like book mode but without the gap, and applies the view's
+// flips and rotation.
+func composePrintImage(pages []image.Image, rotation int, flipH, flipV bool) image.Image {
+	return transformImage(composePages(pages, 0), rotation, flipH, flipV)
+}
+
+// composePages lays pages out left to right on white with gap pixels
+// between them, centered vertically like book mode.
+func composePages(pages []image.Image, gap int) *image.NRGBA {
+	w, h := gap*max(0, len(pages)-1), 0
+	for _, img := range pages {
+		w += img.Bounds().Dx()
+		h = max(h, img.Bounds().Dy())
+	}
+	sheet := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, xdraw.Src)
+	x := 0
+	for _, img := range pages {
+		b := img.Bounds()
+		y := (h - b.Dy()) / 2
+		xdraw.Draw(sheet, image.Rect(x, y, x+b.Dx(), y+b.Dy()), img, b.Min, xdraw.Over)
+		x += b.Dx() + gap
+	}
+	return sheet
+}
+
+// transformImage flips and then rotates clockwise by angle (a multiple of
+// 90), matching the renderer's view transformations.
+func transformImage(src *image.NRGBA, angle int, flipH, flipV bool) *image.NRGBA {
+	angle = ((angle % 360) + 360) % 360
+	if angle == 0 && !flipH && !flipV {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if angle == 90 || angle == 270 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := range h {
+		for x := range w {
+			fx, fy := x, y
+			if flipH {
+				fx = w - 1 - x
+			}
+			if flipV {
+				fy = h - 1 - y
+			}
+			var dx, dy int
+			switch angle {
+			case 90:
+				dx, dy = h-1-fy, fx
+			case 180:
+				dx, dy = w-1-fx, h-1-fy
+			case 270:
+				dx, dy = fy, w-1-fx
+			default:
+				dx, dy = fx, fy
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// runPrintJob decodes the pages, writes them to a temporary PNG and runs
+// the print command, removing the file once the command has finished.
+func runPrintJob(job printJob) error {
+	pages := make([]image.Image, len(job.Paths))
+	var decodeErr error
+	if err := forEachDecodedImage(job.Paths, func(i int, img image.Image, err error) {
+		if err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+		pages[i] = img
+	}); err != nil {
+		return err
+	}
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	f, err := os.CreateTemp("", "nv-print-*.png")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	defer os.Remove(tmp)
+	if err := writeImageFile(tmp, composePrintImage(pages, job.Rotation, job.FlipH, job.FlipV)); err != nil {
+		return err
+	}
+
+	vars := map[string]string{"file": tmp}
+	line := expandEventCommand(job.Command, vars, runtime.GOOS)
+	out, err := shellCommand(line, vars).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, firstLine(msg))
+		}
+		return err
+	}
+	debugKV("print", "spooled", "command", line, "output", strings.TrimSpace(string(out)))
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// printCurrent prints the current page, or the whole spread in book mode
+// when spread is set, on a background goroutine.
+func (g *Game) printCurrent(spread bool) {
+	if g.displayContent == nil {
+		return
+	}
+	if !g.printActive.CompareAndSwap(false, true) {
+		g.showOverlayMessage("Print job already running")
+		return
+	}
+	if g.printResults == nil {
+		g.printResults = make(chan printResult, 1)
+	}
+
+	meta := g.displayContent.Metadata
+	pages := []int{g.idx + 1}
+	if spread && meta.ActualImages == 2 {
+		pages = []int{meta.LeftPage, meta.RightPage}
+	}
+	job := printJob{Rotation: g.rotationAngle, FlipH: g.flipH, FlipV: g.flipV, Command: g.config.PrintCommand}
+	if job.Command == "" {
+		job.Command = defaultPrintCommand(runtime.GOOS)
+	}
+	for _, page := range pages {
+		if p, ok := g.imageManager.GetPath(page - 1); ok {
+			job.Paths = append(job.Paths, p)
+		}
+	}
+	if len(job.Paths) == 0 {
+		g.printActive.Store(false)
+		return
+	}
+
+	name := contactSheetLabelText(job.Paths[0])
+	if len(job.Paths) > 1 {
+		name += " + " + contactSheetLabelText(job.Paths[1])
+	}
+	results := g.printResults
+	g.showOverlayMessage("Printing " + name + "...")
+	debugKV("print", "begin", "pages", pages, "rotation", job.Rotation, "command", job.Command)
+	go func() {
+		defer g.printActive.Store(false)
+		defer recoverWorker("print", name, func(err error) { results <- printResult{Name: name, Err: err} })
+		results <- printResult{Name: name, Err: runPrintJob(job)}
+	}()
+}
+
+func (g *Game) applyPrintResults() bool {
+	select {
+	case res := <-g.printResults:
+		if res.Err != nil {
+			g.showOverlayMessage(fmt.Sprintf("Print failed: %v", res.Err))
+			warnKV("print", "failed", "name", res.Name, "error", res.Err)
+			return true
+		}
+		g.showOverlayMessage("Sent to printer: " + res.Name)
+		infoKV("print", "sent", "name", res.Name)
+		return true
+	default:
+		return false
+	}
+}
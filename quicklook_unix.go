@@ -0,0 +1,12 @@
+//go:build !windows
+
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// cursorMonitorPosition is only implemented on Windows: ebiten reports the
+// cursor only inside its own window and Wayland has no global position, so
+// the quick look window is centered on the current monitor instead.
+func cursorMonitorPosition() (*ebiten.MonitorType, int, int, bool) {
+	return nil, 0, 0, false
+}
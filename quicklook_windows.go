@@ -0,0 +1,149 @@
+//go:build windows
+
+package main
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procGetCursorPos        = modUser32.NewProc("GetCursorPos")
+	procMonitorFromRect     = modUser32.NewProc("MonitorFromRect")
+	procGetMonitorInfoW     = modUser32.NewProc("GetMonitorInfoW")
+	procEnumDisplayDevicesW = modUser32.NewProc("EnumDisplayDevicesW")
+)
+
+const (
+	monitorDefaultToNearest     = 2
+	displayDeviceActive         = 0x1
+	displayDevicePrimaryDevice  = 0x4
+	displayDeviceNameLength     = 32
+	displayDeviceStringLength   = 128
+	monitorInfoDeviceNameLength = 32
+)
+
+// win32Rect mirrors the Win32 RECT structure.
+type win32Rect struct{ Left, Top, Right, Bottom int32 }
+
+// monitorInfoExW mirrors the Win32 MONITORINFOEXW structure.
+type monitorInfoExW struct {
+	size    uint32
+	monitor win32Rect
+	work    win32Rect
+	flags   uint32
+	device  [monitorInfoDeviceNameLength]uint16
+}
+
+// displayDeviceW mirrors the Win32 DISPLAY_DEVICEW structure.
+type displayDeviceW struct {
+	size       uint32
+	name       [displayDeviceNameLength]uint16
+	str        [displayDeviceStringLength]uint16
+	stateFlags uint32
+	id         [displayDeviceStringLength]uint16
+	key        [displayDeviceStringLength]uint16
+}
+
+func enumDisplayDevice(device string, index uint32) (displayDeviceW, bool) {
+	var dd displayDeviceW
+	dd.size = uint32(unsafe.Sizeof(dd))
+	var name *uint16
+	if device != "" {
+		name, _ = windows.UTF16PtrFromString(device)
+	}
+	r, _, _ := procEnumDisplayDevicesW.Call(uintptr(unsafe.Pointer(name)), uintptr(index), uintptr(unsafe.Pointer(&dd)), 0)
+	return dd, r != 0
+}
+
+// displayAdapterOrder lists the adapter device names (\\.\DISPLAY1, ...) of
+// the active monitors in the order ebiten's GLFW enumerates them: adapter by
+// adapter, with the primary adapter's first display moved to the front.
+func displayAdapterOrder() []string {
+	var order []string
+	for a := uint32(0); ; a++ {
+		adapter, ok := enumDisplayDevice("", a)
+		if !ok {
+			break
+		}
+		if adapter.stateFlags&displayDeviceActive == 0 {
+			continue
+		}
+		name := windows.UTF16ToString(adapter.name[:])
+		primary := adapter.stateFlags&displayDevicePrimaryDevice != 0
+		found := false
+		for d := uint32(0); ; d++ {
+			display, ok := enumDisplayDevice(name, d)
+			if !ok {
+				break
+			}
+			found = true
+			if display.stateFlags&displayDeviceActive == 0 {
+				continue
+			}
+			if primary {
+				order = append([]string{name}, order...)
+				primary = false
+			} else {
+				order = append(order, name)
+			}
+		}
+		if !found {
+			if primary {
+				order = append([]string{name}, order...)
+			} else {
+				order = append(order, name)
+			}
+		}
+	}
+	return order
+}
+
+// cursorMonitorPosition returns the monitor under the cursor and the cursor
+// position on it in device-independent pixels. GetCursorPos reports
+// virtual-desktop pixels, whose origin is the primary monitor, so the
+// position is made relative to the monitor the cursor is on.
+func cursorMonitorPosition() (*ebiten.MonitorType, int, int, bool) {
+	var pt struct{ X, Y int32 }
+	if r, _, _ := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt))); r == 0 {
+		return nil, 0, 0, false
+	}
+	rc := win32Rect{pt.X, pt.Y, pt.X + 1, pt.Y + 1}
+	hmon, _, _ := procMonitorFromRect.Call(uintptr(unsafe.Pointer(&rc)), monitorDefaultToNearest)
+	if hmon == 0 {
+		return nil, 0, 0, false
+	}
+	var info monitorInfoExW
+	info.size = uint32(unsafe.Sizeof(info))
+	if r, _, _ := procGetMonitorInfoW.Call(hmon, uintptr(unsafe.Pointer(&info))); r == 0 {
+		return nil, 0, 0, false
+	}
+
+	monitors := ebiten.AppendMonitors(nil)
+	sizes := make([]image.Point, len(monitors))
+	scales := make([]float64, len(monitors))
+	for i, m := range monitors {
+		w, h := m.Size()
+		sizes[i] = image.Pt(w, h)
+		scales[i] = m.DeviceScaleFactor()
+	}
+	device := windows.UTF16ToString(info.device[:])
+	orderIndex := -1
+	for i, name := range displayAdapterOrder() {
+		if name == device {
+			orderIndex = i
+			break
+		}
+	}
+	bounds := image.Rect(int(info.monitor.Left), int(info.monitor.Top), int(info.monitor.Right), int(info.monitor.Bottom))
+	i := pickQuicklookMonitor(sizes, scales, bounds.Size(), orderIndex)
+	if i < 0 {
+		debugKV("quicklook", "monitor_unmatched", "device", device, "bounds", bounds)
+		return nil, 0, 0, false
+	}
+	x, y := virtualToMonitorPoint(image.Pt(int(pt.X), int(pt.Y)), bounds.Min, scales[i])
+	return monitors[i], x, y, true
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	ratingsFileName = "ratings.json"
+	maxRating       = 5
+)
+
+// RatingEntry is the rating and tag set stored for one image.
+type RatingEntry struct {
+	Rating int      `json:"rating,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func (e RatingEntry) isEmpty() bool {
+	return e.Rating == 0 && len(e.Tags) == 0
+}
+
+// RatingStore is the sidecar database of ratings and tags, kept as a JSON
+// file in the state directory and keyed by ratingKey.
+type RatingStore struct {
+	path    string
+	entries map[string]RatingEntry
+}
+
+// ratingsPathForConfig returns the sidecar database path for a config path;
+// an empty configPath means the default config.
+func ratingsPathForConfig(configPath string) string {
+	return statePathForConfig(configPath, ratingsFileName)
+}
+
+// loadRatingStore reads the database at path. A missing or invalid file
+// yields an empty store so ratings never block startup.
+func loadRatingStore(path string) *RatingStore {
+	store := &RatingStore{path: path, entries: map[string]RatingEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			warnKV("ratings", "ratings_read_failed", "path", path, "error", err)
+		}
+		return store
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		warnKV("ratings", "ratings_invalid", "path", path, "error", err, "reason", "use_empty")
+		store.entries = map[string]RatingEntry{}
+		return store
+	}
+	debugKV("ratings", "ratings_loaded", "path", path, "entries", len(store.entries))
+	return store
+}
+
+func (s *RatingStore) save() {
+	if s == nil || s.path == "" || readOnly {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		errorKV("ratings", "ratings_dir_create_failed", "path", s.path, "error", err)
+		return
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		errorKV("ratings", "ratings_marshal_failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		errorKV("ratings", "ratings_save_failed", "path", s.path, "error", err)
+	}
+}
+
+// Get returns the entry for key; a nil store has no entries.
+func (s *RatingStore) Get(key string) RatingEntry {
+	if s == nil {
+		return RatingEntry{}
+	}
+	return s.entries[key]
+}
+
+func (s *RatingStore) put(key string, entry RatingEntry) {
+	if entry.isEmpty() {
+		delete(s.entries, key)
+	} else {
+		s.entries[key] = entry
+	}
+	s.save()
+}
+
+// SetRating stores rating (0 clears it) for key.
+func (s *RatingStore) SetRating(key string, rating int) {
+	entry := s.entries[key]
+	entry.Rating = rating
+	s.put(key, entry)
+}
+
+// ToggleTag adds tag to key, or removes it if already present. It reports
+// whether the tag is set afterwards.
+func (s *RatingStore) ToggleTag(key, tag string) bool {
+	entry := s.entries[key]
+	if i := slices.Index(entry.Tags, tag); i >= 0 {
+		entry.Tags = slices.Delete(slices.Clone(entry.Tags), i, i+1)
+		s.put(key, entry)
+		return false
+	}
+	entry.Tags = append(slices.Clone(entry.Tags), tag)
+	slices.Sort(entry.Tags)
+	s.put(key, entry)
+	return true
+}
+
+// RemoveTag removes tag from key, reporting whether it was present.
+func (s *RatingStore) RemoveTag(key, tag string) bool {
+	entry := s.entries[key]
+	i := slices.Index(entry.Tags, tag)
+	if i < 0 {
+		return false
+	}
+	entry.Tags = slices.Delete(slices.Clone(entry.Tags), i, i+1)
+	s.put(key, entry)
+	return true
+}
+
+// Rename moves the entry of oldKey to newKey, for a renamed file.
+func (s *RatingStore) Rename(oldKey, newKey string) {
+	if s == nil || oldKey == newKey {
+		return
+	}
+	entry, ok := s.entries[oldKey]
+	if !ok {
+		return
+	}
+	delete(s.entries, oldKey)
+	s.entries[newKey] = entry
+	s.save()
+}
+
+// ratingKey identifies an image independently of the working directory.
+// Archive entries are keyed by archive path plus entry path.
+func ratingKey(imagePath ImagePath) string {
+	if imagePath.ArchivePath != "" {
+		return absPathOrSelf(imagePath.ArchivePath) + ":" + imagePath.EntryPath
+	}
+	return absPathOrSelf(imagePath.Path)
+}
+
+func absPathOrSelf(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// CollectionFilter narrows the active path list by rating, tags and an
+// optional filename pattern.
+type CollectionFilter struct {
+	MinRating int
+	Tags      []string
+	Pattern   string // Filename pattern from the live name filter
+}
+
+func (f CollectionFilter) Active() bool {
+	return f.MinRating > 0 || len(f.Tags) > 0 || f.Pattern != ""
+}
+
+// Matches reports whether entry satisfies every filter condition.
+func (f CollectionFilter) Matches(entry RatingEntry) bool {
+	if entry.Rating < f.MinRating {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(entry.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes every active condition, including the name pattern,
+// for the info display.
+func (f CollectionFilter) Summary() string {
+	summary := f.String()
+	if f.Pattern != "" {
+		summary = strings.TrimSpace(summary + " /" + f.Pattern + "/")
+	}
+	return summary
+}
+
+// String returns the rating and tag terms in parseCollectionFilter syntax.
+func (f CollectionFilter) String() string {
+	var parts []string
+	if f.MinRating > 0 {
+		parts = append(parts, fmt.Sprintf(">=%d", f.MinRating))
+	}
+	for _, tag := range f.Tags {
+		parts = append(parts, "tag:"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseCollectionFilter parses space-separated filter terms: ">=N" or "N+"
+// for a minimum rating and "tag:name" for a required tag. Empty input yields
+// an inactive filter.
+func parseCollectionFilter(input string) (CollectionFilter, error) {
+	var filter CollectionFilter
+	for _, term := range strings.Fields(input) {
+		if tag, ok := strings.CutPrefix(term, "tag:"); ok {
+			if tag == "" {
+				return CollectionFilter{}, fmt.Errorf("empty tag in %q", term)
+			}
+			if !slices.Contains(filter.Tags, tag) {
+				filter.Tags = append(filter.Tags, tag)
+			}
+			continue
+		}
+
+		digits, ok := strings.CutPrefix(term, ">=")
+		if !ok {
+			digits, ok = strings.CutSuffix(term, "+")
+		}
+		rating, err := strconv.Atoi(digits)
+		if !ok || err != nil || rating < 1 || rating > maxRating {
+			return CollectionFilter{}, fmt.Errorf("unknown filter term %q", term)
+		}
+		filter.MinRating = rating
+	}
+	return filter, nil
+}
+
+// filterPaths returns the paths whose stored entry matches filter.
+func filterPaths(paths []ImagePath, filter CollectionFilter, store *RatingStore) []ImagePath {
+	if !filter.Active() {
+		return paths
+	}
+	filtered := make([]ImagePath, 0, len(paths))
+	for _, p := range paths {
+		if filter.Matches(store.Get(ratingKey(p))) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filterPathsByName(filtered, filter.Pattern)
+}
+
+func formatRatingStars(rating int) string {
+	return strings.Repeat("★", rating) + strings.Repeat("☆", maxRating-rating)
+}
+
+// currentRatingKey returns the ratings key of the current page. Pasted
+// pages have none; their file is gone after the session.
+func (g *Game) currentRatingKey() (string, bool) {
+	imagePath, ok := g.imageManager.GetPath(g.idx)
+	if !ok || g.refusePastedPage(imagePath, "rated or tagged") {
+		return "", false
+	}
+	return ratingKey(imagePath), true
+}
+
+func (g *Game) setCurrentRating(rating int) {
+	key, ok := g.currentRatingKey()
+	if !ok || g.ratings == nil {
+		return
+	}
+	g.ratings.SetRating(key, rating)
+	if rating == 0 {
+		g.showOverlayMessage("Rating cleared")
+	} else {
+		g.showOverlayMessage("Rating: " + formatRatingStars(rating))
+	}
+	g.calculateDisplayContent()
+	debugKV("ratings", "set_rating", "key", key, "rating", rating)
+}
+
+func (g *Game) enterTagInput() {
+	if _, ok := g.currentRatingKey(); !ok || g.ratings == nil {
+		return
+	}
+	g.openTextPrompt(TextPromptTag, "")
+}
+
+// processTagInput toggles each space-separated tag on the current image;
+// a "-" prefix removes the tag instead.
+func (g *Game) processTagInput(input string) {
+	key, ok := g.currentRatingKey()
+	if !ok || g.ratings == nil {
+		return
+	}
+
+	var changes []string
+	for _, tag := range strings.Fields(input) {
+		if name, remove := strings.CutPrefix(tag, "-"); remove {
+			if name != "" && g.ratings.RemoveTag(key, name) {
+				changes = append(changes, "-"+name)
+			}
+			continue
+		}
+		if g.ratings.ToggleTag(key, tag) {
+			changes = append(changes, "+"+tag)
+		} else {
+			changes = append(changes, "-"+tag)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	g.showOverlayMessage("Tags: " + strings.Join(changes, " "))
+	g.calculateDisplayContent()
+	debugKV("ratings", "tags_changed", "key", key, "changes", changes)
+}
+
+func (g *Game) enterFilterInput() {
+	g.openTextPrompt(TextPromptFilter, g.collectionFilter.String())
+}
+
+// processFilterInput applies a new collection filter. A filter that matches
+// nothing is rejected so the current list stays usable.
+func (g *Game) processFilterInput(input string) {
+	filter, err := parseCollectionFilter(input)
+	if err != nil {
+		g.showOverlayMessage(fmt.Sprintf("Filter: %v", err))
+		return
+	}
+
+	prev := g.collectionFilter
+	filter.Pattern = prev.Pattern
+	g.collectionFilter = filter
+	if !g.reloadPathsForCurrentSource() {
+		g.collectionFilter = prev
+		g.showOverlayMessage("Filter: no images match " + filter.String())
+		return
+	}
+	g.imageManager.StartPreload(g.idx, NavigationJump)
+
+	count := g.imageManager.GetPathsCount()
+	if filter.Active() {
+		g.showOverlayMessage(fmt.Sprintf("Filter %s: %d image(s)", filter.String(), count))
+	} else {
+		g.showOverlayMessage(fmt.Sprintf("Filter cleared: %d image(s)", count))
+	}
+	debugKV("ratings", "filter_applied", "filter", filter.String(), "paths_count", count)
+}
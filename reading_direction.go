@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	directionsFileName = "directions.json"
+
+	directionLTR = "ltr"
+	directionRTL = "rtl"
+)
+
+// DirectionStore remembers the reading direction chosen for each volume, an
+// archive or a directory of images, kept as a JSON file in the state
+// directory and keyed by volumeKey.
+type DirectionStore struct {
+	path    string
+	entries map[string]string // directionLTR or directionRTL
+}
+
+// newDirectionStoreForConfig loads the directions file, or returns nil when
+// remember_reading_direction is off.
+func newDirectionStoreForConfig(config Config, configPath string) *DirectionStore {
+	if !config.RememberDirection {
+		return nil
+	}
+	return loadDirectionStore(statePathForConfig(configPath, directionsFileName))
+}
+
+// loadDirectionStore reads the file at path. A missing or invalid file yields
+// an empty store.
+func loadDirectionStore(path string) *DirectionStore {
+	store := &DirectionStore{path: path, entries: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			warnKV("direction", "directions_read_failed", "path", path, "error", err)
+		}
+		return store
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		warnKV("direction", "directions_invalid", "path", path, "error", err, "reason", "use_empty")
+		store.entries = map[string]string{}
+		return store
+	}
+	debugKV("direction", "directions_loaded", "path", path, "entries", len(store.entries))
+	return store
+}
+
+func (s *DirectionStore) save() {
+	if s == nil || s.path == "" || readOnly {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		errorKV("direction", "directions_dir_create_failed", "path", s.path, "error", err)
+		return
+	}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		errorKV("direction", "directions_marshal_failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		errorKV("direction", "directions_save_failed", "path", s.path, "error", err)
+	}
+}
+
+// Get returns whether volume key reads right to left, and whether a
+// direction was remembered for it.
+func (s *DirectionStore) Get(key string) (rightToLeft, ok bool) {
+	if s == nil {
+		return false, false
+	}
+	dir, ok := s.entries[key]
+	return dir == directionRTL, ok
+}
+
+// Set remembers the direction of volume key.
+func (s *DirectionStore) Set(key string, rightToLeft bool) {
+	dir := directionLTR
+	if rightToLeft {
+		dir = directionRTL
+	}
+	if s.entries[key] == dir {
+		return
+	}
+	s.entries[key] = dir
+	s.save()
+}
+
+// currentVolume returns the volume of the current page.
+func (g *Game) currentVolume() (string, bool) {
+	if g.imageManager == nil {
+		return "", false
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok || p.Transient {
+		return "", false // Pasted pages belong to no volume
+	}
+	// volumeKey resolves absolute paths, so it is only run on page changes
+	if p.Path != g.directionPagePath {
+		g.directionPagePath = p.Path
+		g.directionPageVolume, _ = volumeKey(p)
+	}
+	return g.directionPageVolume, true
+}
+
+// applyVolumeDirection switches to the reading direction of the volume of
+// the current page once it changes: the one remembered for it, or with
+// auto_reading_direction one detected from its path or metadata, which is
+// announced. Other volumes keep the current direction. It reports whether
+// the direction changed.
+func (g *Game) applyVolumeDirection() bool {
+	if g.directions == nil && !g.config.AutoDirection {
+		return false
+	}
+	key, ok := g.currentVolume()
+	if !ok || key == g.directionVolume {
+		return false
+	}
+	g.directionVolume = key
+	rightToLeft, ok := g.directions.Get(key)
+	source := ""
+	if !ok && g.config.AutoDirection {
+		rightToLeft, source, ok = g.detectVolumeDirection(key)
+	}
+	if !ok || rightToLeft == g.config.RightToLeft {
+		return false
+	}
+	if !g.volumeDirectionActive {
+		g.readerRightToLeft = g.config.RightToLeft
+		g.volumeDirectionActive = true
+	}
+	g.config.RightToLeft = rightToLeft
+	g.calculateDisplayContent()
+	if source != "" {
+		direction := "Left-to-Right"
+		if rightToLeft {
+			direction = "Right-to-Left"
+		}
+		g.showOverlayMessage(fmt.Sprintf("Reading Direction: %s (%s)", direction, source))
+	}
+	debugKV("direction", "volume_direction_applied", "volume", key, "rtl", rightToLeft, "source", source)
+	return true
+}
+
+// rememberVolumeDirection stores the current reading direction for the
+// volume of the current page.
+func (g *Game) rememberVolumeDirection() {
+	if g.directions == nil {
+		return
+	}
+	key, ok := g.currentVolume()
+	if !ok {
+		return
+	}
+	g.directionVolume = key
+	g.directions.Set(key, g.config.RightToLeft)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	defaultReadingTimerSeconds = 20
+
+	// The progress bar is redrawn only when it grows by one step
+	readingTimerSteps = 200
+
+	readingTimerBarHeight = 3
+)
+
+var (
+	readingTimerColor     = color.NRGBA{255, 255, 255, 110}
+	readingTimerDoneColor = color.NRGBA{255, 220, 80, 170}
+)
+
+// setReadingTimer starts or stops the guided reading timer. Unlike the
+// slideshow it shows how much of the estimated reading time has passed
+// and only turns pages when reading_timer_advance is on.
+func (g *Game) setReadingTimer(on bool) {
+	if on == g.readingTimerActive {
+		return
+	}
+	if on && g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+	g.readingTimerActive = on
+	g.readingTimerElapsed = 0
+	g.readingTimerIdx = g.idx
+	if on {
+		mode := "indicator only"
+		if g.config.ReadingTimerAdvance {
+			mode = "auto-advance"
+		}
+		g.showOverlayMessage(fmt.Sprintf("Reading timer: %ds per page, %s", g.config.ReadingTimerSeconds, mode))
+	} else {
+		g.showOverlayMessage("Reading timer stopped")
+	}
+	debugKV("navigation", "reading_timer", "active", on, "seconds", g.config.ReadingTimerSeconds, "advance", g.config.ReadingTimerAdvance, "idx", g.idx)
+}
+
+func (g *Game) toggleReadingTimer() {
+	g.setReadingTimer(!g.readingTimerActive)
+}
+
+// readingTimerBudget is the time allowed for the pages on screen, so a
+// book mode spread gets twice the time of a single page.
+func (g *Game) readingTimerBudget() time.Duration {
+	pages := 1
+	if g.displayContent != nil {
+		pages = max(1, g.displayContent.Metadata.ActualImages)
+	}
+	return time.Duration(pages*g.config.ReadingTimerSeconds) * time.Second
+}
+
+// advanceReadingTimer counts the time on the current page. Manual
+// navigation restarts the timer. With auto-advance the next page is shown
+// when the time is up, and the timer stops on the last page. It reports
+// whether the display changed.
+func (g *Game) advanceReadingTimer(tick time.Duration) bool {
+	if !g.readingTimerActive {
+		return false
+	}
+	if g.idx != g.readingTimerIdx {
+		g.readingTimerIdx = g.idx
+		g.readingTimerElapsed = 0
+	}
+	budget := g.readingTimerBudget()
+	if g.readingTimerElapsed >= budget && !g.config.ReadingTimerAdvance {
+		return false
+	}
+	g.readingTimerElapsed += tick
+	if g.readingTimerElapsed < budget || !g.config.ReadingTimerAdvance {
+		return false
+	}
+
+	g.readingTimerElapsed = 0
+	prev := g.idx
+	g.NavigateNext()
+	if g.idx == prev {
+		g.readingTimerActive = false
+		g.showOverlayMessage("Reading timer finished")
+		debugKV("navigation", "reading_timer_finished", "idx", g.idx)
+		return true
+	}
+	g.readingTimerIdx = g.idx
+	return true
+}
+
+// GetReadingTimer returns the share of the page's reading time that has
+// passed, from 0 to 1, while the timer runs.
+func (g *Game) GetReadingTimer() (float64, bool) {
+	if !g.readingTimerActive {
+		return 0, false
+	}
+	budget := g.readingTimerBudget()
+	if budget <= 0 {
+		return 1, true
+	}
+	return min(1, float64(g.readingTimerElapsed)/float64(budget)), true
+}
+
+// readingTimerStep quantizes the timer progress for the render snapshot,
+// -1 while the timer is off.
+func readingTimerStep(progress float64, active bool) int {
+	if !active {
+		return -1
+	}
+	return int(progress * readingTimerSteps)
+}
+
+// drawReadingTimer draws a thin progress bar along the bottom edge. It
+// turns yellow once the time for the page is up.
+func (r *Renderer) drawReadingTimer(screen *ebiten.Image) {
+	progress, ok := r.renderState.GetReadingTimer()
+	if !ok {
+		return
+	}
+	w, h := float32(screen.Bounds().Dx()), float32(screen.Bounds().Dy())
+	clr := readingTimerColor
+	if progress >= 1 {
+		clr = readingTimerDoneColor
+	}
+	vector.DrawFilledRect(screen, 0, h-readingTimerBarHeight, w*float32(progress), readingTimerBarHeight, clr, false)
+}
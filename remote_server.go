@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/jpeg"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// remoteMaxImageSize bounds the longest edge of images sent to browsers.
+	remoteMaxImageSize   = 2048
+	remoteJPEGQuality    = 85
+	remoteCommandBacklog = 16
+	remoteImageCacheSize = 4
+	// remoteTokenCookie carries the access token after the first page load,
+	// so the page's own requests need not repeat it.
+	remoteTokenCookie = "nv_remote"
+)
+
+//go:embed remote/index.html
+var remoteIndexHTML []byte
+
+// remoteActions maps the commands accepted from browsers to viewer actions.
+// Only navigation is exposed.
+var remoteActions = map[string]string{
+	"next":     "next",
+	"previous": "previous",
+	"first":    "jump_first",
+	"last":     "jump_last",
+}
+
+// remoteState is the session snapshot served to browsers. It is replaced
+// as a whole from the game loop and never mutated afterwards.
+type remoteState struct {
+	Version uint64   `json:"version"`
+	Pages   []int    `json:"pages"` // Displayed page numbers, left to right
+	Total   int      `json:"total"`
+	Names   []string `json:"names"`
+
+	paths []ImagePath
+}
+
+type remoteImage struct {
+	key  imageCacheKey // Changes when the file is rewritten
+	data []byte
+}
+
+// remoteServer mirrors the session over HTTP. Handlers run on server
+// goroutines; navigation requests are queued for the game loop. Every
+// request needs the random token of the session, given in the URL once and
+// kept in a cookie, and requests from other origins are refused, so other
+// people on the network and web pages in the same browser cannot watch or
+// drive the session.
+type remoteServer struct {
+	token    string
+	state    atomic.Pointer[remoteState]
+	commands chan string
+	server   *http.Server
+	listener net.Listener
+
+	mu     sync.Mutex
+	images []remoteImage // Recently encoded images, newest last
+}
+
+func newRemoteServer() *remoteServer {
+	s := &remoteServer{token: newRemoteToken(), commands: make(chan string, remoteCommandBacklog)}
+	s.state.Store(&remoteState{})
+	s.server = &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// newRemoteToken returns a random access token for one session.
+func newRemoteToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// remoteListenAddr binds an address without a host, such as ":8080", to the
+// loopback interface; other devices need an explicit host or 0.0.0.0.
+func remoteListenAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// Start listens on addr and serves in the background.
+func (s *remoteServer) Start(addr string) error {
+	ln, err := net.Listen("tcp", remoteListenAddr(addr))
+	if err != nil {
+		return err
+	}
+	s.listener = ln
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			warnKV("remote", "serve_failed", "addr", addr, "error", err)
+		}
+	}()
+	infoKV("remote", "listening", "addr", ln.Addr().String(), "url", s.URL())
+	return nil
+}
+
+// URL is the address to open in a browser, with the access token. A server
+// listening on all interfaces is shown under the host name.
+func (s *remoteServer) URL() string {
+	host := "127.0.0.1"
+	port := "0"
+	if s.listener != nil {
+		addr := s.listener.Addr().(*net.TCPAddr)
+		port = strconv.Itoa(addr.Port)
+		host = addr.IP.String()
+		if addr.IP.IsUnspecified() {
+			if name, err := os.Hostname(); err == nil {
+				host = name
+			}
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/?token=" + s.token
+}
+
+func (s *remoteServer) Close() {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		debugKV("remote", "shutdown_failed", "error", err)
+	}
+}
+
+func (s *remoteServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && s.validToken(token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     remoteTokenCookie,
+				Value:    s.token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(remoteIndexHTML)
+	})
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(s.state.Load())
+	})
+	mux.HandleFunc("GET /image", s.serveImage)
+	mux.HandleFunc("POST /action/{name}", func(w http.ResponseWriter, r *http.Request) {
+		action, ok := remoteActions[r.PathValue("name")]
+		if !ok {
+			http.Error(w, "unknown action", http.StatusNotFound)
+			return
+		}
+		select {
+		case s.commands <- action:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		}
+	})
+	return s.guard(mux)
+}
+
+// guard refuses requests from other origins and, except for the page
+// itself, requests without the session token.
+func (s *remoteServer) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			warnKV("remote", "request_refused", "path", r.URL.Path, "origin", r.Header.Get("Origin"), "reason", "cross_origin")
+			http.Error(w, "cross-origin request", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/" {
+			// The page loads with the token in its URL; its requests then
+			// carry the cookie set for it.
+			if !s.validToken(r.URL.Query().Get("token")) && !s.authorized(r) {
+				http.Error(w, "open the address nv printed, including its token", http.StatusUnauthorized)
+				return
+			}
+		} else if !s.authorized(r) {
+			warnKV("remote", "request_refused", "path", r.URL.Path, "reason", "no_token")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries the session token in its cookie or
+// its query.
+func (s *remoteServer) authorized(r *http.Request) bool {
+	if c, err := r.Cookie(remoteTokenCookie); err == nil && s.validToken(c.Value) {
+		return true
+	}
+	return s.validToken(r.URL.Query().Get("token"))
+}
+
+func (s *remoteServer) validToken(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// sameOrigin reports whether r comes from the remote viewer page itself or
+// from outside a browser. Browsers send Origin on cross-origin and POST
+// requests, and Sec-Fetch-Site on all requests.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// serveImage sends the displayed page in slot (0 = left) as JPEG. Only
+// pages currently on screen can be fetched.
+func (s *remoteServer) serveImage(w http.ResponseWriter, r *http.Request) {
+	state := s.state.Load()
+	slot, err := strconv.Atoi(r.URL.Query().Get("slot"))
+	if err != nil || slot < 0 || slot >= len(state.paths) {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := s.encodedImage(state.paths[slot])
+	if err != nil {
+		warnKV("remote", "image_failed", "path", state.paths[slot].Path, "error", err)
+		http.Error(w, "cannot decode image", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Write(data)
+}
+
+// encodedImage decodes p and encodes it as a downscaled JPEG, keeping the
+// last few results so both pages of a spread and reloads stay cheap.
+func (s *remoteServer) encodedImage(p ImagePath) ([]byte, error) {
+	key := newImageCacheKey(p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cached := range s.images {
+		if cached.key == key {
+			return cached.data, nil
+		}
+	}
+
+	var decoded image.Image
+	var decodeErr error
+	if err := forEachDecodedImage([]ImagePath{p}, func(_ int, img image.Image, err error) {
+		decoded, decodeErr = img, err
+	}); err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleToFit(decoded, remoteMaxImageSize), &jpeg.Options{Quality: remoteJPEGQuality}); err != nil {
+		return nil, err
+	}
+
+	if len(s.images) == remoteImageCacheSize {
+		s.images = s.images[1:]
+	}
+	s.images = append(s.images, remoteImage{key: key, data: buf.Bytes()})
+	return buf.Bytes(), nil
+}
+
+// publish replaces the served state when the displayed pages changed.
+func (s *remoteServer) publish(paths []ImagePath, pages []int, total int) {
+	prev := s.state.Load()
+	if prev.Total == total && equalImagePaths(prev.paths, paths) {
+		return
+	}
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = contactSheetLabelText(p)
+	}
+	s.state.Store(&remoteState{
+		Version: prev.Version + 1,
+		Pages:   pages,
+		Total:   total,
+		Names:   names,
+		paths:   paths,
+	})
+}
+
+func equalImagePaths(a, b []ImagePath) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// startRemoteServer serves the session on addr for the --serve flag.
+func (g *Game) startRemoteServer(addr string) error {
+	server := newRemoteServer()
+	if err := server.Start(addr); err != nil {
+		return err
+	}
+	g.remote = server
+	g.publishRemoteState()
+	g.showOverlayMessage("Remote viewer: " + server.URL())
+	return nil
+}
+
+// publishRemoteState shares the pages on screen with remote viewers.
+func (g *Game) publishRemoteState() {
+	if g.remote == nil {
+		return
+	}
+	var paths []ImagePath
+	var pages []int
+	total := 0
+	if g.displayContent != nil {
+		meta := g.displayContent.Metadata
+		total = meta.TotalPages
+		slots := []int{meta.LeftPage}
+		if meta.ActualImages == 2 {
+			slots = append(slots, meta.RightPage)
+		}
+		for _, page := range slots {
+			if p, ok := g.imageManager.GetPath(page - 1); ok {
+				paths = append(paths, p)
+				pages = append(pages, page)
+			}
+		}
+	}
+	g.remote.publish(paths, pages, total)
+}
+
+// applyRemoteCommands runs navigation requested by remote viewers.
+func (g *Game) applyRemoteCommands() bool {
+	if g.remote == nil {
+		return false
+	}
+	applied := false
+	for {
+		select {
+		case action := <-g.remote.commands:
+			debugKV("remote", "action", "action", action)
+			globalActionExecutor.ExecuteAction(action, g, g)
+			applied = true
+		default:
+			return applied
+		}
+	}
+}
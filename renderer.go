@@ -39,14 +39,32 @@ type Renderer struct {
 	lastSnapshot   *RenderStateSnapshot // Previous frame's state for comparison
 	bookCache      rendererBookCache
 	transformCache rendererTransformCache
+	compareCache   rendererCompareCache
+	view           viewTransform // Placement of the last drawn canvas
+
+	filterShader       *ebiten.Shader
+	filterShaderFailed bool
+
+	postBuffer   *ebiten.Image  // Frame drawn before the custom shader pass
+	failedShader *ebiten.Shader // Custom shader that panicked while drawing
+
+	faces        map[float64]text.Face // Help font by size, see face
+	help         *helpLayout           // Last measured help overlay
+	infoPanel    textPanel
+	messagePanel textPanel
+
+	diagnosticsPanel textPanel // Debug mode load timings
 }
 
+// rendererBookCache holds the last composed spread, keyed by its two pages.
 type rendererBookCache struct {
 	left  *ebiten.Image
 	right *ebiten.Image
 	image *ebiten.Image
 }
 
+// rendererTransformCache holds the last rotated or flipped image, keyed by
+// its source and the transformation.
 type rendererTransformCache struct {
 	source   *ebiten.Image
 	rotation int
@@ -96,10 +114,32 @@ func (r *Renderer) getActionsList() []string {
 	return actions
 }
 
-// Draw renders the entire screen
+// Draw renders the entire screen, through the custom shader when one is
+// active
 func (r *Renderer) Draw(screen *ebiten.Image) {
+	if shader, uniforms := r.renderState.GetCustomShader(); shader != nil && shader != r.failedShader {
+		r.drawPostProcessed(screen, shader, uniforms)
+		return
+	}
+	r.drawFrame(screen)
+}
+
+func (r *Renderer) drawFrame(screen *ebiten.Image) {
 	// Clear the screen since SetScreenClearedEveryFrame(false) is enabled
-	screen.Clear()
+	screen.Fill(r.renderState.GetBackgroundColor())
+
+	if r.drawBlankScreen(screen) {
+		return
+	}
+
+	// Nothing loaded yet: show the start screen instead of an image
+	if r.renderState.GetTotalPagesCount() == 0 {
+		r.drawEmptyState(screen)
+		if r.renderState.GetOverlayMessage() != "" && time.Since(r.renderState.GetOverlayMessageTime()) < overlayMessageDuration {
+			r.drawOverlayMessage(screen)
+		}
+		return
+	}
 
 	// Get display content - all rendering decisions are already made
 	content := r.renderState.GetDisplayContent()
@@ -109,13 +149,37 @@ func (r *Renderer) Draw(screen *ebiten.Image) {
 	}
 
 	// Draw images (unified handling for single and book mode)
-	r.drawImagesDirect(screen, content.LeftImage, content.RightImage)
+	if !r.drawCompare(screen, content) {
+		r.drawImagesDirect(screen, content.LeftImage, content.RightImage)
+	}
+	r.drawGuides(screen)
+	r.drawOnScreenControls(screen)
+
+	// Presentation mode hides status overlays; prompts opened on purpose stay
+	presenting := r.renderState.IsPresenting()
+
+	if !presenting {
+		r.drawNextPagePreview(screen)
+		r.drawLoadDiagnostics(screen)
+	}
 
 	// Draw info display (page status, etc.) at bottom of screen if enabled
-	if r.renderState.IsShowingInfo() {
+	if r.renderState.IsShowingInfo() && !presenting {
 		r.drawInfoDisplay(screen)
 	}
 
+	// Draw unreadable image list if enabled
+	if r.renderState.IsShowingLoadErrors() && !presenting {
+		r.drawLoadErrorsOverlay(screen)
+	}
+
+	// Draw reading statistics if enabled
+	if r.renderState.IsShowingReadingStats() && !presenting {
+		r.drawReadingStatsOverlay(screen)
+	}
+
+	r.drawVolumeEnd(screen)
+
 	// Draw help overlay if enabled
 	if r.renderState.IsShowingHelp() {
 		r.drawHelpOverlay(screen)
@@ -126,15 +190,112 @@ func (r *Renderer) Draw(screen *ebiten.Image) {
 		r.drawPageInputOverlay(screen)
 	}
 
+	// Draw text prompt overlay if active
+	if r.renderState.IsInTextPrompt() {
+		r.drawTextPromptOverlay(screen)
+	}
+
 	// Draw settings overlay if active (only when base was redrawn)
 	if r.renderState.IsShowingSettings() {
 		r.drawSettingsOverlay(screen)
 	}
 
 	// Draw overlay message if active
-	if r.renderState.GetOverlayMessage() != "" && time.Since(r.renderState.GetOverlayMessageTime()) < overlayMessageDuration {
+	if !presenting && r.renderState.GetOverlayMessage() != "" && time.Since(r.renderState.GetOverlayMessageTime()) < overlayMessageDuration {
 		r.drawOverlayMessage(screen)
 	}
+
+	r.drawReadingTimer(screen)
+	r.drawLoadingIndicator(screen)
+	r.drawMeasureLine(screen)
+	r.drawLaserPointer(screen)
+}
+
+// drawEmptyState renders the start screen: load failure details, drop hint,
+// open shortcuts, and recent files
+func (r *Renderer) drawEmptyState(screen *ebiten.Image) {
+	w := float64(screen.Bounds().Dx())
+
+	titleFont := r.face(26)
+	itemFont := r.face(18)
+
+	keybindings := r.renderState.GetKeybindings()
+	bindingText := func(action string) string {
+		if keys := keybindings[action]; len(keys) > 0 {
+			return strings.Join(keys, ", ")
+		}
+		return "(unbound)"
+	}
+
+	x := 40.0
+	y := 40.0
+	maxTextWidth := w - (x + 60) - 20
+	DrawText(screen, getWindowTitle(), titleFont, x, y, colorWhite)
+	y += 50
+
+	failure := r.renderState.GetLoadFailure()
+	if failure != nil {
+		DrawText(screen, truncateTextToWidth(failure.Reason, itemFont, w-x-20), itemFont, x, y, colorLightRed)
+		y += 32
+		for i, arg := range failure.Args {
+			if i >= 5 { // Keep long argument lists from pushing the actions off screen
+				DrawText(screen, fmt.Sprintf("… and %d more", len(failure.Args)-i), itemFont, x+20, y, colorGray)
+				y += 28
+				break
+			}
+			DrawText(screen, truncateTextToWidth(arg, itemFont, maxTextWidth), itemFont, x+20, y, colorLightGray)
+			y += 28
+		}
+		y += 12
+	}
+
+	DrawText(screen, "Drop images, archives, or folders here", itemFont, x, y, colorLightGray)
+	y += 40
+
+	type emptyStateHint struct{ keys, desc string }
+	hints := []emptyStateHint{
+		{bindingText("open"), "Open images or archives"},
+		{bindingText("open_directory"), "Open a directory"},
+	}
+	if failure != nil && len(failure.Args) > 0 {
+		hints = append(hints, emptyStateHint{bindingText("retry_open"), "Retry"})
+	}
+	hints = append(hints, emptyStateHint{bindingText("exit"), "Quit"})
+	for _, hint := range hints {
+		DrawText(screen, hint.keys, itemFont, x+20, y, colorYellow)
+		DrawText(screen, hint.desc, itemFont, x+260, y, colorGray)
+		y += 28
+	}
+
+	recent := r.renderState.GetRecentFiles()
+	if len(recent) == 0 {
+		return
+	}
+
+	y += 20
+	DrawText(screen, "Recent (press 1-9):", itemFont, x, y, colorWhite)
+	y += 32
+	for i, path := range recent {
+		DrawText(screen, fmt.Sprintf("%d", i+1), itemFont, x+20, y, colorYellow)
+		DrawText(screen, truncateTextToWidth(path, itemFont, maxTextWidth), itemFont, x+60, y, colorCyan)
+		y += 28
+	}
+}
+
+// truncateTextToWidth shortens s from the left with an ellipsis so it fits maxWidth,
+// keeping the file name end of a path visible.
+func truncateTextToWidth(s string, font text.Face, maxWidth float64) string {
+	if width, _ := measureText(s, font); width <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		candidate := "…" + string(runes[i:])
+		if width, _ := measureText(candidate, font); width <= maxWidth {
+			return candidate
+		}
+	}
+	return "…"
 }
 
 // drawSettingsOverlay renders the settings panel
@@ -142,9 +303,9 @@ func (r *Renderer) drawSettingsOverlay(screen *ebiten.Image) {
 	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
 
 	// Fonts
-	titleFont := &text.GoTextFace{Source: r.helpFontSource, Size: 22}
-	itemFont := &text.GoTextFace{Source: r.helpFontSource, Size: 18}
-	hintFont := &text.GoTextFace{Source: r.helpFontSource, Size: 14}
+	titleFont := r.face(22)
+	itemFont := r.face(18)
+	hintFont := r.face(14)
 
 	// Dim background and panel
 	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
@@ -158,7 +319,7 @@ func (r *Renderer) drawSettingsOverlay(screen *ebiten.Image) {
 	// Title and hints
 	DrawText(screen, "Settings", titleFont, panelX+16, panelY+20, colorWhite)
 	hint := "↑/↓: select  ←/→: change  Enter: toggle  Ctrl+S: save  Esc: cancel"
-	hw, hh := text.Measure(hint, hintFont, 0)
+	hw, hh := measureText(hint, hintFont)
 	DrawText(screen, hint, hintFont, panelX+panelW-hw-16, panelY+20+(22-hh)/2, colorLightGray)
 
 	// List items
@@ -182,6 +343,88 @@ func (r *Renderer) drawSettingsOverlay(screen *ebiten.Image) {
 	}
 }
 
+// drawLoadErrorsOverlay lists collection entries that failed to decode
+func (r *Renderer) drawLoadErrorsOverlay(screen *ebiten.Image) {
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+
+	titleFont := r.face(22)
+	itemFont := r.face(16)
+
+	loadErrors := r.renderState.GetLoadErrors()
+	rowH := 44.0
+	panelW := math.Min(900, w*0.9)
+	panelH := math.Min(0.9*h, 60+math.Max(1, float64(len(loadErrors)))*rowH+20)
+	panelX := (w - panelW) / 2
+	panelY := (h - panelH) / 2
+
+	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
+	DrawFilledRect(screen, panelX, panelY, panelW, panelH, bgColorDark)
+	DrawText(screen, fmt.Sprintf("Unreadable images (%d)", len(loadErrors)), titleFont, panelX+16, panelY+20, colorWhite)
+
+	if len(loadErrors) == 0 {
+		DrawText(screen, "No unreadable images found so far", itemFont, panelX+24, panelY+60, colorGray)
+		return
+	}
+
+	maxRows := int((panelH - 80) / rowH)
+	textW := panelW - 100
+	y := panelY + 60
+	for i, loadErr := range loadErrors {
+		if i >= maxRows {
+			DrawText(screen, fmt.Sprintf("… and %d more", len(loadErrors)-i), itemFont, panelX+24, y, colorGray)
+			break
+		}
+		DrawText(screen, fmt.Sprintf("%d", loadErr.Index+1), itemFont, panelX+24, y, colorYellow)
+		DrawText(screen, truncateTextToWidth(loadErr.Path, itemFont, textW), itemFont, panelX+80, y, colorWhite)
+		DrawText(screen, truncateTextToWidth(loadErr.Reason, itemFont, textW), itemFont, panelX+80, y+20, colorLightRed)
+		y += rowH
+	}
+}
+
+func (r *Renderer) drawReadingStatsOverlay(screen *ebiten.Image) {
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+
+	titleFont := r.face(22)
+	itemFont := r.face(16)
+
+	stats := r.renderState.GetReadingStats()
+	rowH := 28.0
+	panelW := math.Min(700, w*0.9)
+	panelH := math.Min(0.9*h, 60+5*rowH+20)
+	panelX := (w - panelW) / 2
+	panelY := (h - panelH) / 2
+
+	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
+	DrawFilledRect(screen, panelX, panelY, panelW, panelH, bgColorDark)
+	DrawText(screen, "Reading statistics", titleFont, panelX+16, panelY+20, colorWhite)
+
+	if stats.Volume == "" {
+		DrawText(screen, "No pages read yet", itemFont, panelX+24, panelY+60, colorGray)
+		return
+	}
+
+	textW := panelW - 48
+	pace := "not enough pages yet"
+	if stats.PagePace > 0 {
+		pace = formatReadingDuration(stats.PagePace) + " per page"
+	}
+	rows := []struct {
+		text string
+		clr  color.RGBA
+	}{
+		{stats.Volume, colorGray},
+		{stats.Summary(), colorYellow},
+		{fmt.Sprintf("Pages read: %d of %d", stats.Read, stats.Total), colorWhite},
+		{"Time in this volume: " + formatReadingDuration(stats.VolumeTime), colorWhite},
+		{fmt.Sprintf("This session: %d pages in %s, %s", stats.SessionPages, formatReadingDuration(stats.SessionTime), pace), colorWhite},
+	}
+	y := panelY + 60
+	for _, row := range rows {
+		DrawText(screen, truncateTextToWidth(row.text, itemFont, textW), itemFont, panelX+24, y, row.clr)
+		y += rowH
+	}
+}
+
 func (r *Renderer) drawImageInRegionWithAlign(screen *ebiten.Image, img *ebiten.Image, x, y, maxW, maxH int, align string) {
 	// Calculate scaling
 	scale := r.calculateImageScale(img, maxW, maxH)
@@ -205,11 +448,11 @@ func (r *Renderer) drawImageInRegionWithAlign(screen *ebiten.Image, img *ebiten.
 func (r *Renderer) calculateImageScale(img *ebiten.Image, maxW, maxH int) float64 {
 	iw, ih := img.Bounds().Dx(), img.Bounds().Dy()
 
-	if r.renderState.IsFullscreen() {
+	if r.renderState.FitsSmallImages() {
 		return math.Min(float64(maxW)/float64(iw), float64(maxH)/float64(ih))
 	}
 
-	// In windowed mode, don't scale up small images
+	// In windowed mode, don't scale up small images unless configured
 	if iw > maxW || ih > maxH {
 		return math.Min(float64(maxW)/float64(iw), float64(maxH)/float64(ih))
 	}
@@ -227,60 +470,117 @@ func (r *Renderer) CalculateHorizontalPosition(x, maxW int, scaledW float64, ali
 	}
 }
 
-func (r *Renderer) drawHelpOverlay(screen *ebiten.Image) {
-	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+// minHelpFontSize is the smallest help font; below it the rows are paged.
+const minHelpFontSize = 12.0
+
+// helpLayout is the help overlay measured for one window size, font size
+// and filter, so drawing it does no text measurement.
+type helpLayout struct {
+	width, height float64
+	fontSize      float64 // Configured maximum size
+	filter        string
+	fits          bool // At least one row can be shown
+	paged         bool // The rows do not fit at once and scroll
+	pageRows      int  // Rows shown at once
+
+	face       text.Face
+	titleY     float64
+	controlsY  float64
+	lineHeight float64
+	actionX    float64
+	arrowX     float64
+	inputX     float64
+	descX      float64
+	rows       []helpRow
+
+	systemY     float64
+	status      string
+	statusColor color.RGBA
+	warnings    []string
+	footerY     float64
+
+	image       *ebiten.Image // Rendered overlay, drawn lazily
+	imageScroll int           // First row shown in image
+}
 
-	// Calculate available space (accounting for padding)
-	padding := 40.0
-	availableWidth := w - padding*2
-	availableHeight := h - padding*2
+// maxScroll is the largest first row that still fills a page.
+func (l *helpLayout) maxScroll() int {
+	return max(0, len(l.rows)-l.pageRows)
+}
 
-	// Calculate optimal font size
-	optimalFontSize, canFit := r.calculateOptimalFontSize(availableWidth, availableHeight)
+// helpRow is one action line of the help overlay.
+type helpRow struct {
+	action string
+	keys   string
+	mouse  string
+	desc   string
+	sepX   float64 // Keyboard/mouse separator, from inputX
+	mouseX float64 // Mouse bindings, from inputX
+}
 
-	// If cannot fit even with minimum font size, show Fermat's joke
-	if !canFit {
-		r.drawMarginTooSmallMessage(screen)
-		return
+// invalidateHelpLayout drops the measured help overlay, e.g. after the
+// bindings or config status changed.
+func (r *Renderer) invalidateHelpLayout() {
+	if r.help != nil && r.help.image != nil {
+		r.help.image.Deallocate()
 	}
+	r.help = nil
+}
 
-	// Get data needed for rendering
-	actions := r.getActionsList()
+// helpActions returns the bound actions matching the help filter, sorted.
+func (r *Renderer) helpActions() []string {
+	filter := r.renderState.GetHelpFilter()
 	keybindings := r.renderState.GetKeybindings()
 	mousebindings := r.renderState.GetMousebindings()
-	configStatus := r.renderState.GetConfigStatus()
-
-	// Semi-transparent black background (lighter for more image transparency)
-	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
-
-	// Help text area with semi-transparent black background
-	DrawFilledRect(screen, padding, padding, w-padding*2, h-padding*2, bgColorMedium)
+	descriptions := getActionDescriptions()
 
-	// Create font with dynamically calculated size
-	helpFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   optimalFontSize,
+	var actions []string
+	for _, action := range r.getActionsList() {
+		keys, mouse := keybindings[action], mousebindings[action]
+		if len(keys) == 0 && len(mouse) == 0 {
+			continue
+		}
+		if helpMatches(filter, action, keys, mouse, descriptions[action]) {
+			actions = append(actions, action)
+		}
 	}
+	return actions
+}
 
-	// Draw title
-	titleY := padding + 30
-	DrawText(screen, "HELP:", helpFont, padding+20, titleY, colorWhite)
+// helpLayoutFor returns the help overlay layout for a w×h screen, measuring
+// it only when the size, font size or filter changed. When the rows do not
+// fit even at minHelpFontSize they are paged at that size.
+func (r *Renderer) helpLayoutFor(w, h float64) *helpLayout {
+	fontSize := r.renderState.GetFontSize()
+	filter := r.renderState.GetHelpFilter()
+	if r.help != nil && r.help.width == w && r.help.height == h && r.help.fontSize == fontSize && r.help.filter == filter {
+		return r.help
+	}
 
-	currentY := titleY + optimalFontSize*2 // Start below title
-	lineHeight := optimalFontSize * 1.5
+	// Calculate available space (accounting for padding)
+	padding := 40.0
+	optimalFontSize, canFit := r.calculateOptimalFontSize(w-padding*2, h-padding*2)
+	layout := &helpLayout{width: w, height: h, fontSize: fontSize, filter: filter, paged: !canFit}
+	r.invalidateHelpLayout()
+	r.help = layout
 
-	// Get action descriptions
+	// Get data needed for rendering
+	actions := r.helpActions()
+	keybindings := r.renderState.GetKeybindings()
+	mousebindings := r.renderState.GetMousebindings()
+	configStatus := r.renderState.GetConfigStatus()
 	actionDescriptions := getActionDescriptions()
 
-	// Draw input bindings title
-	DrawText(screen, "Controls (Keyboard | Mouse):", helpFont, padding+20, currentY, colorWhite)
-	currentY += lineHeight * 1.5
+	helpFont := newTextFace(r.helpFontSource, optimalFontSize)
+	layout.face = helpFont
+	layout.titleY = padding + 30
+	layout.controlsY = layout.titleY + optimalFontSize*2 // Start below title
+	layout.lineHeight = optimalFontSize * 1.5
 
-	// Calculate column widths using text measurement
+	// Measure text to determine column widths
 	maxActionWidth := 0.0
 	maxInputWidth := 0.0
-
-	// First pass: measure text to determine column widths
+	sepWidth, _ := measureText(" | ", helpFont)
 	for _, action := range actions {
 		keys := keybindings[action]
 		mouseActions := mousebindings[action]
@@ -290,135 +590,189 @@ func (r *Renderer) drawHelpOverlay(screen *ebiten.Image) {
 			continue
 		}
 
-		// Measure action name width
-		actionWidth, _ := text.Measure(action, helpFont, 0)
-		if actionWidth > maxActionWidth {
-			maxActionWidth = actionWidth
+		row := helpRow{
+			action: action,
+			keys:   strings.Join(keys, ", "),
+			mouse:  strings.Join(mouseActions, ", "),
+			desc:   actionDescriptions[action],
+		}
+		if row.desc == "" {
+			row.desc = "No description available"
 		}
 
-		// Build combined input string (keyboard | mouse)
-		var inputParts []string
-		if len(keys) > 0 {
-			inputParts = append(inputParts, strings.Join(keys, ", "))
+		actionWidth, _ := measureText(action, helpFont)
+		maxActionWidth = max(maxActionWidth, actionWidth)
+
+		inputWidth := 0.0
+		if row.keys != "" {
+			inputWidth, _ = measureText(row.keys, helpFont)
 		}
-		if len(mouseActions) > 0 {
-			inputParts = append(inputParts, strings.Join(mouseActions, ", "))
+		row.sepX = inputWidth
+		if row.keys != "" && row.mouse != "" {
+			inputWidth += sepWidth
 		}
-
-		combinedInput := strings.Join(inputParts, " | ")
-		inputWidth, _ := text.Measure(combinedInput, helpFont, 0)
-		if inputWidth > maxInputWidth {
-			maxInputWidth = inputWidth
+		row.mouseX = inputWidth
+		if row.mouse != "" {
+			mouseWidth, _ := measureText(row.mouse, helpFont)
+			inputWidth += mouseWidth
 		}
+		maxInputWidth = max(maxInputWidth, inputWidth)
+		layout.rows = append(layout.rows, row)
 	}
 
 	// Calculate column positions with proper spacing
-	actionColumnX := padding + 40
-	arrowColumnX := actionColumnX + maxActionWidth + 20 // 20px spacing
-	inputColumnX := arrowColumnX + 30                   // Arrow width + spacing
-	descColumnX := inputColumnX + maxInputWidth + 20    // 20px spacing after input
-
-	// Draw each action and its input bindings on single line
-	for _, action := range actions {
-		keys := keybindings[action]
-		mouseActions := mousebindings[action]
+	layout.actionX = padding + 40
+	layout.arrowX = layout.actionX + maxActionWidth + 20 // 20px spacing
+	layout.inputX = layout.arrowX + 30                   // Arrow width + spacing
+	layout.descX = layout.inputX + maxInputWidth + 20    // 20px spacing after input
+
+	// Rows left for the actions once the header, system section and
+	// footer have their room
+	layout.pageRows = len(layout.rows)
+	layout.fits = true
+	if layout.paged {
+		rowsTop := layout.controlsY + layout.lineHeight*1.5
+		reserved := layout.lineHeight * float64(4+min(len(configStatus.Warnings), 2))
+		available := int((h - padding - reserved - rowsTop) / layout.lineHeight)
+		layout.fits = available >= 1
+		layout.pageRows = max(0, min(len(layout.rows), available))
+		// A window that is only too narrow still shows every row
+		layout.paged = layout.pageRows < len(layout.rows)
+	}
+	debugKV("renderer", "help_layout_rebuilt", "width", w, "height", h, "font_size", optimalFontSize, "rows", len(layout.rows), "page_rows", layout.pageRows, "paged", layout.paged)
 
-		// Skip if no bindings at all
-		if len(keys) == 0 && len(mouseActions) == 0 {
-			continue
+	// Config status section after some spacing; a filter that matches
+	// nothing still leaves a line for saying so
+	layout.systemY = layout.controlsY + layout.lineHeight*1.5 + float64(max(layout.pageRows, 1)+1)*layout.lineHeight
+	layout.status = fmt.Sprintf("Config Status: %s", configStatus.Status)
+	layout.statusColor = colorGreen
+	if configStatus.Status == "Warning" || configStatus.Status == "Error" {
+		layout.statusColor = colorOrange
+	}
+	for i, warning := range configStatus.Warnings {
+		if i >= 2 { // Limit to first 2 warnings to avoid clutter
+			break
 		}
-
-		// Get description
-		description := actionDescriptions[action]
-		if description == "" {
-			description = "No description available"
+		if len(warning) > 50 {
+			warning = warning[:47] + "..."
 		}
+		layout.warnings = append(layout.warnings, "• "+warning)
+	}
+	layout.footerY = layout.systemY + layout.lineHeight*float64(2+len(layout.warnings)) + layout.lineHeight*0.5
+	return layout
+}
 
-		// Draw action name (left-aligned)
-		DrawText(screen, action, helpFont, actionColumnX, currentY, colorLightBlue)
-
-		// Draw arrow
-		DrawText(screen, "→", helpFont, arrowColumnX, currentY, colorWhite)
+// helpFooter describes the filter and, for paged rows, which rows are shown.
+func helpFooter(layout *helpLayout, scroll int) string {
+	footer := "Type to filter"
+	if layout.filter != "" {
+		footer = "Filter: " + layout.filter + "_"
+	}
+	if layout.paged && len(layout.rows) > 0 {
+		footer += fmt.Sprintf("   Rows %d-%d of %d   PgUp/PgDn: scroll", scroll+1, scroll+layout.pageRows, len(layout.rows))
+	}
+	return footer + "   Esc: close"
+}
 
-		// Draw combined input bindings with color coding
-		currentInputX := inputColumnX
+// drawHelpOverlay draws the help overlay from an offscreen image that is
+// rendered again only when its layout changes.
+func (r *Renderer) drawHelpOverlay(screen *ebiten.Image) {
+	bounds := screen.Bounds()
+	layout := r.helpLayoutFor(float64(bounds.Dx()), float64(bounds.Dy()))
 
-		// Draw keyboard bindings in yellow
-		if len(keys) > 0 {
-			keysList := strings.Join(keys, ", ")
-			DrawText(screen, keysList, helpFont, currentInputX, currentY, colorYellow)
+	// If not even one row fits at the minimum font size, show Fermat's joke
+	if !layout.fits {
+		r.drawMarginTooSmallMessage(screen)
+		return
+	}
 
-			keysWidth, _ := text.Measure(keysList, helpFont, 0)
-			currentInputX += keysWidth
+	scroll := min(max(r.renderState.GetHelpScroll(), 0), layout.maxScroll())
+	if layout.image == nil || layout.imageScroll != scroll {
+		if layout.image == nil {
+			layout.image = ebiten.NewImage(bounds.Dx(), bounds.Dy())
 		}
+		layout.image.Clear()
+		layout.imageScroll = scroll
+		drawHelpPanel(layout.image, layout, scroll)
+	}
+	screen.DrawImage(layout.image, nil)
+}
 
-		// Draw separator if both keyboard and mouse bindings exist
-		if len(keys) > 0 && len(mouseActions) > 0 {
-			DrawText(screen, " | ", helpFont, currentInputX, currentY, colorWhite)
+// drawHelpPanel draws the help overlay described by layout onto screen,
+// starting at row scroll.
+func drawHelpPanel(screen *ebiten.Image, layout *helpLayout, scroll int) {
+	w, h := layout.width, layout.height
+	padding := 40.0
 
-			sepWidth, _ := text.Measure(" | ", helpFont, 0)
-			currentInputX += sepWidth
-		}
+	// Semi-transparent black background (lighter for more image transparency)
+	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
 
-		// Draw mouse bindings in cyan
-		if len(mouseActions) > 0 {
-			mouseList := strings.Join(mouseActions, ", ")
-			DrawText(screen, mouseList, helpFont, currentInputX, currentY, colorCyan)
-		}
+	// Help text area with semi-transparent black background
+	DrawFilledRect(screen, padding, padding, w-padding*2, h-padding*2, bgColorMedium)
 
-		// Draw description on same line
-		DrawText(screen, description, helpFont, descColumnX, currentY, colorGray)
+	helpFont := layout.face
+	DrawText(screen, "HELP:", helpFont, padding+20, layout.titleY, colorWhite)
+	DrawText(screen, "Controls (Keyboard | Mouse):", helpFont, padding+20, layout.controlsY, colorWhite)
 
-		currentY += lineHeight
+	// Draw each action and its input bindings on single line
+	currentY := layout.controlsY + layout.lineHeight*1.5
+	if len(layout.rows) == 0 {
+		DrawText(screen, "No bindings match the filter", helpFont, layout.actionX, currentY, colorGray)
 	}
+	for _, row := range layout.rows[scroll : scroll+layout.pageRows] {
+		DrawText(screen, row.action, helpFont, layout.actionX, currentY, colorLightBlue)
+		DrawText(screen, "→", helpFont, layout.arrowX, currentY, colorWhite)
+
+		// Keyboard bindings in yellow, mouse bindings in cyan
+		if row.keys != "" {
+			DrawText(screen, row.keys, helpFont, layout.inputX, currentY, colorYellow)
+		}
+		if row.keys != "" && row.mouse != "" {
+			DrawText(screen, " | ", helpFont, layout.inputX+row.sepX, currentY, colorWhite)
+		}
+		if row.mouse != "" {
+			DrawText(screen, row.mouse, helpFont, layout.inputX+row.mouseX, currentY, colorCyan)
+		}
 
-	// Add some spacing before config status
-	currentY += lineHeight
+		DrawText(screen, row.desc, helpFont, layout.descX, currentY, colorGray)
+		currentY += layout.lineHeight
+	}
 
 	// Draw config status section
-
-	// Draw section title
+	currentY = layout.systemY
 	DrawText(screen, "System:", helpFont, padding+20, currentY, colorWhite)
-	currentY += lineHeight
+	currentY += layout.lineHeight
+	DrawText(screen, layout.status, helpFont, padding+40, currentY, layout.statusColor)
+	currentY += layout.lineHeight
+	for _, warning := range layout.warnings {
+		DrawText(screen, warning, helpFont, padding+40, currentY, colorLightRed)
+		currentY += layout.lineHeight
+	}
 
-	// Add config status
-	statusText := fmt.Sprintf("Config Status: %s", configStatus.Status)
+	DrawText(screen, helpFooter(layout, scroll), helpFont, padding+20, layout.footerY, colorGray)
+}
 
-	statusColor := colorGreen
-	if configStatus.Status == "Warning" || configStatus.Status == "Error" {
-		statusColor = colorOrange
+// face returns the help font at size, created once per size.
+func (r *Renderer) face(size float64) text.Face {
+	if f, ok := r.faces[size]; ok {
+		return f
 	}
-	DrawText(screen, statusText, helpFont, padding+40, currentY, statusColor)
-	currentY += lineHeight
-
-	// Add warnings if any
-	if len(configStatus.Warnings) > 0 {
-		for i, warning := range configStatus.Warnings {
-			if i >= 2 { // Limit to first 2 warnings to avoid clutter
-				break
-			}
-			shortWarning := warning
-			if len(shortWarning) > 50 {
-				shortWarning = shortWarning[:47] + "..."
-			}
-			DrawText(screen, "• "+shortWarning, helpFont, padding+40, currentY, colorLightRed)
-			currentY += lineHeight
-		}
+	if r.faces == nil {
+		r.faces = make(map[float64]text.Face)
 	}
-
+	f := newTextFace(r.helpFontSource, size)
+	r.faces[size] = f
+	return f
 }
 
 // calculateRequiredDimensions calculates the required width and height for help content at a given font size
 func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float64) {
-	actions := r.getActionsList()
+	actions := r.helpActions()
 	keybindings := r.renderState.GetKeybindings()
 	mousebindings := r.renderState.GetMousebindings()
 	configStatus := r.renderState.GetConfigStatus()
 	// Create temporary font for measurements
-	tempFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   fontSize,
-	}
+	tempFont := newTextFace(r.helpFontSource, fontSize)
 
 	padding := 40.0
 	lineHeight := fontSize * 1.5
@@ -439,7 +793,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		}
 		actionLines++
 	}
-	height += float64(actionLines) * lineHeight
+	height += float64(max(actionLines, 1)) * lineHeight
 
 	// System section
 	height += lineHeight // Spacing before system section
@@ -452,18 +806,19 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		warningLines = 2
 	}
 	height += float64(warningLines) * lineHeight
+	height += lineHeight * 1.5 // Filter footer
 
 	// Calculate width
 	maxWidth := 0.0
 
 	// Check title width
-	titleWidth, _ := text.Measure("HELP:", tempFont, 0)
+	titleWidth, _ := measureText("HELP:", tempFont)
 	if titleWidth+padding*2+40 > maxWidth { // 40 for left margin
 		maxWidth = titleWidth + padding*2 + 40
 	}
 
 	// Check controls title width
-	controlsTitleWidth, _ := text.Measure("Controls (Keyboard | Mouse):", tempFont, 0)
+	controlsTitleWidth, _ := measureText("Controls (Keyboard | Mouse):", tempFont)
 	if controlsTitleWidth+padding*2+40 > maxWidth {
 		maxWidth = controlsTitleWidth + padding*2 + 40
 	}
@@ -485,7 +840,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		}
 
 		// Measure action name width
-		actionWidth, _ := text.Measure(action, tempFont, 0)
+		actionWidth, _ := measureText(action, tempFont)
 		if actionWidth > maxActionWidth {
 			maxActionWidth = actionWidth
 		}
@@ -500,7 +855,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		}
 
 		combinedInput := strings.Join(inputParts, " | ")
-		inputWidth, _ := text.Measure(combinedInput, tempFont, 0)
+		inputWidth, _ := measureText(combinedInput, tempFont)
 		if inputWidth > maxInputWidth {
 			maxInputWidth = inputWidth
 		}
@@ -510,7 +865,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		if description == "" {
 			description = "No description available"
 		}
-		descWidth, _ := text.Measure(description, tempFont, 0)
+		descWidth, _ := measureText(description, tempFont)
 		if descWidth > maxDescWidth {
 			maxDescWidth = descWidth
 		}
@@ -523,13 +878,13 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 	}
 
 	// Check system section width
-	systemTitleWidth, _ := text.Measure("System:", tempFont, 0)
+	systemTitleWidth, _ := measureText("System:", tempFont)
 	if systemTitleWidth+padding*2+40 > maxWidth {
 		maxWidth = systemTitleWidth + padding*2 + 40
 	}
 
 	statusText := fmt.Sprintf("Config Status: %s", configStatus.Status)
-	statusWidth, _ := text.Measure(statusText, tempFont, 0)
+	statusWidth, _ := measureText(statusText, tempFont)
 	if statusWidth+padding*2+80 > maxWidth { // 80 for indentation
 		maxWidth = statusWidth + padding*2 + 80
 	}
@@ -543,7 +898,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 		if len(shortWarning) > 50 {
 			shortWarning = shortWarning[:47] + "..."
 		}
-		warningWidth, _ := text.Measure("• "+shortWarning, tempFont, 0)
+		warningWidth, _ := measureText("• "+shortWarning, tempFont)
 		if warningWidth+padding*2+80 > maxWidth {
 			maxWidth = warningWidth + padding*2 + 80
 		}
@@ -555,7 +910,7 @@ func (r *Renderer) calculateRequiredDimensions(fontSize float64) (float64, float
 // calculateOptimalFontSize finds the largest font size that fits within the given dimensions
 func (r *Renderer) calculateOptimalFontSize(availableWidth, availableHeight float64) (float64, bool) {
 	maxFontSize := r.renderState.GetFontSize()
-	minFontSize := 12.0
+	minFontSize := minHelpFontSize
 
 	// Quick check: can we fit with minimum font size?
 	minWidth, minHeight := r.calculateRequiredDimensions(minFontSize)
@@ -601,18 +956,15 @@ func (r *Renderer) drawMarginTooSmallMessage(screen *ebiten.Image) {
 	DrawFilledRect(screen, 0, 0, float64(w), float64(h), bgColorLight)
 
 	// Create font for the joke (16px should be readable)
-	jokeFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   16.0,
-	}
+	jokeFont := r.face(16.0)
 
 	// The famous quote from Fermat's Last Theorem margin note
 	message := "Hanc marginis exiguitas non caperet."
 	subtitle := "(This margin is too small to contain it.)"
 
 	// Measure text for centering
-	messageWidth, messageHeight := text.Measure(message, jokeFont, 0)
-	subtitleWidth, _ := text.Measure(subtitle, jokeFont, 0)
+	messageWidth, messageHeight := measureText(message, jokeFont)
+	subtitleWidth, _ := measureText(subtitle, jokeFont)
 
 	// Calculate center positions
 	messageX := float64(w)/2 - messageWidth/2
@@ -632,16 +984,10 @@ func (r *Renderer) drawPageInputOverlay(screen *ebiten.Image) {
 	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
 
 	// Create font for page input
-	inputFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   r.renderState.GetFontSize(),
-	}
+	inputFont := r.face(r.renderState.GetFontSize())
 
 	// Create smaller font for range display
-	rangeFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   r.renderState.GetFontSize() * 0.8,
-	}
+	rangeFont := r.face(r.renderState.GetFontSize() * 0.8)
 
 	// Get total pages for range display
 	totalPages := r.renderState.GetTotalPagesCount()
@@ -651,8 +997,8 @@ func (r *Renderer) drawPageInputOverlay(screen *ebiten.Image) {
 	rangeText := fmt.Sprintf("(1-%d)", totalPages)
 
 	// Measure text dimensions
-	inputWidth, inputHeight := text.Measure(inputText, inputFont, 0)
-	rangeWidth, rangeHeight := text.Measure(rangeText, rangeFont, 0)
+	inputWidth, inputHeight := measureText(inputText, inputFont)
+	rangeWidth, rangeHeight := measureText(rangeText, rangeFont)
 
 	// Calculate box dimensions (accommodate both lines)
 	maxWidth := math.Max(inputWidth, rangeWidth)
@@ -676,63 +1022,105 @@ func (r *Renderer) drawPageInputOverlay(screen *ebiten.Image) {
 	DrawText(screen, rangeText, rangeFont, rangeTextX, boxY+float64(padding)+inputHeight+10, colorLightGray)
 }
 
-func (r *Renderer) drawInfoDisplay(screen *ebiten.Image) {
-	// Create font for info display (same size as help text)
-	infoFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   r.renderState.GetFontSize(),
-	}
+func (r *Renderer) drawTextPromptOverlay(screen *ebiten.Image) {
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
 
-	// Get page status text
-	infoText := r.buildPageNumberString()
+	inputFont := r.face(r.renderState.GetFontSize())
+	hintFont := r.face(r.renderState.GetFontSize() * 0.6)
 
-	// Measure text dimensions
-	textWidth, textHeight := text.Measure(infoText, infoFont, 0)
+	padding := 20.0
+	maxTextWidth := math.Max(100, w*0.8-padding*2)
+	kind := r.renderState.GetTextPromptKind()
+	prompt := fmt.Sprintf("%s: %s_", kind.Label(), r.renderState.GetTextPromptBuffer())
+	if status := r.renderState.GetTextPromptStatus(); status != "" {
+		prompt += "  (" + status + ")"
+	}
+	inputText := truncateTextToWidth(prompt, inputFont, maxTextWidth)
+	hintText := kind.Hint()
+
+	inputWidth, inputHeight := measureText(inputText, inputFont)
+	hintWidth, hintHeight := measureText(hintText, hintFont)
+
+	// Search prompts list their results between the input and the hint
+	results := r.renderState.GetSearchResults()
+	var resultLines []string
+	resultLineHeight := 0.0
+	resultsWidth := 0.0
+	for _, result := range results {
+		name := result.Name
+		if result.Hidden {
+			name += " (filtered out)"
+		}
+		line := truncateTextToWidth(name, hintFont, maxTextWidth)
+		lineWidth, lineHeight := measureText(line, hintFont)
+		resultsWidth = math.Max(resultsWidth, lineWidth)
+		resultLineHeight = math.Max(resultLineHeight, lineHeight+4)
+		resultLines = append(resultLines, line)
+	}
+	resultsHeight := resultLineHeight * float64(len(resultLines))
+	if len(resultLines) > 0 {
+		resultsHeight += 10
+		// Keep the box width stable while typing
+		resultsWidth = maxTextWidth
+	}
 
-	// Position at bottom right corner
-	padding := 10.0
-	textX := float64(screen.Bounds().Dx()) - textWidth - padding
-	textY := float64(screen.Bounds().Dy()) - textHeight - padding
+	boxWidth := math.Max(math.Max(inputWidth, hintWidth), resultsWidth) + padding*2
+	boxHeight := inputHeight + resultsHeight + hintHeight + 10 + padding*2
+	boxX := (w - boxWidth) / 2
+	boxY := (h - boxHeight) / 2
 
-	// Semi-transparent background
-	bgPadding := 5.0
-	bgX := textX - bgPadding
-	bgY := textY - bgPadding
-	bgW := textWidth + bgPadding*2
-	bgH := textHeight + bgPadding*2
+	DrawFilledRect(screen, boxX, boxY, boxWidth, boxHeight, bgColorDark)
+	DrawText(screen, inputText, inputFont, boxX+padding, boxY+padding, colorWhite)
 
-	DrawFilledRect(screen, bgX, bgY, bgW, bgH, bgColorLight)
+	lineY := boxY + padding + inputHeight + 10
+	selection := r.renderState.GetSearchSelection()
+	selColor := color.RGBA{60, 60, 60, 200}
+	for i, line := range resultLines {
+		lineColor := colorLightGray
+		if i == selection {
+			DrawFilledRect(screen, boxX+padding-4, lineY-2, boxWidth-padding*2+8, resultLineHeight, selColor)
+			lineColor = colorWhite
+		}
+		DrawText(screen, line, hintFont, boxX+padding, lineY, lineColor)
+		lineY += resultLineHeight
+	}
 
-	// Draw text
-	DrawText(screen, infoText, infoFont, textX, textY, colorWhite)
+	DrawText(screen, hintText, hintFont, boxX+(boxWidth-hintWidth)/2, boxY+padding+inputHeight+resultsHeight+10, colorLightGray)
 }
 
-func (r *Renderer) drawOverlayMessage(screen *ebiten.Image) {
-	// Create font for overlay message
-	messageFont := &text.GoTextFace{
-		Source: r.helpFontSource,
-		Size:   r.renderState.GetFontSize(),
-	}
-
-	// Measure text dimensions
-	textWidth, textHeight := text.Measure(r.renderState.GetOverlayMessage(), messageFont, 0)
+func (r *Renderer) drawInfoDisplay(screen *ebiten.Image) {
+	// Page status at the help text size, on a semi-transparent box
+	bgPadding := 5.0
+	panel := r.infoPanel.render(r, r.buildPageNumberString(), r.renderState.GetFontSize(), bgPadding, bgColorLight)
 
-	// Calculate position (center of screen)
-	padding := 20.0
-	boxWidth := textWidth + padding*2
-	boxHeight := textHeight + padding*2
-	boxX := (float64(screen.Bounds().Dx()) - boxWidth) / 2
-	boxY := (float64(screen.Bounds().Dy()) - boxHeight) / 2
+	// Position at bottom right corner
+	padding := 10.0
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(
+		float64(screen.Bounds().Dx()-panel.Bounds().Dx())-padding+bgPadding,
+		float64(screen.Bounds().Dy()-panel.Bounds().Dy())-padding+bgPadding,
+	)
+	screen.DrawImage(panel, op)
+}
 
-	// Semi-transparent black background
-	DrawFilledRect(screen, boxX, boxY, boxWidth, boxHeight, bgColorDark)
+func (r *Renderer) drawOverlayMessage(screen *ebiten.Image) {
+	// Message in a semi-transparent box at the center of the screen
+	panel := r.messagePanel.render(r, r.renderState.GetOverlayMessage(), r.renderState.GetFontSize(), 20, bgColorDark)
 
-	// Draw text
-	DrawText(screen, r.renderState.GetOverlayMessage(), messageFont, boxX+padding, boxY+padding, colorWhite)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(
+		float64(screen.Bounds().Dx()-panel.Bounds().Dx())/2,
+		float64(screen.Bounds().Dy()-panel.Bounds().Dy())/2,
+	)
+	screen.DrawImage(panel, op)
 }
 
+// applyTransformations returns img rotated and flipped as the render state
+// asks. The result is cached, so repeated draws of the same page allocate
+// nothing; the cached copy is released once no transformation is active.
 func (r *Renderer) applyTransformations(img *ebiten.Image) *ebiten.Image {
 	if r.renderState.GetRotationAngle() == 0 && !r.renderState.IsFlippedH() && !r.renderState.IsFlippedV() {
+		r.invalidateTransformCache("no_transform")
 		return img
 	}
 
@@ -802,6 +1190,8 @@ func (r *Renderer) applyTransformations(img *ebiten.Image) *ebiten.Image {
 	return transformedImg
 }
 
+// createBookModeImage composes two pages side by side. The spread is cached
+// until either page changes.
 func (r *Renderer) createBookModeImage(leftImg, rightImg *ebiten.Image) *ebiten.Image {
 	if rightImg == nil {
 		r.invalidateBookCache("single_image")
@@ -883,15 +1273,61 @@ func (r *Renderer) buildPageNumberString() string {
 	rightPage := content.Metadata.RightPage
 	actualImages := content.Metadata.ActualImages
 
+	pageText := fmt.Sprintf("%d / %d", leftPage, total)
 	if actualImages == 2 {
 		separator := "→"
 		if leftPage > rightPage {
 			separator = "←"
 		}
-		return fmt.Sprintf("%d%s%d / %d", leftPage, separator, rightPage, total)
+		pageText = fmt.Sprintf("%d%s%d / %d", leftPage, separator, rightPage, total)
+	}
+	if content.Metadata.Mode != "" {
+		pageText = content.Metadata.Mode + " " + pageText
 	}
 
-	return fmt.Sprintf("%d / %d", leftPage, total)
+	if content.Metadata.Chapter != "" {
+		pageText += " <" + content.Metadata.Chapter + ">"
+	}
+	if content.Metadata.Unreadable > 0 {
+		pageText += fmt.Sprintf(" (%d unreadable)", content.Metadata.Unreadable)
+	}
+	if content.Metadata.Anomaly != "" {
+		pageText += " (! " + content.Metadata.Anomaly + ")"
+	}
+	if content.Metadata.Marked {
+		pageText += fmt.Sprintf(" [marked, %d total]", content.Metadata.MarkCount)
+	} else if content.Metadata.MarkCount > 0 {
+		pageText += fmt.Sprintf(" [%d marked]", content.Metadata.MarkCount)
+	}
+	for _, img := range []DisplayImage{content.LeftImage, content.RightImage} {
+		anim, ok := img.(AnimatedImage)
+		if !ok || anim.FrameCount() < 2 {
+			continue
+		}
+		pageText += fmt.Sprintf(" frame %d/%d", anim.CurrentFrame()+1, anim.FrameCount())
+		if content.Metadata.AnimationPaused {
+			pageText += " paused"
+		}
+		if content.Metadata.AnimationSpeed != 1 {
+			pageText += fmt.Sprintf(" %gx", content.Metadata.AnimationSpeed)
+		}
+		break
+	}
+	if content.Metadata.Rating > 0 {
+		pageText += " " + formatRatingStars(content.Metadata.Rating)
+	}
+	if len(content.Metadata.Tags) > 0 {
+		pageText += " [" + strings.Join(content.Metadata.Tags, ", ") + "]"
+	}
+	if content.Metadata.Filter != "" {
+		pageText += " {" + content.Metadata.Filter + "}"
+	}
+	if debugMode.Load() {
+		if stats, ok := r.renderState.GetGPUMemory(); ok {
+			pageText += " " + stats.String()
+		}
+	}
+	return pageText
 }
 
 func (r *Renderer) drawTransformedImageCentered(screen *ebiten.Image, img *ebiten.Image) {
@@ -907,7 +1343,7 @@ func (r *Renderer) drawTransformedImageCentered(screen *ebiten.Image, img *ebite
 
 	if r.renderState.GetZoomMode() == ZoomModeFitWindow {
 		// Fit to window mode - calculate scale here for centering
-		if r.renderState.IsFullscreen() {
+		if r.renderState.FitsSmallImages() {
 			scale = math.Min(w/iw, h/ih)
 		} else {
 			if iw > w || ih > h {
@@ -978,6 +1414,13 @@ func (r *Renderer) drawImagesDirect(screen *ebiten.Image, leftImg, rightImg Disp
 
 	layout := r.calculateDisplayLayout(leftImg, rightImg)
 	scale, offsetX, offsetY := r.calculateDisplayTransform(screen, layout.transformedW, layout.transformedH)
+	r.view = viewTransform{
+		Scale:   scale,
+		OffsetX: offsetX,
+		OffsetY: offsetY,
+		Width:   float64(layout.transformedW) * scale,
+		Height:  float64(layout.transformedH) * scale,
+	}
 	r.drawDisplayImageTiles(screen, leftImg, layout.leftX, layout.leftY, layout, scale, offsetX, offsetY)
 	if rightImg != nil {
 		r.drawDisplayImageTiles(screen, rightImg, layout.rightX, layout.rightY, layout, scale, offsetX, offsetY)
@@ -1024,17 +1467,20 @@ func (r *Renderer) calculateDisplayTransform(screen *ebiten.Image, imageW, image
 	var offsetX, offsetY float64
 
 	if r.renderState.GetZoomMode() == ZoomModeFitWindow {
-		if r.renderState.IsFullscreen() {
+		if r.renderState.FitsSmallImages() {
 			scale = math.Min(w/iw, h/ih)
 		} else if iw > w || ih > h {
 			scale = math.Min(w/iw, h/ih)
 		} else {
 			scale = 1
 		}
+		if r.renderState.IsIntegerZoom() {
+			scale = snapIntegerZoom(scale)
+		}
 		sw, sh := iw*scale, ih*scale
 		offsetX = w/2 - sw/2
 		offsetY = h/2 - sh/2
-		return scale, offsetX, offsetY
+		return scale, r.pixelAligned(offsetX), r.pixelAligned(offsetY)
 	}
 
 	scale = r.renderState.GetZoomLevel()
@@ -1054,12 +1500,23 @@ func (r *Renderer) calculateDisplayTransform(screen *ebiten.Image, imageW, image
 		offsetY = math.Max(h-sh, math.Min(0, h/2-sh/2+panY))
 	}
 
-	return scale, offsetX, offsetY
+	return scale, r.pixelAligned(offsetX), r.pixelAligned(offsetY)
+}
+
+// pixelAligned rounds an image offset to whole screen pixels in integer
+// zoom, so image pixels do not straddle screen pixels.
+func (r *Renderer) pixelAligned(offset float64) float64 {
+	if r.renderState.IsIntegerZoom() {
+		return math.Round(offset)
+	}
+	return offset
 }
 
 func (r *Renderer) drawDisplayImageTiles(screen *ebiten.Image, img DisplayImage, imageX, imageY int, layout displayLayout, scale, offsetX, offsetY float64) {
 	centerX := float64(layout.canvasW) / 2
 	centerY := float64(layout.canvasH) / 2
+	texScale := displayTextureScale(img)
+	shader, uniforms := r.displayFilter(scale / texScale)
 
 	for _, tile := range img.Tiles() {
 		if tile.Image == nil {
@@ -1068,7 +1525,13 @@ func (r *Renderer) drawDisplayImageTiles(screen *ebiten.Image, img DisplayImage,
 
 		op := &ebiten.DrawImageOptions{}
 		op.Filter = ebiten.FilterLinear
-		op.GeoM.Translate(float64(imageX+tile.X), float64(imageY+tile.Y))
+		if r.renderState.IsIntegerZoom() && scale/texScale >= 1 {
+			// Whole multiples draw each pixel as a sharp block
+			op.Filter = ebiten.FilterNearest
+		}
+		op.GeoM.Translate(float64(tile.X), float64(tile.Y))
+		op.GeoM.Scale(1/texScale, 1/texScale)
+		op.GeoM.Translate(float64(imageX), float64(imageY))
 		op.GeoM.Translate(-centerX, -centerY)
 
 		if r.renderState.IsFlippedH() {
@@ -1084,6 +1547,14 @@ func (r *Renderer) drawDisplayImageTiles(screen *ebiten.Image, img DisplayImage,
 		op.GeoM.Translate(float64(layout.transformedW)/2, float64(layout.transformedH)/2)
 		op.GeoM.Scale(scale, scale)
 		op.GeoM.Translate(offsetX, offsetY)
+		if shader != nil {
+			// The shader samples neighbors itself and interpolates linearly
+			b := tile.Image.Bounds()
+			sop := &ebiten.DrawRectShaderOptions{GeoM: op.GeoM, Uniforms: uniforms}
+			sop.Images[0] = tile.Image
+			screen.DrawRectShader(b.Dx(), b.Dy(), shader, sop)
+			continue
+		}
 		screen.DrawImage(tile.Image, op)
 	}
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// screenshotResult carries a written screenshot back to the game loop.
+type screenshotResult struct {
+	What string // "Screenshot" or "Spread", for the overlay message
+	Path string
+	Err  error
+}
+
+// screenshotDir returns the configured directory, defaulting to the user's
+// Pictures folder (or home when there is none).
+func screenshotDir(configured string) string {
+	if configured != "" {
+		if dir, ok := strings.CutPrefix(configured, "~"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				return filepath.Join(home, dir)
+			}
+		}
+		return configured
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	if info, err := os.Stat(filepath.Join(home, "Pictures")); err == nil && info.IsDir() {
+		return filepath.Join(home, "Pictures")
+	}
+	return home
+}
+
+// screenshotFileName names a capture after the time and the page shown.
+func screenshotFileName(p ImagePath, now time.Time) string {
+	name := "nv_" + now.Format("20060102_150405.000")
+	name = strings.Replace(name, ".", "_", 1)
+	if label := contactSheetLabelText(p); p.Path != "" && label != "" {
+		name += "_" + strings.TrimSuffix(label, filepath.Ext(label))
+	}
+	return name + ".png"
+}
+
+// requestScreenshot captures the next drawn frame.
+func (g *Game) requestScreenshot() {
+	g.screenshotPending = true
+	debugKV("screenshot", "requested")
+}
+
+// captureScreenshot reads back the frame just drawn and writes it on a
+// background goroutine. Called from Draw.
+func (g *Game) captureScreenshot(screen *ebiten.Image) {
+	if !g.screenshotPending {
+		return
+	}
+	g.screenshotPending = false
+	if g.screenshotResults == nil {
+		g.screenshotResults = make(chan screenshotResult, 4)
+	}
+
+	b := screen.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	screen.ReadPixels(img.Pix)
+	// Areas never drawn are transparent but show as black
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+
+	current, _ := g.imageManager.GetPath(g.idx)
+	path := filepath.Join(screenshotDir(g.config.ScreenshotDir), screenshotFileName(current, time.Now()))
+	results := g.screenshotResults
+	go func() {
+		defer recoverWorker("screenshot", path, func(err error) { results <- screenshotResult{What: "Screenshot", Path: path, Err: err} })
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = writeImageFile(path, img)
+		}
+		results <- screenshotResult{What: "Screenshot", Path: path, Err: err}
+	}()
+}
+
+func (g *Game) applyScreenshotResults() bool {
+	select {
+	case res := <-g.screenshotResults:
+		if res.Err != nil {
+			g.showOverlayMessage(fmt.Sprintf("%s failed: %v", res.What, res.Err))
+			warnKV("screenshot", "failed", "what", res.What, "path", res.Path, "error", res.Err)
+			return true
+		}
+		g.showOverlayMessage(res.What + " saved: " + res.Path)
+		infoKV("screenshot", "saved", "what", res.What, "path", res.Path)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Script hook events.
+const (
+	scriptEventStartup      = "startup"
+	scriptEventImageChanged = "image_changed"
+)
+
+// scriptActionPrefix marks keybinding actions implemented by scripts, e.g.
+// "script:copy_starred".
+const scriptActionPrefix = "script:"
+
+// scriptActionNames returns the script actions that have a binding, sorted so
+// they are checked in a stable order after the built-in actions.
+func scriptActionNames(bindings map[string][]string) []string {
+	var names []string
+	for action, inputs := range bindings {
+		if strings.HasPrefix(action, scriptActionPrefix) && len(inputs) > 0 {
+			names = append(names, action)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// scriptTimeout bounds each hook or action call so a runaway script cannot
+// freeze the viewer.
+const scriptTimeout = 2 * time.Second
+
+// scriptHost is the part of the viewer that scripts can see and drive.
+type scriptHost interface {
+	scriptCurrentImage() (scriptImageInfo, bool)
+	showOverlayMessage(message string)
+	scriptJumpToPage(page int)
+}
+
+// scriptImageInfo describes the current page to scripts.
+type scriptImageInfo struct {
+	Path    string
+	Archive string
+	Entry   string
+	Page    int
+	Total   int
+	Rating  int
+	Tags    []string
+}
+
+// scriptEngine runs user Lua scripts. All calls happen on the game loop, so
+// the Lua state needs no locking.
+type scriptEngine struct {
+	L       *lua.LState
+	host    scriptHost
+	hooks   map[string][]*lua.LFunction
+	actions map[string]*lua.LFunction
+}
+
+// newScriptEngine loads each script in order. A script that fails to load
+// is reported and skipped; the others still run.
+func newScriptEngine(host scriptHost, paths []string) (*scriptEngine, []error) {
+	e := &scriptEngine{
+		L:       lua.NewState(),
+		host:    host,
+		hooks:   make(map[string][]*lua.LFunction),
+		actions: make(map[string]*lua.LFunction),
+	}
+	e.L.SetGlobal("nv", e.module())
+
+	var errs []error
+	for _, path := range paths {
+		if err := e.call(func() error { return e.L.DoFile(path) }); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			warnKV("script", "load_failed", "path", path, "error", err)
+			continue
+		}
+		infoKV("script", "loaded", "path", path)
+	}
+	return e, errs
+}
+
+func (e *scriptEngine) Close() {
+	if e != nil {
+		e.L.Close()
+	}
+}
+
+// call runs fn with the script deadline applied.
+func (e *scriptEngine) call(fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+	e.L.SetContext(ctx)
+	defer e.L.RemoveContext()
+	return fn()
+}
+
+func (e *scriptEngine) callFunction(fn *lua.LFunction, args ...lua.LValue) error {
+	return e.call(func() error {
+		return e.L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, args...)
+	})
+}
+
+// Fire calls the hooks registered for event, stopping at the first error.
+func (e *scriptEngine) Fire(event string, args ...lua.LValue) error {
+	if e == nil {
+		return nil
+	}
+	for _, fn := range e.hooks[event] {
+		if err := e.callFunction(fn, args...); err != nil {
+			return fmt.Errorf("%s hook: %w", event, err)
+		}
+	}
+	return nil
+}
+
+// RunAction calls a script-defined action; ok is false when no script
+// registered name.
+func (e *scriptEngine) RunAction(name string) (ok bool, err error) {
+	if e == nil {
+		return false, nil
+	}
+	fn, ok := e.actions[name]
+	if !ok {
+		return false, nil
+	}
+	return true, e.callFunction(fn)
+}
+
+// ImageTable converts info to the table passed to hooks and nv.current().
+func (e *scriptEngine) ImageTable(info scriptImageInfo) *lua.LTable {
+	t := e.L.NewTable()
+	t.RawSetString("path", lua.LString(info.Path))
+	t.RawSetString("archive", lua.LString(info.Archive))
+	t.RawSetString("entry", lua.LString(info.Entry))
+	t.RawSetString("page", lua.LNumber(info.Page))
+	t.RawSetString("total", lua.LNumber(info.Total))
+	t.RawSetString("rating", lua.LNumber(info.Rating))
+	tags := e.L.NewTable()
+	for _, tag := range info.Tags {
+		tags.Append(lua.LString(tag))
+	}
+	t.RawSetString("tags", tags)
+	return t
+}
+
+// module builds the nv table exposed to scripts.
+func (e *scriptEngine) module() *lua.LTable {
+	return e.L.SetFuncs(e.L.NewTable(), map[string]lua.LGFunction{
+		"on": func(L *lua.LState) int {
+			event := L.CheckString(1)
+			if event != scriptEventStartup && event != scriptEventImageChanged {
+				L.ArgError(1, "unknown event "+event)
+			}
+			e.hooks[event] = append(e.hooks[event], L.CheckFunction(2))
+			return 0
+		},
+		"action": func(L *lua.LState) int {
+			e.actions[L.CheckString(1)] = L.CheckFunction(2)
+			return 0
+		},
+		"current": func(L *lua.LState) int {
+			info, ok := e.host.scriptCurrentImage()
+			if !ok {
+				L.Push(lua.LNil)
+				return 1
+			}
+			L.Push(e.ImageTable(info))
+			return 1
+		},
+		"message": func(L *lua.LState) int {
+			e.host.showOverlayMessage(L.CheckString(1))
+			return 0
+		},
+		"jump": func(L *lua.LState) int {
+			e.host.scriptJumpToPage(L.CheckInt(1))
+			return 0
+		},
+		"log": func(L *lua.LState) int {
+			infoKV("script", "log", "message", L.CheckString(1))
+			return 0
+		},
+		"copy_file": func(L *lua.LState) int {
+			return pushScriptResult(L, copyFile(L.CheckString(1), L.CheckString(2)))
+		},
+		"run": func(L *lua.LState) int {
+			args := make([]string, 0, L.GetTop()-1)
+			for i := 2; i <= L.GetTop(); i++ {
+				args = append(args, L.CheckString(i))
+			}
+			return pushScriptResult(L, startDetached(L.CheckString(1), args))
+		},
+	})
+}
+
+// pushScriptResult follows the Lua convention of returning true, or nil and
+// an error message.
+func pushScriptResult(L *lua.LState, err error) int {
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+// copyFile copies src to dst, creating dst's directory. A dst that is an
+// existing directory receives a file of the same name.
+func copyFile(src, dst string) error {
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	_, err = writeFileFromReader(dst, in)
+	return err
+}
+
+// startDetached starts a program without a shell and without waiting for
+// it; the exit status is only logged.
+func startDetached(name string, args []string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = io.Discard, io.Discard
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			warnKV("script", "command_failed", "command", name, "error", err)
+		}
+	}()
+	return nil
+}
+
+// resolveScriptPaths makes relative script paths relative to the directory
+// of the config file.
+func resolveScriptPaths(configPath string, scripts []string) []string {
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	dir := filepath.Dir(configPath)
+	resolved := make([]string, 0, len(scripts))
+	for _, s := range scripts {
+		if !filepath.IsAbs(s) {
+			s = filepath.Join(dir, s)
+		}
+		resolved = append(resolved, s)
+	}
+	return resolved
+}
+
+// scriptCurrentImage implements scriptHost.
+func (g *Game) scriptCurrentImage() (scriptImageInfo, bool) {
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok {
+		return scriptImageInfo{}, false
+	}
+	entry := g.ratings.Get(ratingKey(p))
+	return scriptImageInfo{
+		Path:    p.Path,
+		Archive: p.ArchivePath,
+		Entry:   p.EntryPath,
+		Page:    g.idx + 1,
+		Total:   g.imageManager.GetPathsCount(),
+		Rating:  entry.Rating,
+		Tags:    entry.Tags,
+	}, true
+}
+
+// scriptJumpToPage implements scriptHost. Script jumps are automatic, so
+// they stay out of the undo history.
+func (g *Game) scriptJumpToPage(page int) {
+	g.jumpToPageAutomatically(page)
+}
+
+// loadScripts (re)starts the script engine from the config and runs the
+// startup hooks.
+func (g *Game) loadScripts() {
+	g.scripts.Close()
+	g.scripts = nil
+	g.scriptLastPath = ""
+	if len(g.config.Scripts) == 0 {
+		return
+	}
+
+	engine, errs := newScriptEngine(g, resolveScriptPaths(g.configPath, g.config.Scripts))
+	g.scripts = engine
+	if len(errs) > 0 {
+		g.showOverlayMessage(fmt.Sprintf("Script error: %v", errs[0]))
+	}
+	g.reportScriptError(engine.Fire(scriptEventStartup))
+}
+
+// notifyScriptImageChanged fires image_changed when the current page differs
+// from the one last reported. It reports whether a hook ran.
+func (g *Game) notifyScriptImageChanged() bool {
+	if g.scripts == nil || len(g.scripts.hooks[scriptEventImageChanged]) == 0 {
+		return false
+	}
+	info, ok := g.scriptCurrentImage()
+	if !ok || info.Path == g.scriptLastPath {
+		return false
+	}
+	g.scriptLastPath = info.Path
+	g.reportScriptError(g.scripts.Fire(scriptEventImageChanged, g.scripts.ImageTable(info)))
+	return true
+}
+
+func (g *Game) runScriptAction(name string) {
+	ok, err := g.scripts.RunAction(name)
+	if !ok {
+		g.showOverlayMessage("Unknown script action: " + name)
+		warnKV("script", "action_unknown", "action", name)
+		return
+	}
+	debugKV("script", "action", "action", name)
+	g.reportScriptError(err)
+}
+
+func (g *Game) reportScriptError(err error) {
+	if err == nil {
+		return
+	}
+	msg := err.Error()
+	if first, _, ok := strings.Cut(msg, "\n"); ok {
+		msg = first
+	}
+	g.showOverlayMessage("Script error: " + msg)
+	warnKV("script", "error", "error", err)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchResultLimit caps how many matches the search overlay lists.
+const searchResultLimit = 12
+
+// SearchResult is one entry of the fuzzy search overlay.
+type SearchResult struct {
+	Index  int    // Index into the searched path list
+	Name   string // Display name that was matched
+	Score  int
+	Hidden bool // Hidden by the rating, tag or name filter
+}
+
+// fuzzyScore matches query as a case-insensitive subsequence of candidate,
+// fzf style. Consecutive matches and matches at word starts score higher;
+// gaps between matched characters cost a little.
+func fuzzyScore(query, candidate string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	c := []rune(candidate)
+	score, qi, prevMatch := 0, 0, -1
+	for i := 0; i < len(c) && qi < len(q); i++ {
+		if unicode.ToLower(c[i]) != q[qi] {
+			continue
+		}
+		score += 16
+		if prevMatch >= 0 && i == prevMatch+1 {
+			score += 8
+		} else if prevMatch >= 0 {
+			score -= min(i-prevMatch-1, 8)
+		}
+		if i == 0 || strings.ContainsRune("/\\_-. ", c[i-1]) {
+			score += 8
+		}
+		prevMatch = i
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
+
+// fuzzySearchPaths returns the best matches for query, highest score first.
+// An empty query lists the first entries in collection order.
+func fuzzySearchPaths(paths []ImagePath, query string, limit int) []SearchResult {
+	var results []SearchResult
+	for i, p := range paths {
+		name := imageDisplayName(p)
+		if p.ArchivePath != "" {
+			name = p.Path
+		}
+		score, ok := fuzzyScore(query, name)
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{Index: i, Name: name, Score: score})
+	}
+
+	if query != "" {
+		results = fuzzySortResults(results)
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// fuzzySortResults orders results by score, shorter names first on ties.
+func fuzzySortResults(results []SearchResult) []SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].Name) < len(results[j].Name)
+	})
+	return results
+}
+
+func (g *Game) enterSearch() {
+	if g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+	g.openTextPrompt(TextPromptSearch, "")
+	g.searchBase = g.unfilteredPaths()
+	g.updateSearch("")
+}
+
+// unfilteredPaths returns every loaded image, including those the rating,
+// tag or name filter hides, so a search can still find them.
+func (g *Game) unfilteredPaths() []ImagePath {
+	current := g.currentPaths()
+	if !g.collectionFilter.Active() {
+		return current
+	}
+	paths, err := g.collectionSource.collect(g.config.SortMethod)
+	if err != nil || len(paths) == 0 {
+		warnKV("collection", "search_collect_failed", "error", err, "fallback", "filtered_list")
+		return current
+	}
+	return keepPastedPages(current, paths)
+}
+
+// searchFromPageInput turns page input into a file name search for query,
+// for when a page is easier to find by name than by number.
+func (g *Game) searchFromPageInput(query string) {
+	g.pageInputMode = false
+	g.pageInputBuffer = ""
+	if g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+	g.openTextPrompt(TextPromptSearch, query)
+	g.searchBase = g.unfilteredPaths()
+	g.updateSearch(query)
+}
+
+// updateSearch recomputes the result list for query over the unfiltered
+// list and resets the selection to the best match.
+func (g *Game) updateSearch(query string) {
+	paths := g.searchBase
+	if paths == nil {
+		paths = g.currentPaths()
+	}
+	g.searchResults = fuzzySearchPaths(paths, query, searchResultLimit)
+	g.searchSelection = 0
+	hidden := 0
+	if g.collectionFilter.Active() {
+		visible := make(map[string]bool, g.imageManager.GetPathsCount())
+		for _, p := range g.currentPaths() {
+			visible[p.Path] = true
+		}
+		for _, p := range paths {
+			if !visible[p.Path] {
+				hidden++
+			}
+		}
+		for i := range g.searchResults {
+			g.searchResults[i].Hidden = !visible[paths[g.searchResults[i].Index].Path]
+		}
+	}
+	switch {
+	case query != "":
+		g.textPromptStatus = fmt.Sprintf("%d shown", len(g.searchResults))
+	case hidden > 0:
+		g.textPromptStatus = fmt.Sprintf("%d images, %d filtered out", len(paths), hidden)
+	default:
+		g.textPromptStatus = fmt.Sprintf("%d images", len(paths))
+	}
+}
+
+// selectedSearchPath returns the image of the highlighted search result.
+func (g *Game) selectedSearchPath() (ImagePath, bool) {
+	idx, ok := g.selectedSearchIndex()
+	if !ok || idx >= len(g.searchBase) {
+		return ImagePath{}, false
+	}
+	return g.searchBase[idx], true
+}
+
+// jumpToSearchResult shows p. A page hidden by the filter clears the filter
+// first, since it cannot be shown otherwise, and says so.
+func (g *Game) jumpToSearchResult(p ImagePath) {
+	idx := findImagePathIndex(g.currentPaths(), p.Path)
+	if idx < 0 && g.collectionFilter.Active() {
+		prev := g.collectionFilter
+		g.collectionFilter = CollectionFilter{}
+		if !g.reloadPathsForCurrentSource() {
+			g.collectionFilter = prev
+			g.showOverlayMessage("Search: " + imageDisplayName(p) + " is no longer available")
+			return
+		}
+		idx = findImagePathIndex(g.currentPaths(), p.Path)
+		if idx >= 0 {
+			g.jumpToPage(idx + 1)
+		}
+		g.showOverlayMessage(fmt.Sprintf("Filter %s cleared to show %s", prev.String(), imageDisplayName(p)))
+		debugKV("collection", "search_cleared_filter", "filter", prev.String(), "path", p.Path)
+		return
+	}
+	if idx >= 0 {
+		g.jumpToPage(idx + 1)
+	}
+}
+
+func (g *Game) moveSearchSelection(delta int) {
+	if len(g.searchResults) == 0 {
+		return
+	}
+	n := len(g.searchResults)
+	g.searchSelection = ((g.searchSelection+delta)%n + n) % n
+}
+
+// selectedSearchIndex returns the path index of the highlighted result.
+func (g *Game) selectedSearchIndex() (int, bool) {
+	if g.searchSelection < 0 || g.searchSelection >= len(g.searchResults) {
+		return 0, false
+	}
+	return g.searchResults[g.searchSelection].Index, true
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"nv/internal/imgdecode"
 )
 
 // Settings UI model is intentionally simple: a flat list of items with
@@ -17,25 +21,74 @@ func settingsListOrder() []string {
 		"DefaultWindowWidth",
 		"DefaultWindowHeight",
 		"Fullscreen",
+		"Maximized",
+		"FullscreenMonitor",
 		"FontSize",
 		"BookMode",
+		"BookModePolicy",
+		"PlanSpreads",
 		"RightToLeft",
 		"SortMethod",
 		"AspectRatioThreshold",
 		"InitialZoomMode",
+		"UpscaleInWindow",
+		"IntegerZoom",
+		"AutoRotate",
 		"FitWidthAlignTop",
 		"FitHeightAlignLeft",
+		"PanSpeed",
+		"PanTurnsPage",
+		"PageScrollFraction",
 		"MaxImageDimension",
+		"ToneMapOperator",
+		"HDRExposure",
+		"DisplaySharpen",
+		"DisplayDenoise",
 		"CacheSize (restart)",
 		"TransitionFrames",
 		"PreloadEnabled",
 		"PreloadCount",
+		"UploadBudgetMB",
+		"GPUMemoryCapMB",
+		"IdleThrottle",
+		"LogToFile (restart)",
+		"LogLevel",
+		"CheckForUpdates (restart)",
+		"SaveOnExit",
+		"ArchivePrefetch",
+		"ArchivePrefetchMaxMB",
+		"SkipUnreadableImages",
+		"SniffContent",
+		"ContactSheetColumns",
+		"ContactSheetCellSize",
+		"ContactSheetLabels",
+		"SlideshowSeconds",
+		"ReadingTimerSeconds",
+		"ReadingTimerAdvance",
+		"PageToast",
+		"NextPagePreview",
+		"VolumeEnd",
+		"HardDelete",
+		"ConfirmDelete",
+		"MediaControls",
+		"TrackReadingProgress",
+		"RememberDirection",
+		"AutoDirection",
+		"PresentationPointer",
+		"OnScreenControls",
+		"CursorHideSeconds",
+		"MeasureDPI",
+		"Upscale",
+		"UpscaleFactor",
+		"GridSpacing",
 		"Mouse.EnableMouse",
 		"Mouse.WheelSensitivity",
 		"Mouse.WheelInverted",
 		"Mouse.EnableDragPan",
 		"Mouse.DragSensitivity",
 		"Mouse.DragPanInverted",
+		"Mouse.WheelZoomed",
+		"Mouse.WheelPageModifier",
 		"Mouse.DoubleClickTime",
 		"Mouse.DragThreshold",
 		"[ Save ]",
@@ -61,6 +114,16 @@ func getSettingValueStringFromConfig(c Config, i int) string {
 			return "ON"
 		}
 		return "OFF"
+	case "Maximized":
+		if c.Maximized {
+			return "ON"
+		}
+		return "OFF"
+	case "FullscreenMonitor":
+		if c.FullscreenMonitor == 0 {
+			return "Window's monitor"
+		}
+		return fmt.Sprintf("%d", c.FullscreenMonitor)
 	case "FontSize":
 		return fmt.Sprintf("%.1f", c.FontSize)
 	case "BookMode":
@@ -79,11 +142,38 @@ func getSettingValueStringFromConfig(c Config, i int) string {
 		return fmt.Sprintf("%.2f", c.AspectRatioThreshold)
 	case "InitialZoomMode":
 		return c.InitialZoomMode
+	case "AutoRotate":
+		if c.AutoRotate {
+			return "ON"
+		}
+		return "OFF"
+	case "IntegerZoom":
+		if c.IntegerZoom {
+			return "ON"
+		}
+		return "OFF"
+	case "UpscaleInWindow":
+		if c.UpscaleInWindow {
+			return "ON"
+		}
+		return "OFF"
 	case "FitWidthAlignTop":
 		if c.FitWidthAlignTop {
 			return "ON"
 		}
 		return "OFF"
+	case "PanSpeed":
+		if c.PanSpeed == 0 {
+			return "OFF (steps)"
+		}
+		return fmt.Sprintf("%.1f screens/s", c.PanSpeed)
+	case "PageScrollFraction":
+		return fmt.Sprintf("%.0f%%", c.PageScrollFraction*100)
+	case "PanTurnsPage":
+		if c.PanTurnsPage {
+			return "ON"
+		}
+		return "OFF"
 	case "FitHeightAlignLeft":
 		if c.FitHeightAlignLeft {
 			return "ON"
@@ -94,6 +184,14 @@ func getSettingValueStringFromConfig(c Config, i int) string {
 			return fmt.Sprintf("Auto (%d)", defaultMaxImageDimension)
 		}
 		return fmt.Sprintf("%d", c.MaxImageDimension)
+	case "ToneMapOperator":
+		return c.ToneMapOperator
+	case "HDRExposure":
+		return fmt.Sprintf("%+.1f EV", c.HDRExposure)
+	case "DisplaySharpen":
+		return fmt.Sprintf("%.2f", c.DisplaySharpen)
+	case "DisplayDenoise":
+		return fmt.Sprintf("%.1f", c.DisplayDenoise)
 	case "CacheSize (restart)":
 		return fmt.Sprintf("%d", c.CacheSize)
 	case "TransitionFrames":
@@ -105,6 +203,142 @@ func getSettingValueStringFromConfig(c Config, i int) string {
 		return "OFF"
 	case "PreloadCount":
 		return fmt.Sprintf("%d", c.PreloadCount)
+	case "UploadBudgetMB":
+		if c.UploadBudgetMB == 0 {
+			return "Unlimited"
+		}
+		return fmt.Sprintf("%d MB/frame", c.UploadBudgetMB)
+	case "GPUMemoryCapMB":
+		if c.GPUMemoryCapMB == 0 {
+			return "Unlimited"
+		}
+		return fmt.Sprintf("%d MB", c.GPUMemoryCapMB)
+	case "IdleThrottle":
+		if c.IdleThrottle {
+			return "ON"
+		}
+		return "OFF"
+	case "LogToFile (restart)":
+		if c.LogToFile {
+			return "ON"
+		}
+		return "OFF"
+	case "LogLevel":
+		return c.LogLevel
+	case "BookModePolicy":
+		return c.BookModePolicy
+	case "PlanSpreads":
+		if c.PlanSpreads {
+			return "ON"
+		}
+		return "OFF"
+	case "SaveOnExit":
+		return c.SaveOnExit
+	case "CheckForUpdates (restart)":
+		if c.CheckForUpdates {
+			return "ON"
+		}
+		return "OFF"
+	case "ArchivePrefetch":
+		return c.ArchivePrefetch
+	case "VolumeEnd":
+		return c.VolumeEnd
+	case "ArchivePrefetchMaxMB":
+		return fmt.Sprintf("%d MB", c.ArchivePrefetchMaxMB)
+	case "SkipUnreadableImages":
+		if c.SkipUnreadableImages {
+			return "ON"
+		}
+		return "OFF"
+	case "SniffContent":
+		if c.SniffContent {
+			return "ON"
+		}
+		return "OFF"
+	case "ContactSheetColumns":
+		return fmt.Sprintf("%d", c.ContactSheetColumns)
+	case "ContactSheetCellSize":
+		return fmt.Sprintf("%d px", c.ContactSheetCellSize)
+	case "ContactSheetLabels":
+		if c.ContactSheetLabels {
+			return "ON"
+		}
+		return "OFF"
+	case "SlideshowSeconds":
+		return fmt.Sprintf("%d s", c.SlideshowSeconds)
+	case "ReadingTimerSeconds":
+		return fmt.Sprintf("%d s", c.ReadingTimerSeconds)
+	case "ReadingTimerAdvance":
+		if c.ReadingTimerAdvance {
+			return "ON"
+		}
+		return "OFF"
+	case "MediaControls":
+		if c.MediaControls {
+			return "ON"
+		}
+		return "OFF"
+	case "PageToast":
+		if c.PageToast {
+			return "ON"
+		}
+		return "OFF"
+	case "NextPagePreview":
+		if c.NextPagePreview {
+			return "ON"
+		}
+		return "OFF"
+	case "HardDelete":
+		if c.HardDelete {
+			return "ON"
+		}
+		return "OFF"
+	case "ConfirmDelete":
+		if c.ConfirmDelete {
+			return "ON"
+		}
+		return "OFF"
+	case "AutoDirection":
+		if c.AutoDirection {
+			return "ON"
+		}
+		return "OFF"
+	case "RememberDirection":
+		if c.RememberDirection {
+			return "ON"
+		}
+		return "OFF"
+	case "TrackReadingProgress":
+		if c.TrackReadingProgress {
+			return "ON"
+		}
+		return "OFF"
+	case "PresentationPointer":
+		if c.PresentationPointer {
+			return "ON"
+		}
+		return "OFF"
+	case "OnScreenControls":
+		if c.OnScreenControls {
+			return "ON"
+		}
+		return "OFF"
+	case "CursorHideSeconds":
+		if c.CursorHideSeconds == 0 {
+			return "Fullscreen/slideshow only"
+		}
+		return fmt.Sprintf("%d s", c.CursorHideSeconds)
+	case "Upscale":
+		return c.Upscale
+	case "UpscaleFactor":
+		return fmt.Sprintf("%dx", c.UpscaleFactor)
+	case "GridSpacing":
+		return fmt.Sprintf("%d px", c.GridSpacing)
+	case "MeasureDPI":
+		if c.MeasureDPI == 0 {
+			return "OFF (pixels)"
+		}
+		return fmt.Sprintf("%d dpi", c.MeasureDPI)
 	case "Mouse.EnableMouse":
 		if c.MouseSettings.EnableMouse {
 			return "ON"
@@ -129,6 +363,13 @@ func getSettingValueStringFromConfig(c Config, i int) string {
 			return "ON"
 		}
 		return "OFF"
+	case "Mouse.WheelZoomed":
+		return c.MouseSettings.WheelZoomed
+	case "Mouse.WheelPageModifier":
+		if c.MouseSettings.WheelPageModifier == "" {
+			return "None"
+		}
+		return c.MouseSettings.WheelPageModifier
 	case "Mouse.DoubleClickTime":
 		return fmt.Sprintf("%d ms", c.MouseSettings.DoubleClickTime)
 	case "Mouse.DragThreshold":
@@ -229,8 +470,20 @@ func (g *Game) settingsAdjust(left bool) {
 		c.InitialZoomMode = modes[cur]
 	case "FitWidthAlignTop":
 		c.FitWidthAlignTop = !c.FitWidthAlignTop
+	case "UpscaleInWindow":
+		c.UpscaleInWindow = !c.UpscaleInWindow
+	case "IntegerZoom":
+		c.IntegerZoom = !c.IntegerZoom
+	case "AutoRotate":
+		c.AutoRotate = !c.AutoRotate
 	case "FitHeightAlignLeft":
 		c.FitHeightAlignLeft = !c.FitHeightAlignLeft
+	case "PanSpeed":
+		c.PanSpeed = clampFloat(c.PanSpeed+float64(stepSign)*floatStep, 0, 10)
+	case "PanTurnsPage":
+		c.PanTurnsPage = !c.PanTurnsPage
+	case "PageScrollFraction":
+		c.PageScrollFraction = clampFloat(c.PageScrollFraction+float64(stepSign)*floatStep, 0.1, 1)
 	case "MaxImageDimension":
 		const minMaxImageDimension = 512
 		const maxMaxImageDimension = 16383
@@ -247,16 +500,154 @@ func (g *Game) settingsAdjust(left bool) {
 		} else {
 			c.MaxImageDimension = clampInt(newValue, minMaxImageDimension, maxMaxImageDimension)
 		}
+	case "ToneMapOperator":
+		ops := imgdecode.ToneMapOperators
+		cur := slices.Index(ops, c.ToneMapOperator)
+		if left {
+			cur = (cur + len(ops) - 1) % len(ops)
+		} else {
+			cur = (cur + 1) % len(ops)
+		}
+		c.ToneMapOperator = ops[cur]
+	case "HDRExposure":
+		c.HDRExposure = clampHDRExposure(c.HDRExposure + float64(stepSign)*hdrExposureStep)
+	case "DisplaySharpen":
+		c.DisplaySharpen = clampSharpen(c.DisplaySharpen + float64(stepSign)*sharpenStep)
+	case "DisplayDenoise":
+		c.DisplayDenoise = clampDenoise(c.DisplayDenoise + float64(stepSign)*denoiseStep)
 	case "CacheSize (restart)":
 		c.CacheSize = clampInt(c.CacheSize+stepSign*1, 1, 64)
 	case "TransitionFrames":
 		c.TransitionFrames = clampInt(c.TransitionFrames+stepSign*1, 0, 60)
 	case "Fullscreen":
 		c.Fullscreen = !c.Fullscreen
+	case "Maximized":
+		c.Maximized = !c.Maximized
+	case "FullscreenMonitor":
+		c.FullscreenMonitor = clampInt(c.FullscreenMonitor+stepSign*1, 0, len(ebiten.AppendMonitors(nil)))
 	case "PreloadEnabled":
 		c.PreloadEnabled = !c.PreloadEnabled
 	case "PreloadCount":
 		c.PreloadCount = clampInt(c.PreloadCount+stepSign*1, 1, 16)
+	case "UploadBudgetMB":
+		c.UploadBudgetMB = clampInt(c.UploadBudgetMB+stepSign*8, 0, 1024)
+	case "GPUMemoryCapMB":
+		c.GPUMemoryCapMB = clampInt(c.GPUMemoryCapMB+stepSign*128, 0, 65536)
+	case "IdleThrottle":
+		c.IdleThrottle = !c.IdleThrottle
+	case "LogToFile (restart)":
+		c.LogToFile = !c.LogToFile
+	case "CheckForUpdates (restart)":
+		c.CheckForUpdates = !c.CheckForUpdates
+	case "PlanSpreads":
+		c.PlanSpreads = !c.PlanSpreads
+	case "BookModePolicy":
+		cur := slices.Index(bookModePolicies, c.BookModePolicy)
+		if left {
+			cur = (cur + len(bookModePolicies) - 1) % len(bookModePolicies)
+		} else {
+			cur = (cur + 1) % len(bookModePolicies)
+		}
+		c.BookModePolicy = bookModePolicies[cur]
+	case "SaveOnExit":
+		cur := slices.Index(saveOnExitPolicies, c.SaveOnExit)
+		if left {
+			cur = (cur + len(saveOnExitPolicies) - 1) % len(saveOnExitPolicies)
+		} else {
+			cur = (cur + 1) % len(saveOnExitPolicies)
+		}
+		c.SaveOnExit = saveOnExitPolicies[cur]
+	case "LogLevel":
+		cur := slices.Index(logLevels, logLevel(c.LogLevel))
+		if left {
+			cur = (cur + len(logLevels) - 1) % len(logLevels)
+		} else {
+			cur = (cur + 1) % len(logLevels)
+		}
+		c.LogLevel = string(logLevels[cur])
+	case "ArchivePrefetch":
+		cur := slices.Index(archivePrefetchModes, c.ArchivePrefetch)
+		if left {
+			cur = (cur + len(archivePrefetchModes) - 1) % len(archivePrefetchModes)
+		} else {
+			cur = (cur + 1) % len(archivePrefetchModes)
+		}
+		c.ArchivePrefetch = archivePrefetchModes[cur]
+	case "VolumeEnd":
+		cur := slices.Index(volumeEndModes, c.VolumeEnd)
+		if left {
+			cur = (cur + len(volumeEndModes) - 1) % len(volumeEndModes)
+		} else {
+			cur = (cur + 1) % len(volumeEndModes)
+		}
+		c.VolumeEnd = volumeEndModes[cur]
+	case "ArchivePrefetchMaxMB":
+		c.ArchivePrefetchMaxMB = clampInt(c.ArchivePrefetchMaxMB+stepSign*64, 64, 16384)
+	case "SkipUnreadableImages":
+		c.SkipUnreadableImages = !c.SkipUnreadableImages
+	case "SniffContent":
+		c.SniffContent = !c.SniffContent
+	case "ContactSheetColumns":
+		c.ContactSheetColumns = clampInt(c.ContactSheetColumns+stepSign, 1, 32)
+	case "ContactSheetCellSize":
+		c.ContactSheetCellSize = clampInt(c.ContactSheetCellSize+stepSign*32, 32, 1024)
+	case "ContactSheetLabels":
+		c.ContactSheetLabels = !c.ContactSheetLabels
+	case "SlideshowSeconds":
+		c.SlideshowSeconds = clampInt(c.SlideshowSeconds+stepSign, 1, 3600)
+	case "ReadingTimerSeconds":
+		c.ReadingTimerSeconds = clampInt(c.ReadingTimerSeconds+stepSign, 1, 3600)
+	case "ReadingTimerAdvance":
+		c.ReadingTimerAdvance = !c.ReadingTimerAdvance
+	case "MediaControls":
+		c.MediaControls = !c.MediaControls
+	case "TrackReadingProgress":
+		c.TrackReadingProgress = !c.TrackReadingProgress
+	case "RememberDirection":
+		c.RememberDirection = !c.RememberDirection
+	case "AutoDirection":
+		c.AutoDirection = !c.AutoDirection
+	case "PageToast":
+		c.PageToast = !c.PageToast
+	case "NextPagePreview":
+		c.NextPagePreview = !c.NextPagePreview
+	case "HardDelete":
+		c.HardDelete = !c.HardDelete
+	case "ConfirmDelete":
+		c.ConfirmDelete = !c.ConfirmDelete
+	case "PresentationPointer":
+		c.PresentationPointer = !c.PresentationPointer
+	case "OnScreenControls":
+		c.OnScreenControls = !c.OnScreenControls
+	case "CursorHideSeconds":
+		c.CursorHideSeconds = clampInt(c.CursorHideSeconds+stepSign, 0, 3600)
+	case "Upscale":
+		// "command" is only offered once upscale_command is configured
+		modes := upscaleModes
+		if strings.TrimSpace(c.UpscaleCommand) == "" {
+			modes = modes[:2]
+		}
+		cur := max(0, slices.Index(modes, c.Upscale))
+		if left {
+			cur = (cur + len(modes) - 1) % len(modes)
+		} else {
+			cur = (cur + 1) % len(modes)
+		}
+		c.Upscale = modes[cur]
+	case "UpscaleFactor":
+		c.UpscaleFactor = clampInt(c.UpscaleFactor+stepSign, 2, 4)
+	case "GridSpacing":
+		c.GridSpacing = clampInt(c.GridSpacing+stepSign*8, 8, 4096)
+	case "MeasureDPI":
+		// Custom values from the config file step to the next preset up
+		cur, found := slices.BinarySearch(measureDPIPresets, c.MeasureDPI)
+		switch {
+		case left:
+			cur = (cur + len(measureDPIPresets) - 1) % len(measureDPIPresets)
+		case found:
+			cur = (cur + 1) % len(measureDPIPresets)
+		}
+		c.MeasureDPI = measureDPIPresets[cur%len(measureDPIPresets)]
 	case "Mouse.EnableMouse":
 		c.MouseSettings.EnableMouse = !c.MouseSettings.EnableMouse
 	case "Mouse.WheelSensitivity":
@@ -269,6 +660,22 @@ func (g *Game) settingsAdjust(left bool) {
 		c.MouseSettings.DragSensitivity = clampFloat(c.MouseSettings.DragSensitivity+float64(stepSign)*floatStep, 0.1, 5.0)
 	case "Mouse.DragPanInverted":
 		c.MouseSettings.DragPanInverted = !c.MouseSettings.DragPanInverted
+	case "Mouse.WheelZoomed":
+		cur := slices.Index(wheelZoomedModes, c.MouseSettings.WheelZoomed)
+		if left {
+			cur = (cur + len(wheelZoomedModes) - 1) % len(wheelZoomedModes)
+		} else {
+			cur = (cur + 1) % len(wheelZoomedModes)
+		}
+		c.MouseSettings.WheelZoomed = wheelZoomedModes[cur]
+	case "Mouse.WheelPageModifier":
+		cur := slices.Index(wheelPageModifiers, c.MouseSettings.WheelPageModifier)
+		if left {
+			cur = (cur + len(wheelPageModifiers) - 1) % len(wheelPageModifiers)
+		} else {
+			cur = (cur + 1) % len(wheelPageModifiers)
+		}
+		c.MouseSettings.WheelPageModifier = wheelPageModifiers[cur]
 	case "Mouse.DoubleClickTime":
 		c.MouseSettings.DoubleClickTime = clampInt(c.MouseSettings.DoubleClickTime+stepSign*50, 100, 1000)
 	case "Mouse.DragThreshold":
@@ -6,6 +6,9 @@ type stubImageManager struct {
 	paths             []ImagePath
 	images            []DisplayImage
 	preloadDirections []NavigationDirection
+	preloadLayout     PreloadLayout
+	failed            map[int]string
+	invalidated       []int
 }
 
 func testDisplayImage(w, h int) DisplayImage {
@@ -54,6 +57,10 @@ func (m *stubImageManager) StartPreload(currentIdx int, direction NavigationDire
 	m.preloadDirections = append(m.preloadDirections, direction)
 }
 
+func (m *stubImageManager) SetPreloadLayout(layout PreloadLayout) {
+	m.preloadLayout = layout
+}
+
 func (m *stubImageManager) StopPreload() {}
 
 func (m *stubImageManager) GetPreloadStats() PreloadStats {
@@ -63,3 +70,35 @@ func (m *stubImageManager) GetPreloadStats() PreloadStats {
 func (m *stubImageManager) ConsumeAsyncRefresh() bool {
 	return false
 }
+
+func (m *stubImageManager) IsLoadFailed(idx int) bool {
+	_, ok := m.failed[idx]
+	return ok
+}
+
+func (m *stubImageManager) GetLoadErrors() []ImageLoadError {
+	var errs []ImageLoadError
+	for i, imagePath := range m.paths {
+		if reason, ok := m.failed[i]; ok {
+			errs = append(errs, ImageLoadError{Index: i, Path: imagePath.Path, Reason: reason})
+		}
+	}
+	return errs
+}
+
+func (m *stubImageManager) InvalidateImage(idx int) bool {
+	if idx < 0 || idx >= len(m.paths) {
+		return false
+	}
+	m.invalidated = append(m.invalidated, idx)
+	delete(m.failed, idx)
+	return true
+}
+
+func (m *stubImageManager) RenamePath(idx int, newPath string) bool {
+	if idx < 0 || idx >= len(m.paths) {
+		return false
+	}
+	m.paths[idx] = ImagePath{Path: newPath}
+	return true
+}
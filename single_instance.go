@@ -167,6 +167,7 @@ func (m *singleInstanceManager) serve(bridge *singleInstanceBridge) {
 
 func (m *singleInstanceManager) handleConn(conn io.ReadWriteCloser, bridge *singleInstanceBridge) {
 	defer conn.Close()
+	defer recoverWorker("single_instance", "", nil)
 
 	reader := bufio.NewReader(conn)
 	writer := bufio.NewWriter(conn)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultSlideshowSeconds = 5
+
+// setSlideshow starts or stops automatic page advance.
+func (g *Game) setSlideshow(on bool) {
+	if on == g.slideshowActive {
+		return
+	}
+	if on && g.imageManager.GetPathsCount() == 0 {
+		return
+	}
+	g.slideshowActive = on
+	g.slideshowElapsed = 0
+	g.slideshowIdx = g.idx
+	if on {
+		g.showOverlayMessage(fmt.Sprintf("Slideshow: %ds per page", g.config.SlideshowSeconds))
+	} else {
+		g.showOverlayMessage("Slideshow stopped")
+	}
+	debugKV("navigation", "slideshow", "active", on, "seconds", g.config.SlideshowSeconds, "idx", g.idx)
+}
+
+func (g *Game) toggleSlideshow() {
+	g.setSlideshow(!g.slideshowActive)
+}
+
+// advanceSlideshow moves to the next page once the interval has passed.
+// Manual navigation restarts the interval; the slideshow stops on the last
+// page. It reports whether the display changed.
+func (g *Game) advanceSlideshow(tick time.Duration) bool {
+	if !g.slideshowActive {
+		return false
+	}
+	if g.idx != g.slideshowIdx {
+		g.slideshowIdx = g.idx
+		g.slideshowElapsed = 0
+	}
+	g.slideshowElapsed += tick
+	if g.slideshowElapsed < time.Duration(g.config.SlideshowSeconds)*time.Second {
+		return false
+	}
+
+	g.slideshowElapsed = 0
+	prev := g.idx
+	g.NavigateNext()
+	if g.idx == prev && g.kiosk {
+		// Kiosk displays run unattended, so start over
+		g.jumpToPageAutomatically(1)
+		g.slideshowIdx = g.idx
+		debugKV("navigation", "slideshow_looped", "idx", g.idx)
+		return true
+	}
+	if g.idx == prev {
+		g.slideshowActive = false
+		g.showOverlayMessage("Slideshow finished")
+		debugKV("navigation", "slideshow_finished", "idx", g.idx)
+		return true
+	}
+	g.slideshowIdx = g.idx
+	return true
+}
@@ -29,7 +29,7 @@ func (s *NaturalSortStrategy) Sort(images []ImagePath) []ImagePath {
 	copy(result, images)
 
 	sort.Slice(result, func(i, j int) bool {
-		return natural.Less(result[i].Path, result[j].Path)
+		return groupedLess(result[i], result[j], natural.Less)
 	})
 
 	return result
@@ -56,7 +56,7 @@ func (s *SimpleSortStrategy) Sort(images []ImagePath) []ImagePath {
 	copy(result, images)
 
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].Path < result[j].Path
+		return groupedLess(result[i], result[j], func(x, y string) bool { return x < y })
 	})
 
 	return result
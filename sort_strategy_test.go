@@ -265,3 +265,28 @@ func pathsToStrings(paths []ImagePath) []string {
 	}
 	return strings
 }
+
+func TestPureSortGroupsArchiveEntriesByFolder(t *testing.T) {
+	entry := func(name string) ImagePath {
+		return ImagePath{Path: "book.zip:" + name, ArchivePath: "book.zip", EntryPath: name}
+	}
+	input := []ImagePath{
+		entry("ch10/01.png"),
+		entry("ch2/b.png"),
+		entry("z-cover.png"),
+		entry("ch2/a.png"),
+		entry("ch2-extra.png"),
+	}
+	want := []ImagePath{
+		entry("ch2-extra.png"),
+		entry("z-cover.png"),
+		entry("ch2/a.png"),
+		entry("ch2/b.png"),
+		entry("ch10/01.png"),
+	}
+
+	got := (&NaturalSortStrategy{}).Sort(input)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NaturalSortStrategy.Sort() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stitchSpread puts left and right side by side imageGap apart, centered
+// vertically as book mode draws them, on bg.
+func stitchSpread(left, right image.Image, bg color.Color) *image.NRGBA {
+	lb, rb := left.Bounds(), right.Bounds()
+	h := max(lb.Dy(), rb.Dy())
+	dst := image.NewNRGBA(image.Rect(0, 0, lb.Dx()+imageGap+rb.Dx(), h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, (h-lb.Dy())/2, lb.Dx(), (h-lb.Dy())/2+lb.Dy()), left, lb.Min, draw.Over)
+	x := lb.Dx() + imageGap
+	draw.Draw(dst, image.Rect(x, (h-rb.Dy())/2, x+rb.Dx(), (h-rb.Dy())/2+rb.Dy()), right, rb.Min, draw.Over)
+	return dst
+}
+
+// spreadFileName names a stitched spread after its volume and pages, e.g.
+// "vol1_p012-013.png".
+func spreadFileName(first ImagePath, lowPage, highPage int) string {
+	source := first.ArchivePath
+	if source == "" {
+		source = filepath.Dir(first.Path)
+	}
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	return fmt.Sprintf("%s_p%03d-%03d.png", base, lowPage, highPage)
+}
+
+// exportSpread writes the two pages on screen as one PNG in screenshot_dir,
+// in screen order so right-to-left spreads read as printed. The pages are
+// decoded again at full size on a background goroutine.
+func (g *Game) exportSpread() {
+	if g.displayContent == nil || g.displayContent.Metadata.ActualImages != 2 {
+		g.showOverlayMessage("Not showing a spread")
+		return
+	}
+	meta := g.displayContent.Metadata
+	left, okLeft := g.imageManager.GetPath(meta.LeftPage - 1)
+	right, okRight := g.imageManager.GetPath(meta.RightPage - 1)
+	if !okLeft || !okRight {
+		return
+	}
+	if g.screenshotResults == nil {
+		g.screenshotResults = make(chan screenshotResult, 4)
+	}
+
+	first := left
+	if meta.RightPage < meta.LeftPage {
+		first = right
+	}
+	path := filepath.Join(screenshotDir(g.config.ScreenshotDir),
+		spreadFileName(first, min(meta.LeftPage, meta.RightPage), max(meta.LeftPage, meta.RightPage)))
+	bg := g.GetBackgroundColor()
+	results := g.screenshotResults
+	debugKV("screenshot", "spread_export_begin", "path", path, "left", left.Path, "right", right.Path)
+	go func() {
+		defer recoverWorker("spread_export", path, func(err error) { results <- screenshotResult{What: "Spread", Path: path, Err: err} })
+		pages := make([]image.Image, 2)
+		var decodeErr error
+		err := forEachDecodedImage([]ImagePath{left, right}, func(i int, img image.Image, err error) {
+			pages[i] = img
+			if err != nil && decodeErr == nil {
+				decodeErr = err
+			}
+		})
+		if err == nil {
+			err = decodeErr
+		}
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(path), 0o755)
+		}
+		if err == nil {
+			err = writeImageFile(path, stitchSpread(pages[0], pages[1], bg))
+		}
+		results <- screenshotResult{What: "Spread", Path: path, Err: err}
+	}()
+}
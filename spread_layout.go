@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"image"
+	"io"
+	"os"
+
+	"nv/navlogic"
+)
+
+// spreadLayoutKey identifies the page list a spread layout was planned for.
+type spreadLayoutKey struct {
+	count       int
+	first, last string
+}
+
+type spreadLayoutResult struct {
+	key    spreadLayoutKey
+	layout *navlogic.Layout
+}
+
+// currentSpreadLayoutKey returns the key of the current page list.
+func (g *Game) currentSpreadLayoutKey() spreadLayoutKey {
+	key := spreadLayoutKey{count: g.imageManager.GetPathsCount()}
+	if first, ok := g.imageManager.GetPath(0); ok {
+		key.first = first.Path
+	}
+	if last, ok := g.imageManager.GetPath(key.count - 1); ok {
+		key.last = last.Path
+	}
+	return key
+}
+
+// updateSpreadLayout keeps the book mode pairing planned for the current
+// page list when plan_spreads is on. Page sizes are read from the image
+// headers off the Ebiten thread; until they arrive, pages are paired at
+// each step as before. It reports whether the display changed.
+func (g *Game) updateSpreadLayout() bool {
+	if !g.config.PlanSpreads || g.imageManager == nil {
+		return g.dropSpreadLayout()
+	}
+	if !g.bookMode && !g.tempSingleMode && g.spreadLayout == nil && g.spreadLayoutCancel == nil {
+		return false // Nothing to plan until book mode is used
+	}
+
+	key := g.currentSpreadLayoutKey()
+	if key != g.spreadLayoutKey {
+		g.dropSpreadLayout()
+		g.spreadLayoutKey = key
+		g.startSpreadLayoutPlan(key)
+		return false
+	}
+
+	select {
+	case res := <-g.spreadLayoutResults:
+		if res.key != g.spreadLayoutKey {
+			return false
+		}
+		g.spreadLayoutCancel = nil
+		g.spreadLayout = res.layout
+		g.spreadLayoutLearned = -1 // Re-pair with the current threshold and ratios
+	default:
+	}
+	if g.spreadLayout == nil {
+		return false
+	}
+
+	if g.spreadLayoutLearned == len(g.learnedSpreadAspects) &&
+		g.spreadLayoutThreshold == g.config.AspectRatioThreshold {
+		return false
+	}
+	g.spreadLayout = g.spreadLayout.Replan(g.config.AspectRatioThreshold, g.learnedSpreadAspects, g.pagePairings)
+	g.spreadLayoutLearned = len(g.learnedSpreadAspects)
+	g.spreadLayoutThreshold = g.config.AspectRatioThreshold
+	g.calculateDisplayContent()
+	debugKV("nav", "spread_layout_planned", "pages", g.spreadLayout.PageCount(), "learned_count", g.spreadLayoutLearned)
+	return true
+}
+
+// dropSpreadLayout forgets the layout and stops a running plan. It reports
+// whether a layout was in use.
+func (g *Game) dropSpreadLayout() bool {
+	if g.spreadLayoutCancel != nil {
+		g.spreadLayoutCancel()
+		g.spreadLayoutCancel = nil
+	}
+	g.spreadLayoutKey = spreadLayoutKey{}
+	if g.spreadLayout == nil {
+		return false
+	}
+	g.spreadLayout = nil
+	g.calculateDisplayContent()
+	return true
+}
+
+func (g *Game) startSpreadLayoutPlan(key spreadLayoutKey) {
+	if g.spreadLayoutResults == nil {
+		g.spreadLayoutResults = make(chan spreadLayoutResult, 1)
+	}
+	paths := make([]ImagePath, key.count)
+	for i := range paths {
+		paths[i], _ = g.imageManager.GetPath(i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	g.spreadLayoutCancel = cancel
+	threshold := g.config.AspectRatioThreshold
+	results := g.spreadLayoutResults
+
+	go func() {
+		defer recoverWorker("spread_layout", "", nil)
+		metrics := readPageMetrics(ctx, paths)
+		if ctx.Err() != nil {
+			return
+		}
+		layout := navlogic.PlanLayout(metrics, threshold, nil)
+		// Replace a result nobody picked up, which is for an older list
+		select {
+		case <-results:
+		default:
+		}
+		results <- spreadLayoutResult{key: key, layout: layout}
+	}()
+}
+
+// recordSpread adds the spread about to be shown to the spread index,
+// starting a new index when the page list changed.
+func (g *Game) recordSpread(state navlogic.State, plan navlogic.DisplayPlan) {
+	if !state.BookMode && !state.TempSingleMode {
+		return
+	}
+	if key := g.currentSpreadLayoutKey(); g.spreadIndex == nil || key != g.spreadIndexKey {
+		g.spreadIndex = navlogic.NewSpreadIndex()
+		g.spreadIndexKey = key
+	}
+	g.spreadIndex.Record(plan)
+}
+
+// readPageMetrics reads the size of each page from its image header,
+// walking each archive once. Pages it cannot read get zero metrics.
+func readPageMetrics(ctx context.Context, paths []ImagePath) []navlogic.PageMetrics {
+	metrics := make([]navlogic.PageMetrics, len(paths))
+	entries := map[string]map[string]int{} // archive -> entry -> page
+	for i, p := range paths {
+		if p.ArchivePath != "" {
+			if entries[p.ArchivePath] == nil {
+				entries[p.ArchivePath] = map[string]int{}
+			}
+			entries[p.ArchivePath][p.EntryPath] = i
+			continue
+		}
+		if ctx.Err() != nil {
+			return metrics
+		}
+		if f, err := os.Open(p.Path); err == nil {
+			metrics[i] = decodePageMetrics(f)
+			f.Close()
+		}
+	}
+
+	for archivePath, pages := range entries {
+		walkArchiveImages(archivePath, func(name string, r io.Reader) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if i, ok := pages[name]; ok {
+				metrics[i] = decodePageMetrics(r)
+			}
+			return nil
+		})
+	}
+	return metrics
+}
+
+func decodePageMetrics(r io.Reader) navlogic.PageMetrics {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return navlogic.PageMetrics{}
+	}
+	return navlogic.PageMetrics{Width: cfg.Width, Height: cfg.Height}
+}
@@ -8,8 +8,10 @@ import (
 	"image"
 	"image/png"
 	"os"
+	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"nv/internal/imgdecode"
 	"nv/navlogic"
 )
 
@@ -19,8 +21,12 @@ var (
 	buildDate = "unknown"
 )
 
-// Global debug mode flag
-var debugMode bool
+// Global debug mode flag. Like contentSniffing it is read from loader
+// goroutines while the config can change it, hence atomic.
+var debugMode atomic.Bool
+
+// debugFlag records -d, which keeps debug mode on whatever log_level says
+var debugFlag atomic.Bool
 
 //go:embed icon/icon_16.png
 var icon16 []byte
@@ -32,9 +38,20 @@ var icon32 []byte
 var icon48 []byte
 
 type startupOptions struct {
-	configPath string
-	logPath    string
-	args       []string
+	configPath    string
+	logPath       string
+	serveAddr     string
+	kiosk         bool
+	quicklook     bool
+	printSelected bool
+	markedFile    string
+	args          []string
+
+	// Headless contact sheet export; zero layout values use the config
+	contactSheet  string
+	sheetColumns  int
+	sheetCellSize int
+	sheetLabels   *bool
 }
 
 func parseStartupOptions() startupOptions {
@@ -42,6 +59,19 @@ func parseStartupOptions() startupOptions {
 	debug := flag.Bool("d", false, "enable debug logging")
 	logFile := flag.String("log-file", "", "append logs to file as well as console")
 	showVersion := flag.Bool("version", false, "show version information")
+	selfUpdate := flag.Bool("self-update", false, "download the latest release, replace this binary and exit")
+	register := flag.Bool("register", false, "associate images and comic archives with nv for this user and exit")
+	unregister := flag.Bool("unregister", false, "remove the file associations made by --register and exit")
+	noSave := flag.Bool("no-save", false, "never write the config file or the state directory")
+	serve := flag.String("serve", "", "serve a remote viewer on this address, e.g. :8080 (this computer only) or 0.0.0.0:8080")
+	kiosk := flag.Bool("kiosk", false, "locked fullscreen mode for unattended displays (unlock: Ctrl+Alt+U)")
+	quicklook := flag.Bool("quicklook", false, "borderless previewer near the cursor (centered outside Windows); Escape or focus loss closes it, nothing is saved")
+	printSelected := flag.Bool("print-selected", false, "print the path accepted with the accept key (Alt+Enter) and exit 0; quitting otherwise exits 1")
+	markedFile := flag.String("marked-file", "", "file the marked paths are written to on accept_marked and on every other exit (default: stdout on accept_marked only)")
+	contactSheet := flag.String("contact-sheet", "", "write a contact sheet of the images to this PNG/JPEG file and exit")
+	sheetColumns := flag.Int("sheet-columns", 0, "contact sheet columns (default: config)")
+	sheetCellSize := flag.Int("sheet-cell-size", 0, "contact sheet thumbnail cell size in pixels (default: config)")
+	sheetLabels := flag.Bool("sheet-labels", true, "label contact sheet thumbnails with file names (default: config)")
 	flag.Parse()
 
 	if *showVersion {
@@ -49,12 +79,49 @@ func parseStartupOptions() startupOptions {
 		os.Exit(0)
 	}
 
-	debugMode = *debug
-	return startupOptions{
-		configPath: *configFile,
-		logPath:    *logFile,
-		args:       flag.Args(),
+	debugMode.Store(*debug)
+	if *selfUpdate {
+		os.Exit(runSelfUpdate())
+	}
+	if *register || *unregister {
+		os.Exit(runFileAssociation(*register))
+	}
+	debugFlag.Store(*debug)
+	readOnly = *noSave || *quicklook
+	opts := startupOptions{
+		configPath:    *configFile,
+		logPath:       *logFile,
+		serveAddr:     *serve,
+		kiosk:         *kiosk,
+		quicklook:     *quicklook,
+		printSelected: *printSelected,
+		markedFile:    *markedFile,
+		args:          flag.Args(),
+		contactSheet:  *contactSheet,
+		sheetColumns:  *sheetColumns,
+		sheetCellSize: *sheetCellSize,
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "sheet-labels" {
+			opts.sheetLabels = sheetLabels
+		}
+	})
+	return opts
+}
+
+// contactSheetOptions merges the contact sheet flags over the config.
+func (o startupOptions) contactSheetOptions(config Config) contactSheetOptions {
+	sheet := contactSheetOptionsFromConfig(config)
+	if o.sheetColumns > 0 {
+		sheet.Columns = min(32, o.sheetColumns)
+	}
+	if o.sheetCellSize > 0 {
+		sheet.CellSize = max(32, min(1024, o.sheetCellSize))
+	}
+	if o.sheetLabels != nil {
+		sheet.Labels = *o.sheetLabels
 	}
+	return sheet
 }
 
 func loadStartupConfig(configPath string) ConfigLoadResult {
@@ -66,6 +133,7 @@ func loadStartupConfig(configPath string) ConfigLoadResult {
 
 func newGameFromStartup(configResult ConfigLoadResult, configPath string, args []string, paths []ImagePath) *Game {
 	config := configResult.Config
+	workerConfigPath.Store(&configPath)
 	debugKV("startup", "game_create_begin",
 		"args_count", len(args),
 		"paths_count", len(paths),
@@ -79,6 +147,11 @@ func newGameFromStartup(configResult ConfigLoadResult, configPath string, args [
 	imageManager := NewImageManagerWithPreload(config.CacheSize, config.PreloadCount, config.PreloadEnabled)
 	if dm, ok := imageManager.(*DefaultImageManager); ok {
 		dm.SetMaxImageDimension(config.MaxImageDimension)
+		textureUploads.setPerFrame(int64(config.UploadBudgetMB) << 20)
+		dm.SetGPUMemoryCap(int64(config.GPUMemoryCapMB) << 20)
+		dm.SetToneMapping(imgdecode.ToneMapping{Operator: config.ToneMapOperator, Exposure: config.HDRExposure})
+		dm.SetLevels(imgdecode.Levels{Exposure: config.HDRExposure})
+		dm.SetArchivePrefetch(config.ArchivePrefetch, config.ArchivePrefetchMaxMB)
 	}
 	imageManager.SetPaths(paths)
 
@@ -93,9 +166,20 @@ func newGameFromStartup(configResult ConfigLoadResult, configPath string, args [
 		collectionSource: newArgsCollectionSource(args),
 		configStatus:     configResult,
 		zoomState:        NewZoomState(),
+		ratings:          loadRatingStore(ratingsPathForConfig(configPath)),
+		progress:         newProgressStoreForConfig(config, configPath),
+		directions:       newDirectionStoreForConfig(config, configPath),
+		pairings:         loadPairingStore(statePathForConfig(configPath, pairingsFileName)),
+		hdrExposure:      config.HDRExposure,
+		sharpen:          config.DisplaySharpen,
+		denoise:          config.DisplayDenoise,
 	}
 
 	g.resetZoomToInitial()
+	if dm, ok := imageManager.(*DefaultImageManager); ok && config.PreloadEnabled {
+		// The current page plus what the preloader would fetch next
+		dm.WarmUp(0, config.PreloadCount+1)
+	}
 	imageManager.StartPreload(0, NavigationForward)
 
 	keybindingManager := NewKeybindingManager(config.Keybindings)
@@ -109,10 +193,29 @@ func newGameFromStartup(configResult ConfigLoadResult, configPath string, args [
 	applyStartupConfigWarning(g, configResult)
 	initializeSingleFileMode(g, args)
 	initializeBookModeForLaunch(g, paths)
+	g.rememberRecentFiles(args)
 	g.calculateDisplayContent()
+	g.loadScripts()
+	g.initCustomShader()
 	return g
 }
 
+// collectStartupImages collects the launch paths. A failed or empty collection
+// is returned as a load failure so the start screen can show it instead of
+// exiting.
+func collectStartupImages(args []string, sortMethod int) ([]ImagePath, *CollectionLoadFailure) {
+	paths, err := collectImages(args, sortMethod)
+	if err != nil {
+		warnKV("startup", "collect_images_failed", "args", args, "error", err)
+		return nil, &CollectionLoadFailure{Args: args, Reason: fmt.Sprintf("Open failed: %v", err)}
+	}
+	if len(paths) == 0 && len(args) > 0 {
+		warnKV("startup", "no_images", "args_count", len(args))
+		return nil, &CollectionLoadFailure{Args: args, Reason: "No images found"}
+	}
+	return paths, nil
+}
+
 func applyStartupConfigWarning(g *Game, configResult ConfigLoadResult) {
 	if configResult.Status != "Warning" && configResult.Status != "Error" {
 		return
@@ -127,7 +230,7 @@ func applyStartupConfigWarning(g *Game, configResult ConfigLoadResult) {
 }
 
 func initializeSingleFileMode(g *Game, args []string) {
-	if len(args) == 1 && isSupportedExt(args[0]) && !isArchiveExt(args[0]) {
+	if len(args) == 1 && !isArchiveExt(args[0]) && isImageFile(args[0]) {
 		g.launchSingleFile = args[0]
 		debugKV("startup", "single_file_mode_enabled", "path", args[0])
 	}
@@ -160,20 +263,36 @@ func initializeBookModeForLaunch(g *Game, paths []ImagePath) {
 
 func configureWindow(g *Game) {
 	ebiten.SetWindowTitle(getWindowTitle())
-	ebiten.SetWindowSize(g.config.WindowWidth, g.config.WindowHeight)
+	if g.quicklook {
+		configureQuicklookWindow(g)
+	} else {
+		ebiten.SetWindowSize(g.config.WindowWidth, g.config.WindowHeight)
+		restoreWindowPosition(g.config)
+	}
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	if g.config.Maximized && !g.quicklook {
+		ebiten.MaximizeWindow()
+	}
 	ebiten.SetScreenClearedEveryFrame(false)
 	setWindowIcon()
 
-	if g.config.Fullscreen {
+	if (g.config.Fullscreen && !g.quicklook) || g.kiosk {
+		g.fullscreen = true
 		g.savedWinW, g.savedWinH = g.config.WindowWidth, g.config.WindowHeight
+		g.windowedMonitor = ebiten.Monitor()
+		moveToMonitor(g.fullscreenMonitor())
 		ebiten.SetFullscreen(true)
 	}
+	if g.kiosk {
+		ebiten.SetWindowClosingHandled(true)
+		g.closeHandled = true
+	}
 
 	debugKV("startup", "window_configured",
 		"width", g.config.WindowWidth,
 		"height", g.config.WindowHeight,
 		"fullscreen", g.config.Fullscreen,
+		"maximized", g.config.Maximized,
 	)
 }
 
@@ -186,6 +305,10 @@ func getWindowTitle() string {
 }
 
 func main() {
+	if code, ok := runSubcommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	opts := parseStartupOptions()
 	logFile, err := configureLogOutput(opts.logPath)
 	if err != nil {
@@ -197,47 +320,110 @@ func main() {
 	}
 
 	configResult := loadStartupConfig(opts.configPath)
-	instanceBridge := newSingleInstanceBridge(configResult.Config.SortMethod)
-	instanceManager, err := newSingleInstanceManager(opts.configPath)
-	if err != nil {
-		fatalKV("single_instance", "init_failed", "config_path", opts.configPath, "error", err)
+	setLogLevel(configResult.Config.LogLevel)
+	if configResult.Config.ReadOnly {
+		readOnly = true
 	}
-	isPrimary, err := instanceManager.AcquireOrForward(opts.args, instanceBridge)
-	if err != nil {
-		fatalKV("single_instance", "acquire_failed", "config_path", opts.configPath, "error", err)
+	if readOnly {
+		infoKV("startup", "read_only", "config_path", opts.configPath)
 	}
-	if !isPrimary {
-		return
+	migrateStateFiles(opts.configPath)
+	if logFile == nil && configResult.Config.LogToFile && !readOnly {
+		logPath := logPathForConfig(opts.configPath)
+		logFile, err = configureLogOutput(logPath)
+		if err != nil {
+			warnKV("startup", "log_output_configure_failed", "path", logPath, "error", err)
+		} else {
+			defer logFile.Close()
+			infoKV("startup", "log_file_enabled", "path", logPath)
+		}
+	}
+	if opts.contactSheet != "" {
+		code := runContactSheetCLI(opts.contactSheet, opts.args, configResult.Config, opts.contactSheetOptions(configResult.Config))
+		if logFile != nil {
+			logFile.Close()
+		}
+		os.Exit(code)
+	}
+	// Quick look and picking always open their own window instead of reusing
+	// a running one
+	instanceBridge := newSingleInstanceBridge(configResult.Config.SortMethod)
+	if !opts.quicklook && !opts.printSelected && opts.markedFile == "" {
+		instanceManager, err := newSingleInstanceManager(opts.configPath)
+		if err != nil {
+			fatalKV("single_instance", "init_failed", "config_path", opts.configPath, "error", err)
+		}
+		isPrimary, err := instanceManager.AcquireOrForward(opts.args, instanceBridge)
+		if err != nil {
+			fatalKV("single_instance", "acquire_failed", "config_path", opts.configPath, "error", err)
+		}
+		if !isPrimary {
+			return
+		}
+		defer instanceManager.Close()
 	}
-	defer instanceManager.Close()
 
 	debugKV("startup", "options_parsed",
 		"config_path", opts.configPath,
 		"log_path", opts.logPath,
 		"args", opts.args,
-		"debug", debugMode,
+		"debug", debugMode.Load(),
 	)
 
 	if err := InitGraphics(); err != nil {
 		warnKV("startup", "graphics_init_failed", "error", err)
 	}
 
-	paths, err := collectImages(opts.args, configResult.Config.SortMethod)
-	if err != nil {
-		fatalKV("startup", "collect_images_failed", "error", err)
-	}
-	if len(paths) == 0 {
-		fatalKV("startup", "no_images", "args_count", len(opts.args))
+	contentSniffing.Store(configResult.Config.SniffContent)
+	setArchiveIgnore(configResult.Config.ArchiveIgnore)
+	setChapterPattern(configResult.Config.ChapterPattern)
+	launchArgs := opts.args
+	paths, loadFailure := collectStartupImages(opts.args, configResult.Config.SortMethod)
+	if loadFailure != nil {
+		launchArgs = nil
 	}
 	infoKV("startup", "images_collected", "paths_count", len(paths), "sort_method", configResult.Config.SortMethod)
 
-	g := newGameFromStartup(configResult, opts.configPath, opts.args, paths)
+	g := newGameFromStartup(configResult, opts.configPath, launchArgs, paths)
+	g.loadFailure = loadFailure
+	if opts.kiosk {
+		g.startKiosk()
+	}
+	if opts.quicklook {
+		g.startQuicklook()
+	}
+	g.printSelected = opts.printSelected
+	g.markedFile = opts.markedFile
 	initSingleInstanceBridge(instanceBridge, g)
 	configureWindow(g)
+	if !g.quicklook {
+		g.startMediaControls()
+		g.startUpdateCheck()
+	}
+	g.startIdleWakeups()
+	if opts.serveAddr != "" {
+		if err := g.startRemoteServer(opts.serveAddr); err != nil {
+			fatalKV("remote", "listen_failed", "addr", opts.serveAddr, "error", err)
+		}
+	}
 
+	defer g.recoverCrash()
 	if err := ebiten.RunGame(g); err != nil && err != ebiten.Termination {
 		fatalKV("startup", "run_game_failed", "error", err)
 	}
+	g.writeMarksOnExit(os.Stdout)
+	if g.acceptedMarks != nil || opts.printSelected {
+		var code int
+		if g.acceptedMarks != nil {
+			code = writeMarkedPaths(os.Stdout, opts.markedFile, g.acceptedMarks)
+		} else {
+			code = printSelection(os.Stdout, g.selectedPath)
+		}
+		if logFile != nil {
+			logFile.Close()
+		}
+		os.Exit(code)
+	}
 }
 
 func setWindowIcon() {
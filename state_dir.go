@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// historyFileName holds the recent files list, kept out of config.json so
+// the config only changes when settings do
+const historyFileName = "history.json"
+
+// stateDirForConfig returns the directory for data nv writes as it runs:
+// history, ratings, reading progress, logs and crash reports. With the
+// default config that is $XDG_STATE_HOME/nekomimist/nv (%LOCALAPPDATA% on
+// Windows, ~/.local/state elsewhere). A config given with -c keeps its data
+// next to it, so a portable or per-profile setup stays self-contained.
+func stateDirForConfig(configPath string) string {
+	if configPath != "" && configPath != getConfigPath() {
+		return filepath.Dir(configPath)
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "nekomimist", "nv")
+	}
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		return filepath.Join(dir, "nekomimist", "nv")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Dir(getConfigPath())
+	}
+	return filepath.Join(home, ".local", "state", "nekomimist", "nv")
+}
+
+// readOnly is set by --no-save or read_only: nothing is written to the
+// config file or the state directory. Settings, ratings and progress still
+// change for the session.
+var readOnly bool
+
+// migratedStateFiles are the state files older versions kept next to the
+// config file.
+var migratedStateFiles = []string{ratingsFileName, progressFileName}
+
+// statePathForConfig returns the path of the state file name. A file older
+// versions kept next to the config is used where it is until
+// migrateStateFiles has moved it.
+func statePathForConfig(configPath, name string) string {
+	path := filepath.Join(stateDirForConfig(configPath), name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if legacy := legacyStatePath(configPath, name); legacy != path {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return path
+}
+
+func legacyStatePath(configPath, name string) string {
+	if configPath == "" {
+		configPath = getConfigPath()
+	}
+	return filepath.Join(filepath.Dir(configPath), name)
+}
+
+// migrateStateFiles moves state files older versions kept next to the
+// config file to the state directory. Read-only mode leaves them in place.
+func migrateStateFiles(configPath string) {
+	if readOnly {
+		return
+	}
+	dir := stateDirForConfig(configPath)
+	for _, name := range migratedStateFiles {
+		if legacy, path := legacyStatePath(configPath, name), filepath.Join(dir, name); legacy != path {
+			migrateStateFile(legacy, path)
+		}
+	}
+}
+
+// migrateStateFile moves legacy to path unless path already exists. Renames
+// across file systems fall back to copying.
+func migrateStateFile(legacy, path string) {
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		warnKV("config", "state_migrate_failed", "from", legacy, "to", path, "error", err)
+		return
+	}
+	err := os.Rename(legacy, path)
+	if err != nil {
+		if err = copyFile(legacy, path); err == nil {
+			os.Remove(legacy)
+		}
+	}
+	if err != nil {
+		warnKV("config", "state_migrate_failed", "from", legacy, "to", path, "error", err)
+		return
+	}
+	infoKV("config", "state_migrated", "from", legacy, "to", path)
+}
+
+type historyFile struct {
+	RecentFiles []string `json:"recent_files"`
+}
+
+// loadRecentFiles reads the recent files list; ok is false when there is no
+// history file yet.
+func loadRecentFiles(path string) ([]string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			warnKV("config", "history_load_failed", "path", path, "error", err)
+		}
+		return nil, false
+	}
+	var h historyFile
+	if err := json.Unmarshal(data, &h); err != nil {
+		warnKV("config", "history_load_failed", "path", path, "error", err)
+		return nil, false
+	}
+	return normalizeRecentFiles(h.RecentFiles), true
+}
+
+func saveRecentFiles(path string, files []string) {
+	if readOnly {
+		return
+	}
+	data, err := json.MarshalIndent(historyFile{RecentFiles: normalizeRecentFiles(files)}, "", "  ")
+	if err != nil {
+		errorKV("config", "history_marshal_failed", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		errorKV("config", "history_save_failed", "path", path, "error", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		errorKV("config", "history_save_failed", "path", path, "error", err)
+	}
+}
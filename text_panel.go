@@ -0,0 +1,41 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// textPanel is a line of text on a background box, rendered once into an
+// offscreen image and reused while the text and font size stay the same.
+type textPanel struct {
+	text  string
+	size  float64
+	image *ebiten.Image
+}
+
+// render returns s in the help font at size on a bg box padded by padding,
+// rendering it only when s or size changed.
+func (p *textPanel) render(r *Renderer, s string, size, padding float64, bg color.RGBA) *ebiten.Image {
+	if p.image != nil && p.text == s && p.size == size {
+		return p.image
+	}
+	p.release()
+
+	face := r.face(size)
+	w, h := measureText(s, face)
+	img := ebiten.NewImage(int(math.Ceil(w+padding*2)), int(math.Ceil(h+padding*2)))
+	img.Fill(bg)
+	DrawText(img, s, face, padding, padding, colorWhite)
+	p.text, p.size, p.image = s, size, img
+	return img
+}
+
+// release frees the rendered image.
+func (p *textPanel) release() {
+	if p.image != nil {
+		p.image.Deallocate()
+	}
+	*p = textPanel{}
+}
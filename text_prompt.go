@@ -0,0 +1,149 @@
+package main
+
+// TextPromptKind identifies which free-text prompt is currently open.
+type TextPromptKind int
+
+const (
+	TextPromptNone TextPromptKind = iota
+	TextPromptRename
+	TextPromptTag
+	TextPromptFilter
+	TextPromptNameFilter
+	TextPromptSearch
+	TextPromptExportConfig
+	TextPromptImportConfig
+	TextPromptExportPages
+	TextPromptChapters
+)
+
+// Label returns the prompt caption shown before the input buffer.
+func (k TextPromptKind) Label() string {
+	switch k {
+	case TextPromptRename:
+		return "Rename"
+	case TextPromptTag:
+		return "Tag"
+	case TextPromptFilter:
+		return "Filter"
+	case TextPromptNameFilter:
+		return "Find"
+	case TextPromptSearch:
+		return "Search"
+	case TextPromptExportConfig:
+		return "Export config"
+	case TextPromptImportConfig:
+		return "Import config"
+	case TextPromptExportPages:
+		return "Export pages to"
+	case TextPromptChapters:
+		return "Chapters"
+	default:
+		return ""
+	}
+}
+
+// Hint returns the key help line shown under the input buffer.
+func (k TextPromptKind) Hint() string {
+	switch k {
+	case TextPromptRename:
+		return "Enter: rename  Esc: cancel"
+	case TextPromptTag:
+		return "name: toggle tag  -name: remove  Enter: apply  Esc: cancel"
+	case TextPromptFilter:
+		return ">=4, tag:keep (empty clears)  Enter: apply  Esc: cancel"
+	case TextPromptNameFilter:
+		return "substring or regex  Enter: keep filter  Esc: show all"
+	case TextPromptSearch:
+		return "Up/Down: select  Enter: jump  Esc: cancel"
+	case TextPromptExportConfig:
+		return "file name (relative to the config folder)  Enter: export  Esc: cancel"
+	case TextPromptImportConfig:
+		return "file name (relative to the config folder)  Enter: import  Esc: cancel"
+	case TextPromptExportPages:
+		return "folder, after a page range like 12-15 for only those  Enter: export  Esc: cancel"
+	case TextPromptChapters:
+		return "type to filter  Up/Down: select  Enter: jump  Esc: cancel"
+	default:
+		return ""
+	}
+}
+
+func (g *Game) openTextPrompt(kind TextPromptKind, initial string) {
+	g.textPrompt = kind
+	g.textPromptBuffer = initial
+}
+
+func (g *Game) closeTextPrompt() {
+	g.textPrompt = TextPromptNone
+	g.textPromptBuffer = ""
+	g.textPromptStatus = ""
+	g.nameFilterBase = nil
+	g.searchResults = nil
+	g.searchSelection = 0
+	g.searchBase = nil
+}
+
+// updateTextPromptBuffer stores the edited buffer; prompts with live
+// feedback react to every change.
+func (g *Game) updateTextPromptBuffer(buffer string) {
+	g.textPromptBuffer = buffer
+	switch g.textPrompt {
+	case TextPromptNameFilter:
+		g.updateNameFilter(buffer)
+	case TextPromptSearch:
+		g.updateSearch(buffer)
+	case TextPromptChapters:
+		g.updateChapterList(buffer)
+	}
+}
+
+// moveTextPromptSelection moves the highlighted result of prompts that list
+// choices; other prompts ignore it.
+func (g *Game) moveTextPromptSelection(delta int) {
+	if g.textPrompt == TextPromptSearch || g.textPrompt == TextPromptChapters {
+		g.moveSearchSelection(delta)
+	}
+}
+
+// cancelTextPrompt closes the prompt without applying it.
+func (g *Game) cancelTextPrompt() {
+	if g.textPrompt == TextPromptNameFilter {
+		g.cancelNameFilter()
+	}
+	g.closeTextPrompt()
+}
+
+// submitTextPrompt closes the prompt and hands its buffer to the handler for
+// the prompt kind.
+func (g *Game) submitTextPrompt() {
+	kind, input := g.textPrompt, g.textPromptBuffer
+	if kind == TextPromptNameFilter {
+		g.commitNameFilter(input)
+	}
+	searchIdx, searchOK := g.selectedSearchIndex()
+	searchPath, searchPathOK := g.selectedSearchPath()
+	g.closeTextPrompt()
+
+	switch kind {
+	case TextPromptRename:
+		g.processRename(input)
+	case TextPromptTag:
+		g.processTagInput(input)
+	case TextPromptFilter:
+		g.processFilterInput(input)
+	case TextPromptExportConfig:
+		g.processConfigExport(input)
+	case TextPromptImportConfig:
+		g.processConfigImport(input)
+	case TextPromptExportPages:
+		g.processPageExport(input)
+	case TextPromptSearch:
+		if searchPathOK {
+			g.jumpToSearchResult(searchPath)
+		}
+	case TextPromptChapters:
+		if searchOK {
+			g.jumpToPage(searchIdx + 1)
+		}
+	}
+}
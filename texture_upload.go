@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"image"
+	"sync"
+	"time"
+)
+
+const (
+	// Default bytes of texture data preloads may upload per frame; about
+	// one large scanned page
+	defaultUploadBudgetMB = 24
+
+	// Longest wait for the next frame, in case frames stop (a hidden window
+	// or no game loop at all) so preloads are slowed but never stuck
+	maxUploadWait = 250 * time.Millisecond
+)
+
+// textureUploads paces the texture uploads of preloaded images. Ebiten
+// flushes the pixels of every new image at the start of the next frame, so
+// a burst of preloads finishing together shows up as one long frame.
+var textureUploads = newUploadBudget(defaultUploadBudgetMB << 20)
+
+// uploadBudget hands out a number of texture bytes per frame. Preloads wait
+// for room in the budget; loads the user is waiting for are only charged,
+// so they never wait but still push back the preloads behind them.
+type uploadBudget struct {
+	mu        sync.Mutex
+	perFrame  int64 // 0 disables pacing
+	available int64
+	frame     chan struct{} // Closed when the next frame starts
+	running   bool          // Frames have started; nothing is paced before
+}
+
+func newUploadBudget(perFrame int64) *uploadBudget {
+	return &uploadBudget{perFrame: perFrame, available: perFrame, frame: make(chan struct{})}
+}
+
+// setPerFrame changes the budget; 0 or less disables pacing.
+func (b *uploadBudget) setPerFrame(perFrame int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.perFrame = max(0, perFrame)
+	b.available = b.perFrame
+	close(b.frame)
+	b.frame = make(chan struct{})
+}
+
+// nextFrame refills the budget and wakes waiting uploads. It runs once per
+// Update on the main thread.
+func (b *uploadBudget) nextFrame() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.running = true
+	if b.perFrame == 0 {
+		return
+	}
+	b.available = b.perFrame
+	close(b.frame)
+	b.frame = make(chan struct{})
+}
+
+// acquire waits until size bytes fit into the current frame's budget and
+// reserves them. An upload larger than the whole budget gets a frame to
+// itself, and uploads before the first frame never wait. It returns false
+// when ctx is done first.
+func (b *uploadBudget) acquire(ctx context.Context, size int64) bool {
+	deadline := time.NewTimer(maxUploadWait)
+	defer deadline.Stop()
+	for {
+		b.mu.Lock()
+		if !b.running || b.perFrame == 0 || b.available >= size || b.available == b.perFrame {
+			b.available -= size
+			b.mu.Unlock()
+			return true
+		}
+		frame := b.frame
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-deadline.C:
+			b.charge(size)
+			return true
+		case <-frame:
+		}
+	}
+}
+
+// charge records an upload that does not wait for the budget.
+func (b *uploadBudget) charge(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.perFrame > 0 {
+		b.available -= size
+	}
+}
+
+// textureBytes is the GPU size of an RGBA texture of bounds.
+func textureBytes(bounds image.Rectangle) int64 {
+	return int64(bounds.Dx()) * int64(bounds.Dy()) * 4
+}
+
+// awaitUpload makes room for size bytes of texture data before an upload.
+// While preloads or warm-up loads are running, uploads wait for the budget;
+// a page the user waits for may then be held back by at most a frame.
+// Otherwise uploads are only charged. It reports false when loading is
+// shutting down.
+func (m *DefaultImageManager) awaitUpload(size int64) bool {
+	if m.pacedLoads.Load() == 0 {
+		textureUploads.charge(size)
+		return true
+	}
+	return textureUploads.acquire(m.loadCtx, size)
+}
@@ -0,0 +1,48 @@
+package main
+
+import "image/color"
+
+// defaultBackgroundColor fills the screen around the image.
+const defaultBackgroundColor = "#000000"
+
+// ThemeSettings holds the colors of the screen around the image, which can
+// differ between a window and fullscreen.
+type ThemeSettings struct {
+	Background           string `json:"background"`            // "#RRGGBB" in a window
+	FullscreenBackground string `json:"fullscreen_background"` // "#RRGGBB" in fullscreen
+}
+
+func getDefaultThemeSettings() ThemeSettings {
+	return ThemeSettings{
+		Background:           defaultBackgroundColor,
+		FullscreenBackground: defaultBackgroundColor,
+	}
+}
+
+// validateThemeSettings replaces colors that do not parse with the default
+// and returns an error message for each of them.
+func validateThemeSettings(theme ThemeSettings) (ThemeSettings, []string) {
+	var warnings []string
+	for _, c := range []*string{&theme.Background, &theme.FullscreenBackground} {
+		if _, err := parseHexColor(*c); err != nil {
+			warnKV("config", "theme_color_invalid", "value", *c, "reason", "default_used")
+			warnings = append(warnings, err.Error())
+			*c = defaultBackgroundColor
+		}
+	}
+	return theme, warnings
+}
+
+// GetBackgroundColor returns the color around the image for the current
+// window mode.
+func (g *Game) GetBackgroundColor() color.Color {
+	s := g.config.Theme.Background
+	if g.fullscreen {
+		s = g.config.Theme.FullscreenBackground
+	}
+	c, err := parseHexColor(s)
+	if err != nil {
+		return color.Black
+	}
+	return c
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// maxUndoEntries caps the undo history; the oldest entries are dropped,
+// except deletes.
+const maxUndoEntries = 100
+
+// undoEntry is one step of the undo history: a delete, a rename or a jump.
+// undo and redo run with replayingUndo set, so they do not record again.
+type undoEntry struct {
+	label string // What the step did, e.g. "jump to page 40"
+	undo  func() error
+	redo  func() error
+	// pinned entries are never evicted: undoing a delete is the only way
+	// to get the held file back before it is removed on exit
+	pinned bool
+}
+
+// pushUndo records a step the user took. A new step clears the redo history.
+func (g *Game) pushUndo(label string, undo, redo func() error) {
+	g.recordUndo(undoEntry{label: label, undo: undo, redo: redo})
+}
+
+// pushPinnedUndo records a step that the history cap must not drop.
+func (g *Game) pushPinnedUndo(label string, undo, redo func() error) {
+	g.recordUndo(undoEntry{label: label, undo: undo, redo: redo, pinned: true})
+}
+
+func (g *Game) recordUndo(e undoEntry) {
+	if g.replayingUndo {
+		return
+	}
+	g.undoStack = append(g.undoStack, e)
+	for i := 0; len(g.undoStack) > maxUndoEntries && i < len(g.undoStack); {
+		if g.undoStack[i].pinned {
+			i++
+			continue
+		}
+		g.undoStack = slices.Delete(g.undoStack, i, i+1)
+	}
+	g.redoStack = nil
+	debugKV("undo", "recorded", "label", e.label, "depth", len(g.undoStack))
+}
+
+func (g *Game) undo() {
+	g.replayUndoStep(&g.undoStack, &g.redoStack, "undo")
+}
+
+func (g *Game) redo() {
+	g.replayUndoStep(&g.redoStack, &g.undoStack, "redo")
+}
+
+// replayUndoStep takes the newest step from one history, reverses or
+// repeats it, and moves it to the other. A step that cannot be replayed,
+// e.g. because its file was changed outside nv, stays where it is so it can
+// be retried once the cause is fixed.
+func (g *Game) replayUndoStep(from, to *[]undoEntry, kind string) {
+	if len(*from) == 0 {
+		g.showOverlayMessage(fmt.Sprintf("Nothing to %s", kind))
+		return
+	}
+	e := (*from)[len(*from)-1]
+
+	step := e.undo
+	if kind == "redo" {
+		step = e.redo
+	}
+	g.replayingUndo = true
+	err := step()
+	g.replayingUndo = false
+	if err != nil {
+		warnKV("undo", kind+"_failed", "label", e.label, "error", err)
+		g.showOverlayMessage(fmt.Sprintf("Cannot %s %s: %v", kind, e.label, err))
+		return
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, e)
+	if kind == "redo" {
+		g.showOverlayMessage("Redone: " + e.label)
+	} else {
+		g.showOverlayMessage("Undone: " + e.label)
+	}
+	debugKV("undo", kind, "label", e.label, "undo_depth", len(g.undoStack), "redo_depth", len(g.redoStack))
+}
+
+// pageIndexOf finds the page with path in the current list; undo steps
+// refer to pages by path since indices shift when pages are added or
+// removed.
+func (g *Game) pageIndexOf(path string) (int, error) {
+	idx := findImagePathIndex(g.currentPaths(), path)
+	if idx < 0 {
+		return 0, errors.New("page no longer in the list")
+	}
+	return idx, nil
+}
+
+// recordJump makes a jump from the page at from undoable, so undo goes
+// back to where the reader was before e.g. jump_last.
+func (g *Game) recordJump(from ImagePath, to ImagePath, page int) {
+	g.pushUndo(fmt.Sprintf("jump to page %d", page),
+		func() error { return g.jumpToPath(from.Path) },
+		func() error { return g.jumpToPath(to.Path) },
+	)
+}
+
+// jumpToPageAutomatically jumps without recording the jump for undo or
+// history, for jumps the reader did not ask for.
+func (g *Game) jumpToPageAutomatically(pageNum int) {
+	g.automaticJump = true
+	defer func() { g.automaticJump = false }()
+	g.jumpToPage(pageNum)
+}
+
+func (g *Game) jumpToPath(path string) error {
+	idx, err := g.pageIndexOf(path)
+	if err != nil {
+		return err
+	}
+	g.jumpToPage(idx + 1)
+	return nil
+}
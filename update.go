@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// latestReleaseURL is the GitHub API endpoint describing the newest release.
+// Tests point it at a local server.
+var latestReleaseURL = "https://api.github.com/repos/nekomimist/nv/releases/latest"
+
+const (
+	updateCheckTimeout = 10 * time.Second
+	selfUpdateTimeout  = 5 * time.Minute
+
+	// A real build is tens of megabytes; anything far outside that range
+	// is an error page or a truncated or unrelated download.
+	minUpdateSize   = 1 << 20
+	maxUpdateSize   = 512 << 20
+	maxChecksumSize = 1 << 20
+)
+
+// releaseInfo is the part of a GitHub release the update check reads.
+type releaseInfo struct {
+	TagName string         `json:"tag_name"`
+	HTMLURL string         `json:"html_url"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+// Version returns the release version without its "v" prefix.
+func (r releaseInfo) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+type updateCheckResult struct {
+	Release releaseInfo
+	Err     error
+}
+
+// fetchLatestRelease asks GitHub for the newest release.
+func fetchLatestRelease(ctx context.Context) (releaseInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return releaseInfo{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "nv/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return releaseInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return releaseInfo{}, fmt.Errorf("release query: %s", resp.Status)
+	}
+	var rel releaseInfo
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return releaseInfo{}, err
+	}
+	if rel.TagName == "" {
+		return releaseInfo{}, errors.New("release has no tag")
+	}
+	return rel, nil
+}
+
+// isNewerVersion reports whether latest is newer than current. Versions are
+// build dates (20240102); dev builds never report updates.
+func isNewerVersion(current, latest string) bool {
+	cur, err := strconv.ParseUint(strings.TrimPrefix(current, "v"), 10, 64)
+	if err != nil {
+		return false
+	}
+	next, err := strconv.ParseUint(strings.TrimPrefix(latest, "v"), 10, 64)
+	if err != nil {
+		return false
+	}
+	return next > cur
+}
+
+// releaseAssetFor picks the binary for goos/goarch: an asset named after the
+// platform (nv-linux-amd64, nv_windows_amd64.exe), else the plain names the
+// Makefile builds, nv.exe on Windows and nv elsewhere. Archives, checksums
+// and other files with an extension are skipped. The platform must appear
+// as whole name components, so arm does not pick an arm64 build.
+func releaseAssetFor(rel releaseInfo, goos, goarch string) (releaseAsset, bool) {
+	ext := ""
+	if goos == "windows" {
+		ext = ".exe"
+	}
+	plain := "nv" + ext
+	var fallback releaseAsset
+	found := false
+	for _, a := range rel.Assets {
+		name := strings.ToLower(a.Name)
+		if filepath.Ext(name) != ext {
+			continue
+		}
+		tokens := assetNameTokens(strings.TrimSuffix(name, ext))
+		if slices.Contains(tokens, goos) && slices.Contains(tokens, goarch) && !slices.Contains(tokens, "debug") {
+			return a, true
+		}
+		if name == plain && !found {
+			fallback, found = a, true
+		}
+	}
+	return fallback, found
+}
+
+// assetNameTokens splits an asset name at every character that is neither
+// a letter nor a digit.
+func assetNameTokens(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// checksumAssetsFor lists the assets that may carry the SHA-256 of asset:
+// a sidecar named after it (nv-linux-amd64.sha256), then the combined
+// checksum lists release tools publish.
+func checksumAssetsFor(rel releaseInfo, asset releaseAsset) []releaseAsset {
+	var sidecar, lists []releaseAsset
+	for _, a := range rel.Assets {
+		name := strings.ToLower(a.Name)
+		switch {
+		case name == strings.ToLower(asset.Name)+".sha256":
+			sidecar = append(sidecar, a)
+		case name == "checksums.txt" || name == "sha256sums" || name == "sha256sums.txt" || strings.HasSuffix(name, "_checksums.txt"):
+			lists = append(lists, a)
+		}
+	}
+	return append(sidecar, lists...)
+}
+
+// parseChecksum finds the SHA-256 of name in sha256sum output ("<hex>  name"
+// or "<hex> *name"). A file holding only a digest applies to any name.
+func parseChecksum(data []byte, name string) ([]byte, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || len(fields) > 2 {
+			continue
+		}
+		if len(fields) == 2 && !strings.EqualFold(strings.TrimPrefix(fields[1], "*"), name) {
+			continue
+		}
+		sum, err := hex.DecodeString(fields[0])
+		if err == nil && len(sum) == sha256.Size {
+			return sum, true
+		}
+	}
+	return nil, false
+}
+
+// fetchReleaseChecksum downloads the published SHA-256 of asset. Releases
+// without one cannot be installed; the download would be unverifiable.
+func fetchReleaseChecksum(ctx context.Context, rel releaseInfo, asset releaseAsset) ([]byte, error) {
+	for _, a := range checksumAssetsFor(rel, asset) {
+		body, err := openDownload(ctx, a.DownloadURL)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(body, maxChecksumSize))
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if sum, ok := parseChecksum(data, asset.Name); ok {
+			return sum, nil
+		}
+	}
+	return nil, fmt.Errorf("release publishes no SHA-256 checksum for %s", asset.Name)
+}
+
+// startUpdateCheck looks for a newer release in the background when
+// check_for_updates is on; applyUpdateCheckResult reports it.
+func (g *Game) startUpdateCheck() {
+	if !g.config.CheckForUpdates || version == "dev" {
+		return
+	}
+	results := make(chan updateCheckResult, 1)
+	g.updateResults = results
+	go func() {
+		defer recoverWorker("update_check", "", func(err error) { results <- updateCheckResult{Err: err} })
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		rel, err := fetchLatestRelease(ctx)
+		results <- updateCheckResult{Release: rel, Err: err}
+	}()
+}
+
+// applyUpdateCheckResult shows a message when the update check found a newer
+// release. Failures are only logged; the check is a courtesy.
+func (g *Game) applyUpdateCheckResult() bool {
+	select {
+	case res := <-g.updateResults:
+		g.updateResults = nil
+		if res.Err != nil {
+			warnKV("update", "check_failed", "error", res.Err)
+			return false
+		}
+		if !isNewerVersion(version, res.Release.Version()) {
+			debugKV("update", "up_to_date", "version", version, "latest", res.Release.Version())
+			return false
+		}
+		infoKV("update", "available", "version", version, "latest", res.Release.Version(), "url", res.Release.HTMLURL)
+		g.showOverlayMessage(fmt.Sprintf("nv v%s is available (run nv --self-update)", res.Release.Version()))
+		return true
+	default:
+		return false
+	}
+}
+
+// runSelfUpdate replaces the running binary with the newest release for this
+// platform and returns the exit code.
+func runSelfUpdate() int {
+	ctx, cancel := context.WithTimeout(context.Background(), selfUpdateTimeout)
+	defer cancel()
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		errorKV("update", "executable_not_found", "error", err)
+		return 1
+	}
+	os.Remove(exe + ".old")
+
+	rel, err := fetchLatestRelease(ctx)
+	if err != nil {
+		errorKV("update", "check_failed", "error", err)
+		return 1
+	}
+	if !isNewerVersion(version, rel.Version()) {
+		if version == "dev" {
+			fmt.Printf("nv is a development build; the latest release is v%s\n", rel.Version())
+		} else {
+			fmt.Printf("nv v%s is up to date\n", version)
+		}
+		return 0
+	}
+	asset, ok := releaseAssetFor(rel, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		errorKV("update", "no_asset", "release", rel.TagName, "os", runtime.GOOS, "arch", runtime.GOARCH, "url", rel.HTMLURL)
+		return 1
+	}
+
+	sum, err := fetchReleaseChecksum(ctx, rel, asset)
+	if err != nil {
+		errorKV("update", "checksum_unavailable", "release", rel.TagName, "asset", asset.Name, "error", err)
+		return 1
+	}
+
+	fmt.Printf("Downloading nv v%s (%s)...\n", rel.Version(), asset.Name)
+	if err := replaceExecutable(ctx, exe, asset.DownloadURL, sum); err != nil {
+		errorKV("update", "replace_failed", "path", exe, "url", asset.DownloadURL, "error", err)
+		return 1
+	}
+	infoKV("update", "updated", "from", version, "to", rel.Version(), "path", exe)
+	fmt.Printf("Updated %s to v%s\n", exe, rel.Version())
+	return 0
+}
+
+// openDownload starts a GET of a release asset.
+func openDownload(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nv/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// copyVerifiedExecutable copies an update to dst and fails unless it has a
+// plausible size, starts with the executable header for goos and matches
+// the published SHA-256.
+func copyVerifiedExecutable(dst io.Writer, src io.Reader, sum []byte, goos string) error {
+	h := sha256.New()
+	var head bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(dst, h, &limitedBuffer{buf: &head, max: 4}), io.LimitReader(src, maxUpdateSize+1))
+	if err != nil {
+		return err
+	}
+	if n < minUpdateSize || n > maxUpdateSize {
+		return fmt.Errorf("download is %d bytes, outside the expected size range", n)
+	}
+	if !isExecutableHeader(goos, head.Bytes()) {
+		return fmt.Errorf("download is not a %s executable", goos)
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, sum) {
+		return fmt.Errorf("checksum mismatch: got %x, want %x", got, sum)
+	}
+	return nil
+}
+
+// isExecutableHeader checks the magic number of the binary format goos uses.
+func isExecutableHeader(goos string, head []byte) bool {
+	switch goos {
+	case "windows":
+		return bytes.HasPrefix(head, []byte("MZ"))
+	case "darwin":
+		for _, magic := range [][]byte{{0xcf, 0xfa, 0xed, 0xfe}, {0xce, 0xfa, 0xed, 0xfe}, {0xca, 0xfe, 0xba, 0xbe}} {
+			if bytes.HasPrefix(head, magic) {
+				return true
+			}
+		}
+		return false
+	default:
+		return bytes.HasPrefix(head, []byte("\x7fELF"))
+	}
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// replaceExecutable downloads url next to exe, verifies it against sum and
+// swaps it in. The running binary is renamed to exe.old first, since
+// Windows cannot overwrite a running executable but can rename it; the next
+// update removes it.
+func replaceExecutable(ctx context.Context, exe, url string, sum []byte) error {
+	body, err := openDownload(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".nv-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := copyVerifiedExecutable(tmp, body, sum, runtime.GOOS); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+
+	old := exe + ".old"
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		os.Remove(old)
+	}
+	return nil
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+	"nv/internal/imgdecode"
+)
+
+// Upscale modes: how small pages are enlarged for fullscreen viewing.
+const (
+	upscaleOff     = "off"
+	upscaleBuiltin = "builtin" // Bicubic (Catmull-Rom) plus unsharp mask
+	upscaleCommand = "command" // External tool such as waifu2x or Real-ESRGAN
+)
+
+var upscaleModes = []string{upscaleOff, upscaleBuiltin, upscaleCommand}
+
+const (
+	defaultUpscaleFactor = 2
+
+	// Pages are upscaled once they are drawn this much larger than their size
+	upscaleMinScale = 1.25
+
+	// Upscaled textures kept in memory; pages shown at once are at most two
+	upscaleMemoryEntries = 6
+
+	// Unsharp mask strength for the built-in path
+	upscaleSharpenAmount = 0.6
+
+	// Disk cache size; the least recently used pages are deleted beyond it
+	upscaleCacheMaxBytes = 1 << 30
+)
+
+// textureScaler is implemented by display images whose textures hold more
+// pixels than their Bounds, such as upscaled pages.
+type textureScaler interface {
+	TextureScale() float64
+}
+
+// displayTextureScale returns how many texture pixels make up one pixel of
+// img's Bounds.
+func displayTextureScale(img DisplayImage) float64 {
+	if s, ok := img.(textureScaler); ok {
+		return s.TextureScale()
+	}
+	return 1
+}
+
+// upscaledDisplayImage shows an upscaled texture at the page's original
+// size, so layout, zoom and the ruler are unaffected.
+type upscaledDisplayImage struct {
+	DisplayImage
+	bounds image.Rectangle
+	scale  float64
+}
+
+func (u *upscaledDisplayImage) Bounds() image.Rectangle {
+	return u.bounds
+}
+
+func (u *upscaledDisplayImage) TextureScale() float64 {
+	return u.scale
+}
+
+// upscaleJob enlarges one page off the game loop.
+type upscaleJob struct {
+	Path     ImagePath
+	Mode     string
+	Factor   int
+	Command  string
+	CacheDir string
+}
+
+type upscaleResult struct {
+	Key    string
+	Bounds image.Rectangle // Original page size
+	Image  DisplayImage    // Nil on failure
+	Cached bool            // Read from the disk cache
+	Err    error
+}
+
+// upscaleEntry is a finished page; image is nil when upscaling failed so it
+// is not retried on every frame.
+type upscaleEntry struct {
+	bounds image.Rectangle
+	image  DisplayImage
+}
+
+// upscaler tracks upscaled pages for the game loop.
+type upscaler struct {
+	entries map[string]upscaleEntry
+	order   []string // Least recently used first
+	pending string
+	results chan upscaleResult
+}
+
+func newUpscaler() *upscaler {
+	return &upscaler{entries: make(map[string]upscaleEntry), results: make(chan upscaleResult, 1)}
+}
+
+func (u *upscaler) touch(key string) {
+	if i := slices.Index(u.order, key); i >= 0 {
+		u.order = append(u.order[:i], u.order[i+1:]...)
+	}
+	u.order = append(u.order, key)
+}
+
+// store records a result and evicts the least recently used textures,
+// except those in keep (the pages on screen).
+func (u *upscaler) store(res upscaleResult, keep ...string) {
+	if old, ok := u.entries[res.Key]; ok && old.image != nil {
+		old.image.Deallocate()
+	}
+	u.entries[res.Key] = upscaleEntry{bounds: res.Bounds, image: res.Image}
+	u.touch(res.Key)
+	for i := 0; len(u.order) > upscaleMemoryEntries && i < len(u.order); {
+		key := u.order[i]
+		if slices.Contains(keep, key) {
+			i++
+			continue
+		}
+		if e := u.entries[key]; e.image != nil {
+			e.image.Deallocate()
+		}
+		delete(u.entries, key)
+		u.order = append(u.order[:i], u.order[i+1:]...)
+	}
+}
+
+// upscaleCacheDir is where upscaled pages are kept between sessions.
+func upscaleCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "nv-upscale")
+	}
+	return filepath.Join(dir, "nv", "upscale")
+}
+
+// upscaleCacheKey names the cached result for a page. The source's
+// modification time is part of the key, so edited files are upscaled again.
+func upscaleCacheKey(p ImagePath, modTime int64, mode string, factor int, command string) string {
+	h := sha256.New()
+	for _, part := range []string{p.Path, p.ArchivePath, p.EntryPath, strconv.FormatInt(modTime, 10), mode, strconv.Itoa(factor)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	if mode == upscaleCommand {
+		h.Write([]byte(command))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// upscaleCacheFile names the cache entry for key. The original page size is
+// part of the name, so a hit needs neither the source decoded nor a sidecar.
+func upscaleCacheFile(dir, key string, bounds image.Rectangle) string {
+	return filepath.Join(dir, fmt.Sprintf("%s-%dx%d.png", key, bounds.Dx(), bounds.Dy()))
+}
+
+// readUpscaleCache returns the cached result for key and the original page
+// bounds recorded in its name. A hit refreshes the file's time so pruning
+// drops the least recently used pages first.
+func readUpscaleCache(dir, key string) (image.Image, image.Rectangle, bool) {
+	matches, _ := filepath.Glob(filepath.Join(dir, key+"-*.png"))
+	for _, path := range matches {
+		var w, h int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(filepath.Base(path), key+"-"), "%dx%d.png", &w, &h); err != nil || w <= 0 || h <= 0 {
+			continue
+		}
+		img, err := imgdecode.DecodeFile(path)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		return img, image.Rect(0, 0, w, h), true
+	}
+	return nil, image.Rectangle{}, false
+}
+
+// pruneUpscaleCache deletes the oldest cached pages until the directory holds
+// at most limit bytes.
+func pruneUpscaleCache(dir string, limit int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cacheFile
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".png") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{filepath.Join(dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	if total <= limit {
+		return
+	}
+	slices.SortFunc(files, func(a, b cacheFile) int { return a.modTime.Compare(b.modTime) })
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			warnKV("upscale", "cache_prune_failed", "path", f.path, "error", err)
+			continue
+		}
+		total -= f.size
+	}
+}
+
+// upscaleBicubic enlarges src by factor with Catmull-Rom and sharpens the
+// result to offset the softness interpolation adds.
+func upscaleBicubic(src image.Image, factor int) *image.NRGBA {
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, xdraw.Src, nil)
+	return sharpen(dst, upscaleSharpenAmount)
+}
+
+// sharpen applies an unsharp mask with a 3x3 box blur: out = in + amount *
+// (in - blur). Alpha is left alone.
+func sharpen(src *image.NRGBA, amount float64) *image.NRGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	for y := range h {
+		for x := range w {
+			var sum [3]int
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					sx, sy := x+dx, y+dy
+					if sx < 0 || sy < 0 || sx >= w || sy >= h {
+						continue
+					}
+					i := src.PixOffset(sx, sy)
+					sum[0] += int(src.Pix[i])
+					sum[1] += int(src.Pix[i+1])
+					sum[2] += int(src.Pix[i+2])
+					n++
+				}
+			}
+			i := src.PixOffset(x, y)
+			for c := range 3 {
+				v := float64(src.Pix[i+c])
+				v += amount * (v - float64(sum[c])/float64(n))
+				dst.Pix[i+c] = uint8(max(0, min(255, v+0.5)))
+			}
+		}
+	}
+	return dst
+}
+
+// runUpscaleJob returns the upscaled page and its original bounds, from the
+// disk cache when possible.
+func runUpscaleJob(job upscaleJob) (image.Image, image.Rectangle, bool, error) {
+	statPath := job.Path.Path
+	if job.Path.ArchivePath != "" {
+		statPath = job.Path.ArchivePath
+	}
+	info, err := os.Stat(statPath)
+	if err != nil {
+		return nil, image.Rectangle{}, false, err
+	}
+
+	key := upscaleCacheKey(job.Path, info.ModTime().UnixNano(), job.Mode, job.Factor, job.Command)
+	if cached, bounds, ok := readUpscaleCache(job.CacheDir, key); ok {
+		return cached, bounds, true, nil
+	}
+
+	var src image.Image
+	if err := forEachDecodedImage([]ImagePath{job.Path}, func(_ int, img image.Image, decodeErr error) {
+		src, err = img, decodeErr
+	}); err != nil {
+		return nil, image.Rectangle{}, false, err
+	}
+	if err != nil {
+		return nil, image.Rectangle{}, false, err
+	}
+	bounds := src.Bounds()
+
+	var out image.Image
+	switch job.Mode {
+	case upscaleBuiltin:
+		out = upscaleBicubic(src, job.Factor)
+	case upscaleCommand:
+		out, err = runUpscaleCommand(src, job.Command, job.Factor)
+		if err != nil {
+			return nil, image.Rectangle{}, false, err
+		}
+	default:
+		return nil, image.Rectangle{}, false, fmt.Errorf("unknown upscale mode %q", job.Mode)
+	}
+	if out.Bounds().Dx() < bounds.Dx() || out.Bounds().Dy() < bounds.Dy() {
+		return nil, image.Rectangle{}, false, fmt.Errorf("upscaled image is smaller than the original (%dx%d)", out.Bounds().Dx(), out.Bounds().Dy())
+	}
+
+	if err := os.MkdirAll(job.CacheDir, 0o755); err == nil {
+		cachePath := upscaleCacheFile(job.CacheDir, key, bounds)
+		if err := writeImageFile(cachePath, out); err != nil {
+			warnKV("upscale", "cache_write_failed", "path", cachePath, "error", err)
+		}
+		pruneUpscaleCache(job.CacheDir, upscaleCacheMaxBytes)
+	}
+	return out, bounds, false, nil
+}
+
+// runUpscaleCommand writes src to a temporary PNG and runs command with
+// {input}, {output} and {scale} filled in.
+func runUpscaleCommand(src image.Image, command string, factor int) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "nv-upscale-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	input, output := filepath.Join(dir, "input.png"), filepath.Join(dir, "output.png")
+	if err := writeImageFile(input, src); err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		"input":  input,
+		"output": output,
+		"scale":  strconv.Itoa(factor),
+	}
+	line := expandEventCommand(command, vars, runtime.GOOS)
+	out, err := shellCommand(line, vars).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, firstLine(msg))
+		}
+		return nil, err
+	}
+	img, err := imgdecode.DecodeFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("reading upscaler output: %w", err)
+	}
+	return img, nil
+}
+
+// newUpscaledDisplayImage uploads img to be drawn at bounds' size.
+func newUpscaledDisplayImage(img image.Image, bounds image.Rectangle) (DisplayImage, error) {
+	var tex DisplayImage
+	var err error
+	if b := img.Bounds(); b.Dx() > defaultMaxImageDimension || b.Dy() > defaultMaxImageDimension {
+		tex, err = createTiledDisplayImage(img, defaultTileSize)
+	} else {
+		tex, err = newDisplayImageFromImage(img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upscaledDisplayImage{
+		DisplayImage: tex,
+		bounds:       image.Rect(0, 0, bounds.Dx(), bounds.Dy()),
+		scale:        float64(img.Bounds().Dx()) / float64(bounds.Dx()),
+	}, nil
+}
+
+// upscaleActive reports whether small pages should be replaced by upscaled
+// versions: only in fullscreen, where they are blown up the most.
+func (g *Game) upscaleActive() bool {
+	return g.fullscreen && g.config.Upscale != "" && g.config.Upscale != upscaleOff
+}
+
+// upscaledImageAt returns the upscaled version of src, the page at idx,
+// when one is ready.
+func (g *Game) upscaledImageAt(idx int, src DisplayImage) DisplayImage {
+	if g.upscale == nil || src == nil || !g.upscaleActive() {
+		return nil
+	}
+	p, ok := g.imageManager.GetPath(idx)
+	if !ok {
+		return nil
+	}
+	e, ok := g.upscale.entries[p.Path]
+	// A loading placeholder or error image has different bounds
+	if !ok || e.image == nil || e.bounds.Size() != src.Bounds().Size() {
+		return nil
+	}
+	g.upscale.touch(p.Path)
+	return e.image
+}
+
+// updateUpscale applies finished jobs and starts one for a displayed page
+// that is drawn enlarged and has no upscaled version yet. It reports
+// whether the display changed.
+func (g *Game) updateUpscale() bool {
+	if g.upscale == nil {
+		g.upscale = newUpscaler()
+	}
+	changed := g.applyUpscaleResults()
+	if !g.upscaleActive() || g.upscale.pending != "" || g.displayContent == nil || g.renderer.view.Scale < upscaleMinScale {
+		return changed
+	}
+
+	meta := g.displayContent.Metadata
+	for _, page := range []int{meta.LeftPage, meta.RightPage} {
+		idx := page - 1
+		p, ok := g.imageManager.GetPath(idx)
+		if !ok || page == 0 || g.imageManager.IsLoadFailed(idx) {
+			continue
+		}
+		if _, done := g.upscale.entries[p.Path]; done {
+			continue
+		}
+		if _, animated := g.imageManager.GetImage(idx).(AnimatedImage); animated {
+			continue
+		}
+		g.startUpscale(p)
+		break
+	}
+	return changed
+}
+
+func (g *Game) startUpscale(p ImagePath) {
+	job := upscaleJob{
+		Path:     p,
+		Mode:     g.config.Upscale,
+		Factor:   g.config.UpscaleFactor,
+		Command:  g.config.UpscaleCommand,
+		CacheDir: upscaleCacheDir(),
+	}
+	g.upscale.pending = p.Path
+	results := g.upscale.results
+	debugKV("upscale", "begin", "path", p.Path, "mode", job.Mode, "factor", job.Factor)
+	go func() {
+		res := upscaleResult{Key: p.Path}
+		defer recoverWorker("upscale", p.Path, func(err error) {
+			res.Err = err
+			results <- res
+		})
+		img, bounds, cached, err := runUpscaleJob(job)
+		if err == nil {
+			res.Image, err = newUpscaledDisplayImage(img, bounds)
+		}
+		res.Bounds, res.Cached, res.Err = bounds, cached, err
+		results <- res
+	}()
+}
+
+func (g *Game) applyUpscaleResults() bool {
+	select {
+	case res := <-g.upscale.results:
+		g.upscale.pending = ""
+		var keep []string
+		if g.displayContent != nil {
+			for _, page := range []int{g.displayContent.Metadata.LeftPage, g.displayContent.Metadata.RightPage} {
+				if p, ok := g.imageManager.GetPath(page - 1); ok {
+					keep = append(keep, p.Path)
+				}
+			}
+		}
+		g.upscale.store(res, keep...)
+		if res.Err != nil {
+			g.showOverlayMessage(fmt.Sprintf("Upscale failed: %v", res.Err))
+			warnKV("upscale", "failed", "path", res.Key, "error", res.Err)
+			return true
+		}
+		infoKV("upscale", "ready", "path", res.Key, "cached", res.Cached, "scale", displayTextureScale(res.Image))
+		g.calculateDisplayContent()
+		return true
+	default:
+		return false
+	}
+}
+
+var errUpscaleCommandMissing = errors.New(`upscale is "command" but upscale_command is empty`)
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/maruel/natural"
+)
+
+// What "next" does on the last page of an archive
+const (
+	volumeEndStop  = "stop"  // Stay on the last page
+	volumeEndOffer = "offer" // Show the interstitial; next again opens the next volume
+	volumeEndAuto  = "auto"  // Show the interstitial and open the next volume after a moment
+)
+
+var volumeEndModes = []string{volumeEndStop, volumeEndOffer, volumeEndAuto}
+
+// volumeEndAutoDelay is how long volume_end auto shows the interstitial.
+const volumeEndAutoDelay = 3 * time.Second
+
+// volumeEndState is the "Volume finished" interstitial after the last page
+// of an archive.
+type volumeEndState struct {
+	page  ImagePath    // The last page, on screen behind the interstitial
+	next  string       // Next archive in the folder, empty after the last one
+	stats ReadingStats // Progress through the finished volume
+	pages int          // Pages in the finished volume
+	since time.Time
+}
+
+// nextVolumePath returns the archive after archivePath in its folder, in
+// natural order, or "" when it is the last one.
+func nextVolumePath(archivePath string) string {
+	dir := filepath.Dir(archivePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		warnKV("volume_end", "folder_read_failed", "dir", dir, "error", err)
+		return ""
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && isArchiveExt(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		switch {
+		case natural.Less(a, b):
+			return -1
+		case natural.Less(b, a):
+			return 1
+		}
+		return 0
+	})
+	current := filepath.Base(archivePath)
+	for _, name := range names {
+		if natural.Less(current, name) {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// handleVolumeEnd runs when "next" hits the last page, reporting whether it
+// took over: the first press shows the interstitial, the next one opens the
+// next volume or, after the last volume, dismisses it.
+func (g *Game) handleVolumeEnd(now time.Time) bool {
+	if g.config.VolumeEnd == volumeEndStop || g.config.VolumeEnd == "" {
+		return false
+	}
+	if g.volumeEnd != nil {
+		next := g.volumeEnd.next
+		g.volumeEnd = nil
+		if next == "" {
+			return false
+		}
+		g.openNextVolume(next)
+		return true
+	}
+	p, ok := g.imageManager.GetPath(g.idx)
+	if !ok || p.ArchivePath == "" {
+		return false
+	}
+	key, _ := volumeKey(p)
+	g.volumeEnd = &volumeEndState{
+		page:  p,
+		next:  nextVolumePath(p.ArchivePath),
+		pages: g.volumePageCount(key),
+		since: now,
+	}
+	if stats := g.GetReadingStats(); stats.Volume == key {
+		g.volumeEnd.stats = stats
+	}
+	debugKV("volume_end", "shown", "archive", p.ArchivePath, "next", g.volumeEnd.next)
+	return true
+}
+
+func (g *Game) openNextVolume(next string) {
+	infoKV("volume_end", "open_next", "path", next)
+	if g.openPaths([]string{next}, "next_volume") {
+		g.showOverlayMessage("Opened " + filepath.Base(next))
+	}
+}
+
+// updateVolumeEnd dismisses the interstitial once another page is shown
+// and opens the next volume when volume_end is auto.
+func (g *Game) updateVolumeEnd(now time.Time) bool {
+	if g.volumeEnd == nil {
+		return false
+	}
+	if p, ok := g.imageManager.GetPath(g.idx); !ok || p != g.volumeEnd.page {
+		g.volumeEnd = nil
+		return true
+	}
+	if g.config.VolumeEnd != volumeEndAuto || g.volumeEnd.next == "" || now.Sub(g.volumeEnd.since) < volumeEndAutoDelay {
+		return false
+	}
+	next := g.volumeEnd.next
+	g.volumeEnd = nil
+	g.openNextVolume(next)
+	return true
+}
+
+// volumeEndLines is the text of the interstitial: the finished volume, its
+// stats and what comes next.
+func volumeEndLines(s volumeEndState, mode string) []string {
+	lines := []string{filepath.Base(s.page.ArchivePath)}
+	if s.stats.Volume != "" {
+		lines = append(lines, fmt.Sprintf("%d pages, %s in this volume, %d pages this session",
+			s.pages, formatReadingDuration(s.stats.VolumeTime), s.stats.SessionPages))
+	} else {
+		lines = append(lines, fmt.Sprintf("%d pages", s.pages))
+	}
+	switch {
+	case s.next == "":
+		lines = append(lines, "No next volume in this folder")
+	case mode == volumeEndAuto:
+		lines = append(lines, "Opening next: "+filepath.Base(s.next))
+	default:
+		lines = append(lines, "Next: "+filepath.Base(s.next)+" (next page key to open)")
+	}
+	return lines
+}
+
+// GetVolumeEnd returns the lines of the "Volume finished" interstitial, or
+// nil when it is not shown.
+func (g *Game) GetVolumeEnd() []string {
+	if g.volumeEnd == nil {
+		return nil
+	}
+	return volumeEndLines(*g.volumeEnd, g.config.VolumeEnd)
+}
+
+func (r *Renderer) drawVolumeEnd(screen *ebiten.Image) {
+	lines := r.renderState.GetVolumeEnd()
+	if lines == nil {
+		return
+	}
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	titleFont := r.face(22)
+	itemFont := r.face(16)
+
+	rowH := 28.0
+	panelW := min(700, w*0.9)
+	panelH := min(0.9*h, 60+float64(len(lines))*rowH+20)
+	panelX := (w - panelW) / 2
+	panelY := (h - panelH) / 2
+
+	DrawFilledRect(screen, 0, 0, w, h, bgColorLight)
+	DrawFilledRect(screen, panelX, panelY, panelW, panelH, bgColorDark)
+	DrawText(screen, "Volume finished", titleFont, panelX+16, panelY+20, colorWhite)
+	y := panelY + 60
+	for i, line := range lines {
+		clr := colorWhite
+		if i == len(lines)-1 {
+			clr = colorYellow
+		}
+		DrawText(screen, truncateTextToWidth(line, itemFont, panelW-48), itemFont, panelX+24, y, clr)
+		y += rowH
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// windowMinVisible is how much of a restored window, in device-independent
+// pixels, has to stay on its monitor so it can still be grabbed and moved.
+const windowMinVisible = 64
+
+// clampWindowPosition keeps a w×h window at (x, y) reachable on a monitor of
+// mw×mh, e.g. after the monitor's resolution was lowered. The top edge, with
+// the title bar, always stays on the monitor.
+func clampWindowPosition(x, y, w, h, mw, mh int) (int, int) {
+	x = max(windowMinVisible-w, min(mw-windowMinVisible, x))
+	y = max(0, min(mh-windowMinVisible, y))
+	return x, y
+}
+
+// restoreWindowPosition moves the window to where it was last closed, on the
+// same monitor. Without a saved position, or when that monitor is no longer
+// connected, the system places the window.
+func restoreWindowPosition(c Config) {
+	if c.WindowMonitor == "" {
+		return
+	}
+	for _, m := range ebiten.AppendMonitors(nil) {
+		if m.Name() != c.WindowMonitor {
+			continue
+		}
+		mw, mh := m.Size()
+		if mw <= 0 || mh <= 0 {
+			break
+		}
+		x, y := clampWindowPosition(c.WindowX, c.WindowY, c.WindowWidth, c.WindowHeight, mw, mh)
+		ebiten.SetMonitor(m)
+		ebiten.SetWindowPosition(x, y)
+		debugKV("startup", "window_position_restored",
+			"monitor", c.WindowMonitor,
+			"saved_x", c.WindowX,
+			"saved_y", c.WindowY,
+			"x", x,
+			"y", y,
+		)
+		return
+	}
+	debugKV("startup", "window_position_skipped", "reason", "monitor_unavailable", "monitor", c.WindowMonitor)
+}
+
+// saveWindowPosition records the window position and its monitor for the
+// next start. In fullscreen, the position the window returns to is kept.
+func (g *Game) saveWindowPosition() {
+	m := ebiten.Monitor()
+	if m == nil {
+		return
+	}
+	g.config.WindowX, g.config.WindowY = ebiten.WindowPosition()
+	g.config.WindowMonitor = m.Name()
+}